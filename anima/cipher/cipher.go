@@ -0,0 +1,279 @@
+package cipher
+
+import (
+	"crypto/aes"
+	stdcipher "crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync/atomic"
+)
+
+// Prefix marks encrypted values, followed by the key ID, the wrapped data key and the sealed
+// value. Values without it are plaintext written before encryption was enabled, and are read
+// as they are.
+//
+// Every value is encrypted under a label naming what it holds, usually the table and column
+// storing it. The data key is wrapped with the key ID and the label as additional data, and the
+// value sealed with the label, so that a value copied to another column or wrapped by another key
+// no longer decrypts.
+const Prefix = "enc:"
+
+// Size of the data key each value is encrypted with
+const dataKeySize = 32
+
+// Maximum length of a key ID, which is stored with every value
+const maxKeyIdLength = 16
+
+var (
+	ErrDisabled   = errors.New("encryption is not configured")
+	ErrUnknownKey = errors.New("value is encrypted with an unknown key")
+	ErrMalformed  = errors.New("malformed encrypted value")
+)
+
+// Key is a key encryption key, identified by the ID stored with the values it protects
+type Key struct {
+	Id string
+	// Secret is an AES key of 16, 24 or 32 bytes
+	Secret []byte
+}
+
+type Config struct {
+	// Keys wrap the data keys of the values. Keys that are no longer primary are kept to read the
+	// values they wrapped until these are rewrapped.
+	Keys []Key
+	// Primary is the ID of the key new values are wrapped with
+	Primary string
+	// IndexKey is the HMAC key of the blind indexes
+	IndexKey []byte
+}
+
+// keyring holds the keys of the configuration
+type keyring struct {
+	primary string
+	keys    map[string]stdcipher.AEAD
+	index   []byte
+}
+
+var current atomic.Pointer[keyring]
+
+// Init loads the keys of the configuration, disabling encryption when it is nil
+func Init(config *Config) error {
+	if config == nil {
+		current.Store(nil)
+		return nil
+	}
+
+	if len(config.IndexKey) == 0 {
+		return errors.New("the blind index key is empty")
+	}
+
+	ring := &keyring{primary: config.Primary, keys: make(map[string]stdcipher.AEAD, len(config.Keys)), index: config.IndexKey}
+	for _, key := range config.Keys {
+		if key.Id == "" || len(key.Id) > maxKeyIdLength || strings.Contains(key.Id, ":") {
+			return errors.New("key IDs must have 1 to 16 characters and no colon")
+		}
+		if _, ok := ring.keys[key.Id]; ok {
+			return errors.New("duplicate key ID " + key.Id)
+		}
+		aead, err := newAead(key.Secret)
+		if err != nil {
+			return errors.New("invalid key " + key.Id + ": " + err.Error())
+		}
+		ring.keys[key.Id] = aead
+	}
+	if _, ok := ring.keys[config.Primary]; !ok {
+		return errors.New("the primary key " + config.Primary + " is not configured")
+	}
+
+	current.Store(ring)
+	return nil
+}
+
+// Enabled reports whether values are encrypted
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Encrypt encrypts a value under a label with a new data key wrapped by the primary key. Empty
+// values, and every value while encryption is disabled, are returned as they are.
+func Encrypt(label, plaintext string) (string, error) {
+	ring := current.Load()
+	if ring == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	aead, err := newAead(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := seal(ring.keys[ring.primary], dataKey, wrapData(ring.primary, label))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(aead, []byte(plaintext), []byte(label))
+	if err != nil {
+		return "", err
+	}
+
+	return encode(ring.primary, wrapped, sealed), nil
+}
+
+// Decrypt decrypts a value encrypted under a label, returning values without the prefix as
+// they are
+func Decrypt(label, value string) (string, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return value, nil
+	}
+
+	ring := current.Load()
+	if ring == nil {
+		return "", ErrDisabled
+	}
+
+	keyId, wrapped, sealed, err := decode(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := ring.unwrap(keyId, label, wrapped)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAead(dataKey)
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	plaintext, err := open(aead, sealed, []byte(label))
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap brings a value encrypted under a label under the primary key, reporting whether it
+// changed. Plaintext values are encrypted, and the data keys of values wrapped by another key are
+// rewrapped by the primary one, leaving the sealed data as it is.
+func Rewrap(label, value string) (string, bool, error) {
+	ring := current.Load()
+	if ring == nil {
+		return value, false, ErrDisabled
+	}
+	if value == "" {
+		return value, false, nil
+	}
+	if !strings.HasPrefix(value, Prefix) {
+		encrypted, err := Encrypt(label, value)
+		return encrypted, err == nil, err
+	}
+
+	keyId, wrapped, sealed, err := decode(value)
+	if err != nil {
+		return value, false, err
+	}
+	if keyId == ring.primary {
+		return value, false, nil
+	}
+
+	dataKey, err := ring.unwrap(keyId, label, wrapped)
+	if err != nil {
+		return value, false, err
+	}
+	rewrapped, err := seal(ring.keys[ring.primary], dataKey, wrapData(ring.primary, label))
+	if err != nil {
+		return value, false, err
+	}
+	return encode(ring.primary, rewrapped, sealed), true, nil
+}
+
+// BlindIndex returns the keyed hash of a value, which finds the rows holding it without
+// decrypting them. Values are compared ignoring case and surrounding spaces, as the database
+// compares the plaintext. Empty values, and every value while encryption is disabled, have
+// an empty index.
+func BlindIndex(value string) string {
+	ring := current.Load()
+	value = strings.ToLower(strings.TrimSpace(value))
+	if ring == nil || value == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, ring.index)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// unwrap decrypts the data key of a value encrypted under a label with the key of its ID
+func (r *keyring) unwrap(keyId, label string, wrapped []byte) ([]byte, error) {
+	key, ok := r.keys[keyId]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	dataKey, err := open(key, wrapped, wrapData(keyId, label))
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return dataKey, nil
+}
+
+// wrapData is the additional data a data key is wrapped with: the ID of the key wrapping it and
+// the label of the value. Key IDs have no colon, so the two cannot be confused.
+func wrapData(keyId, label string) []byte {
+	return []byte(keyId + ":" + label)
+}
+
+func newAead(secret []byte) (stdcipher.AEAD, error) {
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return stdcipher.NewGCM(block)
+}
+
+// seal encrypts data behind a random nonce, authenticating the additional data with it
+func seal(aead stdcipher.AEAD, data, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, additional), nil
+}
+
+// open decrypts data sealed by seal
+func open(aead stdcipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additional)
+}
+
+// encode formats an encrypted value as enc:<key ID>:<wrapped data key>:<sealed value>
+func encode(keyId string, wrapped, sealed []byte) string {
+	return Prefix + keyId + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+// decode splits an encrypted value into its key ID, wrapped data key and sealed value
+func decode(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	return parts[0], wrapped, sealed, nil
+}
@@ -0,0 +1,228 @@
+package cipher
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// label is the column the values of the tests are bound to
+const label = "sys_user.email"
+
+var (
+	oldKey = Key{Id: "k1", Secret: bytes.Repeat([]byte{1}, 32)}
+	newKey = Key{Id: "k2", Secret: bytes.Repeat([]byte{2}, 16)}
+)
+
+// initKeys loads the keys with the primary one, disabling encryption again when the test ends
+func initKeys(t *testing.T, primary string, keys ...Key) {
+	t.Helper()
+	if err := Init(&Config{Keys: keys, Primary: primary, IndexKey: []byte("index")}); err != nil {
+		t.Fatalf("failed to load the keys: %v", err)
+	}
+	t.Cleanup(func() { Init(nil) })
+}
+
+// mustEncrypt encrypts a value bound to a label, failing the test on error
+func mustEncrypt(t *testing.T, label, plaintext string) string {
+	t.Helper()
+	encrypted, err := Encrypt(label, plaintext)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	return encrypted
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	initKeys(t, "k1", oldKey)
+
+	tests := []struct {
+		name      string
+		plaintext string
+	}{
+		{"should round-trip an email", "manager@example.com"},
+		{"should round-trip non-ASCII text", "研发部 张三"},
+		{"should round-trip text with colons", "fe80::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := Encrypt(label, tt.plaintext)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(encrypted, Prefix+"k1:"))
+			assert.NotContains(t, encrypted, tt.plaintext)
+
+			decrypted, err := Decrypt(label, encrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.plaintext, decrypted)
+		})
+	}
+
+	t.Run("should encrypt the same value differently every time", func(t *testing.T) {
+		first, _ := Encrypt(label, "manager@example.com")
+		second, _ := Encrypt(label, "manager@example.com")
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("should leave empty values as they are", func(t *testing.T) {
+		encrypted, err := Encrypt(label, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "", encrypted)
+	})
+
+	t.Run("should read plaintext values as they are", func(t *testing.T) {
+		decrypted, err := Decrypt(label, "manager@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "manager@example.com", decrypted)
+	})
+}
+
+func TestDecryptTampered(t *testing.T) {
+	initKeys(t, "k1", oldKey)
+
+	encrypted, err := Encrypt(label, "manager@example.com")
+	assert.NoError(t, err)
+	parts := strings.Split(strings.TrimPrefix(encrypted, Prefix), ":")
+
+	// flip changes the last byte of an encoded part
+	flip := func(part string) string {
+		data, _ := base64.RawStdEncoding.DecodeString(part)
+		data[len(data)-1] ^= 1
+		return base64.RawStdEncoding.EncodeToString(data)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		err   error
+	}{
+		{"should detect a changed value", Prefix + parts[0] + ":" + parts[1] + ":" + flip(parts[2]), ErrMalformed},
+		{"should detect a changed data key", Prefix + parts[0] + ":" + flip(parts[1]) + ":" + parts[2], ErrMalformed},
+		{"should detect a data key moved to another key ID", Prefix + "k2:" + parts[1] + ":" + parts[2], ErrUnknownKey},
+		{"should detect a value copied from another column", mustEncrypt(t, "sys_user.phonenumber", "manager@example.com"), ErrMalformed},
+		{"should detect a truncated value", Prefix + parts[0] + ":" + parts[1], ErrMalformed},
+		{"should detect invalid encoding", Prefix + parts[0] + ":" + parts[1] + ":!!", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(label, tt.value)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	t.Run("should refuse encrypted values while encryption is disabled", func(t *testing.T) {
+		Init(nil)
+		defer initKeys(t, "k1", oldKey)
+
+		_, err := Decrypt(label, encrypted)
+		assert.ErrorIs(t, err, ErrDisabled)
+	})
+}
+
+func TestRewrap(t *testing.T) {
+	initKeys(t, "k1", oldKey)
+	wrappedByOld, err := Encrypt(label, "manager@example.com")
+	assert.NoError(t, err)
+
+	initKeys(t, "k2", oldKey, newKey)
+
+	tests := []struct {
+		name    string
+		value   string
+		changed bool
+	}{
+		{"should rewrap values of a former primary key", wrappedByOld, true},
+		{"should encrypt plaintext values", "manager@example.com", true},
+		{"should leave empty values as they are", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewrapped, changed, err := Rewrap(label, tt.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.changed, changed)
+			if !changed {
+				assert.Equal(t, tt.value, rewrapped)
+				return
+			}
+			assert.True(t, strings.HasPrefix(rewrapped, Prefix+"k2:"))
+
+			decrypted, err := Decrypt(label, rewrapped)
+			assert.NoError(t, err)
+			assert.Equal(t, "manager@example.com", decrypted)
+		})
+	}
+
+	t.Run("should keep the sealed value when rewrapping", func(t *testing.T) {
+		rewrapped, _, _ := Rewrap(label, wrappedByOld)
+		assert.Equal(t, wrappedByOld[strings.LastIndex(wrappedByOld, ":"):], rewrapped[strings.LastIndex(rewrapped, ":"):])
+	})
+
+	t.Run("should keep the value bound to its column when rewrapping", func(t *testing.T) {
+		rewrapped, _, _ := Rewrap(label, wrappedByOld)
+		_, err := Decrypt("sys_user.phonenumber", rewrapped)
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+
+	t.Run("should leave values of the primary key as they are", func(t *testing.T) {
+		rewrapped, _, _ := Rewrap(label, wrappedByOld)
+		again, changed, err := Rewrap(label, rewrapped)
+		assert.NoError(t, err)
+		assert.False(t, changed)
+		assert.Equal(t, rewrapped, again)
+	})
+
+	t.Run("should read rewrapped values once the former key is removed", func(t *testing.T) {
+		rewrapped, _, _ := Rewrap(label, wrappedByOld)
+		initKeys(t, "k2", newKey)
+
+		decrypted, err := Decrypt(label, rewrapped)
+		assert.NoError(t, err)
+		assert.Equal(t, "manager@example.com", decrypted)
+
+		_, err = Decrypt(label, wrappedByOld)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+}
+
+func TestBlindIndex(t *testing.T) {
+	initKeys(t, "k1", oldKey)
+	index := BlindIndex("manager@example.com")
+
+	tests := []struct {
+		name  string
+		value string
+		same  bool
+	}{
+		{"should match the same value", "manager@example.com", true},
+		{"should ignore case", "Manager@Example.COM", true},
+		{"should ignore surrounding spaces", "  manager@example.com\t", true},
+		{"should differ for other values", "developer@example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.same, BlindIndex(tt.value) == index)
+		})
+	}
+
+	t.Run("should not depend on the encryption keys", func(t *testing.T) {
+		initKeys(t, "k2", newKey)
+		assert.Equal(t, index, BlindIndex("manager@example.com"))
+	})
+
+	t.Run("should depend on the index key", func(t *testing.T) {
+		assert.NoError(t, Init(&Config{Keys: []Key{oldKey}, Primary: "k1", IndexKey: []byte("other")}))
+		assert.NotEqual(t, index, BlindIndex("manager@example.com"))
+	})
+
+	t.Run("should be empty for empty values or while encryption is disabled", func(t *testing.T) {
+		assert.Empty(t, BlindIndex("  "))
+		Init(nil)
+		assert.Empty(t, BlindIndex("manager@example.com"))
+	})
+}
@@ -0,0 +1,147 @@
+package cipher
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// TagBlindIndex tags the blind index of a field with the name of the field, for instance
+//
+//	EmailHash string `blindIndex:"Email"`
+const TagBlindIndex = "blindIndex"
+
+// Plugin keeps the blind indexes of the models in step with the fields they index, on every
+// create and update that writes these fields, whether from a struct or a map.
+type Plugin struct{}
+
+// Name returns the name of the plugin
+func (Plugin) Name() string {
+	return "cipher:blind_index"
+}
+
+// Initialize registers the callbacks of the plugin
+func (Plugin) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("cipher:blind_index", func(db *gorm.DB) {
+		setBlindIndexes(db, true)
+	}); err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("cipher:blind_index", func(db *gorm.DB) {
+		setBlindIndexes(db, false)
+	})
+}
+
+// setBlindIndexes sets the blind indexes of the fields the statement writes
+func setBlindIndexes(db *gorm.DB, create bool) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+
+	for _, index := range stmt.Schema.Fields {
+		name, ok := index.Tag.Lookup(TagBlindIndex)
+		if !ok {
+			continue
+		}
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			db.AddError(fmt.Errorf("blind index %s of unknown field %s", index.Name, name))
+			return
+		}
+		setBlindIndex(db, field, index, create)
+	}
+}
+
+// setBlindIndex sets the blind index of a field the statement writes
+func setBlindIndex(db *gorm.DB, field, index *schema.Field, create bool) {
+	stmt := db.Statement
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		setMapBlindIndex(dest, field, index)
+		return
+	case []map[string]interface{}:
+		for _, row := range dest {
+			setMapBlindIndex(row, field, index)
+		}
+		return
+	}
+
+	selected, restricted := stmt.SelectAndOmitColumns(create, !create)
+	if write, ok := selected[field.DBName]; (ok && !write) || (!ok && restricted) {
+		return
+	}
+	if restricted && !selected[index.DBName] {
+		stmt.Selects = append(stmt.Selects, index.DBName)
+	}
+
+	destValue := reflect.ValueOf(stmt.Dest)
+	for destValue.Kind() == reflect.Ptr {
+		destValue = destValue.Elem()
+	}
+
+	switch destValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < destValue.Len(); i++ {
+			db.AddError(setStructBlindIndex(db, destValue.Index(i), field, index, true))
+		}
+	case reflect.Struct:
+		if !destValue.CanAddr() {
+			addressable := reflect.New(destValue.Type())
+			addressable.Elem().Set(destValue)
+			stmt.Dest = addressable.Interface()
+			destValue = addressable.Elem()
+		}
+		// Updates from a struct skip its zero fields unless they are selected
+		db.AddError(setStructBlindIndex(db, destValue, field, index, create || restricted))
+	}
+}
+
+// setMapBlindIndex sets the blind index of a field written from a map
+func setMapBlindIndex(dest map[string]interface{}, field, index *schema.Field) {
+	value, ok := dest[field.DBName]
+	if !ok {
+		value, ok = dest[field.Name]
+	}
+	if !ok {
+		return
+	}
+	if text, ok := blindIndexText(value); ok {
+		dest[index.DBName] = BlindIndex(text)
+	}
+}
+
+// setStructBlindIndex sets the blind index of a field written from a struct
+func setStructBlindIndex(db *gorm.DB, value reflect.Value, field, index *schema.Field, writesZero bool) error {
+	fieldValue, zero := field.ValueOf(db.Statement.Context, value)
+	if zero && !writesZero {
+		return nil
+	}
+	text, ok := blindIndexText(fieldValue)
+	if !ok {
+		return nil
+	}
+	return index.Set(db.Statement.Context, value, BlindIndex(text))
+}
+
+// blindIndexText returns the text of a value to index, if it is a string
+func blindIndexText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, true
+	case Text:
+		return v.Plaintext(), true
+	case *string:
+		if v == nil {
+			return "", true
+		}
+		return *v, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,85 @@
+package cipher
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// Label names what the values of a String hold, usually the table and column storing them
+type Label interface {
+	Label() string
+}
+
+// Text is implemented by the strings stored encrypted, whatever their label
+type Text interface {
+	Label
+	Plaintext() string
+}
+
+// String is a string stored encrypted under the label of L. It is written encrypted with the
+// primary key and read decrypted, and is a plain string everywhere else, JSON included.
+type String[L Label] string
+
+// Label returns the label the string is encrypted under.
+func (s String[L]) Label() string {
+	var label L
+	return label.Label()
+}
+
+// Plaintext returns the string as it is before encryption.
+func (s String[L]) Plaintext() string {
+	return string(s)
+}
+
+// Value converts to a database value.
+func (s String[L]) Value() (driver.Value, error) {
+	return Encrypt(s.Label(), string(s))
+}
+
+// Scan converts a database value to String.
+func (s *String[L]) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return errors.New("cannot convert value to string")
+	}
+
+	plaintext, err := Decrypt(s.Label(), text)
+	if err != nil {
+		return err
+	}
+	*s = String[L](plaintext)
+	return nil
+}
+
+// Labelled is a string stored encrypted under a label given at run time, for writes to columns
+// known by name only
+type Labelled struct {
+	label string
+	text  string
+}
+
+// NewLabelled returns a string to store encrypted under a label
+func NewLabelled(label, text string) Labelled {
+	return Labelled{label: label, text: text}
+}
+
+// Label returns the label the string is encrypted under.
+func (l Labelled) Label() string {
+	return l.label
+}
+
+// Plaintext returns the string as it is before encryption.
+func (l Labelled) Plaintext() string {
+	return l.text
+}
+
+// Value converts to a database value.
+func (l Labelled) Value() (driver.Value, error) {
+	return Encrypt(l.label, l.text)
+}
@@ -22,9 +22,12 @@ type RedisConfig struct {
 	PoolTimeout        time.Duration
 	IdleTimeout        time.Duration
 	IdleCheckFrequency time.Duration
+	// Cluster node addresses, connecting to a Redis Cluster instead of Host and Port when given
+	Cluster []string
 }
 
-var Redis *redis.Client
+// Redis is a single node client, or a cluster client when cluster nodes are configured
+var Redis redis.UniversalClient
 
 func initRedis(config *RedisConfig) {
 	// Set default values for performance
@@ -56,22 +59,39 @@ func initRedis(config *RedisConfig) {
 		config.IdleCheckFrequency = time.Minute
 	}
 
-	Redis = redis.NewClient(&redis.Options{
-		Addr:               config.Host + ":" + strconv.Itoa(config.Port),
-		Password:           config.Password,
-		DB:                 config.Database,
-		PoolSize:           config.PoolSize,
-		MinIdleConns:       config.MinIdleConns,
-		MaxRetries:         config.MaxRetries,
-		DialTimeout:        config.DialTimeout,
-		ReadTimeout:        config.ReadTimeout,
-		WriteTimeout:       config.WriteTimeout,
-		PoolTimeout:        config.PoolTimeout,
-		IdleTimeout:        config.IdleTimeout,
-		IdleCheckFrequency: config.IdleCheckFrequency,
-		// Enable connection pooling
-		MaxConnAge:         30 * time.Minute,
-	})
+	if len(config.Cluster) > 0 {
+		Redis = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:              config.Cluster,
+			Password:           config.Password,
+			PoolSize:           config.PoolSize,
+			MinIdleConns:       config.MinIdleConns,
+			MaxRetries:         config.MaxRetries,
+			DialTimeout:        config.DialTimeout,
+			ReadTimeout:        config.ReadTimeout,
+			WriteTimeout:       config.WriteTimeout,
+			PoolTimeout:        config.PoolTimeout,
+			IdleTimeout:        config.IdleTimeout,
+			IdleCheckFrequency: config.IdleCheckFrequency,
+			MaxConnAge:         30 * time.Minute,
+		})
+	} else {
+		Redis = redis.NewClient(&redis.Options{
+			Addr:               config.Host + ":" + strconv.Itoa(config.Port),
+			Password:           config.Password,
+			DB:                 config.Database,
+			PoolSize:           config.PoolSize,
+			MinIdleConns:       config.MinIdleConns,
+			MaxRetries:         config.MaxRetries,
+			DialTimeout:        config.DialTimeout,
+			ReadTimeout:        config.ReadTimeout,
+			WriteTimeout:       config.WriteTimeout,
+			PoolTimeout:        config.PoolTimeout,
+			IdleTimeout:        config.IdleTimeout,
+			IdleCheckFrequency: config.IdleCheckFrequency,
+			// Enable connection pooling
+			MaxConnAge:         30 * time.Minute,
+		})
+	}
 
 	_, err := Redis.Ping(context.Background()).Result()
 	if err != nil {
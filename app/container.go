@@ -2,6 +2,7 @@ package app
 
 import (
 	monitorcontroller "mira/app/controller/monitor"
+	scimcontroller "mira/app/controller/scim"
 	systemcontroller "mira/app/controller/system"
 	"mira/app/middleware"
 	"mira/app/security"
@@ -13,31 +14,64 @@ import (
 // AppContainer holds all instances of services, controllers, and middlewares.
 type AppContainer struct {
 	// Services
-	LogininforService *service.LogininforService
-	OperLogService    *service.OperLogService
-	UserService       *service.UserService
-	DeptService       *service.DeptService
-	RoleService       *service.RoleService
-	PostService       *service.PostService
-	MenuService       *service.MenuService
-	ConfigService     *service.ConfigService
-	DictTypeService   *service.DictTypeService
-	DictDataService   *service.DictDataService
+	LogininforService      *service.LogininforService
+	OperLogService         *service.OperLogService
+	UserService            *service.UserService
+	DeptService            *service.DeptService
+	RoleService            *service.RoleService
+	PostService            *service.PostService
+	MenuService            *service.MenuService
+	ConfigService          *service.ConfigService
+	ConfigHistoryService   *service.ConfigHistoryService
+	DictTypeService        *service.DictTypeService
+	DictDataService        *service.DictDataService
+	PolicyService          *service.PolicyService
+	AccessReportService    *service.AccessReportService
+	CacheService           *service.CacheService
+	ApprovalService        *service.ApprovalService
+	DelegationService      *service.DelegationService
+	RecycleService         *service.RecycleService
+	BackgroundTaskService  *service.BackgroundTaskService
+	ImportJobService       *service.ImportJobService
+	ExportService          *service.ExportService
+	ScimService            *service.ScimService
+	UserGroupService       *service.UserGroupService
+	UserAttrService        *service.UserAttrService
+	UserLifecycleService   *service.UserLifecycleService
+	OnboardTemplateService *service.OnboardTemplateService
+	OrgChartService        *service.OrgChartService
+	SearchService          *service.SearchService
+	PrivacyService         *service.PrivacyService
 
 	// Security
 	Security *security.Security
 
 	// Controllers
-	LogininforController *monitorcontroller.LogininforController
-	OperlogController    *monitorcontroller.OperlogController
-	UserController       *systemcontroller.UserController
-	RoleController       *systemcontroller.RoleController
-	MenuController       *systemcontroller.MenuController
-	DeptController       *systemcontroller.DeptController
-	PostController       *systemcontroller.PostController
-	DictTypeController   *systemcontroller.DictTypeController
-	DictDataController   *systemcontroller.DictDataController
-	ConfigController     *systemcontroller.ConfigController
+	LogininforController      *monitorcontroller.LogininforController
+	OperlogController         *monitorcontroller.OperlogController
+	UserController            *systemcontroller.UserController
+	RoleController            *systemcontroller.RoleController
+	MenuController            *systemcontroller.MenuController
+	DeptController            *systemcontroller.DeptController
+	PostController            *systemcontroller.PostController
+	DictTypeController        *systemcontroller.DictTypeController
+	DictDataController        *systemcontroller.DictDataController
+	ConfigController          *systemcontroller.ConfigController
+	PolicyController          *systemcontroller.PolicyController
+	AccessReportController    *monitorcontroller.AccessReportController
+	CacheController           *monitorcontroller.CacheController
+	ApprovalController        *systemcontroller.ApprovalController
+	DelegationController      *systemcontroller.DelegationController
+	RecycleController         *systemcontroller.RecycleController
+	ExportController          *systemcontroller.ExportController
+	ScimController            *scimcontroller.ScimController
+	UserGroupController       *systemcontroller.UserGroupController
+	UserAttrController        *systemcontroller.UserAttrController
+	UserLifecycleController   *systemcontroller.UserLifecycleController
+	OnboardTemplateController *systemcontroller.OnboardTemplateController
+	OrgChartController        *systemcontroller.OrgChartController
+	SearchController          *systemcontroller.SearchController
+	PrivacyController         *systemcontroller.PrivacyController
 }
 
 // NewAppContainer creates and initializes a new AppContainer.
@@ -53,44 +87,115 @@ func NewAppContainer() *AppContainer {
 	configService := &service.ConfigService{}
 	dictTypeService := &service.DictTypeService{}
 	dictDataService := &service.DictDataService{}
+	configHistoryService := service.NewConfigHistoryService(configService, dictTypeService)
+	policyService := service.NewPolicyService()
+	accessReportService := service.NewAccessReportService()
+	cacheService := service.NewCacheService()
+	approvalService := service.NewApprovalService()
+	delegationService := service.NewDelegationService()
+	recycleService := service.NewRecycleService()
+	userGroupService := service.NewUserGroupService()
+	userAttrService := service.NewUserAttrService()
+	userLifecycleService := service.NewUserLifecycleService()
+	onboardTemplateService := service.NewOnboardTemplateService()
+	orgChartService := service.NewOrgChartService()
+	searchService := service.NewSearchService(userService)
+	privacyService := service.NewPrivacyService()
+
+	// Background jobs such as imports and large exports run on the worker pool instead of the request
+	backgroundTaskService := service.NewBackgroundTaskService(4)
+	backgroundTaskService.Start()
+	importJobService := service.NewImportJobService(backgroundTaskService)
+	exportService := service.NewExportService(backgroundTaskService)
+
+	// Identity providers provision users and roles through the same services as the admin pages
+	scimService := service.NewScimService(userService, roleService)
 
 	// Instantiate security
 	sec := security.NewSecurity(userService)
 
 	// Instantiate controllers with dependencies
-	logininforController := monitorcontroller.NewLogininforController(logininforService)
-	operlogController := monitorcontroller.NewOperlogController(operLogService)
-	userController := systemcontroller.NewUserController(userService, deptService, roleService, postService, configService)
-	roleController := systemcontroller.NewRoleController(roleService, deptService, userService)
+	logininforController := monitorcontroller.NewLogininforController(logininforService, exportService)
+	operlogController := monitorcontroller.NewOperlogController(operLogService, exportService)
+	userController := systemcontroller.NewUserController(userService, deptService, roleService, postService, configService, importJobService, exportService, userAttrService)
+	roleController := systemcontroller.NewRoleController(roleService, deptService, userService, importJobService, exportService, userGroupService)
 	menuController := systemcontroller.NewMenuController(menuService)
-	deptController := systemcontroller.NewDeptController(deptService, userService)
-	postController := systemcontroller.NewPostController(postService)
-	dictTypeController := systemcontroller.NewDictTypeController(dictTypeService)
-	dictDataController := systemcontroller.NewDictDataController(dictDataService)
-	configController := systemcontroller.NewConfigController(configService)
+	deptController := systemcontroller.NewDeptController(deptService, userService, importJobService)
+	postController := systemcontroller.NewPostController(postService, roleService, importJobService, exportService)
+	dictTypeController := systemcontroller.NewDictTypeController(dictTypeService, importJobService, exportService)
+	dictDataController := systemcontroller.NewDictDataController(dictDataService, configHistoryService, importJobService, exportService)
+	configController := systemcontroller.NewConfigController(configService, configHistoryService, exportService)
+	policyController := systemcontroller.NewPolicyController(policyService)
+	accessReportController := monitorcontroller.NewAccessReportController(accessReportService)
+	cacheController := monitorcontroller.NewCacheController(cacheService)
+	approvalController := systemcontroller.NewApprovalController(approvalService, userService)
+	delegationController := systemcontroller.NewDelegationController(delegationService, roleService)
+	recycleController := systemcontroller.NewRecycleController(recycleService)
+	exportController := systemcontroller.NewExportController(exportService)
+	scimController := scimcontroller.NewScimController(scimService)
+	userGroupController := systemcontroller.NewUserGroupController(userGroupService, roleService)
+	userAttrController := systemcontroller.NewUserAttrController(userAttrService)
+	userLifecycleController := systemcontroller.NewUserLifecycleController(userLifecycleService, userService)
+	onboardTemplateController := systemcontroller.NewOnboardTemplateController(onboardTemplateService, roleService)
+	orgChartController := systemcontroller.NewOrgChartController(orgChartService)
+	searchController := systemcontroller.NewSearchController(searchService)
+	privacyController := systemcontroller.NewPrivacyController(privacyService)
 
 	return &AppContainer{
-		LogininforService:    logininforService,
-		OperLogService:       operLogService,
-		UserService:          userService,
-		DeptService:          deptService,
-		RoleService:          roleService,
-		PostService:          postService,
-		MenuService:          menuService,
-		ConfigService:        configService,
-		DictTypeService:      dictTypeService,
-		DictDataService:      dictDataService,
-		Security:             sec,
-		LogininforController: logininforController,
-		OperlogController:    operlogController,
-		UserController:       userController,
-		RoleController:       roleController,
-		MenuController:       menuController,
-		DeptController:       deptController,
-		PostController:       postController,
-		DictTypeController:   dictTypeController,
-		DictDataController:   dictDataController,
-		ConfigController:     configController,
+		LogininforService:         logininforService,
+		OperLogService:            operLogService,
+		UserService:               userService,
+		DeptService:               deptService,
+		RoleService:               roleService,
+		PostService:               postService,
+		MenuService:               menuService,
+		ConfigService:             configService,
+		ConfigHistoryService:      configHistoryService,
+		DictTypeService:           dictTypeService,
+		DictDataService:           dictDataService,
+		PolicyService:             policyService,
+		AccessReportService:       accessReportService,
+		CacheService:              cacheService,
+		ApprovalService:           approvalService,
+		DelegationService:         delegationService,
+		RecycleService:            recycleService,
+		BackgroundTaskService:     backgroundTaskService,
+		ImportJobService:          importJobService,
+		ExportService:             exportService,
+		ScimService:               scimService,
+		UserGroupService:          userGroupService,
+		UserAttrService:           userAttrService,
+		UserLifecycleService:      userLifecycleService,
+		OnboardTemplateService:    onboardTemplateService,
+		OrgChartService:           orgChartService,
+		SearchService:             searchService,
+		PrivacyService:            privacyService,
+		Security:                  sec,
+		LogininforController:      logininforController,
+		OperlogController:         operlogController,
+		UserController:            userController,
+		RoleController:            roleController,
+		MenuController:            menuController,
+		DeptController:            deptController,
+		PostController:            postController,
+		DictTypeController:        dictTypeController,
+		DictDataController:        dictDataController,
+		ConfigController:          configController,
+		PolicyController:          policyController,
+		AccessReportController:    accessReportController,
+		CacheController:           cacheController,
+		ApprovalController:        approvalController,
+		DelegationController:      delegationController,
+		RecycleController:         recycleController,
+		ExportController:          exportController,
+		ScimController:            scimController,
+		UserGroupController:       userGroupController,
+		UserAttrController:        userAttrController,
+		UserLifecycleController:   userLifecycleController,
+		OnboardTemplateController: onboardTemplateController,
+		OrgChartController:        orgChartController,
+		SearchController:          searchController,
+		PrivacyController:         privacyController,
 	}
 }
 
@@ -108,3 +213,19 @@ func (ac *AppContainer) OperLogMiddleware(title string, businessType int) gin.Ha
 func (ac *AppContainer) HasPerm(perm string) gin.HandlerFunc {
 	return middleware.HasPerm(ac.Security, perm)
 }
+
+// HasPolicy returns the attribute-based policy check middleware.
+func (ac *AppContainer) HasPolicy(action string) gin.HandlerFunc {
+	return middleware.HasPolicy(ac.PolicyService, action)
+}
+
+// HasPermOrDelegation returns the permission check middleware that also admits delegated department administrators.
+func (ac *AppContainer) HasPermOrDelegation(perm string) gin.HandlerFunc {
+	return middleware.HasPermOrDelegation(ac.Security, ac.DelegationService, perm)
+}
+
+// RequireApproval returns the four-eyes approval middleware for an action,
+// holding only the requests changing the given assignments when any is given.
+func (ac *AppContainer) RequireApproval(action, title string, changes ...service.ApprovalChange) gin.HandlerFunc {
+	return middleware.RequireApproval(ac.ApprovalService, action, title, changes...)
+}
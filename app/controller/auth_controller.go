@@ -36,14 +36,14 @@ func (*AuthController) CaptchaImage(ctx *gin.Context) {
 
 	b64s = strings.Replace(b64s, "data:image/png;base64,", "", 1)
 
-	config := (&service.ConfigService{}).GetConfigCacheByConfigKey("sys.account.captchaEnabled")
+	captchaEnabled := (&service.ConfigService{}).GetBool(service.ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY)
 
-	response.NewSuccess().SetData("uuid", id).SetData("img", b64s).SetData("captchaEnabled", config.ConfigValue == "true").Json(ctx)
+	response.NewSuccess().SetData("uuid", id).SetData("img", b64s).SetData("captchaEnabled", captchaEnabled).Json(ctx)
 }
 
 // Register
 func (*AuthController) Register(ctx *gin.Context) {
-	if config := (&service.ConfigService{}).GetConfigCacheByConfigKey("sys.account.registerUser"); config.ConfigValue != "true" {
+	if !(&service.ConfigService{}).GetBool(service.ACCOUNT_REGISTER_USER_CONFIG_KEY) {
 		response.NewError().SetMsg("The current system does not have the registration function enabled").Json(ctx)
 		return
 	}
@@ -60,7 +60,7 @@ func (*AuthController) Register(ctx *gin.Context) {
 		return
 	}
 
-	if config := (&service.ConfigService{}).GetConfigCacheByConfigKey("sys.account.captchaEnabled"); config.ConfigValue == "true" {
+	if (&service.ConfigService{}).GetBool(service.ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY) {
 		if err := captcha.NewCaptcha().Verify(param.Uuid, param.Code); err != nil {
 			response.NewError().SetMsg(err.Error()).Json(ctx)
 			return
@@ -106,7 +106,7 @@ func (*AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	if config := (&service.ConfigService{}).GetConfigCacheByConfigKey("sys.account.captchaEnabled"); config.ConfigValue == "true" {
+	if (&service.ConfigService{}).GetBool(service.ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY) {
 		if err := captcha.NewCaptcha().Verify(param.Uuid, param.Code); err != nil {
 			response.NewError().SetMsg(err.Error()).Json(ctx)
 			return
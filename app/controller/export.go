@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"time"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Export writes the dataset in the format, columns and header language requested by the
+// export options of the request, streaming it as the download. Exports of more than
+// service.EXPORT_SYNC_MAX_ROWS rows, or with async set, start a background export job
+// instead and respond with its ID.
+//
+// fileName is the download name without the timestamp and extension.
+func Export(ctx *gin.Context, exportService *service.ExportService, dataset service.ExportDataset, fileName string) {
+	var param dto.ExportRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.ExportValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	count, err := exportService.PrepareExport(dataset, param)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	fileName += "_" + time.Now().Format("20060102150405")
+
+	if param.Async || count > service.EXPORT_SYNC_MAX_ROWS {
+		jobId, err := exportService.CreateExportJob(dataset, param, fileName, security.GetAuthUserName(ctx))
+		if err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+
+		response.NewSuccess().SetData("jobId", jobId).Json(ctx)
+		return
+	}
+
+	ctx.Header("Content-Type", service.ExportContentType(param.Format))
+	ctx.Header("Content-Disposition", "attachment; filename="+fileName+service.ExportFileExt(param.Format))
+
+	if _, err = exportService.WriteExport(ctx.Writer, dataset, param); err != nil {
+		// Once the first rows are out, an error can only cut the download short
+		if ctx.Writer.Written() {
+			ctx.Error(err)
+			return
+		}
+
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+	}
+}
@@ -0,0 +1,376 @@
+package monitorcontroller
+
+import (
+	"strings"
+	"time"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/types/constant"
+
+	"gitee.com/hanshuangjianke/go-excel/excel"
+	"github.com/gin-gonic/gin"
+)
+
+// AccessReportController handles "who can do what" permission reports.
+type AccessReportController struct {
+	AccessReportService *service.AccessReportService
+}
+
+// NewAccessReportController creates a new AccessReportController.
+func NewAccessReportController(accessReportService *service.AccessReportService) *AccessReportController {
+	return &AccessReportController{AccessReportService: accessReportService}
+}
+
+// PermUsers lists the users holding a permission and the roles it comes through.
+// @Summary Get users holding a permission
+// @Description Lists every user holding a permission string, with the role and menu granting it.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.PermUsersRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=[]dto.PermUserResponse} "Success"
+// @Router /monitor/access/permUsers [get]
+func (c *AccessReportController) PermUsers(ctx *gin.Context) {
+	var param dto.PermUsersRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.PermUsersValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	users, err := c.AccessReportService.GetPermUsers(param.Perm)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", users).Json(ctx)
+}
+
+// PermUsersExport exports the users holding a permission to an Excel file.
+// @Summary Export users holding a permission
+// @Description Exports every user holding a permission string to an Excel file.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.PermUsersRequest true "Query parameters"
+// @Success 200 {file} file "Excel file"
+// @Router /monitor/access/permUsers/export [post]
+func (c *AccessReportController) PermUsersExport(ctx *gin.Context) {
+	var param dto.PermUsersRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.PermUsersValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	users, err := c.AccessReportService.GetPermUsers(param.Perm)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	list := make([]dto.PermUserExportResponse, 0)
+	for _, user := range users {
+		list = append(list, dto.PermUserExportResponse{
+			UserId:   user.UserId,
+			UserName: user.UserName,
+			NickName: user.NickName,
+			DeptName: user.DeptName,
+			RoleKey:  user.RoleKey,
+			RoleName: user.RoleName,
+			MenuName: user.MenuName,
+		})
+	}
+
+	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	excel.DownLoadExcel("perm_users_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// UserPerms retrieves a user's effective permissions with source roles and data scope.
+// @Summary Get user effective permissions
+// @Description Retrieves the effective permissions of a user, the roles granting each one and the data scope of every role.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.UserEffectivePermsRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=dto.UserEffectivePermsResponse} "Success"
+// @Router /monitor/access/userPerms [get]
+func (c *AccessReportController) UserPerms(ctx *gin.Context) {
+	var param dto.UserEffectivePermsRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UserEffectivePermsValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	result, err := c.AccessReportService.GetUserEffectivePerms(param.UserId)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", result).Json(ctx)
+}
+
+// UserPermsExport exports a user's effective permissions to an Excel file.
+// @Summary Export user effective permissions
+// @Description Exports the effective permissions of a user with source roles and data scope to an Excel file.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.UserEffectivePermsRequest true "Query parameters"
+// @Success 200 {file} file "Excel file"
+// @Router /monitor/access/userPerms/export [post]
+func (c *AccessReportController) UserPermsExport(ctx *gin.Context) {
+	var param dto.UserEffectivePermsRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UserEffectivePermsValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	result, err := c.AccessReportService.GetUserEffectivePerms(param.UserId)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	// Data scope of each source role, e.g. "editor: 2 (Sales, Marketing)"
+	dataScopes := make(map[string]string)
+	for _, dataScope := range result.DataScopes {
+		label := dataScope.RoleKey + ": " + dataScope.DataScope
+		if len(dataScope.DeptNames) > 0 {
+			label += " (" + strings.Join(dataScope.DeptNames, ", ") + ")"
+		}
+		dataScopes[dataScope.RoleKey] = label
+	}
+
+	list := make([]dto.EffectivePermExportResponse, 0)
+	for _, perm := range result.Perms {
+		labels := make([]string, 0)
+		for _, roleKey := range perm.Roles {
+			if label, ok := dataScopes[roleKey]; ok {
+				labels = append(labels, label)
+			}
+		}
+		list = append(list, dto.EffectivePermExportResponse{
+			Perm:       perm.Perm,
+			MenuName:   perm.MenuName,
+			Roles:      strings.Join(perm.Roles, ", "),
+			DataScopes: strings.Join(labels, "; "),
+		})
+	}
+
+	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	excel.DownLoadExcel("user_perms_"+result.UserName+"_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// Compare compares the permissions of two users or two roles.
+// @Summary Compare permissions
+// @Description Compares the effective permissions of two users or the permissions of two roles.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.AccessCompareRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=dto.AccessCompareResponse} "Success"
+// @Router /monitor/access/compare [get]
+func (c *AccessReportController) Compare(ctx *gin.Context) {
+	result, ok := c.compare(ctx)
+	if !ok {
+		return
+	}
+
+	response.NewSuccess().SetData("data", result).Json(ctx)
+}
+
+// CompareExport exports a permission comparison to an Excel file.
+// @Summary Export permission comparison
+// @Description Exports the comparison of two users or two roles to an Excel file.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.AccessCompareRequest true "Query parameters"
+// @Success 200 {file} file "Excel file"
+// @Router /monitor/access/compare/export [post]
+func (c *AccessReportController) CompareExport(ctx *gin.Context) {
+	result, ok := c.compare(ctx)
+	if !ok {
+		return
+	}
+
+	// The Left and Right columns refer to the first and second compared item, named in the title
+	list := make([]dto.AccessCompareExportResponse, 0)
+	for _, perm := range result.Common {
+		list = append(list, dto.AccessCompareExportResponse{Perm: perm, Left: "Y", Right: "Y"})
+	}
+	for _, perm := range result.OnlyLeft {
+		list = append(list, dto.AccessCompareExportResponse{Perm: perm, Left: "Y", Right: "N"})
+	}
+	for _, perm := range result.OnlyRight {
+		list = append(list, dto.AccessCompareExportResponse{Perm: perm, Left: "N", Right: "Y"})
+	}
+
+	title := "Left: " + result.Left + " / Right: " + result.Right
+
+	file, err := excel.NormalDynamicExport("Sheet1", title, "", false, false, list, nil)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	excel.DownLoadExcel("access_compare_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// compare binds, validates and runs a comparison, writing the error response on failure.
+func (c *AccessReportController) compare(ctx *gin.Context) (dto.AccessCompareResponse, bool) {
+	var param dto.AccessCompareRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return dto.AccessCompareResponse{}, false
+	}
+
+	if err := validator.AccessCompareValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return dto.AccessCompareResponse{}, false
+	}
+
+	var result dto.AccessCompareResponse
+	var err error
+	if param.Type == constant.ACCESS_COMPARE_ROLE {
+		result, err = c.AccessReportService.CompareRoles(param.LeftId, param.RightId)
+	} else {
+		result, err = c.AccessReportService.CompareUsers(param.LeftId, param.RightId)
+	}
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return result, false
+	}
+
+	return result, true
+}
+
+// Explain explains why a permission check passes or fails for a user.
+// @Summary Explain permission check
+// @Description Explains why HasPerm allows or denies a permission for a user, listing every role and menu involved.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.AccessExplainRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=dto.AccessExplainResponse} "Success"
+// @Router /monitor/access/explain [get]
+func (c *AccessReportController) Explain(ctx *gin.Context) {
+	var param dto.AccessExplainRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.AccessExplainValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	result, err := c.AccessReportService.ExplainPerm(param.UserId, param.Perm)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", result).Json(ctx)
+}
+
+// ExplainExport exports a permission check explanation to an Excel file.
+// @Summary Export permission check explanation
+// @Description Exports the roles and menus involved in a permission check to an Excel file, titled with the decision.
+// @Tags Monitor
+// @Accept json
+// @Produce json
+// @Param query body dto.AccessExplainRequest true "Query parameters"
+// @Success 200 {file} file "Excel file"
+// @Router /monitor/access/explain/export [post]
+func (c *AccessReportController) ExplainExport(ctx *gin.Context) {
+	var param dto.AccessExplainRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.AccessExplainValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	result, err := c.AccessReportService.ExplainPerm(param.UserId, param.Perm)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	list := make([]dto.AccessGrantExportResponse, 0)
+	for _, grant := range result.Grants {
+		effective := "N"
+		if grant.Effective {
+			effective = "Y"
+		}
+		list = append(list, dto.AccessGrantExportResponse{
+			RoleKey:    grant.RoleKey,
+			RoleName:   grant.RoleName,
+			RoleStatus: grant.RoleStatus,
+			MenuName:   grant.MenuName,
+			MenuStatus: grant.MenuStatus,
+			Effective:  effective,
+			Note:       grant.Note,
+		})
+	}
+
+	decision := "Denied"
+	if result.Allowed {
+		decision = "Allowed"
+	}
+	title := result.UserName + " / " + result.Perm + ": " + decision + " - " + result.Reason
+
+	file, err := excel.NormalDynamicExport("Sheet1", title, "", false, false, list, nil)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	excel.DownLoadExcel("access_explain_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
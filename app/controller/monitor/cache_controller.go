@@ -0,0 +1,29 @@
+package monitorcontroller
+
+import (
+	"mira/anima/response"
+	"mira/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheController handles cache monitoring.
+type CacheController struct {
+	CacheService *service.CacheService
+}
+
+// NewCacheController creates a new CacheController.
+func NewCacheController(cacheService *service.CacheService) *CacheController {
+	return &CacheController{CacheService: cacheService}
+}
+
+// Metrics lists the hit and miss counts of the two-level caches.
+// @Summary Get cache metrics
+// @Description Lists the hit, miss, coalesced load and eviction counts of the two-level caches of the instance serving the request since it started.
+// @Tags Monitor
+// @Produce json
+// @Success 200 {object} response.Response{data=[]dto.CacheMetricsResponse} "Success"
+// @Router /monitor/cache/metrics [get]
+func (c *CacheController) Metrics(ctx *gin.Context) {
+	response.NewSuccess().SetData("data", c.CacheService.GetCacheMetrics()).Json(ctx)
+}
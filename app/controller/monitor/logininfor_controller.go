@@ -1,30 +1,30 @@
 package monitorcontroller
 
 import (
-	"time"
-
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/service"
+	"mira/app/validator"
 	"mira/common/utils"
 
-	"gitee.com/hanshuangjianke/go-excel/excel"
 	"github.com/gin-gonic/gin"
 )
 
 // LogininforController handles login log related operations.
 type LogininforController struct {
 	LogininforService *service.LogininforService
+	ExportService     *service.ExportService
 }
 
 // NewLogininforController creates a new LogininforController.
-func NewLogininforController(logininforService *service.LogininforService) *LogininforController {
-	return &LogininforController{LogininforService: logininforService}
+func NewLogininforController(logininforService *service.LogininforService, exportService *service.ExportService) *LogininforController {
+	return &LogininforController{LogininforService: logininforService, ExportService: exportService}
 }
 
 // List retrieves a paginated list of login logs.
 // @Summary Get login log list
-// @Description Retrieves a paginated list of login logs based on query parameters.
+// @Description Retrieves a paginated list of login logs based on query parameters. Given a cursor or limit, the list is paged by keyset and returns the nextCursor of the following page.
 // @Tags Monitor
 // @Accept json
 // @Produce json
@@ -39,8 +39,24 @@ func (c *LogininforController) List(ctx *gin.Context) {
 		return
 	}
 
+	if err := validator.PageValidator(param.PageRequest); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
 	param.OrderRule, param.OrderByColumn = utils.ParseSort(param.IsAsc, param.OrderByColumn, "loginTime")
 
+	if param.IsKeyset() {
+		logininfors, total, next, err := c.LogininforService.GetLogininforPage(param)
+		if err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+
+		response.NewSuccess().SetPageData(logininfors, total).SetData("nextCursor", next).Json(ctx)
+		return
+	}
+
 	logininfors, total := c.LogininforService.GetLogininforList(param, true)
 
 	response.NewSuccess().SetPageData(logininfors, total).Json(ctx)
@@ -105,14 +121,16 @@ func (c *LogininforController) Unlock(ctx *gin.Context) {
 	response.NewSuccess().Json(ctx)
 }
 
-// Export exports login logs to an Excel file.
+// Export exports login logs as XLSX, CSV or NDJSON.
 // @Summary Export login log
-// @Description Exports login logs to an Excel file based on query parameters.
+// @Description Streams login logs matching the query parameters in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
 // @Tags Monitor
 // @Accept json
 // @Produce json
 // @Param query body dto.LogininforListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /monitor/logininfor/export [post]
 func (c *LogininforController) Export(ctx *gin.Context) {
 	var param dto.LogininforListRequest
@@ -122,30 +140,5 @@ func (c *LogininforController) Export(ctx *gin.Context) {
 		return
 	}
 
-	param.OrderRule, param.OrderByColumn = utils.ParseSort(param.IsAsc, param.OrderByColumn, "loginTime")
-
-	list := make([]dto.LogininforExportResponse, 0)
-
-	logininfors, _ := c.LogininforService.GetLogininforList(param, false)
-	for _, logininfor := range logininfors {
-		list = append(list, dto.LogininforExportResponse{
-			InfoId:        logininfor.InfoId,
-			UserName:      logininfor.UserName,
-			Status:        logininfor.Status,
-			Ipaddr:        logininfor.Ipaddr,
-			LoginLocation: logininfor.LoginLocation,
-			Browser:       logininfor.Browser,
-			Os:            logininfor.Os,
-			Msg:           logininfor.Msg,
-			LoginTime:     logininfor.LoginTime.Format("2006-01-02 15:04:05"),
-		})
-	}
-
-	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
-	if err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
-
-	excel.DownLoadExcel("logininfor_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	controller.Export(ctx, c.ExportService, c.LogininforService.ExportDataset(param), "logininfor")
 }
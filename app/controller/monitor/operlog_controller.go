@@ -1,31 +1,30 @@
 package monitorcontroller
 
 import (
-	"strconv"
-	"time"
-
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/service"
+	"mira/app/validator"
 	"mira/common/utils"
 
-	"gitee.com/hanshuangjianke/go-excel/excel"
 	"github.com/gin-gonic/gin"
 )
 
 // OperlogController handles operation log related operations.
 type OperlogController struct {
 	OperLogService *service.OperLogService
+	ExportService  *service.ExportService
 }
 
 // NewOperlogController creates a new OperlogController.
-func NewOperlogController(operLogService *service.OperLogService) *OperlogController {
-	return &OperlogController{OperLogService: operLogService}
+func NewOperlogController(operLogService *service.OperLogService, exportService *service.ExportService) *OperlogController {
+	return &OperlogController{OperLogService: operLogService, ExportService: exportService}
 }
 
 // List retrieves a paginated list of operation logs.
 // @Summary Get operation log list
-// @Description Retrieves a paginated list of operation logs based on query parameters.
+// @Description Retrieves a paginated list of operation logs based on query parameters. Given a cursor or limit, the list is paged by keyset and returns the nextCursor of the following page.
 // @Tags Monitor
 // @Accept json
 // @Produce json
@@ -40,8 +39,24 @@ func (c *OperlogController) List(ctx *gin.Context) {
 		return
 	}
 
+	if err := validator.PageValidator(param.PageRequest); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
 	param.OrderRule, param.OrderByColumn = utils.ParseSort(param.IsAsc, param.OrderByColumn, "operTime")
 
+	if param.IsKeyset() {
+		operLogs, total, next, err := c.OperLogService.GetOperLogPage(param)
+		if err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+
+		response.NewSuccess().SetPageData(operLogs, total).SetData("nextCursor", next).Json(ctx)
+		return
+	}
+
 	operLogs, total := c.OperLogService.GetOperLogList(param, true)
 
 	response.NewSuccess().SetPageData(operLogs, total).Json(ctx)
@@ -88,14 +103,16 @@ func (c *OperlogController) Clean(ctx *gin.Context) {
 	response.NewSuccess().Json(ctx)
 }
 
-// Export exports operation logs to an Excel file.
+// Export exports operation logs as XLSX, CSV or NDJSON.
 // @Summary Export operation log
-// @Description Exports operation logs to an Excel file based on query parameters.
+// @Description Streams operation logs matching the query parameters in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
 // @Tags Monitor
 // @Accept json
 // @Produce json
 // @Param query body dto.OperLogListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /monitor/operlog/export [post]
 func (c *OperlogController) Export(ctx *gin.Context) {
 	var param dto.OperLogListRequest
@@ -105,37 +122,5 @@ func (c *OperlogController) Export(ctx *gin.Context) {
 		return
 	}
 
-	param.OrderRule, param.OrderByColumn = utils.ParseSort(param.IsAsc, param.OrderByColumn, "operTime")
-
-	list := make([]dto.OperLogExportResponse, 0)
-
-	operLogs, _ := c.OperLogService.GetOperLogList(param, false)
-	for _, operLog := range operLogs {
-		list = append(list, dto.OperLogExportResponse{
-			OperId:        operLog.OperId,
-			Title:         operLog.Title,
-			BusinessType:  operLog.BusinessType,
-			Method:        operLog.Method,
-			RequestMethod: operLog.RequestMethod,
-			OperName:      operLog.OperName,
-			DeptName:      operLog.DeptName,
-			OperUrl:       operLog.OperUrl,
-			OperIp:        operLog.OperIp,
-			OperLocation:  operLog.OperLocation,
-			OperParam:     operLog.OperParam,
-			JsonResult:    operLog.JsonResult,
-			Status:        operLog.Status,
-			ErrorMsg:      operLog.ErrorMsg,
-			OperTime:      operLog.OperTime.Format("2006-01-02 15:04:05"),
-			CostTime:      strconv.Itoa(operLog.CostTime) + "ms",
-		})
-	}
-
-	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
-	if err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
-
-	excel.DownLoadExcel("operlog_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	controller.Export(ctx, c.ExportService, c.OperLogService.ExportDataset(param), "operlog")
 }
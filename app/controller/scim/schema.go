@@ -0,0 +1,123 @@
+package scimcontroller
+
+import (
+	"mira/app/service"
+	"mira/common/types/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serviceProviderConfig describes the SCIM features of the server (RFC 7643 section 5)
+var serviceProviderConfig = gin.H{
+	"schemas":        []string{constant.SCIM_SCHEMA_SERVICE_PROVIDER_CONFIG},
+	"patch":          gin.H{"supported": true},
+	"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+	"filter":         gin.H{"supported": true, "maxResults": service.SCIM_MAX_COUNT},
+	"changePassword": gin.H{"supported": true},
+	"sort":           gin.H{"supported": false},
+	"etag":           gin.H{"supported": false},
+	"authenticationSchemes": []gin.H{{
+		"type":        "oauthbearertoken",
+		"name":        "OAuth Bearer Token",
+		"description": "Authentication with the bearer token of the SCIM configuration",
+		"primary":     true,
+	}},
+	"meta": gin.H{
+		"resourceType": "ServiceProviderConfig",
+		"location":     constant.SCIM_BASE_PATH + "/ServiceProviderConfig",
+	},
+}
+
+// schemas describes the supported attributes of users and groups (RFC 7643 section 7)
+var schemas = []gin.H{
+	{
+		"schemas":     []string{constant.SCIM_SCHEMA_SCHEMA},
+		"id":          constant.SCIM_SCHEMA_USER,
+		"name":        "User",
+		"description": "User account",
+		"attributes": []gin.H{
+			attribute("userName", "string", "immutable", "server", true),
+			attribute("displayName", "string", "readWrite", "none", false),
+			complexAttribute("name", false, "readWrite",
+				attribute("formatted", "string", "readWrite", "none", false),
+				attribute("givenName", "string", "writeOnly", "none", false),
+				attribute("familyName", "string", "writeOnly", "none", false),
+			),
+			complexAttribute("emails", true, "readWrite",
+				attribute("value", "string", "readWrite", "server", false),
+				attribute("type", "string", "readWrite", "none", false),
+				attribute("primary", "boolean", "readWrite", "none", false),
+			),
+			complexAttribute("phoneNumbers", true, "readWrite",
+				attribute("value", "string", "readWrite", "server", false),
+				attribute("type", "string", "readWrite", "none", false),
+				attribute("primary", "boolean", "readWrite", "none", false),
+			),
+			attribute("active", "boolean", "readWrite", "none", false),
+			attribute("password", "string", "writeOnly", "none", false),
+			complexAttribute("groups", true, "readOnly",
+				attribute("value", "string", "readOnly", "none", false),
+				attribute("display", "string", "readOnly", "none", false),
+				attribute("$ref", "reference", "readOnly", "none", false),
+			),
+		},
+		"meta": gin.H{
+			"resourceType": "Schema",
+			"location":     constant.SCIM_BASE_PATH + "/Schemas/" + constant.SCIM_SCHEMA_USER,
+		},
+	},
+	{
+		"schemas":     []string{constant.SCIM_SCHEMA_SCHEMA},
+		"id":          constant.SCIM_SCHEMA_GROUP,
+		"name":        "Group",
+		"description": "Group, provisioned as a role",
+		"attributes": []gin.H{
+			attribute("displayName", "string", "readWrite", "server", true),
+			complexAttribute("members", true, "readWrite",
+				attribute("value", "string", "immutable", "none", false),
+				attribute("display", "string", "readOnly", "none", false),
+				attribute("$ref", "reference", "immutable", "none", false),
+			),
+		},
+		"meta": gin.H{
+			"resourceType": "Schema",
+			"location":     constant.SCIM_BASE_PATH + "/Schemas/" + constant.SCIM_SCHEMA_GROUP,
+		},
+	},
+}
+
+// attribute describes a single-valued simple attribute
+func attribute(name, attributeType, mutability, uniqueness string, required bool) gin.H {
+	return gin.H{
+		"name":        name,
+		"type":        attributeType,
+		"multiValued": false,
+		"required":    required,
+		"caseExact":   false,
+		"mutability":  mutability,
+		"returned":    returned(mutability),
+		"uniqueness":  uniqueness,
+	}
+}
+
+// complexAttribute describes an attribute made of sub-attributes
+func complexAttribute(name string, multiValued bool, mutability string, subAttributes ...gin.H) gin.H {
+	return gin.H{
+		"name":          name,
+		"type":          "complex",
+		"multiValued":   multiValued,
+		"required":      false,
+		"mutability":    mutability,
+		"returned":      returned(mutability),
+		"uniqueness":    "none",
+		"subAttributes": subAttributes,
+	}
+}
+
+// returned tells when an attribute is returned; write-only attributes never are
+func returned(mutability string) string {
+	if mutability == "writeOnly" {
+		return "never"
+	}
+	return "default"
+}
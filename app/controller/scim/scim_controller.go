@@ -0,0 +1,397 @@
+package scimcontroller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mira/app/dto"
+	"mira/app/service"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// ScimController serves the SCIM 2.0 protocol (RFC 7644) for identity providers. Responses
+// use the SCIM message format and HTTP status codes instead of the admin response envelope.
+type ScimController struct {
+	ScimService *service.ScimService
+}
+
+// NewScimController creates a new ScimController.
+func NewScimController(scimService *service.ScimService) *ScimController {
+	return &ScimController{ScimService: scimService}
+}
+
+// ServiceProviderConfig describes the supported SCIM features.
+// @Summary SCIM service provider configuration
+// @Description Describes the SCIM features supported by the server.
+// @Tags SCIM
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Success"
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (c *ScimController) ServiceProviderConfig(ctx *gin.Context) {
+	scimJson(ctx, http.StatusOK, serviceProviderConfig)
+}
+
+// Schemas lists the schemas of the users and groups.
+// @Summary List SCIM schemas
+// @Description Lists the attributes of the user and group schemas.
+// @Tags SCIM
+// @Produce json
+// @Success 200 {object} dto.ScimListResponse "Success"
+// @Router /scim/v2/Schemas [get]
+func (c *ScimController) Schemas(ctx *gin.Context) {
+	scimJson(ctx, http.StatusOK, dto.ScimListResponse{
+		Schemas:      []string{constant.SCIM_MESSAGE_LIST_RESPONSE},
+		TotalResults: len(schemas),
+		StartIndex:   1,
+		ItemsPerPage: len(schemas),
+		Resources:    schemas,
+	})
+}
+
+// Schema retrieves a schema by its URN.
+// @Summary Get SCIM schema
+// @Description Retrieves the attributes of the user or group schema.
+// @Tags SCIM
+// @Produce json
+// @Param schemaId path string true "Schema URN"
+// @Success 200 {object} map[string]interface{} "Success"
+// @Router /scim/v2/Schemas/{schemaId} [get]
+func (c *ScimController) Schema(ctx *gin.Context) {
+	for _, schema := range schemas {
+		if schema["id"] == ctx.Param("schemaId") {
+			scimJson(ctx, http.StatusOK, schema)
+			return
+		}
+	}
+
+	scimError(ctx, errors.Wrapf(xerrors.ErrScimNotFound, "schema %s", ctx.Param("schemaId")))
+}
+
+// UserList lists the users matching the filter.
+// @Summary List SCIM users
+// @Description Lists the users matching the SCIM filter, a page at a time.
+// @Tags SCIM
+// @Produce json
+// @Param filter query string false "SCIM filter"
+// @Param startIndex query int false "1-based index of the first user"
+// @Param count query int false "Page size"
+// @Success 200 {object} dto.ScimListResponse "Success"
+// @Router /scim/v2/Users [get]
+func (c *ScimController) UserList(ctx *gin.Context) {
+	var param dto.ScimListRequest
+
+	if err := ctx.ShouldBindQuery(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidValue, err.Error()))
+		return
+	}
+
+	users, err := c.ScimService.GetUsers(param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, users)
+}
+
+// UserDetail retrieves a user.
+// @Summary Get SCIM user
+// @Description Retrieves a user with the roles it belongs to as groups.
+// @Tags SCIM
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.ScimUser "Success"
+// @Router /scim/v2/Users/{id} [get]
+func (c *ScimController) UserDetail(ctx *gin.Context) {
+	user, err := c.ScimService.GetUser(ctx.Param("id"))
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, user)
+}
+
+// UserCreate provisions a user.
+// @Summary Create SCIM user
+// @Description Provisions a user, with the initial password when none is given.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param body body dto.ScimUser true "User"
+// @Success 201 {object} dto.ScimUser "Created"
+// @Router /scim/v2/Users [post]
+func (c *ScimController) UserCreate(ctx *gin.Context) {
+	var param dto.ScimUser
+
+	if err := ctx.ShouldBindJSON(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidSyntax, err.Error()))
+		return
+	}
+
+	user, err := c.ScimService.CreateUser(param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", user.Meta.Location)
+	scimJson(ctx, http.StatusCreated, user)
+}
+
+// UserReplace replaces the attributes of a user.
+// @Summary Replace SCIM user
+// @Description Replaces the attributes of a user; the user name cannot change.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body dto.ScimUser true "User"
+// @Success 200 {object} dto.ScimUser "Success"
+// @Router /scim/v2/Users/{id} [put]
+func (c *ScimController) UserReplace(ctx *gin.Context) {
+	var param dto.ScimUser
+
+	if err := ctx.ShouldBindJSON(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidSyntax, err.Error()))
+		return
+	}
+
+	user, err := c.ScimService.ReplaceUser(ctx.Param("id"), param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, user)
+}
+
+// UserPatch applies patch operations to a user.
+// @Summary Patch SCIM user
+// @Description Applies add, replace and remove operations to a user.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param body body dto.ScimPatchRequest true "Patch operations"
+// @Success 200 {object} dto.ScimUser "Success"
+// @Router /scim/v2/Users/{id} [patch]
+func (c *ScimController) UserPatch(ctx *gin.Context) {
+	var param dto.ScimPatchRequest
+
+	if err := ctx.ShouldBindJSON(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidSyntax, err.Error()))
+		return
+	}
+
+	user, err := c.ScimService.PatchUser(ctx.Param("id"), param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, user)
+}
+
+// UserDelete deprovisions a user by disabling it.
+// @Summary Deprovision SCIM user
+// @Description Disables a user; the account and its history are kept.
+// @Tags SCIM
+// @Param id path string true "User ID"
+// @Success 204 "No Content"
+// @Router /scim/v2/Users/{id} [delete]
+func (c *ScimController) UserDelete(ctx *gin.Context) {
+	if err := c.ScimService.DeprovisionUser(ctx.Param("id")); err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GroupList lists the groups matching the filter.
+// @Summary List SCIM groups
+// @Description Lists the roles matching the SCIM filter as groups, a page at a time.
+// @Tags SCIM
+// @Produce json
+// @Param filter query string false "SCIM filter"
+// @Param startIndex query int false "1-based index of the first group"
+// @Param count query int false "Page size"
+// @Param excludedAttributes query string false "Attributes to leave out, such as members"
+// @Success 200 {object} dto.ScimListResponse "Success"
+// @Router /scim/v2/Groups [get]
+func (c *ScimController) GroupList(ctx *gin.Context) {
+	var param dto.ScimListRequest
+
+	if err := ctx.ShouldBindQuery(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidValue, err.Error()))
+		return
+	}
+
+	groups, err := c.ScimService.GetGroups(param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, groups)
+}
+
+// GroupDetail retrieves a group.
+// @Summary Get SCIM group
+// @Description Retrieves a role as a group with its users as members.
+// @Tags SCIM
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param excludedAttributes query string false "Attributes to leave out, such as members"
+// @Success 200 {object} dto.ScimGroup "Success"
+// @Router /scim/v2/Groups/{id} [get]
+func (c *ScimController) GroupDetail(ctx *gin.Context) {
+	excludeMembers := strings.Contains(strings.ToLower(ctx.Query("excludedAttributes")), "members")
+
+	group, err := c.ScimService.GetGroup(ctx.Param("id"), excludeMembers)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, group)
+}
+
+// GroupCreate provisions a group.
+// @Summary Create SCIM group
+// @Description Provisions a role named after the group, with the members assigned.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param body body dto.ScimGroup true "Group"
+// @Success 201 {object} dto.ScimGroup "Created"
+// @Router /scim/v2/Groups [post]
+func (c *ScimController) GroupCreate(ctx *gin.Context) {
+	var param dto.ScimGroup
+
+	if err := ctx.ShouldBindJSON(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidSyntax, err.Error()))
+		return
+	}
+
+	group, err := c.ScimService.CreateGroup(param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", group.Meta.Location)
+	scimJson(ctx, http.StatusCreated, group)
+}
+
+// GroupReplace replaces the name and members of a group.
+// @Summary Replace SCIM group
+// @Description Renames the role of a group and replaces its members.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param body body dto.ScimGroup true "Group"
+// @Success 200 {object} dto.ScimGroup "Success"
+// @Router /scim/v2/Groups/{id} [put]
+func (c *ScimController) GroupReplace(ctx *gin.Context) {
+	var param dto.ScimGroup
+
+	if err := ctx.ShouldBindJSON(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidSyntax, err.Error()))
+		return
+	}
+
+	group, err := c.ScimService.ReplaceGroup(ctx.Param("id"), param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, group)
+}
+
+// GroupPatch applies patch operations to a group.
+// @Summary Patch SCIM group
+// @Description Renames a group or adds and removes its members.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Param id path string true "Role ID"
+// @Param body body dto.ScimPatchRequest true "Patch operations"
+// @Success 200 {object} dto.ScimGroup "Success"
+// @Router /scim/v2/Groups/{id} [patch]
+func (c *ScimController) GroupPatch(ctx *gin.Context) {
+	var param dto.ScimPatchRequest
+
+	if err := ctx.ShouldBindJSON(&param); err != nil {
+		scimError(ctx, errors.Wrap(xerrors.ErrScimInvalidSyntax, err.Error()))
+		return
+	}
+
+	group, err := c.ScimService.PatchGroup(ctx.Param("id"), param)
+	if err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	scimJson(ctx, http.StatusOK, group)
+}
+
+// GroupDelete deletes a group.
+// @Summary Delete SCIM group
+// @Description Deletes the role of a group, keeping it in the recycle bin.
+// @Tags SCIM
+// @Param id path string true "Role ID"
+// @Success 204 "No Content"
+// @Router /scim/v2/Groups/{id} [delete]
+func (c *ScimController) GroupDelete(ctx *gin.Context) {
+	if err := c.ScimService.DeleteGroup(ctx.Param("id")); err != nil {
+		scimError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// scimJson writes the body with the SCIM content type
+func scimJson(ctx *gin.Context, status int, body interface{}) {
+	ctx.Header("Content-Type", constant.SCIM_CONTENT_TYPE)
+	ctx.JSON(status, body)
+}
+
+// scimError writes the error as a SCIM error message, with the status and scimType of the
+// SCIM error it wraps. Any other error is an internal server error.
+func scimError(ctx *gin.Context, err error) {
+	status, scimType := http.StatusInternalServerError, ""
+
+	switch errors.Cause(err) {
+	case xerrors.ErrScimNotFound:
+		status = http.StatusNotFound
+	case xerrors.ErrScimInvalidFilter:
+		status, scimType = http.StatusBadRequest, "invalidFilter"
+	case xerrors.ErrScimInvalidPath:
+		status, scimType = http.StatusBadRequest, "invalidPath"
+	case xerrors.ErrScimInvalidValue:
+		status, scimType = http.StatusBadRequest, "invalidValue"
+	case xerrors.ErrScimInvalidSyntax:
+		status, scimType = http.StatusBadRequest, "invalidSyntax"
+	case xerrors.ErrScimMutability:
+		status, scimType = http.StatusBadRequest, "mutability"
+	case xerrors.ErrScimUniqueness:
+		status, scimType = http.StatusConflict, "uniqueness"
+	}
+
+	scimJson(ctx, status, dto.ScimErrorResponse{
+		Schemas:  []string{constant.SCIM_MESSAGE_ERROR},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   err.Error(),
+	})
+}
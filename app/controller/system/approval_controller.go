@@ -0,0 +1,166 @@
+package systemcontroller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/middleware"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/token"
+	"mira/app/validator"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApprovalController handles four-eyes change request operations.
+type ApprovalController struct {
+	ApprovalService *service.ApprovalService
+	UserService     *service.UserService
+
+	// Handler replays approved change requests, normally the gin engine serving the API.
+	Handler http.Handler
+}
+
+// NewApprovalController creates a new ApprovalController.
+func NewApprovalController(approvalService *service.ApprovalService, userService *service.UserService) *ApprovalController {
+	return &ApprovalController{ApprovalService: approvalService, UserService: userService}
+}
+
+// List retrieves a paginated list of change requests.
+// @Summary Get change request list
+// @Description Retrieves a paginated list of change requests awaiting or past approval.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.ApprovalListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.ApprovalListResponse}} "Success"
+// @Router /system/approval/list [get]
+func (c *ApprovalController) List(ctx *gin.Context) {
+	var param dto.ApprovalListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	c.ApprovalService.ExpireApprovals()
+
+	approvals, total := c.ApprovalService.GetApprovalList(param, true)
+
+	response.NewSuccess().SetPageData(approvals, total).Json(ctx)
+}
+
+// Detail retrieves the details of a change request, including the captured payload.
+// @Summary Get change request details
+// @Description Retrieves the details of a change request by its ID.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param approvalId path int true "Change request ID"
+// @Success 200 {object} response.Response{data=dto.ApprovalDetailResponse} "Success"
+// @Router /system/approval/{approvalId} [get]
+func (c *ApprovalController) Detail(ctx *gin.Context) {
+	approvalId, _ := strconv.Atoi(ctx.Param("approvalId"))
+
+	c.ApprovalService.ExpireApprovals()
+
+	approval := c.ApprovalService.GetApprovalByApprovalId(approvalId)
+
+	response.NewSuccess().SetData("data", approval).Json(ctx)
+}
+
+// Approve approves a change request and replays it under the requester's identity.
+// @Summary Approve change request
+// @Description Approves a pending change request raised by another user and executes the original request.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.ReviewApprovalRequest true "Review data"
+// @Success 200 {object} response.Response{data=dto.ApprovalDetailResponse} "Success"
+// @Router /system/approval/approve [put]
+func (c *ApprovalController) Approve(ctx *gin.Context) {
+	var param dto.ReviewApprovalRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.ApproveApprovalValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	approval, err := c.ApprovalService.ReviewApproval(param.ApprovalId, security.GetAuthUserId(ctx), security.GetAuthUserName(ctx), true, param.Reason)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	// Replay as the requester, who must still be an active user
+	requester := c.UserService.GetUserByUsername(approval.RequesterName)
+	if requester.UserId != approval.RequesterId || requester.Status != constant.NORMAL_STATUS {
+		c.ApprovalService.CompleteApproval(approval.ApprovalId, constant.APPROVAL_STATUS_FAILED, xerrors.ErrApprovalRequesterGone.Error())
+		response.NewError().SetMsg(xerrors.ErrApprovalRequesterGone.Error()).Json(ctx)
+		return
+	}
+
+	status, body := middleware.ReplayApproval(ctx, c.Handler, approval, &token.UserTokenResponse{UserTokenResponse: requester})
+
+	var result response.Response
+	json.Unmarshal([]byte(body), &result)
+
+	approval.Status = constant.APPROVAL_STATUS_APPROVED
+	approval.JsonResult = body
+	if status != http.StatusOK || result.Code != http.StatusOK {
+		approval.Status = constant.APPROVAL_STATUS_FAILED
+	}
+
+	if err = c.ApprovalService.CompleteApproval(approval.ApprovalId, approval.Status, approval.JsonResult); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if approval.Status == constant.APPROVAL_STATUS_FAILED {
+		response.NewError().SetMsg("Change request approved but execution failed: "+result.Msg).SetData("data", approval).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", approval).Json(ctx)
+}
+
+// Reject rejects a change request.
+// @Summary Reject change request
+// @Description Rejects a pending change request raised by another user. A reason is required.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.ReviewApprovalRequest true "Review data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/approval/reject [put]
+func (c *ApprovalController) Reject(ctx *gin.Context) {
+	var param dto.ReviewApprovalRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.RejectApprovalValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if _, err := c.ApprovalService.ReviewApproval(param.ApprovalId, security.GetAuthUserId(ctx), security.GetAuthUserName(ctx), false, param.Reason); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
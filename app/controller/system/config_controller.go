@@ -2,27 +2,29 @@ package systemcontroller
 
 import (
 	"strconv"
-	"time"
 
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/security"
 	"mira/app/service"
 	"mira/app/validator"
+	"mira/common/types/constant"
 	"mira/common/utils"
 
-	"gitee.com/hanshuangjianke/go-excel/excel"
 	"github.com/gin-gonic/gin"
 )
 
 // ConfigController handles parameter configuration operations.
 type ConfigController struct {
-	ConfigService *service.ConfigService
+	ConfigService        *service.ConfigService
+	ConfigHistoryService *service.ConfigHistoryService
+	ExportService        *service.ExportService
 }
 
 // NewConfigController creates a new ConfigController.
-func NewConfigController(configService *service.ConfigService) *ConfigController {
-	return &ConfigController{ConfigService: configService}
+func NewConfigController(configService *service.ConfigService, configHistoryService *service.ConfigHistoryService, exportService *service.ExportService) *ConfigController {
+	return &ConfigController{ConfigService: configService, ConfigHistoryService: configHistoryService, ExportService: exportService}
 }
 
 // List retrieves a paginated list of parameters.
@@ -141,6 +143,7 @@ func (c *ConfigController) Update(ctx *gin.Context) {
 		ConfigType:  param.ConfigType,
 		Remark:      param.Remark,
 		UpdateBy:    security.GetAuthUserName(ctx),
+		Reason:      param.Reason,
 	}); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
@@ -165,7 +168,7 @@ func (c *ConfigController) Remove(ctx *gin.Context) {
 		return
 	}
 
-	if err = c.ConfigService.DeleteConfig(configIds); err != nil {
+	if err = c.ConfigService.DeleteConfig(configIds, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -190,14 +193,28 @@ func (c *ConfigController) ConfigKey(ctx *gin.Context) {
 	response.NewSuccess().SetMsg(config.ConfigValue).Json(ctx)
 }
 
-// Export exports parameter data to an Excel file.
+// Schemas retrieves the declared types of the parameters.
+// @Summary Get parameter schemas
+// @Description Retrieves the value type, default value and constraints of every declared parameter, ordered by key. Parameters without a declaration take any value.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response{data=[]dto.ConfigSchemaResponse} "Success"
+// @Router /system/config/schemas [get]
+func (c *ConfigController) Schemas(ctx *gin.Context) {
+	response.NewSuccess().SetData("data", c.ConfigService.GetConfigSchemas()).Json(ctx)
+}
+
+// Export exports parameter data as XLSX, CSV or NDJSON.
 // @Summary Export parameters
-// @Description Exports parameter data to an Excel file based on query parameters.
+// @Description Streams parameter data matching the query parameters in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
 // @Tags System
 // @Accept json
 // @Produce json
 // @Param query body dto.ConfigListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /system/config/export [post]
 func (c *ConfigController) Export(ctx *gin.Context) {
 	var param dto.ConfigListRequest
@@ -207,26 +224,7 @@ func (c *ConfigController) Export(ctx *gin.Context) {
 		return
 	}
 
-	list := make([]dto.ConfigExportResponse, 0)
-
-	configs, _ := c.ConfigService.GetConfigList(param, false)
-	for _, config := range configs {
-		list = append(list, dto.ConfigExportResponse{
-			ConfigId:    config.ConfigId,
-			ConfigName:  config.ConfigName,
-			ConfigKey:   config.ConfigKey,
-			ConfigValue: config.ConfigValue,
-			ConfigType:  config.ConfigType,
-		})
-	}
-
-	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
-	if err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
-
-	excel.DownLoadExcel("config_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	controller.Export(ctx, c.ExportService, c.ConfigService.ExportDataset(param), "config")
 }
 
 // RefreshCache refreshes the parameter cache.
@@ -245,3 +243,57 @@ func (c *ConfigController) RefreshCache(ctx *gin.Context) {
 
 	response.NewSuccess().Json(ctx)
 }
+
+// HistoryList retrieves a paginated list of the parameter change history.
+// @Summary Get parameter change history
+// @Description Retrieves the versions of the parameters, newest first, each with its operator, reason and the values before and after the change, optionally of one parameter, action, operator or time range.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.ConfigHistoryListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.ConfigHistoryListResponse}} "Success"
+// @Router /system/config/history/list [get]
+func (c *ConfigController) HistoryList(ctx *gin.Context) {
+	configHistoryList(ctx, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_CONFIG)
+}
+
+// HistoryDiff compares two versions of a parameter.
+// @Summary Compare parameter versions
+// @Description Lists the fields that differ between the values two versions of the same parameter left it with. A deletion leaves no values.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.ConfigHistoryDiffRequest true "History IDs of the versions"
+// @Success 200 {object} response.Response{data=dto.ConfigHistoryDiffResponse} "Success"
+// @Router /system/config/history/diff [get]
+func (c *ConfigController) HistoryDiff(ctx *gin.Context) {
+	configHistoryDiff(ctx, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_CONFIG)
+}
+
+// HistoryRollback rolls a parameter back to a version.
+// @Summary Roll back parameter
+// @Description Restores a parameter to the values a version of its history left it with, records the rollback as a new version and refreshes the parameter cache. A reason is required.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.ConfigHistoryRollbackRequest true "Version and reason"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/config/history/rollback [post]
+func (c *ConfigController) HistoryRollback(ctx *gin.Context) {
+	configHistoryRollback(ctx, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_CONFIG)
+}
+
+// HistoryExport exports the parameter change history as XLSX, CSV or NDJSON.
+// @Summary Export parameter change history
+// @Description Streams the parameter change history matching the query parameters, newest first, in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.ConfigHistoryListRequest true "Query parameters"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
+// @Router /system/config/history/export [post]
+func (c *ConfigController) HistoryExport(ctx *gin.Context) {
+	configHistoryExport(ctx, c.ExportService, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_CONFIG, "config_history")
+}
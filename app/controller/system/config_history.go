@@ -0,0 +1,87 @@
+package systemcontroller
+
+import (
+	"mira/anima/response"
+	"mira/app/controller"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// configHistoryList responds with a page of the change history of a type of item, newest first
+func configHistoryList(ctx *gin.Context, configHistoryService *service.ConfigHistoryService, entityType string) {
+	var param dto.ConfigHistoryListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.PageValidator(param.PageRequest); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	histories, total, err := configHistoryService.GetConfigHistoryList(entityType, param)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetPageData(histories, total).Json(ctx)
+}
+
+// configHistoryDiff responds with the fields that differ between two versions of an item
+func configHistoryDiff(ctx *gin.Context, configHistoryService *service.ConfigHistoryService, entityType string) {
+	var param dto.ConfigHistoryDiffRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	diff, err := configHistoryService.GetConfigHistoryDiff(entityType, param.FromHistoryId, param.ToHistoryId)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", diff).Json(ctx)
+}
+
+// configHistoryRollback rolls an item back to the values of a version of its history
+func configHistoryRollback(ctx *gin.Context, configHistoryService *service.ConfigHistoryService, entityType string) {
+	var param dto.ConfigHistoryRollbackRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.ConfigHistoryRollbackValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := configHistoryService.RollbackConfigHistory(entityType, param.HistoryId, param.Reason, security.GetAuthUserName(ctx)); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// configHistoryExport exports the change history of a type of item matching the query parameters
+func configHistoryExport(ctx *gin.Context, exportService *service.ExportService, configHistoryService *service.ConfigHistoryService, entityType, fileName string) {
+	var param dto.ConfigHistoryListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	controller.Export(ctx, exportService, configHistoryService.ExportDataset(entityType, param), fileName)
+}
@@ -0,0 +1,178 @@
+package systemcontroller
+
+import (
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DelegationController handles delegated department administration.
+type DelegationController struct {
+	DelegationService *service.DelegationService
+	RoleService       *service.RoleService
+}
+
+// NewDelegationController creates a new DelegationController.
+func NewDelegationController(delegationService *service.DelegationService, roleService *service.RoleService) *DelegationController {
+	return &DelegationController{DelegationService: delegationService, RoleService: roleService}
+}
+
+// List retrieves a paginated list of delegations.
+// @Summary Get delegation list
+// @Description Retrieves a paginated list of department delegations based on query parameters.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.DelegationListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.DelegationListResponse}} "Success"
+// @Router /system/delegation/list [get]
+func (c *DelegationController) List(ctx *gin.Context) {
+	var param dto.DelegationListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	delegations, total := c.DelegationService.GetDelegationList(param, true)
+
+	response.NewSuccess().SetPageData(delegations, total).Json(ctx)
+}
+
+// Detail retrieves the details of a specific delegation.
+// @Summary Get delegation details
+// @Description Retrieves the details of a department delegation by its ID, including the whitelisted roles.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param delegationId path int true "Delegation ID"
+// @Success 200 {object} response.Response{data=dto.DelegationDetailResponse} "Success"
+// @Router /system/delegation/{delegationId} [get]
+func (c *DelegationController) Detail(ctx *gin.Context) {
+	delegationId, _ := strconv.Atoi(ctx.Param("delegationId"))
+
+	delegation := c.DelegationService.GetDelegationByDelegationId(delegationId)
+
+	response.NewSuccess().SetData("data", delegation).Json(ctx)
+}
+
+// Create adds a new delegation.
+// @Summary Add delegation
+// @Description Delegates the administration of a department subtree to a user or role. Only roles the operator could grant are whitelisted.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateDelegationRequest true "Delegation data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/delegation [post]
+func (c *DelegationController) Create(ctx *gin.Context) {
+	var param dto.CreateDelegationRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.CreateDelegationValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.DelegationService.CreateDelegation(dto.SaveDelegation{
+		DeptId:       param.DeptId,
+		DelegateType: param.DelegateType,
+		DelegateId:   param.DelegateId,
+		Status:       param.Status,
+		CreateBy:     security.GetAuthUserName(ctx),
+		Remark:       param.Remark,
+	}, param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Update modifies an existing delegation.
+// @Summary Update delegation
+// @Description Modifies an existing department delegation and replaces its whitelisted roles.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UpdateDelegationRequest true "Delegation data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/delegation [put]
+func (c *DelegationController) Update(ctx *gin.Context) {
+	var param dto.UpdateDelegationRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UpdateDelegationValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	roleIds := param.RoleIds
+	if roleIds == nil {
+		roleIds = make([]int, 0)
+	}
+
+	if err := c.DelegationService.UpdateDelegation(dto.SaveDelegation{
+		DelegationId: param.DelegationId,
+		DeptId:       param.DeptId,
+		DelegateType: param.DelegateType,
+		DelegateId:   param.DelegateId,
+		Status:       param.Status,
+		UpdateBy:     security.GetAuthUserName(ctx),
+		Remark:       param.Remark,
+	}, roleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Remove deletes one or more delegations.
+// @Summary Delete delegation
+// @Description Deletes department delegations by their IDs.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param delegationIds path string true "Delegation IDs, comma-separated"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/delegation/{delegationIds} [delete]
+func (c *DelegationController) Remove(ctx *gin.Context) {
+	delegationIds, err := utils.StringToIntSlice(ctx.Param("delegationIds"), ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.DelegationService.DeleteDelegation(delegationIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
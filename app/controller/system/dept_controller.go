@@ -3,6 +3,7 @@ package systemcontroller
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"mira/anima/response"
 	"mira/app/dto"
@@ -12,20 +13,23 @@ import (
 	"mira/common/types/constant"
 	"mira/common/utils"
 
+	"gitee.com/hanshuangjianke/go-excel/excel"
 	"github.com/gin-gonic/gin"
 )
 
 // DeptController handles department-related operations.
 type DeptController struct {
-	DeptService *service.DeptService
-	UserService *service.UserService
+	DeptService      *service.DeptService
+	UserService      *service.UserService
+	ImportJobService *service.ImportJobService
 }
 
 // NewDeptController creates a new DeptController.
-func NewDeptController(deptService *service.DeptService, userService *service.UserService) *DeptController {
+func NewDeptController(deptService *service.DeptService, userService *service.UserService, importJobService *service.ImportJobService) *DeptController {
 	return &DeptController{
-		DeptService: deptService,
-		UserService: userService,
+		DeptService:      deptService,
+		UserService:      userService,
+		ImportJobService: importJobService,
 	}
 }
 
@@ -173,31 +177,48 @@ func (c *DeptController) Update(ctx *gin.Context) {
 		return
 	}
 
-	if dept := c.DeptService.GetDeptByDeptId(param.DeptId); dept.ParentId != param.ParentId && c.DeptService.DeptHasChildren(param.DeptId) {
-		response.NewError().SetMsg("Sub-departments exist, cannot directly modify the parent department").Json(ctx)
+	// Changing the parent moves the subtree; the service rewrites the ancestors
+	if err := c.DeptService.UpdateDept(dto.SaveDept{
+		DeptId:   param.DeptId,
+		ParentId: param.ParentId,
+		DeptName: param.DeptName,
+		OrderNum: param.OrderNum,
+		Leader:   param.Leader,
+		Phone:    param.Phone,
+		Email:    param.Email,
+		Status:   param.Status,
+		UpdateBy: security.GetAuthUserName(ctx),
+	}); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
 
-	// Splice ancestors, get the ancestor list of the parent
-	parentDept := c.DeptService.GetDeptByDeptId(param.ParentId)
-	if parentDept.Status == constant.EXCEPTION_STATUS {
-		response.NewError().SetMsg("Department is disabled, adding is not allowed").Json(ctx)
+	response.NewSuccess().Json(ctx)
+}
+
+// Move moves a department with its sub-departments under a new parent.
+// @Summary Move department
+// @Description Moves a department and its subtree under a new parent department, rewriting the ancestors of the subtree. Moving a department under its own sub-department is refused.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.MoveDeptRequest true "Department and new parent"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/dept/move [put]
+func (c *DeptController) Move(ctx *gin.Context) {
+	var param dto.MoveDeptRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
-	ancestors := parentDept.Ancestors + "," + strconv.Itoa(parentDept.DeptId)
 
-	if err := c.DeptService.UpdateDept(dto.SaveDept{
-		DeptId:    param.DeptId,
-		ParentId:  param.ParentId,
-		Ancestors: ancestors,
-		DeptName:  param.DeptName,
-		OrderNum:  param.OrderNum,
-		Leader:    param.Leader,
-		Phone:     param.Phone,
-		Email:     param.Email,
-		Status:    param.Status,
-		UpdateBy:  security.GetAuthUserName(ctx),
-	}); err != nil {
+	if err := validator.MoveDeptValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.DeptService.MoveDept(param.DeptId, param.ParentId, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -205,6 +226,72 @@ func (c *DeptController) Update(ctx *gin.Context) {
 	response.NewSuccess().Json(ctx)
 }
 
+// Merge merges a department into another one.
+// @Summary Merge department
+// @Description Transfers the users, custom data scope grants, delegations and sub-departments of the source department to the target and deletes the source. With dryRun the changes are only reported.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.MergeDeptRequest true "Source and target departments"
+// @Success 200 {object} response.Response{data=dto.DeptMergeResponse} "Success"
+// @Router /system/dept/merge [post]
+func (c *DeptController) Merge(ctx *gin.Context) {
+	var param dto.MergeDeptRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.MergeDeptValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	// The merge records its own operation log entry, in the same transaction and only when applied
+	result, err := c.DeptService.MergeDept(param.SourceDeptId, param.TargetDeptId, param.DryRun, dto.SaveOperLogRequest{
+		Title:         "Merge Department",
+		BusinessType:  constant.REQUEST_BUSINESS_TYPE_UPDATE,
+		Method:        "DeptController.Merge",
+		RequestMethod: ctx.Request.Method,
+		OperName:      security.GetAuthUserName(ctx),
+		OperUrl:       ctx.Request.URL.RequestURI(),
+		OperIp:        ctx.ClientIP(),
+	})
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", result).Json(ctx)
+}
+
+// RepairHierarchy recomputes department ancestors from the parent relation.
+// @Summary Repair department hierarchy
+// @Description Recomputes the ancestors of every department from its parent and reports inconsistencies. With dryRun only the report is returned.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param dryRun query bool false "Only report inconsistencies"
+// @Success 200 {object} response.Response{data=dto.DeptHierarchyRepairResponse} "Success"
+// @Router /system/dept/repairHierarchy [post]
+func (c *DeptController) RepairHierarchy(ctx *gin.Context) {
+	var param dto.RepairDeptHierarchyRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	result, err := c.DeptService.RepairDeptHierarchy(param.DryRun)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", result).Json(ctx)
+}
+
 // Remove deletes a department.
 // @Summary Delete department
 // @Description Deletes a department by its ID.
@@ -227,10 +314,87 @@ func (c *DeptController) Remove(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.DeptService.DeleteDept(deptId); err != nil {
+	if err := c.DeptService.DeleteDept(deptId, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
 
 	response.NewSuccess().Json(ctx)
 }
+
+// ImportTemplate provides a template for importing department data.
+// @Summary Import department template
+// @Description Downloads an Excel template for importing department data.
+// @Tags System
+// @Produce octet-stream
+// @Success 200 {file} file "Excel template"
+// @Router /system/dept/importTemplate [post]
+func (c *DeptController) ImportTemplate(ctx *gin.Context) {
+	list := make([]dto.DeptImportRequest, 0)
+
+	list = append(list, dto.DeptImportRequest{
+		DeptPath: "HQ/Sales/East",
+		OrderNum: 1,
+		Leader:   "leader",
+		Phone:    "12345678901",
+		Email:    "example@example.com",
+		Status:   "0",
+	})
+
+	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	excel.DownLoadExcel("dept_template_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// ImportData starts a background import of department data from an Excel file.
+// @Summary Import department data
+// @Description Starts a background import of department data from an Excel file, with an option to update existing departments, and returns the import job ID. Parents are resolved by name path from the top-level department, e.g. HQ/Sales/East.
+// @Tags System
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Excel file"
+// @Param updateSupport query bool false "Whether to update existing department data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/dept/importData [post]
+func (c *DeptController) ImportData(ctx *gin.Context) {
+	startImport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DEPT, c.ImportJobService.RunDeptImport)
+}
+
+// ImportJob retrieves the progress of a department import job.
+// @Summary Get department import progress
+// @Description Retrieves the status and the processed, succeeded and failed row counts of a department import job.
+// @Tags System
+// @Produce json
+// @Param jobId path int true "Import job ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "Success"
+// @Router /system/dept/importJob/{jobId} [get]
+func (c *DeptController) ImportJob(ctx *gin.Context) {
+	importJobProgress(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DEPT)
+}
+
+// ImportErrorReport downloads the failed rows of a department import job.
+// @Summary Download department import error report
+// @Description Downloads an Excel file with the failed rows of a department import job, each with its row number and error.
+// @Tags System
+// @Produce octet-stream
+// @Param jobId path int true "Import job ID"
+// @Success 200 {file} file "Excel file"
+// @Router /system/dept/importJob/{jobId}/errorReport [get]
+func (c *DeptController) ImportErrorReport(ctx *gin.Context) {
+	importErrorReport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DEPT, "dept_import_errors", func(rowNum int, row dto.DeptImportRequest, errorMsg string) dto.DeptImportErrorResponse {
+		return dto.DeptImportErrorResponse{
+			RowNum:   rowNum,
+			DeptPath: row.DeptPath,
+			OrderNum: row.OrderNum,
+			Leader:   row.Leader,
+			Phone:    row.Phone,
+			Email:    row.Email,
+			Status:   row.Status,
+			ErrorMsg: errorMsg,
+		}
+	})
+}
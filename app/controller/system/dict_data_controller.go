@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/security"
 	"mira/app/service"
@@ -18,12 +19,15 @@ import (
 
 // DictDataController handles dictionary data operations.
 type DictDataController struct {
-	DictDataService *service.DictDataService
+	DictDataService      *service.DictDataService
+	ConfigHistoryService *service.ConfigHistoryService
+	ImportJobService     *service.ImportJobService
+	ExportService        *service.ExportService
 }
 
 // NewDictDataController creates a new DictDataController.
-func NewDictDataController(dictDataService *service.DictDataService) *DictDataController {
-	return &DictDataController{DictDataService: dictDataService}
+func NewDictDataController(dictDataService *service.DictDataService, configHistoryService *service.ConfigHistoryService, importJobService *service.ImportJobService, exportService *service.ExportService) *DictDataController {
+	return &DictDataController{DictDataService: dictDataService, ConfigHistoryService: configHistoryService, ImportJobService: importJobService, ExportService: exportService}
 }
 
 // List retrieves a paginated list of dictionary data.
@@ -140,6 +144,7 @@ func (c *DictDataController) Update(ctx *gin.Context) {
 		Status:    param.Status,
 		UpdateBy:  security.GetAuthUserName(ctx),
 		Remark:    param.Remark,
+		Reason:    param.Reason,
 	}); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
@@ -164,7 +169,7 @@ func (c *DictDataController) Remove(ctx *gin.Context) {
 		return
 	}
 
-	if err = c.DictDataService.DeleteDictData(dictCodes); err != nil {
+	if err = c.DictDataService.DeleteDictData(dictCodes, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -193,14 +198,16 @@ func (c *DictDataController) Type(ctx *gin.Context) {
 	response.NewSuccess().SetData("data", dictDatas).Json(ctx)
 }
 
-// Export exports dictionary data to an Excel file.
+// Export exports dictionary data as XLSX, CSV or NDJSON.
 // @Summary Export dictionary data
-// @Description Exports dictionary data to an Excel file based on query parameters.
+// @Description Streams dictionary data matching the query parameters in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
 // @Tags System
 // @Accept json
 // @Produce json
 // @Param query body dto.DictDataListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /system/dict/data/export [post]
 func (c *DictDataController) Export(ctx *gin.Context) {
 	var param dto.DictDataListRequest
@@ -210,20 +217,27 @@ func (c *DictDataController) Export(ctx *gin.Context) {
 		return
 	}
 
-	list := make([]dto.DictDataExportResponse, 0)
-
-	dictDatas, _ := c.DictDataService.GetDictDataList(param, false)
-	for _, dictData := range dictDatas {
-		list = append(list, dto.DictDataExportResponse{
-			DictCode:  dictData.DictCode,
-			DictSort:  dictData.DictSort,
-			DictLabel: dictData.DictLabel,
-			DictValue: dictData.DictValue,
-			DictType:  dictData.DictType,
-			IsDefault: dictData.IsDefault,
-			Status:    dictData.Status,
-		})
-	}
+	controller.Export(ctx, c.ExportService, c.DictDataService.ExportDataset(param), "data")
+}
+
+// ImportTemplate provides a template for importing dictionary data.
+// @Summary Import dictionary data template
+// @Description Downloads an Excel template for importing dictionary data.
+// @Tags System
+// @Produce octet-stream
+// @Success 200 {file} file "Excel template"
+// @Router /system/dict/data/importTemplate [post]
+func (c *DictDataController) ImportTemplate(ctx *gin.Context) {
+	list := make([]dto.DictDataImportRequest, 0)
+
+	list = append(list, dto.DictDataImportRequest{
+		DictType:  "example",
+		DictSort:  1,
+		DictLabel: "template",
+		DictValue: "0",
+		IsDefault: "N",
+		Status:    "0",
+	})
 
 	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
 	if err != nil {
@@ -231,5 +245,111 @@ func (c *DictDataController) Export(ctx *gin.Context) {
 		return
 	}
 
-	excel.DownLoadExcel("data_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	excel.DownLoadExcel("dict_data_template_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// ImportData starts a background import of dictionary data from an Excel file.
+// @Summary Import dictionary data
+// @Description Starts a background import of dictionary data from an Excel file, with an option to update existing dictionary data, and returns the import job ID. Existing data is matched by dictionary type and key value; the dictionary types must exist.
+// @Tags System
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Excel file"
+// @Param updateSupport query bool false "Whether to update existing dictionary data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/dict/data/importData [post]
+func (c *DictDataController) ImportData(ctx *gin.Context) {
+	startImport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DICT_DATA, c.ImportJobService.RunDictDataImport)
+}
+
+// ImportJob retrieves the progress of a dictionary data import job.
+// @Summary Get dictionary data import progress
+// @Description Retrieves the status and the processed, succeeded and failed row counts of a dictionary data import job.
+// @Tags System
+// @Produce json
+// @Param jobId path int true "Import job ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "Success"
+// @Router /system/dict/data/importJob/{jobId} [get]
+func (c *DictDataController) ImportJob(ctx *gin.Context) {
+	importJobProgress(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DICT_DATA)
+}
+
+// ImportErrorReport downloads the failed rows of a dictionary data import job.
+// @Summary Download dictionary data import error report
+// @Description Downloads an Excel file with the failed rows of a dictionary data import job, each with its row number and error.
+// @Tags System
+// @Produce octet-stream
+// @Param jobId path int true "Import job ID"
+// @Success 200 {file} file "Excel file"
+// @Router /system/dict/data/importJob/{jobId}/errorReport [get]
+func (c *DictDataController) ImportErrorReport(ctx *gin.Context) {
+	importErrorReport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DICT_DATA, "dict_data_import_errors", func(rowNum int, row dto.DictDataImportRequest, errorMsg string) dto.DictDataImportErrorResponse {
+		return dto.DictDataImportErrorResponse{
+			RowNum:    rowNum,
+			DictType:  row.DictType,
+			DictSort:  row.DictSort,
+			DictLabel: row.DictLabel,
+			DictValue: row.DictValue,
+			CssClass:  row.CssClass,
+			ListClass: row.ListClass,
+			IsDefault: row.IsDefault,
+			Status:    row.Status,
+			Remark:    row.Remark,
+			ErrorMsg:  errorMsg,
+		}
+	})
+}
+
+// HistoryList retrieves a paginated list of the dictionary data change history.
+// @Summary Get dictionary data change history
+// @Description Retrieves the versions of the dictionary data, newest first, each with its operator, reason and the values before and after the change, optionally of one entry, action, operator or time range.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.ConfigHistoryListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.ConfigHistoryListResponse}} "Success"
+// @Router /system/dict/data/history/list [get]
+func (c *DictDataController) HistoryList(ctx *gin.Context) {
+	configHistoryList(ctx, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_DICT_DATA)
+}
+
+// HistoryDiff compares two versions of a dictionary data item.
+// @Summary Compare dictionary data versions
+// @Description Lists the fields that differ between the values two versions of the same dictionary data item left it with. A deletion leaves no values.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.ConfigHistoryDiffRequest true "History IDs of the versions"
+// @Success 200 {object} response.Response{data=dto.ConfigHistoryDiffResponse} "Success"
+// @Router /system/dict/data/history/diff [get]
+func (c *DictDataController) HistoryDiff(ctx *gin.Context) {
+	configHistoryDiff(ctx, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_DICT_DATA)
+}
+
+// HistoryRollback rolls a dictionary data item back to a version.
+// @Summary Roll back dictionary data
+// @Description Restores a dictionary data item to the values a version of its history left it with, records the rollback as a new version and refreshes the dictionary cache. A reason is required.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.ConfigHistoryRollbackRequest true "Version and reason"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/dict/data/history/rollback [post]
+func (c *DictDataController) HistoryRollback(ctx *gin.Context) {
+	configHistoryRollback(ctx, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_DICT_DATA)
+}
+
+// HistoryExport exports the dictionary data change history as XLSX, CSV or NDJSON.
+// @Summary Export dictionary data change history
+// @Description Streams the dictionary data change history matching the query parameters, newest first, in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.ConfigHistoryListRequest true "Query parameters"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
+// @Router /system/dict/data/history/export [post]
+func (c *DictDataController) HistoryExport(ctx *gin.Context) {
+	configHistoryExport(ctx, c.ExportService, c.ConfigHistoryService, constant.CONFIG_HISTORY_ENTITY_DICT_DATA, "dict_data_history")
 }
@@ -5,10 +5,12 @@ import (
 	"time"
 
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/security"
 	"mira/app/service"
 	"mira/app/validator"
+	"mira/common/types/constant"
 	"mira/common/utils"
 
 	"gitee.com/hanshuangjianke/go-excel/excel"
@@ -17,12 +19,14 @@ import (
 
 // DictTypeController handles dictionary type operations.
 type DictTypeController struct {
-	DictTypeService *service.DictTypeService
+	DictTypeService  *service.DictTypeService
+	ImportJobService *service.ImportJobService
+	ExportService    *service.ExportService
 }
 
 // NewDictTypeController creates a new DictTypeController.
-func NewDictTypeController(dictTypeService *service.DictTypeService) *DictTypeController {
-	return &DictTypeController{DictTypeService: dictTypeService}
+func NewDictTypeController(dictTypeService *service.DictTypeService, importJobService *service.ImportJobService, exportService *service.ExportService) *DictTypeController {
+	return &DictTypeController{DictTypeService: dictTypeService, ImportJobService: importJobService, ExportService: exportService}
 }
 
 // List retrieves a paginated list of dictionary types.
@@ -163,7 +167,7 @@ func (c *DictTypeController) Remove(ctx *gin.Context) {
 		return
 	}
 
-	if err = c.DictTypeService.DeleteDictType(dictIds); err != nil {
+	if err = c.DictTypeService.DeleteDictType(dictIds, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -187,14 +191,16 @@ func (c *DictTypeController) Optionselect(ctx *gin.Context) {
 	response.NewSuccess().SetData("data", dictTypes).Json(ctx)
 }
 
-// Export exports dictionary type data to an Excel file.
+// Export exports dictionary type data as XLSX, CSV or NDJSON.
 // @Summary Export dictionary types
-// @Description Exports dictionary type data to an Excel file based on query parameters.
+// @Description Streams dictionary type data matching the query parameters in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
 // @Tags System
 // @Accept json
 // @Produce json
 // @Param query body dto.DictTypeListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /system/dict/type/export [post]
 func (c *DictTypeController) Export(ctx *gin.Context) {
 	var param dto.DictTypeListRequest
@@ -204,25 +210,7 @@ func (c *DictTypeController) Export(ctx *gin.Context) {
 		return
 	}
 
-	list := make([]dto.DictTypeExportResponse, 0)
-
-	dictTypes, _ := c.DictTypeService.GetDictTypeList(param, false)
-	for _, dictType := range dictTypes {
-		list = append(list, dto.DictTypeExportResponse{
-			DictId:   dictType.DictId,
-			DictName: dictType.DictName,
-			DictType: dictType.DictType,
-			Status:   dictType.Status,
-		})
-	}
-
-	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
-	if err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
-
-	excel.DownLoadExcel("type_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	controller.Export(ctx, c.ExportService, c.DictTypeService.ExportDataset(param), "type")
 }
 
 // RefreshCache refreshes the dictionary cache.
@@ -241,3 +229,75 @@ func (c *DictTypeController) RefreshCache(ctx *gin.Context) {
 
 	response.NewSuccess().Json(ctx)
 }
+
+// ImportTemplate provides a template for importing dictionary type data.
+// @Summary Import dictionary type template
+// @Description Downloads an Excel template for importing dictionary type data.
+// @Tags System
+// @Produce octet-stream
+// @Success 200 {file} file "Excel template"
+// @Router /system/dict/type/importTemplate [post]
+func (c *DictTypeController) ImportTemplate(ctx *gin.Context) {
+	list := make([]dto.DictTypeImportRequest, 0)
+
+	list = append(list, dto.DictTypeImportRequest{
+		DictName: "template",
+		DictType: "example",
+		Status:   "0",
+	})
+
+	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	excel.DownLoadExcel("dict_type_template_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// ImportData starts a background import of dictionary type data from an Excel file.
+// @Summary Import dictionary type data
+// @Description Starts a background import of dictionary type data from an Excel file, with an option to update existing dictionary types, and returns the import job ID. Existing types are matched by dictionary type.
+// @Tags System
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Excel file"
+// @Param updateSupport query bool false "Whether to update existing dictionary type data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/dict/type/importData [post]
+func (c *DictTypeController) ImportData(ctx *gin.Context) {
+	startImport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DICT_TYPE, c.ImportJobService.RunDictTypeImport)
+}
+
+// ImportJob retrieves the progress of a dictionary type import job.
+// @Summary Get dictionary type import progress
+// @Description Retrieves the status and the processed, succeeded and failed row counts of a dictionary type import job.
+// @Tags System
+// @Produce json
+// @Param jobId path int true "Import job ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "Success"
+// @Router /system/dict/type/importJob/{jobId} [get]
+func (c *DictTypeController) ImportJob(ctx *gin.Context) {
+	importJobProgress(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DICT_TYPE)
+}
+
+// ImportErrorReport downloads the failed rows of a dictionary type import job.
+// @Summary Download dictionary type import error report
+// @Description Downloads an Excel file with the failed rows of a dictionary type import job, each with its row number and error.
+// @Tags System
+// @Produce octet-stream
+// @Param jobId path int true "Import job ID"
+// @Success 200 {file} file "Excel file"
+// @Router /system/dict/type/importJob/{jobId}/errorReport [get]
+func (c *DictTypeController) ImportErrorReport(ctx *gin.Context) {
+	importErrorReport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_DICT_TYPE, "dict_type_import_errors", func(rowNum int, row dto.DictTypeImportRequest, errorMsg string) dto.DictTypeImportErrorResponse {
+		return dto.DictTypeImportErrorResponse{
+			RowNum:   rowNum,
+			DictName: row.DictName,
+			DictType: row.DictType,
+			Status:   row.Status,
+			Remark:   row.Remark,
+			ErrorMsg: errorMsg,
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package systemcontroller
+
+import (
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/security"
+	"mira/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportController handles the background export jobs started by the export endpoints.
+// Each user can only see and download their own jobs.
+type ExportController struct {
+	ExportService *service.ExportService
+}
+
+// NewExportController creates a new ExportController.
+func NewExportController(exportService *service.ExportService) *ExportController {
+	return &ExportController{ExportService: exportService}
+}
+
+// Job retrieves the progress of an export job.
+// @Summary Get export job progress
+// @Description Retrieves the status and the number of rows written of an export job started by the current user.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param jobId path int true "Export job ID"
+// @Success 200 {object} response.Response{data=dto.ExportJobResponse} "Success"
+// @Router /system/export/job/{jobId} [get]
+func (c *ExportController) Job(ctx *gin.Context) {
+	jobId, _ := strconv.Atoi(ctx.Param("jobId"))
+
+	job, err := c.ExportService.GetExportJob(jobId, security.GetAuthUserName(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", job).Json(ctx)
+}
+
+// Download downloads the file of a finished export job.
+// @Summary Download export file
+// @Description Downloads the file written by a finished export job started by the current user.
+// @Tags System
+// @Accept json
+// @Produce octet-stream
+// @Param jobId path int true "Export job ID"
+// @Success 200 {file} file "Export file"
+// @Router /system/export/job/{jobId}/download [get]
+func (c *ExportController) Download(ctx *gin.Context) {
+	jobId, _ := strconv.Atoi(ctx.Param("jobId"))
+
+	filePath, fileName, err := c.ExportService.GetExportJobFile(jobId, security.GetAuthUserName(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	ctx.FileAttachment(filePath, fileName)
+}
@@ -0,0 +1,141 @@
+package systemcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/common/uuid"
+	"mira/common/xerrors"
+	"mira/config"
+
+	"gitee.com/hanshuangjianke/go-excel/excel"
+	"github.com/gin-gonic/gin"
+	excelize "github.com/xuri/excelize/v2"
+)
+
+// startImport saves the uploaded Excel file and starts a background import job of the given type,
+// which reads the rows from the file and passes them to run. Responds with the job ID.
+func startImport[T any](ctx *gin.Context, importJobService *service.ImportJobService, jobType string, run func(jobId int, rows []T, options dto.ImportOptions) error) {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	// Keep the upload under a unique name until the background job has read it
+	tempName, err := uuid.New()
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+	fileName := config.Data.Ruoyi.UploadPath + tempName + filepath.Ext(file.Filename)
+
+	if err = ctx.SaveUploadedFile(file, fileName); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	// Whether to update existing data
+	updateSupport, _ := strconv.ParseBool(ctx.Query("updateSupport"))
+
+	options := dto.ImportOptions{
+		UpdateSupport: updateSupport,
+		OperatorId:    security.GetAuthUserId(ctx),
+		OperatorName:  security.GetAuthUserName(ctx),
+	}
+
+	jobId, err := importJobService.CreateImportJob(jobType, file.Filename, options.OperatorName)
+	if err != nil {
+		os.Remove(fileName)
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = importJobService.SubmitImportJob(jobId, func(_ context.Context) error {
+		defer os.Remove(fileName)
+
+		excelFile, err := excelize.OpenFile(fileName)
+		if err != nil {
+			return err
+		}
+		defer excelFile.Close()
+
+		list := make([]T, 0)
+		if err = excel.ImportExcel(excelFile, &list, 0, 1); err != nil {
+			return err
+		}
+
+		return run(jobId, list, options)
+	}); err != nil {
+		os.Remove(fileName)
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("jobId", jobId).Json(ctx)
+}
+
+// importJobProgress responds with the progress of the import job named in the path
+func importJobProgress(ctx *gin.Context, importJobService *service.ImportJobService, jobType string) {
+	job, err := getImportJob(ctx, importJobService, jobType)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", job).Json(ctx)
+}
+
+// importErrorReport downloads the failed rows of the import job named in the path,
+// each converted to a report row carrying its row number and error
+func importErrorReport[T any, R any](ctx *gin.Context, importJobService *service.ImportJobService, jobType, fileName string, toReport func(rowNum int, row T, errorMsg string) R) {
+	job, err := getImportJob(ctx, importJobService, jobType)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	rowErrors, err := importJobService.GetImportJobErrors(job.JobId)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	list := make([]R, 0, len(rowErrors))
+	for _, rowError := range rowErrors {
+		var row T
+		json.Unmarshal([]byte(rowError.RowData), &row)
+
+		list = append(list, toReport(rowError.RowNum, row, rowError.ErrorMsg))
+	}
+
+	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	excel.DownLoadExcel(fileName+"_"+strconv.Itoa(job.JobId), ctx.Writer, file)
+}
+
+// getImportJob retrieves the import job named in the path, which must be of the given type
+func getImportJob(ctx *gin.Context, importJobService *service.ImportJobService, jobType string) (dto.ImportJobResponse, error) {
+	jobId, _ := strconv.Atoi(ctx.Param("jobId"))
+
+	job, err := importJobService.GetImportJob(jobId)
+	if err != nil {
+		return job, err
+	}
+	if job.JobType != jobType {
+		return job, xerrors.ErrImportJobNotFound
+	}
+
+	return job, nil
+}
@@ -221,7 +221,7 @@ func (c *MenuController) Remove(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.MenuService.DeleteMenu(menuId); err != nil {
+	if err := c.MenuService.DeleteMenu(menuId, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
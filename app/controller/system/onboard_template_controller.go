@@ -0,0 +1,183 @@
+package systemcontroller
+
+import (
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OnboardTemplateController handles onboarding template operations.
+type OnboardTemplateController struct {
+	OnboardTemplateService *service.OnboardTemplateService
+	RoleService            *service.RoleService
+}
+
+// NewOnboardTemplateController creates a new OnboardTemplateController.
+func NewOnboardTemplateController(onboardTemplateService *service.OnboardTemplateService, roleService *service.RoleService) *OnboardTemplateController {
+	return &OnboardTemplateController{OnboardTemplateService: onboardTemplateService, RoleService: roleService}
+}
+
+// List retrieves a paginated list of onboarding templates.
+// @Summary Get onboarding template list
+// @Description Retrieves a paginated list of onboarding templates with the names of their department and post.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.OnboardTemplateListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.OnboardTemplateListResponse}} "Success"
+// @Router /system/onboardTemplate/list [get]
+func (c *OnboardTemplateController) List(ctx *gin.Context) {
+	var param dto.OnboardTemplateListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	templates, total := c.OnboardTemplateService.GetOnboardTemplateList(param, true)
+
+	response.NewSuccess().SetPageData(templates, total).Json(ctx)
+}
+
+// Detail retrieves the details of a specific onboarding template.
+// @Summary Get onboarding template details
+// @Description Retrieves the details of an onboarding template, including its role and post IDs, by its ID.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param templateId path int true "Onboarding template ID"
+// @Success 200 {object} response.Response{data=dto.OnboardTemplateDetailResponse} "Success"
+// @Router /system/onboardTemplate/{templateId} [get]
+func (c *OnboardTemplateController) Detail(ctx *gin.Context) {
+	templateId, _ := strconv.Atoi(ctx.Param("templateId"))
+
+	template := c.OnboardTemplateService.GetOnboardTemplateByTemplateId(templateId)
+
+	response.NewSuccess().SetData("data", template).Json(ctx)
+}
+
+// Create adds a new onboarding template.
+// @Summary Add onboarding template
+// @Description Adds a new onboarding template with the roles and posts it grants.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateOnboardTemplateRequest true "Onboarding template data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/onboardTemplate [post]
+func (c *OnboardTemplateController) Create(ctx *gin.Context) {
+	var param dto.CreateOnboardTemplateRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.CreateOnboardTemplateValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if template := c.OnboardTemplateService.GetOnboardTemplateByTemplateName(param.TemplateName); template.TemplateId > 0 {
+		response.NewError().SetMsg("Failed to add onboarding template " + param.TemplateName + ", template name already exists").Json(ctx)
+		return
+	}
+
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.OnboardTemplateService.CreateOnboardTemplate(dto.SaveOnboardTemplate{
+		TemplateName: param.TemplateName,
+		DeptId:       param.DeptId,
+		PostId:       param.PostId,
+		Status:       param.Status,
+		CreateBy:     security.GetAuthUserName(ctx),
+		Remark:       param.Remark,
+	}, param.RoleIds, param.PostIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Update modifies an existing onboarding template.
+// @Summary Update onboarding template
+// @Description Modifies an existing onboarding template and replaces the roles and posts it grants.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UpdateOnboardTemplateRequest true "Onboarding template data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/onboardTemplate [put]
+func (c *OnboardTemplateController) Update(ctx *gin.Context) {
+	var param dto.UpdateOnboardTemplateRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UpdateOnboardTemplateValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if template := c.OnboardTemplateService.GetOnboardTemplateByTemplateName(param.TemplateName); template.TemplateId > 0 && template.TemplateId != param.TemplateId {
+		response.NewError().SetMsg("Failed to modify onboarding template " + param.TemplateName + ", template name already exists").Json(ctx)
+		return
+	}
+
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.OnboardTemplateService.UpdateOnboardTemplate(dto.SaveOnboardTemplate{
+		TemplateId:   param.TemplateId,
+		TemplateName: param.TemplateName,
+		DeptId:       param.DeptId,
+		PostId:       param.PostId,
+		Status:       param.Status,
+		UpdateBy:     security.GetAuthUserName(ctx),
+		Remark:       param.Remark,
+	}, param.RoleIds, param.PostIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Remove deletes one or more onboarding templates.
+// @Summary Delete onboarding template
+// @Description Deletes onboarding templates by their IDs. Users already onboarded keep what they were granted.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param templateIds path string true "Onboarding template IDs, comma-separated"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/onboardTemplate/{templateIds} [delete]
+func (c *OnboardTemplateController) Remove(ctx *gin.Context) {
+	templateIds, err := utils.StringToIntSlice(ctx.Param("templateIds"), ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.OnboardTemplateService.DeleteOnboardTemplate(templateIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
@@ -0,0 +1,103 @@
+package systemcontroller
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/types/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrgChartController handles the organization chart.
+type OrgChartController struct {
+	OrgChartService *service.OrgChartService
+}
+
+// NewOrgChartController creates a new OrgChartController.
+func NewOrgChartController(orgChartService *service.OrgChartService) *OrgChartController {
+	return &OrgChartController{OrgChartService: orgChartService}
+}
+
+// OrgChart retrieves the organization chart.
+// @Summary Get organization chart
+// @Description Retrieves the department tree with the posts held in each department, the superior posts they report to and the users holding them, within the data scope of the current user. A department ID selects its subtree.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.OrgChartRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=[]dto.OrgChartDeptResponse} "Success"
+// @Router /system/dept/orgChart [get]
+func (c *OrgChartController) OrgChart(ctx *gin.Context) {
+	var param dto.OrgChartRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	tree, err := c.OrgChartService.GetOrgChartWithErr(param, security.GetAuthUserId(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", tree).Json(ctx)
+}
+
+// Export exports the organization chart.
+// @Summary Export organization chart
+// @Description Exports the organization chart within the data scope of the current user as an SVG drawing, a PDF tiled across A4 landscape pages, or a JSON graph of nodes and edges for frontend graph libraries. A department ID selects its subtree; fields lists the visible fields among leader, headCount, posts and users, leader and headCount by default.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Produce image/svg+xml
+// @Produce application/pdf
+// @Param query body dto.OrgChartExportRequest true "Export parameters"
+// @Success 200 {object} response.Response{data=dto.OrgChartGraphResponse} "Success"
+// @Router /system/dept/orgChart/export [post]
+func (c *OrgChartController) Export(ctx *gin.Context) {
+	var param dto.OrgChartExportRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.OrgChartExportValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if param.Format == constant.ORG_CHART_FORMAT_JSON {
+		graph, err := c.OrgChartService.GetOrgChartGraphWithErr(param, security.GetAuthUserId(ctx))
+		if err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+
+		response.NewSuccess().SetData("data", graph).Json(ctx)
+		return
+	}
+
+	// The chart is rendered before anything is sent, so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	if err := c.OrgChartService.WriteOrgChart(&buf, param, security.GetAuthUserId(ctx)); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	contentType, ext := "image/svg+xml", constant.ORG_CHART_FORMAT_SVG
+	if param.Format == constant.ORG_CHART_FORMAT_PDF {
+		contentType, ext = "application/pdf", constant.ORG_CHART_FORMAT_PDF
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename=org_chart_"+time.Now().Format("20060102150405")+"."+ext)
+	ctx.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,228 @@
+package systemcontroller
+
+import (
+	"strconv"
+	"time"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/types/constant"
+	"mira/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PolicyController handles access policy operations.
+type PolicyController struct {
+	PolicyService *service.PolicyService
+}
+
+// NewPolicyController creates a new PolicyController.
+func NewPolicyController(policyService *service.PolicyService) *PolicyController {
+	return &PolicyController{PolicyService: policyService}
+}
+
+// List retrieves a paginated list of policies.
+// @Summary Get policy list
+// @Description Retrieves a paginated list of access policies based on query parameters.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.PolicyListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.PolicyListResponse}} "Success"
+// @Router /system/policy/list [get]
+func (c *PolicyController) List(ctx *gin.Context) {
+	var param dto.PolicyListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	policies, total := c.PolicyService.GetPolicyList(param, true)
+
+	response.NewSuccess().SetPageData(policies, total).Json(ctx)
+}
+
+// Detail retrieves the details of a specific policy.
+// @Summary Get policy details
+// @Description Retrieves the details of an access policy by its ID.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param policyId path int true "Policy ID"
+// @Success 200 {object} response.Response{data=dto.PolicyDetailResponse} "Success"
+// @Router /system/policy/{policyId} [get]
+func (c *PolicyController) Detail(ctx *gin.Context) {
+	policyId, _ := strconv.Atoi(ctx.Param("policyId"))
+
+	policy := c.PolicyService.GetPolicyByPolicyId(policyId)
+
+	response.NewSuccess().SetData("data", policy).Json(ctx)
+}
+
+// Create adds a new policy.
+// @Summary Add policy
+// @Description Adds a new access policy to the system.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.CreatePolicyRequest true "Policy data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/policy [post]
+func (c *PolicyController) Create(ctx *gin.Context) {
+	var param dto.CreatePolicyRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.CreatePolicyValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if policy := c.PolicyService.GetPolicyByPolicyName(param.PolicyName); policy.PolicyId > 0 {
+		response.NewError().SetMsg("Failed to add policy " + param.PolicyName + ", policy name already exists").Json(ctx)
+		return
+	}
+
+	if err := c.PolicyService.CreatePolicy(dto.SavePolicy{
+		PolicyName: param.PolicyName,
+		Action:     param.Action,
+		Effect:     param.Effect,
+		Condition:  param.Condition,
+		Status:     param.Status,
+		CreateBy:   security.GetAuthUserName(ctx),
+		Remark:     param.Remark,
+	}); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Update modifies an existing policy.
+// @Summary Update policy
+// @Description Modifies an existing access policy in the system.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UpdatePolicyRequest true "Policy data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/policy [put]
+func (c *PolicyController) Update(ctx *gin.Context) {
+	var param dto.UpdatePolicyRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UpdatePolicyValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if policy := c.PolicyService.GetPolicyByPolicyName(param.PolicyName); policy.PolicyId > 0 && policy.PolicyId != param.PolicyId {
+		response.NewError().SetMsg("Failed to modify policy " + param.PolicyName + ", policy name already exists").Json(ctx)
+		return
+	}
+
+	if err := c.PolicyService.UpdatePolicy(dto.SavePolicy{
+		PolicyId:   param.PolicyId,
+		PolicyName: param.PolicyName,
+		Action:     param.Action,
+		Effect:     param.Effect,
+		Condition:  param.Condition,
+		Status:     param.Status,
+		UpdateBy:   security.GetAuthUserName(ctx),
+		Remark:     param.Remark,
+	}); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Remove deletes one or more policies.
+// @Summary Delete policy
+// @Description Deletes access policies by their IDs.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param policyIds path string true "Policy IDs, comma-separated"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/policy/{policyIds} [delete]
+func (c *PolicyController) Remove(ctx *gin.Context) {
+	policyIds, err := utils.StringToIntSlice(ctx.Param("policyIds"), ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.PolicyService.DeletePolicy(policyIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Test evaluates policies for an action and explains the decision.
+// When a condition is given, only that condition is evaluated as an allow policy,
+// which allows trying out an expression before saving it.
+// @Summary Test policies
+// @Description Evaluates access policies for a user, resource and environment and explains the allow/deny decision.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.PolicyTestRequest true "Test input"
+// @Success 200 {object} response.Response{data=dto.PolicyDecisionResponse} "Success"
+// @Router /system/policy/test [post]
+func (c *PolicyController) Test(ctx *gin.Context) {
+	var param dto.PolicyTestRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.PolicyTestValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	input := dto.PolicyContext{
+		UserId:   param.UserId,
+		Resource: param.Resource,
+		Ip:       param.Ip,
+		Time:     time.Now(),
+	}
+
+	if param.Time != "" {
+		t, err := time.ParseInLocation(time.DateTime, param.Time, time.Local)
+		if err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+		input.Time = t
+	}
+
+	var decision dto.PolicyDecisionResponse
+	if param.Condition != "" {
+		decision = c.PolicyService.EvaluatePolicies(param.Action, []dto.PolicyDetailResponse{
+			{PolicyName: "condition", Action: param.Action, Effect: constant.POLICY_EFFECT_ALLOW, Condition: param.Condition},
+		}, input)
+	} else {
+		decision = c.PolicyService.Evaluate(param.Action, input)
+	}
+
+	response.NewSuccess().SetData("data", decision).Json(ctx)
+}
@@ -5,10 +5,12 @@ import (
 	"time"
 
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/security"
 	"mira/app/service"
 	"mira/app/validator"
+	"mira/common/types/constant"
 	"mira/common/utils"
 
 	"gitee.com/hanshuangjianke/go-excel/excel"
@@ -17,12 +19,15 @@ import (
 
 // PostController handles post-related operations.
 type PostController struct {
-	PostService *service.PostService
+	PostService      *service.PostService
+	RoleService      *service.RoleService
+	ImportJobService *service.ImportJobService
+	ExportService    *service.ExportService
 }
 
 // NewPostController creates a new PostController.
-func NewPostController(postService *service.PostService) *PostController {
-	return &PostController{PostService: postService}
+func NewPostController(postService *service.PostService, roleService *service.RoleService, importJobService *service.ImportJobService, exportService *service.ExportService) *PostController {
+	return &PostController{PostService: postService, RoleService: roleService, ImportJobService: importJobService, ExportService: exportService}
 }
 
 // List retrieves a paginated list of posts.
@@ -66,7 +71,7 @@ func (c *PostController) Detail(ctx *gin.Context) {
 
 // Create adds a new post.
 // @Summary Add post
-// @Description Adds a new post to the system.
+// @Description Adds a new post with its superior post and the default roles granted to its holders.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -96,14 +101,20 @@ func (c *PostController) Create(ctx *gin.Context) {
 		return
 	}
 
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
 	if err := c.PostService.CreatePost(dto.SavePost{
+		ParentId: param.ParentId,
 		PostCode: param.PostCode,
 		PostName: param.PostName,
 		PostSort: param.PostSort,
 		Status:   param.Status,
 		CreateBy: security.GetAuthUserName(ctx),
 		Remark:   param.Remark,
-	}); err != nil {
+	}, param.RoleIds); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -113,7 +124,7 @@ func (c *PostController) Create(ctx *gin.Context) {
 
 // Update modifies an existing post.
 // @Summary Update post
-// @Description Modifies an existing post in the system.
+// @Description Modifies an existing post with its superior post and, when given, the default roles granted to its holders.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -143,15 +154,21 @@ func (c *PostController) Update(ctx *gin.Context) {
 		return
 	}
 
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
 	if err := c.PostService.UpdatePost(dto.SavePost{
 		PostId:   param.PostId,
+		ParentId: param.ParentId,
 		PostCode: param.PostCode,
 		PostName: param.PostName,
 		PostSort: param.PostSort,
 		Status:   param.Status,
 		UpdateBy: security.GetAuthUserName(ctx),
 		Remark:   param.Remark,
-	}); err != nil {
+	}, param.RoleIds); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -175,7 +192,7 @@ func (c *PostController) Remove(ctx *gin.Context) {
 		return
 	}
 
-	if err = c.PostService.DeletePost(postIds); err != nil {
+	if err = c.PostService.DeletePost(postIds, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error())
 		return
 	}
@@ -183,14 +200,16 @@ func (c *PostController) Remove(ctx *gin.Context) {
 	response.NewSuccess().Json(ctx)
 }
 
-// Export exports post data to an Excel file.
+// Export exports post data as XLSX, CSV or NDJSON.
 // @Summary Export posts
-// @Description Exports post data to an Excel file based on query parameters.
+// @Description Streams post data matching the query parameters in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
 // @Tags System
 // @Accept json
 // @Produce json
 // @Param query body dto.PostListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /system/post/export [post]
 func (c *PostController) Export(ctx *gin.Context) {
 	var param dto.PostListRequest
@@ -200,18 +219,25 @@ func (c *PostController) Export(ctx *gin.Context) {
 		return
 	}
 
-	list := make([]dto.PostExportResponse, 0)
+	controller.Export(ctx, c.ExportService, c.PostService.ExportDataset(param), "post")
+}
 
-	posts, _ := c.PostService.GetPostList(param, false)
-	for _, post := range posts {
-		list = append(list, dto.PostExportResponse{
-			PostId:   post.PostId,
-			PostCode: post.PostCode,
-			PostName: post.PostName,
-			PostSort: post.PostSort,
-			Status:   post.Status,
-		})
-	}
+// ImportTemplate provides a template for importing post data.
+// @Summary Import post template
+// @Description Downloads an Excel template for importing post data.
+// @Tags System
+// @Produce octet-stream
+// @Success 200 {file} file "Excel template"
+// @Router /system/post/importTemplate [post]
+func (c *PostController) ImportTemplate(ctx *gin.Context) {
+	list := make([]dto.PostImportRequest, 0)
+
+	list = append(list, dto.PostImportRequest{
+		PostCode: "example",
+		PostName: "template",
+		PostSort: 1,
+		Status:   "0",
+	})
 
 	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
 	if err != nil {
@@ -219,5 +245,53 @@ func (c *PostController) Export(ctx *gin.Context) {
 		return
 	}
 
-	excel.DownLoadExcel("post_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	excel.DownLoadExcel("post_template_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// ImportData starts a background import of post data from an Excel file.
+// @Summary Import post data
+// @Description Starts a background import of post data from an Excel file, with an option to update existing posts, and returns the import job ID. Existing posts are matched by post code.
+// @Tags System
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Excel file"
+// @Param updateSupport query bool false "Whether to update existing post data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/post/importData [post]
+func (c *PostController) ImportData(ctx *gin.Context) {
+	startImport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_POST, c.ImportJobService.RunPostImport)
+}
+
+// ImportJob retrieves the progress of a post import job.
+// @Summary Get post import progress
+// @Description Retrieves the status and the processed, succeeded and failed row counts of a post import job.
+// @Tags System
+// @Produce json
+// @Param jobId path int true "Import job ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "Success"
+// @Router /system/post/importJob/{jobId} [get]
+func (c *PostController) ImportJob(ctx *gin.Context) {
+	importJobProgress(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_POST)
+}
+
+// ImportErrorReport downloads the failed rows of a post import job.
+// @Summary Download post import error report
+// @Description Downloads an Excel file with the failed rows of a post import job, each with its row number and error.
+// @Tags System
+// @Produce octet-stream
+// @Param jobId path int true "Import job ID"
+// @Success 200 {file} file "Excel file"
+// @Router /system/post/importJob/{jobId}/errorReport [get]
+func (c *PostController) ImportErrorReport(ctx *gin.Context) {
+	importErrorReport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_POST, "post_import_errors", func(rowNum int, row dto.PostImportRequest, errorMsg string) dto.PostImportErrorResponse {
+		return dto.PostImportErrorResponse{
+			RowNum:   rowNum,
+			PostCode: row.PostCode,
+			PostName: row.PostName,
+			PostSort: row.PostSort,
+			Status:   row.Status,
+			Remark:   row.Remark,
+			ErrorMsg: errorMsg,
+		}
+	})
 }
@@ -0,0 +1,174 @@
+package systemcontroller
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyController handles personal data export and erasure requests.
+type PrivacyController struct {
+	PrivacyService *service.PrivacyService
+}
+
+// NewPrivacyController creates a new PrivacyController.
+func NewPrivacyController(privacyService *service.PrivacyService) *PrivacyController {
+	return &PrivacyController{PrivacyService: privacyService}
+}
+
+// List retrieves a paginated list of privacy requests.
+// @Summary Get privacy request list
+// @Description Retrieves the personal data export and erasure requests, newest first, optionally of one user, type or status.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.PrivacyRequestListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.PrivacyRequestListResponse}} "Success"
+// @Router /system/user/privacy/list [get]
+func (c *PrivacyController) List(ctx *gin.Context) {
+	var param dto.PrivacyRequestListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.PageValidator(param.PageRequest); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.PrivacyRequestListValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	requests, total, err := c.PrivacyService.GetPrivacyRequestList(param)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetPageData(requests, total).Json(ctx)
+}
+
+// Detail retrieves a privacy request with its audit trail.
+// @Summary Privacy request details
+// @Description Retrieves a privacy request with its result and every step of its audit trail.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param requestId path int true "Privacy request ID"
+// @Success 200 {object} response.Response{data=dto.PrivacyRequestDetailResponse} "Success"
+// @Router /system/user/privacy/{requestId} [get]
+func (c *PrivacyController) Detail(ctx *gin.Context) {
+	requestId, _ := strconv.Atoi(ctx.Param("requestId"))
+
+	detail, err := c.PrivacyService.GetPrivacyRequest(requestId)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", detail).Json(ctx)
+}
+
+// Export exports the personal data of a user.
+// @Summary Export personal data
+// @Description Exports the profile, roles, posts, login history, operation logs and avatar of a user as a ZIP archive of JSON and CSV files, recording the request in its audit trail.
+// @Tags System
+// @Accept json
+// @Produce application/zip
+// @Param body body dto.PrivacyExportRequest true "Export request"
+// @Success 200 {file} file "ZIP archive"
+// @Router /system/user/privacy/export [post]
+func (c *PrivacyController) Export(ctx *gin.Context) {
+	var param dto.PrivacyExportRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	c.export(ctx, param)
+}
+
+// ProfileExport exports the personal data of the current user.
+// @Summary Export own personal data
+// @Description Exports the personal data of the current user as a ZIP archive of JSON and CSV files, recording the request in its audit trail.
+// @Tags System
+// @Accept json
+// @Produce application/zip
+// @Param body body dto.PrivacyExportRequest true "Export request, the user ID is ignored"
+// @Success 200 {file} file "ZIP archive"
+// @Router /system/user/profile/privacyExport [post]
+func (c *PrivacyController) ProfileExport(ctx *gin.Context) {
+	var param dto.PrivacyExportRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+	param.UserId = security.GetAuthUserId(ctx)
+
+	c.export(ctx, param)
+}
+
+// export writes the export archive of a user as the response
+func (c *PrivacyController) export(ctx *gin.Context, param dto.PrivacyExportRequest) {
+	if err := validator.PrivacyExportValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	// The archive is written before anything is sent, so a failure can still be reported as JSON
+	var buf bytes.Buffer
+	report, err := c.PrivacyService.ExportUserData(&buf, param, security.GetAuthUserName(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename=personal_data_"+strconv.Itoa(report.UserId)+"_"+time.Now().Format("20060102150405")+".zip")
+	ctx.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// Erase erases the personal data of a user.
+// @Summary Erase personal data
+// @Description Scrubs the personal data of a disabled user. The account is renamed to a pseudonym everywhere it is referred to, its contact details, avatar and custom attributes are removed, and its logs are kept under the pseudonym with pseudonymised IP addresses.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.PrivacyEraseRequest true "Erasure request"
+// @Success 200 {object} response.Response{data=dto.PrivacyEraseReport} "Success"
+// @Router /system/user/privacy/erase [post]
+func (c *PrivacyController) Erase(ctx *gin.Context) {
+	var param dto.PrivacyEraseRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.PrivacyEraseValidator(param, security.GetAuthUserId(ctx)); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	report, err := c.PrivacyService.EraseUserData(param, security.GetAuthUserName(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", report).Json(ctx)
+}
@@ -0,0 +1,125 @@
+package systemcontroller
+
+import (
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecycleController handles the recycle bin of deleted users, roles, departments, posts, menus and dictionaries.
+type RecycleController struct {
+	RecycleService *service.RecycleService
+}
+
+// NewRecycleController creates a new RecycleController.
+func NewRecycleController(recycleService *service.RecycleService) *RecycleController {
+	return &RecycleController{RecycleService: recycleService}
+}
+
+// List retrieves a paginated list of recycle bin entries.
+// @Summary Get recycle bin list
+// @Description Retrieves a paginated list of deleted items, with the time each is due to be purged.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.RecycleListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.RecycleListResponse}} "Success"
+// @Router /system/recycle/list [get]
+func (c *RecycleController) List(ctx *gin.Context) {
+	var param dto.RecycleListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.RecycleListValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	entries, total := c.RecycleService.GetRecycleList(param, true)
+
+	response.NewSuccess().SetPageData(entries, total).Json(ctx)
+}
+
+// Restore restores one or more deleted items.
+// @Summary Restore deleted items
+// @Description Restores deleted items with their links, all or nothing. Fails when a name or key of an item has been reused since its deletion.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param recycleIds path string true "Recycle bin entry IDs, comma-separated"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/recycle/restore/{recycleIds} [put]
+func (c *RecycleController) Restore(ctx *gin.Context) {
+	recycleIds, err := utils.StringToIntSlice(ctx.Param("recycleIds"), ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = validator.RecycleIdsValidator(recycleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.RecycleService.RestoreRecycle(recycleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Purge permanently deletes one or more deleted items.
+// @Summary Purge deleted items
+// @Description Permanently deletes deleted items, which can no longer be restored.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param recycleIds path string true "Recycle bin entry IDs, comma-separated"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/recycle/{recycleIds} [delete]
+func (c *RecycleController) Purge(ctx *gin.Context) {
+	recycleIds, err := utils.StringToIntSlice(ctx.Param("recycleIds"), ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = validator.RecycleIdsValidator(recycleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	purged, err := c.RecycleService.PurgeRecycle(recycleIds)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("purged", purged).Json(ctx)
+}
+
+// PurgeExpired permanently deletes the items that outlived the retention period.
+// @Summary Purge expired deleted items
+// @Description Permanently deletes the items deleted longer ago than the retention period (sys.recycle.retentionDays), as the scheduled purge does.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Response "Success"
+// @Router /system/recycle/expired [delete]
+func (c *RecycleController) PurgeExpired(ctx *gin.Context) {
+	purged, err := c.RecycleService.PurgeExpiredRecycle()
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("purged", purged).Json(ctx)
+}
@@ -7,10 +7,12 @@ import (
 	"time"
 
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/security"
 	"mira/app/service"
 	"mira/app/validator"
+	"mira/common/types/constant"
 	"mira/common/utils"
 
 	"gitee.com/hanshuangjianke/go-excel/excel"
@@ -20,17 +22,23 @@ import (
 
 // RoleController handles role-related operations.
 type RoleController struct {
-	RoleService *service.RoleService
-	DeptService *service.DeptService
-	UserService *service.UserService
+	RoleService      *service.RoleService
+	DeptService      *service.DeptService
+	UserService      *service.UserService
+	ImportJobService *service.ImportJobService
+	ExportService    *service.ExportService
+	UserGroupService *service.UserGroupService
 }
 
 // NewRoleController creates a new RoleController.
-func NewRoleController(roleService *service.RoleService, deptService *service.DeptService, userService *service.UserService) *RoleController {
+func NewRoleController(roleService *service.RoleService, deptService *service.DeptService, userService *service.UserService, importJobService *service.ImportJobService, exportService *service.ExportService, userGroupService *service.UserGroupService) *RoleController {
 	return &RoleController{
-		RoleService: roleService,
-		DeptService: deptService,
-		UserService: userService,
+		RoleService:      roleService,
+		DeptService:      deptService,
+		UserService:      userService,
+		ImportJobService: importJobService,
+		ExportService:    exportService,
+		UserGroupService: userGroupService,
 	}
 }
 
@@ -241,7 +249,7 @@ func (c *RoleController) Remove(ctx *gin.Context) {
 		}
 	}
 
-	if err = c.RoleService.DeleteRole(roleIds); err != nil {
+	if err = c.RoleService.DeleteRole(roleIds, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -302,9 +310,27 @@ func (c *RoleController) DeptTree(ctx *gin.Context) {
 	response.NewSuccess().SetData("depts", tree).SetData("checkedKeys", roleHasDeptIds).Json(ctx)
 }
 
+// GroupSelect retrieves the user groups for a specific role.
+// @Summary Get user groups for role
+// @Description Retrieves the normal user groups and the user group IDs selected by the custom data scope of a specific role.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param roleId path int true "Role ID"
+// @Success 200 {object} response.Response{data=map[string]interface{}} "Success, returns 'groups' and 'checkedKeys'"
+// @Router /system/role/groupSelect/{roleId} [get]
+func (c *RoleController) GroupSelect(ctx *gin.Context) {
+	roleId, _ := strconv.Atoi(ctx.Param("roleId"))
+	roleHasGroupIds := c.UserGroupService.GetGroupIdsByRoleId(roleId)
+
+	groups, _ := c.UserGroupService.GetUserGroupList(dto.UserGroupListRequest{Status: constant.NORMAL_STATUS}, false)
+
+	response.NewSuccess().SetData("groups", groups).SetData("checkedKeys", roleHasGroupIds).Json(ctx)
+}
+
 // DataScope assigns data permissions to a role.
 // @Summary Assign data permissions
-// @Description Assigns data permissions (e.g., department access) to a role.
+// @Description Assigns data permissions (e.g., department or user group access) to a role. User groups are kept unless group IDs are given.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -334,6 +360,13 @@ func (c *RoleController) DataScope(ctx *gin.Context) {
 		return
 	}
 
+	if param.GroupIds != nil {
+		if err := c.UserGroupService.UpdateRoleGroups(param.RoleId, param.GroupIds); err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+	}
+
 	response.NewSuccess().Json(ctx)
 }
 
@@ -468,14 +501,16 @@ func (c *RoleController) RoleAuthUserCancelAll(ctx *gin.Context) {
 	response.NewSuccess().Json(ctx)
 }
 
-// Export exports role data to an Excel file.
+// Export exports role data as XLSX, CSV or NDJSON.
 // @Summary Export roles
-// @Description Exports role data to an Excel file based on query parameters.
+// @Description Streams role data matching the query parameters in the requested format, columns and header language. Large exports run as a background job and respond with its ID.
 // @Tags System
 // @Accept json
 // @Produce json
 // @Param query body dto.RoleListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /system/role/export [post]
 func (c *RoleController) Export(ctx *gin.Context) {
 	var param dto.RoleListRequest
@@ -485,19 +520,26 @@ func (c *RoleController) Export(ctx *gin.Context) {
 		return
 	}
 
-	list := make([]dto.RoleExportResponse, 0)
+	controller.Export(ctx, c.ExportService, c.RoleService.ExportDataset(param), "role")
+}
 
-	roles, _ := c.RoleService.GetRoleList(param, false)
-	for _, role := range roles {
-		list = append(list, dto.RoleExportResponse{
-			RoleId:    role.RoleId,
-			RoleName:  role.RoleName,
-			RoleKey:   role.RoleKey,
-			RoleSort:  role.RoleSort,
-			DataScope: role.DataScope,
-			Status:    role.Status,
-		})
-	}
+// ImportTemplate provides a template for importing role data.
+// @Summary Import role template
+// @Description Downloads an Excel template for importing role data.
+// @Tags System
+// @Produce octet-stream
+// @Success 200 {file} file "Excel template"
+// @Router /system/role/importTemplate [post]
+func (c *RoleController) ImportTemplate(ctx *gin.Context) {
+	list := make([]dto.RoleImportRequest, 0)
+
+	list = append(list, dto.RoleImportRequest{
+		RoleName: "template",
+		RoleKey:  "example",
+		RoleSort: 1,
+		Status:   "0",
+		Perms:    "system:user:list,system:user:query",
+	})
 
 	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
 	if err != nil {
@@ -505,5 +547,54 @@ func (c *RoleController) Export(ctx *gin.Context) {
 		return
 	}
 
-	excel.DownLoadExcel("role_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	excel.DownLoadExcel("role_template_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+}
+
+// ImportData starts a background import of role data from an Excel file.
+// @Summary Import role data
+// @Description Starts a background import of role data from an Excel file, with an option to update existing roles, and returns the import job ID. Existing roles are matched by role key, and menus are granted by comma-separated permission strings.
+// @Tags System
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Excel file"
+// @Param updateSupport query bool false "Whether to update existing role data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/role/importData [post]
+func (c *RoleController) ImportData(ctx *gin.Context) {
+	startImport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_ROLE, c.ImportJobService.RunRoleImport)
+}
+
+// ImportJob retrieves the progress of a role import job.
+// @Summary Get role import progress
+// @Description Retrieves the status and the processed, succeeded and failed row counts of a role import job.
+// @Tags System
+// @Produce json
+// @Param jobId path int true "Import job ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "Success"
+// @Router /system/role/importJob/{jobId} [get]
+func (c *RoleController) ImportJob(ctx *gin.Context) {
+	importJobProgress(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_ROLE)
+}
+
+// ImportErrorReport downloads the failed rows of a role import job.
+// @Summary Download role import error report
+// @Description Downloads an Excel file with the failed rows of a role import job, each with its row number and error.
+// @Tags System
+// @Produce octet-stream
+// @Param jobId path int true "Import job ID"
+// @Success 200 {file} file "Excel file"
+// @Router /system/role/importJob/{jobId}/errorReport [get]
+func (c *RoleController) ImportErrorReport(ctx *gin.Context) {
+	importErrorReport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_ROLE, "role_import_errors", func(rowNum int, row dto.RoleImportRequest, errorMsg string) dto.RoleImportErrorResponse {
+		return dto.RoleImportErrorResponse{
+			RowNum:   rowNum,
+			RoleName: row.RoleName,
+			RoleKey:  row.RoleKey,
+			RoleSort: row.RoleSort,
+			Status:   row.Status,
+			Perms:    row.Perms,
+			Remark:   row.Remark,
+			ErrorMsg: errorMsg,
+		}
+	})
 }
@@ -0,0 +1,53 @@
+package systemcontroller
+
+import (
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchController handles the unified search of the admin pages.
+// Each user only finds what they may list, within their data scope.
+type SearchController struct {
+	SearchService *service.SearchService
+}
+
+// NewSearchController creates a new SearchController.
+func NewSearchController(searchService *service.SearchService) *SearchController {
+	return &SearchController{SearchService: searchService}
+}
+
+// Search searches users, departments, roles, posts, menus and configs.
+// @Summary Unified search
+// @Description Searches users by name, nickname, phone number and email, departments, roles, posts, menus by name and permission, and configs by name and key in one call. Items are ranked by score; users and departments are limited to the data scope of the current user, menus to the ones granted to the user, and the other types to users allowed to list them. Types is a comma-separated list, all types by default.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.SearchRequest true "Search parameters"
+// @Success 200 {object} response.Response{data=dto.SearchResponse} "Success"
+// @Router /system/search [get]
+func (c *SearchController) Search(ctx *gin.Context) {
+	var param dto.SearchRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.SearchValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	result, err := c.SearchService.SearchWithErr(param, security.GetAuthUserId(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", result).Json(ctx)
+}
@@ -0,0 +1,182 @@
+package systemcontroller
+
+import (
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserAttrController handles custom user attribute operations.
+type UserAttrController struct {
+	UserAttrService *service.UserAttrService
+}
+
+// NewUserAttrController creates a new UserAttrController.
+func NewUserAttrController(userAttrService *service.UserAttrService) *UserAttrController {
+	return &UserAttrController{UserAttrService: userAttrService}
+}
+
+// List retrieves a paginated list of custom user attributes.
+// @Summary Get user attribute list
+// @Description Retrieves a paginated list of custom user attribute definitions based on query parameters.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.UserAttrListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.UserAttrListResponse}} "Success"
+// @Router /system/userAttr/list [get]
+func (c *UserAttrController) List(ctx *gin.Context) {
+	var param dto.UserAttrListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	attrs, total := c.UserAttrService.GetUserAttrList(param, true)
+
+	response.NewSuccess().SetPageData(attrs, total).Json(ctx)
+}
+
+// Detail retrieves the details of a specific custom user attribute.
+// @Summary Get user attribute details
+// @Description Retrieves the definition of a custom user attribute by its ID.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param attrId path int true "User attribute ID"
+// @Success 200 {object} response.Response{data=dto.UserAttrDetailResponse} "Success"
+// @Router /system/userAttr/{attrId} [get]
+func (c *UserAttrController) Detail(ctx *gin.Context) {
+	attrId, _ := strconv.Atoi(ctx.Param("attrId"))
+
+	attr := c.UserAttrService.GetUserAttrByAttrId(attrId)
+
+	response.NewSuccess().SetData("data", attr).Json(ctx)
+}
+
+// Create adds a new custom user attribute.
+// @Summary Add user attribute
+// @Description Adds a custom user attribute with its type, validation pattern, dictionary and whether it is required or searchable.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateUserAttrRequest true "User attribute data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/userAttr [post]
+func (c *UserAttrController) Create(ctx *gin.Context) {
+	var param dto.CreateUserAttrRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.CreateUserAttrValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if attr := c.UserAttrService.GetUserAttrByAttrKey(param.AttrKey); attr.AttrId > 0 {
+		response.NewError().SetMsg("Failed to add user attribute " + param.AttrLabel + ", attribute key already exists").Json(ctx)
+		return
+	}
+
+	if err := c.UserAttrService.CreateUserAttr(dto.SaveUserAttr{
+		AttrKey:      param.AttrKey,
+		AttrLabel:    param.AttrLabel,
+		AttrType:     param.AttrType,
+		Pattern:      param.Pattern,
+		DictType:     param.DictType,
+		IsRequired:   param.IsRequired,
+		IsSearchable: param.IsSearchable,
+		AttrSort:     param.AttrSort,
+		Status:       param.Status,
+		CreateBy:     security.GetAuthUserName(ctx),
+		Remark:       param.Remark,
+	}); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Update modifies an existing custom user attribute.
+// @Summary Update user attribute
+// @Description Modifies the definition of a custom user attribute. The values users hold are kept.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UpdateUserAttrRequest true "User attribute data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/userAttr [put]
+func (c *UserAttrController) Update(ctx *gin.Context) {
+	var param dto.UpdateUserAttrRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UpdateUserAttrValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if attr := c.UserAttrService.GetUserAttrByAttrKey(param.AttrKey); attr.AttrId > 0 && attr.AttrId != param.AttrId {
+		response.NewError().SetMsg("Failed to modify user attribute " + param.AttrLabel + ", attribute key already exists").Json(ctx)
+		return
+	}
+
+	if err := c.UserAttrService.UpdateUserAttr(dto.SaveUserAttr{
+		AttrId:       param.AttrId,
+		AttrKey:      param.AttrKey,
+		AttrLabel:    param.AttrLabel,
+		AttrType:     param.AttrType,
+		Pattern:      param.Pattern,
+		DictType:     param.DictType,
+		IsRequired:   param.IsRequired,
+		IsSearchable: param.IsSearchable,
+		AttrSort:     param.AttrSort,
+		Status:       param.Status,
+		UpdateBy:     security.GetAuthUserName(ctx),
+		Remark:       param.Remark,
+	}); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Remove deletes one or more custom user attributes.
+// @Summary Delete user attribute
+// @Description Deletes custom user attributes by their IDs, together with the values users hold for them.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param attrIds path string true "User attribute IDs, comma-separated"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/userAttr/{attrIds} [delete]
+func (c *UserAttrController) Remove(ctx *gin.Context) {
+	attrIds, err := utils.StringToIntSlice(ctx.Param("attrIds"), ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.UserAttrService.DeleteUserAttr(attrIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
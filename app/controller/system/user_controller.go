@@ -3,44 +3,49 @@ package systemcontroller
 import (
 	"fmt"
 	"io"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"mira/anima/response"
+	"mira/app/controller"
 	"mira/app/dto"
 	"mira/app/security"
 	"mira/app/service"
 	"mira/app/validator"
 	"mira/common/password"
+	"mira/common/types/constant"
 	"mira/common/upload"
 	"mira/common/utils"
 	"mira/common/xerrors"
-	"mira/config"
 
 	"gitee.com/hanshuangjianke/go-excel/excel"
 	"github.com/gin-gonic/gin"
-	excelize "github.com/xuri/excelize/v2"
 )
 
 // UserController handles user-related operations.
 type UserController struct {
-	UserService   *service.UserService
-	DeptService   *service.DeptService
-	RoleService   *service.RoleService
-	PostService   *service.PostService
-	ConfigService *service.ConfigService
+	UserService      *service.UserService
+	DeptService      *service.DeptService
+	RoleService      *service.RoleService
+	PostService      *service.PostService
+	ConfigService    *service.ConfigService
+	ImportJobService *service.ImportJobService
+	ExportService    *service.ExportService
+	UserAttrService  *service.UserAttrService
 }
 
 // NewUserController creates a new UserController.
-func NewUserController(userService *service.UserService, deptService *service.DeptService, roleService *service.RoleService, postService *service.PostService, configService *service.ConfigService) *UserController {
+func NewUserController(userService *service.UserService, deptService *service.DeptService, roleService *service.RoleService, postService *service.PostService, configService *service.ConfigService, importJobService *service.ImportJobService, exportService *service.ExportService, userAttrService *service.UserAttrService) *UserController {
 	return &UserController{
-		UserService:   userService,
-		DeptService:   deptService,
-		RoleService:   roleService,
-		PostService:   postService,
-		ConfigService: configService,
+		UserService:      userService,
+		DeptService:      deptService,
+		RoleService:      roleService,
+		PostService:      postService,
+		ConfigService:    configService,
+		ImportJobService: importJobService,
+		ExportService:    exportService,
+		UserAttrService:  userAttrService,
 	}
 }
 
@@ -53,6 +58,22 @@ func NewUserController(userService *service.UserService, deptService *service.De
 // @Success 200 {object} response.Response{data=[]dto.DeptTreeResponse} "Success"
 // @Router /system/user/deptTree [get]
 func (c *UserController) DeptTree(ctx *gin.Context) {
+	if scope := security.GetDelegationScope(ctx); scope != nil {
+		// Delegates see the delegated subtrees only, each rooted at its delegated department
+		depts := utils.Filter(c.DeptService.GetUserDeptTree(1), func(dept dto.DeptTreeResponse) bool {
+			return utils.Contains(scope.DeptIds, dept.Id)
+		})
+		tree := make([]dto.DeptTreeResponse, 0)
+		for _, dept := range depts {
+			if !utils.Contains(scope.DeptIds, dept.ParentId) {
+				dept.Children = c.UserService.DeptListToTree(depts, dept.Id)
+				tree = append(tree, dept)
+			}
+		}
+		response.NewSuccess().SetData("data", tree).Json(ctx)
+		return
+	}
+
 	depts := c.DeptService.GetUserDeptTree(security.GetAuthUserId(ctx))
 
 	tree := c.UserService.DeptListToTree(depts, 0)
@@ -62,7 +83,7 @@ func (c *UserController) DeptTree(ctx *gin.Context) {
 
 // List retrieves a paginated list of users.
 // @Summary Get user list
-// @Description Retrieves a paginated list of users based on query parameters.
+// @Description Retrieves a paginated list of users based on query parameters. Searchable custom attributes are filtered by attrs[key]=value parameters. Given a cursor or limit, the list is paged by keyset and returns the nextCursor of the following page.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -76,20 +97,51 @@ func (c *UserController) List(ctx *gin.Context) {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
+	if err := validator.PageValidator(param.PageRequest); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+	param.Attrs = userAttrFilters(ctx)
 
-	users, total := c.UserService.GetUserList(param, security.GetAuthUserId(ctx), true)
+	if scope := security.GetDelegationScope(ctx); scope != nil {
+		param.DeptIds = scope.DeptIds
+	}
+
+	if param.OrderByColumn != "" {
+		param.OrderRule, param.OrderByColumn = utils.ParseSort(param.IsAsc, param.OrderByColumn, "userId")
+	}
+
+	var (
+		users []dto.UserListResponse
+		total int
+		next  string
+		err   error
+	)
+	if param.IsKeyset() {
+		users, total, next, err = c.UserService.GetUserPage(param, security.GetAuthUserId(ctx))
+	} else {
+		users, total, err = c.UserService.GetUserListWithErr(param, security.GetAuthUserId(ctx), true)
+	}
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
 
 	for key, user := range users {
 		users[key].Dept.DeptName = user.DeptName
 		users[key].Dept.Leader = user.Leader
 	}
 
-	response.NewSuccess().SetPageData(users, total).Json(ctx)
+	resp := response.NewSuccess().SetPageData(users, total)
+	if param.IsKeyset() {
+		resp.SetData("nextCursor", next)
+	}
+	resp.Json(ctx)
 }
 
 // Detail retrieves the details of a specific user.
 // @Summary User details
-// @Description Retrieves comprehensive details for a specific user, including roles, posts, and department info.
+// @Description Retrieves comprehensive details for a specific user, including roles, posts, department info and custom attributes, with the enabled custom attribute definitions.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -102,8 +154,14 @@ func (c *UserController) Detail(ctx *gin.Context) {
 	resp := response.NewSuccess()
 
 	if userId > 0 {
+		if err := c.UserService.CheckDelegatedUser(security.GetAuthUserId(ctx), security.GetDelegationScope(ctx), userId, 0, nil, nil); err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+
 		user := c.UserService.GetUserByUserId(userId)
 		user.Admin = user.UserId == 1
+		user.Attributes = c.UserAttrService.GetUserAttrValues(user.UserId)
 		dept := c.DeptService.GetDeptByDeptId(user.DeptId)
 		roles, err := c.RoleService.GetRoleListByUserId(user.UserId)
 		if err != nil {
@@ -133,11 +191,14 @@ func (c *UserController) Detail(ctx *gin.Context) {
 			return role.RoleId != 1
 		})
 	}
+	roles = c.filterDelegatedRoles(ctx, roles)
 	resp.SetData("roles", roles)
 
 	posts, _ := c.PostService.GetPostList(dto.PostListRequest{}, false)
 	resp.SetData("posts", posts)
 
+	resp.SetData("attrs", c.UserAttrService.GetActiveUserAttrs())
+
 	resp.Json(ctx)
 }
 
@@ -182,6 +243,11 @@ func (c *UserController) Create(ctx *gin.Context) {
 		}
 	}
 
+	if err := c.UserAttrService.CheckUserAttrValues(param.Attributes); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
 	hashedPassword, err := password.Generate(param.Password)
 	if err != nil {
 		response.NewError().SetCode(500).SetMsg("Failed to process password").Json(ctx)
@@ -198,6 +264,9 @@ func (c *UserController) Create(ctx *gin.Context) {
 		Status:      param.Status,
 		Remark:      param.Remark,
 		CreateBy:    security.GetAuthUserName(ctx),
+		Attributes:  param.Attributes,
+		OperatorId:  security.GetAuthUserId(ctx),
+		Delegation:  security.GetDelegationScope(ctx),
 	}, param.RoleIds, param.PostIds); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
@@ -208,7 +277,7 @@ func (c *UserController) Create(ctx *gin.Context) {
 
 // Update modifies an existing user.
 // @Summary Update user
-// @Description Modifies an existing user's information, roles, and posts.
+// @Description Modifies an existing user's information, roles, posts and, when given, custom attributes.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -242,6 +311,13 @@ func (c *UserController) Update(ctx *gin.Context) {
 		}
 	}
 
+	if param.Attributes != nil {
+		if err := c.UserAttrService.CheckUserAttrValues(param.Attributes); err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			return
+		}
+	}
+
 	if err := c.UserService.UpdateUser(dto.SaveUser{
 		UserId:      param.UserId,
 		DeptId:      param.DeptId,
@@ -252,6 +328,9 @@ func (c *UserController) Update(ctx *gin.Context) {
 		Status:      param.Status,
 		Remark:      param.Remark,
 		UpdateBy:    security.GetAuthUserName(ctx),
+		Attributes:  param.Attributes,
+		OperatorId:  security.GetAuthUserId(ctx),
+		Delegation:  security.GetDelegationScope(ctx),
 	}, param.RoleIds, param.PostIds); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
@@ -281,7 +360,7 @@ func (c *UserController) Remove(ctx *gin.Context) {
 		return
 	}
 
-	if err = c.UserService.DeleteUser(userIds); err != nil {
+	if err = c.UserService.DeleteUser(userIds, security.GetAuthUserName(ctx)); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
 	}
@@ -312,9 +391,11 @@ func (c *UserController) ChangeStatus(ctx *gin.Context) {
 	}
 
 	if err := c.UserService.UpdateUser(dto.SaveUser{
-		UserId:   param.UserId,
-		Status:   param.Status,
-		UpdateBy: security.GetAuthUserName(ctx),
+		UserId:     param.UserId,
+		Status:     param.Status,
+		UpdateBy:   security.GetAuthUserName(ctx),
+		OperatorId: security.GetAuthUserId(ctx),
+		Delegation: security.GetDelegationScope(ctx),
 	}, nil, nil); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
@@ -351,9 +432,11 @@ func (c *UserController) ResetPwd(ctx *gin.Context) {
 		return
 	}
 	if err := c.UserService.UpdateUser(dto.SaveUser{
-		UserId:   param.UserId,
-		Password: hashedPassword,
-		UpdateBy: security.GetAuthUserName(ctx),
+		UserId:     param.UserId,
+		Password:   hashedPassword,
+		UpdateBy:   security.GetAuthUserName(ctx),
+		OperatorId: security.GetAuthUserId(ctx),
+		Delegation: security.GetDelegationScope(ctx),
 	}, nil, nil); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
@@ -374,6 +457,11 @@ func (c *UserController) ResetPwd(ctx *gin.Context) {
 func (c *UserController) AuthRole(ctx *gin.Context) {
 	userId, _ := strconv.Atoi(ctx.Param("userId"))
 
+	if err := c.UserService.CheckDelegatedUser(security.GetAuthUserId(ctx), security.GetDelegationScope(ctx), userId, 0, nil, nil); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
 	resp := response.NewSuccess()
 
 	var userHasRoleIds []int
@@ -410,6 +498,7 @@ func (c *UserController) AuthRole(ctx *gin.Context) {
 			}
 		}
 	}
+	roles = c.filterDelegatedRoles(ctx, roles)
 	resp.SetData("roles", roles)
 
 	resp.Json(ctx)
@@ -438,6 +527,11 @@ func (c *UserController) AddAuthRole(ctx *gin.Context) {
 		return
 	}
 
+	if err := c.UserService.CheckDelegatedUser(security.GetAuthUserId(ctx), security.GetDelegationScope(ctx), param.UserId, 0, roleIds, nil); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
 	if err := c.UserService.AddAuthRole(param.UserId, roleIds); err != nil {
 		response.NewError().SetMsg(err.Error()).Json(ctx)
 		return
@@ -446,6 +540,29 @@ func (c *UserController) AddAuthRole(ctx *gin.Context) {
 	response.NewSuccess().Json(ctx)
 }
 
+// userAttrFilters collects the custom attribute filters given as attrs[key]=value in the query or the form
+func userAttrFilters(ctx *gin.Context) map[string]string {
+	filters := ctx.QueryMap("attrs")
+	for key, value := range ctx.PostFormMap("attrs") {
+		filters[key] = value
+	}
+
+	return filters
+}
+
+// filterDelegatedRoles keeps the roles a delegate may assign when the request is
+// authorized by a department delegation.
+func (c *UserController) filterDelegatedRoles(ctx *gin.Context, roles []dto.RoleListResponse) []dto.RoleListResponse {
+	scope := security.GetDelegationScope(ctx)
+	if scope == nil {
+		return roles
+	}
+
+	return utils.Filter(roles, func(role dto.RoleListResponse) bool {
+		return utils.Contains(scope.RoleIds, role.RoleId)
+	})
+}
+
 // ImportTemplate provides a template for importing user data.
 // @Summary Import user template
 // @Description Downloads an Excel template for importing user data.
@@ -456,6 +573,12 @@ func (c *UserController) AddAuthRole(ctx *gin.Context) {
 func (c *UserController) ImportTemplate(ctx *gin.Context) {
 	list := make([]dto.UserImportRequest, 0)
 
+	// Custom attributes are written as key=value pairs separated by semicolons
+	attrPairs := make([]string, 0)
+	for _, attr := range c.UserAttrService.GetActiveUserAttrs() {
+		attrPairs = append(attrPairs, attr.AttrKey+"=")
+	}
+
 	list = append(list, dto.UserImportRequest{
 		DeptId:      1,
 		UserName:    "example",
@@ -464,6 +587,9 @@ func (c *UserController) ImportTemplate(ctx *gin.Context) {
 		Phonenumber: "12345678901",
 		Sex:         "1",
 		Status:      "0",
+		RoleNames:   "Common",
+		PostNames:   "CEO",
+		Attributes:  strings.Join(attrPairs, ";"),
 	})
 
 	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
@@ -475,9 +601,9 @@ func (c *UserController) ImportTemplate(ctx *gin.Context) {
 	excel.DownLoadExcel("user_template_"+time.Now().Format("20060102150405"), ctx.Writer, file)
 }
 
-// ImportData imports user data from an Excel file.
+// ImportData starts a background import of user data from an Excel file.
 // @Summary Import user data
-// @Description Imports user data from an Excel file, with an option to update existing users.
+// @Description Starts a background import of user data from an Excel file, with an option to update existing users, and returns the import job ID. Roles and posts are assigned by name, custom attributes as key=value pairs separated by semicolons.
 // @Tags System
 // @Accept multipart/form-data
 // @Produce json
@@ -486,138 +612,58 @@ func (c *UserController) ImportTemplate(ctx *gin.Context) {
 // @Success 200 {object} response.Response "Success"
 // @Router /system/user/importData [post]
 func (c *UserController) ImportData(ctx *gin.Context) {
-	file, err := ctx.FormFile("file")
-	if err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
-
-	fileName := config.Data.Ruoyi.UploadPath + file.Filename
-
-	// Temporarily save the file
-	ctx.SaveUploadedFile(file, fileName)
-	defer os.Remove(fileName)
-
-	// Whether to update existing user data
-	updateSupport, _ := strconv.ParseBool(ctx.Query("updateSupport"))
-
-	excelFile, err := excelize.OpenFile(fileName)
-	if err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
-
-	list := make([]dto.UserImportRequest, 0)
-
-	if err = excel.ImportExcel(excelFile, &list, 0, 1); err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
-
-	if len(list) <= 0 {
-		response.NewError().SetMsg("Import user data cannot be empty").Json(ctx)
-		return
-	}
-
-	var successNum, failNum int
-	var failMsg []string
-
-	authUserName := security.GetAuthUserName(ctx)
+	startImport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_USER, c.ImportJobService.RunUserImport)
+}
 
-	for _, item := range list {
-		user := c.UserService.GetUserByUsername(item.UserName)
+// ImportJob retrieves the progress of a user import job.
+// @Summary Get user import progress
+// @Description Retrieves the status and the processed, succeeded and failed row counts of a user import job.
+// @Tags System
+// @Produce json
+// @Param jobId path int true "Import job ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobResponse} "Success"
+// @Router /system/user/importJob/{jobId} [get]
+func (c *UserController) ImportJob(ctx *gin.Context) {
+	importJobProgress(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_USER)
+}
 
-		// Insert new user
-		if user.UserId <= 0 {
-			if err = validator.ImportUserValidator(dto.CreateUserRequest{
-				DeptId:      item.DeptId,
-				UserName:    item.UserName,
-				NickName:    item.NickName,
-				Email:       item.Email,
-				Phonenumber: item.Phonenumber,
-				Sex:         item.Sex,
-				Status:      item.Status,
-			}); err != nil {
-				failNum++
-				failMsg = append(failMsg, strconv.Itoa(failNum)+", Account "+item.UserName+" failed to be added: "+err.Error())
-				continue
-			}
-			hashedPassword, err := password.Generate(c.ConfigService.GetConfigCacheByConfigKey("sys.user.initPassword").ConfigValue)
-			if err != nil {
-				failNum++
-				failMsg = append(failMsg, strconv.Itoa(failNum)+", Account "+item.UserName+" failed to be added: "+err.Error())
-				continue
-			}
-			if err = c.UserService.CreateUser(dto.SaveUser{
-				DeptId:      item.DeptId,
-				UserName:    item.UserName,
-				NickName:    item.NickName,
-				Email:       item.Email,
-				Phonenumber: item.Phonenumber,
-				Sex:         item.Sex,
-				Password:    hashedPassword,
-				Status:      item.Status,
-				CreateBy:    authUserName,
-			}, nil, nil); err != nil {
-				failNum++
-				failMsg = append(failMsg, strconv.Itoa(failNum)+", Account "+item.UserName+" failed to be added: "+err.Error())
-				continue
-			}
-			successNum++
-			continue
-		} else if updateSupport {
-			if err = validator.UpdateUserValidator(dto.UpdateUserRequest{
-				UserId:      user.UserId,
-				DeptId:      item.DeptId,
-				NickName:    item.NickName,
-				Email:       item.Email,
-				Phonenumber: item.Phonenumber,
-				Sex:         item.Sex,
-				Status:      item.Status,
-			}); err != nil {
-				failNum++
-				failMsg = append(failMsg, strconv.Itoa(failNum)+", Account "+item.UserName+" failed to be updated: "+err.Error())
-				continue
-			}
-			// Update existing user
-			if err = c.UserService.UpdateUser(dto.SaveUser{
-				UserId:      user.UserId,
-				DeptId:      item.DeptId,
-				NickName:    item.NickName,
-				Email:       item.Email,
-				Phonenumber: item.Phonenumber,
-				Sex:         item.Sex,
-				Status:      item.Status,
-				UpdateBy:    authUserName,
-			}, nil, nil); err != nil {
-				failNum++
-				failMsg = append(failMsg, strconv.Itoa(failNum)+", Account "+item.UserName+" failed to be updated: "+err.Error())
-				continue
-			}
-			successNum++
-			continue
-		} else {
-			failNum++
-			failMsg = append(failMsg, strconv.Itoa(failNum)+", Account "+item.UserName+" already exists")
+// ImportErrorReport downloads the failed rows of a user import job.
+// @Summary Download user import error report
+// @Description Downloads an Excel file with the failed rows of a user import job, each with its row number and error.
+// @Tags System
+// @Produce octet-stream
+// @Param jobId path int true "Import job ID"
+// @Success 200 {file} file "Excel file"
+// @Router /system/user/importJob/{jobId}/errorReport [get]
+func (c *UserController) ImportErrorReport(ctx *gin.Context) {
+	importErrorReport(ctx, c.ImportJobService, constant.IMPORT_JOB_TYPE_USER, "user_import_errors", func(rowNum int, row dto.UserImportRequest, errorMsg string) dto.UserImportErrorResponse {
+		return dto.UserImportErrorResponse{
+			RowNum:      rowNum,
+			DeptId:      row.DeptId,
+			UserName:    row.UserName,
+			NickName:    row.NickName,
+			Email:       row.Email,
+			Phonenumber: row.Phonenumber,
+			Sex:         row.Sex,
+			Status:      row.Status,
+			RoleNames:   row.RoleNames,
+			PostNames:   row.PostNames,
+			Attributes:  row.Attributes,
+			ErrorMsg:    errorMsg,
 		}
-	}
-
-	if failNum > 0 {
-		response.NewError().SetMsg("Import failed, " + strconv.Itoa(failNum) + " pieces of data are wrong, the errors are as follows:" + strings.Join(failMsg, "<br/>")).Json(ctx)
-		return
-	}
-
-	response.NewSuccess().SetMsg("Import successful, " + strconv.Itoa(successNum) + " pieces of data in total").Json(ctx)
+	})
 }
 
-// Export exports user data to an Excel file.
+// Export exports user data as XLSX, CSV or NDJSON.
 // @Summary Export user data
-// @Description Exports user data to an Excel file based on query parameters.
+// @Description Streams user data matching the query parameters in the requested format, columns and header language, with a column per enabled custom attribute. Large exports run as a background job and respond with its ID.
 // @Tags System
 // @Accept json
 // @Produce json
 // @Param query body dto.UserListRequest true "Query parameters"
-// @Success 200 {file} file "Excel file"
+// @Param options body dto.ExportRequest false "Export options"
+// @Success 200 {file} file "Export file"
+// @Success 200 {object} response.Response{data=map[string]int} "Export job ID"
 // @Router /system/user/export [post]
 func (c *UserController) Export(ctx *gin.Context) {
 	var param dto.UserListRequest
@@ -627,43 +673,14 @@ func (c *UserController) Export(ctx *gin.Context) {
 		return
 	}
 
-	list := make([]dto.UserExportResponse, 0)
-
-	users, _ := c.UserService.GetUserList(param, security.GetAuthUserId(ctx), false)
-	for _, user := range users {
-
-		loginDate := user.LoginDate.Format("2006-01-02 15:04:05")
-		if user.LoginDate.IsZero() {
-			loginDate = ""
-		}
-
-		list = append(list, dto.UserExportResponse{
-			UserId:      user.UserId,
-			UserName:    user.UserName,
-			NickName:    user.NickName,
-			Email:       user.Email,
-			Phonenumber: user.Phonenumber,
-			Sex:         user.Sex,
-			Status:      user.Status,
-			LoginIp:     user.LoginIp,
-			LoginDate:   loginDate,
-			DeptName:    user.DeptName,
-			DeptLeader:  user.Leader,
-		})
-	}
-
-	file, err := excel.NormalDynamicExport("Sheet1", "", "", false, false, list, nil)
-	if err != nil {
-		response.NewError().SetMsg(err.Error()).Json(ctx)
-		return
-	}
+	param.Attrs = userAttrFilters(ctx)
 
-	excel.DownLoadExcel("user_"+time.Now().Format("20060102150405"), ctx.Writer, file)
+	controller.Export(ctx, c.ExportService, c.UserService.ExportDataset(param, security.GetAuthUserId(ctx)), "user")
 }
 
 // GetProfile retrieves the profile of the currently authenticated user.
 // @Summary Get personal information
-// @Description Retrieves the profile, roles, and posts of the currently authenticated user.
+// @Description Retrieves the profile, roles, posts and custom attributes of the currently authenticated user.
 // @Tags System
 // @Accept json
 // @Produce json
@@ -672,6 +689,7 @@ func (c *UserController) Export(ctx *gin.Context) {
 func (c *UserController) GetProfile(ctx *gin.Context) {
 	user := c.UserService.GetUserByUserId(security.GetAuthUserId(ctx))
 	user.Admin = user.UserId == 1
+	user.Attributes = c.UserAttrService.GetUserAttrValues(user.UserId)
 	dept := c.DeptService.GetDeptByDeptId(user.DeptId)
 	roles, err := c.RoleService.GetRoleListByUserId(user.UserId)
 	if err != nil {
@@ -0,0 +1,297 @@
+package systemcontroller
+
+import (
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+	"mira/common/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserGroupController handles user group operations.
+type UserGroupController struct {
+	UserGroupService *service.UserGroupService
+	RoleService      *service.RoleService
+}
+
+// NewUserGroupController creates a new UserGroupController.
+func NewUserGroupController(userGroupService *service.UserGroupService, roleService *service.RoleService) *UserGroupController {
+	return &UserGroupController{UserGroupService: userGroupService, RoleService: roleService}
+}
+
+// List retrieves a paginated list of user groups.
+// @Summary Get user group list
+// @Description Retrieves a paginated list of user groups with their member counts based on query parameters.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.UserGroupListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.UserGroupListResponse}} "Success"
+// @Router /system/group/list [get]
+func (c *UserGroupController) List(ctx *gin.Context) {
+	var param dto.UserGroupListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	groups, total := c.UserGroupService.GetUserGroupList(param, true)
+
+	response.NewSuccess().SetPageData(groups, total).Json(ctx)
+}
+
+// Detail retrieves the details of a specific user group.
+// @Summary Get user group details
+// @Description Retrieves the details of a user group, including its role IDs, by its ID.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param groupId path int true "User group ID"
+// @Success 200 {object} response.Response{data=dto.UserGroupDetailResponse} "Success"
+// @Router /system/group/{groupId} [get]
+func (c *UserGroupController) Detail(ctx *gin.Context) {
+	groupId, _ := strconv.Atoi(ctx.Param("groupId"))
+
+	group := c.UserGroupService.GetUserGroupByGroupId(groupId)
+
+	response.NewSuccess().SetData("data", group).Json(ctx)
+}
+
+// Create adds a new user group.
+// @Summary Add user group
+// @Description Adds a new user group with the roles its members hold.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.CreateUserGroupRequest true "User group data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/group [post]
+func (c *UserGroupController) Create(ctx *gin.Context) {
+	var param dto.CreateUserGroupRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.CreateUserGroupValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if group := c.UserGroupService.GetUserGroupByGroupName(param.GroupName); group.GroupId > 0 {
+		response.NewError().SetMsg("Failed to add user group " + param.GroupName + ", group name already exists").Json(ctx)
+		return
+	}
+
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.UserGroupService.CreateUserGroup(dto.SaveUserGroup{
+		GroupName: param.GroupName,
+		GroupSort: param.GroupSort,
+		Status:    param.Status,
+		CreateBy:  security.GetAuthUserName(ctx),
+		Remark:    param.Remark,
+	}, param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Update modifies an existing user group.
+// @Summary Update user group
+// @Description Modifies an existing user group and, when role IDs are given, replaces its roles.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UpdateUserGroupRequest true "User group data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/group [put]
+func (c *UserGroupController) Update(ctx *gin.Context) {
+	var param dto.UpdateUserGroupRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UpdateUserGroupValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if group := c.UserGroupService.GetUserGroupByGroupName(param.GroupName); group.GroupId > 0 && group.GroupId != param.GroupId {
+		response.NewError().SetMsg("Failed to modify user group " + param.GroupName + ", group name already exists").Json(ctx)
+		return
+	}
+
+	if err := c.RoleService.CheckRolesGrantable(security.GetAuthUserId(ctx), param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := c.UserGroupService.UpdateUserGroup(dto.SaveUserGroup{
+		GroupId:   param.GroupId,
+		GroupName: param.GroupName,
+		GroupSort: param.GroupSort,
+		Status:    param.Status,
+		UpdateBy:  security.GetAuthUserName(ctx),
+		Remark:    param.Remark,
+	}, param.RoleIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Remove deletes one or more user groups.
+// @Summary Delete user group
+// @Description Moves user groups to the recycle bin by their IDs. Their members lose the roles of the groups.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param groupIds path string true "User group IDs, comma-separated"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/group/{groupIds} [delete]
+func (c *UserGroupController) Remove(ctx *gin.Context) {
+	groupIds, err := utils.StringToIntSlice(ctx.Param("groupIds"), ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.UserGroupService.DeleteUserGroup(groupIds, security.GetAuthUserName(ctx)); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// MemberAllocatedList retrieves the members of a user group.
+// @Summary Query user group member list
+// @Description Retrieves a paginated list of users who are members of a specific user group.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.UserGroupMemberListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.UserListResponse}} "Success"
+// @Router /system/group/member/allocatedList [get]
+func (c *UserGroupController) MemberAllocatedList(ctx *gin.Context) {
+	var param dto.UserGroupMemberListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	users, total := c.UserGroupService.GetMemberList(param, security.GetAuthUserId(ctx), true)
+
+	response.NewSuccess().SetPageData(users, total).Json(ctx)
+}
+
+// MemberUnallocatedList retrieves the users outside a user group.
+// @Summary Query user group non-member list
+// @Description Retrieves a paginated list of users who are not members of a specific user group.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param query body dto.UserGroupMemberListRequest true "Query parameters"
+// @Success 200 {object} response.Response{data=response.PageData{list=[]dto.UserListResponse}} "Success"
+// @Router /system/group/member/unallocatedList [get]
+func (c *UserGroupController) MemberUnallocatedList(ctx *gin.Context) {
+	var param dto.UserGroupMemberListRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	users, total := c.UserGroupService.GetMemberList(param, security.GetAuthUserId(ctx), false)
+
+	response.NewSuccess().SetPageData(users, total).Json(ctx)
+}
+
+// AddMembers adds users to a user group.
+// @Summary Batch add user group members
+// @Description Adds multiple users to a specific user group.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UserGroupMemberRequest true "Member data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/group/member/selectAll [put]
+func (c *UserGroupController) AddMembers(ctx *gin.Context) {
+	var param dto.UserGroupMemberRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	userIds, err := utils.StringToIntSlice(param.UserIds, ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = validator.UserGroupMemberValidator(param.GroupId, userIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.UserGroupService.AddMembers(param.GroupId, userIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// RemoveMembers removes users from a user group.
+// @Summary Batch remove user group members
+// @Description Removes multiple users from a specific user group.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UserGroupMemberRequest true "Member data"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/group/member/cancelAll [put]
+func (c *UserGroupController) RemoveMembers(ctx *gin.Context) {
+	var param dto.UserGroupMemberRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	userIds, err := utils.StringToIntSlice(param.UserIds, ",")
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = validator.UserGroupMemberValidator(param.GroupId, userIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err = c.UserGroupService.RemoveMembers(param.GroupId, userIds); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
@@ -0,0 +1,137 @@
+package systemcontroller
+
+import (
+	"strconv"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/validator"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserLifecycleController handles user onboarding, offboarding and scheduled activation.
+type UserLifecycleController struct {
+	UserLifecycleService *service.UserLifecycleService
+	UserService          *service.UserService
+}
+
+// NewUserLifecycleController creates a new UserLifecycleController.
+func NewUserLifecycleController(userLifecycleService *service.UserLifecycleService, userService *service.UserService) *UserLifecycleController {
+	return &UserLifecycleController{UserLifecycleService: userLifecycleService, UserService: userService}
+}
+
+// Schedule sets when a user is activated and deactivated.
+// @Summary Schedule user activation and deactivation
+// @Description Sets the times at which the lifecycle scheduler onboards and disables a user. An empty time clears it.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UserLifecycleScheduleRequest true "Lifecycle schedule"
+// @Success 200 {object} response.Response "Success"
+// @Router /system/user/lifecycle [put]
+func (c *UserLifecycleController) Schedule(ctx *gin.Context) {
+	var param dto.UserLifecycleScheduleRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UserLifecycleScheduleValidator(param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if user := c.UserService.GetUserByUserId(param.UserId); user.UserId <= 0 {
+		response.NewError().SetMsg("User does not exist").Json(ctx)
+		return
+	}
+
+	if err := c.UserLifecycleService.ScheduleUserLifecycle(param, security.GetAuthUserName(ctx)); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().Json(ctx)
+}
+
+// Onboard grants a user the roles and posts of its onboarding templates and enables it.
+// @Summary Onboard user
+// @Description Grants a user the roles and posts of the onboarding templates matching its department and posts, then enables it.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} response.Response{data=dto.UserOnboardResponse} "Success"
+// @Router /system/user/onboard/{userId} [post]
+func (c *UserLifecycleController) Onboard(ctx *gin.Context) {
+	userId, _ := strconv.Atoi(ctx.Param("userId"))
+
+	if user := c.UserService.GetUserByUserId(userId); user.UserId <= 0 {
+		response.NewError().SetMsg("User does not exist").Json(ctx)
+		return
+	}
+
+	result, err := c.UserLifecycleService.OnboardUser(userId, security.GetAuthUserName(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", result).Json(ctx)
+}
+
+// Offboard takes a user out of the organization.
+// @Summary Offboard user
+// @Description Revokes every session of a user, disables it, removes its role grants, expires its pending approvals and transfers its export jobs, returning the offboarding report.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param body body dto.UserOffboardRequest true "Offboarding options"
+// @Success 200 {object} response.Response{data=dto.UserOffboardReport} "Success"
+// @Router /system/user/offboard [post]
+func (c *UserLifecycleController) Offboard(ctx *gin.Context) {
+	var param dto.UserOffboardRequest
+
+	if err := ctx.ShouldBind(&param); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	if err := validator.UserOffboardValidator(param, security.GetAuthUserId(ctx)); err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	report, err := c.UserLifecycleService.OffboardUser(param, security.GetAuthUserName(ctx))
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", report).Json(ctx)
+}
+
+// OffboardReports retrieves the offboarding reports of a user.
+// @Summary Get user offboarding reports
+// @Description Retrieves the reports of every offboarding of a user, newest first.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Param userId path int true "User ID"
+// @Success 200 {object} response.Response{data=[]dto.UserOffboardResponse} "Success"
+// @Router /system/user/offboard/{userId} [get]
+func (c *UserLifecycleController) OffboardReports(ctx *gin.Context) {
+	userId, _ := strconv.Atoi(ctx.Param("userId"))
+
+	reports, err := c.UserLifecycleService.GetOffboardReports(userId)
+	if err != nil {
+		response.NewError().SetMsg(err.Error()).Json(ctx)
+		return
+	}
+
+	response.NewSuccess().SetData("data", reports).Json(ctx)
+}
@@ -0,0 +1,24 @@
+package dto
+
+// Users holding a permission
+type PermUsersRequest struct {
+	Perm string `query:"perm" form:"perm"`
+}
+
+// User effective permissions
+type UserEffectivePermsRequest struct {
+	UserId int `query:"userId" form:"userId"`
+}
+
+// Compare two users or two roles
+type AccessCompareRequest struct {
+	Type    string `query:"type" form:"type"`
+	LeftId  int    `query:"leftId" form:"leftId"`
+	RightId int    `query:"rightId" form:"rightId"`
+}
+
+// Explain a permission check
+type AccessExplainRequest struct {
+	UserId int    `query:"userId" form:"userId"`
+	Perm   string `query:"perm" form:"perm"`
+}
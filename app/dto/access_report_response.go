@@ -0,0 +1,111 @@
+package dto
+
+// User holding a permission
+type PermUserResponse struct {
+	UserId   int    `json:"userId"`
+	UserName string `json:"userName"`
+	NickName string `json:"nickName"`
+	DeptName string `json:"deptName"`
+	RoleId   int    `json:"roleId"`
+	RoleKey  string `json:"roleKey"`
+	RoleName string `json:"roleName"`
+	MenuName string `json:"menuName"`
+}
+
+// Effective permission with its source roles
+type EffectivePermResponse struct {
+	Perm     string   `json:"perm"`
+	MenuName string   `json:"menuName"`
+	Roles    []string `json:"roles"`
+}
+
+// Data scope granted by a role
+type RoleDataScopeResponse struct {
+	RoleId     int      `json:"roleId"`
+	RoleKey    string   `json:"roleKey"`
+	RoleName   string   `json:"roleName"`
+	DataScope  string   `json:"dataScope"`
+	DeptNames  []string `json:"deptNames"`
+	GroupNames []string `json:"groupNames"`
+}
+
+// User effective permissions
+type UserEffectivePermsResponse struct {
+	UserId       int                     `json:"userId"`
+	UserName     string                  `json:"userName"`
+	NickName     string                  `json:"nickName"`
+	IsSuperAdmin bool                    `json:"isSuperAdmin"`
+	Perms        []EffectivePermResponse `json:"perms"`
+	DataScopes   []RoleDataScopeResponse `json:"dataScopes"`
+}
+
+// Permission comparison
+type AccessCompareResponse struct {
+	Type      string   `json:"type"`
+	Left      string   `json:"left"`
+	Right     string   `json:"right"`
+	OnlyLeft  []string `json:"onlyLeft"`
+	OnlyRight []string `json:"onlyRight"`
+	Common    []string `json:"common"`
+}
+
+// Role and menu that could grant a permission
+type AccessGrantResponse struct {
+	RoleId     int    `json:"roleId"`
+	RoleKey    string `json:"roleKey"`
+	RoleName   string `json:"roleName"`
+	RoleStatus string `json:"roleStatus"`
+	MenuId     int    `json:"menuId"`
+	MenuName   string `json:"menuName"`
+	MenuStatus string `json:"menuStatus"`
+	Effective  bool   `json:"effective"`
+	Note       string `json:"note"`
+}
+
+// Permission check explanation
+type AccessExplainResponse struct {
+	UserId   int                   `json:"userId"`
+	UserName string                `json:"userName"`
+	Perm     string                `json:"perm"`
+	Allowed  bool                  `json:"allowed"`
+	Reason   string                `json:"reason"`
+	Roles    []string              `json:"roles"`
+	Grants   []AccessGrantResponse `json:"grants"`
+}
+
+// Users holding a permission export
+type PermUserExportResponse struct {
+	UserId   int    `excel:"name:User ID;"`
+	UserName string `excel:"name:User Name;"`
+	NickName string `excel:"name:Nick Name;"`
+	DeptName string `excel:"name:Department;"`
+	RoleKey  string `excel:"name:Role Permission;"`
+	RoleName string `excel:"name:Role Name;"`
+	MenuName string `excel:"name:Menu Name;"`
+}
+
+// User effective permissions export
+type EffectivePermExportResponse struct {
+	Perm       string `excel:"name:Permission;"`
+	MenuName   string `excel:"name:Menu Name;"`
+	Roles      string `excel:"name:Source Roles;"`
+	DataScopes string `excel:"name:Data Scope;"`
+}
+
+// Permission comparison export
+type AccessCompareExportResponse struct {
+	Perm  string `excel:"name:Permission;"`
+	Left  string `excel:"name:Left;replace:Y_Yes,N_No;"`
+	Right string `excel:"name:Right;replace:Y_Yes,N_No;"`
+}
+
+// Permission check explanation export
+type AccessGrantExportResponse struct {
+	RoleKey    string `excel:"name:Role Permission;"`
+	RoleName   string `excel:"name:Role Name;"`
+	RoleStatus string `excel:"name:Role Status;replace:0_Normal,1_Disabled;"`
+	MenuName   string `excel:"name:Menu Name;"`
+	MenuStatus string `excel:"name:Menu Status;replace:0_Normal,1_Disabled;"`
+	Effective  string `excel:"name:Effective;replace:Y_Yes,N_No;"`
+	Note       string `excel:"name:Note;"`
+}
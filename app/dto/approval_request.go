@@ -0,0 +1,32 @@
+package dto
+
+import "mira/anima/datetime"
+
+// Save Approval
+type SaveApproval struct {
+	ApprovalId    int               `json:"approvalId"`
+	Action        string            `json:"action"`
+	Title         string            `json:"title"`
+	RequestMethod string            `json:"requestMethod"`
+	RequestUrl    string            `json:"requestUrl"`
+	RequestBody   string            `json:"requestBody"`
+	ContentType   string            `json:"contentType"`
+	RequesterId   int               `json:"requesterId"`
+	RequesterName string            `json:"requesterName"`
+	ExpireTime    datetime.Datetime `json:"expireTime"`
+}
+
+// Approval List
+type ApprovalListRequest struct {
+	PageRequest
+	Action        string `query:"action" form:"action"`
+	Title         string `query:"title" form:"title"`
+	RequesterName string `query:"requesterName" form:"requesterName"`
+	Status        string `query:"status" form:"status"`
+}
+
+// Review Approval
+type ReviewApprovalRequest struct {
+	ApprovalId int    `json:"approvalId"`
+	Reason     string `json:"reason"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "mira/anima/datetime"
+
+// Approval List
+type ApprovalListResponse struct {
+	ApprovalId    int               `json:"approvalId"`
+	Action        string            `json:"action"`
+	Title         string            `json:"title"`
+	RequestMethod string            `json:"requestMethod"`
+	RequestUrl    string            `json:"requestUrl"`
+	RequesterName string            `json:"requesterName"`
+	Status        string            `json:"status"`
+	ReviewerName  string            `json:"reviewerName"`
+	ReviewTime    datetime.Datetime `json:"reviewTime"`
+	ExpireTime    datetime.Datetime `json:"expireTime"`
+	CreateTime    datetime.Datetime `json:"createTime"`
+}
+
+// Approval Details
+type ApprovalDetailResponse struct {
+	ApprovalId    int               `json:"approvalId"`
+	Action        string            `json:"action"`
+	Title         string            `json:"title"`
+	RequestMethod string            `json:"requestMethod"`
+	RequestUrl    string            `json:"requestUrl"`
+	RequestBody   string            `json:"requestBody"`
+	ContentType   string            `json:"contentType"`
+	RequesterId   int               `json:"requesterId"`
+	RequesterName string            `json:"requesterName"`
+	Status        string            `json:"status"`
+	ReviewerId    int               `json:"reviewerId"`
+	ReviewerName  string            `json:"reviewerName"`
+	ReviewReason  string            `json:"reviewReason"`
+	ReviewTime    datetime.Datetime `json:"reviewTime"`
+	JsonResult    string            `json:"jsonResult"`
+	ExpireTime    datetime.Datetime `json:"expireTime"`
+	CreateTime    datetime.Datetime `json:"createTime"`
+}
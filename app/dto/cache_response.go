@@ -0,0 +1,21 @@
+package dto
+
+// Hit and miss counts of a two-level cache since startup
+type CacheMetricsResponse struct {
+	Name string `json:"name"`
+	// Entries held in process
+	LocalSize int `json:"localSize"`
+	// Reads served in process, from Redis, and by loading the data
+	LocalHits uint64 `json:"localHits"`
+	RedisHits uint64 `json:"redisHits"`
+	Misses    uint64 `json:"misses"`
+	// Reads of data known to be missing, included in the hits
+	NegativeHits uint64 `json:"negativeHits"`
+	// Reads that waited for a load already in flight instead of loading again
+	Coalesced  uint64 `json:"coalesced"`
+	LoadErrors uint64 `json:"loadErrors"`
+	// Entries dropped from the process to make room for others
+	Evictions uint64 `json:"evictions"`
+	// Share of the reads served without loading
+	HitRate float64 `json:"hitRate"`
+}
@@ -10,6 +10,8 @@ type SaveConfig struct {
 	CreateBy    string `json:"createBy"`
 	UpdateBy    string `json:"updateBy"`
 	Remark      string `json:"remark"`
+	// Reason is recorded in the change history
+	Reason string `json:"reason"`
 }
 
 // Parameter List
@@ -39,4 +41,28 @@ type UpdateConfigRequest struct {
 	ConfigValue string `json:"configValue"`
 	ConfigType  string `json:"configType"`
 	Remark      string `json:"remark"`
+	Reason      string `json:"reason"`
+}
+
+// Configuration Change History List
+type ConfigHistoryListRequest struct {
+	PageRequest
+	EntityId   int    `query:"entityId" form:"entityId"`
+	EntityName string `query:"entityName" form:"entityName"`
+	Action     string `query:"action" form:"action"`
+	CreateBy   string `query:"createBy" form:"createBy"`
+	BeginTime  string `query:"params[beginTime]" form:"params[beginTime]"`
+	EndTime    string `query:"params[endTime]" form:"params[endTime]"`
+}
+
+// Compare Two Versions of the Configuration Change History
+type ConfigHistoryDiffRequest struct {
+	FromHistoryId int `query:"from" form:"from"`
+	ToHistoryId   int `query:"to" form:"to"`
+}
+
+// Roll Back to a Version of the Configuration Change History
+type ConfigHistoryRollbackRequest struct {
+	HistoryId int    `json:"historyId"`
+	Reason    string `json:"reason"`
 }
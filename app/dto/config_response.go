@@ -1,6 +1,10 @@
 package dto
 
-import "mira/anima/datetime"
+import (
+	"encoding/json"
+
+	"mira/anima/datetime"
+)
 
 // Parameter List
 type ConfigListResponse struct {
@@ -23,11 +27,47 @@ type ConfigDetailResponse struct {
 	Remark      string `json:"remark"`
 }
 
-// Parameter Export
-type ConfigExportResponse struct {
-	ConfigId    int    `excel:"name:Config ID;"`
-	ConfigName  string `excel:"name:Config Name;"`
-	ConfigKey   string `excel:"name:Config Key;"`
-	ConfigValue string `excel:"name:Config Value;"`
-	ConfigType  string `excel:"name:System Built-in;replace:Y_Yes,N_No;"`
+// Parameter Schema
+type ConfigSchemaResponse struct {
+	ConfigKey    string          `json:"configKey"`
+	ConfigName   string          `json:"configName"`
+	ValueType    string          `json:"valueType"`
+	DefaultValue string          `json:"defaultValue"`
+	Description  string          `json:"description"`
+	Options      []string        `json:"options,omitempty"`
+	Min          string          `json:"min,omitempty"`
+	Max          string          `json:"max,omitempty"`
+	JsonSchema   json.RawMessage `json:"jsonSchema,omitempty"`
+}
+
+// Configuration Change History List
+type ConfigHistoryListResponse struct {
+	HistoryId     int               `json:"historyId"`
+	EntityType    string            `json:"entityType"`
+	EntityId      int               `json:"entityId"`
+	EntityName    string            `json:"entityName"`
+	Version       int               `json:"version"`
+	Action        string            `json:"action"`
+	OldValue      string            `json:"oldValue"`
+	NewValue      string            `json:"newValue"`
+	SourceVersion int               `json:"sourceVersion"`
+	Reason        string            `json:"reason"`
+	CreateBy      string            `json:"createBy"`
+	CreateTime    datetime.Datetime `json:"createTime"`
+}
+
+// Differences Between Two Versions of the Configuration Change History
+type ConfigHistoryDiffResponse struct {
+	EntityType  string                `json:"entityType"`
+	EntityId    int                   `json:"entityId"`
+	FromVersion int                   `json:"fromVersion"`
+	ToVersion   int                   `json:"toVersion"`
+	Changes     []ConfigHistoryChange `json:"changes"`
+}
+
+// Field Changed Between Two Versions
+type ConfigHistoryChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
 }
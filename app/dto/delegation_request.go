@@ -0,0 +1,42 @@
+package dto
+
+// Save Delegation
+type SaveDelegation struct {
+	DelegationId int    `json:"delegationId"`
+	DeptId       int    `json:"deptId"`
+	DelegateType string `json:"delegateType"`
+	DelegateId   int    `json:"delegateId"`
+	Status       string `json:"status"`
+	CreateBy     string `json:"createBy"`
+	UpdateBy     string `json:"updateBy"`
+	Remark       string `json:"remark"`
+}
+
+// Delegation List
+type DelegationListRequest struct {
+	PageRequest
+	DeptId       int    `query:"deptId" form:"deptId"`
+	DelegateType string `query:"delegateType" form:"delegateType"`
+	Status       string `query:"status" form:"status"`
+}
+
+// Create Delegation
+type CreateDelegationRequest struct {
+	DeptId       int    `json:"deptId"`
+	DelegateType string `json:"delegateType"`
+	DelegateId   int    `json:"delegateId"`
+	RoleIds      []int  `json:"roleIds"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+}
+
+// Update Delegation
+type UpdateDelegationRequest struct {
+	DelegationId int    `json:"delegationId"`
+	DeptId       int    `json:"deptId"`
+	DelegateType string `json:"delegateType"`
+	DelegateId   int    `json:"delegateId"`
+	RoleIds      []int  `json:"roleIds"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "mira/anima/datetime"
+
+// Delegation List
+type DelegationListResponse struct {
+	DelegationId int               `json:"delegationId"`
+	DeptId       int               `json:"deptId"`
+	DeptName     string            `json:"deptName"`
+	DelegateType string            `json:"delegateType"`
+	DelegateId   int               `json:"delegateId"`
+	DelegateName string            `json:"delegateName"`
+	Status       string            `json:"status"`
+	CreateTime   datetime.Datetime `json:"createTime"`
+	Remark       string            `json:"remark"`
+}
+
+// Delegation Details
+type DelegationDetailResponse struct {
+	DelegationId int    `json:"delegationId"`
+	DeptId       int    `json:"deptId"`
+	DelegateType string `json:"delegateType"`
+	DelegateId   int    `json:"delegateId"`
+	RoleIds      []int  `json:"roleIds" gorm:"-"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+}
+
+// Delegated administration scope of a user, merged from all delegations it receives
+type DelegationScope struct {
+	DeptIds []int `json:"deptIds"`
+	RoleIds []int `json:"roleIds"`
+}
@@ -44,3 +44,31 @@ type UpdateDeptRequest struct {
 	Email     string `json:"email"`
 	Status    string `json:"status"`
 }
+
+// Move Department
+type MoveDeptRequest struct {
+	DeptId   int `json:"deptId"`
+	ParentId int `json:"parentId"`
+}
+
+// Repair Department Hierarchy
+type RepairDeptHierarchyRequest struct {
+	DryRun bool `query:"dryRun" form:"dryRun"`
+}
+
+// Merge Department
+type MergeDeptRequest struct {
+	SourceDeptId int  `json:"sourceDeptId"`
+	TargetDeptId int  `json:"targetDeptId"`
+	DryRun       bool `json:"dryRun"`
+}
+
+// Department Import
+type DeptImportRequest struct {
+	DeptPath string `excel:"name:Department Path;"`
+	OrderNum int    `excel:"name:Display Order;"`
+	Leader   string `excel:"name:Leader;"`
+	Phone    string `excel:"name:Phone;"`
+	Email    string `excel:"name:Email;"`
+	Status   string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+}
@@ -40,3 +40,47 @@ type DeptTreeResponse struct {
 	Children []DeptTreeResponse `json:"children" gorm:"-"`
 	ParentId int                `json:"-"`
 }
+
+// Department Hierarchy Issue
+type DeptHierarchyIssue struct {
+	DeptId            int    `json:"deptId"`
+	DeptName          string `json:"deptName"`
+	ParentId          int    `json:"parentId"`
+	Type              string `json:"type"`
+	Ancestors         string `json:"ancestors"`
+	ExpectedAncestors string `json:"expectedAncestors"`
+	Repaired          bool   `json:"repaired"`
+}
+
+// Department Hierarchy Repair Result
+type DeptHierarchyRepairResponse struct {
+	DryRun   bool                 `json:"dryRun"`
+	Checked  int                  `json:"checked"`
+	Repaired int                  `json:"repaired"`
+	Issues   []DeptHierarchyIssue `json:"issues"`
+}
+
+// Department Merge Result
+type DeptMergeResponse struct {
+	DryRun         bool   `json:"dryRun"`
+	SourceDeptId   int    `json:"sourceDeptId"`
+	SourceDeptName string `json:"sourceDeptName"`
+	TargetDeptId   int    `json:"targetDeptId"`
+	TargetDeptName string `json:"targetDeptName"`
+	UserIds        []int  `json:"userIds"`       // Users transferred to the target
+	RoleIds        []int  `json:"roleIds"`       // Roles whose custom data scope grants are re-pointed
+	ChildDeptIds   []int  `json:"childDeptIds"`  // Sub-departments re-parented under the target
+	DelegationIds  []int  `json:"delegationIds"` // Delegations re-pointed to the target
+}
+
+// Department Import Error Report
+type DeptImportErrorResponse struct {
+	RowNum   int    `excel:"name:Row;"`
+	DeptPath string `excel:"name:Department Path;"`
+	OrderNum int    `excel:"name:Display Order;"`
+	Leader   string `excel:"name:Leader;"`
+	Phone    string `excel:"name:Phone;"`
+	Email    string `excel:"name:Email;"`
+	Status   string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+	ErrorMsg string `excel:"name:Error;"`
+}
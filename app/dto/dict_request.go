@@ -52,6 +52,8 @@ type SaveDictData struct {
 	CreateBy  string `json:"createBy"`
 	UpdateBy  string `json:"updateBy"`
 	Remark    string `json:"remark"`
+	// Reason is recorded in the change history
+	Reason string `json:"reason"`
 }
 
 // Dictionary Data List
@@ -87,4 +89,26 @@ type UpdateDictDataRequest struct {
 	IsDefault string `json:"isDefault"`
 	Status    string `json:"status"`
 	Remark    string `json:"remark"`
+	Reason    string `json:"reason"`
+}
+
+// Dictionary Type Import
+type DictTypeImportRequest struct {
+	DictName string `excel:"name:Dict Name;"`
+	DictType string `excel:"name:Dict Type;"`
+	Status   string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+	Remark   string `excel:"name:Remark;"`
+}
+
+// Dictionary Data Import
+type DictDataImportRequest struct {
+	DictType  string `excel:"name:Dict Type;"`
+	DictSort  int    `excel:"name:Dict Sort;"`
+	DictLabel string `excel:"name:Dict Label;"`
+	DictValue string `excel:"name:Dict Value;"`
+	CssClass  string `excel:"name:Css Class;"`
+	ListClass string `excel:"name:List Class;"`
+	IsDefault string `excel:"name:Is Default;replace:Y_Yes,N_No;"`
+	Status    string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+	Remark    string `excel:"name:Remark;"`
 }
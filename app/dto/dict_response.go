@@ -50,21 +50,27 @@ type DictDataDetailResponse struct {
 	Default   bool   `json:"default" gorm:"-"`
 }
 
-// Dictionary Type Export
-type DictTypeExportResponse struct {
-	DictId   int    `excel:"name:Dict ID;"`
+// Dictionary Type Import Error Report
+type DictTypeImportErrorResponse struct {
+	RowNum   int    `excel:"name:Row;"`
 	DictName string `excel:"name:Dict Name;"`
 	DictType string `excel:"name:Dict Type;"`
 	Status   string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+	Remark   string `excel:"name:Remark;"`
+	ErrorMsg string `excel:"name:Error;"`
 }
 
-// Dictionary Data Export
-type DictDataExportResponse struct {
-	DictCode  int    `excel:"name:Dict Code;"`
+// Dictionary Data Import Error Report
+type DictDataImportErrorResponse struct {
+	RowNum    int    `excel:"name:Row;"`
+	DictType  string `excel:"name:Dict Type;"`
 	DictSort  int    `excel:"name:Dict Sort;"`
 	DictLabel string `excel:"name:Dict Label;"`
 	DictValue string `excel:"name:Dict Value;"`
-	DictType  string `excel:"name:Dict Type;"`
+	CssClass  string `excel:"name:Css Class;"`
+	ListClass string `excel:"name:List Class;"`
 	IsDefault string `excel:"name:Is Default;replace:Y_Yes,N_No;"`
 	Status    string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+	Remark    string `excel:"name:Remark;"`
+	ErrorMsg  string `excel:"name:Error;"`
 }
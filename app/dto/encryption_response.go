@@ -0,0 +1,14 @@
+package dto
+
+// Encryption Migration Report of a Table
+type EncryptionReport struct {
+	Table string `json:"table"`
+	// Rows is the number of rows read, Updated of rows written back
+	Rows    int `json:"rows"`
+	Updated int `json:"updated"`
+	// Encrypted values were plaintext, Rewrapped values were wrapped by a key that is no longer primary
+	Encrypted int `json:"encrypted"`
+	Rewrapped int `json:"rewrapped"`
+	// Indexed is the number of blind indexes filled in or recomputed
+	Indexed int `json:"indexed"`
+}
@@ -0,0 +1,12 @@
+package dto
+
+// Export Options
+//
+// Columns is a comma-separated list of column keys in the order they are written;
+// empty exports every column of the dataset.
+type ExportRequest struct {
+	Format  string `form:"format"`
+	Columns string `form:"columns"`
+	Lang    string `form:"lang"`
+	Async   bool   `form:"async"`
+}
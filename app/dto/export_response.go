@@ -0,0 +1,18 @@
+package dto
+
+import "mira/anima/datetime"
+
+// Export Job Progress
+type ExportJobResponse struct {
+	JobId      int               `json:"jobId"`
+	Dataset    string            `json:"dataset"`
+	Format     string            `json:"format"`
+	FileName   string            `json:"fileName"`
+	Status     string            `json:"status"`
+	Total      int               `json:"total"`
+	Processed  int               `json:"processed"`
+	ErrorMsg   string            `json:"errorMsg"`
+	CreateBy   string            `json:"createBy"`
+	CreateTime datetime.Datetime `json:"createTime"`
+	FinishTime datetime.Datetime `json:"finishTime"`
+}
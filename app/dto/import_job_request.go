@@ -0,0 +1,8 @@
+package dto
+
+// Import Options
+type ImportOptions struct {
+	UpdateSupport bool
+	OperatorId    int
+	OperatorName  string
+}
@@ -0,0 +1,26 @@
+package dto
+
+import "mira/anima/datetime"
+
+// Import Job Progress
+type ImportJobResponse struct {
+	JobId      int               `json:"jobId"`
+	JobType    string            `json:"jobType"`
+	FileName   string            `json:"fileName"`
+	Status     string            `json:"status"`
+	Total      int               `json:"total"`
+	Processed  int               `json:"processed"`
+	Succeeded  int               `json:"succeeded"`
+	Failed     int               `json:"failed"`
+	ErrorMsg   string            `json:"errorMsg"`
+	CreateBy   string            `json:"createBy"`
+	CreateTime datetime.Datetime `json:"createTime"`
+	FinishTime datetime.Datetime `json:"finishTime"`
+}
+
+// Import Job Failed Row
+type ImportJobErrorResponse struct {
+	RowNum   int    `json:"rowNum"`
+	RowData  string `json:"rowData"`
+	ErrorMsg string `json:"errorMsg"`
+}
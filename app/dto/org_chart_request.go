@@ -0,0 +1,14 @@
+package dto
+
+// Org Chart
+type OrgChartRequest struct {
+	DeptId int `query:"deptId" form:"deptId"`
+}
+
+// Org Chart Export
+type OrgChartExportRequest struct {
+	DeptId int    `query:"deptId" form:"deptId"`
+	Format string `query:"format" form:"format"`
+	Fields string `query:"fields" form:"fields"`
+	Lang   string `query:"lang" form:"lang"`
+}
@@ -0,0 +1,51 @@
+package dto
+
+// Org Chart Department
+type OrgChartDeptResponse struct {
+	DeptId         int                    `json:"deptId"`
+	ParentId       int                    `json:"parentId"`
+	DeptName       string                 `json:"deptName"`
+	Leader         string                 `json:"leader"`
+	HeadCount      int                    `json:"headCount" gorm:"-"`
+	TotalHeadCount int                    `json:"totalHeadCount" gorm:"-"`
+	Posts          []OrgChartPostResponse `json:"posts" gorm:"-"`
+	Users          []OrgChartUserResponse `json:"users" gorm:"-"`
+	Children       []OrgChartDeptResponse `json:"children" gorm:"-"`
+}
+
+// Org Chart Post
+type OrgChartPostResponse struct {
+	PostId   int                    `json:"postId"`
+	ParentId int                    `json:"parentId"`
+	PostCode string                 `json:"postCode"`
+	PostName string                 `json:"postName"`
+	Users    []OrgChartUserResponse `json:"users"`
+}
+
+// Org Chart User
+type OrgChartUserResponse struct {
+	UserId   int    `json:"userId"`
+	UserName string `json:"userName"`
+	NickName string `json:"nickName"`
+}
+
+// Org Chart Graph
+type OrgChartGraphResponse struct {
+	Nodes []OrgChartGraphNode `json:"nodes"`
+	Edges []OrgChartGraphEdge `json:"edges"`
+}
+
+// Org Chart Graph Node
+type OrgChartGraphNode struct {
+	Id    string                 `json:"id"`
+	Type  string                 `json:"type"`
+	Label string                 `json:"label"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+// Org Chart Graph Edge
+type OrgChartGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
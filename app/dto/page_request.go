@@ -1,6 +1,19 @@
 package dto
 
+// PageRequest pages a list by page number, or by keyset when a cursor or limit is given.
+//
+// Keyset pages start after the row the cursor points at, returning the cursor of the next
+// page as nextCursor, so deep pages cost the same as the first. Count is exact (default),
+// estimate or none; a list not counted reports a total of -1.
 type PageRequest struct {
-	PageNum  int `query:"pageNum" form:"pageNum"`
-	PageSize int `query:"pageSize" form:"pageSize"`
+	PageNum  int    `query:"pageNum" form:"pageNum"`
+	PageSize int    `query:"pageSize" form:"pageSize"`
+	Cursor   string `query:"cursor" form:"cursor"`
+	Limit    int    `query:"limit" form:"limit"`
+	Count    string `query:"count" form:"count"`
+}
+
+// IsKeyset reports whether the list is paged by keyset
+func (p PageRequest) IsKeyset() bool {
+	return p.Cursor != "" || p.Limit > 0
 }
@@ -0,0 +1,64 @@
+package dto
+
+import "time"
+
+// Save Policy
+type SavePolicy struct {
+	PolicyId   int    `json:"policyId"`
+	PolicyName string `json:"policyName"`
+	Action     string `json:"action"`
+	Effect     string `json:"effect"`
+	Condition  string `json:"condition"`
+	Status     string `json:"status"`
+	CreateBy   string `json:"createBy"`
+	UpdateBy   string `json:"updateBy"`
+	Remark     string `json:"remark"`
+}
+
+// Policy List
+type PolicyListRequest struct {
+	PageRequest
+	PolicyName string `query:"policyName" form:"policyName"`
+	Action     string `query:"action" form:"action"`
+	Effect     string `query:"effect" form:"effect"`
+	Status     string `query:"status" form:"status"`
+}
+
+// Create Policy
+type CreatePolicyRequest struct {
+	PolicyName string `json:"policyName"`
+	Action     string `json:"action"`
+	Effect     string `json:"effect"`
+	Condition  string `json:"condition"`
+	Status     string `json:"status"`
+	Remark     string `json:"remark"`
+}
+
+// Update Policy
+type UpdatePolicyRequest struct {
+	PolicyId   int    `json:"policyId"`
+	PolicyName string `json:"policyName"`
+	Action     string `json:"action"`
+	Effect     string `json:"effect"`
+	Condition  string `json:"condition"`
+	Status     string `json:"status"`
+	Remark     string `json:"remark"`
+}
+
+// Policy Test
+type PolicyTestRequest struct {
+	Action    string                 `json:"action"`
+	UserId    int                    `json:"userId"`
+	Resource  map[string]interface{} `json:"resource"`
+	Ip        string                 `json:"ip"`
+	Time      string                 `json:"time"`
+	Condition string                 `json:"condition"`
+}
+
+// Policy evaluation input
+type PolicyContext struct {
+	UserId   int
+	Resource map[string]interface{}
+	Ip       string
+	Time     time.Time
+}
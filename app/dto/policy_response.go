@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"mira/anima/datetime"
+	"mira/common/policy"
+)
+
+// Policy List
+type PolicyListResponse struct {
+	PolicyId   int               `json:"policyId"`
+	PolicyName string            `json:"policyName"`
+	Action     string            `json:"action"`
+	Effect     string            `json:"effect"`
+	Condition  string            `json:"condition"`
+	Status     string            `json:"status"`
+	CreateTime datetime.Datetime `json:"createTime"`
+	Remark     string            `json:"remark"`
+}
+
+// Policy Details
+type PolicyDetailResponse struct {
+	PolicyId   int    `json:"policyId"`
+	PolicyName string `json:"policyName"`
+	Action     string `json:"action"`
+	Effect     string `json:"effect"`
+	Condition  string `json:"condition"`
+	Status     string `json:"status"`
+	Remark     string `json:"remark"`
+}
+
+// Policy Decision
+type PolicyDecisionResponse struct {
+	Action   string                     `json:"action"`
+	Allowed  bool                       `json:"allowed"`
+	Decision string                     `json:"decision"`
+	Reason   string                     `json:"reason"`
+	Input    map[string]interface{}     `json:"input"`
+	Policies []PolicyEvaluationResponse `json:"policies"`
+}
+
+// Single policy evaluation result
+type PolicyEvaluationResponse struct {
+	PolicyId   int           `json:"policyId"`
+	PolicyName string        `json:"policyName"`
+	Effect     string        `json:"effect"`
+	Condition  string        `json:"condition"`
+	Matched    bool          `json:"matched"`
+	Error      string        `json:"error,omitempty"`
+	Steps      []policy.Step `json:"steps"`
+}
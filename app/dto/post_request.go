@@ -3,6 +3,7 @@ package dto
 // Save Post
 type SavePost struct {
 	PostId   int    `json:"postId"`
+	ParentId int    `json:"parentId"`
 	PostCode string `json:"postCode"`
 	PostName string `json:"postName"`
 	PostSort int    `json:"postSort"`
@@ -22,19 +23,32 @@ type PostListRequest struct {
 
 // Create Post
 type CreatePostRequest struct {
+	ParentId int    `json:"parentId"`
 	PostCode string `json:"postCode"`
 	PostName string `json:"postName"`
 	PostSort int    `json:"postSort"`
 	Status   string `json:"status"`
+	RoleIds  []int  `json:"roleIds"`
 	Remark   string `json:"remark"`
 }
 
 // Update Post
 type UpdatePostRequest struct {
 	PostId   int    `json:"postId"`
+	ParentId int    `json:"parentId"`
 	PostCode string `json:"postCode"`
 	PostName string `json:"postName"`
 	PostSort int    `json:"postSort"`
 	Status   string `json:"status"`
+	RoleIds  []int  `json:"roleIds"`
 	Remark   string `json:"remark"`
 }
+
+// Post Import
+type PostImportRequest struct {
+	PostCode string `excel:"name:Post Code;"`
+	PostName string `excel:"name:Post Name;"`
+	PostSort int    `excel:"name:Post Sort;"`
+	Status   string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+	Remark   string `excel:"name:Remark;"`
+}
@@ -5,6 +5,7 @@ import "mira/anima/datetime"
 // Post List
 type PostListResponse struct {
 	PostId     int               `json:"postId"`
+	ParentId   int               `json:"parentId"`
 	PostCode   string            `json:"postCode"`
 	PostName   string            `json:"postName"`
 	PostSort   int               `json:"postSort"`
@@ -15,18 +16,22 @@ type PostListResponse struct {
 // Post Details
 type PostDetailResponse struct {
 	PostId   int    `json:"postId"`
+	ParentId int    `json:"parentId"`
 	PostCode string `json:"postCode"`
 	PostName string `json:"postName"`
 	PostSort int    `json:"postSort"`
 	Status   string `json:"status"`
+	RoleIds  []int  `json:"roleIds" gorm:"-"`
 	Remark   string `json:"remark"`
 }
 
-// Post Export
-type PostExportResponse struct {
-	PostId   int    `excel:"name:Post ID;"`
+// Post Import Error Report
+type PostImportErrorResponse struct {
+	RowNum   int    `excel:"name:Row;"`
 	PostCode string `excel:"name:Post Code;"`
 	PostName string `excel:"name:Post Name;"`
 	PostSort int    `excel:"name:Post Sort;"`
 	Status   string `excel:"name:Status;replace:0_Normal,1_Disabled;"`
+	Remark   string `excel:"name:Remark;"`
+	ErrorMsg string `excel:"name:Error;"`
 }
@@ -0,0 +1,22 @@
+package dto
+
+// Export Personal Data
+type PrivacyExportRequest struct {
+	UserId int    `json:"userId"`
+	Lang   string `json:"lang"`
+	Reason string `json:"reason"`
+}
+
+// Erase Personal Data
+type PrivacyEraseRequest struct {
+	UserId int    `json:"userId"`
+	Reason string `json:"reason"`
+}
+
+// Privacy Request List
+type PrivacyRequestListRequest struct {
+	PageRequest
+	UserId      int    `query:"userId" form:"userId"`
+	RequestType string `query:"requestType" form:"requestType"`
+	Status      string `query:"status" form:"status"`
+}
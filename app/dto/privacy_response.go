@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"mira/anima/datetime"
+)
+
+// Personal Data Profile
+type PrivacyProfileResponse struct {
+	UserDetailResponse
+	DeptName   string            `json:"deptName"`
+	UpdateTime datetime.Datetime `json:"updateTime"`
+	Remark     string            `json:"remark"`
+}
+
+// Personal Data Export Report, also written to the archive as manifest.json
+type PrivacyExportReport struct {
+	RequestId int  `json:"requestId"`
+	UserId    int  `json:"userId"`
+	Roles     int  `json:"roles"`
+	Posts     int  `json:"posts"`
+	LoginLogs int  `json:"loginLogs"`
+	OperLogs  int  `json:"operLogs"`
+	Avatar    bool `json:"avatar"`
+}
+
+// Personal Data Erasure Report
+type PrivacyEraseReport struct {
+	RequestId             int    `json:"requestId"`
+	UserId                int    `json:"userId"`
+	Pseudonym             string `json:"pseudonym"`
+	RemovedAttributes     int    `json:"removedAttributes"`
+	PseudonymisedOperLogs int    `json:"pseudonymisedOperLogs"`
+	PseudonymisedLogins   int    `json:"pseudonymisedLogins"`
+	RenamedReferences     int    `json:"renamedReferences"`
+	RemovedRecycleEntries int    `json:"removedRecycleEntries"`
+	RemovedAvatar         bool   `json:"removedAvatar"`
+}
+
+// Privacy Request List
+type PrivacyRequestListResponse struct {
+	RequestId   int               `json:"requestId"`
+	UserId      int               `json:"userId"`
+	RequestType string            `json:"requestType"`
+	Status      string            `json:"status"`
+	Reason      string            `json:"reason"`
+	ErrorMsg    string            `json:"errorMsg"`
+	CreateBy    string            `json:"createBy"`
+	CreateTime  datetime.Datetime `json:"createTime"`
+	FinishTime  datetime.Datetime `json:"finishTime"`
+}
+
+// Privacy Request Details with its audit trail
+type PrivacyRequestDetailResponse struct {
+	PrivacyRequestListResponse
+	Result json.RawMessage             `json:"result" gorm:"-"`
+	Logs   []PrivacyRequestLogResponse `json:"logs" gorm:"-"`
+}
+
+// Privacy Request Audit Trail Entry
+type PrivacyRequestLogResponse struct {
+	LogId      int               `json:"logId"`
+	Step       string            `json:"step"`
+	Detail     string            `json:"detail"`
+	CreateTime datetime.Datetime `json:"createTime"`
+}
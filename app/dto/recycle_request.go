@@ -0,0 +1,9 @@
+package dto
+
+// Recycle Bin List
+type RecycleListRequest struct {
+	PageRequest
+	EntityType string `query:"entityType" form:"entityType"`
+	EntityName string `query:"entityName" form:"entityName"`
+	DeleteBy   string `query:"deleteBy" form:"deleteBy"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "mira/anima/datetime"
+
+// Recycle Bin List
+type RecycleListResponse struct {
+	RecycleId  int               `json:"recycleId"`
+	EntityType string            `json:"entityType"`
+	EntityId   int               `json:"entityId"`
+	EntityName string            `json:"entityName"`
+	DeleteBy   string            `json:"deleteBy"`
+	DeleteTime datetime.Datetime `json:"deleteTime"`
+	PurgeTime  datetime.Datetime `json:"purgeTime" gorm:"-"`
+}
@@ -50,6 +50,7 @@ type UpdateRoleRequest struct {
 	Remark            string `json:"remark"`
 	MenuIds           []int  `json:"menuIds"`
 	DeptIds           []int  `json:"deptIds"`
+	GroupIds          []int  `json:"groupIds"`
 }
 
 // Query allocated user role list
@@ -77,3 +78,13 @@ type RoleAuthUserCancelAllRequest struct {
 	RoleId  int    `query:"roleId" form:"roleId"`
 	UserIds string `query:"userIds" form:"userIds"`
 }
+
+// Role Import
+type RoleImportRequest struct {
+	RoleName string `excel:"name:Role Name;"`
+	RoleKey  string `excel:"name:Role Permission;"`
+	RoleSort int    `excel:"name:Role Sort;"`
+	Status   string `excel:"name:Role Status;replace:0_Normal,1_Disabled;"`
+	Perms    string `excel:"name:Menu Permissions;"`
+	Remark   string `excel:"name:Remark;"`
+}
@@ -31,12 +31,14 @@ type RoleDetailResponse struct {
 	Remark            string `json:"remark"`
 }
 
-// Role Export
-type RoleExportResponse struct {
-	RoleId    int    `excel:"name:Role ID;"`
-	RoleName  string `excel:"name:Role Name;"`
-	RoleKey   string `excel:"name:Role Permission;"`
-	RoleSort  int    `excel:"name:Role Sort;"`
-	DataScope string `excel:"name:Data Scope;replace:1_All Data Permissions,2_Custom Data Permissions,3_Department Data Permissions,4_Department and Below Data Permissions,5_Only Personal Data Permissions;"`
-	Status    string `excel:"name:Role Status;replace:0_Normal,1_Disabled;"`
+// Role Import Error Report
+type RoleImportErrorResponse struct {
+	RowNum   int    `excel:"name:Row;"`
+	RoleName string `excel:"name:Role Name;"`
+	RoleKey  string `excel:"name:Role Permission;"`
+	RoleSort int    `excel:"name:Role Sort;"`
+	Status   string `excel:"name:Role Status;replace:0_Normal,1_Disabled;"`
+	Perms    string `excel:"name:Menu Permissions;"`
+	Remark   string `excel:"name:Remark;"`
+	ErrorMsg string `excel:"name:Error;"`
 }
@@ -0,0 +1,30 @@
+package dto
+
+import "encoding/json"
+
+// SCIM List Query
+//
+// Count is a pointer so that an explicit count of 0, which asks for the total only,
+// can be told apart from no count.
+type ScimListRequest struct {
+	Filter             string `form:"filter"`
+	StartIndex         int    `form:"startIndex"`
+	Count              *int   `form:"count"`
+	ExcludedAttributes string `form:"excludedAttributes"`
+}
+
+// SCIM Patch
+type ScimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []ScimPatchOperation `json:"Operations"`
+}
+
+// SCIM Patch Operation
+//
+// Value is kept raw as its shape depends on the path, and identity providers differ
+// in sending booleans as strings.
+type ScimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
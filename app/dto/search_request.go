@@ -0,0 +1,8 @@
+package dto
+
+// Unified Search
+type SearchRequest struct {
+	Keyword string `query:"keyword" form:"keyword"`
+	Types   string `query:"types" form:"types"`
+	Limit   int    `query:"limit" form:"limit"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// Unified Search
+type SearchResponse struct {
+	Backend string               `json:"backend"`
+	Items   []SearchItemResponse `json:"items"`
+}
+
+// Unified Search Item
+type SearchItemResponse struct {
+	Type        string  `json:"type"`
+	Id          int     `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Status      string  `json:"status"`
+	Matched     string  `json:"matched"`
+	Score       float64 `json:"score"`
+}
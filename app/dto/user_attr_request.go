@@ -0,0 +1,55 @@
+package dto
+
+// Save User Attribute
+type SaveUserAttr struct {
+	AttrId       int    `json:"attrId"`
+	AttrKey      string `json:"attrKey"`
+	AttrLabel    string `json:"attrLabel"`
+	AttrType     string `json:"attrType"`
+	Pattern      string `json:"pattern"`
+	DictType     string `json:"dictType"`
+	IsRequired   string `json:"isRequired"`
+	IsSearchable string `json:"isSearchable"`
+	AttrSort     int    `json:"attrSort"`
+	Status       string `json:"status"`
+	CreateBy     string `json:"createBy"`
+	UpdateBy     string `json:"updateBy"`
+	Remark       string `json:"remark"`
+}
+
+// User Attribute List
+type UserAttrListRequest struct {
+	PageRequest
+	AttrKey   string `query:"attrKey" form:"attrKey"`
+	AttrLabel string `query:"attrLabel" form:"attrLabel"`
+	Status    string `query:"status" form:"status"`
+}
+
+// Create User Attribute
+type CreateUserAttrRequest struct {
+	AttrKey      string `json:"attrKey"`
+	AttrLabel    string `json:"attrLabel"`
+	AttrType     string `json:"attrType"`
+	Pattern      string `json:"pattern"`
+	DictType     string `json:"dictType"`
+	IsRequired   string `json:"isRequired"`
+	IsSearchable string `json:"isSearchable"`
+	AttrSort     int    `json:"attrSort"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+}
+
+// Update User Attribute
+type UpdateUserAttrRequest struct {
+	AttrId       int    `json:"attrId"`
+	AttrKey      string `json:"attrKey"`
+	AttrLabel    string `json:"attrLabel"`
+	AttrType     string `json:"attrType"`
+	Pattern      string `json:"pattern"`
+	DictType     string `json:"dictType"`
+	IsRequired   string `json:"isRequired"`
+	IsSearchable string `json:"isSearchable"`
+	AttrSort     int    `json:"attrSort"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import "mira/anima/datetime"
+
+// User Attribute List
+type UserAttrListResponse struct {
+	AttrId       int               `json:"attrId"`
+	AttrKey      string            `json:"attrKey"`
+	AttrLabel    string            `json:"attrLabel"`
+	AttrType     string            `json:"attrType"`
+	Pattern      string            `json:"pattern"`
+	DictType     string            `json:"dictType"`
+	IsRequired   string            `json:"isRequired"`
+	IsSearchable string            `json:"isSearchable"`
+	AttrSort     int               `json:"attrSort"`
+	Status       string            `json:"status"`
+	CreateTime   datetime.Datetime `json:"createTime"`
+}
+
+// User Attribute Details
+type UserAttrDetailResponse struct {
+	AttrId       int    `json:"attrId"`
+	AttrKey      string `json:"attrKey"`
+	AttrLabel    string `json:"attrLabel"`
+	AttrType     string `json:"attrType"`
+	Pattern      string `json:"pattern"`
+	DictType     string `json:"dictType"`
+	IsRequired   string `json:"isRequired"`
+	IsSearchable string `json:"isSearchable"`
+	AttrSort     int    `json:"attrSort"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+}
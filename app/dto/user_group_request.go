@@ -0,0 +1,52 @@
+package dto
+
+// Save User Group
+type SaveUserGroup struct {
+	GroupId   int    `json:"groupId"`
+	GroupName string `json:"groupName"`
+	GroupSort int    `json:"groupSort"`
+	Status    string `json:"status"`
+	CreateBy  string `json:"createBy"`
+	UpdateBy  string `json:"updateBy"`
+	Remark    string `json:"remark"`
+}
+
+// User Group List
+type UserGroupListRequest struct {
+	PageRequest
+	GroupName string `query:"groupName" form:"groupName"`
+	Status    string `query:"status" form:"status"`
+}
+
+// Create User Group
+type CreateUserGroupRequest struct {
+	GroupName string `json:"groupName"`
+	GroupSort int    `json:"groupSort"`
+	Status    string `json:"status"`
+	Remark    string `json:"remark"`
+	RoleIds   []int  `json:"roleIds"`
+}
+
+// Update User Group
+type UpdateUserGroupRequest struct {
+	GroupId   int    `json:"groupId"`
+	GroupName string `json:"groupName"`
+	GroupSort int    `json:"groupSort"`
+	Status    string `json:"status"`
+	Remark    string `json:"remark"`
+	RoleIds   []int  `json:"roleIds"`
+}
+
+// Query allocated user group member list
+type UserGroupMemberListRequest struct {
+	PageRequest
+	GroupId     int    `query:"groupId" form:"groupId"`
+	UserName    string `query:"userName" form:"userName"`
+	Phonenumber string `query:"phonenumber" form:"phonenumber"`
+}
+
+// Batch add or remove user group members
+type UserGroupMemberRequest struct {
+	GroupId int    `query:"groupId" form:"groupId"`
+	UserIds string `query:"userIds" form:"userIds"`
+}
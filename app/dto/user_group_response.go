@@ -0,0 +1,24 @@
+package dto
+
+import "mira/anima/datetime"
+
+// User Group List
+type UserGroupListResponse struct {
+	GroupId     int               `json:"groupId"`
+	GroupName   string            `json:"groupName"`
+	GroupSort   int               `json:"groupSort"`
+	Status      string            `json:"status"`
+	MemberCount int               `json:"memberCount"`
+	CreateTime  datetime.Datetime `json:"createTime"`
+	Remark      string            `json:"remark"`
+}
+
+// User Group Details
+type UserGroupDetailResponse struct {
+	GroupId   int    `json:"groupId"`
+	GroupName string `json:"groupName"`
+	GroupSort int    `json:"groupSort"`
+	Status    string `json:"status"`
+	RoleIds   []int  `json:"roleIds" gorm:"-"`
+	Remark    string `json:"remark"`
+}
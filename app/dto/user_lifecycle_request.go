@@ -0,0 +1,61 @@
+package dto
+
+import "mira/anima/datetime"
+
+// Schedule User Activation and Deactivation
+type UserLifecycleScheduleRequest struct {
+	UserId         int               `json:"userId"`
+	ActivateTime   datetime.Datetime `json:"activateTime"`
+	DeactivateTime datetime.Datetime `json:"deactivateTime"`
+}
+
+// Offboard User
+type UserOffboardRequest struct {
+	UserId        int  `json:"userId"`
+	TransferTo    int  `json:"transferTo"`
+	ArchiveGrants bool `json:"archiveGrants"`
+}
+
+// Save Onboarding Template
+type SaveOnboardTemplate struct {
+	TemplateId   int    `json:"templateId"`
+	TemplateName string `json:"templateName"`
+	DeptId       int    `json:"deptId"`
+	PostId       int    `json:"postId"`
+	Status       string `json:"status"`
+	CreateBy     string `json:"createBy"`
+	UpdateBy     string `json:"updateBy"`
+	Remark       string `json:"remark"`
+}
+
+// Onboarding Template List
+type OnboardTemplateListRequest struct {
+	PageRequest
+	TemplateName string `query:"templateName" form:"templateName"`
+	DeptId       int    `query:"deptId" form:"deptId"`
+	PostId       int    `query:"postId" form:"postId"`
+	Status       string `query:"status" form:"status"`
+}
+
+// Create Onboarding Template
+type CreateOnboardTemplateRequest struct {
+	TemplateName string `json:"templateName"`
+	DeptId       int    `json:"deptId"`
+	PostId       int    `json:"postId"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+	RoleIds      []int  `json:"roleIds"`
+	PostIds      []int  `json:"postIds"`
+}
+
+// Update Onboarding Template
+type UpdateOnboardTemplateRequest struct {
+	TemplateId   int    `json:"templateId"`
+	TemplateName string `json:"templateName"`
+	DeptId       int    `json:"deptId"`
+	PostId       int    `json:"postId"`
+	Status       string `json:"status"`
+	Remark       string `json:"remark"`
+	RoleIds      []int  `json:"roleIds"`
+	PostIds      []int  `json:"postIds"`
+}
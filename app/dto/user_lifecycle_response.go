@@ -0,0 +1,63 @@
+package dto
+
+import "mira/anima/datetime"
+
+// User Onboarding Result
+type UserOnboardResponse struct {
+	UserId      int   `json:"userId"`
+	TemplateIds []int `json:"templateIds"`
+	RoleIds     []int `json:"roleIds"`
+	PostIds     []int `json:"postIds"`
+}
+
+// User Offboarding Report
+type UserOffboardReport struct {
+	UserId                int    `json:"userId"`
+	UserName              string `json:"userName"`
+	RevokedSessions       int    `json:"revokedSessions"`
+	RemovedRoles          int    `json:"removedRoles"`
+	RemovedGroups         int    `json:"removedGroups"`
+	RemovedDelegations    int    `json:"removedDelegations"`
+	ArchivedRoleIds       []int  `json:"archivedRoleIds,omitempty"`
+	ArchivedGroupIds      []int  `json:"archivedGroupIds,omitempty"`
+	ArchivedDelegationIds []int  `json:"archivedDelegationIds,omitempty"`
+	ExpiredApprovals      int    `json:"expiredApprovals"`
+	TransferTo            string `json:"transferTo"`
+	TransferredExportJobs int    `json:"transferredExportJobs"`
+}
+
+// User Offboarding Record
+type UserOffboardResponse struct {
+	OffboardId int                `json:"offboardId"`
+	UserId     int                `json:"userId"`
+	UserName   string             `json:"userName"`
+	TransferTo string             `json:"transferTo"`
+	Report     UserOffboardReport `json:"report" gorm:"-"`
+	CreateBy   string             `json:"createBy"`
+	CreateTime datetime.Datetime  `json:"createTime"`
+}
+
+// Onboarding Template List
+type OnboardTemplateListResponse struct {
+	TemplateId   int               `json:"templateId"`
+	TemplateName string            `json:"templateName"`
+	DeptId       int               `json:"deptId"`
+	DeptName     string            `json:"deptName"`
+	PostId       int               `json:"postId"`
+	PostName     string            `json:"postName"`
+	Status       string            `json:"status"`
+	CreateTime   datetime.Datetime `json:"createTime"`
+	Remark       string            `json:"remark"`
+}
+
+// Onboarding Template Details
+type OnboardTemplateDetailResponse struct {
+	TemplateId   int    `json:"templateId"`
+	TemplateName string `json:"templateName"`
+	DeptId       int    `json:"deptId"`
+	PostId       int    `json:"postId"`
+	Status       string `json:"status"`
+	RoleIds      []int  `json:"roleIds" gorm:"-"`
+	PostIds      []int  `json:"postIds" gorm:"-"`
+	Remark       string `json:"remark"`
+}
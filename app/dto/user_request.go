@@ -20,6 +20,12 @@ type SaveUser struct {
 	CreateBy    string            `json:"createBy"`
 	UpdateBy    string            `json:"updateBy"`
 	Remark      string            `json:"remark"`
+	// Attributes holds the custom attribute values by key, nil keeps the current values
+	Attributes map[string]string `json:"attributes"`
+	// OperatorId and Delegation confine a change authorized by a department delegation;
+	// a nil Delegation means the change is authorized by the global permission
+	OperatorId int              `json:"-"`
+	Delegation *DelegationScope `json:"-"`
 }
 
 // User List
@@ -31,37 +37,47 @@ type UserListRequest struct {
 	DeptId      int    `query:"deptId" form:"deptId"`
 	BeginTime   string `query:"params[beginTime]" form:"params[beginTime]"`
 	EndTime     string `query:"params[endTime]" form:"params[endTime]"`
+	// OrderByColumn sorts by userId (default), userName, nickName or createTime
+	OrderByColumn string `query:"orderByColumn" form:"orderByColumn"`
+	IsAsc         string `query:"isAsc" form:"isAsc"`
+	OrderRule     string `query:"-" form:"-" json:"-"`
+	// DeptIds restricts the list to the departments of a delegation scope, replacing the data scope
+	DeptIds []int `query:"-" form:"-" json:"-"`
+	// Attrs filters by searchable custom attributes, bound from the attrs[key] query parameters
+	Attrs map[string]string `query:"-" form:"-" json:"-"`
 }
 
 // Create User
 type CreateUserRequest struct {
-	DeptId      int    `json:"deptId"`
-	UserName    string `json:"userName"`
-	NickName    string `json:"nickName"`
-	Email       string `json:"email"`
-	Phonenumber string `json:"phonenumber"`
-	Sex         string `json:"sex"`
-	Password    string `json:"password"`
-	Status      string `json:"status"`
-	Remark      string `json:"remark"`
-	PostIds     []int  `json:"postIds"`
-	RoleIds     []int  `json:"roleIds"`
+	DeptId      int               `json:"deptId"`
+	UserName    string            `json:"userName"`
+	NickName    string            `json:"nickName"`
+	Email       string            `json:"email"`
+	Phonenumber string            `json:"phonenumber"`
+	Sex         string            `json:"sex"`
+	Password    string            `json:"password"`
+	Status      string            `json:"status"`
+	Remark      string            `json:"remark"`
+	PostIds     []int             `json:"postIds"`
+	RoleIds     []int             `json:"roleIds"`
+	Attributes  map[string]string `json:"attributes"`
 }
 
 // Update User
 type UpdateUserRequest struct {
-	UserId      int    `json:"userId"`
-	DeptId      int    `json:"deptId"`
-	UserName    string `json:"userName"`
-	NickName    string `json:"nickName"`
-	Email       string `json:"email"`
-	Phonenumber string `json:"phonenumber"`
-	Sex         string `json:"sex"`
-	Password    string `json:"password"`
-	Status      string `json:"status"`
-	Remark      string `json:"remark"`
-	PostIds     []int  `json:"postIds"`
-	RoleIds     []int  `json:"roleIds"`
+	UserId      int               `json:"userId"`
+	DeptId      int               `json:"deptId"`
+	UserName    string            `json:"userName"`
+	NickName    string            `json:"nickName"`
+	Email       string            `json:"email"`
+	Phonenumber string            `json:"phonenumber"`
+	Sex         string            `json:"sex"`
+	Password    string            `json:"password"`
+	Status      string            `json:"status"`
+	Remark      string            `json:"remark"`
+	PostIds     []int             `json:"postIds"`
+	RoleIds     []int             `json:"roleIds"`
+	Attributes  map[string]string `json:"attributes"`
 }
 
 // User Authorized Role
@@ -93,4 +109,7 @@ type UserImportRequest struct {
 	Phonenumber string `excel:"name:Phone Number;"`
 	Sex         string `excel:"name:User Gender;replace:0_Male,1_Female,2_Unknown;"`
 	Status      string `excel:"name:Account Status;replace:0_Normal,1_Disabled;"`
+	RoleNames   string `excel:"name:Role Names;"`
+	PostNames   string `excel:"name:Post Names;"`
+	Attributes  string `excel:"name:Custom Attributes;"`
 }
@@ -1,6 +1,10 @@
 package dto
 
-import "mira/anima/datetime"
+import (
+	"mira/anima/cipher"
+	"mira/anima/datetime"
+	"mira/app/model"
+)
 
 // User Authorization
 type UserTokenResponse struct {
@@ -16,43 +20,47 @@ type UserTokenResponse struct {
 
 // User List
 type UserListResponse struct {
-	UserId      int               `json:"userId"`
-	DeptId      int               `json:"deptId"`
-	UserName    string            `json:"userName"`
-	NickName    string            `json:"nickName"`
-	Email       string            `json:"email"`
-	Phonenumber string            `json:"phonenumber"`
-	Sex         string            `json:"sex"`
-	LoginIp     string            `json:"loginIp"`
-	LoginDate   datetime.Datetime `json:"loginDate"`
-	Status      string            `json:"status"`
-	CreateTime  datetime.Datetime `json:"createTime"`
+	UserId      int                                     `json:"userId"`
+	DeptId      int                                     `json:"deptId"`
+	UserName    string                                  `json:"userName"`
+	NickName    string                                  `json:"nickName"`
+	Email       cipher.String[model.SysUserEmail]       `json:"email"`
+	Phonenumber cipher.String[model.SysUserPhonenumber] `json:"phonenumber"`
+	Sex         string                                  `json:"sex"`
+	LoginIp     cipher.String[model.SysUserLoginIP]     `json:"loginIp"`
+	LoginDate   datetime.Datetime                       `json:"loginDate"`
+	Status      string                                  `json:"status"`
+	CreateTime  datetime.Datetime                       `json:"createTime"`
 	Dept        struct {
 		DeptId   int    `json:"deptId"`
 		DeptName string `json:"deptName"`
 		Leader   string `json:"leader"`
 	} `json:"dept" gorm:"-"`
-	DeptName string `json:"-"`
-	Leader   string `json:"-"`
+	DeptName   string            `json:"-"`
+	Leader     string            `json:"-"`
+	Attributes map[string]string `json:"attributes" gorm:"-"`
 }
 
 // User Details
 type UserDetailResponse struct {
-	UserId      int               `json:"userId"`
-	DeptId      int               `json:"deptId"`
-	UserName    string            `json:"userName"`
-	NickName    string            `json:"nickName"`
-	UserType    string            `json:"userType"`
-	Email       string            `json:"email"`
-	Phonenumber string            `json:"phonenumber"`
-	Sex         string            `json:"sex"`
-	Avatar      string            `json:"avatar"`
-	Password    string            `json:"-"`
-	LoginIP     string            `json:"loginIp"`
-	LoginDate   datetime.Datetime `json:"loginDate"`
-	Status      string            `json:"status"`
-	CreateTime  datetime.Datetime `json:"createTime"`
-	Admin       bool              `json:"admin" gorm:"-"`
+	UserId         int                                     `json:"userId"`
+	DeptId         int                                     `json:"deptId"`
+	UserName       string                                  `json:"userName"`
+	NickName       string                                  `json:"nickName"`
+	UserType       string                                  `json:"userType"`
+	Email          cipher.String[model.SysUserEmail]       `json:"email"`
+	Phonenumber    cipher.String[model.SysUserPhonenumber] `json:"phonenumber"`
+	Sex            string                                  `json:"sex"`
+	Avatar         string                                  `json:"avatar"`
+	Password       string                                  `json:"-"`
+	LoginIP        cipher.String[model.SysUserLoginIP]     `json:"loginIp"`
+	LoginDate      datetime.Datetime                       `json:"loginDate"`
+	Status         string                                  `json:"status"`
+	ActivateTime   datetime.Datetime                       `json:"activateTime"`
+	DeactivateTime datetime.Datetime                       `json:"deactivateTime"`
+	CreateTime     datetime.Datetime                       `json:"createTime"`
+	Admin          bool                                    `json:"admin" gorm:"-"`
+	Attributes     map[string]string                       `json:"attributes" gorm:"-"`
 }
 
 // Authorized User Information
@@ -62,17 +70,18 @@ type AuthUserInfoResponse struct {
 	Roles []RoleListResponse `json:"roles"`
 }
 
-// User Export
-type UserExportResponse struct {
-	UserId      int    `excel:"name:User ID;"`
+// User Import Error Report
+type UserImportErrorResponse struct {
+	RowNum      int    `excel:"name:Row;"`
+	DeptId      int    `excel:"name:Dept ID;"`
 	UserName    string `excel:"name:Login Name;"`
 	NickName    string `excel:"name:User Name;"`
 	Email       string `excel:"name:User Email;"`
 	Phonenumber string `excel:"name:Phone Number;"`
 	Sex         string `excel:"name:User Gender;replace:0_Male,1_Female,2_Unknown;"`
 	Status      string `excel:"name:Account Status;replace:0_Normal,1_Disabled;"`
-	LoginIp     string `excel:"name:Last Login IP;"`
-	LoginDate   string `excel:"name:Last Login Time;"`
-	DeptName    string `excel:"name:Dept Name;"`
-	DeptLeader  string `excel:"name:Dept Leader;"`
+	RoleNames   string `excel:"name:Role Names;"`
+	PostNames   string `excel:"name:Post Names;"`
+	Attributes  string `excel:"name:Custom Attributes;"`
+	ErrorMsg    string `excel:"name:Error;"`
 }
@@ -13,6 +13,13 @@ import (
 // AuthMiddleware for authentication
 func AuthMiddleware() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		// A replayed change request runs under the requester's identity instead of a token
+		if authUser := ApprovalReplayUser(ctx.Request.Context()); authUser != nil {
+			ctx.Set(token.UserTokenKey, authUser)
+			ctx.Next()
+			return
+		}
+
 		tokenKey, err := token.GetUserTokenKey(ctx)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": http.StatusUnauthorized, "msg": "Not logged in"})
@@ -40,6 +47,8 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		ctx.Set(token.UserTokenKey, authUser)
+
 		ctx.Next()
 	}
 }
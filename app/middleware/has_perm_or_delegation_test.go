@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDelegationService is a mock type for the DelegationService
+type MockDelegationService struct {
+	service.DelegationService
+	mock.Mock
+}
+
+var _ service.DelegationServiceInterface = (*MockDelegationService)(nil)
+
+// GetDelegationScope is a mock method
+func (m *MockDelegationService) GetDelegationScope(userId int) (dto.DelegationScope, error) {
+	args := m.Called(userId)
+	return args.Get(0).(dto.DelegationScope), args.Error(1)
+}
+
+func TestHasPermOrDelegation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(mockSecurity *MockSecurity, mockDelegation *MockDelegationService, userId int, perm string, scope **dto.DelegationScope) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set(token.UserTokenKey, &token.UserTokenResponse{
+				UserTokenResponse: dto.UserTokenResponse{
+					UserId: userId,
+				},
+			})
+			c.Next()
+		})
+		r.Use(HasPermOrDelegation(mockSecurity, mockDelegation, perm))
+		r.GET("/test", func(c *gin.Context) {
+			*scope = security.GetDelegationScope(c)
+			c.Status(http.StatusOK)
+		})
+		return r
+	}
+
+	t.Run("should allow access without scope when user has permission", func(t *testing.T) {
+		mockSecurity := new(MockSecurity)
+		mockSecurity.On("HasPerm", 2, "system:user:edit").Return(true)
+		mockDelegation := new(MockDelegationService)
+
+		var scope *dto.DelegationScope
+		w := httptest.NewRecorder()
+		newRouter(mockSecurity, mockDelegation, 2, "system:user:edit", &scope).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, scope)
+		mockDelegation.AssertNotCalled(t, "GetDelegationScope", mock.Anything)
+	})
+
+	t.Run("should allow access with scope when user administers a delegation", func(t *testing.T) {
+		mockSecurity := new(MockSecurity)
+		mockSecurity.On("HasPerm", 2, "system:user:edit").Return(false)
+		mockDelegation := new(MockDelegationService)
+		mockDelegation.On("GetDelegationScope", 2).Return(dto.DelegationScope{DeptIds: []int{103, 104}, RoleIds: []int{2}}, nil)
+
+		var scope *dto.DelegationScope
+		w := httptest.NewRecorder()
+		newRouter(mockSecurity, mockDelegation, 2, "system:user:edit", &scope).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		if assert.NotNil(t, scope) {
+			assert.Equal(t, []int{103, 104}, scope.DeptIds)
+			assert.Equal(t, []int{2}, scope.RoleIds)
+		}
+	})
+
+	t.Run("should deny access without permission or delegation", func(t *testing.T) {
+		mockSecurity := new(MockSecurity)
+		mockSecurity.On("HasPerm", 2, "system:user:edit").Return(false)
+		mockDelegation := new(MockDelegationService)
+		mockDelegation.On("GetDelegationScope", 2).Return(dto.DelegationScope{}, nil)
+
+		var scope *dto.DelegationScope
+		w := httptest.NewRecorder()
+		newRouter(mockSecurity, mockDelegation, 2, "system:user:edit", &scope).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		mockDelegation.AssertExpectations(t)
+	})
+
+	t.Run("should deny delegates actions the delegation does not grant", func(t *testing.T) {
+		mockSecurity := new(MockSecurity)
+		mockSecurity.On("HasPerm", 2, "system:user:remove").Return(false)
+		mockDelegation := new(MockDelegationService)
+
+		var scope *dto.DelegationScope
+		w := httptest.NewRecorder()
+		newRouter(mockSecurity, mockDelegation, 2, "system:user:remove", &scope).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Nil(t, scope)
+		mockDelegation.AssertNotCalled(t, "GetDelegationScope", mock.Anything)
+	})
+
+	t.Run("should allow access for admin user", func(t *testing.T) {
+		mockSecurity := new(MockSecurity)
+		mockDelegation := new(MockDelegationService)
+
+		var scope *dto.DelegationScope
+		w := httptest.NewRecorder()
+		newRouter(mockSecurity, mockDelegation, 1, "system:user:edit", &scope).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, scope)
+		mockSecurity.AssertNotCalled(t, "HasPerm", mock.Anything, mock.Anything)
+	})
+}
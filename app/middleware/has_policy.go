@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HasPolicy evaluates the attribute-based policies attached to an action.
+// The entities named by the request are loaded and exposed to policies as the
+// resource, on top of the request payload (query, JSON body and path parameters),
+// and every one of them must be allowed; entities that fail to load deny access. Use it next to HasPerm on routes that
+// need conditions beyond role permissions.
+func HasPolicy(policyService service.PolicyServiceInterface, action string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authUserId := security.GetAuthUserId(ctx)
+		if authUserId == 1 {
+			ctx.Next()
+			return
+		}
+
+		resources, err := policyService.LoadResources(action, RequestResource(ctx))
+		if err != nil {
+			response.NewError().SetStatus(http.StatusForbidden).SetCode(601).SetMsg("Access denied: " + err.Error()).Json(ctx)
+			ctx.Abort()
+			return
+		}
+
+		now := time.Now()
+		for _, resource := range resources {
+			decision := policyService.Evaluate(action, dto.PolicyContext{
+				UserId:   authUserId,
+				Resource: resource,
+				Ip:       ctx.ClientIP(),
+				Time:     now,
+			})
+			if !decision.Allowed {
+				response.NewError().SetStatus(http.StatusForbidden).SetCode(601).SetMsg("Access denied: " + decision.Reason).Json(ctx)
+				ctx.Abort()
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequestResource collects query parameters, the JSON body and path parameters
+// into a map, in that order so that path parameters are never shadowed. The
+// request body is restored so handlers can bind it again.
+func RequestResource(ctx *gin.Context) map[string]interface{} {
+	resource := make(map[string]interface{})
+
+	for key, values := range ctx.Request.URL.Query() {
+		if len(values) == 1 {
+			resource[key] = values[0]
+		} else {
+			resource[key] = values
+		}
+	}
+
+	if ctx.ContentType() == "application/json" && ctx.Request.Body != nil {
+		bodyBytes, _ := ctx.GetRawData()
+		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		var jsonParams map[string]interface{}
+		if err := json.Unmarshal(bodyBytes, &jsonParams); err == nil {
+			for key, value := range jsonParams {
+				resource[key] = value
+			}
+		}
+	}
+
+	for _, param := range ctx.Params {
+		resource[param.Key] = param.Value
+	}
+
+	return resource
+}
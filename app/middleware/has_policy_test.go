@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mira/app/dto"
+	"mira/app/service"
+	"mira/app/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPolicyService is a mock type for the PolicyService
+type MockPolicyService struct {
+	mock.Mock
+	service.PolicyServiceInterface
+}
+
+// Evaluate is a mock method
+func (m *MockPolicyService) Evaluate(action string, input dto.PolicyContext) dto.PolicyDecisionResponse {
+	args := m.Called(action, input.UserId, input.Resource)
+	return args.Get(0).(dto.PolicyDecisionResponse)
+}
+
+// LoadResources is a mock method
+func (m *MockPolicyService) LoadResources(action string, request map[string]interface{}) ([]map[string]interface{}, error) {
+	args := m.Called(action, request)
+	resources, _ := args.Get(0).([]map[string]interface{})
+	return resources, args.Error(1)
+}
+
+func TestHasPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(userId int, policyService service.PolicyServiceInterface) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set(token.UserTokenKey, &token.UserTokenResponse{
+				UserTokenResponse: dto.UserTokenResponse{
+					UserId: userId,
+				},
+			})
+			c.Next()
+		})
+		r.PUT("/test/:userId", HasPolicy(policyService, "test:action"), func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, string(body))
+		})
+		return r
+	}
+
+	t.Run("should allow access and keep the request body", func(t *testing.T) {
+		mockPolicy := new(MockPolicyService)
+		request := map[string]interface{}{"userId": "3", "deptId": float64(105)}
+		mockPolicy.On("LoadResources", "test:action", request).Return([]map[string]interface{}{request}, nil)
+		mockPolicy.On("Evaluate", "test:action", 2, request).
+			Return(dto.PolicyDecisionResponse{Allowed: true})
+
+		req := httptest.NewRequest(http.MethodPut, "/test/3", strings.NewReader(`{"deptId":105}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		newRouter(2, mockPolicy).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `{"deptId":105}`, w.Body.String())
+		mockPolicy.AssertExpectations(t)
+	})
+
+	t.Run("should deny access when policy denies", func(t *testing.T) {
+		mockPolicy := new(MockPolicyService)
+		mockPolicy.On("LoadResources", "test:action", mock.Anything).Return([]map[string]interface{}{{"userId": 2}}, nil)
+		mockPolicy.On("Evaluate", "test:action", 2, mock.Anything).
+			Return(dto.PolicyDecisionResponse{Allowed: false, Reason: "denied by policy no self edit"})
+
+		req := httptest.NewRequest(http.MethodPut, "/test/2", nil)
+		w := httptest.NewRecorder()
+
+		newRouter(2, mockPolicy).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "denied by policy no self edit")
+	})
+
+	t.Run("should not let the body shadow path parameters", func(t *testing.T) {
+		mockPolicy := new(MockPolicyService)
+		request := map[string]interface{}{"userId": "3"}
+		mockPolicy.On("LoadResources", "test:action", request).Return([]map[string]interface{}{request}, nil)
+		mockPolicy.On("Evaluate", "test:action", 2, request).Return(dto.PolicyDecisionResponse{Allowed: true})
+
+		req := httptest.NewRequest(http.MethodPut, "/test/3", strings.NewReader(`{"userId":4}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		newRouter(2, mockPolicy).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockPolicy.AssertExpectations(t)
+	})
+
+	t.Run("should deny access when any loaded entity is denied", func(t *testing.T) {
+		mockPolicy := new(MockPolicyService)
+		mockPolicy.On("LoadResources", "test:action", mock.Anything).
+			Return([]map[string]interface{}{{"userId": 3, "deptId": 105}, {"userId": 4, "deptId": 200}}, nil)
+		mockPolicy.On("Evaluate", "test:action", 2, map[string]interface{}{"userId": 3, "deptId": 105}).
+			Return(dto.PolicyDecisionResponse{Allowed: true})
+		mockPolicy.On("Evaluate", "test:action", 2, map[string]interface{}{"userId": 4, "deptId": 200}).
+			Return(dto.PolicyDecisionResponse{Allowed: false, Reason: "no allow policy matched: own dept"})
+
+		req := httptest.NewRequest(http.MethodPut, "/test/3,4", nil)
+		w := httptest.NewRecorder()
+
+		newRouter(2, mockPolicy).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "own dept")
+		mockPolicy.AssertExpectations(t)
+	})
+
+	t.Run("should deny access when the entities cannot be loaded", func(t *testing.T) {
+		mockPolicy := new(MockPolicyService)
+		mockPolicy.On("LoadResources", "test:action", mock.Anything).Return(nil, errors.New("failed to load user 3"))
+
+		req := httptest.NewRequest(http.MethodPut, "/test/3", nil)
+		w := httptest.NewRecorder()
+
+		newRouter(2, mockPolicy).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "failed to load user 3")
+		mockPolicy.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("should allow access for admin user", func(t *testing.T) {
+		mockPolicy := new(MockPolicyService)
+
+		req := httptest.NewRequest(http.MethodPut, "/test/2", nil)
+		w := httptest.NewRecorder()
+
+		newRouter(1, mockPolicy).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockPolicy.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
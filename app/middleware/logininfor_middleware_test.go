@@ -35,6 +35,12 @@ func (m *MockLogininforService) GetLogininforList(param dto.LogininforListReques
 	return args.Get(0).([]dto.LogininforListResponse), args.Int(1)
 }
 
+// GetLogininforPage is a mock method
+func (m *MockLogininforService) GetLogininforPage(param dto.LogininforListRequest) ([]dto.LogininforListResponse, int, string, error) {
+	args := m.Called(param)
+	return args.Get(0).([]dto.LogininforListResponse), args.Int(1), args.String(2), args.Error(3)
+}
+
 // DeleteLogininfor is a mock method
 func (m *MockLogininforService) DeleteLogininfor(logininforIds []int) error {
 	args := m.Called(logininforIds)
@@ -47,6 +53,12 @@ func (m *MockLogininforService) Unlock(userName string) error {
 	return args.Error(0)
 }
 
+// ExportDataset is a mock method
+func (m *MockLogininforService) ExportDataset(param dto.LogininforListRequest) service.ExportDataset {
+	args := m.Called(param)
+	return args.Get(0).(service.ExportDataset)
+}
+
 func TestLogininforMiddleware(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
@@ -36,12 +36,24 @@ func (m *MockOperLogService) GetOperLogList(param dto.OperLogListRequest, isPagi
 	return args.Get(0).([]dto.OperLogListResponse), args.Int(1)
 }
 
+// GetOperLogPage is a mock method
+func (m *MockOperLogService) GetOperLogPage(param dto.OperLogListRequest) ([]dto.OperLogListResponse, int, string, error) {
+	args := m.Called(param)
+	return args.Get(0).([]dto.OperLogListResponse), args.Int(1), args.String(2), args.Error(3)
+}
+
 // CreateSysOperLog is a mock method
 func (m *MockOperLogService) CreateSysOperLog(param dto.SaveOperLogRequest) error {
 	args := m.Called(param)
 	return args.Error(0)
 }
 
+// ExportDataset is a mock method
+func (m *MockOperLogService) ExportDataset(param dto.OperLogListRequest) service.ExportDataset {
+	args := m.Called(param)
+	return args.Get(0).(service.ExportDataset)
+}
+
 func TestOperLogMiddleware(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
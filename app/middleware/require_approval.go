@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"unicode/utf8"
+
+	"mira/anima/response"
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+// approvalReplayKey marks a request replayed from an approved change request
+type approvalReplayKey struct{}
+
+// approvalBodyPrefix marks request bodies stored base64 encoded, such as uploaded files,
+// which are not valid text
+const approvalBodyPrefix = "base64:"
+
+// RequireApproval holds requests to an action configured for four-eyes approval
+// (see service.APPROVAL_ACTIONS_CONFIG_KEY). Instead of running the handler, the
+// request is captured as a pending change request; approving it replays the
+// request under the requester's identity. When changes are given, only requests
+// changing one of those assignments are held. Place it after OperLogMiddleware so
+// the submission is recorded in the operation log.
+func RequireApproval(approvalService service.ApprovalServiceInterface, action, title string, changes ...service.ApprovalChange) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ApprovalReplayUser(ctx.Request.Context()) != nil || !approvalService.IsApprovalRequired(action) {
+			ctx.Next()
+			return
+		}
+
+		authUser := security.GetAuthUser(ctx)
+		if authUser == nil {
+			response.NewError().SetStatus(http.StatusUnauthorized).SetCode(http.StatusUnauthorized).SetMsg("Not logged in").Json(ctx)
+			ctx.Abort()
+			return
+		}
+
+		bodyBytes, _ := ctx.GetRawData()
+		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		if len(changes) > 0 && !isChangeRequested(approvalService, changes, bodyBytes) {
+			ctx.Next()
+			return
+		}
+
+		approvalId, err := approvalService.CreateApproval(dto.SaveApproval{
+			Action:        action,
+			Title:         title,
+			RequestMethod: ctx.Request.Method,
+			RequestUrl:    ctx.Request.URL.RequestURI(),
+			RequestBody:   encodeApprovalBody(bodyBytes),
+			ContentType:   ctx.GetHeader("Content-Type"),
+			RequesterId:   authUser.UserId,
+			RequesterName: authUser.UserName,
+		})
+		if err != nil {
+			response.NewError().SetMsg(err.Error()).Json(ctx)
+			ctx.Abort()
+			return
+		}
+
+		response.NewSuccess().SetMsg("Submitted for approval").SetData("approvalId", approvalId).Json(ctx)
+		ctx.Abort()
+	}
+}
+
+// isChangeRequested reports whether a request body changes any of the assignments
+func isChangeRequested(approvalService service.ApprovalServiceInterface, changes []service.ApprovalChange, body []byte) bool {
+	for _, change := range changes {
+		if approvalService.IsChangeRequested(change, body) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeApprovalBody returns a request body as stored in a change request
+func encodeApprovalBody(body []byte) string {
+	if utf8.Valid(body) && !bytes.HasPrefix(body, []byte(approvalBodyPrefix)) {
+		return string(body)
+	}
+	return approvalBodyPrefix + base64.StdEncoding.EncodeToString(body)
+}
+
+// decodeApprovalBody returns the request body stored in a change request
+func decodeApprovalBody(body string) ([]byte, error) {
+	if !strings.HasPrefix(body, approvalBodyPrefix) {
+		return []byte(body), nil
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimPrefix(body, approvalBodyPrefix))
+}
+
+// ApprovalReplayUser returns the requester a replayed change request runs as, or nil
+func ApprovalReplayUser(ctx context.Context) *token.UserTokenResponse {
+	authUser, _ := ctx.Value(approvalReplayKey{}).(*token.UserTokenResponse)
+	return authUser
+}
+
+// ReplayApproval executes an approved change request through handler as the requester.
+// The replayed request skips token authentication and RequireApproval, but still passes
+// permission, policy and operation log middlewares. The client address of the reviewing
+// request is kept. Returns the HTTP status and response body.
+func ReplayApproval(ctx *gin.Context, handler http.Handler, approval dto.ApprovalDetailResponse, authUser *token.UserTokenResponse) (int, string) {
+	body, err := decodeApprovalBody(approval.RequestBody)
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	req, err := http.NewRequest(approval.RequestMethod, approval.RequestUrl, bytes.NewReader(body))
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+
+	if approval.ContentType != "" {
+		req.Header.Set("Content-Type", approval.ContentType)
+	}
+	req.Header.Set("User-Agent", ctx.Request.UserAgent())
+	req.RemoteAddr = ctx.Request.RemoteAddr
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req.WithContext(context.WithValue(ctx.Request.Context(), approvalReplayKey{}, authUser)))
+
+	return rec.Code, rec.Body.String()
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mira/app/dto"
+	"mira/app/security"
+	"mira/app/service"
+	"mira/app/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockApprovalService is a mock type for the ApprovalService
+type MockApprovalService struct {
+	mock.Mock
+	service.ApprovalServiceInterface
+}
+
+// IsApprovalRequired is a mock method
+func (m *MockApprovalService) IsApprovalRequired(action string) bool {
+	args := m.Called(action)
+	return args.Bool(0)
+}
+
+// CreateApproval is a mock method
+func (m *MockApprovalService) CreateApproval(param dto.SaveApproval) (int, error) {
+	args := m.Called(param)
+	return args.Int(0), args.Error(1)
+}
+
+// IsChangeRequested is a mock method
+func (m *MockApprovalService) IsChangeRequested(change service.ApprovalChange, body []byte) bool {
+	args := m.Called(change, string(body))
+	return args.Bool(0)
+}
+
+func TestRequireApproval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// authenticate stands in for AuthMiddleware on regular requests
+	authenticate := func(c *gin.Context) {
+		c.Set(token.UserTokenKey, &token.UserTokenResponse{
+			UserTokenResponse: dto.UserTokenResponse{UserId: 2, UserName: "editor"},
+		})
+		c.Next()
+	}
+
+	t.Run("should run handler when approval is not required", func(t *testing.T) {
+		mockApproval := new(MockApprovalService)
+		mockApproval.On("IsApprovalRequired", "test:action").Return(false)
+
+		req := httptest.NewRequest(http.MethodPut, "/test/3", strings.NewReader(`{"a":1}`))
+		w := httptest.NewRecorder()
+
+		r := gin.New()
+		r.Use(authenticate)
+		r.PUT("/test/:userId", RequireApproval(mockApproval, "test:action", "Test"), func(c *gin.Context) {
+			c.String(http.StatusOK, "done")
+		})
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, "done", w.Body.String())
+		mockApproval.AssertExpectations(t)
+	})
+
+	t.Run("should capture request as change request", func(t *testing.T) {
+		mockApproval := new(MockApprovalService)
+		mockApproval.On("IsApprovalRequired", "test:action").Return(true)
+		mockApproval.On("CreateApproval", dto.SaveApproval{
+			Action:        "test:action",
+			Title:         "Test",
+			RequestMethod: http.MethodPut,
+			RequestUrl:    "/test/3?force=1",
+			RequestBody:   `{"a":1}`,
+			ContentType:   "application/json",
+			RequesterId:   2,
+			RequesterName: "editor",
+		}).Return(7, nil)
+
+		r := gin.New()
+		r.Use(authenticate)
+		r.PUT("/test/:userId", RequireApproval(mockApproval, "test:action", "Test"), func(c *gin.Context) {
+			t.Fatal("handler must not run before approval")
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/test/3?force=1", strings.NewReader(`{"a":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		assert.Equal(t, float64(7), body["approvalId"])
+		mockApproval.AssertExpectations(t)
+	})
+
+	t.Run("should hold only requests changing the assignments", func(t *testing.T) {
+		mockApproval := new(MockApprovalService)
+		mockApproval.On("IsApprovalRequired", "test:action").Return(true)
+		mockApproval.On("IsChangeRequested", service.ApprovalChangeUserRoles, `{"userId":3,"nickName":"x"}`).Return(false)
+		mockApproval.On("IsChangeRequested", service.ApprovalChangeUserRoles, `{"userId":3,"roleIds":[1]}`).Return(true)
+		mockApproval.On("CreateApproval", mock.Anything).Return(8, nil)
+
+		r := gin.New()
+		r.Use(authenticate)
+		r.PUT("/test", RequireApproval(mockApproval, "test:action", "Test", service.ApprovalChangeUserRoles), func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, string(body))
+		})
+
+		req := httptest.NewRequest(http.MethodPut, "/test", strings.NewReader(`{"userId":3,"nickName":"x"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, `{"userId":3,"nickName":"x"}`, w.Body.String())
+
+		req = httptest.NewRequest(http.MethodPut, "/test", strings.NewReader(`{"userId":3,"roleIds":[1]}`))
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		assert.Equal(t, float64(8), body["approvalId"])
+		mockApproval.AssertExpectations(t)
+	})
+
+	t.Run("should replay approved request as the requester", func(t *testing.T) {
+		mockApproval := new(MockApprovalService)
+
+		r := gin.New()
+		r.Use(AuthMiddleware())
+		r.PUT("/test/:userId", RequireApproval(mockApproval, "test:action", "Test"), func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, security.GetAuthUserName(c)+":"+string(body))
+		})
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPut, "/approve", nil)
+
+		status, body := ReplayApproval(c, r, dto.ApprovalDetailResponse{
+			RequestMethod: http.MethodPut,
+			RequestUrl:    "/test/3",
+			RequestBody:   `{"a":1}`,
+			ContentType:   "application/json",
+		}, &token.UserTokenResponse{UserTokenResponse: dto.UserTokenResponse{UserId: 5, UserName: "requester"}})
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `requester:{"a":1}`, body)
+		mockApproval.AssertNotCalled(t, "IsApprovalRequired", mock.Anything)
+	})
+
+	t.Run("should keep uploaded files intact through approval", func(t *testing.T) {
+		upload := "PK\x03\x04\xff\xfe"
+		mockApproval := new(MockApprovalService)
+		mockApproval.On("IsApprovalRequired", "test:action").Return(true)
+		mockApproval.On("CreateApproval", mock.Anything).Return(9, nil)
+
+		r := gin.New()
+		r.Use(authenticate)
+		r.POST("/test", RequireApproval(mockApproval, "test:action", "Test"), func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			c.String(http.StatusOK, string(body))
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(upload))
+		req.Header.Set("Content-Type", "application/octet-stream")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		saved := mockApproval.Calls[1].Arguments.Get(0).(dto.SaveApproval)
+		assert.True(t, strings.HasPrefix(saved.RequestBody, approvalBodyPrefix))
+
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPut, "/approve", nil)
+
+		status, body := ReplayApproval(c, r, dto.ApprovalDetailResponse{
+			RequestMethod: http.MethodPost,
+			RequestUrl:    "/test",
+			RequestBody:   saved.RequestBody,
+			ContentType:   saved.ContentType,
+		}, &token.UserTokenResponse{UserTokenResponse: dto.UserTokenResponse{UserId: 5, UserName: "requester"}})
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, upload, body)
+	})
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScimAuthMiddleware authenticates the identity provider by the bearer token of the SCIM
+// configuration. Every request is refused while no token is configured.
+func ScimAuthMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		expected := config.Data.Scim.Token
+		bearer, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+
+		if expected == "" || !found || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(bearer)), []byte(expected)) != 1 {
+			ctx.Header("Content-Type", constant.SCIM_CONTENT_TYPE)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, dto.ScimErrorResponse{
+				Schemas: []string{constant.SCIM_MESSAGE_ERROR},
+				Status:  strconv.Itoa(http.StatusUnauthorized),
+				Detail:  "Not authorized",
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
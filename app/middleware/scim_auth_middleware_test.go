@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mira/common/types/constant"
+	"mira/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScimAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(ScimAuthMiddleware())
+	r.GET("/scim/v2/Users", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should refuse every request while no token is configured", func(t *testing.T) {
+		config.Data.Scim.Token = ""
+
+		w := request("Bearer ")
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, constant.SCIM_CONTENT_TYPE, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"401","detail":"Not authorized"}`, w.Body.String())
+	})
+
+	config.Data.Scim.Token = "scim-secret"
+	defer func() { config.Data.Scim.Token = "" }()
+
+	t.Run("should pass a request with the configured bearer token", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("Bearer scim-secret").Code)
+	})
+
+	t.Run("should refuse a missing or wrong token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request("").Code)
+		assert.Equal(t, http.StatusUnauthorized, request("Bearer wrong").Code)
+		assert.Equal(t, http.StatusUnauthorized, request("scim-secret").Code)
+	})
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysApproval struct {
+	ApprovalId    int `gorm:"primaryKey;autoIncrement"`
+	Action        string
+	Title         string
+	RequestMethod string
+	RequestUrl    string
+	RequestBody   string
+	ContentType   string
+	RequesterId   int
+	RequesterName string
+	Status        string `gorm:"default:0"`
+	ReviewerId    int
+	ReviewerName  string
+	ReviewReason  string
+	ReviewTime    datetime.Datetime
+	JsonResult    string
+	ExpireTime    datetime.Datetime
+	CreateTime    datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateTime    datetime.Datetime `gorm:"autoUpdateTime"`
+}
+
+func (SysApproval) TableName() string {
+	return "sys_approval"
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysConfigHistory struct {
+	HistoryId     int `gorm:"primaryKey;autoIncrement"`
+	EntityType    string
+	EntityId      int
+	EntityName    string
+	Version       int
+	Action        string
+	OldValue      string
+	NewValue      string
+	SourceVersion int
+	Reason        string
+	CreateBy      string
+	CreateTime    datetime.Datetime `gorm:"autoCreateTime"`
+}
+
+func (SysConfigHistory) TableName() string {
+	return "sys_config_history"
+}
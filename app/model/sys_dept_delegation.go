@@ -0,0 +1,22 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysDeptDelegation struct {
+	DelegationId int `gorm:"primaryKey;autoIncrement"`
+	DeptId       int
+	DelegateType string
+	DelegateId   int
+	Status       string `gorm:"default:0"`
+	CreateBy     string
+	CreateTime   datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateBy     string
+	UpdateTime   datetime.Datetime `gorm:"autoUpdateTime"`
+	Remark       string
+}
+
+func (SysDeptDelegation) TableName() string {
+	return "sys_dept_delegation"
+}
@@ -0,0 +1,10 @@
+package model
+
+type SysDeptDelegationRole struct {
+	DelegationId int
+	RoleId       int
+}
+
+func (SysDeptDelegationRole) TableName() string {
+	return "sys_dept_delegation_role"
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysExportJob struct {
+	JobId      int `gorm:"primaryKey;autoIncrement"`
+	Dataset    string
+	Format     string
+	FileName   string
+	FilePath   string
+	Status     string `gorm:"default:0"`
+	Total      int
+	Processed  int
+	ErrorMsg   string
+	CreateBy   string
+	CreateTime datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateTime datetime.Datetime `gorm:"autoUpdateTime"`
+	FinishTime datetime.Datetime
+}
+
+func (SysExportJob) TableName() string {
+	return "sys_export_job"
+}
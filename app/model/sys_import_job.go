@@ -0,0 +1,25 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysImportJob struct {
+	JobId      int `gorm:"primaryKey;autoIncrement"`
+	JobType    string
+	FileName   string
+	Status     string `gorm:"default:0"`
+	Total      int
+	Processed  int
+	Succeeded  int
+	Failed     int
+	ErrorMsg   string
+	CreateBy   string
+	CreateTime datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateTime datetime.Datetime `gorm:"autoUpdateTime"`
+	FinishTime datetime.Datetime
+}
+
+func (SysImportJob) TableName() string {
+	return "sys_import_job"
+}
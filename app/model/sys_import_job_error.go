@@ -0,0 +1,13 @@
+package model
+
+type SysImportJobError struct {
+	ErrorId  int `gorm:"primaryKey;autoIncrement"`
+	JobId    int
+	RowNum   int
+	RowData  string
+	ErrorMsg string
+}
+
+func (SysImportJobError) TableName() string {
+	return "sys_import_job_error"
+}
@@ -1,11 +1,15 @@
 package model
 
-import "mira/anima/datetime"
+import (
+	"mira/anima/cipher"
+	"mira/anima/datetime"
+)
 
 type SysLogininfor struct {
 	InfoId        int `gorm:"primaryKey;autoIncrement"`
 	UserName      string
-	Ipaddr        string
+	Ipaddr        cipher.String[SysLogininforIpaddr]
+	IpaddrHash    string `blindIndex:"Ipaddr"`
 	LoginLocation string
 	Browser       string
 	Os            string
@@ -17,3 +21,10 @@ type SysLogininfor struct {
 func (SysLogininfor) TableName() string {
 	return "sys_logininfor"
 }
+
+// SysLogininforIpaddr labels the encrypted column of sys_logininfor
+type SysLogininforIpaddr struct{}
+
+func (SysLogininforIpaddr) Label() string {
+	return "sys_logininfor.ipaddr"
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysOnboardTemplate struct {
+	TemplateId   int `gorm:"primaryKey;autoIncrement"`
+	TemplateName string
+	DeptId       int
+	PostId       int
+	Status       string `gorm:"default:0"`
+	CreateBy     string
+	CreateTime   datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateBy     string
+	UpdateTime   datetime.Datetime `gorm:"autoUpdateTime"`
+	Remark       string
+}
+
+func (SysOnboardTemplate) TableName() string {
+	return "sys_onboard_template"
+}
@@ -0,0 +1,10 @@
+package model
+
+type SysOnboardTemplatePost struct {
+	TemplateId int
+	PostId     int
+}
+
+func (SysOnboardTemplatePost) TableName() string {
+	return "sys_onboard_template_post"
+}
@@ -0,0 +1,10 @@
+package model
+
+type SysOnboardTemplateRole struct {
+	TemplateId int
+	RoleId     int
+}
+
+func (SysOnboardTemplateRole) TableName() string {
+	return "sys_onboard_template_role"
+}
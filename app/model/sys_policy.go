@@ -0,0 +1,23 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysPolicy struct {
+	PolicyId   int `gorm:"primaryKey;autoIncrement"`
+	PolicyName string
+	Action     string
+	Effect     string `gorm:"default:allow"`
+	Condition  string
+	Status     string `gorm:"default:0"`
+	CreateBy   string
+	CreateTime datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateBy   string
+	UpdateTime datetime.Datetime `gorm:"autoUpdateTime"`
+	Remark     string
+}
+
+func (SysPolicy) TableName() string {
+	return "sys_policy"
+}
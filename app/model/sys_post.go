@@ -8,6 +8,7 @@ import (
 
 type SysPost struct {
 	PostId     int `gorm:"primaryKey;autoIncrement"`
+	ParentId   int
 	PostCode   string
 	PostName   string
 	PostSort   int
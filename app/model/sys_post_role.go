@@ -0,0 +1,10 @@
+package model
+
+type SysPostRole struct {
+	PostId int
+	RoleId int
+}
+
+func (SysPostRole) TableName() string {
+	return "sys_post_role"
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysPrivacyRequest struct {
+	RequestId   int `gorm:"primaryKey;autoIncrement"`
+	UserId      int
+	RequestType string
+	Status      string `gorm:"default:0"`
+	Reason      string
+	Result      string
+	ErrorMsg    string
+	CreateBy    string
+	CreateTime  datetime.Datetime `gorm:"autoCreateTime"`
+	FinishTime  datetime.Datetime
+}
+
+func (SysPrivacyRequest) TableName() string {
+	return "sys_privacy_request"
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysPrivacyRequestLog struct {
+	LogId      int `gorm:"primaryKey;autoIncrement"`
+	RequestId  int
+	Step       string
+	Detail     string
+	CreateTime datetime.Datetime `gorm:"autoCreateTime"`
+}
+
+func (SysPrivacyRequestLog) TableName() string {
+	return "sys_privacy_request_log"
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysRecycle struct {
+	RecycleId  int `gorm:"primaryKey;autoIncrement"`
+	EntityType string
+	EntityId   int
+	EntityName string
+	Snapshot   string
+	DeleteBy   string
+	DeleteTime datetime.Datetime
+}
+
+func (SysRecycle) TableName() string {
+	return "sys_recycle"
+}
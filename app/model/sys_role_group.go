@@ -0,0 +1,10 @@
+package model
+
+type SysRoleGroup struct {
+	RoleId  int
+	GroupId int
+}
+
+func (SysRoleGroup) TableName() string {
+	return "sys_role_group"
+}
@@ -1,33 +1,57 @@
 package model
 
 import (
+	"mira/anima/cipher"
 	"mira/anima/datetime"
 
 	"gorm.io/gorm"
 )
 
 type SysUser struct {
-	UserId      int `gorm:"primaryKey;autoIncrement"`
-	DeptId      int
-	UserName    string
-	NickName    string
-	UserType    string `gorm:"default:00"`
-	Email       string
-	Phonenumber string
-	Sex         string `gorm:"default:0"`
-	Avatar      string
-	Password    string
-	LoginIP     string
-	LoginDate   datetime.Datetime
-	Status      string `gorm:"default:0"`
-	CreateBy    string
-	CreateTime  datetime.Datetime `gorm:"autoCreateTime"`
-	UpdateBy    string
-	UpdateTime  datetime.Datetime `gorm:"autoUpdateTime"`
-	DeleteTime  gorm.DeletedAt
-	Remark      string
+	UserId          int `gorm:"primaryKey;autoIncrement"`
+	DeptId          int
+	UserName        string
+	NickName        string
+	UserType        string `gorm:"default:00"`
+	Email           cipher.String[SysUserEmail]
+	EmailHash       string `blindIndex:"Email"`
+	Phonenumber     cipher.String[SysUserPhonenumber]
+	PhonenumberHash string `blindIndex:"Phonenumber"`
+	Sex             string `gorm:"default:0"`
+	Avatar          string
+	Password        string
+	LoginIP         cipher.String[SysUserLoginIP]
+	LoginDate       datetime.Datetime
+	Status          string `gorm:"default:0"`
+	ActivateTime    datetime.Datetime
+	DeactivateTime  datetime.Datetime
+	CreateBy        string
+	CreateTime      datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateBy        string
+	UpdateTime      datetime.Datetime `gorm:"autoUpdateTime"`
+	DeleteTime      gorm.DeletedAt
+	Remark          string
 }
 
 func (SysUser) TableName() string {
 	return "sys_user"
 }
+
+// Labels of the encrypted columns of sys_user, named after the table and the column
+type (
+	SysUserEmail       struct{}
+	SysUserPhonenumber struct{}
+	SysUserLoginIP     struct{}
+)
+
+func (SysUserEmail) Label() string {
+	return "sys_user.email"
+}
+
+func (SysUserPhonenumber) Label() string {
+	return "sys_user.phonenumber"
+}
+
+func (SysUserLoginIP) Label() string {
+	return "sys_user.login_ip"
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysUserAttr struct {
+	AttrId       int `gorm:"primaryKey;autoIncrement"`
+	AttrKey      string
+	AttrLabel    string
+	AttrType     string `gorm:"default:text"`
+	Pattern      string
+	DictType     string
+	IsRequired   string `gorm:"default:N"`
+	IsSearchable string `gorm:"default:N"`
+	AttrSort     int
+	Status       string `gorm:"default:0"`
+	CreateBy     string
+	CreateTime   datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateBy     string
+	UpdateTime   datetime.Datetime `gorm:"autoUpdateTime"`
+	Remark       string
+}
+
+func (SysUserAttr) TableName() string {
+	return "sys_user_attr"
+}
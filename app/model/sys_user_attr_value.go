@@ -0,0 +1,11 @@
+package model
+
+type SysUserAttrValue struct {
+	UserId    int
+	AttrId    int
+	AttrValue string
+}
+
+func (SysUserAttrValue) TableName() string {
+	return "sys_user_attr_value"
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"mira/anima/datetime"
+
+	"gorm.io/gorm"
+)
+
+type SysUserGroup struct {
+	GroupId    int `gorm:"primaryKey;autoIncrement"`
+	GroupName  string
+	GroupSort  int
+	Status     string `gorm:"default:0"`
+	CreateBy   string
+	CreateTime datetime.Datetime `gorm:"autoCreateTime"`
+	UpdateBy   string
+	UpdateTime datetime.Datetime `gorm:"autoUpdateTime"`
+	DeleteTime gorm.DeletedAt
+	Remark     string
+}
+
+func (SysUserGroup) TableName() string {
+	return "sys_user_group"
+}
@@ -0,0 +1,10 @@
+package model
+
+type SysUserGroupRole struct {
+	GroupId int
+	RoleId  int
+}
+
+func (SysUserGroupRole) TableName() string {
+	return "sys_user_group_role"
+}
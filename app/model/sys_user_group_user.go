@@ -0,0 +1,10 @@
+package model
+
+type SysUserGroupUser struct {
+	GroupId int
+	UserId  int
+}
+
+func (SysUserGroupUser) TableName() string {
+	return "sys_user_group_user"
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"mira/anima/datetime"
+)
+
+type SysUserOffboard struct {
+	OffboardId int `gorm:"primaryKey;autoIncrement"`
+	UserId     int
+	UserName   string
+	TransferTo string
+	Report     string
+	CreateBy   string
+	CreateTime datetime.Datetime `gorm:"autoCreateTime"`
+}
+
+func (SysUserOffboard) TableName() string {
+	return "sys_user_offboard"
+}
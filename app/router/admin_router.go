@@ -4,6 +4,7 @@ import (
 	"mira/app"
 	"mira/app/controller"
 	"mira/app/middleware"
+	"mira/app/service"
 	"mira/common/types/constant"
 
 	"github.com/gin-gonic/gin"
@@ -40,20 +41,31 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 		userGroup.PUT("/profile", container.UserController.UpdateProfile)
 		userGroup.PUT("/profile/updatePwd", container.UserController.UserProfileUpdatePwd)
 		userGroup.POST("/profile/avatar", container.UserController.UserProfileUpdateAvatar)
-		userGroup.GET("/deptTree", container.HasPerm("system:user:list"), container.UserController.DeptTree)
-		userGroup.GET("/list", container.HasPerm("system:user:list"), container.UserController.List)
-		userGroup.GET("/", container.HasPerm("system:user:query"), container.UserController.Detail)
-		userGroup.GET("/:userId", container.HasPerm("system:user:query"), container.UserController.Detail)
-		userGroup.GET("/authRole/:userId", container.HasPerm("system:user:query"), container.UserController.AuthRole)
-		userGroup.POST("", container.HasPerm("system:user:add"), container.OperLogMiddleware("Add User", constant.REQUEST_BUSINESS_TYPE_INSERT), container.UserController.Create)
-		userGroup.PUT("", container.HasPerm("system:user:edit"), container.OperLogMiddleware("Update User", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.UserController.Update)
-		userGroup.DELETE("/:userIds", container.HasPerm("system:user:remove"), container.OperLogMiddleware("Delete User", constant.REQUEST_BUSINESS_TYPE_DELETE), container.UserController.Remove)
-		userGroup.PUT("/changeStatus", container.HasPerm("system:user:edit"), container.OperLogMiddleware("Modify User Status", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.UserController.ChangeStatus)
-		userGroup.PUT("/resetPwd", container.HasPerm("system:user:edit"), container.OperLogMiddleware("Modify User Password", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.UserController.ResetPwd)
-		userGroup.PUT("/authRole", container.HasPerm("system:user:edit"), container.OperLogMiddleware("User Authorized Role", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.UserController.AddAuthRole)
+		userGroup.POST("/profile/privacyExport", container.OperLogMiddleware("Export Personal Data", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.PrivacyController.ProfileExport)
+		userGroup.GET("/deptTree", container.HasPermOrDelegation("system:user:list"), container.UserController.DeptTree)
+		userGroup.GET("/list", container.HasPermOrDelegation("system:user:list"), container.UserController.List)
+		userGroup.GET("/", container.HasPermOrDelegation("system:user:query"), container.UserController.Detail)
+		userGroup.GET("/:userId", container.HasPermOrDelegation("system:user:query"), container.UserController.Detail)
+		userGroup.GET("/authRole/:userId", container.HasPermOrDelegation("system:user:query"), container.UserController.AuthRole)
+		userGroup.POST("", container.HasPermOrDelegation("system:user:add"), container.OperLogMiddleware("Add User", constant.REQUEST_BUSINESS_TYPE_INSERT), container.RequireApproval("system:user:authRole", "Add User", service.ApprovalChangeUserRoles, service.ApprovalChangeUserPosts), container.UserController.Create)
+		userGroup.PUT("", container.HasPermOrDelegation("system:user:edit"), container.HasPolicy("system:user:edit"), container.OperLogMiddleware("Update User", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:user:authRole", "Update User", service.ApprovalChangeUserRoles, service.ApprovalChangeUserPosts), container.UserController.Update)
+		userGroup.DELETE("/:userIds", container.HasPerm("system:user:remove"), container.HasPolicy("system:user:remove"), container.OperLogMiddleware("Delete User", constant.REQUEST_BUSINESS_TYPE_DELETE), container.RequireApproval("system:user:remove", "Delete User"), container.UserController.Remove)
+		userGroup.PUT("/changeStatus", container.HasPermOrDelegation("system:user:edit"), container.HasPolicy("system:user:edit"), container.OperLogMiddleware("Modify User Status", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.UserController.ChangeStatus)
+		userGroup.PUT("/resetPwd", container.HasPermOrDelegation("system:user:edit"), container.HasPolicy("system:user:edit"), container.OperLogMiddleware("Modify User Password", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:user:resetPwd", "Modify User Password"), container.UserController.ResetPwd)
+		userGroup.PUT("/authRole", container.HasPermOrDelegation("system:user:edit"), container.HasPolicy("system:user:edit"), container.OperLogMiddleware("User Authorized Role", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:user:authRole", "User Authorized Role"), container.UserController.AddAuthRole)
 		userGroup.POST("/export", container.HasPerm("system:user:export"), container.OperLogMiddleware("Export User", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.UserController.Export)
-		userGroup.POST("/importData", container.HasPerm("system:user:import"), container.OperLogMiddleware("Import User", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.UserController.ImportData)
+		userGroup.POST("/importData", container.HasPerm("system:user:import"), container.OperLogMiddleware("Import User", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.RequireApproval("system:user:import", "Import User"), container.UserController.ImportData)
+		userGroup.GET("/importJob/:jobId", container.HasPerm("system:user:import"), container.UserController.ImportJob)
+		userGroup.GET("/importJob/:jobId/errorReport", container.HasPerm("system:user:import"), container.UserController.ImportErrorReport)
 		userGroup.POST("/importTemplate", container.OperLogMiddleware("Import User Template", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.UserController.ImportTemplate)
+		userGroup.PUT("/lifecycle", container.HasPerm("system:user:edit"), container.HasPolicy("system:user:edit"), container.OperLogMiddleware("Schedule User Lifecycle", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:user:authRole", "Schedule User Lifecycle"), container.UserLifecycleController.Schedule)
+		userGroup.POST("/onboard/:userId", container.HasPerm("system:user:onboard"), container.OperLogMiddleware("Onboard User", constant.REQUEST_BUSINESS_TYPE_GRANT), container.RequireApproval("system:user:authRole", "Onboard User"), container.UserLifecycleController.Onboard)
+		userGroup.POST("/offboard", container.HasPerm("system:user:offboard"), container.HasPolicy("system:user:offboard"), container.OperLogMiddleware("Offboard User", constant.REQUEST_BUSINESS_TYPE_FORCE), container.UserLifecycleController.Offboard)
+		userGroup.GET("/offboard/:userId", container.HasPerm("system:user:query"), container.UserLifecycleController.OffboardReports)
+		userGroup.GET("/privacy/list", container.HasPerm("system:user:privacy"), container.PrivacyController.List)
+		userGroup.GET("/privacy/:requestId", container.HasPerm("system:user:privacy"), container.PrivacyController.Detail)
+		userGroup.POST("/privacy/export", container.HasPerm("system:user:privacy"), container.OperLogMiddleware("Export Personal Data", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.PrivacyController.Export)
+		userGroup.POST("/privacy/erase", container.HasPerm("system:user:erase"), container.HasPolicy("system:user:erase"), container.OperLogMiddleware("Erase Personal Data", constant.REQUEST_BUSINESS_TYPE_CLEAN), container.RequireApproval("system:user:erase", "Erase Personal Data"), container.PrivacyController.Erase)
 	}
 
 	// Role Routes
@@ -62,17 +74,22 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 		roleGroup.GET("/list", container.HasPerm("system:role:list"), container.RoleController.List)
 		roleGroup.GET("/:roleId", container.HasPerm("system:role:query"), container.RoleController.Detail)
 		roleGroup.GET("/deptTree/:roleId", container.HasPerm("system:role:query"), container.RoleController.DeptTree)
+		roleGroup.GET("/groupSelect/:roleId", container.HasPerm("system:role:query"), container.RoleController.GroupSelect)
 		roleGroup.GET("/authUser/allocatedList", container.HasPerm("system:role:list"), container.RoleController.RoleAuthUserAllocatedList)
 		roleGroup.GET("/authUser/unallocatedList", container.HasPerm("system:role:list"), container.RoleController.RoleAuthUserUnallocatedList)
-		roleGroup.POST("", container.HasPerm("system:role:add"), container.OperLogMiddleware("Add Role", constant.REQUEST_BUSINESS_TYPE_INSERT), container.RoleController.Create)
-		roleGroup.PUT("", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Update Role", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RoleController.Update)
+		roleGroup.POST("", container.HasPerm("system:role:add"), container.OperLogMiddleware("Add Role", constant.REQUEST_BUSINESS_TYPE_INSERT), container.RequireApproval("system:role:menu", "Add Role", service.ApprovalChangeRoleMenus), container.RoleController.Create)
+		roleGroup.PUT("", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Update Role", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:role:menu", "Update Role", service.ApprovalChangeRoleMenus), container.RoleController.Update)
 		roleGroup.DELETE("/:roleIds", container.HasPerm("system:role:remove"), container.OperLogMiddleware("Delete Role", constant.REQUEST_BUSINESS_TYPE_DELETE), container.RoleController.Remove)
 		roleGroup.PUT("/changeStatus", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Modify Role Status", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RoleController.ChangeStatus)
-		roleGroup.PUT("/dataScope", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Assign Data Permissions", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RoleController.DataScope)
-		roleGroup.PUT("/authUser/selectAll", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Batch Select User Authorization", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RoleController.RoleAuthUserSelectAll)
-		roleGroup.PUT("/authUser/cancel", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Cancel Authorized User", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RoleController.RoleAuthUserCancel)
-		roleGroup.PUT("/authUser/cancelAll", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Batch Cancel Authorized User", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RoleController.RoleAuthUserCancelAll)
+		roleGroup.PUT("/dataScope", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Assign Data Permissions", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:role:dataScope", "Assign Data Permissions"), container.RoleController.DataScope)
+		roleGroup.PUT("/authUser/selectAll", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Batch Select User Authorization", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:role:authUser", "Batch Select User Authorization"), container.RoleController.RoleAuthUserSelectAll)
+		roleGroup.PUT("/authUser/cancel", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Cancel Authorized User", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:role:authUser", "Cancel Authorized User"), container.RoleController.RoleAuthUserCancel)
+		roleGroup.PUT("/authUser/cancelAll", container.HasPerm("system:role:edit"), container.OperLogMiddleware("Batch Cancel Authorized User", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:role:authUser", "Batch Cancel Authorized User"), container.RoleController.RoleAuthUserCancelAll)
 		roleGroup.POST("/export", container.HasPerm("system:role:export"), container.OperLogMiddleware("Export Role", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.RoleController.Export)
+		roleGroup.POST("/importData", container.HasPerm("system:role:import"), container.OperLogMiddleware("Import Role", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.RequireApproval("system:role:import", "Import Role"), container.RoleController.ImportData)
+		roleGroup.POST("/importTemplate", container.OperLogMiddleware("Import Role Template", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.RoleController.ImportTemplate)
+		roleGroup.GET("/importJob/:jobId", container.HasPerm("system:role:import"), container.RoleController.ImportJob)
+		roleGroup.GET("/importJob/:jobId/errorReport", container.HasPerm("system:role:import"), container.RoleController.ImportErrorReport)
 	}
 
 	// Menu Routes
@@ -82,8 +99,8 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 		menuGroup.GET("/treeselect", container.MenuController.Treeselect)
 		menuGroup.GET("/roleMenuTreeselect/:roleId", container.MenuController.RoleMenuTreeselect)
 		menuGroup.GET("/:menuId", container.HasPerm("system:menu:query"), container.MenuController.Detail)
-		menuGroup.POST("", container.HasPerm("system:menu:add"), container.OperLogMiddleware("Add Menu", constant.REQUEST_BUSINESS_TYPE_INSERT), container.MenuController.Create)
-		menuGroup.PUT("", container.HasPerm("system:menu:edit"), container.OperLogMiddleware("Update Menu", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.MenuController.Update)
+		menuGroup.POST("", container.HasPerm("system:menu:add"), container.OperLogMiddleware("Add Menu", constant.REQUEST_BUSINESS_TYPE_INSERT), container.RequireApproval("system:menu:edit", "Add Menu"), container.MenuController.Create)
+		menuGroup.PUT("", container.HasPerm("system:menu:edit"), container.OperLogMiddleware("Update Menu", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:menu:edit", "Update Menu"), container.MenuController.Update)
 		menuGroup.DELETE("/:menuId", container.HasPerm("system:menu:remove"), container.OperLogMiddleware("Delete Menu", constant.REQUEST_BUSINESS_TYPE_DELETE), container.MenuController.Remove)
 	}
 
@@ -92,10 +109,19 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 	{
 		deptGroup.GET("/list", container.HasPerm("system:dept:list"), container.DeptController.List)
 		deptGroup.GET("/list/exclude/:deptId", container.HasPerm("system:dept:list"), container.DeptController.ListExclude)
+		deptGroup.GET("/orgChart", container.HasPerm("system:dept:list"), container.OrgChartController.OrgChart)
+		deptGroup.POST("/orgChart/export", container.HasPerm("system:dept:export"), container.OrgChartController.Export)
 		deptGroup.GET("/:deptId", container.HasPerm("system:dept:query"), container.DeptController.Detail)
 		deptGroup.POST("", container.HasPerm("system:dept:add"), container.OperLogMiddleware("Add Department", constant.REQUEST_BUSINESS_TYPE_INSERT), container.DeptController.Create)
 		deptGroup.PUT("", container.HasPerm("system:dept:edit"), container.OperLogMiddleware("Update Department", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.DeptController.Update)
+		deptGroup.PUT("/move", container.HasPerm("system:dept:edit"), container.OperLogMiddleware("Move Department", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.DeptController.Move)
+		deptGroup.POST("/merge", container.HasPerm("system:dept:merge"), container.DeptController.Merge)
+		deptGroup.POST("/repairHierarchy", container.HasPerm("system:dept:repair"), container.OperLogMiddleware("Repair Department Hierarchy", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.DeptController.RepairHierarchy)
 		deptGroup.DELETE("/:deptId", container.HasPerm("system:dept:remove"), container.OperLogMiddleware("Delete Department", constant.REQUEST_BUSINESS_TYPE_DELETE), container.DeptController.Remove)
+		deptGroup.POST("/importData", container.HasPerm("system:dept:import"), container.OperLogMiddleware("Import Department", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.RequireApproval("system:dept:import", "Import Department"), container.DeptController.ImportData)
+		deptGroup.POST("/importTemplate", container.OperLogMiddleware("Import Department Template", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.DeptController.ImportTemplate)
+		deptGroup.GET("/importJob/:jobId", container.HasPerm("system:dept:import"), container.DeptController.ImportJob)
+		deptGroup.GET("/importJob/:jobId/errorReport", container.HasPerm("system:dept:import"), container.DeptController.ImportErrorReport)
 	}
 
 	// Post Routes
@@ -104,9 +130,47 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 		postGroup.GET("/list", container.HasPerm("system:post:list"), container.PostController.List)
 		postGroup.GET("/:postId", container.HasPerm("system:post:query"), container.PostController.Detail)
 		postGroup.POST("", container.HasPerm("system:post:add"), container.OperLogMiddleware("Add Post", constant.REQUEST_BUSINESS_TYPE_INSERT), container.PostController.Create)
-		postGroup.PUT("", container.HasPerm("system:post:edit"), container.OperLogMiddleware("Update Post", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.PostController.Update)
+		postGroup.PUT("", container.HasPerm("system:post:edit"), container.OperLogMiddleware("Update Post", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:post:role", "Update Post", service.ApprovalChangePostRoles), container.PostController.Update)
 		postGroup.DELETE("/:postIds", container.HasPerm("system:post:remove"), container.OperLogMiddleware("Delete Post", constant.REQUEST_BUSINESS_TYPE_DELETE), container.PostController.Remove)
 		postGroup.POST("/export", container.HasPerm("system:post:export"), container.OperLogMiddleware("Export Post", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.PostController.Export)
+		postGroup.POST("/importData", container.HasPerm("system:post:import"), container.OperLogMiddleware("Import Post", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.RequireApproval("system:post:import", "Import Post"), container.PostController.ImportData)
+		postGroup.POST("/importTemplate", container.OperLogMiddleware("Import Post Template", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.PostController.ImportTemplate)
+		postGroup.GET("/importJob/:jobId", container.HasPerm("system:post:import"), container.PostController.ImportJob)
+		postGroup.GET("/importJob/:jobId/errorReport", container.HasPerm("system:post:import"), container.PostController.ImportErrorReport)
+	}
+
+	// User Group Routes
+	userGroupGroup := api.Group("/system/group")
+	{
+		userGroupGroup.GET("/list", container.HasPerm("system:group:list"), container.UserGroupController.List)
+		userGroupGroup.GET("/:groupId", container.HasPerm("system:group:query"), container.UserGroupController.Detail)
+		userGroupGroup.GET("/member/allocatedList", container.HasPerm("system:group:list"), container.UserGroupController.MemberAllocatedList)
+		userGroupGroup.GET("/member/unallocatedList", container.HasPerm("system:group:list"), container.UserGroupController.MemberUnallocatedList)
+		userGroupGroup.POST("", container.HasPerm("system:group:add"), container.OperLogMiddleware("Add User Group", constant.REQUEST_BUSINESS_TYPE_INSERT), container.UserGroupController.Create)
+		userGroupGroup.PUT("", container.HasPerm("system:group:edit"), container.OperLogMiddleware("Update User Group", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:group:role", "Update User Group", service.ApprovalChangeGroupRoles), container.UserGroupController.Update)
+		userGroupGroup.DELETE("/:groupIds", container.HasPerm("system:group:remove"), container.OperLogMiddleware("Delete User Group", constant.REQUEST_BUSINESS_TYPE_DELETE), container.UserGroupController.Remove)
+		userGroupGroup.PUT("/member/selectAll", container.HasPerm("system:group:edit"), container.OperLogMiddleware("Batch Add User Group Member", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:group:member", "Batch Add User Group Member"), container.UserGroupController.AddMembers)
+		userGroupGroup.PUT("/member/cancelAll", container.HasPerm("system:group:edit"), container.OperLogMiddleware("Batch Remove User Group Member", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:group:member", "Batch Remove User Group Member"), container.UserGroupController.RemoveMembers)
+	}
+
+	// User Attribute Routes
+	userAttrGroup := api.Group("/system/userAttr")
+	{
+		userAttrGroup.GET("/list", container.HasPerm("system:userAttr:list"), container.UserAttrController.List)
+		userAttrGroup.GET("/:attrId", container.HasPerm("system:userAttr:query"), container.UserAttrController.Detail)
+		userAttrGroup.POST("", container.HasPerm("system:userAttr:add"), container.OperLogMiddleware("Add User Attribute", constant.REQUEST_BUSINESS_TYPE_INSERT), container.UserAttrController.Create)
+		userAttrGroup.PUT("", container.HasPerm("system:userAttr:edit"), container.OperLogMiddleware("Update User Attribute", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.UserAttrController.Update)
+		userAttrGroup.DELETE("/:attrIds", container.HasPerm("system:userAttr:remove"), container.OperLogMiddleware("Delete User Attribute", constant.REQUEST_BUSINESS_TYPE_DELETE), container.UserAttrController.Remove)
+	}
+
+	// Onboarding Template Routes
+	onboardTemplateGroup := api.Group("/system/onboardTemplate")
+	{
+		onboardTemplateGroup.GET("/list", container.HasPerm("system:onboardTemplate:list"), container.OnboardTemplateController.List)
+		onboardTemplateGroup.GET("/:templateId", container.HasPerm("system:onboardTemplate:query"), container.OnboardTemplateController.Detail)
+		onboardTemplateGroup.POST("", container.HasPerm("system:onboardTemplate:add"), container.OperLogMiddleware("Add Onboarding Template", constant.REQUEST_BUSINESS_TYPE_INSERT), container.RequireApproval("system:onboardTemplate:edit", "Add Onboarding Template"), container.OnboardTemplateController.Create)
+		onboardTemplateGroup.PUT("", container.HasPerm("system:onboardTemplate:edit"), container.OperLogMiddleware("Update Onboarding Template", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:onboardTemplate:edit", "Update Onboarding Template"), container.OnboardTemplateController.Update)
+		onboardTemplateGroup.DELETE("/:templateIds", container.HasPerm("system:onboardTemplate:remove"), container.OperLogMiddleware("Delete Onboarding Template", constant.REQUEST_BUSINESS_TYPE_DELETE), container.RequireApproval("system:onboardTemplate:edit", "Delete Onboarding Template"), container.OnboardTemplateController.Remove)
 	}
 
 	// Dict Routes
@@ -120,6 +184,10 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 		dictGroup.DELETE("/type/:dictIds", container.HasPerm("system:dict:remove"), container.OperLogMiddleware("Delete Dictionary Type", constant.REQUEST_BUSINESS_TYPE_DELETE), container.DictTypeController.Remove)
 		dictGroup.POST("/type/export", container.HasPerm("system:dict:export"), container.OperLogMiddleware("Export Dictionary Type", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.DictTypeController.Export)
 		dictGroup.DELETE("/type/refreshCache", container.HasPerm("system:dict:remove"), container.OperLogMiddleware("Refresh Dictionary Type Cache", constant.REQUEST_BUSINESS_TYPE_DELETE), container.DictTypeController.RefreshCache)
+		dictGroup.POST("/type/importData", container.HasPerm("system:dict:import"), container.OperLogMiddleware("Import Dictionary Type", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.DictTypeController.ImportData)
+		dictGroup.POST("/type/importTemplate", container.OperLogMiddleware("Import Dictionary Type Template", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.DictTypeController.ImportTemplate)
+		dictGroup.GET("/type/importJob/:jobId", container.HasPerm("system:dict:import"), container.DictTypeController.ImportJob)
+		dictGroup.GET("/type/importJob/:jobId/errorReport", container.HasPerm("system:dict:import"), container.DictTypeController.ImportErrorReport)
 
 		dictGroup.GET("/data/list", container.HasPerm("system:dict:list"), container.DictDataController.List)
 		dictGroup.GET("/data/:dictCode", container.HasPerm("system:dict:query"), container.DictDataController.Detail)
@@ -128,12 +196,21 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 		dictGroup.PUT("/data", container.HasPerm("system:dict:edit"), container.OperLogMiddleware("Update Dictionary Data", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.DictDataController.Update)
 		dictGroup.DELETE("/data/:dictCodes", container.HasPerm("system:dict:remove"), container.OperLogMiddleware("Delete Dictionary Data", constant.REQUEST_BUSINESS_TYPE_DELETE), container.DictDataController.Remove)
 		dictGroup.POST("/data/export", container.HasPerm("system:dict:export"), container.OperLogMiddleware("Export Dictionary Data", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.DictDataController.Export)
+		dictGroup.POST("/data/importData", container.HasPerm("system:dict:import"), container.OperLogMiddleware("Import Dictionary Data", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.DictDataController.ImportData)
+		dictGroup.POST("/data/importTemplate", container.OperLogMiddleware("Import Dictionary Data Template", constant.REQUEST_BUSINESS_TYPE_IMPORT), container.DictDataController.ImportTemplate)
+		dictGroup.GET("/data/importJob/:jobId", container.HasPerm("system:dict:import"), container.DictDataController.ImportJob)
+		dictGroup.GET("/data/importJob/:jobId/errorReport", container.HasPerm("system:dict:import"), container.DictDataController.ImportErrorReport)
+		dictGroup.GET("/data/history/list", container.HasPerm("system:dict:query"), container.DictDataController.HistoryList)
+		dictGroup.GET("/data/history/diff", container.HasPerm("system:dict:query"), container.DictDataController.HistoryDiff)
+		dictGroup.POST("/data/history/rollback", container.HasPerm("system:dict:edit"), container.OperLogMiddleware("Roll Back Dictionary Data", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.DictDataController.HistoryRollback)
+		dictGroup.POST("/data/history/export", container.HasPerm("system:dict:export"), container.OperLogMiddleware("Export Dictionary Data History", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.DictDataController.HistoryExport)
 	}
 
 	// Config Routes
 	configGroup := api.Group("/system/config")
 	{
 		configGroup.GET("/list", container.HasPerm("system:config:list"), container.ConfigController.List)
+		configGroup.GET("/schemas", container.HasPerm("system:config:list"), container.ConfigController.Schemas)
 		configGroup.GET("/:configId", container.HasPerm("system:config:query"), container.ConfigController.Detail)
 		configGroup.GET("/configKey/:configKey", container.ConfigController.ConfigKey)
 		configGroup.POST("", container.HasPerm("system:config:add"), container.OperLogMiddleware("Add Parameter Configuration", constant.REQUEST_BUSINESS_TYPE_INSERT), container.ConfigController.Create)
@@ -141,7 +218,60 @@ func registerSystemRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 		configGroup.DELETE("/:configIds", container.HasPerm("system:config:remove"), container.OperLogMiddleware("Delete Parameter Configuration", constant.REQUEST_BUSINESS_TYPE_DELETE), container.ConfigController.Remove)
 		configGroup.POST("/export", container.HasPerm("system:config:export"), container.OperLogMiddleware("Export Parameter Configuration", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.ConfigController.Export)
 		configGroup.DELETE("/refreshCache", container.HasPerm("system:config:remove"), container.OperLogMiddleware("Refresh Parameter Configuration Cache", constant.REQUEST_BUSINESS_TYPE_DELETE), container.ConfigController.RefreshCache)
+		configGroup.GET("/history/list", container.HasPerm("system:config:query"), container.ConfigController.HistoryList)
+		configGroup.GET("/history/diff", container.HasPerm("system:config:query"), container.ConfigController.HistoryDiff)
+		configGroup.POST("/history/rollback", container.HasPerm("system:config:edit"), container.OperLogMiddleware("Roll Back Parameter Configuration", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.ConfigController.HistoryRollback)
+		configGroup.POST("/history/export", container.HasPerm("system:config:export"), container.OperLogMiddleware("Export Parameter Configuration History", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.ConfigController.HistoryExport)
+	}
+
+	// Policy Routes
+	policyGroup := api.Group("/system/policy")
+	{
+		policyGroup.GET("/list", container.HasPerm("system:policy:list"), container.PolicyController.List)
+		policyGroup.GET("/:policyId", container.HasPerm("system:policy:query"), container.PolicyController.Detail)
+		policyGroup.POST("", container.HasPerm("system:policy:add"), container.OperLogMiddleware("Add Access Policy", constant.REQUEST_BUSINESS_TYPE_INSERT), container.RequireApproval("system:policy:edit", "Add Access Policy"), container.PolicyController.Create)
+		policyGroup.PUT("", container.HasPerm("system:policy:edit"), container.OperLogMiddleware("Update Access Policy", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:policy:edit", "Update Access Policy"), container.PolicyController.Update)
+		policyGroup.DELETE("/:policyIds", container.HasPerm("system:policy:remove"), container.OperLogMiddleware("Delete Access Policy", constant.REQUEST_BUSINESS_TYPE_DELETE), container.RequireApproval("system:policy:edit", "Delete Access Policy"), container.PolicyController.Remove)
+		policyGroup.POST("/test", container.HasPerm("system:policy:test"), container.PolicyController.Test)
+	}
+
+	// Approval Routes
+	approvalGroup := api.Group("/system/approval")
+	{
+		approvalGroup.GET("/list", container.HasPerm("system:approval:list"), container.ApprovalController.List)
+		approvalGroup.GET("/:approvalId", container.HasPerm("system:approval:query"), container.ApprovalController.Detail)
+		approvalGroup.PUT("/approve", container.HasPerm("system:approval:approve"), container.OperLogMiddleware("Approve Change Request", constant.REQUEST_BUSINESS_TYPE_GRANT), container.ApprovalController.Approve)
+		approvalGroup.PUT("/reject", container.HasPerm("system:approval:approve"), container.OperLogMiddleware("Reject Change Request", constant.REQUEST_BUSINESS_TYPE_GRANT), container.ApprovalController.Reject)
 	}
+
+	// Delegation Routes
+	delegationGroup := api.Group("/system/delegation")
+	{
+		delegationGroup.GET("/list", container.HasPerm("system:delegation:list"), container.DelegationController.List)
+		delegationGroup.GET("/:delegationId", container.HasPerm("system:delegation:query"), container.DelegationController.Detail)
+		delegationGroup.POST("", container.HasPerm("system:delegation:add"), container.OperLogMiddleware("Add Department Delegation", constant.REQUEST_BUSINESS_TYPE_INSERT), container.RequireApproval("system:delegation:edit", "Add Department Delegation"), container.DelegationController.Create)
+		delegationGroup.PUT("", container.HasPerm("system:delegation:edit"), container.OperLogMiddleware("Update Department Delegation", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:delegation:edit", "Update Department Delegation"), container.DelegationController.Update)
+		delegationGroup.DELETE("/:delegationIds", container.HasPerm("system:delegation:remove"), container.OperLogMiddleware("Delete Department Delegation", constant.REQUEST_BUSINESS_TYPE_DELETE), container.DelegationController.Remove)
+	}
+
+	// Recycle Bin Routes
+	recycleGroup := api.Group("/system/recycle")
+	{
+		recycleGroup.GET("/list", container.HasPerm("system:recycle:list"), container.RecycleController.List)
+		recycleGroup.PUT("/restore/:recycleIds", container.HasPerm("system:recycle:restore"), container.OperLogMiddleware("Restore Deleted Item", constant.REQUEST_BUSINESS_TYPE_UPDATE), container.RequireApproval("system:recycle:restore", "Restore Deleted Item"), container.RecycleController.Restore)
+		recycleGroup.DELETE("/expired", container.HasPerm("system:recycle:remove"), container.OperLogMiddleware("Purge Expired Deleted Items", constant.REQUEST_BUSINESS_TYPE_CLEAN), container.RecycleController.PurgeExpired)
+		recycleGroup.DELETE("/:recycleIds", container.HasPerm("system:recycle:remove"), container.OperLogMiddleware("Purge Deleted Item", constant.REQUEST_BUSINESS_TYPE_DELETE), container.RecycleController.Purge)
+	}
+
+	// Export Job Routes
+	exportGroup := api.Group("/system/export")
+	{
+		exportGroup.GET("/job/:jobId", container.ExportController.Job)
+		exportGroup.GET("/job/:jobId/download", container.ExportController.Download)
+	}
+
+	// Unified Search Routes, every type of result checks its own permission
+	api.GET("/system/search", container.SearchController.Search)
 }
 
 func registerMonitorRoutes(api *gin.RouterGroup, container *app.AppContainer) {
@@ -162,5 +292,20 @@ func registerMonitorRoutes(api *gin.RouterGroup, container *app.AppContainer) {
 			operlogGroup.DELETE("/clean", container.HasPerm("monitor:operlog:remove"), container.OperLogMiddleware("Clear Operation Log", constant.REQUEST_BUSINESS_TYPE_DELETE), container.OperlogController.Clean)
 			operlogGroup.POST("/export", container.HasPerm("monitor:operlog:export"), container.OperLogMiddleware("Export Operation Log", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.OperlogController.Export)
 		}
+		accessGroup := monitorGroup.Group("/access")
+		{
+			accessGroup.GET("/permUsers", container.HasPerm("monitor:access:report"), container.AccessReportController.PermUsers)
+			accessGroup.POST("/permUsers/export", container.HasPerm("monitor:access:report"), container.OperLogMiddleware("Export Permission Holders", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.AccessReportController.PermUsersExport)
+			accessGroup.GET("/userPerms", container.HasPerm("monitor:access:report"), container.AccessReportController.UserPerms)
+			accessGroup.POST("/userPerms/export", container.HasPerm("monitor:access:report"), container.OperLogMiddleware("Export User Permissions", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.AccessReportController.UserPermsExport)
+			accessGroup.GET("/compare", container.HasPerm("monitor:access:report"), container.AccessReportController.Compare)
+			accessGroup.POST("/compare/export", container.HasPerm("monitor:access:report"), container.OperLogMiddleware("Export Permission Comparison", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.AccessReportController.CompareExport)
+			accessGroup.GET("/explain", container.HasPerm("monitor:access:report"), container.AccessReportController.Explain)
+			accessGroup.POST("/explain/export", container.HasPerm("monitor:access:report"), container.OperLogMiddleware("Export Permission Explanation", constant.REQUEST_BUSINESS_TYPE_EXPORT), container.AccessReportController.ExplainExport)
+		}
+		cacheGroup := monitorGroup.Group("/cache")
+		{
+			cacheGroup.GET("/metrics", container.HasPerm("monitor:cache:list"), container.CacheController.Metrics)
+		}
 	}
 }
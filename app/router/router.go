@@ -2,6 +2,7 @@ package router
 
 import (
 	"mira/app"
+	"mira/common/types/constant"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,4 +15,10 @@ func Register(server *gin.Engine) {
 	container := app.NewAppContainer()
 
 	RegisterAdminGroupApi(api, container)
+
+	// Identity providers provision users and groups with their own bearer token
+	RegisterScimApi(server.Group(constant.SCIM_BASE_PATH), container)
+
+	// Approved change requests are replayed through the engine as the requester
+	container.ApprovalController.Handler = server
 }
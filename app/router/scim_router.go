@@ -0,0 +1,37 @@
+package router
+
+import (
+	"mira/app"
+	"mira/app/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SCIM 2.0 router group
+func RegisterScimApi(scim *gin.RouterGroup, container *app.AppContainer) {
+	scim.Use(middleware.ScimAuthMiddleware())
+
+	scim.GET("/ServiceProviderConfig", container.ScimController.ServiceProviderConfig)
+	scim.GET("/Schemas", container.ScimController.Schemas)
+	scim.GET("/Schemas/:schemaId", container.ScimController.Schema)
+
+	userGroup := scim.Group("/Users")
+	{
+		userGroup.GET("", container.ScimController.UserList)
+		userGroup.GET("/:id", container.ScimController.UserDetail)
+		userGroup.POST("", container.ScimController.UserCreate)
+		userGroup.PUT("/:id", container.ScimController.UserReplace)
+		userGroup.PATCH("/:id", container.ScimController.UserPatch)
+		userGroup.DELETE("/:id", container.ScimController.UserDelete)
+	}
+
+	groupGroup := scim.Group("/Groups")
+	{
+		groupGroup.GET("", container.ScimController.GroupList)
+		groupGroup.GET("/:id", container.ScimController.GroupDetail)
+		groupGroup.POST("", container.ScimController.GroupCreate)
+		groupGroup.PUT("/:id", container.ScimController.GroupReplace)
+		groupGroup.PATCH("/:id", container.ScimController.GroupPatch)
+		groupGroup.DELETE("/:id", container.ScimController.GroupDelete)
+	}
+}
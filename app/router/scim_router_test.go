@@ -0,0 +1,145 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// scimExchange is a request recorded from an identity provider with the response it expects.
+// Only the fields present in response are compared; a null field must be absent.
+type scimExchange struct {
+	Name          string          `json:"name"`
+	Method        string          `json:"method"`
+	Path          string          `json:"path"`
+	Authorization *string         `json:"authorization"`
+	Body          json.RawMessage `json:"body"`
+	Status        int             `json:"status"`
+	Response      json.RawMessage `json:"response"`
+}
+
+// setupScim initializes an empty database of the given name with the super administrator and its role
+func setupScim(t *testing.T, name string) {
+	redis, _ := redismock.NewClientMock()
+	dal.Redis = redis
+
+	db, err := gorm.Open(sqlite.Open("file:"+name+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	dal.Gorm = db
+
+	models := []interface{}{&model.SysConfig{}, &model.SysDept{}, &model.SysUser{}, &model.SysRole{}, &model.SysUserRole{}, &model.SysRoleMenu{}, &model.SysRoleDept{}, &model.SysRecycle{}, &model.SysUserGroup{}, &model.SysUserGroupUser{}, &model.SysUserGroupRole{}, &model.SysRoleGroup{}, &model.SysPost{}, &model.SysUserPost{}, &model.SysPostRole{}}
+	require.NoError(t, dal.Gorm.AutoMigrate(models...))
+
+	dal.Gorm.Create(&model.SysConfig{ConfigId: 1, ConfigKey: "sys.user.initPassword", ConfigValue: "123456"})
+	dal.Gorm.Create(&model.SysUser{UserId: 1, UserName: "admin", NickName: "Admin", Status: constant.NORMAL_STATUS})
+	dal.Gorm.Create(&model.SysRole{RoleId: 1, RoleName: "Super Admin", RoleKey: "admin", Status: constant.NORMAL_STATUS})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 1, RoleId: 1})
+
+	config.Data = &config.Config{}
+	config.Data.Scim.Token = "scim-test-token"
+}
+
+// TestScimRecordedRequests replays the SCIM requests recorded in testdata/scim, one
+// session per file against a fresh database
+func TestScimRecordedRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	files, err := filepath.Glob("testdata/scim/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			setupScim(t, "scim_"+strings.TrimSuffix(filepath.Base(file), ".json"))
+
+			container := app.NewAppContainer()
+			defer container.BackgroundTaskService.Stop(time.Second)
+
+			server := gin.New()
+			RegisterScimApi(server.Group(constant.SCIM_BASE_PATH), container)
+
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			var exchanges []scimExchange
+			require.NoError(t, json.Unmarshal(content, &exchanges))
+
+			for index, exchange := range exchanges {
+				name := strconv.Itoa(index+1) + " " + exchange.Name
+
+				request := httptest.NewRequest(exchange.Method, exchange.Path, bytes.NewReader(exchange.Body))
+				request.Header.Set("Content-Type", constant.SCIM_CONTENT_TYPE)
+				request.Header.Set("Authorization", "Bearer scim-test-token")
+				if exchange.Authorization != nil {
+					request.Header.Set("Authorization", *exchange.Authorization)
+				}
+
+				recorder := httptest.NewRecorder()
+				server.ServeHTTP(recorder, request)
+
+				if !assert.Equal(t, exchange.Status, recorder.Code, "%s: %s", name, recorder.Body.String()) {
+					continue
+				}
+				if len(exchange.Response) == 0 {
+					assert.Empty(t, recorder.Body.String(), name)
+					continue
+				}
+
+				assert.Equal(t, constant.SCIM_CONTENT_TYPE, recorder.Header().Get("Content-Type"), name)
+
+				var expected, actual interface{}
+				require.NoError(t, json.Unmarshal(exchange.Response, &expected), name)
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actual), name)
+				assertScimSubset(t, name, "", expected, actual)
+			}
+		})
+	}
+}
+
+// assertScimSubset asserts that actual holds every field of expected. Arrays must have the
+// same length and are compared element by element.
+func assertScimSubset(t *testing.T, name, path string, expected, actual interface{}) {
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		object, ok := actual.(map[string]interface{})
+		if !assert.True(t, ok, "%s: %s should be an object", name, path) {
+			return
+		}
+		for key, value := range expected {
+			if value == nil {
+				assert.Nil(t, object[key], "%s: %s.%s should be absent", name, path, key)
+				continue
+			}
+			assertScimSubset(t, name, path+"."+key, value, object[key])
+		}
+	case []interface{}:
+		array, ok := actual.([]interface{})
+		if !assert.True(t, ok, "%s: %s should be an array", name, path) || !assert.Len(t, array, len(expected), "%s: %s", name, path) {
+			return
+		}
+		for index, value := range expected {
+			assertScimSubset(t, name, path+"["+strconv.Itoa(index)+"]", value, array[index])
+		}
+	default:
+		assert.Equal(t, expected, actual, "%s: %s", name, path)
+	}
+}
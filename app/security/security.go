@@ -1,6 +1,7 @@
 package security
 
 import (
+	"mira/app/dto"
 	"mira/app/service"
 	"mira/app/token"
 
@@ -17,6 +18,9 @@ type SecurityInterface interface {
 	HasPerm(userId int, perm string) bool
 }
 
+// DelegationScopeKey is the context key of the delegation scope a request is authorized by
+const DelegationScopeKey = "delegation_scope"
+
 // NewSecurity creates a new Security instance.
 func NewSecurity(userService service.UserServiceInterface) *Security {
 	return &Security{UserService: userService}
@@ -33,12 +37,8 @@ func GetAuthUserId(ctx *gin.Context) int {
 
 // Get department id
 func GetAuthDeptId(ctx *gin.Context) int {
-	tokenKey, err := token.GetUserTokenKey(ctx)
-	if err != nil {
-		return 0
-	}
-	authUser, err := token.GetAuthUser(ctx.Request.Context(), tokenKey)
-	if err != nil {
+	authUser := GetAuthUser(ctx)
+	if authUser == nil {
 		return 0
 	}
 	return authUser.DeptId
@@ -46,19 +46,20 @@ func GetAuthDeptId(ctx *gin.Context) int {
 
 // Get user account
 func GetAuthUserName(ctx *gin.Context) string {
-	tokenKey, err := token.GetUserTokenKey(ctx)
-	if err != nil {
-		return ""
-	}
-	authUser, err := token.GetAuthUser(ctx.Request.Context(), tokenKey)
-	if err != nil {
+	authUser := GetAuthUser(ctx)
+	if authUser == nil {
 		return ""
 	}
 	return authUser.UserName
 }
 
 // Get user
+// The user set on the context by the authentication middleware takes precedence,
+// which lets replayed change requests run under the requester's identity.
 func GetAuthUser(ctx *gin.Context) *token.UserTokenResponse {
+	if val, ok := ctx.Get(token.UserTokenKey); ok {
+		return val.(*token.UserTokenResponse)
+	}
 	tokenKey, err := token.GetUserTokenKey(ctx)
 	if err != nil {
 		return nil
@@ -70,6 +71,16 @@ func GetAuthUser(ctx *gin.Context) *token.UserTokenResponse {
 	return authUser
 }
 
+// Get delegation scope
+// Returns nil unless the request is authorized by a department delegation
+// instead of the global permission.
+func GetDelegationScope(ctx *gin.Context) *dto.DelegationScope {
+	if val, ok := ctx.Get(DelegationScopeKey); ok {
+		return val.(*dto.DelegationScope)
+	}
+	return nil
+}
+
 // HasPerm checks if the user has a specific permission.
 func (s *Security) HasPerm(userId int, perm string) bool {
 	return s.UserService.UserHasPerms(userId, []string{perm})
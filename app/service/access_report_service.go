@@ -0,0 +1,404 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// SUPER_ADMIN_PERM is the wildcard permission reported for the super administrator
+const SUPER_ADMIN_PERM = "*:*:*"
+
+// AccessReportServiceInterface defines the "who can do what" reporting operations
+type AccessReportServiceInterface interface {
+	GetPermUsers(perm string) ([]dto.PermUserResponse, error)
+	GetUserEffectivePerms(userId int) (dto.UserEffectivePermsResponse, error)
+	CompareUsers(leftUserId, rightUserId int) (dto.AccessCompareResponse, error)
+	CompareRoles(leftRoleId, rightRoleId int) (dto.AccessCompareResponse, error)
+	ExplainPerm(userId int, perm string) (dto.AccessExplainResponse, error)
+}
+
+// AccessReportService reports effective permissions resolved through sys_user_role,
+// user groups, post default roles, sys_role_menu and sys_menu, following the same rules as HasPerm
+type AccessReportService struct{}
+
+// Ensure AccessReportService implements AccessReportServiceInterface
+var _ AccessReportServiceInterface = (*AccessReportService)(nil)
+
+// NewAccessReportService creates a new AccessReportService
+func NewAccessReportService() *AccessReportService {
+	return &AccessReportService{}
+}
+
+// accessGrant is one user -> role -> menu path that carries a permission
+type accessGrant struct {
+	UserId     int
+	UserName   string
+	NickName   string
+	DeptName   string
+	RoleId     int
+	RoleKey    string
+	RoleName   string
+	RoleStatus string
+	DataScope  string
+	MenuId     int
+	MenuName   string
+	Perms      string
+	MenuStatus string
+}
+
+// effective reports whether the grant is honoured by HasPerm
+func (g accessGrant) effective() bool {
+	return g.RoleStatus == constant.NORMAL_STATUS && g.MenuStatus == constant.NORMAL_STATUS
+}
+
+// queryAccessGrants lists user -> role -> menu paths, including disabled roles and menus
+func queryAccessGrants(scope func(*gorm.DB) *gorm.DB) ([]accessGrant, error) {
+	grants := make([]accessGrant, 0)
+
+	err := dal.Gorm.Table("(?) AS user_roles", userRoleQuery(0)).
+		Select(
+			"sys_user.user_id", "sys_user.user_name", "sys_user.nick_name", "sys_dept.dept_name",
+			"sys_role.role_id", "sys_role.role_key", "sys_role.role_name", "sys_role.status AS role_status", "sys_role.data_scope",
+			"sys_menu.menu_id", "sys_menu.menu_name", "sys_menu.perms", "sys_menu.status AS menu_status",
+		).
+		Joins("JOIN sys_user ON sys_user.user_id = user_roles.user_id AND sys_user.delete_time IS NULL").
+		Joins("LEFT JOIN sys_dept ON sys_dept.dept_id = sys_user.dept_id").
+		Joins("JOIN sys_role ON sys_role.role_id = user_roles.role_id AND sys_role.delete_time IS NULL").
+		Joins("JOIN sys_role_menu ON sys_role_menu.role_id = sys_role.role_id").
+		Joins("JOIN sys_menu ON sys_menu.menu_id = sys_role_menu.menu_id AND sys_menu.delete_time IS NULL").
+		Where("sys_menu.perms <> ''").
+		Scopes(scope).
+		Order("sys_user.user_id, sys_role.role_id, sys_menu.menu_id").
+		Scan(&grants).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query access grants")
+	}
+
+	return grants, nil
+}
+
+// GetPermUsers lists every user holding a permission and the role it comes through
+func (s *AccessReportService) GetPermUsers(perm string) ([]dto.PermUserResponse, error) {
+	users := make([]dto.PermUserResponse, 0)
+
+	if perm == "" {
+		return users, xerrors.ErrAccessPermEmpty
+	}
+
+	// The super administrator passes every permission check
+	var admin dto.PermUserResponse
+	if err := dal.Gorm.Model(model.SysUser{}).
+		Select("sys_user.user_id", "sys_user.user_name", "sys_user.nick_name", "sys_dept.dept_name").
+		Joins("LEFT JOIN sys_dept ON sys_dept.dept_id = sys_user.dept_id").
+		Where("sys_user.user_id = ?", SUPER_ADMIN_USER_ID).
+		Take(&admin).Error; err == nil {
+		admin.RoleKey = SUPER_ADMIN_PERM
+		admin.RoleName = "Super Administrator"
+		users = append(users, admin)
+	}
+
+	grants, err := queryAccessGrants(func(db *gorm.DB) *gorm.DB {
+		return db.Where("sys_menu.perms = ? AND sys_user.user_id <> ?", perm, SUPER_ADMIN_USER_ID)
+	})
+	if err != nil {
+		return users, err
+	}
+
+	for _, grant := range grants {
+		if !grant.effective() {
+			continue
+		}
+		users = append(users, dto.PermUserResponse{
+			UserId:   grant.UserId,
+			UserName: grant.UserName,
+			NickName: grant.NickName,
+			DeptName: grant.DeptName,
+			RoleId:   grant.RoleId,
+			RoleKey:  grant.RoleKey,
+			RoleName: grant.RoleName,
+			MenuName: grant.MenuName,
+		})
+	}
+
+	return users, nil
+}
+
+// GetUserEffectivePerms returns a user's effective permissions with source roles and data scope
+func (s *AccessReportService) GetUserEffectivePerms(userId int) (dto.UserEffectivePermsResponse, error) {
+	result := dto.UserEffectivePermsResponse{
+		UserId:     userId,
+		Perms:      make([]dto.EffectivePermResponse, 0),
+		DataScopes: make([]dto.RoleDataScopeResponse, 0),
+	}
+
+	user, err := (&UserService{}).GetUserByUserIdWithErr(userId)
+	if err != nil || user.UserId <= 0 {
+		return result, xerrors.ErrAccessUserNotFound
+	}
+
+	result.UserName = user.UserName
+	result.NickName = user.NickName
+	result.IsSuperAdmin = userId == SUPER_ADMIN_USER_ID
+
+	if result.IsSuperAdmin {
+		result.Perms = append(result.Perms, dto.EffectivePermResponse{
+			Perm:     SUPER_ADMIN_PERM,
+			MenuName: "All permissions",
+			Roles:    []string{"Super Administrator"},
+		})
+	}
+
+	grants, err := queryAccessGrants(func(db *gorm.DB) *gorm.DB {
+		return db.Where("sys_user.user_id = ?", userId)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	index := make(map[string]int)
+	for _, grant := range grants {
+		if !grant.effective() {
+			continue
+		}
+		i, ok := index[grant.Perms]
+		if !ok {
+			i = len(result.Perms)
+			index[grant.Perms] = i
+			result.Perms = append(result.Perms, dto.EffectivePermResponse{
+				Perm:     grant.Perms,
+				MenuName: grant.MenuName,
+				Roles:    make([]string, 0),
+			})
+		}
+		if !utils.Contains(result.Perms[i].Roles, grant.RoleKey) {
+			result.Perms[i].Roles = append(result.Perms[i].Roles, grant.RoleKey)
+		}
+	}
+
+	sort.SliceStable(result.Perms, func(i, j int) bool {
+		return result.Perms[i].Perm < result.Perms[j].Perm
+	})
+
+	result.DataScopes, err = s.getUserDataScopes(userId)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// getUserDataScopes returns the data scope contributed by each of the user's normal roles
+func (s *AccessReportService) getUserDataScopes(userId int) ([]dto.RoleDataScopeResponse, error) {
+	dataScopes := make([]dto.RoleDataScopeResponse, 0)
+
+	roles := (&RoleService{}).GetRoleListByUserIdCompat(userId)
+
+	for _, role := range roles {
+		dataScope := dto.RoleDataScopeResponse{
+			RoleId:     role.RoleId,
+			RoleKey:    role.RoleKey,
+			RoleName:   role.RoleName,
+			DataScope:  role.DataScope,
+			DeptNames:  make([]string, 0),
+			GroupNames: make([]string, 0),
+		}
+
+		if role.DataScope == DATA_SCOPE_CUSTOM {
+			if err := dal.Gorm.Model(model.SysRoleDept{}).
+				Joins("JOIN sys_dept ON sys_dept.dept_id = sys_role_dept.dept_id AND sys_dept.delete_time IS NULL").
+				Where("sys_role_dept.role_id = ?", role.RoleId).
+				Order("sys_dept.dept_id").
+				Pluck("sys_dept.dept_name", &dataScope.DeptNames).Error; err != nil {
+				return dataScopes, errors.Wrapf(err, "failed to get custom data scope for role ID %d", role.RoleId)
+			}
+			if err := dal.Gorm.Model(model.SysRoleGroup{}).
+				Joins("JOIN sys_user_group ON sys_user_group.group_id = sys_role_group.group_id AND sys_user_group.delete_time IS NULL").
+				Where("sys_role_group.role_id = ?", role.RoleId).
+				Order("sys_user_group.group_id").
+				Pluck("sys_user_group.group_name", &dataScope.GroupNames).Error; err != nil {
+				return dataScopes, errors.Wrapf(err, "failed to get custom data scope for role ID %d", role.RoleId)
+			}
+		}
+
+		dataScopes = append(dataScopes, dataScope)
+	}
+
+	return dataScopes, nil
+}
+
+// CompareUsers compares the effective permissions of two users
+func (s *AccessReportService) CompareUsers(leftUserId, rightUserId int) (dto.AccessCompareResponse, error) {
+	left, err := s.GetUserEffectivePerms(leftUserId)
+	if err != nil {
+		return dto.AccessCompareResponse{}, err
+	}
+
+	right, err := s.GetUserEffectivePerms(rightUserId)
+	if err != nil {
+		return dto.AccessCompareResponse{}, err
+	}
+
+	return comparePerms(constant.ACCESS_COMPARE_USER, left.UserName, right.UserName, effectivePermStrings(left.Perms), effectivePermStrings(right.Perms)), nil
+}
+
+// CompareRoles compares the permissions granted by two roles
+func (s *AccessReportService) CompareRoles(leftRoleId, rightRoleId int) (dto.AccessCompareResponse, error) {
+	left, leftPerms, err := s.getRolePerms(leftRoleId)
+	if err != nil {
+		return dto.AccessCompareResponse{}, err
+	}
+
+	right, rightPerms, err := s.getRolePerms(rightRoleId)
+	if err != nil {
+		return dto.AccessCompareResponse{}, err
+	}
+
+	return comparePerms(constant.ACCESS_COMPARE_ROLE, left.RoleKey, right.RoleKey, leftPerms, rightPerms), nil
+}
+
+// getRolePerms returns a role with the permissions of its normal menus
+func (s *AccessReportService) getRolePerms(roleId int) (dto.RoleDetailResponse, []string, error) {
+	perms := make([]string, 0)
+
+	role, err := (&RoleService{}).GetRoleByRoleId(roleId)
+	if err != nil || role.RoleId <= 0 {
+		return role, perms, xerrors.ErrAccessRoleNotFound
+	}
+
+	if err := dal.Gorm.Model(model.SysMenu{}).
+		Joins("JOIN sys_role_menu ON sys_role_menu.menu_id = sys_menu.menu_id").
+		Where("sys_role_menu.role_id = ? AND sys_menu.status = ? AND sys_menu.perms <> ''", roleId, constant.NORMAL_STATUS).
+		Distinct().
+		Order("sys_menu.perms").
+		Pluck("sys_menu.perms", &perms).Error; err != nil {
+		return role, perms, errors.Wrapf(err, "failed to get permissions for role ID %d", roleId)
+	}
+
+	return role, perms, nil
+}
+
+// ExplainPerm explains why HasPerm allows or denies a permission for a user
+func (s *AccessReportService) ExplainPerm(userId int, perm string) (dto.AccessExplainResponse, error) {
+	result := dto.AccessExplainResponse{
+		UserId: userId,
+		Perm:   perm,
+		Roles:  make([]string, 0),
+		Grants: make([]dto.AccessGrantResponse, 0),
+	}
+
+	if perm == "" {
+		return result, xerrors.ErrAccessPermEmpty
+	}
+
+	user, err := (&UserService{}).GetUserByUserIdWithErr(userId)
+	if err != nil || user.UserId <= 0 {
+		return result, xerrors.ErrAccessUserNotFound
+	}
+	result.UserName = user.UserName
+
+	if roleKeys, err := (&RoleService{}).GetRoleKeysByUserId(userId); err == nil {
+		result.Roles = roleKeys
+	}
+
+	grants, err := queryAccessGrants(func(db *gorm.DB) *gorm.DB {
+		return db.Where("sys_user.user_id = ? AND sys_menu.perms = ?", userId, perm)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for _, grant := range grants {
+		item := dto.AccessGrantResponse{
+			RoleId:     grant.RoleId,
+			RoleKey:    grant.RoleKey,
+			RoleName:   grant.RoleName,
+			RoleStatus: grant.RoleStatus,
+			MenuId:     grant.MenuId,
+			MenuName:   grant.MenuName,
+			MenuStatus: grant.MenuStatus,
+			Effective:  grant.effective(),
+		}
+		switch {
+		case grant.RoleStatus != constant.NORMAL_STATUS:
+			item.Note = "role is disabled"
+		case grant.MenuStatus != constant.NORMAL_STATUS:
+			item.Note = "menu is disabled"
+		default:
+			item.Note = "grants the permission"
+		}
+		result.Grants = append(result.Grants, item)
+	}
+
+	effective := make([]string, 0)
+	for _, grant := range result.Grants {
+		if grant.Effective {
+			effective = append(effective, grant.RoleKey)
+		}
+	}
+
+	switch {
+	case userId == SUPER_ADMIN_USER_ID:
+		result.Allowed = true
+		result.Reason = "the super administrator bypasses permission checks"
+	case len(effective) > 0:
+		result.Allowed = true
+		result.Reason = "granted by role " + strings.Join(effective, ", ")
+	case len(result.Grants) > 0:
+		result.Reason = "only disabled roles or menus carry the permission"
+	default:
+		result.Reason = "no role of the user carries the permission"
+	}
+
+	if user.Status != constant.NORMAL_STATUS {
+		result.Reason += "; the account is disabled and is rejected before permission checks"
+	}
+
+	return result, nil
+}
+
+// comparePerms splits two permission sets into left-only, right-only and common
+func comparePerms(compareType, left, right string, leftPerms, rightPerms []string) dto.AccessCompareResponse {
+	result := dto.AccessCompareResponse{
+		Type:      compareType,
+		Left:      left,
+		Right:     right,
+		OnlyLeft:  make([]string, 0),
+		OnlyRight: make([]string, 0),
+		Common:    make([]string, 0),
+	}
+
+	for _, perm := range leftPerms {
+		if utils.Contains(rightPerms, perm) {
+			result.Common = append(result.Common, perm)
+		} else {
+			result.OnlyLeft = append(result.OnlyLeft, perm)
+		}
+	}
+
+	for _, perm := range rightPerms {
+		if !utils.Contains(leftPerms, perm) {
+			result.OnlyRight = append(result.OnlyRight, perm)
+		}
+	}
+
+	return result
+}
+
+// effectivePermStrings extracts the permission strings from effective permissions
+func effectivePermStrings(perms []dto.EffectivePermResponse) []string {
+	result := make([]string, 0, len(perms))
+	for _, perm := range perms {
+		result = append(result, perm.Perm)
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/model"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seedAccessReport() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 100, UserName: "editor", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 100, UserName: "viewer", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Editor", RoleKey: "editor", Status: "0", DataScope: DATA_SCOPE_CUSTOM})
+	dal.Gorm.Create(&model.SysRole{RoleId: 3, RoleName: "Viewer", RoleKey: "viewer", Status: "0", DataScope: DATA_SCOPE_DEPT})
+	dal.Gorm.Create(&model.SysRole{RoleId: 4, RoleName: "Legacy", RoleKey: "legacy", Status: "1", DataScope: DATA_SCOPE_ALL})
+	dal.Gorm.Create(&model.SysRoleDept{RoleId: 2, DeptId: 100})
+
+	dal.Gorm.Create(&model.SysMenu{MenuId: 1, MenuName: "User List", MenuType: "F", Perms: "system:user:list", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 2, MenuName: "User Edit", MenuType: "F", Perms: "system:user:edit", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 3, MenuName: "User Remove", MenuType: "F", Perms: "system:user:remove", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 2})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 3, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 4, MenuId: 3})
+
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 2})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 3})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 4})
+}
+
+func TestAccessReportService_GetPermUsers(t *testing.T) {
+	setup()
+	defer teardown()
+	seedAccessReport()
+	s := NewAccessReportService()
+
+	t.Run("should list users holding a permission", func(t *testing.T) {
+		users, err := s.GetPermUsers("system:user:list")
+		assert.NoError(t, err)
+		assert.Len(t, users, 3)
+		assert.Equal(t, SUPER_ADMIN_PERM, users[0].RoleKey)
+	})
+
+	t.Run("should skip disabled roles", func(t *testing.T) {
+		users, err := s.GetPermUsers("system:user:remove")
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, "admin", users[0].UserName)
+	})
+
+	t.Run("should return error when permission is empty", func(t *testing.T) {
+		_, err := s.GetPermUsers("")
+		assert.Equal(t, xerrors.ErrAccessPermEmpty, err)
+	})
+}
+
+func TestAccessReportService_GetUserEffectivePerms(t *testing.T) {
+	setup()
+	defer teardown()
+	seedAccessReport()
+	s := NewAccessReportService()
+
+	t.Run("should return perms with source roles and data scope", func(t *testing.T) {
+		result, err := s.GetUserEffectivePerms(2)
+		assert.NoError(t, err)
+		assert.False(t, result.IsSuperAdmin)
+		assert.Len(t, result.Perms, 2)
+		assert.Equal(t, "system:user:edit", result.Perms[0].Perm)
+		assert.Equal(t, []string{"editor"}, result.Perms[0].Roles)
+		assert.Len(t, result.DataScopes, 1)
+		assert.Equal(t, []string{"HQ"}, result.DataScopes[0].DeptNames)
+	})
+
+	t.Run("should return error when user does not exist", func(t *testing.T) {
+		_, err := s.GetUserEffectivePerms(99)
+		assert.Equal(t, xerrors.ErrAccessUserNotFound, err)
+	})
+}
+
+func TestAccessReportService_Compare(t *testing.T) {
+	setup()
+	defer teardown()
+	seedAccessReport()
+	s := NewAccessReportService()
+
+	t.Run("should compare roles", func(t *testing.T) {
+		result, err := s.CompareRoles(2, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"system:user:edit"}, result.OnlyLeft)
+		assert.Empty(t, result.OnlyRight)
+		assert.Equal(t, []string{"system:user:list"}, result.Common)
+	})
+
+	t.Run("should compare users", func(t *testing.T) {
+		result, err := s.CompareUsers(3, 2)
+		assert.NoError(t, err)
+		assert.Empty(t, result.OnlyLeft)
+		assert.Equal(t, []string{"system:user:edit"}, result.OnlyRight)
+	})
+
+	t.Run("should return error when role does not exist", func(t *testing.T) {
+		_, err := s.CompareRoles(2, 99)
+		assert.Equal(t, xerrors.ErrAccessRoleNotFound, err)
+	})
+}
+
+func TestAccessReportService_ExplainPerm(t *testing.T) {
+	setup()
+	defer teardown()
+	seedAccessReport()
+	s := NewAccessReportService()
+
+	t.Run("should explain granted permission", func(t *testing.T) {
+		result, err := s.ExplainPerm(2, "system:user:edit")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Len(t, result.Grants, 1)
+	})
+
+	t.Run("should explain permission carried only by a disabled role", func(t *testing.T) {
+		result, err := s.ExplainPerm(3, "system:user:remove")
+		assert.NoError(t, err)
+		assert.False(t, result.Allowed)
+		assert.Len(t, result.Grants, 1)
+		assert.False(t, result.Grants[0].Effective)
+		assert.Equal(t, "role is disabled", result.Grants[0].Note)
+	})
+
+	t.Run("should allow super administrator", func(t *testing.T) {
+		result, err := s.ExplainPerm(1, "system:user:remove")
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+	})
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+)
+
+// Approval configuration
+const (
+	// APPROVAL_ACTIONS_CONFIG_KEY lists the actions requiring approval, comma-separated.
+	// Entries follow the policy action syntax: "system:user:remove", "system:user:*" or "*".
+	APPROVAL_ACTIONS_CONFIG_KEY = "sys.approval.actions"
+	// APPROVAL_EXPIRE_HOURS_CONFIG_KEY is the number of hours a change request stays pending
+	APPROVAL_EXPIRE_HOURS_CONFIG_KEY = "sys.approval.expireHours"
+	// APPROVAL_DEFAULT_EXPIRE_HOURS applies when the expire hours are not configured
+	APPROVAL_DEFAULT_EXPIRE_HOURS = 72
+)
+
+// ApprovalChange describes an assignment granting privileges that a request body may change:
+// the JSON fields holding the owner ID (absent on creation) and the assigned IDs, and the link
+// table storing the assignment
+type ApprovalChange struct {
+	OwnerField  string
+	ItemsField  string
+	Table       string
+	OwnerColumn string
+	ItemColumn  string
+}
+
+// Assignments changed by the user, role, post and user group routes
+var (
+	ApprovalChangeUserRoles  = ApprovalChange{"userId", "roleIds", "sys_user_role", "user_id", "role_id"}
+	ApprovalChangeUserPosts  = ApprovalChange{"userId", "postIds", "sys_user_post", "user_id", "post_id"}
+	ApprovalChangeRoleMenus  = ApprovalChange{"roleId", "menuIds", "sys_role_menu", "role_id", "menu_id"}
+	ApprovalChangePostRoles  = ApprovalChange{"postId", "roleIds", "sys_post_role", "post_id", "role_id"}
+	ApprovalChangeGroupRoles = ApprovalChange{"groupId", "roleIds", "sys_user_group_role", "group_id", "role_id"}
+)
+
+// ApprovalServiceInterface defines operations for four-eyes change requests
+type ApprovalServiceInterface interface {
+	IsApprovalRequired(action string) bool
+	CreateApproval(param dto.SaveApproval) (int, error)
+	GetApprovalList(param dto.ApprovalListRequest, isPaging bool) ([]dto.ApprovalListResponse, int)
+	GetApprovalByApprovalId(approvalId int) dto.ApprovalDetailResponse
+	ReviewApproval(approvalId, reviewerId int, reviewerName string, approve bool, reason string) (dto.ApprovalDetailResponse, error)
+	CompleteApproval(approvalId int, status, jsonResult string) error
+	ExpireApprovals() (int, error)
+	IsChangeRequested(change ApprovalChange, body []byte) bool
+}
+
+// ApprovalService implements the change request workflow
+//
+// A change request captures a request to a route requiring approval. It moves from
+// pending to approved (and then executed or failed), rejected or expired exactly once;
+// every transition is guarded on the pending status so concurrent reviews cannot
+// both succeed.
+type ApprovalService struct{}
+
+// Ensure ApprovalService implements ApprovalServiceInterface
+var _ ApprovalServiceInterface = (*ApprovalService)(nil)
+
+// NewApprovalService creates a new ApprovalService
+func NewApprovalService() *ApprovalService {
+	return &ApprovalService{}
+}
+
+// IsApprovalRequired reports whether an action is configured to require approval
+func (s *ApprovalService) IsApprovalRequired(action string) bool {
+	actions := (&ConfigService{}).GetString(APPROVAL_ACTIONS_CONFIG_KEY)
+
+	for _, pattern := range strings.Split(actions, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" && matchPolicyAction(pattern, action) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsChangeRequested reports whether a JSON request body changes an assignment. Absent or null
+// IDs keep the assignment, as the services do; bodies or assignments that cannot be read are
+// treated as changing it.
+func (s *ApprovalService) IsChangeRequested(change ApprovalChange, body []byte) bool {
+	var request map[string]json.RawMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		return true
+	}
+
+	raw, ok := request[change.ItemsField]
+	if !ok || string(raw) == "null" {
+		return false
+	}
+	requested := make([]int, 0)
+	if err := json.Unmarshal(raw, &requested); err != nil {
+		return true
+	}
+
+	var ownerId int
+	if raw, ok := request[change.OwnerField]; ok {
+		if err := json.Unmarshal(raw, &ownerId); err != nil {
+			return true
+		}
+	}
+
+	stored := make([]int, 0)
+	if ownerId > 0 {
+		if err := dal.Gorm.Table(change.Table).Where(change.OwnerColumn+" = ?", ownerId).
+			Pluck(change.ItemColumn, &stored).Error; err != nil {
+			return true
+		}
+	}
+
+	return !sameIds(requested, stored)
+}
+
+// sameIds reports whether two lists hold the same IDs, whatever their order and repetitions
+func sameIds(a, b []int) bool {
+	unique := func(ids []int) []int {
+		sorted := append([]int(nil), ids...)
+		sort.Ints(sorted)
+
+		result := make([]int, 0, len(sorted))
+		for i, id := range sorted {
+			if i == 0 || id != sorted[i-1] {
+				result = append(result, id)
+			}
+		}
+		return result
+	}
+
+	a, b = unique(a), unique(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetApprovalExpireTime returns the expiry time for a change request created now
+func (s *ApprovalService) GetApprovalExpireTime() time.Time {
+	hours := (&ConfigService{}).GetInt(APPROVAL_EXPIRE_HOURS_CONFIG_KEY)
+
+	return time.Now().Add(time.Duration(hours) * time.Hour)
+}
+
+// CreateApproval creates a pending change request and returns its ID
+func (s *ApprovalService) CreateApproval(param dto.SaveApproval) (int, error) {
+	return s.CreateApprovalWithErr(param)
+}
+
+// CreateApprovalWithErr creates a pending change request with proper error handling
+func (s *ApprovalService) CreateApprovalWithErr(param dto.SaveApproval) (int, error) {
+	if param.Action == "" || param.RequestUrl == "" || param.RequesterId <= 0 {
+		return 0, xerrors.ErrParam
+	}
+
+	if param.ExpireTime.IsZero() {
+		param.ExpireTime = datetime.Datetime{Time: s.GetApprovalExpireTime()}
+	}
+
+	approval := model.SysApproval{
+		Action:        param.Action,
+		Title:         param.Title,
+		RequestMethod: param.RequestMethod,
+		RequestUrl:    param.RequestUrl,
+		RequestBody:   param.RequestBody,
+		ContentType:   param.ContentType,
+		RequesterId:   param.RequesterId,
+		RequesterName: param.RequesterName,
+		Status:        constant.APPROVAL_STATUS_PENDING,
+		ExpireTime:    param.ExpireTime,
+	}
+
+	if err := dal.Gorm.Model(model.SysApproval{}).Create(&approval).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to create change request")
+	}
+
+	return approval.ApprovalId, nil
+}
+
+// GetApprovalList retrieves a list of change requests based on search parameters
+func (s *ApprovalService) GetApprovalList(param dto.ApprovalListRequest, isPaging bool) ([]dto.ApprovalListResponse, int) {
+	approvals, count, _ := s.GetApprovalListWithErr(param, isPaging)
+	return approvals, count
+}
+
+// GetApprovalListWithErr retrieves a list of change requests with proper error handling
+func (s *ApprovalService) GetApprovalListWithErr(param dto.ApprovalListRequest, isPaging bool) ([]dto.ApprovalListResponse, int, error) {
+	var count int64
+	approvals := make([]dto.ApprovalListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysApproval{}).Order("approval_id DESC")
+
+	if param.Action != "" {
+		query = query.Where("action LIKE ?", "%"+param.Action+"%")
+	}
+
+	if param.Title != "" {
+		query = query.Where("title LIKE ?", "%"+param.Title+"%")
+	}
+
+	if param.RequesterName != "" {
+		query = query.Where("requester_name LIKE ?", "%"+param.RequesterName+"%")
+	}
+
+	if param.Status != "" {
+		query = query.Where("status = ?", param.Status)
+	}
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count change requests")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&approvals).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve change requests")
+	}
+
+	return approvals, int(count), nil
+}
+
+// GetApprovalByApprovalId retrieves change request details by ID
+func (s *ApprovalService) GetApprovalByApprovalId(approvalId int) dto.ApprovalDetailResponse {
+	approval, _ := s.GetApprovalByApprovalIdWithErr(approvalId)
+	return approval
+}
+
+// GetApprovalByApprovalIdWithErr retrieves change request details by ID with proper error handling
+func (s *ApprovalService) GetApprovalByApprovalIdWithErr(approvalId int) (dto.ApprovalDetailResponse, error) {
+	var approval dto.ApprovalDetailResponse
+
+	if approvalId <= 0 {
+		return approval, xerrors.ErrParam
+	}
+
+	err := dal.Gorm.Model(model.SysApproval{}).Where("approval_id = ?", approvalId).Last(&approval).Error
+	if err != nil {
+		return approval, errors.Wrap(err, "failed to retrieve change request by ID")
+	}
+
+	return approval, nil
+}
+
+// ReviewApproval approves or rejects a pending change request.
+// The requester cannot review their own request, and an overdue request is expired instead.
+func (s *ApprovalService) ReviewApproval(approvalId, reviewerId int, reviewerName string, approve bool, reason string) (dto.ApprovalDetailResponse, error) {
+	approval, err := s.GetApprovalByApprovalIdWithErr(approvalId)
+	if err != nil || approval.ApprovalId <= 0 {
+		return approval, xerrors.ErrApprovalNotFound
+	}
+
+	if approval.Status != constant.APPROVAL_STATUS_PENDING {
+		return approval, xerrors.ErrApprovalNotPending
+	}
+
+	if approval.RequesterId == reviewerId {
+		return approval, xerrors.ErrApprovalSelfReview
+	}
+
+	if !approve && strings.TrimSpace(reason) == "" {
+		return approval, xerrors.ErrApprovalReasonEmpty
+	}
+
+	if approval.ExpireTime.Before(time.Now()) {
+		s.ExpireApprovals()
+		return approval, xerrors.ErrApprovalExpired
+	}
+
+	status := constant.APPROVAL_STATUS_REJECTED
+	if approve {
+		status = constant.APPROVAL_STATUS_APPROVED
+	}
+
+	reviewTime := datetime.Datetime{Time: time.Now()}
+
+	result := dal.Gorm.Model(model.SysApproval{}).
+		Where("approval_id = ? AND status = ?", approvalId, constant.APPROVAL_STATUS_PENDING).
+		Updates(&model.SysApproval{
+			Status:       status,
+			ReviewerId:   reviewerId,
+			ReviewerName: reviewerName,
+			ReviewReason: reason,
+			ReviewTime:   reviewTime,
+		})
+	if result.Error != nil {
+		return approval, errors.Wrap(result.Error, "failed to review change request")
+	}
+
+	if result.RowsAffected == 0 {
+		return approval, xerrors.ErrApprovalNotPending
+	}
+
+	approval.Status = status
+	approval.ReviewerId = reviewerId
+	approval.ReviewerName = reviewerName
+	approval.ReviewReason = reason
+	approval.ReviewTime = reviewTime
+
+	return approval, nil
+}
+
+// CompleteApproval records the outcome of executing an approved change request
+func (s *ApprovalService) CompleteApproval(approvalId int, status, jsonResult string) error {
+	err := dal.Gorm.Model(model.SysApproval{}).
+		Where("approval_id = ? AND status = ?", approvalId, constant.APPROVAL_STATUS_APPROVED).
+		Updates(&model.SysApproval{
+			Status:     status,
+			JsonResult: jsonResult,
+		}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to complete change request")
+	}
+
+	return nil
+}
+
+// ExpireApprovals expires overdue pending change requests, recording each in the operation log
+func (s *ApprovalService) ExpireApprovals() (int, error) {
+	approvals := make([]model.SysApproval, 0)
+
+	now := time.Now()
+
+	if err := dal.Gorm.Model(model.SysApproval{}).
+		Where("status = ? AND expire_time < ?", constant.APPROVAL_STATUS_PENDING, now).
+		Find(&approvals).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve overdue change requests")
+	}
+
+	expired := 0
+	for _, approval := range approvals {
+		result := dal.Gorm.Model(model.SysApproval{}).
+			Where("approval_id = ? AND status = ?", approval.ApprovalId, constant.APPROVAL_STATUS_PENDING).
+			Update("status", constant.APPROVAL_STATUS_EXPIRED)
+		if result.Error != nil {
+			return expired, errors.Wrap(result.Error, "failed to expire change request")
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		expired++
+
+		err := (&OperLogService{}).CreateSysOperLogWithErr(dto.SaveOperLogRequest{
+			Title:         "Change Request Expired",
+			BusinessType:  constant.REQUEST_BUSINESS_TYPE_OTHER,
+			Method:        "ApprovalService.ExpireApprovals",
+			RequestMethod: approval.RequestMethod,
+			OperName:      approval.RequesterName,
+			OperUrl:       approval.RequestUrl,
+			OperParam:     approval.RequestBody,
+			Status:        constant.EXCEPTION_STATUS,
+			ErrorMsg:      "change request " + strconv.Itoa(approval.ApprovalId) + " (" + approval.Title + ") expired without review",
+			OperTime:      datetime.Datetime{Time: now},
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+
+	return expired, nil
+}
+
+// StartExpireScheduler expires overdue change requests at every interval until the context
+// is done
+func (s *ApprovalService) StartExpireScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.ExpireApprovals(); err != nil {
+					log.Printf("Warning: Failed to expire overdue change requests: %v", err)
+				}
+			}
+		}
+	}()
+}
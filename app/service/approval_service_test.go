@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestApproval(t *testing.T, s *ApprovalService, expireTime time.Time) int {
+	approvalId, err := s.CreateApprovalWithErr(dto.SaveApproval{
+		Action:        "system:user:remove",
+		Title:         "Delete User",
+		RequestMethod: "DELETE",
+		RequestUrl:    "/api/system/user/3",
+		RequesterId:   2,
+		RequesterName: "editor",
+		ExpireTime:    datetime.Datetime{Time: expireTime},
+	})
+	assert.NoError(t, err)
+	return approvalId
+}
+
+func TestApprovalService_IsApprovalRequired(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewApprovalService()
+
+	dal.Gorm.Create(&model.SysConfig{ConfigKey: APPROVAL_ACTIONS_CONFIG_KEY, ConfigValue: "system:user:remove, system:role:*"})
+
+	assert.True(t, s.IsApprovalRequired("system:user:remove"))
+	assert.True(t, s.IsApprovalRequired("system:role:dataScope"))
+	assert.False(t, s.IsApprovalRequired("system:user:edit"))
+}
+
+func TestApprovalService_ReviewApproval(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewApprovalService()
+
+	t.Run("should reject review by the requester", func(t *testing.T) {
+		approvalId := newTestApproval(t, s, time.Now().Add(time.Hour))
+		_, err := s.ReviewApproval(approvalId, 2, "editor", true, "")
+		assert.Equal(t, xerrors.ErrApprovalSelfReview, err)
+	})
+
+	t.Run("should approve once and complete", func(t *testing.T) {
+		approvalId := newTestApproval(t, s, time.Now().Add(time.Hour))
+
+		approval, err := s.ReviewApproval(approvalId, 1, "admin", true, "")
+		assert.NoError(t, err)
+		assert.Equal(t, constant.APPROVAL_STATUS_APPROVED, approval.Status)
+
+		_, err = s.ReviewApproval(approvalId, 3, "auditor", true, "")
+		assert.Equal(t, xerrors.ErrApprovalNotPending, err)
+
+		assert.NoError(t, s.CompleteApproval(approvalId, constant.APPROVAL_STATUS_FAILED, `{"code":500}`))
+		assert.Equal(t, constant.APPROVAL_STATUS_FAILED, s.GetApprovalByApprovalId(approvalId).Status)
+	})
+
+	t.Run("should require a reason to reject", func(t *testing.T) {
+		approvalId := newTestApproval(t, s, time.Now().Add(time.Hour))
+
+		_, err := s.ReviewApproval(approvalId, 1, "admin", false, "")
+		assert.Equal(t, xerrors.ErrApprovalReasonEmpty, err)
+
+		approval, err := s.ReviewApproval(approvalId, 1, "admin", false, "not justified")
+		assert.NoError(t, err)
+		assert.Equal(t, constant.APPROVAL_STATUS_REJECTED, approval.Status)
+	})
+
+	t.Run("should expire overdue request", func(t *testing.T) {
+		approvalId := newTestApproval(t, s, time.Now().Add(-time.Minute))
+
+		_, err := s.ReviewApproval(approvalId, 1, "admin", true, "")
+		assert.Equal(t, xerrors.ErrApprovalExpired, err)
+		assert.Equal(t, constant.APPROVAL_STATUS_EXPIRED, s.GetApprovalByApprovalId(approvalId).Status)
+
+		var count int64
+		dal.Gorm.Model(model.SysOperLog{}).Where("title = ?", "Change Request Expired").Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should return error when request does not exist", func(t *testing.T) {
+		_, err := s.ReviewApproval(999, 1, "admin", true, "")
+		assert.Equal(t, xerrors.ErrApprovalNotFound, err)
+	})
+}
+
+func TestApprovalService_IsChangeRequested(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewApprovalService()
+
+	dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 2})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 4})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected bool
+	}{
+		{"same roles in another order", `{"userId":3,"roleIds":[4,2,2]}`, false},
+		{"roles absent", `{"userId":3,"nickName":"x"}`, false},
+		{"roles null", `{"userId":3,"roleIds":null}`, false},
+		{"role granted", `{"userId":3,"roleIds":[2,4,1]}`, true},
+		{"role revoked", `{"userId":3,"roleIds":[2]}`, true},
+		{"roles on creation", `{"roleIds":[2]}`, true},
+		{"no roles on creation", `{"roleIds":[]}`, false},
+		{"unreadable body", `roleIds=1`, true},
+		{"unreadable roles", `{"userId":3,"roleIds":"1"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, s.IsChangeRequested(ApprovalChangeUserRoles, []byte(tt.body)))
+		})
+	}
+}
+
+func TestApprovalService_StartExpireScheduler(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewApprovalService()
+
+	approvalId := newTestApproval(t, s, time.Now().Add(-time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.StartExpireScheduler(ctx, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return s.GetApprovalByApprovalId(approvalId).Status == constant.APPROVAL_STATUS_EXPIRED
+	}, time.Second, 10*time.Millisecond)
+}
@@ -15,6 +15,8 @@ const (
 	TaskTypeGenerateReport TaskType = "generate_report"
 	TaskTypeCleanupCache  TaskType = "cleanup_cache"
 	TaskTypeAuditLog      TaskType = "audit_log"
+	TaskTypeImport        TaskType = "import"
+	TaskTypeExport        TaskType = "export"
 )
 
 // BaseTask provides common functionality for all background tasks
@@ -105,6 +107,10 @@ func (bts *BackgroundTaskService) SubmitTask(taskType TaskType, data map[string]
 		task.Handler = bts.cleanupCacheHandler
 	case TaskTypeAuditLog:
 		task.Handler = bts.auditLogHandler
+	case TaskTypeImport:
+		task.Handler = bts.importHandler
+	case TaskTypeExport:
+		task.Handler = bts.exportHandler
 	default:
 		return fmt.Errorf("unknown task type: %s", taskType)
 	}
@@ -158,6 +164,24 @@ func (bts *BackgroundTaskService) SubmitAuditLogTask(action string, userID int,
 	return bts.SubmitTask(TaskTypeAuditLog, taskData, 1)
 }
 
+// SubmitImportTask submits an import job, run sequentially by a single worker
+func (bts *BackgroundTaskService) SubmitImportTask(jobId int, run func(ctx context.Context) error) error {
+	taskData := map[string]interface{}{
+		"job_id": jobId,
+		"run":    run,
+	}
+	return bts.SubmitTask(TaskTypeImport, taskData, 2)
+}
+
+// SubmitExportTask submits an export job that writes its file in the background
+func (bts *BackgroundTaskService) SubmitExportTask(jobId int, run func(ctx context.Context) error) error {
+	taskData := map[string]interface{}{
+		"job_id": jobId,
+		"run":    run,
+	}
+	return bts.SubmitTask(TaskTypeExport, taskData, 2)
+}
+
 // Task handlers
 func (bts *BackgroundTaskService) sendEmailHandler(ctx context.Context, data map[string]interface{}) error {
 	to, ok := data["to"].(string)
@@ -272,6 +296,24 @@ func (bts *BackgroundTaskService) auditLogHandler(ctx context.Context, data map[
 	return nil
 }
 
+func (bts *BackgroundTaskService) importHandler(ctx context.Context, data map[string]interface{}) error {
+	run, ok := data["run"].(func(ctx context.Context) error)
+	if !ok {
+		return fmt.Errorf("import job %v has nothing to run", data["job_id"])
+	}
+
+	return run(ctx)
+}
+
+func (bts *BackgroundTaskService) exportHandler(ctx context.Context, data map[string]interface{}) error {
+	run, ok := data["run"].(func(ctx context.Context) error)
+	if !ok {
+		return fmt.Errorf("export job %v has nothing to run", data["job_id"])
+	}
+
+	return run(ctx)
+}
+
 // GetStats returns background task service statistics
 func (bts *BackgroundTaskService) GetStats() PoolStats {
 	return bts.workerPool.GetStats()
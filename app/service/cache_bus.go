@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"mira/anima/dal"
+	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
+	"mira/common/uuid"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Cache invalidation bus
+//
+// The services announce every change that makes cached data stale as a typed event. Events are
+// handled at once by the handlers of the instance making the change and, once the bus is
+// started, published over Redis pub/sub to the other instances, whose handlers drop their local
+// caches in turn. Redis pub/sub delivers only to connected subscribers, so an instance that
+// (re)subscribes handles an event of every type without keys or IDs, dropping everything it
+// may have missed.
+
+// CacheEvent reports that cached data of a type changed
+type CacheEvent struct {
+	// Type is one of the constant.CACHE_EVENT_* types
+	Type string `json:"type"`
+	// Keys are the changed configuration keys or dictionary types
+	Keys []string `json:"keys,omitempty"`
+	// Ids are the changed users, roles, menus, departments or posts
+	Ids []int `json:"ids,omitempty"`
+	// Origin is the instance that made the change
+	Origin string `json:"origin"`
+}
+
+// All reports whether every entry of the type may have changed, as no keys or IDs are given
+func (e CacheEvent) All() bool {
+	return len(e.Keys) == 0 && len(e.Ids) == 0
+}
+
+// Remote reports whether the event comes from another instance
+func (e CacheEvent) Remote() bool {
+	return e.Origin != cacheBusInstance
+}
+
+// CacheEventHandler handles a cache event. Handlers run on the goroutine of the change or of the
+// subscription and must not block.
+type CacheEventHandler func(event CacheEvent)
+
+// cacheEventTypes are the types of cache events
+var cacheEventTypes = []string{
+	constant.CACHE_EVENT_CONFIG, constant.CACHE_EVENT_DICT, constant.CACHE_EVENT_USER, constant.CACHE_EVENT_ROLE,
+	constant.CACHE_EVENT_MENU, constant.CACHE_EVENT_DEPT, constant.CACHE_EVENT_POST,
+}
+
+type cacheEventSubscription struct {
+	id      int
+	typ     string
+	handler CacheEventHandler
+}
+
+var (
+	cacheBusMu       sync.RWMutex
+	cacheBusHandlers []cacheEventSubscription
+	cacheBusLastId   int
+	cacheBusStarted  bool
+	// cacheBusInstance tells the events of this instance apart from those of the others
+	cacheBusInstance = newCacheBusInstance()
+)
+
+func newCacheBusInstance() string {
+	id, err := uuid.New()
+	if err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return id
+}
+
+// OnCacheEvent registers a handler of the cache events of a type, of every type when the type is
+// empty, and returns the function removing it
+func OnCacheEvent(typ string, handler CacheEventHandler) func() {
+	cacheBusMu.Lock()
+	defer cacheBusMu.Unlock()
+
+	cacheBusLastId++
+	id := cacheBusLastId
+	cacheBusHandlers = append(cacheBusHandlers, cacheEventSubscription{id: id, typ: typ, handler: handler})
+
+	return func() {
+		cacheBusMu.Lock()
+		defer cacheBusMu.Unlock()
+
+		for i, subscription := range cacheBusHandlers {
+			if subscription.id == id {
+				cacheBusHandlers = append(cacheBusHandlers[:i:i], cacheBusHandlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// StartCacheBus subscribes to the cache events of the other instances and publishes those of this
+// instance until the context is done
+func StartCacheBus(ctx context.Context) {
+	pubsub := dal.Redis.Subscribe(ctx, rediskey.CacheEventChannel())
+	messages := pubsub.ChannelWithSubscriptions(ctx, 100)
+
+	cacheBusMu.Lock()
+	cacheBusStarted = true
+	cacheBusMu.Unlock()
+
+	go func() {
+		defer func() {
+			cacheBusMu.Lock()
+			cacheBusStarted = false
+			cacheBusMu.Unlock()
+			pubsub.Close()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				switch m := message.(type) {
+				case *redis.Subscription:
+					// Events published while the connection was down are lost
+					if m.Kind == "subscribe" {
+						for _, typ := range cacheEventTypes {
+							dispatchCacheEvent(CacheEvent{Type: typ})
+						}
+					}
+				case *redis.Message:
+					receiveCacheEvent(m.Payload)
+				}
+			}
+		}
+	}()
+}
+
+// receiveCacheEvent handles an event published on the channel, ignoring those of this instance,
+// which were handled when they were published
+func receiveCacheEvent(payload string) {
+	var event CacheEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("Warning: Invalid cache event %q: %v", payload, err)
+		return
+	}
+	if !event.Remote() {
+		return
+	}
+
+	dispatchCacheEvent(event)
+}
+
+// publishCacheEvent handles a cache event of this instance and announces it to the others
+func publishCacheEvent(event CacheEvent) {
+	event.Origin = cacheBusInstance
+	dispatchCacheEvent(event)
+
+	cacheBusMu.RLock()
+	started := cacheBusStarted
+	cacheBusMu.RUnlock()
+	if !started {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Warning: Failed to encode cache event: %v", err)
+		return
+	}
+	if err = dal.Redis.Publish(context.Background(), rediskey.CacheEventChannel(), string(payload)).Err(); err != nil {
+		log.Printf("Warning: Failed to publish %s cache event: %v", event.Type, err)
+	}
+}
+
+// publishCacheKeys announces changes to configuration parameters or dictionary types, to all of
+// them when no key is given
+func publishCacheKeys(typ string, keys ...string) {
+	publishCacheEvent(CacheEvent{Type: typ, Keys: keys})
+}
+
+// publishCacheIds announces changes to users, roles, menus, departments or posts, to all of them
+// when no ID is given
+func publishCacheIds(typ string, ids ...int) {
+	publishCacheEvent(CacheEvent{Type: typ, Ids: ids})
+}
+
+// dispatchCacheEvent runs the handlers of an event
+func dispatchCacheEvent(event CacheEvent) {
+	cacheBusMu.RLock()
+	handlers := make([]CacheEventHandler, 0, len(cacheBusHandlers))
+	for _, subscription := range cacheBusHandlers {
+		if subscription.typ == "" || subscription.typ == event.Type {
+			handlers = append(handlers, subscription.handler)
+		}
+	}
+	cacheBusMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
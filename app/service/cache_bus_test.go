@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordCacheEvents records the cache events of a type until the returned function is called
+func recordCacheEvents(typ string) (*[]CacheEvent, func()) {
+	events := make([]CacheEvent, 0)
+	unregister := OnCacheEvent(typ, func(event CacheEvent) {
+		events = append(events, event)
+	})
+	return &events, unregister
+}
+
+func TestCacheBus_Dispatch(t *testing.T) {
+	t.Run("should run the handlers of the type", func(t *testing.T) {
+		users, unregisterUsers := recordCacheEvents(constant.CACHE_EVENT_USER)
+		all, unregisterAll := recordCacheEvents("")
+		defer unregisterAll()
+
+		publishCacheIds(constant.CACHE_EVENT_USER, 1, 2)
+		publishCacheKeys(constant.CACHE_EVENT_DICT, "sys_color")
+
+		if assert.Len(t, *users, 1) {
+			assert.Equal(t, []int{1, 2}, (*users)[0].Ids)
+			assert.False(t, (*users)[0].Remote())
+		}
+		assert.Len(t, *all, 2)
+
+		unregisterUsers()
+		publishCacheIds(constant.CACHE_EVENT_USER)
+		assert.Len(t, *users, 1)
+		if assert.Len(t, *all, 3) {
+			assert.True(t, (*all)[2].All())
+		}
+	})
+
+	t.Run("should handle only the events of other instances", func(t *testing.T) {
+		events, unregister := recordCacheEvents(constant.CACHE_EVENT_ROLE)
+		defer unregister()
+
+		receiveCacheEvent(`{"type":"role","ids":[3],"origin":"` + cacheBusInstance + `"}`)
+		receiveCacheEvent(`not json`)
+		assert.Empty(t, *events)
+
+		receiveCacheEvent(`{"type":"role","ids":[3],"origin":"other"}`)
+		if assert.Len(t, *events, 1) {
+			assert.Equal(t, []int{3}, (*events)[0].Ids)
+			assert.True(t, (*events)[0].Remote())
+		}
+	})
+}
+
+func TestCacheBus_Publish(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cacheBusMu.Lock()
+	cacheBusStarted = true
+	cacheBusMu.Unlock()
+	defer func() {
+		cacheBusMu.Lock()
+		cacheBusStarted = false
+		cacheBusMu.Unlock()
+	}()
+
+	t.Run("should publish the changes of the services", func(t *testing.T) {
+		payload, _ := json.Marshal(CacheEvent{Type: constant.CACHE_EVENT_DICT, Keys: []string{"sys_color"}, Origin: cacheBusInstance})
+		redisMock.ExpectPublish(rediskey.CacheEventChannel(), string(payload)).SetVal(3)
+
+		assert.NoError(t, (&DictDataService{}).CreateDictData(dto.SaveDictData{DictType: "sys_color", DictLabel: "Red", DictValue: "r", Status: "0"}))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
+
+func TestCacheBus_Search(t *testing.T) {
+	calls := make([][]int, 0)
+	SetSearchHook(func(typ string, ids ...int) {
+		if typ == constant.SEARCH_TYPE_USER || typ == constant.SEARCH_TYPE_CONFIG {
+			calls = append(calls, ids)
+		}
+	})
+	defer SetSearchHook(nil)
+
+	t.Run("should follow the changes of other instances", func(t *testing.T) {
+		receiveCacheEvent(`{"type":"user","ids":[5],"origin":"other"}`)
+		// Configuration parameters are announced by key, so all of them are reloaded
+		receiveCacheEvent(`{"type":"config","keys":["sys.index.skinName"],"origin":"other"}`)
+		// The changes of this instance were already told
+		publishCacheIds(constant.CACHE_EVENT_USER, 6)
+
+		assert.Equal(t, [][]int{{5}, nil}, calls)
+	})
+}
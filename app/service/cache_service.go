@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	"mira/anima/dal"
+	"mira/app/dto"
 	"mira/common/types/redis-key"
 )
 
@@ -161,18 +162,10 @@ func (c *CacheService) GetWithFallback(ctx context.Context, key string, dest int
 	return json.Unmarshal(jsonValue, dest)
 }
 
-// InvalidatePattern removes all keys matching a pattern
+// InvalidatePattern removes all keys matching a pattern, walking the keys with SCAN. Prefer tags,
+// see SetWithTags, which do not walk the keyspace.
 func (c *CacheService) InvalidatePattern(ctx context.Context, pattern string) error {
-	keys, err := dal.Redis.Keys(ctx, pattern).Result()
-	if err != nil {
-		return err
-	}
-
-	if len(keys) == 0 {
-		return nil
-	}
-
-	return c.DeleteMultiple(ctx, keys)
+	return scanCacheKeys(ctx, pattern, unlinkCacheKeys)
 }
 
 // Increment increments a numeric value in cache
@@ -233,6 +226,11 @@ func (c *CacheService) GetStats(ctx context.Context) (*CacheStats, error) {
 	return stats, nil
 }
 
+// GetCacheMetrics returns the hit and miss counts of the two-level caches of this instance
+func (c *CacheService) GetCacheMetrics() []dto.CacheMetricsResponse {
+	return twoLevelCacheMetricsList()
+}
+
 // InvalidateUserCache removes all cache entries for a specific user
 func (c *CacheService) InvalidateUserCache(ctx context.Context, userID int) error {
 	cacheKeys := []string{
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"mira/anima/dal"
+	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Cache tags
+//
+// Entries cached with tags are added to a Redis set per tag, so that the entries of a user, a role,
+// a department or the menus are dropped by reading the set instead of searching the keys. The sets
+// are drained with SPOP and the entries unlinked one key per command, which keeps every command
+// within a single slot on Redis Cluster. Pattern invalidation walks the keys with SCAN on every master instead of
+// blocking Redis with KEYS.
+
+const (
+	// cacheTagTTL bounds the life of the tagged entries, so that no entry outlives the sets of its
+	// tags. Every entry added refreshes the sets.
+	cacheTagTTL = 24 * time.Hour
+	// cacheScanCount is the number of keys read per SCAN or SPOP and unlinked per round trip
+	cacheScanCount = 500
+
+	// cacheTagMenu tags the entries depending on any menu
+	cacheTagMenu = "menu"
+	// cacheTagUserList tags the pages of the user list
+	cacheTagUserList = "user:list"
+)
+
+// cacheTagUser tags the entries of a user
+func cacheTagUser(userId int) string {
+	return "user:" + strconv.Itoa(userId)
+}
+
+// cacheTagRole tags the entries depending on a role
+func cacheTagRole(roleId int) string {
+	return "role:" + strconv.Itoa(roleId)
+}
+
+// cacheTagDept tags the entries depending on a department and its subtree
+func cacheTagDept(deptId int) string {
+	return "dept:" + strconv.Itoa(deptId)
+}
+
+// Changes to users, roles and menus drop the entries tagged with them. The entries are shared in
+// Redis, so only the events of this instance drop them.
+func init() {
+	OnCacheEvent("", func(event CacheEvent) {
+		if event.Remote() {
+			return
+		}
+
+		ctx := context.Background()
+		var err error
+		switch event.Type {
+		case constant.CACHE_EVENT_USER:
+			err = invalidateCacheTagGroup(ctx, event.Ids, cacheTagUser, "user:*")
+		case constant.CACHE_EVENT_ROLE:
+			err = invalidateCacheTagGroup(ctx, event.Ids, cacheTagRole, "role:*")
+		case constant.CACHE_EVENT_MENU:
+			err = invalidateCacheTag(ctx, rediskey.CacheTagKey(cacheTagMenu))
+		}
+		if err != nil {
+			log.Printf("Warning: Failed to drop the cache entries tagged %s: %v", event.Type, err)
+		}
+	})
+}
+
+// SetWithTags stores a value in cache under tags. Entries live at most a day however long the
+// expiration, so that they never outlive the sets of their tags.
+func (c *CacheService) SetWithTags(ctx context.Context, key string, value interface{}, expiration time.Duration, tags ...string) error {
+	jsonValue, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache value: %w", err)
+	}
+
+	if expiration <= 0 || expiration > cacheTagTTL {
+		expiration = cacheTagTTL
+	}
+
+	pipe := dal.Redis.Pipeline()
+	pipe.Set(ctx, key, jsonValue, expiration)
+	for _, tag := range tags {
+		tagKey := rediskey.CacheTagKey(tag)
+		pipe.SAdd(ctx, tagKey, key)
+		pipe.Expire(ctx, tagKey, cacheTagTTL)
+	}
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// InvalidateTags removes the entries cached under any of the tags
+func (c *CacheService) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		if err := invalidateCacheTag(ctx, rediskey.CacheTagKey(tag)); err != nil {
+			return fmt.Errorf("failed to invalidate cache tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// invalidateCacheTagGroup removes the entries tagged with the IDs, or with any tag matching the
+// pattern when no ID is given
+func invalidateCacheTagGroup(ctx context.Context, ids []int, tag func(int) string, pattern string) error {
+	if len(ids) > 0 {
+		for _, id := range ids {
+			if err := invalidateCacheTag(ctx, rediskey.CacheTagKey(tag(id))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return scanCacheKeys(ctx, rediskey.CacheTagKey(pattern), func(ctx context.Context, tagKeys []string) error {
+		for _, tagKey := range tagKeys {
+			if err := invalidateCacheTag(ctx, tagKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// invalidateCacheTag drains the set of a tag, unlinking its entries. Entries added meanwhile are
+// left in the set for the next invalidation.
+func invalidateCacheTag(ctx context.Context, tagKey string) error {
+	for {
+		keys, err := dal.Redis.SPopN(ctx, tagKey, cacheScanCount).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+		if err := unlinkCacheKeys(ctx, keys); err != nil {
+			return err
+		}
+	}
+}
+
+// unlinkCacheKeys removes keys without blocking Redis, one key per command since the keys may
+// belong to different slots
+func unlinkCacheKeys(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := dal.Redis.Pipeline()
+	for _, key := range keys {
+		pipe.Unlink(ctx, key)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// scanCacheKeys passes the keys matching a pattern to fn a batch at a time, walking every master
+// on Redis Cluster. Keys may be passed more than once, and keys added during the walk may be
+// missed.
+func scanCacheKeys(ctx context.Context, pattern string, fn func(ctx context.Context, keys []string) error) error {
+	scan := func(ctx context.Context, client redis.UniversalClient) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(ctx, cursor, pattern, cacheScanCount).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := fn(ctx, keys); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	}
+
+	if cluster, ok := dal.Redis.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scan(ctx, node)
+		})
+	}
+	return scan(ctx, dal.Redis)
+}
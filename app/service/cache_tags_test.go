@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheService_SetWithTags(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cache := NewCacheService()
+	ctx := context.Background()
+
+	t.Run("should add the entry to the sets of its tags", func(t *testing.T) {
+		redisMock.ExpectSet("profile:42", []byte(`{"id":42}`), 30*time.Minute).SetVal("OK")
+		redisMock.ExpectSAdd(rediskey.CacheTagKey("user:42"), "profile:42").SetVal(1)
+		redisMock.ExpectExpire(rediskey.CacheTagKey("user:42"), cacheTagTTL).SetVal(true)
+		redisMock.ExpectSAdd(rediskey.CacheTagKey(cacheTagMenu), "profile:42").SetVal(1)
+		redisMock.ExpectExpire(rediskey.CacheTagKey(cacheTagMenu), cacheTagTTL).SetVal(true)
+
+		err := cache.SetWithTags(ctx, "profile:42", map[string]int{"id": 42}, 30*time.Minute, cacheTagUser(42), cacheTagMenu)
+		assert.NoError(t, err)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should not outlive the sets of its tags", func(t *testing.T) {
+		redisMock.ExpectSet("profile:42", []byte(`1`), cacheTagTTL).SetVal("OK")
+		redisMock.ExpectSAdd(rediskey.CacheTagKey("role:3"), "profile:42").SetVal(1)
+		redisMock.ExpectExpire(rediskey.CacheTagKey("role:3"), cacheTagTTL).SetVal(true)
+
+		assert.NoError(t, cache.SetWithTags(ctx, "profile:42", 1, 0, cacheTagRole(3)))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
+
+func TestCacheService_InvalidateTags(t *testing.T) {
+	setup()
+	defer teardown()
+
+	cache := NewCacheService()
+	ctx := context.Background()
+
+	t.Run("should drain the sets of the tags", func(t *testing.T) {
+		tagKey := rediskey.CacheTagKey(cacheTagUserList)
+		redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{"list:1", "list:1:total"})
+		redisMock.ExpectUnlink("list:1").SetVal(1)
+		redisMock.ExpectUnlink("list:1:total").SetVal(1)
+		redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{})
+
+		assert.NoError(t, cache.InvalidateTags(ctx, cacheTagUserList))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should report failures", func(t *testing.T) {
+		redisMock.ExpectSPopN(rediskey.CacheTagKey(cacheTagMenu), cacheScanCount).SetErr(assert.AnError)
+
+		err := cache.InvalidateTags(ctx, cacheTagMenu)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
+
+func TestCacheService_InvalidatePatternScan(t *testing.T) {
+	setup()
+	defer teardown()
+
+	redisMock.ExpectScan(0, "report:*", cacheScanCount).SetVal([]string{"report:1"}, 7)
+	redisMock.ExpectUnlink("report:1").SetVal(1)
+	redisMock.ExpectScan(7, "report:*", cacheScanCount).SetVal([]string{}, 0)
+
+	assert.NoError(t, NewCacheService().InvalidatePattern(context.Background(), "report:*"))
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestDataScopeCacheTags(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope DataScopeInfo
+		tags  []string
+	}{
+		{"should tag all data scopes with the user only", DataScopeInfo{UserID: 2, DeptID: 101, DataScope: DATA_SCOPE_ALL}, []string{"user:2"}},
+		{"should tag department scopes with the department", DataScopeInfo{UserID: 2, DeptID: 101, DataScope: DATA_SCOPE_DEPT}, []string{"user:2", "dept:101"}},
+		{"should tag sub-department scopes with the top department", DataScopeInfo{UserID: 2, DeptID: 101, DataScope: DATA_SCOPE_DEPT_SUB, DeptIDs: []int{101, 103}}, []string{"user:2", "dept:101"}},
+		{"should tag custom scopes with the granted departments", DataScopeInfo{UserID: 2, DeptID: 101, DataScope: DATA_SCOPE_CUSTOM, DeptIDs: []int{102, 104}}, []string{"user:2", "dept:102", "dept:104"}},
+		{"should tag personal scopes with the user only", DataScopeInfo{UserID: 2, DeptID: 101, DataScope: DATA_SCOPE_PERSONAL}, []string{"user:2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.tags, dataScopeCacheTags(&tt.scope))
+		})
+	}
+}
+
+func TestInvalidateDeptCaches(t *testing.T) {
+	setup()
+	defer teardown()
+
+	tagKey := rediskey.CacheTagKey(cacheTagDept(101))
+	redisMock.ExpectDel(rediskey.DeptTreeKey()).SetVal(1)
+	redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{rediskey.UserDataScopeKey(2)})
+	redisMock.ExpectUnlink(rediskey.UserDataScopeKey(2)).SetVal(1)
+	redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{})
+
+	invalidateDeptCaches([]string{cacheTagDept(101)})
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestCacheTags_Events(t *testing.T) {
+	setup()
+	defer teardown()
+
+	t.Run("should drop the entries of the roles changed", func(t *testing.T) {
+		tagKey := rediskey.CacheTagKey("role:3")
+		redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{"roles:42"})
+		redisMock.ExpectUnlink("roles:42").SetVal(1)
+		redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{})
+		redisMock.ExpectDel(rediskey.SysUserDataScopeKey()).SetVal(0)
+		redisMock.ExpectDel(rediskey.SysUserPermsKey()).SetVal(0)
+
+		publishCacheIds(constant.CACHE_EVENT_ROLE, 3)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should drop the entries of every user", func(t *testing.T) {
+		tagKey := rediskey.CacheTagKey("user:42")
+		redisMock.ExpectScan(0, rediskey.CacheTagKey("user:*"), cacheScanCount).SetVal([]string{tagKey}, 0)
+		redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{"profile:42"})
+		redisMock.ExpectUnlink("profile:42").SetVal(1)
+		redisMock.ExpectSPopN(tagKey, cacheScanCount).SetVal([]string{})
+		redisMock.ExpectDel(rediskey.SysUserDataScopeKey()).SetVal(0)
+		redisMock.ExpectDel(rediskey.SysUserPermsKey()).SetVal(0)
+
+		publishCacheIds(constant.CACHE_EVENT_USER)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should leave the entries to the instance changing the data", func(t *testing.T) {
+		receiveCacheEvent(`{"type":"menu","origin":"other"}`)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
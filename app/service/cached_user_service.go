@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"mira/app/dto"
+	"mira/common/types/constant"
 	"mira/common/types/redis-key"
 )
 
@@ -38,7 +39,7 @@ func (s *CachedUserService) GetUserByUserIdCached(ctx context.Context, userId in
 	dbResult := s.UserService.GetUserByUserId(userId)
 
 	// Cache the result for 30 minutes
-	if setErr := s.cacheService.Set(ctx, cacheKey, dbResult, 30*time.Minute); setErr != nil {
+	if setErr := s.cacheService.SetWithTags(ctx, cacheKey, dbResult, 30*time.Minute, cacheTagUser(userId)); setErr != nil {
 		fmt.Printf("Warning: failed to cache user profile for user %d: %v\n", userId, setErr)
 	}
 
@@ -60,7 +61,7 @@ func (s *CachedUserService) GetUserByUsernameCached(ctx context.Context, userNam
 	dbResult := s.UserService.GetUserByUsername(userName)
 
 	// Cache the result for 15 minutes (shorter for auth tokens)
-	if setErr := s.cacheService.Set(ctx, cacheKey, dbResult, 15*time.Minute); setErr != nil {
+	if setErr := s.cacheService.SetWithTags(ctx, cacheKey, dbResult, 15*time.Minute, cacheTagUser(dbResult.UserId)); setErr != nil {
 		fmt.Printf("Warning: failed to cache user token for username %s: %v\n", userName, setErr)
 	}
 
@@ -82,7 +83,7 @@ func (s *CachedUserService) GetUserByEmailCached(ctx context.Context, email stri
 	dbResult := s.UserService.GetUserByEmail(email)
 
 	// Cache the result for 15 minutes
-	if setErr := s.cacheService.Set(ctx, cacheKey, dbResult, 15*time.Minute); setErr != nil {
+	if setErr := s.cacheService.SetWithTags(ctx, cacheKey, dbResult, 15*time.Minute, cacheTagUser(dbResult.UserId)); setErr != nil {
 		fmt.Printf("Warning: failed to cache user token for email %s: %v\n", email, setErr)
 	}
 
@@ -104,7 +105,7 @@ func (s *CachedUserService) GetUserByPhonenumberCached(ctx context.Context, phon
 	dbResult := s.UserService.GetUserByPhonenumber(phonenumber)
 
 	// Cache the result for 15 minutes
-	if setErr := s.cacheService.Set(ctx, cacheKey, dbResult, 15*time.Minute); setErr != nil {
+	if setErr := s.cacheService.SetWithTags(ctx, cacheKey, dbResult, 15*time.Minute, cacheTagUser(dbResult.UserId)); setErr != nil {
 		fmt.Printf("Warning: failed to cache user token for phone %s: %v\n", phonenumber, setErr)
 	}
 
@@ -133,13 +134,13 @@ func (s *CachedUserService) GetUserListCached(ctx context.Context, param dto.Use
 	dbResult, dbTotal := s.UserService.GetUserList(param, userId, isPaging)
 
 	// Cache the result for 5 minutes (shorter for dynamic lists)
-	if setErr := s.cacheService.Set(ctx, cacheKey, dbResult, 5*time.Minute); setErr != nil {
+	if setErr := s.cacheService.SetWithTags(ctx, cacheKey, dbResult, 5*time.Minute, cacheTagUserList); setErr != nil {
 		fmt.Printf("Warning: failed to cache user list: %v\n", setErr)
 	}
 
 	// Cache total count
 	totalKey := cacheKey + ":total"
-	if setErr := s.cacheService.Set(ctx, totalKey, dbTotal, 5*time.Minute); setErr != nil {
+	if setErr := s.cacheService.SetWithTags(ctx, totalKey, dbTotal, 5*time.Minute, cacheTagUserList); setErr != nil {
 		fmt.Printf("Warning: failed to cache user list total: %v\n", setErr)
 	}
 
@@ -162,7 +163,12 @@ func (s *CachedUserService) UserHasPermsCached(ctx context.Context, userId int,
 	hasPerms := s.UserService.UserHasPerms(userId, perms)
 
 	// Cache the user's full permission list for 30 minutes
-	if setErr := s.cacheService.Set(ctx, cacheKey, perms, 30*time.Minute); setErr != nil {
+	tags, err := s.roleCacheTags(userId)
+	if err != nil {
+		fmt.Printf("Warning: failed to cache user permissions for user %d: %v\n", userId, err)
+		return hasPerms
+	}
+	if setErr := s.cacheService.SetWithTags(ctx, cacheKey, perms, 30*time.Minute, append(tags, cacheTagMenu)...); setErr != nil {
 		fmt.Printf("Warning: failed to cache user permissions for user %d: %v\n", userId, setErr)
 	}
 
@@ -185,21 +191,28 @@ func (s *CachedUserService) UserHasRolesCached(ctx context.Context, userId int,
 	hasRoles := s.UserService.UserHasRoles(userId, roles)
 
 	// Cache the user's role list for 30 minutes
-	if setErr := s.cacheService.Set(ctx, cacheKey, roles, 30*time.Minute); setErr != nil {
+	tags, err := s.roleCacheTags(userId)
+	if err != nil {
+		fmt.Printf("Warning: failed to cache user roles for user %d: %v\n", userId, err)
+		return hasRoles
+	}
+	if setErr := s.cacheService.SetWithTags(ctx, cacheKey, roles, 30*time.Minute, tags...); setErr != nil {
 		fmt.Printf("Warning: failed to cache user roles for user %d: %v\n", userId, setErr)
 	}
 
 	return hasRoles
 }
 
-// InvalidateUserCache removes all cached data for a user
+// InvalidateUserCache removes all cached data for a user. The cache event drops the entries tagged
+// with the user.
 func (s *CachedUserService) InvalidateUserCache(ctx context.Context, userId int) error {
+	publishCacheIds(constant.CACHE_EVENT_USER, userId)
 	return s.cacheService.InvalidateUserCache(ctx, userId)
 }
 
 // InvalidateUserListCache removes cached user list data
 func (s *CachedUserService) InvalidateUserListCache(ctx context.Context) error {
-	return s.cacheService.InvalidatePattern(ctx, rediskey.UserPattern()+":userlist:*")
+	return s.cacheService.InvalidateTags(ctx, cacheTagUserList)
 }
 
 // CreateUser creates a user and invalidates relevant caches
@@ -225,8 +238,8 @@ func (s *CachedUserService) UpdateUserWithCache(ctx context.Context, param dto.S
 }
 
 // DeleteUser deletes users and invalidates relevant caches
-func (s *CachedUserService) DeleteUserWithCache(ctx context.Context, userIds []int) error {
-	err := s.UserService.DeleteUser(userIds)
+func (s *CachedUserService) DeleteUserWithCache(ctx context.Context, userIds []int, deleteBy string) error {
+	err := s.UserService.DeleteUser(userIds, deleteBy)
 	if err != nil {
 		return err
 	}
@@ -243,6 +256,19 @@ func (s *CachedUserService) DeleteUserWithCache(ctx context.Context, userIds []i
 }
 
 // Helper methods
+func (s *CachedUserService) roleCacheTags(userId int) ([]string, error) {
+	roles, err := (&RoleService{}).GetRoleListByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{cacheTagUser(userId)}
+	for _, role := range roles {
+		tags = append(tags, cacheTagRole(role.RoleId))
+	}
+	return tags, nil
+}
+
 func (s *CachedUserService) checkPermissions(cachedPerms, requiredPerms []string) bool {
 	permMap := make(map[string]bool)
 	for _, perm := range cachedPerms {
@@ -0,0 +1,451 @@
+package service
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// configHistorySnapshot is the state of a configuration parameter or dictionary entry kept in its
+// change history, by field
+type configHistorySnapshot map[string]string
+
+// configSnapshot returns the versioned fields of a configuration parameter
+func configSnapshot(config model.SysConfig) configHistorySnapshot {
+	return configHistorySnapshot{
+		"configName":  config.ConfigName,
+		"configKey":   config.ConfigKey,
+		"configValue": config.ConfigValue,
+		"configType":  config.ConfigType,
+		"remark":      config.Remark,
+	}
+}
+
+// dictDataSnapshot returns the versioned fields of a dictionary entry
+func dictDataSnapshot(dictData model.SysDictData) configHistorySnapshot {
+	return configHistorySnapshot{
+		"dictSort":  strconv.Itoa(dictData.DictSort),
+		"dictLabel": dictData.DictLabel,
+		"dictValue": dictData.DictValue,
+		"dictType":  dictData.DictType,
+		"cssClass":  dictData.CssClass,
+		"listClass": dictData.ListClass,
+		"isDefault": dictData.IsDefault,
+		"status":    dictData.Status,
+		"remark":    dictData.Remark,
+	}
+}
+
+// encode returns the JSON text of the snapshot, empty for no snapshot
+func (s configHistorySnapshot) encode() string {
+	if s == nil {
+		return ""
+	}
+	text, _ := json.Marshal(s)
+	return string(text)
+}
+
+// equal reports whether two snapshots hold the same fields and values
+func (s configHistorySnapshot) equal(other configHistorySnapshot) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for field, value := range s {
+		if otherValue, ok := other[field]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeConfigHistorySnapshot parses a snapshot of the history, the empty text being no snapshot
+func decodeConfigHistorySnapshot(text string) (configHistorySnapshot, error) {
+	if text == "" {
+		return nil, nil
+	}
+	var snapshot configHistorySnapshot
+	if err := json.Unmarshal([]byte(text), &snapshot); err != nil {
+		return nil, errors.Wrap(err, "failed to decode change history snapshot")
+	}
+	return snapshot, nil
+}
+
+// recordConfigHistory adds the next version of an item to its change history. Updates leaving
+// the item as it was are not recorded.
+func recordConfigHistory(tx *gorm.DB, entityType string, entityId int, entityName, action string, before, after configHistorySnapshot, reason, operName string, sourceVersion int) error {
+	if action == constant.CONFIG_HISTORY_ACTION_UPDATE && before.equal(after) {
+		return nil
+	}
+
+	var version int
+	if err := tx.Model(model.SysConfigHistory{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityId).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return errors.Wrap(err, "failed to read change history version")
+	}
+
+	err := tx.Create(&model.SysConfigHistory{
+		EntityType:    entityType,
+		EntityId:      entityId,
+		EntityName:    entityName,
+		Version:       version + 1,
+		Action:        action,
+		OldValue:      before.encode(),
+		NewValue:      after.encode(),
+		SourceVersion: sourceVersion,
+		Reason:        reason,
+		CreateBy:      operName,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to record change history")
+	}
+
+	return nil
+}
+
+// dictDataHistoryName names a dictionary entry in its change history, as the recycle bin does
+func dictDataHistoryName(dictData model.SysDictData) string {
+	return dictData.DictType + ":" + dictData.DictLabel
+}
+
+// ConfigHistoryServiceInterface defines operations for the change history of configuration
+// parameters and dictionary entries
+type ConfigHistoryServiceInterface interface {
+	GetConfigHistoryList(entityType string, param dto.ConfigHistoryListRequest) ([]dto.ConfigHistoryListResponse, int, error)
+	GetConfigHistoryDiff(entityType string, fromHistoryId, toHistoryId int) (dto.ConfigHistoryDiffResponse, error)
+	RollbackConfigHistory(entityType string, historyId int, reason, operName string) error
+	ExportDataset(entityType string, param dto.ConfigHistoryListRequest) ExportDataset
+}
+
+// ConfigHistoryService implements the change history of configuration parameters and dictionary entries
+//
+// Every creation, update and deletion of a parameter or entry adds a version to its history with
+// the operator, the reason and the values before and after. Any two versions of an item can be
+// compared, and an item can be rolled back to the values of an earlier version, which adds a
+// rollback version of its own.
+type ConfigHistoryService struct {
+	configService   *ConfigService
+	dictTypeService *DictTypeService
+}
+
+// Ensure ConfigHistoryService implements ConfigHistoryServiceInterface
+var _ ConfigHistoryServiceInterface = (*ConfigHistoryService)(nil)
+
+// NewConfigHistoryService creates a new ConfigHistoryService
+func NewConfigHistoryService(configService *ConfigService, dictTypeService *DictTypeService) *ConfigHistoryService {
+	return &ConfigHistoryService{configService: configService, dictTypeService: dictTypeService}
+}
+
+// GetConfigHistoryList retrieves the change history of configuration parameters or dictionary
+// entries, newest first
+func (s *ConfigHistoryService) GetConfigHistoryList(entityType string, param dto.ConfigHistoryListRequest) ([]dto.ConfigHistoryListResponse, int, error) {
+	if !isConfigHistoryEntity(entityType) {
+		return nil, 0, xerrors.ErrConfigHistoryEntity
+	}
+
+	histories := make([]dto.ConfigHistoryListResponse, 0)
+
+	query := configHistoryListQuery(entityType, param).Order("history_id DESC")
+
+	count, err := countPage(query, param.Count)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to count change history")
+	}
+
+	if err = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize).Find(&histories).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve change history")
+	}
+
+	return histories, count, nil
+}
+
+// configHistoryListQuery builds the change history query with the search conditions applied
+func configHistoryListQuery(entityType string, param dto.ConfigHistoryListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysConfigHistory{}).Where("entity_type = ?", entityType)
+
+	if param.EntityId != 0 {
+		query = query.Where("entity_id = ?", param.EntityId)
+	}
+
+	if param.EntityName != "" {
+		query = query.Where("entity_name LIKE ?", "%"+param.EntityName+"%")
+	}
+
+	if param.Action != "" {
+		query = query.Where("action = ?", param.Action)
+	}
+
+	if param.CreateBy != "" {
+		query = query.Where("create_by LIKE ?", "%"+param.CreateBy+"%")
+	}
+
+	if param.BeginTime != "" && param.EndTime != "" {
+		query = query.Where("create_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
+	}
+
+	return query
+}
+
+// GetConfigHistoryDiff compares the values two versions of the same item left it with. The
+// values of a deletion are empty.
+func (s *ConfigHistoryService) GetConfigHistoryDiff(entityType string, fromHistoryId, toHistoryId int) (dto.ConfigHistoryDiffResponse, error) {
+	var diff dto.ConfigHistoryDiffResponse
+
+	from, err := getConfigHistory(entityType, fromHistoryId)
+	if err != nil {
+		return diff, err
+	}
+	to, err := getConfigHistory(entityType, toHistoryId)
+	if err != nil {
+		return diff, err
+	}
+	if from.EntityId != to.EntityId {
+		return diff, xerrors.ErrConfigHistoryDiffEntity
+	}
+
+	fromValues, err := decodeConfigHistorySnapshot(from.NewValue)
+	if err != nil {
+		return diff, err
+	}
+	toValues, err := decodeConfigHistorySnapshot(to.NewValue)
+	if err != nil {
+		return diff, err
+	}
+
+	fields := make([]string, 0, len(fromValues)+len(toValues))
+	for field := range fromValues {
+		fields = append(fields, field)
+	}
+	for field := range toValues {
+		if _, ok := fromValues[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diff = dto.ConfigHistoryDiffResponse{
+		EntityType:  entityType,
+		EntityId:    from.EntityId,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Changes:     make([]dto.ConfigHistoryChange, 0),
+	}
+	for _, field := range fields {
+		if fromValues[field] != toValues[field] {
+			diff.Changes = append(diff.Changes, dto.ConfigHistoryChange{Field: field, From: fromValues[field], To: toValues[field]})
+		}
+	}
+
+	return diff, nil
+}
+
+// RollbackConfigHistory restores an item to the values a version left it with and refreshes its
+// cache. The item must still exist, and a deletion cannot be rolled back to.
+func (s *ConfigHistoryService) RollbackConfigHistory(entityType string, historyId int, reason, operName string) error {
+	history, err := getConfigHistory(entityType, historyId)
+	if err != nil {
+		return err
+	}
+	if history.Action == constant.CONFIG_HISTORY_ACTION_DELETE {
+		return xerrors.ErrConfigHistoryDeleted
+	}
+
+	target, err := decodeConfigHistorySnapshot(history.NewValue)
+	if err != nil {
+		return err
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if entityType == constant.CONFIG_HISTORY_ENTITY_CONFIG {
+		err = rollbackConfig(tx, history, target, reason, operName)
+	} else {
+		err = rollbackDictData(tx, history, target, reason, operName)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	if entityType == constant.CONFIG_HISTORY_ENTITY_CONFIG {
+		if err = s.configService.RefreshCache(); err != nil {
+			log.Printf("Warning: Failed to refresh config cache after rollback: %v", err)
+		}
+		notifySearch(constant.SEARCH_TYPE_CONFIG, history.EntityId)
+	} else if err = s.dictTypeService.RefreshCache(); err != nil {
+		log.Printf("Warning: Failed to refresh dictionary cache after rollback: %v", err)
+	}
+
+	return nil
+}
+
+// rollbackConfig restores a configuration parameter to the values of a version
+func rollbackConfig(tx *gorm.DB, history model.SysConfigHistory, target configHistorySnapshot, reason, operName string) error {
+	var config model.SysConfig
+	if err := tx.Where("config_id = ?", history.EntityId).Limit(1).Find(&config).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve config")
+	}
+	if config.ConfigId <= 0 {
+		return xerrors.ErrConfigHistoryItemNotFound
+	}
+
+	before := configSnapshot(config)
+	if before.equal(target) {
+		return xerrors.ErrConfigHistoryUnchanged
+	}
+
+	// The value is checked against the declarations of today
+	if err := checkConfigValue(target["configKey"], target["configValue"]); err != nil {
+		return err
+	}
+
+	var conflicts int64
+	if err := tx.Model(model.SysConfig{}).Where("config_key = ? AND config_id <> ?", target["configKey"], config.ConfigId).Count(&conflicts).Error; err != nil {
+		return errors.Wrap(err, "failed to check config key")
+	}
+	if conflicts > 0 {
+		return xerrors.ErrConfigHistoryConflict
+	}
+
+	err := tx.Model(model.SysConfig{}).Where("config_id = ?", config.ConfigId).Updates(map[string]interface{}{
+		"config_name":  target["configName"],
+		"config_key":   target["configKey"],
+		"config_value": target["configValue"],
+		"config_type":  target["configType"],
+		"remark":       target["remark"],
+		"update_by":    operName,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to roll back config")
+	}
+
+	if err = tx.Where("config_id = ?", config.ConfigId).Take(&config).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve config")
+	}
+
+	return recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_CONFIG, config.ConfigId, config.ConfigKey, constant.CONFIG_HISTORY_ACTION_ROLLBACK, before, configSnapshot(config), reason, operName, history.Version)
+}
+
+// rollbackDictData restores a dictionary entry to the values of a version
+func rollbackDictData(tx *gorm.DB, history model.SysConfigHistory, target configHistorySnapshot, reason, operName string) error {
+	var dictData model.SysDictData
+	if err := tx.Where("dict_code = ?", history.EntityId).Limit(1).Find(&dictData).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve dictionary data")
+	}
+	if dictData.DictCode <= 0 {
+		return xerrors.ErrConfigHistoryItemNotFound
+	}
+
+	before := dictDataSnapshot(dictData)
+	if before.equal(target) {
+		return xerrors.ErrConfigHistoryUnchanged
+	}
+
+	var conflicts int64
+	if err := tx.Model(model.SysDictData{}).
+		Where("dict_type = ? AND dict_value = ? AND dict_code <> ?", target["dictType"], target["dictValue"], dictData.DictCode).
+		Count(&conflicts).Error; err != nil {
+		return errors.Wrap(err, "failed to check dictionary value")
+	}
+	if conflicts > 0 {
+		return xerrors.ErrConfigHistoryConflict
+	}
+
+	dictSort, _ := strconv.Atoi(target["dictSort"])
+	err := tx.Model(model.SysDictData{}).Where("dict_code = ?", dictData.DictCode).Updates(map[string]interface{}{
+		"dict_sort":  dictSort,
+		"dict_label": target["dictLabel"],
+		"dict_value": target["dictValue"],
+		"dict_type":  target["dictType"],
+		"css_class":  target["cssClass"],
+		"list_class": target["listClass"],
+		"is_default": target["isDefault"],
+		"status":     target["status"],
+		"remark":     target["remark"],
+		"update_by":  operName,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to roll back dictionary data")
+	}
+
+	if err = tx.Where("dict_code = ?", dictData.DictCode).Take(&dictData).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve dictionary data")
+	}
+
+	return recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_DICT_DATA, dictData.DictCode, dictDataHistoryName(dictData), constant.CONFIG_HISTORY_ACTION_ROLLBACK, before, dictDataSnapshot(dictData), reason, operName, history.Version)
+}
+
+// ExportDataset describes the export of the change history matching the search conditions, newest first
+func (s *ConfigHistoryService) ExportDataset(entityType string, param dto.ConfigHistoryListRequest) ExportDataset {
+	name := constant.EXPORT_DATASET_CONFIG_HISTORY
+	if entityType == constant.CONFIG_HISTORY_ENTITY_DICT_DATA {
+		name = constant.EXPORT_DATASET_DICT_DATA_HISTORY
+	}
+
+	return ExportDataset{
+		Name: name,
+		Query: func() *gorm.DB {
+			return configHistoryListQuery(entityType, param)
+		},
+		KeyField:  "history_id",
+		KeyColumn: "history_id",
+		Desc:      true,
+		Columns: []ExportColumn{
+			{Key: "historyId", Field: "history_id", Header: "History ID", HeaderZh: "历史序号"},
+			{Key: "entityId", Field: "entity_id", Header: "Item ID", HeaderZh: "数据ID"},
+			{Key: "entityName", Field: "entity_name", Header: "Item Name", HeaderZh: "数据名称"},
+			{Key: "version", Field: "version", Header: "Version", HeaderZh: "版本"},
+			{Key: "action", Field: "action", Header: "Action", HeaderZh: "操作", Labels: map[string]string{
+				constant.CONFIG_HISTORY_ACTION_CREATE:   "Create",
+				constant.CONFIG_HISTORY_ACTION_UPDATE:   "Update",
+				constant.CONFIG_HISTORY_ACTION_DELETE:   "Delete",
+				constant.CONFIG_HISTORY_ACTION_ROLLBACK: "Rollback",
+			}},
+			{Key: "oldValue", Field: "old_value", Header: "Old Value", HeaderZh: "变更前"},
+			{Key: "newValue", Field: "new_value", Header: "New Value", HeaderZh: "变更后"},
+			{Key: "sourceVersion", Field: "source_version", Header: "Rolled Back To", HeaderZh: "回滚版本"},
+			{Key: "reason", Field: "reason", Header: "Reason", HeaderZh: "变更原因"},
+			{Key: "createBy", Field: "create_by", Header: "Operator", HeaderZh: "操作人员"},
+			{Key: "createTime", Field: "create_time", Header: "Change Time", HeaderZh: "变更时间"},
+		},
+	}
+}
+
+// getConfigHistory retrieves a version of the change history of a type of item
+func getConfigHistory(entityType string, historyId int) (model.SysConfigHistory, error) {
+	var history model.SysConfigHistory
+
+	if !isConfigHistoryEntity(entityType) {
+		return history, xerrors.ErrConfigHistoryEntity
+	}
+
+	if err := dal.Gorm.Model(model.SysConfigHistory{}).
+		Where("history_id = ? AND entity_type = ?", historyId, entityType).
+		Limit(1).Find(&history).Error; err != nil {
+		return history, errors.Wrap(err, "failed to retrieve change history")
+	}
+	if history.HistoryId <= 0 {
+		return history, xerrors.ErrConfigHistoryNotFound
+	}
+
+	return history, nil
+}
+
+// isConfigHistoryEntity reports whether a type of item keeps a change history
+func isConfigHistoryEntity(entityType string) bool {
+	return entityType == constant.CONFIG_HISTORY_ENTITY_CONFIG || entityType == constant.CONFIG_HISTORY_ENTITY_DICT_DATA
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// configHistoryVersions returns the change history of an item, oldest first
+func configHistoryVersions(entityType string, entityId int) []model.SysConfigHistory {
+	histories := make([]model.SysConfigHistory, 0)
+	dal.Gorm.Where("entity_type = ? AND entity_id = ?", entityType, entityId).Order("version").Find(&histories)
+	return histories
+}
+
+func TestConfigHistoryService_Config(t *testing.T) {
+	setup()
+	defer teardown()
+	configService := &ConfigService{}
+	s := NewConfigHistoryService(configService, &DictTypeService{})
+
+	redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
+	assert.NoError(t, configService.CreateConfig(dto.SaveConfig{ConfigName: "Timeout", ConfigKey: "app.timeout", ConfigValue: "30", ConfigType: "N", CreateBy: "alice"}))
+	redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
+	assert.NoError(t, configService.UpdateConfig(dto.SaveConfig{ConfigId: 1, ConfigValue: "60", UpdateBy: "bob", Reason: "slow network"}))
+	// An update changing nothing adds no version
+	redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
+	assert.NoError(t, configService.UpdateConfig(dto.SaveConfig{ConfigId: 1, ConfigValue: "60", UpdateBy: "bob"}))
+	assert.NoError(t, redisMock.ExpectationsWereMet())
+
+	t.Run("should record who changed what and why", func(t *testing.T) {
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_CONFIG, 1)
+		if assert.Len(t, histories, 2) {
+			assert.Equal(t, constant.CONFIG_HISTORY_ACTION_CREATE, histories[0].Action)
+			assert.Equal(t, "alice", histories[0].CreateBy)
+			assert.Empty(t, histories[0].OldValue)
+
+			assert.Equal(t, 2, histories[1].Version)
+			assert.Equal(t, constant.CONFIG_HISTORY_ACTION_UPDATE, histories[1].Action)
+			assert.Equal(t, "app.timeout", histories[1].EntityName)
+			assert.Equal(t, "bob", histories[1].CreateBy)
+			assert.Equal(t, "slow network", histories[1].Reason)
+			assert.Equal(t, histories[0].NewValue, histories[1].OldValue)
+		}
+	})
+
+	t.Run("should list the history newest first", func(t *testing.T) {
+		list, total, err := s.GetConfigHistoryList(constant.CONFIG_HISTORY_ENTITY_CONFIG, dto.ConfigHistoryListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, total)
+		assert.Equal(t, constant.CONFIG_HISTORY_ACTION_UPDATE, list[0].Action)
+
+		list, total, err = s.GetConfigHistoryList(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, dto.ConfigHistoryListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, total)
+		assert.Empty(t, list)
+
+		_, _, err = s.GetConfigHistoryList("user", dto.ConfigHistoryListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}})
+		assert.ErrorIs(t, err, xerrors.ErrConfigHistoryEntity)
+	})
+
+	t.Run("should diff two versions", func(t *testing.T) {
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_CONFIG, 1)
+
+		diff, err := s.GetConfigHistoryDiff(constant.CONFIG_HISTORY_ENTITY_CONFIG, histories[0].HistoryId, histories[1].HistoryId)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, diff.FromVersion)
+		assert.Equal(t, 2, diff.ToVersion)
+		assert.Equal(t, []dto.ConfigHistoryChange{{Field: "configValue", From: "30", To: "60"}}, diff.Changes)
+
+		_, err = s.GetConfigHistoryDiff(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, histories[0].HistoryId, histories[1].HistoryId)
+		assert.ErrorIs(t, err, xerrors.ErrConfigHistoryNotFound)
+	})
+
+	t.Run("should roll back and refresh the cache", func(t *testing.T) {
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_CONFIG, 1)
+
+		redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
+		assert.NoError(t, s.RollbackConfigHistory(constant.CONFIG_HISTORY_ENTITY_CONFIG, histories[0].HistoryId, "revert", "carol"))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+		assert.Equal(t, "30", configService.GetConfigByConfigId(1).ConfigValue)
+
+		histories = configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_CONFIG, 1)
+		if assert.Len(t, histories, 3) {
+			assert.Equal(t, constant.CONFIG_HISTORY_ACTION_ROLLBACK, histories[2].Action)
+			assert.Equal(t, 1, histories[2].SourceVersion)
+			assert.Equal(t, "carol", histories[2].CreateBy)
+			assert.Equal(t, "revert", histories[2].Reason)
+		}
+
+		err := s.RollbackConfigHistory(constant.CONFIG_HISTORY_ENTITY_CONFIG, histories[0].HistoryId, "again", "carol")
+		assert.ErrorIs(t, err, xerrors.ErrConfigHistoryUnchanged)
+	})
+
+	t.Run("should not roll back to a deletion or a deleted item", func(t *testing.T) {
+		redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
+		assert.NoError(t, configService.DeleteConfig([]int{1}, "dave"))
+
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_CONFIG, 1)
+		deletion := histories[len(histories)-1]
+		assert.Equal(t, constant.CONFIG_HISTORY_ACTION_DELETE, deletion.Action)
+		assert.Empty(t, deletion.NewValue)
+
+		assert.ErrorIs(t, s.RollbackConfigHistory(constant.CONFIG_HISTORY_ENTITY_CONFIG, deletion.HistoryId, "undo", "carol"), xerrors.ErrConfigHistoryDeleted)
+		assert.ErrorIs(t, s.RollbackConfigHistory(constant.CONFIG_HISTORY_ENTITY_CONFIG, histories[1].HistoryId, "undo", "carol"), xerrors.ErrConfigHistoryItemNotFound)
+	})
+
+	t.Run("should check rolled back values against the schema", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysConfig{ConfigId: 2, ConfigName: "Register", ConfigKey: ACCOUNT_REGISTER_USER_CONFIG_KEY, ConfigValue: "yes"})
+		dal.Gorm.Create(&model.SysConfigHistory{
+			EntityType: constant.CONFIG_HISTORY_ENTITY_CONFIG, EntityId: 2, EntityName: ACCOUNT_REGISTER_USER_CONFIG_KEY, Version: 1,
+			Action:   constant.CONFIG_HISTORY_ACTION_CREATE,
+			NewValue: `{"configKey":"` + ACCOUNT_REGISTER_USER_CONFIG_KEY + `","configName":"Register","configType":"N","configValue":"maybe","remark":""}`,
+		})
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_CONFIG, 2)
+
+		err := s.RollbackConfigHistory(constant.CONFIG_HISTORY_ENTITY_CONFIG, histories[0].HistoryId, "undo", "carol")
+		assert.ErrorIs(t, err, xerrors.ErrConfigValueInvalid)
+	})
+}
+
+func TestConfigHistoryService_DictData(t *testing.T) {
+	setup()
+	defer teardown()
+	dictDataService := &DictDataService{}
+	s := NewConfigHistoryService(&ConfigService{}, &DictTypeService{})
+
+	assert.NoError(t, dictDataService.CreateDictData(dto.SaveDictData{DictType: "sys_color", DictLabel: "Red", DictValue: "r", Status: "0", CreateBy: "alice"}))
+	assert.NoError(t, dictDataService.CreateDictData(dto.SaveDictData{DictType: "sys_color", DictLabel: "Green", DictValue: "g", Status: "0", CreateBy: "alice"}))
+	assert.NoError(t, dictDataService.UpdateDictData(dto.SaveDictData{DictCode: 1, DictType: "sys_color", DictLabel: "Crimson", DictValue: "c", UpdateBy: "bob", Reason: "rename"}))
+
+	t.Run("should version each entry by code", func(t *testing.T) {
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, 1)
+		if assert.Len(t, histories, 2) {
+			assert.Equal(t, "sys_color:Crimson", histories[1].EntityName)
+			assert.Equal(t, "rename", histories[1].Reason)
+		}
+
+		diff, err := s.GetConfigHistoryDiff(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, histories[0].HistoryId, histories[1].HistoryId)
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.ConfigHistoryChange{
+			{Field: "dictLabel", From: "Red", To: "Crimson"},
+			{Field: "dictValue", From: "r", To: "c"},
+		}, diff.Changes)
+
+		others := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, 2)
+		_, err = s.GetConfigHistoryDiff(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, histories[0].HistoryId, others[0].HistoryId)
+		assert.ErrorIs(t, err, xerrors.ErrConfigHistoryDiffEntity)
+	})
+
+	t.Run("should roll back and refresh the dictionary cache", func(t *testing.T) {
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, 1)
+
+		redisMock.ExpectDel(rediskey.SysDictKey()).SetVal(1)
+		assert.NoError(t, s.RollbackConfigHistory(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, histories[0].HistoryId, "revert", "carol"))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+
+		dictData := dictDataService.GetDictDataByDictCode(1)
+		assert.Equal(t, "Red", dictData.DictLabel)
+		assert.Equal(t, "r", dictData.DictValue)
+	})
+
+	t.Run("should reject a rollback to a value another entry took", func(t *testing.T) {
+		assert.NoError(t, dictDataService.UpdateDictData(dto.SaveDictData{DictCode: 2, DictType: "sys_color", DictLabel: "Green", DictValue: "c", UpdateBy: "bob"}))
+
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, 1)
+		err := s.RollbackConfigHistory(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, histories[1].HistoryId, "again", "carol")
+		assert.ErrorIs(t, err, xerrors.ErrConfigHistoryConflict)
+	})
+
+	t.Run("should record deletions", func(t *testing.T) {
+		assert.NoError(t, dictDataService.DeleteDictData([]int{2}, "dave"))
+
+		histories := configHistoryVersions(constant.CONFIG_HISTORY_ENTITY_DICT_DATA, 2)
+		deletion := histories[len(histories)-1]
+		assert.Equal(t, constant.CONFIG_HISTORY_ACTION_DELETE, deletion.Action)
+		assert.Equal(t, "dave", deletion.CreateBy)
+	})
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mira/app/dto"
+	"mira/common/jsonschema"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+)
+
+// Keys of the built-in configuration parameters not owned by another service
+const (
+	INDEX_SKIN_NAME_CONFIG_KEY         = "sys.index.skinName"
+	INDEX_SIDE_THEME_CONFIG_KEY        = "sys.index.sideTheme"
+	USER_INIT_PASSWORD_CONFIG_KEY      = "sys.user.initPassword"
+	ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY = "sys.account.captchaEnabled"
+	ACCOUNT_REGISTER_USER_CONFIG_KEY   = "sys.account.registerUser"
+)
+
+// ConfigSchema declares the type, default and validation of a configuration parameter
+type ConfigSchema struct {
+	Key string
+	// Name and Description are the name and remark of the parameter when it is created at startup
+	Name        string
+	Description string
+	// Type is one of the constant.CONFIG_VALUE_TYPE_* types
+	Type string
+	// Default is the value of a missing or invalid parameter, and of a parameter created at startup
+	Default string
+	// Options are the values of an enum parameter
+	Options []string
+	// Min and Max bound int parameters, and duration parameters in nanoseconds, when Max is
+	// greater than Min
+	Min int64
+	Max int64
+	// JsonSchema checks the values of a json parameter when set
+	JsonSchema string
+	// Validate checks a value further once it suits the type
+	Validate func(value string) error
+
+	compiled *jsonschema.Schema
+}
+
+var (
+	configSchemasMu sync.RWMutex
+	configSchemas   = make(map[string]*ConfigSchema)
+)
+
+// builtinConfigSchemas are the configuration parameters the application reads
+var builtinConfigSchemas = []ConfigSchema{
+	{
+		Key: INDEX_SKIN_NAME_CONFIG_KEY, Name: "主框架页-默认皮肤样式名称", Type: constant.CONFIG_VALUE_TYPE_ENUM, Default: "skin-blue",
+		Options:     []string{"skin-blue", "skin-green", "skin-purple", "skin-red", "skin-yellow"},
+		Description: "蓝色 skin-blue、绿色 skin-green、紫色 skin-purple、红色 skin-red、黄色 skin-yellow",
+	},
+	{
+		Key: USER_INIT_PASSWORD_CONFIG_KEY, Name: "用户管理-账号初始密码", Type: constant.CONFIG_VALUE_TYPE_STRING, Default: "123456",
+		Description: "初始化密码 123456",
+		Validate: func(value string) error {
+			if length := len([]rune(value)); length < 5 || length > 20 {
+				return xerrors.ErrPasswordLength
+			}
+			return nil
+		},
+	},
+	{
+		Key: INDEX_SIDE_THEME_CONFIG_KEY, Name: "主框架页-侧边栏主题", Type: constant.CONFIG_VALUE_TYPE_ENUM, Default: "theme-dark",
+		Options:     []string{"theme-dark", "theme-light"},
+		Description: "深色主题theme-dark，浅色主题theme-light",
+	},
+	{
+		Key: ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY, Name: "账号自助-验证码开关", Type: constant.CONFIG_VALUE_TYPE_BOOL, Default: "true",
+		Description: "是否开启验证码功能（true开启，false关闭）",
+	},
+	{
+		Key: ACCOUNT_REGISTER_USER_CONFIG_KEY, Name: "账号自助-是否开启用户注册功能", Type: constant.CONFIG_VALUE_TYPE_BOOL, Default: "false",
+		Description: "是否开启注册用户功能（true开启，false关闭）",
+	},
+	{
+		Key: APPROVAL_ACTIONS_CONFIG_KEY, Name: "变更审批-需审批的操作", Type: constant.CONFIG_VALUE_TYPE_STRING,
+		Default:     "system:user:remove,system:user:resetPwd,system:user:authRole,system:user:import,system:role:dataScope,system:role:menu,system:role:authUser,system:role:import,system:menu:edit,system:dept:import,system:post:role,system:post:import,system:group:role,system:group:member,system:onboardTemplate:edit,system:policy:edit,system:delegation:edit,system:recycle:restore",
+		Description: "需双人审批的操作标识，多个用逗号分隔，支持 system:user:* 通配",
+	},
+	{
+		Key: APPROVAL_EXPIRE_HOURS_CONFIG_KEY, Name: "变更审批-待审批有效时长", Type: constant.CONFIG_VALUE_TYPE_INT,
+		Default: strconv.Itoa(APPROVAL_DEFAULT_EXPIRE_HOURS), Min: 1, Max: 24 * 365,
+		Description: "待审批变更请求的有效时长（小时），过期自动失效",
+	},
+	{
+		Key: RECYCLE_RETENTION_DAYS_CONFIG_KEY, Name: "回收站-保留天数", Type: constant.CONFIG_VALUE_TYPE_INT,
+		Default: strconv.Itoa(RECYCLE_DEFAULT_RETENTION_DAYS), Min: 1, Max: 3650,
+		Description: "已删除数据在回收站的保留天数，到期后定时永久删除",
+	},
+}
+
+func init() {
+	for _, schema := range builtinConfigSchemas {
+		if err := RegisterConfigSchema(schema); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterConfigSchema declares the type of a configuration parameter, replacing any earlier
+// declaration of its key. The default value must suit the declaration.
+func RegisterConfigSchema(schema ConfigSchema) error {
+	if schema.Key == "" {
+		return xerrors.ErrConfigKeyEmpty
+	}
+
+	switch schema.Type {
+	case constant.CONFIG_VALUE_TYPE_STRING, constant.CONFIG_VALUE_TYPE_BOOL, constant.CONFIG_VALUE_TYPE_INT, constant.CONFIG_VALUE_TYPE_DURATION:
+	case constant.CONFIG_VALUE_TYPE_ENUM:
+		if len(schema.Options) == 0 {
+			return errors.Errorf("enum parameter %s has no options", schema.Key)
+		}
+	case constant.CONFIG_VALUE_TYPE_JSON:
+		if schema.JsonSchema != "" {
+			compiled, err := jsonschema.Compile(schema.JsonSchema)
+			if err != nil {
+				return errors.Wrapf(err, "parameter %s", schema.Key)
+			}
+			schema.compiled = compiled
+		}
+	default:
+		return errors.Errorf("parameter %s has unknown type %s", schema.Key, schema.Type)
+	}
+
+	if err := schema.check(schema.Default); err != nil {
+		return errors.Wrapf(err, "default value of parameter %s", schema.Key)
+	}
+
+	configSchemasMu.Lock()
+	defer configSchemasMu.Unlock()
+	configSchemas[schema.Key] = &schema
+
+	return nil
+}
+
+// getConfigSchema returns the declaration of a configuration parameter, if any
+func getConfigSchema(configKey string) (*ConfigSchema, bool) {
+	configSchemasMu.RLock()
+	defer configSchemasMu.RUnlock()
+
+	schema, ok := configSchemas[configKey]
+	return schema, ok
+}
+
+// sortedConfigSchemas returns the declarations of the configuration parameters ordered by key
+func sortedConfigSchemas() []*ConfigSchema {
+	configSchemasMu.RLock()
+	defer configSchemasMu.RUnlock()
+
+	schemas := make([]*ConfigSchema, 0, len(configSchemas))
+	for _, schema := range configSchemas {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Key < schemas[j].Key
+	})
+	return schemas
+}
+
+// checkConfigValue checks a value against the declaration of its parameter. Parameters without
+// a declaration take any value.
+func checkConfigValue(configKey, value string) error {
+	schema, ok := getConfigSchema(configKey)
+	if !ok {
+		return nil
+	}
+
+	if err := schema.check(value); err != nil {
+		return errors.Wrapf(xerrors.ErrConfigValueInvalid, "%s %s", configKey, err.Error())
+	}
+	return nil
+}
+
+// check checks a value against the declaration
+func (s *ConfigSchema) check(value string) error {
+	switch s.Type {
+	case constant.CONFIG_VALUE_TYPE_BOOL:
+		if _, err := parseConfigBool(value); err != nil {
+			return err
+		}
+
+	case constant.CONFIG_VALUE_TYPE_INT:
+		number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		if s.Max > s.Min && (number < s.Min || number > s.Max) {
+			return errors.Errorf("must be between %d and %d", s.Min, s.Max)
+		}
+
+	case constant.CONFIG_VALUE_TYPE_DURATION:
+		duration, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return errors.New("must be a duration such as 30s, 15m or 1h30m")
+		}
+		if s.Max > s.Min && (int64(duration) < s.Min || int64(duration) > s.Max) {
+			return errors.Errorf("must be between %s and %s", time.Duration(s.Min), time.Duration(s.Max))
+		}
+
+	case constant.CONFIG_VALUE_TYPE_ENUM:
+		found := false
+		for _, option := range s.Options {
+			if option == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("must be one of %s", strings.Join(s.Options, ", "))
+		}
+
+	case constant.CONFIG_VALUE_TYPE_JSON:
+		if s.compiled != nil {
+			if err := s.compiled.ValidateJSON(value); err != nil {
+				return errors.Wrap(err, "does not match its JSON schema")
+			}
+		} else if !json.Valid([]byte(value)) {
+			return errors.New("must be valid JSON")
+		}
+	}
+
+	if s.Validate != nil {
+		return s.Validate(value)
+	}
+	return nil
+}
+
+// parseConfigBool parses a bool parameter, which is stored as true or false
+func parseConfigBool(value string) (bool, error) {
+	switch strings.TrimSpace(value) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		return false, errors.New("must be true or false")
+	}
+}
+
+// configSchemaResponse describes a declaration to the administration interface
+func configSchemaResponse(schema *ConfigSchema) dto.ConfigSchemaResponse {
+	response := dto.ConfigSchemaResponse{
+		ConfigKey:    schema.Key,
+		ConfigName:   schema.Name,
+		ValueType:    schema.Type,
+		DefaultValue: schema.Default,
+		Description:  schema.Description,
+		Options:      schema.Options,
+	}
+	if schema.Max > schema.Min {
+		response.Min, response.Max = fmt.Sprint(schema.Min), fmt.Sprint(schema.Max)
+		if schema.Type == constant.CONFIG_VALUE_TYPE_DURATION {
+			response.Min, response.Max = time.Duration(schema.Min).String(), time.Duration(schema.Max).String()
+		}
+	}
+	if schema.JsonSchema != "" {
+		response.JsonSchema = json.RawMessage(schema.JsonSchema)
+	}
+	return response
+}
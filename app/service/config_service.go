@@ -5,24 +5,37 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/types/constant"
 	rediskey "mira/common/types/redis-key"
 	"mira/common/xerrors"
+
+	"gorm.io/gorm"
 )
 
 // ConfigServiceInterface defines the interface for configuration service, facilitating testing and dependency injection
 type ConfigServiceInterface interface {
 	CreateConfig(param dto.SaveConfig) error
 	UpdateConfig(param dto.SaveConfig) error
-	DeleteConfig(configIds []int) error
+	DeleteConfig(configIds []int, deleteBy string) error
 	GetConfigList(param dto.ConfigListRequest, isPaging bool) ([]dto.ConfigListResponse, int)
+	ExportDataset(param dto.ConfigListRequest) ExportDataset
 	GetConfigByConfigId(configId int) dto.ConfigDetailResponse
 	GetConfigByConfigKey(configKey string) dto.ConfigDetailResponse
 	GetConfigCacheByConfigKey(configKey string) dto.ConfigDetailResponse
+	GetString(configKey string) string
+	GetBool(configKey string) bool
+	GetInt(configKey string) int
+	GetDuration(configKey string) time.Duration
+	GetJson(configKey string, target interface{}) error
+	GetConfigSchemas() []dto.ConfigSchemaResponse
+	InitConfigs() error
 	RefreshCache() error
 }
 
@@ -49,25 +62,48 @@ func (s *ConfigService) CreateConfig(param dto.SaveConfig) error {
 	if param.ConfigValue == "" {
 		return xerrors.ErrConfigValueEmpty
 	}
+	if err := checkConfigValue(param.ConfigKey, param.ConfigValue); err != nil {
+		return err
+	}
 
-	err := dal.Gorm.Model(model.SysConfig{}).Create(&model.SysConfig{
+	config := model.SysConfig{
 		ConfigName:  param.ConfigName,
 		ConfigKey:   param.ConfigKey,
 		ConfigValue: param.ConfigValue,
 		ConfigType:  param.ConfigType,
 		CreateBy:    param.CreateBy,
 		Remark:      param.Remark,
-	}).Error
-	if err != nil {
+	}
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Model(model.SysConfig{}).Create(&config).Error; err != nil {
+		tx.Rollback()
 		log.Printf("Failed to create config: %v", err)
 		return fmt.Errorf("failed to create config: %w", err)
 	}
 
+	// Read back the defaults the database filled in
+	if err := tx.Where("config_id = ?", config.ConfigId).Take(&config).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to retrieve config: %w", err)
+	}
+
+	if err := recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_CONFIG, config.ConfigId, config.ConfigKey, constant.CONFIG_HISTORY_ACTION_CREATE, nil, configSnapshot(config), param.Reason, param.CreateBy, 0); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Refresh cache after creating new configuration
-	if err := s.RefreshCache(); err != nil {
+	if err := s.refreshCache(config.ConfigKey); err != nil {
 		log.Printf("Warning: Failed to refresh config cache after creation: %v", err)
 	}
 
+	notifySearch(constant.SEARCH_TYPE_CONFIG, config.ConfigId)
+
 	return nil
 }
 
@@ -83,7 +119,26 @@ func (s *ConfigService) UpdateConfig(param dto.SaveConfig) error {
 		return xerrors.ErrParam
 	}
 
-	err := dal.Gorm.Model(model.SysConfig{}).Where("config_id = ?", param.ConfigId).Updates(&model.SysConfig{
+	// The value is checked against the parameter it will belong to, which keeps its key when none is given
+	configKey := param.ConfigKey
+	if configKey == "" {
+		configKey = s.GetConfigByConfigId(param.ConfigId).ConfigKey
+	}
+	if param.ConfigValue != "" {
+		if err := checkConfigValue(configKey, param.ConfigValue); err != nil {
+			return err
+		}
+	}
+
+	tx := dal.Gorm.Begin()
+
+	var before model.SysConfig
+	if err := tx.Where("config_id = ?", param.ConfigId).Limit(1).Find(&before).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to retrieve config: %w", err)
+	}
+
+	err := tx.Model(model.SysConfig{}).Where("config_id = ?", param.ConfigId).Updates(&model.SysConfig{
 		ConfigName:  param.ConfigName,
 		ConfigKey:   param.ConfigKey,
 		ConfigValue: param.ConfigValue,
@@ -92,15 +147,36 @@ func (s *ConfigService) UpdateConfig(param dto.SaveConfig) error {
 		Remark:      param.Remark,
 	}).Error
 	if err != nil {
+		tx.Rollback()
 		log.Printf("Failed to update config: %v", err)
 		return fmt.Errorf("failed to update config: %w", err)
 	}
 
+	changedKeys := []string{configKey}
+	if before.ConfigId > 0 {
+		var after model.SysConfig
+		if err = tx.Where("config_id = ?", param.ConfigId).Take(&after).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to retrieve config: %w", err)
+		}
+		if err = recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_CONFIG, after.ConfigId, after.ConfigKey, constant.CONFIG_HISTORY_ACTION_UPDATE, configSnapshot(before), configSnapshot(after), param.Reason, param.UpdateBy, 0); err != nil {
+			tx.Rollback()
+			return err
+		}
+		changedKeys = []string{before.ConfigKey, after.ConfigKey}
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Refresh cache after updating configuration
-	if err := s.RefreshCache(); err != nil {
+	if err := s.refreshCache(changedKeys...); err != nil {
 		log.Printf("Warning: Failed to refresh config cache after update: %v", err)
 	}
 
+	notifySearch(constant.SEARCH_TYPE_CONFIG, param.ConfigId)
+
 	return nil
 }
 
@@ -108,25 +184,50 @@ func (s *ConfigService) UpdateConfig(param dto.SaveConfig) error {
 //
 // Parameters:
 //   - configIds: Array of configuration IDs to delete
+//   - deleteBy: Username of the operator deleting the configurations
 //
 // Returns:
 //   - error: Any error that occurred during deletion, or nil on success
-func (s *ConfigService) DeleteConfig(configIds []int) error {
+func (s *ConfigService) DeleteConfig(configIds []int, deleteBy string) error {
 	if len(configIds) == 0 {
 		return xerrors.ErrParam
 	}
 
-	err := dal.Gorm.Model(model.SysConfig{}).Where("config_id IN ?", configIds).Delete(&model.SysConfig{}).Error
+	tx := dal.Gorm.Begin()
+
+	configs := make([]model.SysConfig, 0)
+	if err := tx.Where("config_id IN ?", configIds).Find(&configs).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to retrieve configs: %w", err)
+	}
+
+	err := tx.Model(model.SysConfig{}).Where("config_id IN ?", configIds).Delete(&model.SysConfig{}).Error
 	if err != nil {
+		tx.Rollback()
 		log.Printf("Failed to delete configs: %v", err)
 		return fmt.Errorf("failed to delete configs: %w", err)
 	}
 
+	configKeys := make([]string, 0, len(configs))
+	for _, config := range configs {
+		configKeys = append(configKeys, config.ConfigKey)
+		if err = recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_CONFIG, config.ConfigId, config.ConfigKey, constant.CONFIG_HISTORY_ACTION_DELETE, configSnapshot(config), nil, "", deleteBy, 0); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Refresh cache after deleting configurations
-	if err := s.RefreshCache(); err != nil {
+	if err := s.refreshCache(configKeys...); err != nil {
 		log.Printf("Warning: Failed to refresh config cache after deletion: %v", err)
 	}
 
+	notifySearch(constant.SEARCH_TYPE_CONFIG, configIds...)
+
 	return nil
 }
 
@@ -143,7 +244,25 @@ func (s *ConfigService) GetConfigList(param dto.ConfigListRequest, isPaging bool
 	var count int64
 	configs := make([]dto.ConfigListResponse, 0)
 
-	query := dal.Gorm.Model(model.SysConfig{}).Order("config_id")
+	query := configListQuery(param).Order("config_id")
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			log.Printf("Failed to count configs: %v", err)
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&configs).Error; err != nil {
+		log.Printf("Failed to query configs: %v", err)
+	}
+
+	return configs, int(count)
+}
+
+// configListQuery builds the configuration parameter query with the search conditions applied
+func configListQuery(param dto.ConfigListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysConfig{})
 
 	if param.ConfigName != "" {
 		query = query.Where("config_name LIKE ?", "%"+param.ConfigName+"%")
@@ -161,18 +280,28 @@ func (s *ConfigService) GetConfigList(param dto.ConfigListRequest, isPaging bool
 		query = query.Where("create_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
 	}
 
-	if isPaging {
-		if err := query.Count(&count).Error; err != nil {
-			log.Printf("Failed to count configs: %v", err)
-		}
-		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
-	}
+	return query
+}
 
-	if err := query.Find(&configs).Error; err != nil {
-		log.Printf("Failed to query configs: %v", err)
+// ExportDataset describes the export of the configuration parameters matching the search conditions
+func (s *ConfigService) ExportDataset(param dto.ConfigListRequest) ExportDataset {
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_CONFIG,
+		Query: func() *gorm.DB {
+			return configListQuery(param)
+		},
+		KeyField:  "config_id",
+		KeyColumn: "config_id",
+		Columns: []ExportColumn{
+			{Key: "configId", Field: "config_id", Header: "Config ID", HeaderZh: "参数主键"},
+			{Key: "configName", Field: "config_name", Header: "Config Name", HeaderZh: "参数名称"},
+			{Key: "configKey", Field: "config_key", Header: "Config Key", HeaderZh: "参数键名"},
+			{Key: "configValue", Field: "config_value", Header: "Config Value", HeaderZh: "参数键值"},
+			{Key: "configType", Field: "config_type", Header: "System Built-in", HeaderZh: "系统内置", DictType: "sys_yes_no", Labels: map[string]string{
+				"Y": "Yes", "N": "No",
+			}},
+		},
 	}
-
-	return configs, int(count)
 }
 
 // GetConfigByConfigId gets configuration parameter details by config ID
@@ -226,49 +355,193 @@ func (s *ConfigService) GetConfigByConfigKey(configKey string) dto.ConfigDetailR
 //   - dto.ConfigDetailResponse: Configuration details, or empty object if not found
 //
 // Caching Strategy:
-//   - Prioritizes fetching from Redis cache
-//   - Falls back to database query on cache miss or error
-//   - Writes query results to cache with 24-hour expiration time
+//   - Reads the two-level cache: the local LRU first, then Redis
+//   - Loads missing parameters from the database once for all concurrent readers
+//   - Remembers missing parameters for a minute
 //   - Logs errors but does not interrupt flow (graceful degradation)
 func (s *ConfigService) GetConfigCacheByConfigKey(configKey string) dto.ConfigDetailResponse {
+	config, _, err := configCache.Get(context.Background(), configKey)
+	if err != nil {
+		log.Printf("Failed to get config for key %s: %v", configKey, err)
+	}
+
+	return config
+}
+
+// configCache holds the configuration parameters by key
+var configCache = NewTwoLevelCache(TwoLevelCacheOptions{
+	Name:        "config",
+	Hash:        rediskey.SysConfigKey,
+	LocalSize:   1024,
+	LocalTTL:    10 * time.Minute,
+	RedisTTL:    24 * time.Hour,
+	NegativeTTL: time.Minute,
+	Jitter:      0.1,
+}, loadConfigCache)
+
+// The services drop the whole hash in Redis, the events drop the changed parameters in process
+func init() {
+	OnCacheEvent(constant.CACHE_EVENT_CONFIG, func(event CacheEvent) {
+		configCache.Forget(event.Keys...)
+	})
+}
+
+// loadConfigCache loads a configuration parameter into the cache
+func loadConfigCache(configKey string) (dto.ConfigDetailResponse, bool, error) {
 	var config dto.ConfigDetailResponse
-	ctx := context.Background()
 
-	// If cache is not empty, avoid reading from database to reduce database pressure
-	configCache, err := dal.Redis.HGet(ctx, rediskey.SysConfigKey(), configKey).Result()
-	if err != nil {
-		// Log error but continue execution (fallback to database)
-		log.Printf("Redis error when getting config for key %s: %v", configKey, err)
-	} else if configCache != "" {
-		if err := json.Unmarshal([]byte(configCache), &config); err != nil {
-			// Log deserialization error
-			log.Printf("Failed to unmarshal config for key %s: %v", configKey, err)
-		} else {
-			return config
-		}
+	if configKey == "" {
+		return config, false, nil
+	}
+
+	if err := dal.Gorm.Model(model.SysConfig{}).Where("config_key = ?", configKey).Order("config_id DESC").Limit(1).Find(&config).Error; err != nil {
+		return config, false, fmt.Errorf("failed to get config by key %s: %w", configKey, err)
+	}
+
+	return config, config.ConfigId > 0, nil
+}
+
+// GetString gets the value of a configuration parameter, or its declared default when it is missing
+//
+// Parameters:
+//   - configKey: Configuration key
+//
+// Returns:
+//   - string: The cached value, the default value, or an empty string for an undeclared missing parameter
+func (s *ConfigService) GetString(configKey string) string {
+	value, _ := s.typedValue(configKey)
+	return value
+}
+
+// GetBool gets the value of a bool configuration parameter, or its declared default when it is
+// missing or invalid
+func (s *ConfigService) GetBool(configKey string) bool {
+	value, schema := s.typedValue(configKey)
+	enabled, err := parseConfigBool(value)
+	if err != nil && schema != nil {
+		enabled, _ = parseConfigBool(schema.Default)
+	}
+	return enabled
+}
+
+// GetInt gets the value of an int configuration parameter, or its declared default when it is
+// missing or invalid
+func (s *ConfigService) GetInt(configKey string) int {
+	value, schema := s.typedValue(configKey)
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil && schema != nil {
+		number, _ = strconv.Atoi(schema.Default)
 	}
+	return number
+}
+
+// GetDuration gets the value of a duration configuration parameter, or its declared default when
+// it is missing or invalid
+func (s *ConfigService) GetDuration(configKey string) time.Duration {
+	value, schema := s.typedValue(configKey)
+	duration, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil && schema != nil {
+		duration, _ = time.ParseDuration(schema.Default)
+	}
+	return duration
+}
 
-	// Read configuration from database and record to cache
-	config = s.GetConfigByConfigKey(configKey)
-	if config.ConfigId > 0 {
-		configBytes, err := json.Marshal(&config)
-		if err != nil {
-			log.Printf("Failed to marshal config for key %s: %v", configKey, err)
-			return config
+// GetJson decodes the value of a json configuration parameter, or its declared default when it
+// is missing or invalid, into the target
+func (s *ConfigService) GetJson(configKey string, target interface{}) error {
+	value, _ := s.typedValue(configKey)
+	if value == "" {
+		return fmt.Errorf("config %s has no value", configKey)
+	}
+	if err := json.Unmarshal([]byte(value), target); err != nil {
+		return fmt.Errorf("failed to decode config %s: %w", configKey, err)
+	}
+	return nil
+}
+
+// typedValue gets the cached value of a configuration parameter with its declaration. A missing
+// value, or one the declaration rejects, is replaced by the declared default; such values can
+// only come from edits outside of the service.
+func (s *ConfigService) typedValue(configKey string) (string, *ConfigSchema) {
+	value := s.GetConfigCacheByConfigKey(configKey).ConfigValue
+
+	schema, ok := getConfigSchema(configKey)
+	if !ok {
+		return value, nil
+	}
+	if value == "" {
+		return schema.Default, schema
+	}
+	if err := schema.check(value); err != nil {
+		log.Printf("Invalid value of config %s, using the default: %v", configKey, err)
+		return schema.Default, schema
+	}
+	return value, schema
+}
+
+// GetConfigSchemas gets the declarations of the configuration parameters ordered by key
+//
+// Returns:
+//   - []dto.ConfigSchemaResponse: Type, default value and constraints of each declared parameter
+func (s *ConfigService) GetConfigSchemas() []dto.ConfigSchemaResponse {
+	schemas := sortedConfigSchemas()
+
+	responses := make([]dto.ConfigSchemaResponse, 0, len(schemas))
+	for _, schema := range schemas {
+		responses = append(responses, configSchemaResponse(schema))
+	}
+	return responses
+}
+
+// InitConfigs creates the declared configuration parameters missing from the database with their
+// default values, as built-in parameters. Existing values the declarations reject are reported
+// and left as they are, reading as the default until they are corrected.
+//
+// Returns:
+//   - error: Any error that occurred while reading or creating parameters, or nil on success
+func (s *ConfigService) InitConfigs() error {
+	existing := make([]model.SysConfig, 0)
+	if err := dal.Gorm.Model(model.SysConfig{}).Select("config_key", "config_value").Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to read configs: %w", err)
+	}
+	values := make(map[string]string, len(existing))
+	for _, config := range existing {
+		values[config.ConfigKey] = config.ConfigValue
+	}
+
+	created := 0
+	for _, schema := range sortedConfigSchemas() {
+		value, ok := values[schema.Key]
+		if ok {
+			if err := checkConfigValue(schema.Key, value); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			continue
 		}
 
-		// Set cache
-		_, err = dal.Redis.HSet(ctx, rediskey.SysConfigKey(), configKey, string(configBytes)).Result()
-		if err != nil {
-			// Log error but don't affect return value
-			log.Printf("Failed to set config cache for key %s: %v", configKey, err)
-		} else {
-			// Set cache expiration time (if not already set)
-			dal.Redis.Expire(ctx, rediskey.SysConfigKey(), 24*time.Hour)
+		config := model.SysConfig{
+			ConfigName:  schema.Name,
+			ConfigKey:   schema.Key,
+			ConfigValue: schema.Default,
+			ConfigType:  constant.IS_DEFAULT_YES,
+			CreateBy:    constant.CONFIG_INIT_OPERATOR,
+			Remark:      schema.Description,
+		}
+		if config.ConfigName == "" {
+			config.ConfigName = schema.Key
+		}
+		if err := dal.Gorm.Create(&config).Error; err != nil {
+			return fmt.Errorf("failed to create config %s: %w", schema.Key, err)
 		}
+		notifySearch(constant.SEARCH_TYPE_CONFIG, config.ConfigId)
+		created++
 	}
 
-	return config
+	if created > 0 {
+		log.Printf("Created %d missing built-in configs", created)
+		return s.RefreshCache()
+	}
+	return nil
 }
 
 // RefreshCache refreshes the configuration cache
@@ -276,8 +549,17 @@ func (s *ConfigService) GetConfigCacheByConfigKey(configKey string) dto.ConfigDe
 // Returns:
 //   - error: Any error that occurred during refresh, or nil on success
 func (s *ConfigService) RefreshCache() error {
+	return s.refreshCache()
+}
+
+// refreshCache drops the configuration cache and announces the changed parameters to the local
+// caches of every instance, all of them when no key is given
+func (s *ConfigService) refreshCache(configKeys ...string) error {
 	ctx := context.Background()
 	err := dal.Redis.Del(ctx, rediskey.SysConfigKey()).Err()
+
+	publishCacheKeys(constant.CACHE_EVENT_CONFIG, configKeys...)
+
 	if err != nil {
 		log.Printf("Failed to refresh config cache: %v", err)
 		return fmt.Errorf("failed to refresh config cache: %w", err)
@@ -2,12 +2,14 @@ package service
 
 import (
 	"encoding/json"
+	"strconv"
 	"testing"
 	"time"
 
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/types/constant"
 	rediskey "mira/common/types/redis-key"
 	"mira/common/xerrors"
 
@@ -59,6 +61,18 @@ func TestConfigService_CreateConfig(t *testing.T) {
 		assert.Equal(t, xerrors.ErrConfigValueEmpty, err)
 	})
 
+	t.Run("should reject values the declaration of the parameter rejects", func(t *testing.T) {
+		for key, value := range map[string]string{
+			ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY: "yes",
+			RECYCLE_RETENTION_DAYS_CONFIG_KEY:  "0",
+			INDEX_SIDE_THEME_CONFIG_KEY:        "theme-blue",
+			USER_INIT_PASSWORD_CONFIG_KEY:      "123",
+		} {
+			err := s.CreateConfig(dto.SaveConfig{ConfigName: "some-name", ConfigKey: key, ConfigValue: value})
+			assert.ErrorIs(t, err, xerrors.ErrConfigValueInvalid, key)
+		}
+	})
+
 	t.Run("should create config successfully", func(t *testing.T) {
 		param := dto.SaveConfig{
 			ConfigName:  "test-config",
@@ -126,13 +140,39 @@ func TestConfigService_UpdateConfig(t *testing.T) {
 	})
 }
 
+func TestConfigService_UpdateConfigValidation(t *testing.T) {
+	setup()
+	defer teardown()
+	s := &ConfigService{}
+
+	dal.Gorm.Create(&model.SysConfig{ConfigId: 1, ConfigName: "Expire Hours", ConfigKey: APPROVAL_EXPIRE_HOURS_CONFIG_KEY, ConfigValue: "72"})
+
+	t.Run("should check the value against the current key when none is given", func(t *testing.T) {
+		err := s.UpdateConfig(dto.SaveConfig{ConfigId: 1, ConfigValue: "three days"})
+		assert.ErrorIs(t, err, xerrors.ErrConfigValueInvalid)
+		assert.EqualError(t, err, "sys.approval.expireHours must be an integer: invalid parameter value")
+	})
+
+	t.Run("should check the value against the new key", func(t *testing.T) {
+		err := s.UpdateConfig(dto.SaveConfig{ConfigId: 1, ConfigKey: ACCOUNT_REGISTER_USER_CONFIG_KEY, ConfigValue: "72"})
+		assert.ErrorIs(t, err, xerrors.ErrConfigValueInvalid)
+	})
+
+	t.Run("should update a valid value", func(t *testing.T) {
+		redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
+		assert.NoError(t, s.UpdateConfig(dto.SaveConfig{ConfigId: 1, ConfigValue: "24"}))
+		assert.Equal(t, "24", s.GetConfigByConfigId(1).ConfigValue)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
+
 func TestConfigService_DeleteConfig(t *testing.T) {
 	setup()
 	defer teardown()
 	s := &ConfigService{}
 
 	t.Run("should return error when config ids is empty", func(t *testing.T) {
-		err := s.DeleteConfig([]int{})
+		err := s.DeleteConfig([]int{}, "admin")
 		assert.Error(t, err)
 		assert.Equal(t, xerrors.ErrParam, err)
 	})
@@ -152,7 +192,7 @@ func TestConfigService_DeleteConfig(t *testing.T) {
 
 		// Now, delete the config
 		redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
-		err = s.DeleteConfig([]int{1})
+		err = s.DeleteConfig([]int{1}, "admin")
 		assert.NoError(t, err)
 
 		// Verify the deletion
@@ -250,7 +290,7 @@ func TestConfigService_GetConfigCacheByConfigKey(t *testing.T) {
 	t.Run("should return config from cache", func(t *testing.T) {
 		// Mock cache
 		cachedConfig := `{"ConfigId":1,"ConfigName":"cached-config","ConfigKey":"cached-key","ConfigValue":"cached-value"}`
-		redisMock.ExpectHGet(rediskey.SysConfigKey(), "cached-key").SetVal(cachedConfig)
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), "cached-key").SetVal("0:" + cachedConfig)
 
 		// Get the config
 		config := s.GetConfigCacheByConfigKey("cached-key")
@@ -279,8 +319,8 @@ func TestConfigService_GetConfigCacheByConfigKey(t *testing.T) {
 
 		// Mock cache miss and set
 		redisMock.ExpectHGet(rediskey.SysConfigKey(), "db-key").RedisNil()
-		redisMock.ExpectHSet(rediskey.SysConfigKey(), "db-key", string(configBytes)).SetVal(1)
-		redisMock.ExpectExpire(rediskey.SysConfigKey(), 24*time.Hour).SetVal(true)
+		redisMock.CustomMatch(matchTwoLevelCacheEntry(24*time.Hour, 0.1)).ExpectHSet(rediskey.SysConfigKey(), "db-key", string(configBytes)).SetVal(1)
+		redisMock.ExpectExpire(rediskey.SysConfigKey(), 24*time.Hour+144*time.Minute).SetVal(true)
 
 		// Get the config
 		config := s.GetConfigCacheByConfigKey("db-key")
@@ -309,3 +349,118 @@ func TestConfigService_RefreshCache(t *testing.T) {
 		assert.NoError(t, redisMock.ExpectationsWereMet())
 	})
 }
+
+func TestConfigService_TypedAccessors(t *testing.T) {
+	setup()
+	defer teardown()
+	s := &ConfigService{}
+
+	// cached returns the cache entry of a parameter
+	cached := func(key, value string) string {
+		return `0:{"configId":1,"configKey":"` + key + `","configValue":` + strconv.Quote(value) + `}`
+	}
+
+	assert.NoError(t, RegisterConfigSchema(ConfigSchema{Key: "test.sync.interval", Type: constant.CONFIG_VALUE_TYPE_DURATION, Default: "5m",
+		Min: int64(time.Minute), Max: int64(time.Hour)}))
+	assert.NoError(t, RegisterConfigSchema(ConfigSchema{Key: "test.sync.target", Type: constant.CONFIG_VALUE_TYPE_JSON, Default: `{"host":"localhost"}`,
+		JsonSchema: `{"type":"object","required":["host"],"properties":{"host":{"type":"string"},"port":{"type":"integer"}}}`}))
+	defer func() {
+		configSchemasMu.Lock()
+		delete(configSchemas, "test.sync.interval")
+		delete(configSchemas, "test.sync.target")
+		configSchemasMu.Unlock()
+	}()
+
+	t.Run("should parse stored values", func(t *testing.T) {
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), ACCOUNT_REGISTER_USER_CONFIG_KEY).SetVal(cached(ACCOUNT_REGISTER_USER_CONFIG_KEY, "true"))
+		assert.True(t, s.GetBool(ACCOUNT_REGISTER_USER_CONFIG_KEY))
+
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), RECYCLE_RETENTION_DAYS_CONFIG_KEY).SetVal(cached(RECYCLE_RETENTION_DAYS_CONFIG_KEY, "7"))
+		assert.Equal(t, 7, s.GetInt(RECYCLE_RETENTION_DAYS_CONFIG_KEY))
+
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), "test.sync.interval").SetVal(cached("test.sync.interval", "45m"))
+		assert.Equal(t, 45*time.Minute, s.GetDuration("test.sync.interval"))
+
+		var target struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		}
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), "test.sync.target").SetVal(cached("test.sync.target", `{"host":"example.com","port":443}`))
+		assert.NoError(t, s.GetJson("test.sync.target", &target))
+		assert.Equal(t, "example.com", target.Host)
+		assert.Equal(t, 443, target.Port)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fall back to the default of invalid values", func(t *testing.T) {
+		configCache.Forget()
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY).SetVal(cached(ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY, "ture"))
+		assert.True(t, s.GetBool(ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY))
+
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), APPROVAL_EXPIRE_HOURS_CONFIG_KEY).SetVal(cached(APPROVAL_EXPIRE_HOURS_CONFIG_KEY, "-1"))
+		assert.Equal(t, APPROVAL_DEFAULT_EXPIRE_HOURS, s.GetInt(APPROVAL_EXPIRE_HOURS_CONFIG_KEY))
+
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), "test.sync.interval").SetVal(cached("test.sync.interval", "1d"))
+		assert.Equal(t, 5*time.Minute, s.GetDuration("test.sync.interval"))
+
+		var target map[string]interface{}
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), "test.sync.target").SetVal(cached("test.sync.target", `{"port":443}`))
+		assert.NoError(t, s.GetJson("test.sync.target", &target))
+		assert.Equal(t, map[string]interface{}{"host": "localhost"}, target)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should fall back to the default of missing values", func(t *testing.T) {
+		configCache.Forget()
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), INDEX_SKIN_NAME_CONFIG_KEY).RedisNil()
+		assert.Equal(t, "skin-blue", s.GetString(INDEX_SKIN_NAME_CONFIG_KEY))
+
+		redisMock.ExpectHGet(rediskey.SysConfigKey(), "test.undeclared").RedisNil()
+		assert.Equal(t, "", s.GetString("test.undeclared"))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should reject invalid declarations", func(t *testing.T) {
+		assert.Error(t, RegisterConfigSchema(ConfigSchema{Key: "test.invalid", Type: "float", Default: "1"}))
+		assert.Error(t, RegisterConfigSchema(ConfigSchema{Key: "test.invalid", Type: constant.CONFIG_VALUE_TYPE_ENUM, Default: "a"}))
+		assert.Error(t, RegisterConfigSchema(ConfigSchema{Key: "test.invalid", Type: constant.CONFIG_VALUE_TYPE_INT, Default: "0", Min: 1, Max: 10}))
+		assert.Error(t, RegisterConfigSchema(ConfigSchema{Key: "test.invalid", Type: constant.CONFIG_VALUE_TYPE_JSON, Default: "{}", JsonSchema: `{"type":"text"}`}))
+		_, ok := getConfigSchema("test.invalid")
+		assert.False(t, ok)
+	})
+}
+
+func TestConfigService_InitConfigs(t *testing.T) {
+	setup()
+	defer teardown()
+	s := &ConfigService{}
+
+	dal.Gorm.Create(&model.SysConfig{ConfigId: 1, ConfigName: "Captcha", ConfigKey: ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY, ConfigValue: "false"})
+
+	t.Run("should create the missing built-in parameters with their defaults", func(t *testing.T) {
+		redisMock.ExpectDel(rediskey.SysConfigKey()).SetVal(1)
+		assert.NoError(t, s.InitConfigs())
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+
+		var count int64
+		dal.Gorm.Model(model.SysConfig{}).Count(&count)
+		assert.Equal(t, int64(len(builtinConfigSchemas)), count)
+
+		assert.Equal(t, "false", s.GetConfigByConfigKey(ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY).ConfigValue)
+		config := s.GetConfigByConfigKey(RECYCLE_RETENTION_DAYS_CONFIG_KEY)
+		assert.Equal(t, "30", config.ConfigValue)
+		assert.Equal(t, constant.IS_DEFAULT_YES, config.ConfigType)
+	})
+
+	t.Run("should leave the parameters alone once created", func(t *testing.T) {
+		assert.NoError(t, s.InitConfigs())
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should list the declarations by key", func(t *testing.T) {
+		schemas := s.GetConfigSchemas()
+		assert.Len(t, schemas, len(builtinConfigSchemas))
+		assert.Equal(t, ACCOUNT_CAPTCHA_ENABLED_CONFIG_KEY, schemas[0].ConfigKey)
+		assert.Equal(t, constant.CONFIG_VALUE_TYPE_BOOL, schemas[0].ValueType)
+	})
+}
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"context"
+	"log"
+	"strconv"
 	"strings"
+	"time"
 
 	"mira/app/dto"
 	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
 
 	"gorm.io/gorm"
 )
@@ -39,6 +44,14 @@ type DataScopeServiceInterface interface {
 type DataScopeService struct {
 	userService DataScopeUserServiceInterface
 	roleService DataScopeRoleServiceInterface
+	// subjects caches the departments and roles of the users, nil to read them at every call
+	subjects *TwoLevelCache[dataScopeSubject]
+}
+
+// dataScopeSubject is what the data scope of a user depends on
+type dataScopeSubject struct {
+	DeptId int                    `json:"deptId"`
+	Roles  []dto.RoleListResponse `json:"roles"`
 }
 
 // NewDataScopeService creates a new data scope service with dependencies
@@ -57,6 +70,25 @@ func init() {
 		userService: &UserService{},
 		roleService: &RoleService{},
 	}
+	defaultDataScopeService.subjects = NewTwoLevelCache(TwoLevelCacheOptions{
+		Name:        "dataScope",
+		Hash:        rediskey.SysUserDataScopeKey,
+		LocalSize:   4096,
+		LocalTTL:    5 * time.Minute,
+		RedisTTL:    30 * time.Minute,
+		NegativeTTL: time.Minute,
+		Jitter:      0.1,
+	}, defaultDataScopeService.loadSubject)
+
+	// Changes to users drop their departments and roles, changes to roles drop those of every user
+	OnCacheEvent("", func(event CacheEvent) {
+		switch event.Type {
+		case constant.CACHE_EVENT_USER:
+			defaultDataScopeService.subjects.drop(event, cacheEventIdKeys(event.Ids)...)
+		case constant.CACHE_EVENT_ROLE:
+			defaultDataScopeService.subjects.drop(event)
+		}
+	})
 }
 
 // GetDataScope gets the data scope.
@@ -89,17 +121,17 @@ func (s *DataScopeService) GetDataScope(deptAlias string, userId int, userAlias
 		deptAlias = "sys_dept"
 	}
 
-	// Get user information
-	user := s.userService.GetUserByUserId(userId)
-	if user.UserId == 0 {
+	// Get the department and roles of the user
+	subject, found := s.getSubject(userId)
+	if !found {
 		// If user not found, return a scope that returns no data
 		return func(db *gorm.DB) *gorm.DB {
 			return db.Where("1 = 0") // Return no data if user not found
 		}
 	}
+	user := dto.UserDetailResponse{UserId: userId, DeptId: subject.DeptId}
 
-	// Get the roles of the current user
-	roles := s.roleService.GetRoleListByUserIdCompat(user.UserId)
+	roles := subject.Roles
 	if len(roles) == 0 {
 		// No logging, just continue with empty roles list
 	}
@@ -113,6 +145,34 @@ func (s *DataScopeService) GetDataScope(deptAlias string, userId int, userAlias
 	}
 }
 
+// getSubject gets the department and roles of a user, reporting whether the user exists
+func (s *DataScopeService) getSubject(userId int) (dataScopeSubject, bool) {
+	key := strconv.Itoa(userId)
+
+	if s.subjects != nil {
+		subject, found, err := s.subjects.Get(context.Background(), key)
+		if err == nil {
+			return subject, found
+		}
+		log.Printf("Failed to get data scope of user ID %d: %v", userId, err)
+	}
+
+	subject, found, _ := s.loadSubject(key)
+	return subject, found
+}
+
+// loadSubject loads the department and roles of a user
+func (s *DataScopeService) loadSubject(key string) (dataScopeSubject, bool, error) {
+	userId, _ := strconv.Atoi(key)
+
+	user := s.userService.GetUserByUserId(userId)
+	if user.UserId == 0 {
+		return dataScopeSubject{}, false, nil
+	}
+
+	return dataScopeSubject{DeptId: user.DeptId, Roles: s.roleService.GetRoleListByUserIdCompat(user.UserId)}, true, nil
+}
+
 // getCustomDataScopeRoleIds collects role IDs with custom data scope
 func (s *DataScopeService) getCustomDataScopeRoleIds(roles []dto.RoleListResponse) []int {
 	var roleIds []int
@@ -171,6 +231,7 @@ func (s *DataScopeService) addDataScopeCondition(
 	switch dataScope {
 	case DATA_SCOPE_CUSTOM:
 		s.addCustomScopeCondition(roleId, deptAlias, roleIds, sqlCondition, sqlArg)
+		s.addCustomGroupScopeCondition(roleId, userAlias, roleIds, sqlCondition, sqlArg)
 	case DATA_SCOPE_DEPT:
 		s.addDeptScopeCondition(user.DeptId, deptAlias, sqlCondition, sqlArg)
 	case DATA_SCOPE_DEPT_SUB:
@@ -197,6 +258,32 @@ func (s *DataScopeService) addCustomScopeCondition(
 	}
 }
 
+// addCustomGroupScopeCondition adds conditions for the user groups selected by custom data scope.
+// Groups select users, so the condition only applies when there is a userAlias.
+func (s *DataScopeService) addCustomGroupScopeCondition(
+	roleId int,
+	userAlias string,
+	roleIds []int,
+	sqlCondition *[]string,
+	sqlArg *[]interface{},
+) {
+	if userAlias == "" {
+		return
+	}
+
+	groupUsers := "SELECT sys_user_group_user.user_id FROM sys_user_group_user" +
+		" JOIN sys_role_group ON sys_role_group.group_id = sys_user_group_user.group_id" +
+		" JOIN sys_user_group ON sys_user_group.group_id = sys_user_group_user.group_id AND sys_user_group.status = '0' AND sys_user_group.delete_time IS NULL"
+
+	if len(roleIds) > 0 {
+		*sqlCondition = append(*sqlCondition, userAlias+".user_id IN ("+groupUsers+" WHERE sys_role_group.role_id IN (?))")
+		*sqlArg = append(*sqlArg, roleIds)
+	} else {
+		*sqlCondition = append(*sqlCondition, userAlias+".user_id IN ("+groupUsers+" WHERE sys_role_group.role_id = ?)")
+		*sqlArg = append(*sqlArg, roleId)
+	}
+}
+
 // addDeptScopeCondition adds conditions for department data scope
 func (s *DataScopeService) addDeptScopeCondition(
 	deptId int,
@@ -153,7 +153,9 @@ func TestDataScopeService_GetDataScope(t *testing.T) {
 		tx := db.Find(&model.SysDept{})
 
 		// Assert
-		assert.Equal(t, "SELECT * FROM `sys_dept` WHERE (d.dept_id IN (SELECT dept_id FROM sys_role_dept WHERE role_id IN (?,?)) OR d.dept_id IN (SELECT dept_id FROM sys_role_dept WHERE role_id IN (?,?))) AND `sys_dept`.`delete_time` IS NULL", tx.Statement.SQL.String())
-		assert.Equal(t, []interface{}{2, 3, 2, 3}, tx.Statement.Vars)
+		deptCondition := "d.dept_id IN (SELECT dept_id FROM sys_role_dept WHERE role_id IN (?,?))"
+		groupCondition := "u.user_id IN (SELECT sys_user_group_user.user_id FROM sys_user_group_user JOIN sys_role_group ON sys_role_group.group_id = sys_user_group_user.group_id JOIN sys_user_group ON sys_user_group.group_id = sys_user_group_user.group_id AND sys_user_group.status = '0' AND sys_user_group.delete_time IS NULL WHERE sys_role_group.role_id IN (?,?))"
+		assert.Equal(t, "SELECT * FROM `sys_dept` WHERE ("+deptCondition+" OR "+groupCondition+" OR "+deptCondition+" OR "+groupCondition+") AND `sys_dept`.`delete_time` IS NULL", tx.Statement.SQL.String())
+		assert.Equal(t, []interface{}{2, 3, 2, 3, 2, 3, 2, 3}, tx.Statement.Vars)
 	})
 }
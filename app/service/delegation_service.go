@@ -0,0 +1,336 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+)
+
+// DelegationServiceInterface defines operations for delegated department administration
+type DelegationServiceInterface interface {
+	CreateDelegation(param dto.SaveDelegation, roleIds []int) error
+	UpdateDelegation(param dto.SaveDelegation, roleIds []int) error
+	DeleteDelegation(delegationIds []int) error
+	GetDelegationList(param dto.DelegationListRequest, isPaging bool) ([]dto.DelegationListResponse, int)
+	GetDelegationByDelegationId(delegationId int) dto.DelegationDetailResponse
+	GetDelegationScope(userId int) (dto.DelegationScope, error)
+}
+
+// DelegationService implements delegated department administration
+//
+// A delegation hands the administration of a department subtree to a user, or to
+// every user holding a role. Delegates may manage users inside the subtree and
+// assign the whitelisted roles only; a user receiving several delegations gets the
+// union of their subtrees and roles.
+type DelegationService struct{}
+
+// Ensure DelegationService implements DelegationServiceInterface
+var _ DelegationServiceInterface = (*DelegationService)(nil)
+
+// DelegatedPerms lists the permissions a delegation grants within its subtree. Removing,
+// importing or exporting users and any other action still needs the permission itself.
+var DelegatedPerms = []string{"system:user:list", "system:user:query", "system:user:add", "system:user:edit"}
+
+// NewDelegationService creates a new DelegationService
+func NewDelegationService() *DelegationService {
+	return &DelegationService{}
+}
+
+// CreateDelegation creates a new delegation with its whitelisted roles
+func (s *DelegationService) CreateDelegation(param dto.SaveDelegation, roleIds []int) error {
+	return s.CreateDelegationWithErr(param, roleIds)
+}
+
+// CreateDelegationWithErr creates a new delegation with proper error handling
+func (s *DelegationService) CreateDelegationWithErr(param dto.SaveDelegation, roleIds []int) error {
+	if err := checkDelegation(param); err != nil {
+		return err
+	}
+
+	tx := dal.Gorm.Begin()
+
+	delegation := model.SysDeptDelegation{
+		DeptId:       param.DeptId,
+		DelegateType: param.DelegateType,
+		DelegateId:   param.DelegateId,
+		Status:       param.Status,
+		CreateBy:     param.CreateBy,
+		Remark:       param.Remark,
+	}
+
+	if err := tx.Model(model.SysDeptDelegation{}).Create(&delegation).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to create delegation")
+	}
+
+	for _, roleId := range roleIds {
+		if err := tx.Model(model.SysDeptDelegationRole{}).Create(&model.SysDeptDelegationRole{
+			DelegationId: delegation.DelegationId,
+			RoleId:       roleId,
+		}).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "failed to whitelist role ID %d", roleId)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// UpdateDelegation updates a delegation; nil roleIds keeps the current whitelist
+func (s *DelegationService) UpdateDelegation(param dto.SaveDelegation, roleIds []int) error {
+	return s.UpdateDelegationWithErr(param, roleIds)
+}
+
+// UpdateDelegationWithErr updates a delegation with proper error handling
+func (s *DelegationService) UpdateDelegationWithErr(param dto.SaveDelegation, roleIds []int) error {
+	if param.DelegationId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if err := checkDelegation(param); err != nil {
+		return err
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Model(model.SysDeptDelegation{}).Where("delegation_id = ?", param.DelegationId).Updates(&model.SysDeptDelegation{
+		DeptId:       param.DeptId,
+		DelegateType: param.DelegateType,
+		DelegateId:   param.DelegateId,
+		Status:       param.Status,
+		UpdateBy:     param.UpdateBy,
+		Remark:       param.Remark,
+	}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to update delegation")
+	}
+
+	if roleIds != nil {
+		if err := tx.Model(model.SysDeptDelegationRole{}).Where("delegation_id = ?", param.DelegationId).Delete(&model.SysDeptDelegationRole{}).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete delegation roles")
+		}
+		for _, roleId := range roleIds {
+			if err := tx.Model(model.SysDeptDelegationRole{}).Create(&model.SysDeptDelegationRole{
+				DelegationId: param.DelegationId,
+				RoleId:       roleId,
+			}).Error; err != nil {
+				tx.Rollback()
+				return errors.Wrapf(err, "failed to whitelist role ID %d", roleId)
+			}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// DeleteDelegation deletes delegations and their whitelisted roles
+func (s *DelegationService) DeleteDelegation(delegationIds []int) error {
+	return s.DeleteDelegationWithErr(delegationIds)
+}
+
+// DeleteDelegationWithErr deletes delegations with proper error handling
+func (s *DelegationService) DeleteDelegationWithErr(delegationIds []int) error {
+	if len(delegationIds) == 0 {
+		return xerrors.ErrParam
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Model(model.SysDeptDelegation{}).Where("delegation_id IN ?", delegationIds).Delete(&model.SysDeptDelegation{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to delete delegations")
+	}
+
+	if err := tx.Model(model.SysDeptDelegationRole{}).Where("delegation_id IN ?", delegationIds).Delete(&model.SysDeptDelegationRole{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to delete delegation roles")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// GetDelegationList retrieves a list of delegations based on search parameters
+func (s *DelegationService) GetDelegationList(param dto.DelegationListRequest, isPaging bool) ([]dto.DelegationListResponse, int) {
+	delegations, count, _ := s.GetDelegationListWithErr(param, isPaging)
+	return delegations, count
+}
+
+// GetDelegationListWithErr retrieves a list of delegations with proper error handling
+func (s *DelegationService) GetDelegationListWithErr(param dto.DelegationListRequest, isPaging bool) ([]dto.DelegationListResponse, int, error) {
+	var count int64
+	delegations := make([]dto.DelegationListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysDeptDelegation{}).
+		Select(
+			"sys_dept_delegation.*", "sys_dept.dept_name",
+			"COALESCE(sys_user.user_name, sys_role.role_name) AS delegate_name",
+		).
+		Joins("LEFT JOIN sys_dept ON sys_dept.dept_id = sys_dept_delegation.dept_id").
+		Joins("LEFT JOIN sys_user ON sys_dept_delegation.delegate_type = ? AND sys_user.user_id = sys_dept_delegation.delegate_id", constant.DELEGATE_TYPE_USER).
+		Joins("LEFT JOIN sys_role ON sys_dept_delegation.delegate_type = ? AND sys_role.role_id = sys_dept_delegation.delegate_id", constant.DELEGATE_TYPE_ROLE).
+		Order("sys_dept_delegation.delegation_id")
+
+	if param.DeptId != 0 {
+		query = query.Where("sys_dept_delegation.dept_id = ?", param.DeptId)
+	}
+
+	if param.DelegateType != "" {
+		query = query.Where("sys_dept_delegation.delegate_type = ?", param.DelegateType)
+	}
+
+	if param.Status != "" {
+		query = query.Where("sys_dept_delegation.status = ?", param.Status)
+	}
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count delegations")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&delegations).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve delegations")
+	}
+
+	return delegations, int(count), nil
+}
+
+// GetDelegationByDelegationId retrieves delegation details with whitelisted roles by ID
+func (s *DelegationService) GetDelegationByDelegationId(delegationId int) dto.DelegationDetailResponse {
+	delegation, _ := s.GetDelegationByDelegationIdWithErr(delegationId)
+	return delegation
+}
+
+// GetDelegationByDelegationIdWithErr retrieves delegation details with proper error handling
+func (s *DelegationService) GetDelegationByDelegationIdWithErr(delegationId int) (dto.DelegationDetailResponse, error) {
+	var delegation dto.DelegationDetailResponse
+
+	if delegationId <= 0 {
+		return delegation, xerrors.ErrParam
+	}
+
+	if err := dal.Gorm.Model(model.SysDeptDelegation{}).Where("delegation_id = ?", delegationId).Last(&delegation).Error; err != nil {
+		return delegation, errors.Wrap(err, "failed to retrieve delegation by ID")
+	}
+
+	delegation.RoleIds = make([]int, 0)
+	if err := dal.Gorm.Model(model.SysDeptDelegationRole{}).Where("delegation_id = ?", delegationId).Pluck("role_id", &delegation.RoleIds).Error; err != nil {
+		return delegation, errors.Wrap(err, "failed to retrieve delegation roles")
+	}
+
+	return delegation, nil
+}
+
+// GetDelegationScope returns the departments and roles a user may administer through
+// the enabled delegations granted to the user or to any of its normal roles
+func (s *DelegationService) GetDelegationScope(userId int) (dto.DelegationScope, error) {
+	scope := dto.DelegationScope{
+		DeptIds: make([]int, 0),
+		RoleIds: make([]int, 0),
+	}
+
+	if userId <= 0 {
+		return scope, nil
+	}
+
+	userRoleIds := make([]int, 0)
+	if err := dal.Gorm.Table("(?) AS user_roles", userRoleQuery(userId)).
+		Joins("JOIN sys_role ON sys_role.role_id = user_roles.role_id AND sys_role.status = ? AND sys_role.delete_time IS NULL", constant.NORMAL_STATUS).
+		Pluck("user_roles.role_id", &userRoleIds).Error; err != nil {
+		return scope, errors.Wrapf(err, "failed to get roles of user ID %d", userId)
+	}
+
+	delegations := make([]model.SysDeptDelegation, 0)
+	if err := dal.Gorm.Model(model.SysDeptDelegation{}).
+		Where("status = ?", constant.NORMAL_STATUS).
+		Where("(delegate_type = ? AND delegate_id = ?) OR (delegate_type = ? AND delegate_id IN ?)",
+			constant.DELEGATE_TYPE_USER, userId, constant.DELEGATE_TYPE_ROLE, userRoleIds).
+		Find(&delegations).Error; err != nil {
+		return scope, errors.Wrapf(err, "failed to get delegations of user ID %d", userId)
+	}
+
+	if len(delegations) == 0 {
+		return scope, nil
+	}
+
+	delegationIds := make([]int, 0, len(delegations))
+	rootDeptIds := make([]int, 0, len(delegations))
+	for _, delegation := range delegations {
+		delegationIds = append(delegationIds, delegation.DelegationId)
+		rootDeptIds = append(rootDeptIds, delegation.DeptId)
+	}
+
+	if err := dal.Gorm.Model(model.SysDeptDelegationRole{}).
+		Where("delegation_id IN ?", delegationIds).
+		Distinct().
+		Order("role_id").
+		Pluck("role_id", &scope.RoleIds).Error; err != nil {
+		return scope, errors.Wrap(err, "failed to get delegated roles")
+	}
+
+	depts := make([]model.SysDept, 0)
+	if err := dal.Gorm.Model(model.SysDept{}).Select("dept_id", "ancestors").Find(&depts).Error; err != nil {
+		return scope, errors.Wrap(err, "failed to get departments")
+	}
+
+	for _, dept := range depts {
+		if inDeptSubtrees(dept.DeptId, dept.Ancestors, rootDeptIds) {
+			scope.DeptIds = append(scope.DeptIds, dept.DeptId)
+		}
+	}
+	sort.Ints(scope.DeptIds)
+
+	return scope, nil
+}
+
+// inDeptSubtrees reports whether a department is one of the roots or a descendant of one
+func inDeptSubtrees(deptId int, ancestors string, rootDeptIds []int) bool {
+	if utils.Contains(rootDeptIds, deptId) {
+		return true
+	}
+
+	for _, ancestor := range strings.Split(ancestors, ",") {
+		if id, err := strconv.Atoi(ancestor); err == nil && utils.Contains(rootDeptIds, id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkDelegation validates a delegation before saving
+func checkDelegation(param dto.SaveDelegation) error {
+	switch {
+	case param.DeptId <= 0:
+		return xerrors.ErrDelegationDeptEmpty
+	case param.DelegateType != constant.DELEGATE_TYPE_USER && param.DelegateType != constant.DELEGATE_TYPE_ROLE:
+		return xerrors.ErrDelegationTypeInvalid
+	case param.DelegateId <= 0:
+		return xerrors.ErrDelegationDelegateEmpty
+	default:
+		return nil
+	}
+}
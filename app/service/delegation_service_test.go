@@ -0,0 +1,271 @@
+package service
+
+import (
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seedDelegation() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 101, ParentId: 100, Ancestors: "0,100", DeptName: "Shenzhen"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 103, ParentId: 101, Ancestors: "0,100,101", DeptName: "R&D"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 110, ParentId: 100, Ancestors: "0,100", DeptName: "Beijing"})
+
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 101, UserName: "manager", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 103, UserName: "developer", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 110, UserName: "outsider", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Common", RoleKey: "common", Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 3, RoleName: "Team Lead", RoleKey: "lead", Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 4, RoleName: "Auditor", RoleKey: "auditor", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 3, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 3, MenuId: 2})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 4, MenuId: 3})
+
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 3})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 4, RoleId: 4})
+}
+
+func TestDelegationService_CreateDelegation(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDelegation()
+	s := NewDelegationService()
+
+	t.Run("should create delegation with whitelisted roles", func(t *testing.T) {
+		err := s.CreateDelegation(dto.SaveDelegation{
+			DeptId:       101,
+			DelegateType: constant.DELEGATE_TYPE_USER,
+			DelegateId:   2,
+			CreateBy:     "admin",
+		}, []int{2, 3})
+		assert.NoError(t, err)
+
+		delegations, total := s.GetDelegationList(dto.DelegationListRequest{
+			PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10},
+		}, true)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "Shenzhen", delegations[0].DeptName)
+		assert.Equal(t, "manager", delegations[0].DelegateName)
+
+		delegation := s.GetDelegationByDelegationId(delegations[0].DelegationId)
+		assert.ElementsMatch(t, []int{2, 3}, delegation.RoleIds)
+	})
+
+	t.Run("should return error when delegate type is invalid", func(t *testing.T) {
+		err := s.CreateDelegation(dto.SaveDelegation{DeptId: 101, DelegateType: "dept", DelegateId: 2}, nil)
+		assert.Equal(t, xerrors.ErrDelegationTypeInvalid, err)
+	})
+}
+
+func TestDelegationService_UpdateDelegation(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDelegation()
+	s := NewDelegationService()
+
+	assert.NoError(t, s.CreateDelegation(dto.SaveDelegation{DeptId: 101, DelegateType: constant.DELEGATE_TYPE_USER, DelegateId: 2}, []int{2, 3}))
+	delegations, _ := s.GetDelegationList(dto.DelegationListRequest{}, false)
+	delegationId := delegations[0].DelegationId
+
+	t.Run("should replace whitelisted roles", func(t *testing.T) {
+		err := s.UpdateDelegation(dto.SaveDelegation{
+			DelegationId: delegationId,
+			DeptId:       103,
+			DelegateType: constant.DELEGATE_TYPE_ROLE,
+			DelegateId:   3,
+		}, []int{2})
+		assert.NoError(t, err)
+
+		delegation := s.GetDelegationByDelegationId(delegationId)
+		assert.Equal(t, 103, delegation.DeptId)
+		assert.Equal(t, constant.DELEGATE_TYPE_ROLE, delegation.DelegateType)
+		assert.Equal(t, []int{2}, delegation.RoleIds)
+	})
+
+	t.Run("should delete delegation with its roles", func(t *testing.T) {
+		assert.NoError(t, s.DeleteDelegation([]int{delegationId}))
+
+		var count int64
+		dal.Gorm.Model(model.SysDeptDelegationRole{}).Where("delegation_id = ?", delegationId).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestDelegationService_GetDelegationScope(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDelegation()
+	s := NewDelegationService()
+
+	t.Run("should return empty scope without delegation", func(t *testing.T) {
+		scope, err := s.GetDelegationScope(2)
+		assert.NoError(t, err)
+		assert.Empty(t, scope.DeptIds)
+	})
+
+	assert.NoError(t, s.CreateDelegation(dto.SaveDelegation{DeptId: 101, DelegateType: constant.DELEGATE_TYPE_USER, DelegateId: 2, Status: "0"}, []int{2}))
+	assert.NoError(t, s.CreateDelegation(dto.SaveDelegation{DeptId: 110, DelegateType: constant.DELEGATE_TYPE_ROLE, DelegateId: 3, Status: "0"}, []int{3}))
+	assert.NoError(t, s.CreateDelegation(dto.SaveDelegation{DeptId: 100, DelegateType: constant.DELEGATE_TYPE_USER, DelegateId: 2, Status: "1"}, []int{4}))
+
+	t.Run("should merge user and role delegations with subtrees", func(t *testing.T) {
+		scope, err := s.GetDelegationScope(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{101, 103, 110}, scope.DeptIds)
+		assert.Equal(t, []int{2, 3}, scope.RoleIds)
+	})
+
+	t.Run("should ignore delegations to other users", func(t *testing.T) {
+		scope, err := s.GetDelegationScope(4)
+		assert.NoError(t, err)
+		assert.Empty(t, scope.DeptIds)
+	})
+}
+
+func TestUserService_CheckUserDelegation(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDelegation()
+	s := &UserService{}
+	scope := dto.DelegationScope{DeptIds: []int{101, 103}, RoleIds: []int{2, 3, 4}}
+
+	t.Run("should allow users in delegated departments", func(t *testing.T) {
+		assert.NoError(t, s.CheckUserDelegation(2, scope, 2, 3, 3))
+	})
+
+	t.Run("should reject users outside delegated departments", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrDelegationUserOutOfScope, s.CheckUserDelegation(2, scope, 3, 4))
+	})
+
+	t.Run("should reject super admin", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrDelegationUserOutOfScope, s.CheckUserDelegation(2, dto.DelegationScope{DeptIds: []int{100}}, 1))
+	})
+
+	t.Run("should reject users holding roles outside the whitelist", func(t *testing.T) {
+		narrow := dto.DelegationScope{DeptIds: []int{101, 103}, RoleIds: []int{2}}
+		assert.Equal(t, xerrors.ErrDelegationUserPrivileged, s.CheckUserDelegation(2, narrow, 2))
+	})
+
+	t.Run("should reject users holding permissions the operator lacks", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 4})
+		defer dal.Gorm.Where("user_id = ? AND role_id = ?", 3, 4).Delete(&model.SysUserRole{})
+
+		assert.Equal(t, xerrors.ErrDelegationUserPrivileged, s.CheckUserDelegation(2, scope, 3))
+	})
+
+	t.Run("should reject users holding roles through groups", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysUserGroup{GroupId: 1, GroupName: "Auditors", Status: constant.NORMAL_STATUS})
+		dal.Gorm.Create(&model.SysUserGroupRole{GroupId: 1, RoleId: 4})
+		dal.Gorm.Create(&model.SysUserGroupUser{GroupId: 1, UserId: 3})
+
+		assert.Equal(t, xerrors.ErrDelegationUserPrivileged, s.CheckUserDelegation(2, scope, 3))
+	})
+}
+
+func TestUserService_CheckDelegatedUser(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDelegation()
+	s := &UserService{}
+	scope := dto.DelegationScope{DeptIds: []int{101, 103}, RoleIds: []int{2, 3, 4}}
+
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "audit", PostName: "Audit", Status: "0"})
+	dal.Gorm.Create(&model.SysPostRole{PostId: 1, RoleId: 4})
+
+	tests := []struct {
+		name    string
+		scope   *dto.DelegationScope
+		userId  int
+		deptId  int
+		roleIds []int
+		postIds []int
+		err     error
+	}{
+		{"should not check changes authorized by the global permission", nil, 4, 110, []int{4}, nil, nil},
+		{"should allow whitelisted roles in delegated departments", &scope, 0, 103, []int{2}, nil, nil},
+		{"should keep the department of the user", &scope, 3, 0, []int{2}, nil, nil},
+		{"should reject users outside delegated departments", &scope, 4, 0, nil, nil, xerrors.ErrDelegationUserOutOfScope},
+		{"should reject departments outside the delegation", &scope, 0, 110, nil, nil, xerrors.ErrDelegationDeptOutOfScope},
+		{"should reject roles outside the whitelist", &dto.DelegationScope{DeptIds: []int{103}, RoleIds: []int{2}}, 0, 103, []int{3}, nil, xerrors.ErrDelegationRoleNotAllowed},
+		{"should reject roles the operator could not grant", &scope, 0, 103, []int{4}, nil, xerrors.ErrDelegationRoleEscalation},
+		{"should reject posts granting roles the operator could not grant", &scope, 3, 0, nil, []int{1}, xerrors.ErrDelegationRoleEscalation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, s.CheckDelegatedUser(2, tt.scope, tt.userId, tt.deptId, tt.roleIds, tt.postIds))
+		})
+	}
+
+	t.Run("should confine creating and updating users", func(t *testing.T) {
+		err := s.CreateUser(dto.SaveUser{DeptId: 110, UserName: "intruder", NickName: "intruder", Password: "secret", OperatorId: 2, Delegation: &scope}, nil, nil)
+		assert.Equal(t, xerrors.ErrDelegationDeptOutOfScope, err)
+		assert.Zero(t, s.GetUserByUsername("intruder").UserId)
+
+		err = s.UpdateUser(dto.SaveUser{UserId: 4, Status: "1", OperatorId: 2, Delegation: &scope}, nil, nil)
+		assert.Equal(t, xerrors.ErrDelegationUserOutOfScope, err)
+		assert.Equal(t, "0", s.GetUserByUserId(4).Status)
+	})
+}
+
+func TestRoleService_CheckRolesGrantable(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDelegation()
+	s := &RoleService{}
+
+	t.Run("should allow roles within operator menus", func(t *testing.T) {
+		assert.NoError(t, s.CheckRolesGrantable(2, []int{2, 3}))
+	})
+
+	t.Run("should reject roles with menus operator lacks", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrDelegationRoleEscalation, s.CheckRolesGrantable(2, []int{4}))
+	})
+
+	t.Run("should reject super admin role", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrDelegationRoleEscalation, s.CheckRolesGrantable(2, []int{1}))
+	})
+
+	t.Run("should allow super admin operator", func(t *testing.T) {
+		assert.NoError(t, s.CheckRolesGrantable(1, []int{1, 4}))
+	})
+	t.Run("should check data scopes against the operator's", func(t *testing.T) {
+		dal.Gorm.Model(&model.SysRole{}).Where("role_id = ?", 3).Update("data_scope", DATA_SCOPE_DEPT_SUB)
+		dal.Gorm.Create(&model.SysRole{RoleId: 5, RoleName: "Viewer", RoleKey: "viewer", DataScope: DATA_SCOPE_DEPT, Status: "0"})
+		dal.Gorm.Create(&model.SysRole{RoleId: 6, RoleName: "Global Viewer", RoleKey: "global", DataScope: DATA_SCOPE_ALL, Status: "0"})
+		dal.Gorm.Create(&model.SysRoleMenu{RoleId: 5, MenuId: 1})
+		dal.Gorm.Create(&model.SysRoleMenu{RoleId: 6, MenuId: 1})
+
+		assert.NoError(t, s.CheckRolesGrantable(2, []int{5}))
+		assert.Equal(t, xerrors.ErrDelegationRoleEscalation, s.CheckRolesGrantable(2, []int{6}))
+	})
+
+	t.Run("should check custom departments against the operator's reach", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysRole{RoleId: 7, RoleName: "R&D Viewer", RoleKey: "rd", DataScope: DATA_SCOPE_CUSTOM, Status: "0"})
+		dal.Gorm.Create(&model.SysRole{RoleId: 8, RoleName: "Beijing Viewer", RoleKey: "bj", DataScope: DATA_SCOPE_CUSTOM, Status: "0"})
+		dal.Gorm.Create(&model.SysRoleMenu{RoleId: 7, MenuId: 1})
+		dal.Gorm.Create(&model.SysRoleMenu{RoleId: 8, MenuId: 1})
+		dal.Gorm.Create(&model.SysRoleDept{RoleId: 7, DeptId: 103})
+		dal.Gorm.Create(&model.SysRoleDept{RoleId: 8, DeptId: 110})
+
+		assert.NoError(t, s.CheckRolesGrantable(2, []int{7}))
+		assert.Equal(t, xerrors.ErrDelegationRoleEscalation, s.CheckRolesGrantable(2, []int{8}))
+
+		dal.Gorm.Model(&model.SysRole{}).Where("role_id = ?", 3).Update("data_scope", DATA_SCOPE_PERSONAL)
+		assert.Equal(t, xerrors.ErrDelegationRoleEscalation, s.CheckRolesGrantable(2, []int{7}))
+
+		dal.Gorm.Create(&model.SysDeptDelegation{DeptId: 101, DelegateType: constant.DELEGATE_TYPE_USER, DelegateId: 2, Status: "0"})
+		assert.NoError(t, s.CheckRolesGrantable(2, []int{7}))
+		assert.Equal(t, xerrors.ErrDelegationRoleEscalation, s.CheckRolesGrantable(2, []int{8}))
+	})
+}
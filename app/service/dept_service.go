@@ -1,19 +1,31 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
 	"mira/anima/dal"
+	"mira/anima/datetime"
 	"mira/app/dto"
 	"mira/app/model"
 	"mira/common/types/constant"
+	"mira/common/types/redis-key"
+	"mira/common/xerrors"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // DeptServiceInterface defines operations for department management
 type DeptServiceInterface interface {
 	CreateDept(param dto.SaveDept) error
 	UpdateDept(param dto.SaveDept) error
-	DeleteDept(deptId int) error
+	DeleteDept(deptId int, deleteBy string) error
 	GetDeptList(param dto.DeptListRequest, userId int) []dto.DeptListResponse
 	GetDeptByDeptId(deptId int) dto.DeptDetailResponse
 	GetDeptByDeptName(deptName string) dto.DeptDetailResponse
@@ -22,6 +34,9 @@ type DeptServiceInterface interface {
 	DeptSelect() []dto.SeleteTree
 	DeptSeleteToTree(depts []dto.SeleteTree, parentId int) []dto.SeleteTree
 	DeptHasChildren(deptId int) bool
+	MoveDept(deptId, parentId int, updateBy string) error
+	RepairDeptHierarchy(dryRun bool) (dto.DeptHierarchyRepairResponse, error)
+	MergeDept(sourceDeptId, targetDeptId int, dryRun bool, operLog dto.SaveOperLogRequest) (dto.DeptMergeResponse, error)
 }
 
 // DeptService implements the department management interface
@@ -38,7 +53,7 @@ var _ DeptServiceInterface = (*DeptService)(nil)
 // Returns:
 //   - error: Any error that occurred during creation, or nil on success
 func (s *DeptService) CreateDept(param dto.SaveDept) error {
-	err := dal.Gorm.Model(model.SysDept{}).Create(&model.SysDept{
+	dept := model.SysDept{
 		ParentId:  param.ParentId,
 		Ancestors: param.Ancestors,
 		DeptName:  param.DeptName,
@@ -48,25 +63,130 @@ func (s *DeptService) CreateDept(param dto.SaveDept) error {
 		Email:     param.Email,
 		Status:    param.Status,
 		CreateBy:  param.CreateBy,
-	}).Error
-	if err != nil {
+	}
+	if err := dal.Gorm.Model(model.SysDept{}).Create(&dept).Error; err != nil {
 		return errors.Wrap(err, "failed to create department")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_DEPT, dept.DeptId)
+
+	publishCacheIds(constant.CACHE_EVENT_DEPT, dept.DeptId)
+
 	return nil
 }
 
 // UpdateDept updates an existing department
 //
+// Changing the parent moves the department with its subtree, rewriting the ancestors of
+// every descendant. Disabling a department disables its descendants, and a department
+// cannot be enabled under a disabled parent. All changes run in a single transaction.
+//
 // Parameters:
 //   - param: Department data transfer object containing fields to update
 //
 // Returns:
 //   - error: Any error that occurred during update, or nil on success
 func (s *DeptService) UpdateDept(param dto.SaveDept) error {
-	err := dal.Gorm.Model(model.SysDept{}).Where("dept_id = ?", param.DeptId).Updates(&model.SysDept{
+	// Departments covering the department before the change may not cover it after
+	tags := deptCacheTags(param.DeptId)
+
+	tx := dal.Gorm.Begin()
+
+	if err := s.updateDept(tx, param); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	invalidateDeptCaches(append(tags, deptCacheTags(param.DeptId)...))
+	notifySearch(constant.SEARCH_TYPE_DEPT, param.DeptId)
+	publishCacheIds(constant.CACHE_EVENT_DEPT, param.DeptId)
+
+	return nil
+}
+
+// MoveDept moves a department with its subtree under a new parent
+//
+// Parameters:
+//   - deptId: Department ID to move
+//   - parentId: ID of the new parent department
+//   - updateBy: Account of the operator
+//
+// Returns:
+//   - error: xerrors.ErrDeptMoveCycle if the new parent is in the subtree, or any other error
+func (s *DeptService) MoveDept(deptId, parentId int, updateBy string) error {
+	if parentId <= 0 {
+		return xerrors.ErrParentDeptEmpty
+	}
+
+	return s.UpdateDept(dto.SaveDept{
+		DeptId:   deptId,
+		ParentId: parentId,
+		UpdateBy: updateBy,
+	})
+}
+
+// updateDept applies a department update within a transaction. The departments are read
+// with row locks, so that concurrent moves and merges are serialised and cannot build a
+// cycle from hierarchies each checked before the other changed it.
+func (s *DeptService) updateDept(tx *gorm.DB, param dto.SaveDept) error {
+	depts := make([]model.SysDept, 0)
+	if err := lockDepts(tx).Find(&depts).Error; err != nil {
+		return errors.Wrap(err, "failed to load departments")
+	}
+
+	deptMap := make(map[int]model.SysDept, len(depts))
+	for _, dept := range depts {
+		deptMap[dept.DeptId] = dept
+	}
+
+	dept, ok := deptMap[param.DeptId]
+	if !ok {
+		return xerrors.ErrDeptNotFound
+	}
+
+	subtree := deptSubtree(depts, dept.DeptId)
+
+	// A zero parent keeps the current parent
+	moved := param.ParentId > 0 && param.ParentId != dept.ParentId
+	parentId := dept.ParentId
+	if moved {
+		if param.ParentId == dept.DeptId {
+			return xerrors.ErrDeptParentSelf
+		}
+		for _, child := range subtree {
+			if child.DeptId == param.ParentId {
+				return xerrors.ErrDeptMoveCycle
+			}
+		}
+		parentId = param.ParentId
+	}
+
+	parent, hasParent := deptMap[parentId]
+	if moved && !hasParent {
+		return xerrors.ErrDeptNotFound
+	}
+
+	status := param.Status
+	if status == "" {
+		status = dept.Status
+	}
+	enabling := param.Status == constant.NORMAL_STATUS && dept.Status != constant.NORMAL_STATUS
+	if hasParent && parent.Status != constant.NORMAL_STATUS && status == constant.NORMAL_STATUS && (enabling || moved) {
+		return xerrors.ErrDeptParentDisabled
+	}
+
+	ancestors := param.Ancestors
+	if moved {
+		ancestors = parent.Ancestors + "," + strconv.Itoa(parent.DeptId)
+	}
+
+	if err := tx.Model(model.SysDept{}).Where("dept_id = ?", param.DeptId).Updates(&model.SysDept{
 		ParentId:  param.ParentId,
-		Ancestors: param.Ancestors,
+		Ancestors: ancestors,
 		DeptName:  param.DeptName,
 		OrderNum:  param.OrderNum,
 		Leader:    param.Leader,
@@ -74,27 +194,432 @@ func (s *DeptService) UpdateDept(param dto.SaveDept) error {
 		Email:     param.Email,
 		Status:    param.Status,
 		UpdateBy:  param.UpdateBy,
-	}).Error
-	if err != nil {
+	}).Error; err != nil {
 		return errors.Wrapf(err, "failed to update department with ID %d", param.DeptId)
 	}
 
+	if moved {
+		// Descendants are ordered parents first, so each parent is rewritten before its children
+		ancestorsMap := map[int]string{dept.DeptId: ancestors}
+		for _, child := range subtree {
+			childAncestors := ancestorsMap[child.ParentId] + "," + strconv.Itoa(child.ParentId)
+			ancestorsMap[child.DeptId] = childAncestors
+			if err := tx.Model(model.SysDept{}).Where("dept_id = ?", child.DeptId).Update("ancestors", childAncestors).Error; err != nil {
+				return errors.Wrapf(err, "failed to update ancestors of department ID %d", child.DeptId)
+			}
+		}
+	}
+
+	if param.Status == constant.EXCEPTION_STATUS && len(subtree) > 0 {
+		childIds := make([]int, 0, len(subtree))
+		for _, child := range subtree {
+			childIds = append(childIds, child.DeptId)
+		}
+		if err := tx.Model(model.SysDept{}).Where("dept_id IN ?", childIds).Updates(&model.SysDept{
+			Status:   constant.EXCEPTION_STATUS,
+			UpdateBy: param.UpdateBy,
+		}).Error; err != nil {
+			return errors.Wrapf(err, "failed to disable sub-departments of department ID %d", param.DeptId)
+		}
+	}
+
 	return nil
 }
 
-// DeleteDept deletes a department by its ID
+// MergeDept merges a department into another one
+//
+// Users, custom data scope grants and delegations of the source department are transferred
+// to the target, its sub-departments are re-parented under the target, and the source is then
+// soft-deleted. The merge runs in a single transaction recorded in the operation log. A dry run
+// performs the same steps and rolls them back, reporting what would change.
+//
+// Parameters:
+//   - sourceDeptId: ID of the department merged away
+//   - targetDeptId: ID of the department receiving the source's content
+//   - dryRun: Only report the changes without applying them
+//   - operLog: Operator details of the operation log entry written for the merge
+//
+// Returns:
+//   - dto.DeptMergeResponse: The users, roles, sub-departments and delegations changed
+//   - error: Any error that occurred during the merge, or nil on success
+func (s *DeptService) MergeDept(sourceDeptId, targetDeptId int, dryRun bool, operLog dto.SaveOperLogRequest) (dto.DeptMergeResponse, error) {
+	result := dto.DeptMergeResponse{
+		DryRun:        dryRun,
+		SourceDeptId:  sourceDeptId,
+		TargetDeptId:  targetDeptId,
+		UserIds:       make([]int, 0),
+		RoleIds:       make([]int, 0),
+		ChildDeptIds:  make([]int, 0),
+		DelegationIds: make([]int, 0),
+	}
+
+	if sourceDeptId == targetDeptId {
+		return result, xerrors.ErrDeptMergeSelf
+	}
+
+	tags := make([]string, 0)
+	if !dryRun {
+		tags = deptCacheTags(sourceDeptId, targetDeptId)
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := s.mergeDept(tx, &result, operLog.OperName); err != nil {
+		tx.Rollback()
+		return result, err
+	}
+
+	if dryRun {
+		tx.Rollback()
+		return result, nil
+	}
+
+	operParam, _ := json.Marshal(dto.MergeDeptRequest{SourceDeptId: sourceDeptId, TargetDeptId: targetDeptId})
+	jsonResult, _ := json.Marshal(result)
+
+	operLog.OperParam = string(operParam)
+	operLog.JsonResult = string(jsonResult)
+	operLog.Status = constant.NORMAL_STATUS
+	operLog.OperTime = datetime.Datetime{Time: time.Now()}
+
+	if err := tx.Model(model.SysOperLog{}).Create(&model.SysOperLog{
+		Title:         operLog.Title,
+		BusinessType:  operLog.BusinessType,
+		Method:        operLog.Method,
+		RequestMethod: operLog.RequestMethod,
+		OperName:      operLog.OperName,
+		DeptName:      operLog.DeptName,
+		OperUrl:       operLog.OperUrl,
+		OperIp:        operLog.OperIp,
+		OperLocation:  operLog.OperLocation,
+		OperParam:     operLog.OperParam,
+		JsonResult:    operLog.JsonResult,
+		Status:        operLog.Status,
+		OperTime:      operLog.OperTime,
+	}).Error; err != nil {
+		tx.Rollback()
+		return result, errors.Wrap(err, "failed to create operation log record")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return result, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	invalidateDeptCaches(tags)
+	notifySearch(constant.SEARCH_TYPE_DEPT, sourceDeptId)
+	publishCacheIds(constant.CACHE_EVENT_DEPT, sourceDeptId)
+
+	return result, nil
+}
+
+// mergeDept applies a department merge within a transaction, filling in the result
+func (s *DeptService) mergeDept(tx *gorm.DB, result *dto.DeptMergeResponse, updateBy string) error {
+	var source, target model.SysDept
+
+	if err := tx.Model(model.SysDept{}).Where("dept_id = ?", result.SourceDeptId).Limit(1).Find(&source).Error; err != nil {
+		return errors.Wrapf(err, "failed to get department by ID %d", result.SourceDeptId)
+	}
+	if err := tx.Model(model.SysDept{}).Where("dept_id = ?", result.TargetDeptId).Limit(1).Find(&target).Error; err != nil {
+		return errors.Wrapf(err, "failed to get department by ID %d", result.TargetDeptId)
+	}
+	if source.DeptId == 0 || target.DeptId == 0 {
+		return xerrors.ErrDeptNotFound
+	}
+	result.SourceDeptName = source.DeptName
+	result.TargetDeptName = target.DeptName
+
+	if source.ParentId == 0 {
+		return xerrors.ErrDeptMergeRoot
+	}
+
+	depts := make([]model.SysDept, 0)
+	if err := lockDepts(tx).Find(&depts).Error; err != nil {
+		return errors.Wrap(err, "failed to load departments")
+	}
+	for _, dept := range deptSubtree(depts, source.DeptId) {
+		if dept.DeptId == target.DeptId {
+			return xerrors.ErrDeptMergeSubtree
+		}
+	}
+
+	// Users
+	if err := tx.Model(model.SysUser{}).Where("dept_id = ?", source.DeptId).Order("user_id").Pluck("user_id", &result.UserIds).Error; err != nil {
+		return errors.Wrap(err, "failed to get users of the department")
+	}
+	if len(result.UserIds) > 0 {
+		if err := tx.Model(model.SysUser{}).Where("user_id IN ?", result.UserIds).Updates(&model.SysUser{
+			DeptId:   target.DeptId,
+			UpdateBy: updateBy,
+		}).Error; err != nil {
+			return errors.Wrap(err, "failed to transfer users")
+		}
+	}
+
+	// Custom data scope grants; roles granted both departments keep the target grant only
+	if err := tx.Model(model.SysRoleDept{}).Where("dept_id = ?", source.DeptId).Order("role_id").Pluck("role_id", &result.RoleIds).Error; err != nil {
+		return errors.Wrap(err, "failed to get data scope grants of the department")
+	}
+	if len(result.RoleIds) > 0 {
+		grantedRoleIds := make([]int, 0)
+		if err := tx.Model(model.SysRoleDept{}).Where("dept_id = ? AND role_id IN ?", target.DeptId, result.RoleIds).Pluck("role_id", &grantedRoleIds).Error; err != nil {
+			return errors.Wrap(err, "failed to get data scope grants of the target department")
+		}
+		if len(grantedRoleIds) > 0 {
+			if err := tx.Model(model.SysRoleDept{}).Where("dept_id = ? AND role_id IN ?", source.DeptId, grantedRoleIds).Delete(&model.SysRoleDept{}).Error; err != nil {
+				return errors.Wrap(err, "failed to delete duplicate data scope grants")
+			}
+		}
+		if err := tx.Model(model.SysRoleDept{}).Where("dept_id = ?", source.DeptId).Update("dept_id", target.DeptId).Error; err != nil {
+			return errors.Wrap(err, "failed to re-point data scope grants")
+		}
+	}
+
+	// Delegations
+	if err := tx.Model(model.SysDeptDelegation{}).Where("dept_id = ?", source.DeptId).Order("delegation_id").Pluck("delegation_id", &result.DelegationIds).Error; err != nil {
+		return errors.Wrap(err, "failed to get delegations of the department")
+	}
+	if len(result.DelegationIds) > 0 {
+		if err := tx.Model(model.SysDeptDelegation{}).Where("delegation_id IN ?", result.DelegationIds).Updates(&model.SysDeptDelegation{
+			DeptId:   target.DeptId,
+			UpdateBy: updateBy,
+		}).Error; err != nil {
+			return errors.Wrap(err, "failed to re-point delegations")
+		}
+	}
+
+	// Sub-departments
+	if err := tx.Model(model.SysDept{}).Where("parent_id = ?", source.DeptId).Order("dept_id").Pluck("dept_id", &result.ChildDeptIds).Error; err != nil {
+		return errors.Wrap(err, "failed to get sub-departments")
+	}
+	for _, childId := range result.ChildDeptIds {
+		if err := s.updateDept(tx, dto.SaveDept{
+			DeptId:   childId,
+			ParentId: target.DeptId,
+			UpdateBy: updateBy,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := recycleDepts(tx, []int{source.DeptId}, updateBy); err != nil {
+		return err
+	}
+
+	if err := tx.Model(model.SysDept{}).Where("dept_id = ?", source.DeptId).Delete(&model.SysDept{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete department with ID %d", source.DeptId)
+	}
+
+	return nil
+}
+
+// RepairDeptHierarchy recomputes the ancestors of every department from parent_id
+//
+// Departments whose parent does not exist or whose parent chain forms a cycle are reported
+// but left untouched, since their ancestors cannot be determined.
+//
+// Parameters:
+//   - dryRun: Only report the inconsistencies without repairing them
+//
+// Returns:
+//   - dto.DeptHierarchyRepairResponse: The inconsistencies found and the number repaired
+//   - error: Any error that occurred during the repair, or nil on success
+func (s *DeptService) RepairDeptHierarchy(dryRun bool) (dto.DeptHierarchyRepairResponse, error) {
+	result := dto.DeptHierarchyRepairResponse{
+		DryRun: dryRun,
+		Issues: make([]dto.DeptHierarchyIssue, 0),
+	}
+
+	tx := dal.Gorm.Begin()
+
+	depts := make([]model.SysDept, 0)
+	if err := lockDepts(tx).Order("dept_id").Find(&depts).Error; err != nil {
+		tx.Rollback()
+		return result, errors.Wrap(err, "failed to load departments")
+	}
+	result.Checked = len(depts)
+
+	deptMap := make(map[int]model.SysDept, len(depts))
+	for _, dept := range depts {
+		deptMap[dept.DeptId] = dept
+	}
+
+	for _, dept := range depts {
+		expected, issueType := expectedDeptAncestors(deptMap, dept)
+		if issueType == "" && expected == dept.Ancestors {
+			continue
+		}
+		if issueType == "" {
+			issueType = constant.DEPT_ISSUE_ANCESTORS
+		}
+
+		issue := dto.DeptHierarchyIssue{
+			DeptId:            dept.DeptId,
+			DeptName:          dept.DeptName,
+			ParentId:          dept.ParentId,
+			Type:              issueType,
+			Ancestors:         dept.Ancestors,
+			ExpectedAncestors: expected,
+		}
+
+		if !dryRun && issueType == constant.DEPT_ISSUE_ANCESTORS {
+			if err := tx.Model(model.SysDept{}).Where("dept_id = ?", dept.DeptId).Update("ancestors", expected).Error; err != nil {
+				tx.Rollback()
+				return result, errors.Wrapf(err, "failed to repair ancestors of department ID %d", dept.DeptId)
+			}
+			issue.Repaired = true
+			result.Repaired++
+		}
+
+		result.Issues = append(result.Issues, issue)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return result, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	if result.Repaired > 0 {
+		// The departments listed in the wrong ancestors covered the repaired ones until now
+		deptIds := make([]int, 0)
+		for _, issue := range result.Issues {
+			if !issue.Repaired {
+				continue
+			}
+			deptIds = append(deptIds, issue.DeptId)
+			for _, id := range strings.Split(issue.Ancestors, ",") {
+				if ancestorId, _ := strconv.Atoi(id); ancestorId > 0 {
+					deptIds = append(deptIds, ancestorId)
+				}
+			}
+		}
+		invalidateDeptCaches(deptCacheTags(deptIds...))
+	}
+
+	return result, nil
+}
+
+// invalidateDeptCaches drops the cached department tree and the entries tagged with the
+// departments a hierarchy change affects (see deptCacheTags). The cache is best effort;
+// failures only delay the refresh until expiry.
+func invalidateDeptCaches(tags []string) {
+	ctx := context.Background()
+	cache := NewCacheService()
+
+	cache.Delete(ctx, rediskey.DeptTreeKey())
+	if err := cache.InvalidateTags(ctx, tags...); err != nil {
+		log.Printf("Warning: Failed to drop the cache entries of departments: %v", err)
+	}
+	publishCacheIds(constant.CACHE_EVENT_DEPT)
+}
+
+// lockDepts selects the departments for update, holding the hierarchy until the transaction ends
+func lockDepts(tx *gorm.DB) *gorm.DB {
+	return tx.Model(model.SysDept{}).Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// deptCacheTags returns the tags of the cache entries depending on the departments: the data
+// scopes granted on them or on their parent departments, which cover their subtrees. Only the
+// department hierarchy is read, so the cost follows the number of departments rather than the
+// number of users.
+func deptCacheTags(deptIds ...int) []string {
+	tags := make([]string, 0)
+	if len(deptIds) == 0 {
+		return tags
+	}
+
+	depts := make([]model.SysDept, 0)
+	dal.Gorm.Model(model.SysDept{}).Select("dept_id", "parent_id").Find(&depts)
+
+	parentIds := make(map[int]int, len(depts))
+	for _, dept := range depts {
+		parentIds[dept.DeptId] = dept.ParentId
+	}
+
+	visited := make(map[int]bool)
+	for _, deptId := range deptIds {
+		for id := deptId; id != 0 && !visited[id]; id = parentIds[id] {
+			visited[id] = true
+			tags = append(tags, cacheTagDept(id))
+		}
+	}
+
+	return tags
+}
+
+// expectedDeptAncestors walks up parent_id and returns the ancestors a department should have,
+// or the issue type when the chain is broken
+func expectedDeptAncestors(deptMap map[int]model.SysDept, dept model.SysDept) (string, string) {
+	chain := make([]string, 0)
+	visited := map[int]bool{dept.DeptId: true}
+
+	for parentId := dept.ParentId; parentId != 0; {
+		if visited[parentId] {
+			return "", constant.DEPT_ISSUE_CYCLE
+		}
+		visited[parentId] = true
+
+		parent, ok := deptMap[parentId]
+		if !ok {
+			return "", constant.DEPT_ISSUE_ORPHAN
+		}
+		chain = append(chain, strconv.Itoa(parentId))
+		parentId = parent.ParentId
+	}
+
+	chain = append(chain, "0")
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return strings.Join(chain, ","), ""
+}
+
+// deptSubtree returns the descendants of a department by following parent_id, parents before children
+func deptSubtree(depts []model.SysDept, deptId int) []model.SysDept {
+	subtree := make([]model.SysDept, 0)
+	visited := map[int]bool{deptId: true}
+
+	for queue := []int{deptId}; len(queue) > 0; queue = queue[1:] {
+		for _, dept := range depts {
+			if dept.ParentId == queue[0] && !visited[dept.DeptId] {
+				visited[dept.DeptId] = true
+				subtree = append(subtree, dept)
+				queue = append(queue, dept.DeptId)
+			}
+		}
+	}
+
+	return subtree
+}
+
+// DeleteDept deletes a department by its ID, keeping it in the recycle bin
 //
 // Parameters:
 //   - deptId: Department ID to delete
+//   - deleteBy: Username of the operator deleting the department
 //
 // Returns:
 //   - error: Any error that occurred during deletion, or nil on success
-func (s *DeptService) DeleteDept(deptId int) error {
-	err := dal.Gorm.Model(model.SysDept{}).Where("dept_id = ?", deptId).Delete(&model.SysDept{}).Error
-	if err != nil {
+func (s *DeptService) DeleteDept(deptId int, deleteBy string) error {
+	tx := dal.Gorm.Begin()
+
+	if err := recycleDepts(tx, []int{deptId}, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(model.SysDept{}).Where("dept_id = ?", deptId).Delete(&model.SysDept{}).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrapf(err, "failed to delete department with ID %d", deptId)
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	notifySearch(constant.SEARCH_TYPE_DEPT, deptId)
+
+	publishCacheIds(constant.CACHE_EVENT_DEPT, deptId)
+
 	return nil
 }
 
@@ -6,6 +6,8 @@ import (
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -80,7 +82,7 @@ func TestDeptService_DeleteDept(t *testing.T) {
 		dal.Gorm.Last(&createdDept)
 
 		// Execute
-		err := s.DeleteDept(createdDept.DeptId)
+		err := s.DeleteDept(createdDept.DeptId, "admin")
 		assert.NoError(t, err)
 
 		// Verify
@@ -229,3 +231,191 @@ func TestDeptService_GetDeptList(t *testing.T) {
 		assert.Len(t, depts, 2)
 	})
 }
+
+func seedDeptHierarchy() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ", Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 101, ParentId: 100, Ancestors: "0,100", DeptName: "Shenzhen", Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 102, ParentId: 100, Ancestors: "0,100", DeptName: "Changsha", Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 103, ParentId: 101, Ancestors: "0,100,101", DeptName: "R&D", Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 104, ParentId: 103, Ancestors: "0,100,101,103", DeptName: "Platform", Status: "0"})
+}
+
+func TestDeptService_MoveDept(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDeptHierarchy()
+	s := &DeptService{}
+
+	t.Run("should rewrite ancestors of the subtree", func(t *testing.T) {
+		err := s.MoveDept(103, 102, "admin")
+		assert.NoError(t, err)
+
+		assert.Equal(t, "0,100,102", s.GetDeptByDeptId(103).Ancestors)
+		assert.Equal(t, 102, s.GetDeptByDeptId(103).ParentId)
+		assert.Equal(t, "0,100,102,103", s.GetDeptByDeptId(104).Ancestors)
+	})
+
+	t.Run("should refuse moving under own descendant", func(t *testing.T) {
+		err := s.MoveDept(102, 104, "admin")
+		assert.Equal(t, xerrors.ErrDeptMoveCycle, err)
+		assert.Equal(t, 100, s.GetDeptByDeptId(102).ParentId)
+	})
+
+	t.Run("should refuse moving under missing department", func(t *testing.T) {
+		err := s.MoveDept(103, 999, "admin")
+		assert.Equal(t, xerrors.ErrDeptNotFound, err)
+	})
+
+	t.Run("should read the hierarchy for update", func(t *testing.T) {
+		tx := lockDepts(dal.Gorm.Session(&gorm.Session{DryRun: true})).Find(&[]model.SysDept{})
+		assert.Contains(t, tx.Statement.Clauses, "FOR")
+	})
+}
+
+func TestDeptCacheTags(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDeptHierarchy()
+
+	t.Run("should tag the departments and their parents", func(t *testing.T) {
+		assert.Equal(t, []string{"dept:104", "dept:103", "dept:101", "dept:100"}, deptCacheTags(104))
+	})
+
+	t.Run("should tag shared parents once", func(t *testing.T) {
+		assert.Equal(t, []string{"dept:103", "dept:101", "dept:100", "dept:102"}, deptCacheTags(103, 102))
+	})
+
+	t.Run("should return no tags without departments", func(t *testing.T) {
+		assert.Empty(t, deptCacheTags())
+	})
+}
+
+func TestDeptService_UpdateDeptStatus(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDeptHierarchy()
+	s := &DeptService{}
+
+	t.Run("should cascade disabling to sub-departments", func(t *testing.T) {
+		err := s.UpdateDept(dto.SaveDept{DeptId: 101, Status: constant.EXCEPTION_STATUS})
+		assert.NoError(t, err)
+
+		assert.Equal(t, constant.EXCEPTION_STATUS, s.GetDeptByDeptId(103).Status)
+		assert.Equal(t, constant.EXCEPTION_STATUS, s.GetDeptByDeptId(104).Status)
+		assert.Equal(t, constant.NORMAL_STATUS, s.GetDeptByDeptId(102).Status)
+	})
+
+	t.Run("should refuse enabling under a disabled parent", func(t *testing.T) {
+		err := s.UpdateDept(dto.SaveDept{DeptId: 103, Status: constant.NORMAL_STATUS})
+		assert.Equal(t, xerrors.ErrDeptParentDisabled, err)
+		assert.Equal(t, constant.EXCEPTION_STATUS, s.GetDeptByDeptId(103).Status)
+	})
+
+	t.Run("should refuse moving an enabled department under a disabled parent", func(t *testing.T) {
+		err := s.MoveDept(102, 101, "admin")
+		assert.Equal(t, xerrors.ErrDeptParentDisabled, err)
+	})
+}
+
+func TestDeptService_RepairDeptHierarchy(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDeptHierarchy()
+	dal.Gorm.Model(model.SysDept{}).Where("dept_id = ?", 104).Update("ancestors", "0,100")
+	dal.Gorm.Create(&model.SysDept{DeptId: 105, ParentId: 999, Ancestors: "0,999", DeptName: "Orphan"})
+	s := &DeptService{}
+
+	t.Run("should report without repairing in dry run", func(t *testing.T) {
+		result, err := s.RepairDeptHierarchy(true)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, result.Checked)
+		assert.Equal(t, 0, result.Repaired)
+		assert.Len(t, result.Issues, 2)
+		assert.Equal(t, constant.DEPT_ISSUE_ANCESTORS, result.Issues[0].Type)
+		assert.Equal(t, "0,100,101,103", result.Issues[0].ExpectedAncestors)
+		assert.Equal(t, constant.DEPT_ISSUE_ORPHAN, result.Issues[1].Type)
+		assert.Equal(t, "0,100", s.GetDeptByDeptId(104).Ancestors)
+	})
+
+	t.Run("should repair ancestors from parent relation", func(t *testing.T) {
+		result, err := s.RepairDeptHierarchy(false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Repaired)
+		assert.Equal(t, "0,100,101,103", s.GetDeptByDeptId(104).Ancestors)
+
+		result, err = s.RepairDeptHierarchy(true)
+		assert.NoError(t, err)
+		assert.Len(t, result.Issues, 1)
+	})
+}
+
+func TestDeptService_MergeDept(t *testing.T) {
+	setup()
+	defer teardown()
+	seedDeptHierarchy()
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 101, UserName: "alice"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 101, UserName: "bob"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 102, UserName: "carol"})
+	dal.Gorm.Create(&model.SysRoleDept{RoleId: 2, DeptId: 101})
+	dal.Gorm.Create(&model.SysRoleDept{RoleId: 3, DeptId: 101})
+	dal.Gorm.Create(&model.SysRoleDept{RoleId: 3, DeptId: 102})
+	dal.Gorm.Create(&model.SysDeptDelegation{DelegationId: 1, DeptId: 101, DelegateType: constant.DELEGATE_TYPE_USER, DelegateId: 2})
+	s := &DeptService{}
+	operLog := dto.SaveOperLogRequest{Title: "Merge Department", OperName: "admin"}
+
+	t.Run("should report changes without applying them in dry run", func(t *testing.T) {
+		result, err := s.MergeDept(101, 102, true, operLog)
+		assert.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, "Shenzhen", result.SourceDeptName)
+		assert.Equal(t, []int{2, 3}, result.UserIds)
+		assert.Equal(t, []int{2, 3}, result.RoleIds)
+		assert.Equal(t, []int{103}, result.ChildDeptIds)
+		assert.Equal(t, []int{1}, result.DelegationIds)
+
+		assert.Equal(t, "Shenzhen", s.GetDeptByDeptId(101).DeptName)
+		assert.Equal(t, 101, s.GetDeptByDeptId(103).ParentId)
+
+		var count int64
+		dal.Gorm.Model(model.SysOperLog{}).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("should refuse merging into own sub-department", func(t *testing.T) {
+		_, err := s.MergeDept(101, 104, false, operLog)
+		assert.Equal(t, xerrors.ErrDeptMergeSubtree, err)
+	})
+
+	t.Run("should refuse merging the top-level department", func(t *testing.T) {
+		_, err := s.MergeDept(100, 102, false, operLog)
+		assert.Equal(t, xerrors.ErrDeptMergeRoot, err)
+	})
+
+	t.Run("should merge department in a transaction", func(t *testing.T) {
+		_, err := s.MergeDept(101, 102, false, operLog)
+		assert.NoError(t, err)
+
+		var users []model.SysUser
+		dal.Gorm.Where("dept_id = ?", 102).Find(&users)
+		assert.Len(t, users, 3)
+
+		var roleDepts []model.SysRoleDept
+		dal.Gorm.Order("role_id").Find(&roleDepts)
+		assert.Equal(t, []model.SysRoleDept{{RoleId: 2, DeptId: 102}, {RoleId: 3, DeptId: 102}}, roleDepts)
+
+		assert.Equal(t, 102, s.GetDeptByDeptId(103).ParentId)
+		assert.Equal(t, "0,100,102,103", s.GetDeptByDeptId(104).Ancestors)
+
+		var delegation model.SysDeptDelegation
+		dal.Gorm.First(&delegation, 1)
+		assert.Equal(t, 102, delegation.DeptId)
+
+		err = dal.Gorm.First(&model.SysDept{}, 101).Error
+		assert.Equal(t, gorm.ErrRecordNotFound, err)
+
+		var operLogs []model.SysOperLog
+		dal.Gorm.Find(&operLogs)
+		assert.Len(t, operLogs, 1)
+		assert.Contains(t, operLogs[0].JsonResult, `"userIds":[2,3]`)
+	})
+}
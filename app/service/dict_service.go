@@ -2,7 +2,8 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"log"
+	"time"
 
 	"mira/anima/dal"
 	"mira/app/dto"
@@ -10,6 +11,7 @@ import (
 	"mira/common/types/constant"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 
 	rediskey "mira/common/types/redis-key"
 )
@@ -18,8 +20,9 @@ import (
 type DictTypeServiceInterface interface {
 	CreateDictType(param dto.SaveDictType) error
 	UpdateDictType(param dto.SaveDictType) error
-	DeleteDictType(dictIds []int) error
+	DeleteDictType(dictIds []int, deleteBy string) error
 	GetDictTypeList(param dto.DictTypeListRequest, isPaging bool) ([]dto.DictTypeListResponse, int)
+	ExportDataset(param dto.DictTypeListRequest) ExportDataset
 	GetDictTypeByDictId(dictId int) dto.DictTypeDetailResponse
 	GetDcitTypeByDictType(dictType string) dto.DictTypeDetailResponse
 	RefreshCache() error
@@ -50,6 +53,8 @@ func (s *DictTypeService) CreateDictType(param dto.SaveDictType) error {
 		return errors.Wrap(err, "failed to create dictionary type")
 	}
 
+	refreshDictCache(param.DictType)
+
 	return nil
 }
 
@@ -72,26 +77,43 @@ func (s *DictTypeService) UpdateDictType(param dto.SaveDictType) error {
 		return errors.Wrapf(err, "failed to update dictionary type with ID %d", param.DictId)
 	}
 
+	// The type may have been renamed
+	refreshDictCache()
+
 	return nil
 }
 
-// DeleteDictType deletes dictionary types by their IDs
+// DeleteDictType deletes dictionary types by their IDs, keeping a snapshot in the recycle bin
 //
 // Parameters:
 //   - dictIds: Array of dictionary type IDs to delete
+//   - deleteBy: Username of the operator deleting the dictionary types
 //
 // Returns:
 //   - error: Any error that occurred during deletion, or nil on success
-func (s *DictTypeService) DeleteDictType(dictIds []int) error {
+func (s *DictTypeService) DeleteDictType(dictIds []int, deleteBy string) error {
 	if len(dictIds) == 0 {
 		return errors.New("no dictionary type IDs provided for deletion")
 	}
 
-	err := dal.Gorm.Model(model.SysDictType{}).Where("dict_id IN ?", dictIds).Delete(&model.SysDictType{}).Error
-	if err != nil {
+	tx := dal.Gorm.Begin()
+
+	if err := recycleDictTypes(tx, dictIds, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(model.SysDictType{}).Where("dict_id IN ?", dictIds).Delete(&model.SysDictType{}).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "failed to delete dictionary types")
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	refreshDictCache()
+
 	return nil
 }
 
@@ -126,7 +148,25 @@ func (s *DictTypeService) GetDictTypeListWithErr(param dto.DictTypeListRequest,
 	var count int64
 	dictTypes := make([]dto.DictTypeListResponse, 0)
 
-	query := dal.Gorm.Model(model.SysDictType{}).Order("dict_id")
+	query := dictTypeListQuery(param).Order("dict_id")
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count dictionary types")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&dictTypes).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to query dictionary types")
+	}
+
+	return dictTypes, int(count), nil
+}
+
+// dictTypeListQuery builds the dictionary type query with the search conditions applied
+func dictTypeListQuery(param dto.DictTypeListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysDictType{})
 
 	if param.DictName != "" {
 		query = query.Where("dict_name LIKE ?", "%"+param.DictName+"%")
@@ -144,18 +184,27 @@ func (s *DictTypeService) GetDictTypeListWithErr(param dto.DictTypeListRequest,
 		query = query.Where("create_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
 	}
 
-	if isPaging {
-		if err := query.Count(&count).Error; err != nil {
-			return nil, 0, errors.Wrap(err, "failed to count dictionary types")
-		}
-		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
-	}
+	return query
+}
 
-	if err := query.Find(&dictTypes).Error; err != nil {
-		return nil, 0, errors.Wrap(err, "failed to query dictionary types")
+// ExportDataset describes the export of the dictionary types matching the search conditions
+func (s *DictTypeService) ExportDataset(param dto.DictTypeListRequest) ExportDataset {
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_DICT_TYPE,
+		Query: func() *gorm.DB {
+			return dictTypeListQuery(param)
+		},
+		KeyField:  "dict_id",
+		KeyColumn: "dict_id",
+		Columns: []ExportColumn{
+			{Key: "dictId", Field: "dict_id", Header: "Dict ID", HeaderZh: "字典主键"},
+			{Key: "dictName", Field: "dict_name", Header: "Dict Name", HeaderZh: "字典名称"},
+			{Key: "dictType", Field: "dict_type", Header: "Dict Type", HeaderZh: "字典类型"},
+			{Key: "status", Field: "status", Header: "Status", HeaderZh: "状态", DictType: "sys_normal_disable", Labels: map[string]string{
+				"0": "Normal", "1": "Disabled",
+			}},
+		},
 	}
-
-	return dictTypes, int(count), nil
 }
 
 // GetDictTypeByDictId gets dictionary type details by ID
@@ -238,18 +287,39 @@ func (s *DictTypeService) GetDcitTypeByDictTypeWithErr(dictType string) (dto.Dic
 //   - error: Any error that occurred during refresh, or nil on success
 func (s *DictTypeService) RefreshCache() error {
 	err := dal.Redis.Del(context.Background(), rediskey.SysDictKey()).Err()
+
+	publishCacheKeys(constant.CACHE_EVENT_DICT)
+
 	if err != nil {
 		return errors.Wrap(err, "failed to refresh dictionary cache")
 	}
 	return nil
 }
 
+// refreshDictCache drops changed dictionary types from Redis, all of them when no type is given,
+// and announces them to the local caches of every instance. The cache is best effort; failures
+// only delay the refresh until expiry.
+func refreshDictCache(dictTypes ...string) {
+	var err error
+	if len(dictTypes) == 0 {
+		err = dal.Redis.Del(context.Background(), rediskey.SysDictKey()).Err()
+	} else {
+		err = dal.Redis.HDel(context.Background(), rediskey.SysDictKey(), dictTypes...).Err()
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to drop dictionary cache: %v", err)
+	}
+
+	publishCacheKeys(constant.CACHE_EVENT_DICT, dictTypes...)
+}
+
 // DictDataServiceInterface defines operations for dictionary data management
 type DictDataServiceInterface interface {
 	CreateDictData(param dto.SaveDictData) error
 	UpdateDictData(param dto.SaveDictData) error
-	DeleteDictData(dictCodes []int) error
+	DeleteDictData(dictCodes []int, deleteBy string) error
 	GetDictDataList(param dto.DictDataListRequest, isPaging bool) ([]dto.DictDataListResponse, int)
+	ExportDataset(param dto.DictDataListRequest) ExportDataset
 	GetDictDataByDictCode(dictCode int) dto.DictDataDetailResponse
 	GetDictDataByDictType(dictType string) []dto.DictDataListResponse
 	GetDictDataCacheByDictType(dictType string) []dto.DictDataListResponse
@@ -269,7 +339,7 @@ var _ DictDataServiceInterface = (*DictDataService)(nil)
 // Returns:
 //   - error: Any error that occurred during creation, or nil on success
 func (s *DictDataService) CreateDictData(param dto.SaveDictData) error {
-	err := dal.Gorm.Model(model.SysDictData{}).Create(&model.SysDictData{
+	dictData := model.SysDictData{
 		DictSort:  param.DictSort,
 		DictLabel: param.DictLabel,
 		DictValue: param.DictValue,
@@ -280,11 +350,32 @@ func (s *DictDataService) CreateDictData(param dto.SaveDictData) error {
 		Status:    param.Status,
 		Remark:    param.Remark,
 		CreateBy:  param.CreateBy,
-	}).Error
-	if err != nil {
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Model(model.SysDictData{}).Create(&dictData).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "failed to create dictionary data")
 	}
 
+	// Read back the defaults the database filled in
+	if err := tx.Where("dict_code = ?", dictData.DictCode).Take(&dictData).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to retrieve dictionary data")
+	}
+
+	if err := recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_DICT_DATA, dictData.DictCode, dictDataHistoryName(dictData), constant.CONFIG_HISTORY_ACTION_CREATE, nil, dictDataSnapshot(dictData), param.Reason, param.CreateBy, 0); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	refreshDictCache(dictData.DictType)
+
 	return nil
 }
 
@@ -296,7 +387,15 @@ func (s *DictDataService) CreateDictData(param dto.SaveDictData) error {
 // Returns:
 //   - error: Any error that occurred during update, or nil on success
 func (s *DictDataService) UpdateDictData(param dto.SaveDictData) error {
-	err := dal.Gorm.Model(model.SysDictData{}).Where("dict_code = ?", param.DictCode).Updates(&model.SysDictData{
+	tx := dal.Gorm.Begin()
+
+	var before model.SysDictData
+	if err := tx.Where("dict_code = ?", param.DictCode).Limit(1).Find(&before).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrapf(err, "failed to retrieve dictionary data with code %d", param.DictCode)
+	}
+
+	err := tx.Model(model.SysDictData{}).Where("dict_code = ?", param.DictCode).Updates(&model.SysDictData{
 		DictSort:  param.DictSort,
 		DictLabel: param.DictLabel,
 		DictValue: param.DictValue,
@@ -309,29 +408,80 @@ func (s *DictDataService) UpdateDictData(param dto.SaveDictData) error {
 		UpdateBy:  param.UpdateBy,
 	}).Error
 	if err != nil {
+		tx.Rollback()
 		return errors.Wrapf(err, "failed to update dictionary data with code %d", param.DictCode)
 	}
 
+	dictTypes := []string{param.DictType}
+	if before.DictCode > 0 {
+		var after model.SysDictData
+		if err = tx.Where("dict_code = ?", param.DictCode).Take(&after).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "failed to retrieve dictionary data with code %d", param.DictCode)
+		}
+		if err = recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_DICT_DATA, after.DictCode, dictDataHistoryName(after), constant.CONFIG_HISTORY_ACTION_UPDATE, dictDataSnapshot(before), dictDataSnapshot(after), param.Reason, param.UpdateBy, 0); err != nil {
+			tx.Rollback()
+			return err
+		}
+		dictTypes = []string{before.DictType, after.DictType}
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	refreshDictCache(dictTypes...)
+
 	return nil
 }
 
-// DeleteDictData deletes dictionary data entries by their codes
+// DeleteDictData deletes dictionary data entries by their codes, keeping a snapshot in the recycle bin
+// and recording the deletion in their change history
 //
 // Parameters:
 //   - dictCodes: Array of dictionary data codes to delete
+//   - deleteBy: Username of the operator deleting the dictionary data
 //
 // Returns:
 //   - error: Any error that occurred during deletion, or nil on success
-func (s *DictDataService) DeleteDictData(dictCodes []int) error {
+func (s *DictDataService) DeleteDictData(dictCodes []int, deleteBy string) error {
 	if len(dictCodes) == 0 {
 		return errors.New("no dictionary data codes provided for deletion")
 	}
 
-	err := dal.Gorm.Model(model.SysDictData{}).Where("dict_code IN ?", dictCodes).Delete(&model.SysDictData{}).Error
-	if err != nil {
+	tx := dal.Gorm.Begin()
+
+	dictDatas := make([]model.SysDictData, 0)
+	if err := tx.Where("dict_code IN ?", dictCodes).Find(&dictDatas).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to retrieve dictionary data")
+	}
+
+	if err := recycleDictData(tx, dictCodes, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	dictTypes := make([]string, 0, len(dictDatas))
+	for _, dictData := range dictDatas {
+		dictTypes = append(dictTypes, dictData.DictType)
+		if err := recordConfigHistory(tx, constant.CONFIG_HISTORY_ENTITY_DICT_DATA, dictData.DictCode, dictDataHistoryName(dictData), constant.CONFIG_HISTORY_ACTION_DELETE, dictDataSnapshot(dictData), nil, "", deleteBy, 0); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Model(model.SysDictData{}).Where("dict_code IN ?", dictCodes).Delete(&model.SysDictData{}).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "failed to delete dictionary data")
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	refreshDictCache(dictTypes...)
+
 	return nil
 }
 
@@ -366,7 +516,25 @@ func (s *DictDataService) GetDictDataListWithErr(param dto.DictDataListRequest,
 	var count int64
 	dictDatas := make([]dto.DictDataListResponse, 0)
 
-	query := dal.Gorm.Model(model.SysDictData{}).Order("dict_code")
+	query := dictDataListQuery(param).Order("dict_code")
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count dictionary data")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&dictDatas).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to query dictionary data")
+	}
+
+	return dictDatas, int(count), nil
+}
+
+// dictDataListQuery builds the dictionary data query with the search conditions applied
+func dictDataListQuery(param dto.DictDataListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysDictData{})
 
 	if param.DictLabel != "" {
 		query = query.Where("dict_label LIKE ?", "%"+param.DictLabel+"%")
@@ -380,18 +548,32 @@ func (s *DictDataService) GetDictDataListWithErr(param dto.DictDataListRequest,
 		query = query.Where("status = ?", param.Status)
 	}
 
-	if isPaging {
-		if err := query.Count(&count).Error; err != nil {
-			return nil, 0, errors.Wrap(err, "failed to count dictionary data")
-		}
-		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
-	}
+	return query
+}
 
-	if err := query.Find(&dictDatas).Error; err != nil {
-		return nil, 0, errors.Wrap(err, "failed to query dictionary data")
+// ExportDataset describes the export of the dictionary data matching the search conditions
+func (s *DictDataService) ExportDataset(param dto.DictDataListRequest) ExportDataset {
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_DICT_DATA,
+		Query: func() *gorm.DB {
+			return dictDataListQuery(param)
+		},
+		KeyField:  "dict_code",
+		KeyColumn: "dict_code",
+		Columns: []ExportColumn{
+			{Key: "dictCode", Field: "dict_code", Header: "Dict Code", HeaderZh: "字典编码"},
+			{Key: "dictSort", Field: "dict_sort", Header: "Dict Sort", HeaderZh: "字典排序"},
+			{Key: "dictLabel", Field: "dict_label", Header: "Dict Label", HeaderZh: "字典标签"},
+			{Key: "dictValue", Field: "dict_value", Header: "Dict Value", HeaderZh: "字典键值"},
+			{Key: "dictType", Field: "dict_type", Header: "Dict Type", HeaderZh: "字典类型"},
+			{Key: "isDefault", Field: "is_default", Header: "Is Default", HeaderZh: "是否默认", DictType: "sys_yes_no", Labels: map[string]string{
+				"Y": "Yes", "N": "No",
+			}},
+			{Key: "status", Field: "status", Header: "Status", HeaderZh: "状态", DictType: "sys_normal_disable", Labels: map[string]string{
+				"0": "Normal", "1": "Disabled",
+			}},
+		},
 	}
-
-	return dictDatas, int(count), nil
 }
 
 // GetDictDataByDictCode gets dictionary data details by code
@@ -492,32 +674,34 @@ func (s *DictDataService) GetDictDataCacheByDictType(dictType string) []dto.Dict
 //   - []dto.DictDataListResponse: List of dictionary data
 //   - error: Any error that occurred during retrieval, or nil on success
 func (s *DictDataService) GetDictDataCacheByDictTypeWithErr(dictType string) ([]dto.DictDataListResponse, error) {
-	dictDatas := make([]dto.DictDataListResponse, 0)
-
 	if dictType == "" {
-		return dictDatas, errors.New("empty dictionary type provided")
+		return make([]dto.DictDataListResponse, 0), errors.New("empty dictionary type provided")
 	}
 
-	// Try to get from cache first
-	cache, err := dal.Redis.HGet(context.Background(), rediskey.SysDictKey(), dictType).Result()
-	if err == nil && cache != "" {
-		err = json.Unmarshal([]byte(cache), &dictDatas)
-		if err == nil {
-			return dictDatas, nil
-		}
-	}
-
-	// Get from DB if cache fails
-	dictDatas, err = s.GetDictDataByDictTypeWithErr(dictType)
+	dictDatas, _, err := dictCache.Get(context.Background(), dictType)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set cache
-	cacheBytes, err := json.Marshal(dictDatas)
-	if err == nil {
-		dal.Redis.HSet(context.Background(), rediskey.SysDictKey(), dictType, string(cacheBytes))
-	}
-
 	return dictDatas, nil
 }
+
+// dictCache holds the normal dictionary data by type
+var dictCache = NewTwoLevelCache(TwoLevelCacheOptions{
+	Name:      "dict",
+	Hash:      rediskey.SysDictKey,
+	LocalSize: 1024,
+	LocalTTL:  10 * time.Minute,
+	RedisTTL:  24 * time.Hour,
+	Jitter:    0.1,
+}, func(dictType string) ([]dto.DictDataListResponse, bool, error) {
+	dictDatas, err := (&DictDataService{}).GetDictDataByDictTypeWithErr(dictType)
+	return dictDatas, err == nil, err
+})
+
+// The services drop the changed types in Redis, the events drop them in process
+func init() {
+	OnCacheEvent(constant.CACHE_EVENT_DICT, func(event CacheEvent) {
+		dictCache.Forget(event.Keys...)
+	})
+}
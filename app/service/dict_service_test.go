@@ -3,6 +3,7 @@ package service
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"mira/anima/dal"
 	"mira/app/dto"
@@ -79,7 +80,7 @@ func TestDictTypeService_DeleteDictType(t *testing.T) {
 		dal.Gorm.Last(&createdDictType)
 
 		// Execute
-		err := s.DeleteDictType([]int{createdDictType.DictId})
+		err := s.DeleteDictType([]int{createdDictType.DictId}, "admin")
 		assert.NoError(t, err)
 
 		// Verify
@@ -233,7 +234,7 @@ func TestDictDataService_DeleteDictData(t *testing.T) {
 		dal.Gorm.Last(&createdDictData)
 
 		// Execute
-		err := s.DeleteDictData([]int{createdDictData.DictCode})
+		err := s.DeleteDictData([]int{createdDictData.DictCode}, "admin")
 		assert.NoError(t, err)
 
 		// Verify
@@ -304,7 +305,7 @@ func TestDictDataService_GetDictDataCacheByDictType(t *testing.T) {
 	t.Run("should return dict data from cache", func(t *testing.T) {
 		// Mock cache
 		cachedData := `[{"DictLabel":"Label 1","DictValue":"value1","DictType":"test_type"}]`
-		redisMock.ExpectHGet(rediskey.SysDictKey(), "test_type").SetVal(cachedData)
+		redisMock.ExpectHGet(rediskey.SysDictKey(), "test_type").SetVal("0:" + cachedData)
 
 		// Execute
 		dictDatas := s.GetDictDataCacheByDictType("test_type")
@@ -321,7 +322,7 @@ func TestDictDataService_GetDictDataCacheByDictType(t *testing.T) {
 
 		// Mock cache miss and set
 		redisMock.ExpectHGet(rediskey.SysDictKey(), "test_type").RedisNil()
-		redisMock.ExpectHSet(rediskey.SysDictKey(), "test_type", string(createdDictDataBytes)).SetVal(1)
+		redisMock.CustomMatch(matchTwoLevelCacheEntry(24*time.Hour, 0.1)).ExpectHSet(rediskey.SysDictKey(), "test_type", string(createdDictDataBytes)).SetVal(1)
 
 		// Execute
 		dictDatas := s.GetDictDataCacheByDictType("test_type")
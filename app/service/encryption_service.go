@@ -0,0 +1,254 @@
+package service
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"mira/anima/cipher"
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/config"
+
+	"github.com/pkg/errors"
+)
+
+// Number of rows the encryption migration reads and writes at a time
+const ENCRYPTION_BATCH_SIZE = 500
+
+// encryptedTable is a table holding encrypted columns. The blind index of a column is the
+// column of the same name with a _hash suffix.
+type encryptedTable struct {
+	table   string
+	key     string
+	columns []encryptedColumn
+	// indexed are the encrypted columns with a blind index
+	indexed []string
+}
+
+// encryptedColumn is an encrypted column with the label its values are bound to
+type encryptedColumn struct {
+	name  string
+	label cipher.Label
+}
+
+// encryptedTables are the tables holding personal data, matching the cipher.String fields of their models
+var encryptedTables = []encryptedTable{
+	{
+		table:   "sys_user",
+		key:     "user_id",
+		columns: []encryptedColumn{{"email", model.SysUserEmail{}}, {"phonenumber", model.SysUserPhonenumber{}}, {"login_ip", model.SysUserLoginIP{}}},
+		indexed: []string{"email", "phonenumber"},
+	},
+	{
+		table:   "sys_logininfor",
+		key:     "info_id",
+		columns: []encryptedColumn{{"ipaddr", model.SysLogininforIpaddr{}}},
+		indexed: []string{"ipaddr"},
+	},
+}
+
+// EncryptionServiceInterface defines operations for the encryption of personal data at rest
+type EncryptionServiceInterface interface {
+	EncryptTables() ([]dto.EncryptionReport, error)
+}
+
+// EncryptionService migrates the personal data columns to the keys of the configuration
+type EncryptionService struct{}
+
+// Ensure EncryptionService implements EncryptionServiceInterface
+var _ EncryptionServiceInterface = (*EncryptionService)(nil)
+
+// NewEncryptionService creates a new EncryptionService
+func NewEncryptionService() *EncryptionService {
+	return &EncryptionService{}
+}
+
+// InitEncryption loads the encryption keys of the configuration. Encryption stays disabled while
+// no primary key is configured.
+func InitEncryption() error {
+	if config.Data == nil || config.Data.Encryption.Primary == "" {
+		return cipher.Init(nil)
+	}
+
+	conf := &cipher.Config{Primary: config.Data.Encryption.Primary, Keys: make([]cipher.Key, 0, len(config.Data.Encryption.Keys))}
+	for _, key := range config.Data.Encryption.Keys {
+		secret, err := base64.StdEncoding.DecodeString(key.Secret)
+		if err != nil {
+			return errors.Wrapf(err, "invalid secret of encryption key %s", key.Id)
+		}
+		conf.Keys = append(conf.Keys, cipher.Key{Id: key.Id, Secret: secret})
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(config.Data.Encryption.IndexKey)
+	if err != nil {
+		return errors.Wrap(err, "invalid blind index key")
+	}
+	conf.IndexKey = indexKey
+
+	return errors.Wrap(cipher.Init(conf), "failed to load encryption keys")
+}
+
+// EncryptTables brings every encrypted column under the primary key and recomputes its blind index
+//
+// Plaintext values written before encryption was enabled are encrypted, and the data keys of
+// values wrapped by a rotated key are rewrapped by the primary one, after which the rotated key
+// may be removed from the configuration. Blind indexes are recomputed, which is needed after the
+// index key changes. The migration is idempotent and may be stopped and run again.
+func (s *EncryptionService) EncryptTables() ([]dto.EncryptionReport, error) {
+	if !cipher.Enabled() {
+		return nil, cipher.ErrDisabled
+	}
+
+	reports := make([]dto.EncryptionReport, 0, len(encryptedTables))
+	for _, table := range encryptedTables {
+		report, err := encryptTable(table)
+		reports = append(reports, report)
+		if err != nil {
+			return reports, err
+		}
+	}
+
+	return reports, nil
+}
+
+// encryptTable migrates the encrypted columns of a table in batches ordered by its key
+func encryptTable(table encryptedTable) (dto.EncryptionReport, error) {
+	report := dto.EncryptionReport{Table: table.table}
+
+	columns := []string{table.key}
+	for _, column := range table.columns {
+		columns = append(columns, column.name)
+	}
+	for _, column := range table.indexed {
+		columns = append(columns, column+"_hash")
+	}
+
+	var lastKey interface{}
+	for {
+		rows := make([]map[string]interface{}, 0, ENCRYPTION_BATCH_SIZE)
+
+		query := dal.Gorm.Table(table.table).Select(columns)
+		if lastKey != nil {
+			query = query.Where(table.key+" > ?", lastKey)
+		}
+		if err := query.Order(table.key).Limit(ENCRYPTION_BATCH_SIZE).Find(&rows).Error; err != nil {
+			return report, errors.Wrapf(err, "failed to read %s", table.table)
+		}
+
+		for _, row := range rows {
+			updates, err := encryptRow(table, row, &report)
+			if err != nil {
+				return report, errors.Wrapf(err, "failed to encrypt %s %s %v", table.table, table.key, row[table.key])
+			}
+			if len(updates) > 0 {
+				if err = dal.Gorm.Table(table.table).Where(table.key+" = ?", row[table.key]).UpdateColumns(updates).Error; err != nil {
+					return report, errors.Wrapf(err, "failed to update %s", table.table)
+				}
+				report.Updated++
+			}
+		}
+		report.Rows += len(rows)
+
+		if len(rows) < ENCRYPTION_BATCH_SIZE {
+			return report, nil
+		}
+		lastKey = rows[len(rows)-1][table.key]
+	}
+}
+
+// encryptRow returns the columns of a row to update, counting them in the report
+func encryptRow(table encryptedTable, row map[string]interface{}, report *dto.EncryptionReport) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+
+	for _, column := range table.columns {
+		value := encryptedText(row[column.name])
+		rewrapped, changed, err := cipher.Rewrap(column.label.Label(), value)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			updates[column.name] = rewrapped
+			if strings.HasPrefix(value, cipher.Prefix) {
+				report.Rewrapped++
+			} else {
+				report.Encrypted++
+			}
+		}
+	}
+
+	for _, column := range table.indexed {
+		plaintext, err := cipher.Decrypt(encryptedColumnLabel(table.table, column).Label(), encryptedText(row[column]))
+		if err != nil {
+			return nil, err
+		}
+		if index := cipher.BlindIndex(plaintext); index != encryptedText(row[column+"_hash"]) {
+			updates[column+"_hash"] = index
+			report.Indexed++
+		}
+	}
+
+	return updates, nil
+}
+
+// encryptedText returns the text of a column read into a map
+func encryptedText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+// encryptedColumnLabel returns the label of an encrypted column of a table, or nil when the
+// column is not encrypted
+func encryptedColumnLabel(table, column string) cipher.Label {
+	for _, encrypted := range encryptedTables {
+		if encrypted.table == table {
+			for _, encryptedColumn := range encrypted.columns {
+				if encryptedColumn.name == column {
+					return encryptedColumn.label
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isIndexedColumn reports whether a column of a table is encrypted with a blind index
+func isIndexedColumn(table, column string) bool {
+	for _, encrypted := range encryptedTables {
+		if encrypted.table == table {
+			for _, indexed := range encrypted.indexed {
+				if indexed == column {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// piiEquals returns the condition of an encrypted column equal to a value. Encrypted values
+// differ on every write, so they are compared through the blind index of the column; the column
+// itself is compared while encryption is disabled.
+func piiEquals(column, value string) (string, string) {
+	if cipher.Enabled() {
+		return column + "_hash = ?", cipher.BlindIndex(value)
+	}
+	return column + " = ?", value
+}
+
+// piiContains returns the condition of an encrypted column containing a value. Once the column
+// is encrypted only whole values match.
+func piiContains(column, value string) (string, string) {
+	if cipher.Enabled() {
+		return piiEquals(column, value)
+	}
+	return column + " LIKE ?", "%" + value + "%"
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"mira/anima/cipher"
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// initTestEncryption enables encryption with the keys of the IDs, the first being primary
+func initTestEncryption(t *testing.T, ids ...string) {
+	conf := &cipher.Config{Primary: ids[0], IndexKey: []byte("test-index-key")}
+	for _, id := range ids {
+		conf.Keys = append(conf.Keys, cipher.Key{Id: id, Secret: []byte(strings.Repeat(id, 32)[:32])})
+	}
+	assert.NoError(t, cipher.Init(conf))
+}
+
+// rawColumn returns a column of a row as stored, without decrypting it
+func rawColumn(table, column, key string, id int) string {
+	var value string
+	dal.Gorm.Table(table).Where(key+" = ?", id).Pluck(column, &value)
+	return value
+}
+
+func TestEncryption_UserColumns(t *testing.T) {
+	setup()
+	defer teardown()
+	initTestEncryption(t, "k1")
+	defer cipher.Init(nil)
+	s := &UserService{}
+
+	t.Run("should store ciphertext and read back plaintext", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysUser{UserId: 1, UserName: "bob", Email: "bob@example.com", Phonenumber: "13800000001", LoginIP: "10.0.0.1"})
+
+		assert.True(t, strings.HasPrefix(rawColumn("sys_user", "email", "user_id", 1), "enc:k1:"))
+		assert.True(t, strings.HasPrefix(rawColumn("sys_user", "login_ip", "user_id", 1), "enc:k1:"))
+		assert.Equal(t, cipher.BlindIndex("bob@example.com"), rawColumn("sys_user", "email_hash", "user_id", 1))
+
+		var user model.SysUser
+		dal.Gorm.First(&user, 1)
+		assert.Equal(t, "bob@example.com", string(user.Email))
+		assert.Equal(t, "13800000001", string(user.Phonenumber))
+		assert.Equal(t, "10.0.0.1", string(user.LoginIP))
+	})
+
+	t.Run("should find users through the blind indexes", func(t *testing.T) {
+		result, err := s.GetUserByEmailWithErr("Bob@Example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", result.UserName)
+
+		result, err = s.GetUserByPhonenumberWithErr("13800000001")
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", result.UserName)
+	})
+
+	t.Run("should update the blind index with the column", func(t *testing.T) {
+		dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", 1).Updates(map[string]interface{}{"email": cipher.String[model.SysUserEmail]("robert@example.com")})
+		assert.Equal(t, cipher.BlindIndex("robert@example.com"), rawColumn("sys_user", "email_hash", "user_id", 1))
+
+		result, err := s.GetUserByEmailWithErr("robert@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", result.UserName)
+	})
+}
+
+func TestEncryptionService_EncryptTables(t *testing.T) {
+	setup()
+	defer teardown()
+	defer cipher.Init(nil)
+	s := NewEncryptionService()
+
+	// Rows written before encryption was enabled
+	dal.Gorm.Create(&model.SysUser{UserId: 1, UserName: "alice", Email: "alice@example.com", Phonenumber: "13800000001"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, UserName: "bob"})
+	dal.Gorm.Create(&model.SysLogininfor{InfoId: 1, UserName: "alice", Ipaddr: "10.0.0.1"})
+
+	t.Run("should fail while encryption is disabled", func(t *testing.T) {
+		_, err := s.EncryptTables()
+		assert.ErrorIs(t, err, cipher.ErrDisabled)
+	})
+
+	t.Run("should encrypt plaintext rows", func(t *testing.T) {
+		initTestEncryption(t, "k1")
+
+		reports, err := s.EncryptTables()
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.EncryptionReport{
+			{Table: "sys_user", Rows: 2, Updated: 1, Encrypted: 2, Indexed: 2},
+			{Table: "sys_logininfor", Rows: 1, Updated: 1, Encrypted: 1, Indexed: 1},
+		}, reports)
+
+		assert.True(t, strings.HasPrefix(rawColumn("sys_user", "email", "user_id", 1), "enc:k1:"))
+		assert.True(t, strings.HasPrefix(rawColumn("sys_logininfor", "ipaddr", "info_id", 1), "enc:k1:"))
+
+		var user model.SysUser
+		dal.Gorm.First(&user, 1)
+		assert.Equal(t, "alice@example.com", string(user.Email))
+
+		result, err := (&UserService{}).GetUserByEmailWithErr("alice@example.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", result.UserName)
+	})
+
+	t.Run("should leave migrated rows alone", func(t *testing.T) {
+		reports, err := s.EncryptTables()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, reports[0].Updated+reports[1].Updated)
+	})
+
+	t.Run("should rewrap the values of a rotated key", func(t *testing.T) {
+		sealed := strings.SplitN(rawColumn("sys_user", "email", "user_id", 1), ":", 4)[3]
+		initTestEncryption(t, "k2", "k1")
+
+		reports, err := s.EncryptTables()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, reports[0].Rewrapped)
+		assert.Equal(t, 1, reports[1].Rewrapped)
+
+		// Only the data key is wrapped again, the sealed value stays as it is
+		parts := strings.SplitN(rawColumn("sys_user", "email", "user_id", 1), ":", 4)
+		assert.Equal(t, "k2", parts[1])
+		assert.Equal(t, sealed, parts[3])
+
+		// The rotated key is no longer needed
+		initTestEncryption(t, "k2")
+		var login model.SysLogininfor
+		assert.NoError(t, dal.Gorm.First(&login, 1).Error)
+		assert.Equal(t, "10.0.0.1", string(login.Ipaddr))
+	})
+}
@@ -0,0 +1,607 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mira/anima/cipher"
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/uuid"
+	"mira/common/xerrors"
+	"mira/config"
+
+	"github.com/pkg/errors"
+	excelize "github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// EXPORT_BATCH_SIZE is the number of rows read from the database per query,
+// so an export never holds more than one batch in memory
+const EXPORT_BATCH_SIZE = 1000
+
+// EXPORT_SYNC_MAX_ROWS is the largest export streamed in the request;
+// anything bigger runs as a background job with a download link
+const EXPORT_SYNC_MAX_ROWS = 50000
+
+// ExportColumn describes one column an export dataset can write
+type ExportColumn struct {
+	// Key names the column in the columns parameter and in NDJSON output
+	Key string
+	// Field is the column name in the query result
+	Field string
+	// Header and HeaderZh are the English and Chinese headers
+	Header   string
+	HeaderZh string
+	// DictType names the dictionary whose labels replace the raw codes
+	DictType string
+	// Labels replace raw codes in English exports and for codes without a dictionary label
+	Labels map[string]string
+	// Suffix is appended to every non-empty value, e.g. a unit
+	Suffix string
+	// Encrypted is the label of an encrypted column, decrypted before being written
+	Encrypted cipher.Label
+}
+
+// ExportDataset describes the rows and columns of an export
+//
+// Rows are read in batches ordered by KeyField, each batch starting after the last
+// key of the previous one, so deep exports cost the same per batch as the first.
+type ExportDataset struct {
+	Name string
+	// Query returns a fresh query with the filters of the export applied
+	Query func() *gorm.DB
+	// KeyField is the unique column the batches are ordered by, qualified if the query joins
+	KeyField string
+	// KeyColumn is the name of KeyField in the query result
+	KeyColumn string
+	// Desc exports the newest rows first
+	Desc    bool
+	Columns []ExportColumn
+}
+
+// ExportServiceInterface defines operations for streaming exports and background export jobs
+type ExportServiceInterface interface {
+	PrepareExport(dataset ExportDataset, param dto.ExportRequest) (int, error)
+	WriteExport(w io.Writer, dataset ExportDataset, param dto.ExportRequest) (int, error)
+	CreateExportJob(dataset ExportDataset, param dto.ExportRequest, fileName, createBy string) (int, error)
+	GetExportJob(jobId int, createBy string) (dto.ExportJobResponse, error)
+	GetExportJobFile(jobId int, createBy string) (string, string, error)
+}
+
+// ExportService implements streaming exports in CSV, NDJSON and XLSX
+//
+// Small exports are written straight to the response; large ones become jobs that a
+// background worker writes to a file under the upload path, recording its progress.
+type ExportService struct {
+	backgroundTaskService *BackgroundTaskService
+}
+
+// Ensure ExportService implements ExportServiceInterface
+var _ ExportServiceInterface = (*ExportService)(nil)
+
+// NewExportService creates a new ExportService running jobs on the background task service
+func NewExportService(backgroundTaskService *BackgroundTaskService) *ExportService {
+	return &ExportService{backgroundTaskService: backgroundTaskService}
+}
+
+// PrepareExport checks the selected columns and returns the number of rows the export will write
+func (s *ExportService) PrepareExport(dataset ExportDataset, param dto.ExportRequest) (int, error) {
+	if _, err := selectExportColumns(dataset, param.Columns); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := dataset.Query().Count(&count).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to count export rows")
+	}
+
+	return int(count), nil
+}
+
+// WriteExport streams the rows of the dataset to w and returns the number of rows written
+func (s *ExportService) WriteExport(w io.Writer, dataset ExportDataset, param dto.ExportRequest) (int, error) {
+	return writeExport(w, dataset, param, nil)
+}
+
+// CreateExportJob creates an export job, submits it to the background worker and returns its ID
+//
+// fileName is the download name without extension.
+func (s *ExportService) CreateExportJob(dataset ExportDataset, param dto.ExportRequest, fileName, createBy string) (int, error) {
+	if _, err := selectExportColumns(dataset, param.Columns); err != nil {
+		return 0, err
+	}
+
+	format := exportFormat(param.Format)
+
+	storeName, err := uuid.New()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to name export file")
+	}
+
+	job := model.SysExportJob{
+		Dataset:  dataset.Name,
+		Format:   format,
+		FileName: fileName + "." + format,
+		FilePath: config.Data.Ruoyi.UploadPath + "export/" + storeName + "." + format,
+		Status:   constant.EXPORT_JOB_STATUS_PENDING,
+		CreateBy: createBy,
+	}
+
+	if err = dal.Gorm.Create(&job).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to create export job")
+	}
+
+	err = s.backgroundTaskService.SubmitExportTask(job.JobId, func(_ context.Context) error {
+		if err := runExportJob(job, dataset, param); err != nil {
+			os.Remove(job.FilePath)
+			abortExportJob(job.JobId, err)
+		}
+		return nil
+	})
+	if err != nil {
+		abortExportJob(job.JobId, err)
+		return 0, errors.Wrap(err, "failed to submit export job")
+	}
+
+	return job.JobId, nil
+}
+
+// GetExportJob retrieves the progress of an export job started by createBy
+func (s *ExportService) GetExportJob(jobId int, createBy string) (dto.ExportJobResponse, error) {
+	var job dto.ExportJobResponse
+
+	result := dal.Gorm.Model(model.SysExportJob{}).Where("job_id = ? AND create_by = ?", jobId, createBy).Limit(1).Find(&job)
+	if result.Error != nil {
+		return job, errors.Wrap(result.Error, "failed to retrieve export job")
+	}
+	if result.RowsAffected == 0 {
+		return job, xerrors.ErrExportJobNotFound
+	}
+
+	return job, nil
+}
+
+// GetExportJobFile returns the stored path and the download name of a finished export job started by createBy
+func (s *ExportService) GetExportJobFile(jobId int, createBy string) (string, string, error) {
+	var job model.SysExportJob
+
+	result := dal.Gorm.Where("job_id = ? AND create_by = ?", jobId, createBy).Limit(1).Find(&job)
+	if result.Error != nil {
+		return "", "", errors.Wrap(result.Error, "failed to retrieve export job")
+	}
+	if result.RowsAffected == 0 {
+		return "", "", xerrors.ErrExportJobNotFound
+	}
+	if job.Status != constant.EXPORT_JOB_STATUS_FINISHED {
+		return "", "", xerrors.ErrExportJobNotFinished
+	}
+
+	return job.FilePath, job.FileName, nil
+}
+
+// ExportContentType returns the MIME type of an export format
+func ExportContentType(format string) string {
+	switch exportFormat(format) {
+	case constant.EXPORT_FORMAT_CSV:
+		return "text/csv; charset=utf-8"
+	case constant.EXPORT_FORMAT_NDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+}
+
+// ExportFileExt returns the file extension of an export format, including the dot
+func ExportFileExt(format string) string {
+	return "." + exportFormat(format)
+}
+
+// runExportJob writes the file of an export job, updating its progress after each batch
+func runExportJob(job model.SysExportJob, dataset ExportDataset, param dto.ExportRequest) error {
+	var total int64
+	if err := dataset.Query().Count(&total).Error; err != nil {
+		return errors.Wrap(err, "failed to count export rows")
+	}
+
+	if err := dal.Gorm.Model(model.SysExportJob{}).Where("job_id = ?", job.JobId).Updates(map[string]interface{}{
+		"status": constant.EXPORT_JOB_STATUS_RUNNING,
+		"total":  total,
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed to start export job")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(job.FilePath), 0755); err != nil {
+		return errors.Wrap(err, "failed to create export directory")
+	}
+
+	file, err := os.Create(job.FilePath)
+	if err != nil {
+		return errors.Wrap(err, "failed to create export file")
+	}
+	defer file.Close()
+
+	processed, err := writeExport(file, dataset, param, func(written int) error {
+		return dal.Gorm.Model(model.SysExportJob{}).Where("job_id = ?", job.JobId).Update("processed", written).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return errors.Wrap(err, "failed to write export file")
+	}
+
+	if err = dal.Gorm.Model(model.SysExportJob{}).Where("job_id = ?", job.JobId).Updates(map[string]interface{}{
+		"status":      constant.EXPORT_JOB_STATUS_FINISHED,
+		"processed":   processed,
+		"finish_time": datetime.Datetime{Time: time.Now()},
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed to finish export job")
+	}
+
+	return nil
+}
+
+// abortExportJob marks an export job as aborted with the reason
+func abortExportJob(jobId int, cause error) {
+	dal.Gorm.Model(model.SysExportJob{}).Where("job_id = ?", jobId).Updates(map[string]interface{}{
+		"status":      constant.EXPORT_JOB_STATUS_ABORTED,
+		"error_msg":   cause.Error(),
+		"finish_time": datetime.Datetime{Time: time.Now()},
+	})
+}
+
+// writeExport streams the rows of the dataset to w in batches and returns the number of rows written.
+// onBatch, if set, is called with the running total after each batch.
+func writeExport(w io.Writer, dataset ExportDataset, param dto.ExportRequest, onBatch func(written int) error) (int, error) {
+	columns, err := selectExportColumns(dataset, param.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	labels, err := loadExportLabels(columns)
+	if err != nil {
+		return 0, err
+	}
+
+	lang := param.Lang
+	if lang == "" {
+		lang = constant.EXPORT_LANG_EN
+	}
+
+	keys := make([]string, len(columns))
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		keys[i] = column.Key
+		headers[i] = column.Header
+		if lang == constant.EXPORT_LANG_ZH && column.HeaderZh != "" {
+			headers[i] = column.HeaderZh
+		}
+	}
+
+	writer, err := newExportWriter(exportFormat(param.Format), w, keys)
+	if err != nil {
+		return 0, err
+	}
+	if err = writer.WriteHeader(headers); err != nil {
+		return 0, err
+	}
+
+	order := dataset.KeyField
+	after := dataset.KeyField + " > ?"
+	if dataset.Desc {
+		order += " DESC"
+		after = dataset.KeyField + " < ?"
+	}
+
+	written := 0
+	var lastKey interface{}
+	values := make([]string, len(columns))
+
+	for {
+		rows := make([]map[string]interface{}, 0, EXPORT_BATCH_SIZE)
+
+		query := dataset.Query()
+		if lastKey != nil {
+			query = query.Where(after, lastKey)
+		}
+		if err = query.Order(order).Limit(EXPORT_BATCH_SIZE).Find(&rows).Error; err != nil {
+			return written, errors.Wrap(err, "failed to read export rows")
+		}
+
+		for _, row := range rows {
+			for i, column := range columns {
+				value := row[column.Field]
+				if column.Encrypted != nil {
+					if value, err = cipher.Decrypt(column.Encrypted.Label(), encryptedText(value)); err != nil {
+						return written, errors.Wrapf(err, "failed to decrypt %s", column.Field)
+					}
+				}
+				values[i] = exportValue(column, value, labels, lang)
+			}
+			if err = writer.WriteRow(values); err != nil {
+				return written, err
+			}
+		}
+		written += len(rows)
+
+		if onBatch != nil && len(rows) > 0 {
+			if err = onBatch(written); err != nil {
+				return written, errors.Wrap(err, "failed to record export progress")
+			}
+		}
+
+		if len(rows) < EXPORT_BATCH_SIZE {
+			break
+		}
+		lastKey = rows[len(rows)-1][dataset.KeyColumn]
+	}
+
+	if err = writer.Close(); err != nil {
+		return written, err
+	}
+
+	return written, nil
+}
+
+// selectExportColumns returns the columns named in the comma-separated list in its order,
+// or every column of the dataset if the list is empty
+func selectExportColumns(dataset ExportDataset, columnList string) ([]ExportColumn, error) {
+	if strings.TrimSpace(columnList) == "" {
+		return dataset.Columns, nil
+	}
+
+	byKey := make(map[string]ExportColumn, len(dataset.Columns))
+	for _, column := range dataset.Columns {
+		byKey[column.Key] = column
+	}
+
+	columns := make([]ExportColumn, 0)
+	selected := make(map[string]bool)
+	for _, key := range strings.Split(columnList, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		column, ok := byKey[key]
+		if !ok {
+			return nil, errors.Wrap(xerrors.ErrExportColumnInvalid, key)
+		}
+		if selected[key] {
+			return nil, errors.Wrap(xerrors.ErrExportColumnDuplicate, key)
+		}
+		selected[key] = true
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// loadExportLabels loads the labels of every dictionary used by the columns, keyed by dictionary type and value
+func loadExportLabels(columns []ExportColumn) (map[string]map[string]string, error) {
+	labels := make(map[string]map[string]string)
+
+	for _, column := range columns {
+		if column.DictType == "" || labels[column.DictType] != nil {
+			continue
+		}
+
+		dictDatas, err := (&DictDataService{}).GetDictDataCacheByDictTypeWithErr(column.DictType)
+		if err != nil {
+			return nil, err
+		}
+
+		dictLabels := make(map[string]string, len(dictDatas))
+		for _, dictData := range dictDatas {
+			dictLabels[dictData.DictValue] = dictData.DictLabel
+		}
+		labels[column.DictType] = dictLabels
+	}
+
+	return labels, nil
+}
+
+// exportValue formats a value of the query result for a column
+//
+// Codes are replaced by the fixed English label in English exports, otherwise by the
+// dictionary label, falling back to the fixed label and then to the code itself.
+func exportValue(column ExportColumn, value interface{}, labels map[string]map[string]string, lang string) string {
+	var text string
+
+	switch v := value.(type) {
+	case nil:
+		text = ""
+	case time.Time:
+		if !v.IsZero() {
+			text = v.Format("2006-01-02 15:04:05")
+		}
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		text = fmt.Sprint(v)
+	}
+
+	if text == "" {
+		return ""
+	}
+
+	if label, ok := column.Labels[text]; ok && lang == constant.EXPORT_LANG_EN {
+		return label
+	}
+	if label, ok := labels[column.DictType][text]; ok {
+		return label
+	}
+	if label, ok := column.Labels[text]; ok {
+		return label
+	}
+
+	return text + column.Suffix
+}
+
+// exportFormat returns the format to write, XLSX unless another one is requested
+func exportFormat(format string) string {
+	if format == "" {
+		return constant.EXPORT_FORMAT_XLSX
+	}
+	return format
+}
+
+// exportWriter writes the rows of an export in one file format
+type exportWriter interface {
+	WriteHeader(headers []string) error
+	WriteRow(values []string) error
+	Close() error
+}
+
+// newExportWriter creates the writer for a format; keys name the columns in NDJSON output
+func newExportWriter(format string, w io.Writer, keys []string) (exportWriter, error) {
+	switch format {
+	case constant.EXPORT_FORMAT_CSV:
+		return newCsvExportWriter(w)
+	case constant.EXPORT_FORMAT_NDJSON:
+		return &ndjsonExportWriter{w: w, keys: keys}, nil
+	case constant.EXPORT_FORMAT_XLSX:
+		return newXlsxExportWriter(w)
+	default:
+		return nil, xerrors.ErrExportFormatInvalid
+	}
+}
+
+// csvExportWriter writes UTF-8 CSV with a byte order mark so that Excel detects the encoding
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func newCsvExportWriter(w io.Writer) (*csvExportWriter, error) {
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return nil, errors.Wrap(err, "failed to write export")
+	}
+	return &csvExportWriter{writer: csv.NewWriter(w)}, nil
+}
+
+func (c *csvExportWriter) WriteHeader(headers []string) error {
+	return c.WriteRow(headers)
+}
+
+func (c *csvExportWriter) WriteRow(values []string) error {
+	if err := c.writer.Write(values); err != nil {
+		return errors.Wrap(err, "failed to write export")
+	}
+	return nil
+}
+
+func (c *csvExportWriter) Close() error {
+	c.writer.Flush()
+	if err := c.writer.Error(); err != nil {
+		return errors.Wrap(err, "failed to write export")
+	}
+	return nil
+}
+
+// ndjsonExportWriter writes one JSON object per row with the fields in column order
+type ndjsonExportWriter struct {
+	w    io.Writer
+	keys []string
+	buf  bytes.Buffer
+}
+
+func (n *ndjsonExportWriter) WriteHeader(headers []string) error {
+	return nil
+}
+
+func (n *ndjsonExportWriter) WriteRow(values []string) error {
+	n.buf.Reset()
+	n.buf.WriteByte('{')
+	for i, key := range n.keys {
+		if i > 0 {
+			n.buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		value, _ := json.Marshal(values[i])
+		n.buf.Write(name)
+		n.buf.WriteByte(':')
+		n.buf.Write(value)
+	}
+	n.buf.WriteString("}\n")
+
+	if _, err := n.w.Write(n.buf.Bytes()); err != nil {
+		return errors.Wrap(err, "failed to write export")
+	}
+	return nil
+}
+
+func (n *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// xlsxExportWriter writes a workbook with excelize's stream writer, which keeps
+// the rows in a temporary file instead of memory until the workbook is written
+type xlsxExportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXlsxExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, errors.Wrap(err, "failed to create export workbook")
+	}
+
+	return &xlsxExportWriter{w: w, file: file, stream: stream}, nil
+}
+
+func (x *xlsxExportWriter) WriteHeader(headers []string) error {
+	return x.WriteRow(headers)
+}
+
+func (x *xlsxExportWriter) WriteRow(values []string) error {
+	x.row++
+
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return errors.Wrap(err, "failed to write export")
+	}
+
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		row[i] = value
+	}
+
+	if err = x.stream.SetRow(cell, row); err != nil {
+		return errors.Wrap(err, "failed to write export")
+	}
+	return nil
+}
+
+func (x *xlsxExportWriter) Close() error {
+	defer x.file.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return errors.Wrap(err, "failed to write export")
+	}
+	if err := x.file.Write(x.w); err != nil {
+		return errors.Wrap(err, "failed to write export")
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+	"mira/config"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	excelize "github.com/xuri/excelize/v2"
+)
+
+func seedExport() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ", Leader: "Ann"})
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", NickName: "Admin", Sex: "1", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 100, UserName: "bob", NickName: "Bob, Jr.", Sex: "0", Status: "1"})
+	dal.Gorm.Create(&model.SysDictData{DictCode: 1, DictLabel: "男", DictValue: "0", DictType: "sys_user_sex", Status: "0"})
+	dal.Gorm.Create(&model.SysDictData{DictCode: 2, DictLabel: "女", DictValue: "1", DictType: "sys_user_sex", Status: "0"})
+}
+
+func TestExportService_WriteExport(t *testing.T) {
+	setup()
+	defer teardown()
+	seedExport()
+	s := NewExportService(NewBackgroundTaskService(1))
+	dataset := (&UserService{}).ExportDataset(dto.UserListRequest{}, 1)
+
+	t.Run("should write the selected columns as CSV with English labels", func(t *testing.T) {
+		var buf bytes.Buffer
+		written, err := s.WriteExport(&buf, dataset, dto.ExportRequest{Format: constant.EXPORT_FORMAT_CSV, Columns: "nickName,sex,deptName"})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, written)
+		assert.Equal(t, "\xEF\xBB\xBFUser Name,User Gender,Dept Name\nAdmin,Female,HQ\n\"Bob, Jr.\",Male,HQ\n", buf.String())
+	})
+
+	t.Run("should use Chinese headers and dictionary labels", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := s.WriteExport(&buf, dataset, dto.ExportRequest{Format: constant.EXPORT_FORMAT_CSV, Columns: "userName,sex", Lang: constant.EXPORT_LANG_ZH})
+		assert.NoError(t, err)
+		assert.Equal(t, "\xEF\xBB\xBF登录名称,用户性别\nadmin,女\nbob,男\n", buf.String())
+	})
+
+	t.Run("should write NDJSON with the fields in column order", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := s.WriteExport(&buf, dataset, dto.ExportRequest{Format: constant.EXPORT_FORMAT_NDJSON, Columns: "userName,userId,status"})
+		assert.NoError(t, err)
+		assert.Equal(t, "{\"userName\":\"admin\",\"userId\":\"1\",\"status\":\"Normal\"}\n{\"userName\":\"bob\",\"userId\":\"2\",\"status\":\"Disabled\"}\n", buf.String())
+	})
+
+	t.Run("should write every column to an XLSX workbook by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := s.WriteExport(&buf, dataset, dto.ExportRequest{})
+		assert.NoError(t, err)
+
+		file, err := excelize.OpenReader(&buf)
+		assert.NoError(t, err)
+		defer file.Close()
+
+		rows, err := file.GetRows("Sheet1")
+		assert.NoError(t, err)
+		assert.Len(t, rows, 3)
+		assert.Len(t, rows[0], len(dataset.Columns))
+		assert.Equal(t, "User ID", rows[0][0])
+		assert.Equal(t, "bob", rows[2][1])
+		assert.Equal(t, "Ann", rows[2][10])
+	})
+
+	t.Run("should reject unknown or repeated columns", func(t *testing.T) {
+		_, err := s.PrepareExport(dataset, dto.ExportRequest{Columns: "userName,password"})
+		assert.Equal(t, xerrors.ErrExportColumnInvalid, errors.Cause(err))
+
+		_, err = s.PrepareExport(dataset, dto.ExportRequest{Columns: "userName, userName"})
+		assert.Equal(t, xerrors.ErrExportColumnDuplicate, errors.Cause(err))
+
+		_, err = s.WriteExport(&bytes.Buffer{}, dataset, dto.ExportRequest{Format: "pdf"})
+		assert.Equal(t, xerrors.ErrExportFormatInvalid, err)
+	})
+
+	t.Run("should count the rows matching the search conditions", func(t *testing.T) {
+		count, err := s.PrepareExport((&UserService{}).ExportDataset(dto.UserListRequest{UserName: "bo"}, 1), dto.ExportRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+func TestExportService_WriteExportBatches(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewExportService(NewBackgroundTaskService(1))
+
+	operLogs := make([]model.SysOperLog, 0, EXPORT_BATCH_SIZE+5)
+	for i := 1; i <= EXPORT_BATCH_SIZE+5; i++ {
+		operLogs = append(operLogs, model.SysOperLog{OperId: i, Title: "log" + strconv.Itoa(i), CostTime: i})
+	}
+	dal.Gorm.CreateInBatches(operLogs, 200)
+
+	var buf bytes.Buffer
+	written, err := s.WriteExport(&buf, (&OperLogService{}).ExportDataset(dto.OperLogListRequest{}), dto.ExportRequest{Format: constant.EXPORT_FORMAT_CSV, Columns: "operId,costTime"})
+	assert.NoError(t, err)
+	assert.Equal(t, EXPORT_BATCH_SIZE+5, written)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, EXPORT_BATCH_SIZE+6)
+	assert.Equal(t, strconv.Itoa(EXPORT_BATCH_SIZE+5)+","+strconv.Itoa(EXPORT_BATCH_SIZE+5)+"ms", lines[1])
+	assert.Equal(t, "1,1ms", lines[len(lines)-1])
+}
+
+func TestExportService_CreateExportJob(t *testing.T) {
+	setup()
+	defer teardown()
+	seedExport()
+	bts := NewBackgroundTaskService(1)
+	assert.NoError(t, bts.Start())
+	defer bts.Stop(time.Second)
+	s := NewExportService(bts)
+
+	config.Data.Ruoyi.UploadPath = t.TempDir() + "/"
+
+	jobId, err := s.CreateExportJob((&UserService{}).ExportDataset(dto.UserListRequest{}, 1), dto.ExportRequest{Format: constant.EXPORT_FORMAT_CSV, Columns: "userName"}, "user_20261018000000", "admin")
+	assert.NoError(t, err)
+
+	t.Run("should write the file in the background", func(t *testing.T) {
+		assert.Eventually(t, func() bool {
+			job, _ := s.GetExportJob(jobId, "admin")
+			return job.Status == constant.EXPORT_JOB_STATUS_FINISHED
+		}, 5*time.Second, 50*time.Millisecond)
+
+		job, _ := s.GetExportJob(jobId, "admin")
+		assert.Equal(t, 2, job.Total)
+		assert.Equal(t, 2, job.Processed)
+		assert.Equal(t, "user_20261018000000.csv", job.FileName)
+
+		filePath, fileName, err := s.GetExportJobFile(jobId, "admin")
+		assert.NoError(t, err)
+		assert.Equal(t, "user_20261018000000.csv", fileName)
+
+		content, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, "\xEF\xBB\xBFLogin Name\nadmin\nbob\n", string(content))
+	})
+
+	t.Run("should hide the job from other users", func(t *testing.T) {
+		_, err := s.GetExportJob(jobId, "bob")
+		assert.Equal(t, xerrors.ErrExportJobNotFound, err)
+
+		_, _, err = s.GetExportJobFile(jobId, "bob")
+		assert.Equal(t, xerrors.ErrExportJobNotFound, err)
+	})
+
+	t.Run("should not download an unfinished job", func(t *testing.T) {
+		dal.Gorm.Model(model.SysExportJob{}).Where("job_id = ?", jobId).Update("status", constant.EXPORT_JOB_STATUS_RUNNING)
+
+		_, _, err := s.GetExportJobFile(jobId, "admin")
+		assert.Equal(t, xerrors.ErrExportJobNotFinished, err)
+	})
+}
@@ -0,0 +1,1098 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"mira/anima/cipher"
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/app/validator"
+	"mira/common/password"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// IMPORT_BATCH_SIZE is the number of rows validated and written per batch;
+// the job progress is updated after each batch
+const IMPORT_BATCH_SIZE = 500
+
+// ImportJobServiceInterface defines operations for background import jobs
+type ImportJobServiceInterface interface {
+	CreateImportJob(jobType, fileName, createBy string) (int, error)
+	SubmitImportJob(jobId int, run func(ctx context.Context) error) error
+	GetImportJob(jobId int) (dto.ImportJobResponse, error)
+	GetImportJobErrors(jobId int) ([]dto.ImportJobErrorResponse, error)
+	RunUserImport(jobId int, rows []dto.UserImportRequest, options dto.ImportOptions) error
+	RunDeptImport(jobId int, rows []dto.DeptImportRequest, options dto.ImportOptions) error
+	RunPostImport(jobId int, rows []dto.PostImportRequest, options dto.ImportOptions) error
+	RunRoleImport(jobId int, rows []dto.RoleImportRequest, options dto.ImportOptions) error
+	RunDictTypeImport(jobId int, rows []dto.DictTypeImportRequest, options dto.ImportOptions) error
+	RunDictDataImport(jobId int, rows []dto.DictDataImportRequest, options dto.ImportOptions) error
+}
+
+// ImportJobService implements background import jobs
+//
+// An upload creates a pending job and returns its ID at once; the rows are then
+// validated and written in batches by a background worker, which records the
+// progress and every failed row with its error for the error report.
+type ImportJobService struct {
+	backgroundTaskService *BackgroundTaskService
+}
+
+// Ensure ImportJobService implements ImportJobServiceInterface
+var _ ImportJobServiceInterface = (*ImportJobService)(nil)
+
+// NewImportJobService creates a new ImportJobService running jobs on the background task service
+func NewImportJobService(backgroundTaskService *BackgroundTaskService) *ImportJobService {
+	return &ImportJobService{backgroundTaskService: backgroundTaskService}
+}
+
+// CreateImportJob creates a pending import job and returns its ID
+func (s *ImportJobService) CreateImportJob(jobType, fileName, createBy string) (int, error) {
+	job := model.SysImportJob{
+		JobType:  jobType,
+		FileName: fileName,
+		Status:   constant.IMPORT_JOB_STATUS_PENDING,
+		CreateBy: createBy,
+	}
+
+	if err := dal.Gorm.Create(&job).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to create import job")
+	}
+
+	return job.JobId, nil
+}
+
+// SubmitImportJob runs an import job in the background
+//
+// An error returned by run aborts the job with its message. The task itself always
+// succeeds, so the worker pool never retries a partially written import.
+func (s *ImportJobService) SubmitImportJob(jobId int, run func(ctx context.Context) error) error {
+	err := s.backgroundTaskService.SubmitImportTask(jobId, func(ctx context.Context) error {
+		if err := run(ctx); err != nil {
+			abortImportJob(jobId, err)
+		}
+		return nil
+	})
+	if err != nil {
+		abortImportJob(jobId, err)
+		return errors.Wrap(err, "failed to submit import job")
+	}
+
+	return nil
+}
+
+// GetImportJob retrieves the progress of an import job
+func (s *ImportJobService) GetImportJob(jobId int) (dto.ImportJobResponse, error) {
+	var job dto.ImportJobResponse
+
+	result := dal.Gorm.Model(model.SysImportJob{}).Where("job_id = ?", jobId).Limit(1).Find(&job)
+	if result.Error != nil {
+		return job, errors.Wrap(result.Error, "failed to retrieve import job")
+	}
+	if result.RowsAffected == 0 {
+		return job, xerrors.ErrImportJobNotFound
+	}
+
+	return job, nil
+}
+
+// GetImportJobErrors retrieves the failed rows of an import job in file order
+func (s *ImportJobService) GetImportJobErrors(jobId int) ([]dto.ImportJobErrorResponse, error) {
+	rowErrors := make([]dto.ImportJobErrorResponse, 0)
+
+	if err := dal.Gorm.Model(model.SysImportJobError{}).Where("job_id = ?", jobId).Order("row_num").Find(&rowErrors).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve import job errors")
+	}
+
+	return rowErrors, nil
+}
+
+// startImportJob marks an import job as running
+func startImportJob(jobId, total int) error {
+	if err := dal.Gorm.Model(model.SysImportJob{}).Where("job_id = ?", jobId).Updates(map[string]interface{}{
+		"status": constant.IMPORT_JOB_STATUS_RUNNING,
+		"total":  total,
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed to start import job")
+	}
+
+	return nil
+}
+
+// recordImportBatch adds the outcome of a batch to the progress of an import job
+func recordImportBatch(jobId, processed, succeeded int, rowErrors []model.SysImportJobError) error {
+	tx := dal.Gorm.Begin()
+
+	if len(rowErrors) > 0 {
+		if err := tx.Create(&rowErrors).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to record import errors")
+		}
+	}
+
+	if err := tx.Model(model.SysImportJob{}).Where("job_id = ?", jobId).Updates(map[string]interface{}{
+		"processed": gorm.Expr("processed + ?", processed),
+		"succeeded": gorm.Expr("succeeded + ?", succeeded),
+		"failed":    gorm.Expr("failed + ?", len(rowErrors)),
+	}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to record import progress")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// finishImportJob marks an import job as finished
+func finishImportJob(jobId int) error {
+	if err := dal.Gorm.Model(model.SysImportJob{}).Where("job_id = ?", jobId).Updates(map[string]interface{}{
+		"status":      constant.IMPORT_JOB_STATUS_FINISHED,
+		"finish_time": datetime.Datetime{Time: time.Now()},
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed to finish import job")
+	}
+
+	return nil
+}
+
+// abortImportJob marks an import job as aborted, keeping the rows processed so far
+func abortImportJob(jobId int, cause error) {
+	dal.Gorm.Model(model.SysImportJob{}).Where("job_id = ?", jobId).Updates(map[string]interface{}{
+		"status":      constant.IMPORT_JOB_STATUS_ABORTED,
+		"error_msg":   cause.Error(),
+		"finish_time": datetime.Datetime{Time: time.Now()},
+	})
+}
+
+// newImportJobError records a failed row with the data it was read with
+func newImportJobError(jobId, rowNum int, row interface{}, cause error) model.SysImportJobError {
+	rowData, _ := json.Marshal(row)
+
+	return model.SysImportJobError{
+		JobId:    jobId,
+		RowNum:   rowNum,
+		RowData:  string(rowData),
+		ErrorMsg: cause.Error(),
+	}
+}
+
+// importBatchFunc imports a batch of rows starting at the given index of the file,
+// returning the number of rows imported and the failed rows
+type importBatchFunc[T any] func(rows []T, offset int) (int, []model.SysImportJobError, error)
+
+// runImport runs an import job over all rows batch by batch, recording the progress after each batch
+//
+// Row numbers in the error report are Excel row numbers, the header being row 1.
+func runImport[T any](jobId int, rows []T, importBatch importBatchFunc[T]) error {
+	if len(rows) == 0 {
+		return xerrors.ErrImportDataEmpty
+	}
+
+	if err := startImportJob(jobId, len(rows)); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(rows); start += IMPORT_BATCH_SIZE {
+		end := min(start+IMPORT_BATCH_SIZE, len(rows))
+
+		succeeded, rowErrors, err := importBatch(rows[start:end], start)
+		if err != nil {
+			return err
+		}
+
+		if err := recordImportBatch(jobId, end-start, succeeded, rowErrors); err != nil {
+			return err
+		}
+	}
+
+	return finishImportJob(jobId)
+}
+
+// importEachRow builds a batch import that imports the rows one by one; an error fails only its row
+func importEachRow[T any](jobId int, importRow func(row T) error) importBatchFunc[T] {
+	return func(rows []T, offset int) (int, []model.SysImportJobError, error) {
+		succeeded := 0
+		rowErrors := make([]model.SysImportJobError, 0)
+
+		for index, row := range rows {
+			if err := importRow(row); err != nil {
+				rowErrors = append(rowErrors, newImportJobError(jobId, importRowNum(offset, index), row, err))
+				continue
+			}
+			succeeded++
+		}
+
+		return succeeded, rowErrors, nil
+	}
+}
+
+// importRowNum returns the Excel row number of a row of a batch
+func importRowNum(offset, index int) int {
+	return offset + index + 2
+}
+
+// userImporter holds what a user import resolves once for all batches
+type userImporter struct {
+	jobId          int
+	options        dto.ImportOptions
+	hashedPassword string
+	roleIdsByName  map[string]int
+	postIdsByName  map[string]int
+	grantable      map[int]error
+	seen           map[string]bool
+	attrs          []dto.UserAttrListResponse
+}
+
+// RunUserImport imports users in batches, creating new accounts with the initial password and,
+// when update support is enabled, updating existing ones. Roles and posts are assigned by name.
+func (s *ImportJobService) RunUserImport(jobId int, rows []dto.UserImportRequest, options dto.ImportOptions) error {
+	hashedPassword, err := password.Generate((&ConfigService{}).GetString(USER_INIT_PASSWORD_CONFIG_KEY))
+	if err != nil {
+		return errors.Wrap(err, "failed to process initial password")
+	}
+
+	importer := &userImporter{
+		jobId:          jobId,
+		options:        options,
+		hashedPassword: hashedPassword,
+		roleIdsByName:  make(map[string]int),
+		postIdsByName:  make(map[string]int),
+		grantable:      make(map[int]error),
+		seen:           make(map[string]bool),
+	}
+
+	roles := make([]model.SysRole, 0)
+	if err := dal.Gorm.Model(model.SysRole{}).Select("role_id", "role_name").Find(&roles).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve roles")
+	}
+	for _, role := range roles {
+		importer.roleIdsByName[role.RoleName] = role.RoleId
+	}
+
+	posts := make([]model.SysPost, 0)
+	if err := dal.Gorm.Model(model.SysPost{}).Select("post_id", "post_name").Find(&posts).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve posts")
+	}
+	for _, post := range posts {
+		importer.postIdsByName[post.PostName] = post.PostId
+	}
+
+	if importer.attrs, err = activeUserAttrs(dal.Gorm); err != nil {
+		return err
+	}
+
+	return runImport(jobId, rows, importer.importBatch)
+}
+
+// importBatch imports a batch of rows starting at the given index, creating the new accounts
+// in one transaction
+func (i *userImporter) importBatch(rows []dto.UserImportRequest, offset int) (int, []model.SysImportJobError, error) {
+	succeeded := 0
+	rowErrors := make([]model.SysImportJobError, 0)
+
+	userNames := make([]string, 0, len(rows))
+	for _, row := range rows {
+		userNames = append(userNames, row.UserName)
+	}
+
+	existing := make([]model.SysUser, 0)
+	if err := dal.Gorm.Model(model.SysUser{}).Select("user_id", "user_name").Where("user_name IN ?", userNames).Find(&existing).Error; err != nil {
+		return 0, nil, errors.Wrap(err, "failed to retrieve existing users")
+	}
+	userIdsByName := make(map[string]int, len(existing))
+	for _, user := range existing {
+		userIdsByName[user.UserName] = user.UserId
+	}
+
+	type newUser struct {
+		rowNum  int
+		row     dto.UserImportRequest
+		roleIds []int
+		postIds []int
+		attrs   map[string]string
+	}
+	newUsers := make([]newUser, 0)
+
+	for index, row := range rows {
+		rowNum := importRowNum(offset, index)
+
+		if err := i.checkRow(row); err != nil {
+			rowErrors = append(rowErrors, newImportJobError(i.jobId, rowNum, row, err))
+			continue
+		}
+
+		roleIds, postIds, err := i.resolveLinks(row)
+		if err != nil {
+			rowErrors = append(rowErrors, newImportJobError(i.jobId, rowNum, row, err))
+			continue
+		}
+
+		userId, exists := userIdsByName[row.UserName]
+
+		attrs, err := i.resolveAttrs(row, !exists)
+		if err != nil {
+			rowErrors = append(rowErrors, newImportJobError(i.jobId, rowNum, row, err))
+			continue
+		}
+
+		if !exists {
+			newUsers = append(newUsers, newUser{rowNum: rowNum, row: row, roleIds: roleIds, postIds: postIds, attrs: attrs})
+			continue
+		}
+
+		if !i.options.UpdateSupport {
+			rowErrors = append(rowErrors, newImportJobError(i.jobId, rowNum, row, xerrors.ErrImportUserExists))
+			continue
+		}
+
+		if err := i.updateUser(userId, row, roleIds, postIds, attrs); err != nil {
+			rowErrors = append(rowErrors, newImportJobError(i.jobId, rowNum, row, err))
+			continue
+		}
+		succeeded++
+	}
+
+	if len(newUsers) == 0 {
+		return succeeded, rowErrors, nil
+	}
+
+	// Create the new accounts of the batch together; if that fails, fall back to one
+	// transaction per account so a bad row only fails itself
+	tx := dal.Gorm.Begin()
+	var batchErr error
+	userIds := make([]int, 0, len(newUsers))
+	for _, user := range newUsers {
+		var userId int
+		if userId, batchErr = i.createUser(tx, user.row, user.roleIds, user.postIds, user.attrs); batchErr != nil {
+			break
+		}
+		userIds = append(userIds, userId)
+	}
+	if batchErr == nil {
+		batchErr = tx.Commit().Error
+	} else {
+		tx.Rollback()
+	}
+	if batchErr == nil {
+		notifySearch(constant.SEARCH_TYPE_USER, userIds...)
+		publishCacheIds(constant.CACHE_EVENT_USER, userIds...)
+		return succeeded + len(newUsers), rowErrors, nil
+	}
+
+	for _, user := range newUsers {
+		tx := dal.Gorm.Begin()
+		userId, err := i.createUser(tx, user.row, user.roleIds, user.postIds, user.attrs)
+		if err != nil {
+			tx.Rollback()
+			rowErrors = append(rowErrors, newImportJobError(i.jobId, user.rowNum, user.row, err))
+			continue
+		}
+		if err := tx.Commit().Error; err != nil {
+			rowErrors = append(rowErrors, newImportJobError(i.jobId, user.rowNum, user.row, err))
+			continue
+		}
+		notifySearch(constant.SEARCH_TYPE_USER, userId)
+		publishCacheIds(constant.CACHE_EVENT_USER, userId)
+		succeeded++
+	}
+
+	return succeeded, rowErrors, nil
+}
+
+// checkRow validates a row and rejects accounts appearing more than once in the file
+func (i *userImporter) checkRow(row dto.UserImportRequest) error {
+	if err := validator.ImportUserValidator(dto.CreateUserRequest{
+		DeptId:      row.DeptId,
+		UserName:    row.UserName,
+		NickName:    row.NickName,
+		Email:       row.Email,
+		Phonenumber: row.Phonenumber,
+		Sex:         row.Sex,
+		Status:      row.Status,
+	}); err != nil {
+		return err
+	}
+
+	if i.seen[row.UserName] {
+		return xerrors.ErrImportUserDuplicate
+	}
+	i.seen[row.UserName] = true
+
+	return nil
+}
+
+// resolveLinks resolves the comma-separated role and post names of a row; an empty column
+// yields nil so that updates keep the current assignment
+func (i *userImporter) resolveLinks(row dto.UserImportRequest) ([]int, []int, error) {
+	var roleIds, postIds []int
+
+	for _, name := range splitImportNames(row.RoleNames) {
+		roleId, ok := i.roleIdsByName[name]
+		if !ok {
+			return nil, nil, errors.Wrap(xerrors.ErrImportRoleNotFound, name)
+		}
+
+		grantErr, checked := i.grantable[roleId]
+		if !checked {
+			grantErr = (&RoleService{}).CheckRolesGrantable(i.options.OperatorId, []int{roleId})
+			i.grantable[roleId] = grantErr
+		}
+		if grantErr != nil {
+			return nil, nil, errors.Wrap(grantErr, name)
+		}
+
+		roleIds = append(roleIds, roleId)
+	}
+
+	for _, name := range splitImportNames(row.PostNames) {
+		postId, ok := i.postIdsByName[name]
+		if !ok {
+			return nil, nil, errors.Wrap(xerrors.ErrImportPostNotFound, name)
+		}
+		postIds = append(postIds, postId)
+	}
+
+	return roleIds, postIds, nil
+}
+
+// resolveAttrs parses and validates the custom attributes of a row; an empty column yields nil
+// so that updates keep the current values, while new accounts still need their required attributes
+func (i *userImporter) resolveAttrs(row dto.UserImportRequest, isNew bool) (map[string]string, error) {
+	attrs, err := parseImportAttrs(row.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	if attrs != nil || isNew {
+		if err = checkUserAttrValues(i.attrs, attrs); err != nil {
+			return nil, err
+		}
+	}
+
+	return attrs, nil
+}
+
+// createUser creates an account with the initial password, its role and post links and its custom attributes,
+// returning its ID
+func (i *userImporter) createUser(tx *gorm.DB, row dto.UserImportRequest, roleIds, postIds []int, attrs map[string]string) (int, error) {
+	user := model.SysUser{
+		DeptId:      row.DeptId,
+		UserName:    row.UserName,
+		NickName:    row.NickName,
+		Email:       cipher.String[model.SysUserEmail](row.Email),
+		Phonenumber: cipher.String[model.SysUserPhonenumber](row.Phonenumber),
+		Sex:         row.Sex,
+		Password:    i.hashedPassword,
+		Status:      row.Status,
+		CreateBy:    i.options.OperatorName,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to create user")
+	}
+
+	for _, roleId := range roleIds {
+		if err := tx.Create(&model.SysUserRole{UserId: user.UserId, RoleId: roleId}).Error; err != nil {
+			return 0, errors.Wrapf(err, "failed to assign role ID %d", roleId)
+		}
+	}
+
+	for _, postId := range postIds {
+		if err := tx.Create(&model.SysUserPost{UserId: user.UserId, PostId: postId}).Error; err != nil {
+			return 0, errors.Wrapf(err, "failed to assign post ID %d", postId)
+		}
+	}
+
+	if attrs != nil {
+		if err := saveUserAttrValues(tx, user.UserId, attrs); err != nil {
+			return 0, err
+		}
+	}
+
+	return user.UserId, nil
+}
+
+// updateUser updates an existing account from a row
+func (i *userImporter) updateUser(userId int, row dto.UserImportRequest, roleIds, postIds []int, attrs map[string]string) error {
+	if err := validator.UpdateUserValidator(dto.UpdateUserRequest{
+		UserId:      userId,
+		DeptId:      row.DeptId,
+		NickName:    row.NickName,
+		Email:       row.Email,
+		Phonenumber: row.Phonenumber,
+		Sex:         row.Sex,
+		Status:      row.Status,
+	}); err != nil {
+		return err
+	}
+
+	return (&UserService{}).UpdateUser(dto.SaveUser{
+		UserId:      userId,
+		DeptId:      row.DeptId,
+		NickName:    row.NickName,
+		Email:       row.Email,
+		Phonenumber: row.Phonenumber,
+		Sex:         row.Sex,
+		Status:      row.Status,
+		UpdateBy:    i.options.OperatorName,
+		Attributes:  attrs,
+	}, roleIds, postIds)
+}
+
+// parseImportAttrs parses custom attributes written as key=value pairs separated by semicolons,
+// ignoring blanks; an empty column yields nil
+func parseImportAttrs(value string) (map[string]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	attrs := make(map[string]string)
+	for _, pair := range strings.Split(value, ";") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		key, attrValue, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, errors.Wrap(xerrors.ErrImportAttrFormat, pair)
+		}
+		attrs[key] = strings.TrimSpace(attrValue)
+	}
+
+	return attrs, nil
+}
+
+// splitImportNames splits a comma-separated list of names, ignoring blanks
+func splitImportNames(value string) []string {
+	names := make([]string, 0)
+
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// deptImporter holds the department tree a department import resolves paths against
+type deptImporter struct {
+	options     dto.ImportOptions
+	deptsByPath map[string]model.SysDept
+	seen        map[string]bool
+	createdIds  []int
+}
+
+// RunDeptImport imports departments, resolving each parent by its name path from the top-level
+// department, e.g. "HQ/Sales/East". Parents must exist or appear earlier in the file.
+func (s *ImportJobService) RunDeptImport(jobId int, rows []dto.DeptImportRequest, options dto.ImportOptions) error {
+	depts := make([]model.SysDept, 0)
+	if err := dal.Gorm.Model(model.SysDept{}).Find(&depts).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve departments")
+	}
+
+	deptMap := make(map[int]model.SysDept, len(depts))
+	for _, dept := range depts {
+		deptMap[dept.DeptId] = dept
+	}
+
+	importer := &deptImporter{
+		options:     options,
+		deptsByPath: make(map[string]model.SysDept, len(depts)),
+		seen:        make(map[string]bool),
+	}
+
+	for _, dept := range depts {
+		names := make([]string, 0)
+		for _, id := range strings.Split(dept.Ancestors, ",") {
+			ancestorId, _ := strconv.Atoi(id)
+			if ancestor, ok := deptMap[ancestorId]; ok {
+				names = append(names, ancestor.DeptName)
+			}
+		}
+		importer.deptsByPath[strings.Join(append(names, dept.DeptName), "/")] = dept
+	}
+
+	// Updates invalidate as they go, created departments join the scopes of their parents
+	defer func() {
+		invalidateDeptCaches(deptCacheTags(importer.createdIds...))
+	}()
+
+	return runImport(jobId, rows, importEachRow(jobId, importer.importRow))
+}
+
+// importRow creates or updates the department at the path of a row
+func (i *deptImporter) importRow(row dto.DeptImportRequest) error {
+	names := splitImportPath(row.DeptPath)
+	if len(names) == 0 {
+		return xerrors.ErrImportDeptPathEmpty
+	}
+
+	path := strings.Join(names, "/")
+	if i.seen[path] {
+		return xerrors.ErrImportRowDuplicate
+	}
+	i.seen[path] = true
+
+	deptName := names[len(names)-1]
+
+	if dept, exists := i.deptsByPath[path]; exists {
+		if !i.options.UpdateSupport {
+			return xerrors.ErrImportRowExists
+		}
+
+		if err := validator.UpdateDeptValidator(dto.UpdateDeptRequest{
+			DeptId:   dept.DeptId,
+			ParentId: dept.ParentId,
+			DeptName: deptName,
+			OrderNum: row.OrderNum,
+			Leader:   row.Leader,
+			Phone:    row.Phone,
+			Email:    row.Email,
+			Status:   row.Status,
+		}); err != nil {
+			return err
+		}
+
+		return (&DeptService{}).UpdateDept(dto.SaveDept{
+			DeptId:   dept.DeptId,
+			ParentId: dept.ParentId,
+			DeptName: deptName,
+			OrderNum: row.OrderNum,
+			Leader:   row.Leader,
+			Phone:    row.Phone,
+			Email:    row.Email,
+			Status:   row.Status,
+			UpdateBy: i.options.OperatorName,
+		})
+	}
+
+	parent, parentExists := i.deptsByPath[strings.Join(names[:len(names)-1], "/")]
+	if len(names) > 1 && !parentExists {
+		return xerrors.ErrImportDeptParent
+	}
+
+	if err := validator.CreateDeptValidator(dto.CreateDeptRequest{
+		ParentId: parent.DeptId,
+		DeptName: deptName,
+		OrderNum: row.OrderNum,
+		Leader:   row.Leader,
+		Phone:    row.Phone,
+		Email:    row.Email,
+		Status:   row.Status,
+	}); err != nil {
+		return err
+	}
+
+	if parent.Status == constant.EXCEPTION_STATUS {
+		return xerrors.ErrDeptParentDisabled
+	}
+
+	if dept := (&DeptService{}).GetDeptByDeptName(deptName); dept.DeptId > 0 {
+		return xerrors.ErrImportDeptNameTaken
+	}
+
+	if err := (&DeptService{}).CreateDept(dto.SaveDept{
+		ParentId:  parent.DeptId,
+		Ancestors: parent.Ancestors + "," + strconv.Itoa(parent.DeptId),
+		DeptName:  deptName,
+		OrderNum:  row.OrderNum,
+		Leader:    row.Leader,
+		Phone:     row.Phone,
+		Email:     row.Email,
+		Status:    row.Status,
+		CreateBy:  i.options.OperatorName,
+	}); err != nil {
+		return err
+	}
+
+	// Later rows may use the new department as their parent
+	var dept model.SysDept
+	if err := dal.Gorm.Model(model.SysDept{}).Where("parent_id = ? AND dept_name = ?", parent.DeptId, deptName).Take(&dept).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve the created department")
+	}
+	i.deptsByPath[path] = dept
+	i.createdIds = append(i.createdIds, dept.DeptId)
+
+	return nil
+}
+
+// RunPostImport imports posts, matching existing posts by post code
+func (s *ImportJobService) RunPostImport(jobId int, rows []dto.PostImportRequest, options dto.ImportOptions) error {
+	seen := make(map[string]bool)
+
+	return runImport(jobId, rows, importEachRow(jobId, func(row dto.PostImportRequest) error {
+		if err := validator.CreatePostValidator(dto.CreatePostRequest{
+			PostCode: row.PostCode,
+			PostName: row.PostName,
+			PostSort: row.PostSort,
+			Status:   row.Status,
+			Remark:   row.Remark,
+		}); err != nil {
+			return err
+		}
+
+		if seen[row.PostCode] {
+			return xerrors.ErrImportRowDuplicate
+		}
+		seen[row.PostCode] = true
+
+		existing := (&PostService{}).GetPostByPostCode(row.PostCode)
+		postId := existing.PostId
+		if postId > 0 && !options.UpdateSupport {
+			return xerrors.ErrImportRowExists
+		}
+
+		if post := (&PostService{}).GetPostByPostName(row.PostName); post.PostId > 0 && post.PostId != postId {
+			return xerrors.ErrImportPostNameTaken
+		}
+
+		if postId <= 0 {
+			return (&PostService{}).CreatePost(dto.SavePost{
+				PostCode: row.PostCode,
+				PostName: row.PostName,
+				PostSort: row.PostSort,
+				Status:   row.Status,
+				CreateBy: options.OperatorName,
+				Remark:   row.Remark,
+			}, nil)
+		}
+
+		if err := validator.UpdatePostValidator(dto.UpdatePostRequest{
+			PostId:   postId,
+			ParentId: existing.ParentId,
+			PostCode: row.PostCode,
+			PostName: row.PostName,
+			PostSort: row.PostSort,
+			Status:   row.Status,
+			Remark:   row.Remark,
+		}); err != nil {
+			return err
+		}
+
+		// The import file carries no hierarchy or default roles, updated posts keep theirs
+		return (&PostService{}).UpdatePost(dto.SavePost{
+			PostId:   postId,
+			ParentId: existing.ParentId,
+			PostCode: row.PostCode,
+			PostName: row.PostName,
+			PostSort: row.PostSort,
+			Status:   row.Status,
+			UpdateBy: options.OperatorName,
+			Remark:   row.Remark,
+		}, nil)
+	}))
+}
+
+// roleImporter holds the menus a role import resolves permission strings against
+type roleImporter struct {
+	options        dto.ImportOptions
+	menuIdsByPerms map[string]int
+	parentIds      map[int]int
+	seen           map[string]bool
+}
+
+// RunRoleImport imports roles, matching existing roles by role key. Menus are granted by their
+// comma-separated permission strings, together with the directories and menus above them;
+// an empty column keeps the menus of an existing role.
+func (s *ImportJobService) RunRoleImport(jobId int, rows []dto.RoleImportRequest, options dto.ImportOptions) error {
+	menus := make([]model.SysMenu, 0)
+	if err := dal.Gorm.Model(model.SysMenu{}).Select("menu_id", "parent_id", "perms").Find(&menus).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve menus")
+	}
+
+	importer := &roleImporter{
+		options:        options,
+		menuIdsByPerms: make(map[string]int),
+		parentIds:      make(map[int]int, len(menus)),
+		seen:           make(map[string]bool),
+	}
+	for _, menu := range menus {
+		if menu.Perms != "" {
+			importer.menuIdsByPerms[menu.Perms] = menu.MenuId
+		}
+		importer.parentIds[menu.MenuId] = menu.ParentId
+	}
+
+	return runImport(jobId, rows, importEachRow(jobId, importer.importRow))
+}
+
+// importRow creates or updates the role of a row
+func (i *roleImporter) importRow(row dto.RoleImportRequest) error {
+	if err := validator.CreateRoleValidator(dto.CreateRoleRequest{
+		RoleName: row.RoleName,
+		RoleKey:  row.RoleKey,
+		RoleSort: row.RoleSort,
+		Status:   row.Status,
+		Remark:   row.Remark,
+	}); err != nil {
+		return err
+	}
+
+	if i.seen[row.RoleKey] {
+		return xerrors.ErrImportRowDuplicate
+	}
+	i.seen[row.RoleKey] = true
+
+	menuIds, err := i.resolveMenus(row.Perms)
+	if err != nil {
+		return err
+	}
+
+	role, err := (&RoleService{}).GetRoleByRoleKey(row.RoleKey)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if role.RoleId > 0 && !i.options.UpdateSupport {
+		return xerrors.ErrImportRowExists
+	}
+
+	sameName, err := (&RoleService{}).GetRoleByRoleName(row.RoleName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if sameName.RoleId > 0 && sameName.RoleId != role.RoleId {
+		return xerrors.ErrImportRoleNameTaken
+	}
+
+	if role.RoleId <= 0 {
+		menuCheckStrictly, deptCheckStrictly := 1, 1
+
+		return (&RoleService{}).CreateRole(dto.SaveRole{
+			RoleName:          row.RoleName,
+			RoleKey:           row.RoleKey,
+			RoleSort:          row.RoleSort,
+			MenuCheckStrictly: &menuCheckStrictly,
+			DeptCheckStrictly: &deptCheckStrictly,
+			Status:            row.Status,
+			CreateBy:          i.options.OperatorName,
+			Remark:            row.Remark,
+		}, menuIds)
+	}
+
+	if err := validator.UpdateRoleValidator(dto.UpdateRoleRequest{
+		RoleId:   role.RoleId,
+		RoleName: row.RoleName,
+		RoleKey:  row.RoleKey,
+		RoleSort: row.RoleSort,
+		Status:   row.Status,
+		Remark:   row.Remark,
+	}); err != nil {
+		return err
+	}
+
+	return (&RoleService{}).UpdateRole(dto.SaveRole{
+		RoleId:   role.RoleId,
+		RoleName: row.RoleName,
+		RoleKey:  row.RoleKey,
+		RoleSort: row.RoleSort,
+		Status:   row.Status,
+		UpdateBy: i.options.OperatorName,
+		Remark:   row.Remark,
+	}, menuIds, nil)
+}
+
+// resolveMenus resolves comma-separated permission strings to menu IDs, adding the ancestors
+// of each menu; an empty list yields nil
+func (i *roleImporter) resolveMenus(perms string) ([]int, error) {
+	var menuIds []int
+	granted := make(map[int]bool)
+
+	for _, perm := range splitImportNames(perms) {
+		menuId, ok := i.menuIdsByPerms[perm]
+		if !ok {
+			return nil, errors.Wrap(xerrors.ErrImportMenuNotFound, perm)
+		}
+
+		for menuId > 0 && !granted[menuId] {
+			granted[menuId] = true
+			menuIds = append(menuIds, menuId)
+			menuId = i.parentIds[menuId]
+		}
+	}
+
+	return menuIds, nil
+}
+
+// RunDictTypeImport imports dictionary types, matching existing types by dictionary type
+func (s *ImportJobService) RunDictTypeImport(jobId int, rows []dto.DictTypeImportRequest, options dto.ImportOptions) error {
+	seen := make(map[string]bool)
+
+	return runImport(jobId, rows, importEachRow(jobId, func(row dto.DictTypeImportRequest) error {
+		if err := validator.CreateDictTypeValidator(dto.CreateDictTypeRequest{
+			DictName: row.DictName,
+			DictType: row.DictType,
+			Status:   row.Status,
+			Remark:   row.Remark,
+		}); err != nil {
+			return err
+		}
+
+		if seen[row.DictType] {
+			return xerrors.ErrImportRowDuplicate
+		}
+		seen[row.DictType] = true
+
+		dictId := (&DictTypeService{}).GetDcitTypeByDictType(row.DictType).DictId
+		if dictId <= 0 {
+			return (&DictTypeService{}).CreateDictType(dto.SaveDictType{
+				DictName: row.DictName,
+				DictType: row.DictType,
+				Status:   row.Status,
+				CreateBy: options.OperatorName,
+				Remark:   row.Remark,
+			})
+		}
+
+		if !options.UpdateSupport {
+			return xerrors.ErrImportRowExists
+		}
+
+		if err := validator.UpdateDictTypeValidator(dto.UpdateDictTypeRequest{
+			DictId:   dictId,
+			DictName: row.DictName,
+			DictType: row.DictType,
+			Status:   row.Status,
+			Remark:   row.Remark,
+		}); err != nil {
+			return err
+		}
+
+		return (&DictTypeService{}).UpdateDictType(dto.SaveDictType{
+			DictId:   dictId,
+			DictName: row.DictName,
+			DictType: row.DictType,
+			Status:   row.Status,
+			UpdateBy: options.OperatorName,
+			Remark:   row.Remark,
+		})
+	}))
+}
+
+// RunDictDataImport imports dictionary data, matching existing data by dictionary type and key value.
+// The dictionary types must exist; their cached data is dropped once the import ends.
+func (s *ImportJobService) RunDictDataImport(jobId int, rows []dto.DictDataImportRequest, options dto.ImportOptions) error {
+	seen := make(map[string]bool)
+	dictTypes := make(map[string]bool)
+
+	defer func() {
+		importedDictTypes := make([]string, 0, len(dictTypes))
+		for dictType, exists := range dictTypes {
+			if exists {
+				importedDictTypes = append(importedDictTypes, dictType)
+			}
+		}
+		if len(importedDictTypes) > 0 {
+			refreshDictCache(importedDictTypes...)
+		}
+	}()
+
+	return runImport(jobId, rows, importEachRow(jobId, func(row dto.DictDataImportRequest) error {
+		if err := validator.CreateDictDataValidator(dto.CreateDictDataRequest{
+			DictSort:  row.DictSort,
+			DictLabel: row.DictLabel,
+			DictValue: row.DictValue,
+			DictType:  row.DictType,
+			CssClass:  row.CssClass,
+			ListClass: row.ListClass,
+			IsDefault: row.IsDefault,
+			Status:    row.Status,
+			Remark:    row.Remark,
+		}); err != nil {
+			return err
+		}
+
+		exists, checked := dictTypes[row.DictType]
+		if !checked {
+			exists = (&DictTypeService{}).GetDcitTypeByDictType(row.DictType).DictId > 0
+			dictTypes[row.DictType] = exists
+		}
+		if !exists {
+			return xerrors.ErrImportDictNotFound
+		}
+
+		key := row.DictType + "/" + row.DictValue
+		if seen[key] {
+			return xerrors.ErrImportRowDuplicate
+		}
+		seen[key] = true
+
+		var dictCode int
+		if err := dal.Gorm.Model(model.SysDictData{}).Where("dict_type = ? AND dict_value = ?", row.DictType, row.DictValue).Limit(1).Pluck("dict_code", &dictCode).Error; err != nil {
+			return errors.Wrap(err, "failed to retrieve dictionary data")
+		}
+
+		if dictCode <= 0 {
+			return (&DictDataService{}).CreateDictData(dto.SaveDictData{
+				DictSort:  row.DictSort,
+				DictLabel: row.DictLabel,
+				DictValue: row.DictValue,
+				DictType:  row.DictType,
+				CssClass:  row.CssClass,
+				ListClass: row.ListClass,
+				IsDefault: row.IsDefault,
+				Status:    row.Status,
+				CreateBy:  options.OperatorName,
+				Remark:    row.Remark,
+			})
+		}
+
+		if !options.UpdateSupport {
+			return xerrors.ErrImportRowExists
+		}
+
+		if err := validator.UpdateDictDataValidator(dto.UpdateDictDataRequest{
+			DictCode:  dictCode,
+			DictSort:  row.DictSort,
+			DictLabel: row.DictLabel,
+			DictValue: row.DictValue,
+			DictType:  row.DictType,
+			CssClass:  row.CssClass,
+			ListClass: row.ListClass,
+			IsDefault: row.IsDefault,
+			Status:    row.Status,
+			Remark:    row.Remark,
+		}); err != nil {
+			return err
+		}
+
+		return (&DictDataService{}).UpdateDictData(dto.SaveDictData{
+			DictCode:  dictCode,
+			DictSort:  row.DictSort,
+			DictLabel: row.DictLabel,
+			DictValue: row.DictValue,
+			DictType:  row.DictType,
+			CssClass:  row.CssClass,
+			ListClass: row.ListClass,
+			IsDefault: row.IsDefault,
+			Status:    row.Status,
+			UpdateBy:  options.OperatorName,
+			Remark:    row.Remark,
+		})
+	}))
+}
+
+// splitImportPath splits a slash-separated department path, ignoring blanks
+func splitImportPath(value string) []string {
+	names := make([]string, 0)
+
+	for _, name := range strings.Split(value, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seedImportJob() {
+	dal.Gorm.Create(&model.SysConfig{ConfigId: 1, ConfigKey: "sys.user.initPassword", ConfigValue: "123456"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 100, UserName: "manager", NickName: "Manager", Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Common", RoleKey: "common", Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "ceo", PostName: "CEO", Status: "0"})
+}
+
+func TestImportJobService_RunUserImport(t *testing.T) {
+	setup()
+	defer teardown()
+	seedImportJob()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_USER, "users.xlsx", "admin")
+	assert.NoError(t, err)
+
+	rows := []dto.UserImportRequest{
+		{DeptId: 100, UserName: "alice", NickName: "Alice", Status: "0", RoleNames: "Common", PostNames: "CEO"},
+		{DeptId: 100, UserName: "bob", NickName: "Bob", Status: "0", RoleNames: "Auditor"},
+		{DeptId: 100, UserName: "alice", NickName: "Alice Again", Status: "0"},
+		{DeptId: 100, UserName: "manager", NickName: "Manager", Status: "0"},
+		{DeptId: 100, UserName: "carol", Status: "0"},
+	}
+
+	assert.NoError(t, s.RunUserImport(jobId, rows, dto.ImportOptions{OperatorId: 1, OperatorName: "admin"}))
+
+	t.Run("should report the progress of the job", func(t *testing.T) {
+		job, err := s.GetImportJob(jobId)
+		assert.NoError(t, err)
+		assert.Equal(t, constant.IMPORT_JOB_STATUS_FINISHED, job.Status)
+		assert.Equal(t, 5, job.Total)
+		assert.Equal(t, 5, job.Processed)
+		assert.Equal(t, 1, job.Succeeded)
+		assert.Equal(t, 4, job.Failed)
+	})
+
+	t.Run("should assign roles and posts by name", func(t *testing.T) {
+		user := (&UserService{}).GetUserByUsername("alice")
+		assert.NotZero(t, user.UserId)
+
+		roleIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUserRole{}).Where("user_id = ?", user.UserId).Pluck("role_id", &roleIds)
+		assert.Equal(t, []int{2}, roleIds)
+
+		postIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUserPost{}).Where("user_id = ?", user.UserId).Pluck("post_id", &postIds)
+		assert.Equal(t, []int{1}, postIds)
+	})
+
+	t.Run("should record each failed row with its error", func(t *testing.T) {
+		rowErrors, err := s.GetImportJobErrors(jobId)
+		assert.NoError(t, err)
+		assert.Len(t, rowErrors, 4)
+
+		assert.Equal(t, 3, rowErrors[0].RowNum)
+		assert.Contains(t, rowErrors[0].RowData, "bob")
+		assert.Contains(t, rowErrors[0].ErrorMsg, xerrors.ErrImportRoleNotFound.Error())
+		assert.Equal(t, xerrors.ErrImportUserDuplicate.Error(), rowErrors[1].ErrorMsg)
+		assert.Equal(t, xerrors.ErrImportUserExists.Error(), rowErrors[2].ErrorMsg)
+		assert.Equal(t, xerrors.ErrUserNicknameEmpty.Error(), rowErrors[3].ErrorMsg)
+	})
+}
+
+func TestImportJobService_RunUserImportUpdate(t *testing.T) {
+	setup()
+	defer teardown()
+	seedImportJob()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_USER, "users.xlsx", "admin")
+	assert.NoError(t, err)
+
+	rows := []dto.UserImportRequest{
+		{DeptId: 100, UserName: "manager", NickName: "Head", Status: "0", RoleNames: "Common"},
+	}
+
+	t.Run("should update existing users when update support is enabled", func(t *testing.T) {
+		assert.NoError(t, s.RunUserImport(jobId, rows, dto.ImportOptions{UpdateSupport: true, OperatorId: 1, OperatorName: "admin"}))
+
+		assert.Equal(t, "Head", (&UserService{}).GetUserByUsername("manager").NickName)
+
+		roleIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUserRole{}).Where("user_id = ?", 2).Pluck("role_id", &roleIds)
+		assert.Equal(t, []int{2}, roleIds)
+
+		job, _ := s.GetImportJob(jobId)
+		assert.Equal(t, 1, job.Succeeded)
+	})
+}
+
+func TestImportJobService_RunUserImportBatches(t *testing.T) {
+	setup()
+	defer teardown()
+	seedImportJob()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_USER, "users.xlsx", "admin")
+	assert.NoError(t, err)
+
+	rows := make([]dto.UserImportRequest, 0, IMPORT_BATCH_SIZE+10)
+	for i := 0; i < IMPORT_BATCH_SIZE+10; i++ {
+		rows = append(rows, dto.UserImportRequest{DeptId: 100, UserName: "user" + strconv.Itoa(i), NickName: "User", Status: "0"})
+	}
+
+	t.Run("should import every batch", func(t *testing.T) {
+		assert.NoError(t, s.RunUserImport(jobId, rows, dto.ImportOptions{OperatorId: 1, OperatorName: "admin"}))
+
+		job, _ := s.GetImportJob(jobId)
+		assert.Equal(t, IMPORT_BATCH_SIZE+10, job.Processed)
+		assert.Equal(t, IMPORT_BATCH_SIZE+10, job.Succeeded)
+
+		var count int64
+		dal.Gorm.Model(model.SysUser{}).Where("user_name LIKE ?", "user%").Count(&count)
+		assert.Equal(t, int64(IMPORT_BATCH_SIZE+10), count)
+	})
+
+	t.Run("should reject an empty file", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrImportDataEmpty, s.RunUserImport(jobId, nil, dto.ImportOptions{}))
+	})
+}
+
+func TestImportJobService_SubmitImportJob(t *testing.T) {
+	setup()
+	defer teardown()
+	bts := NewBackgroundTaskService(1)
+	assert.NoError(t, bts.Start())
+	defer bts.Stop(time.Second)
+	s := NewImportJobService(bts)
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_USER, "users.xlsx", "admin")
+	assert.NoError(t, err)
+
+	t.Run("should abort the job when it fails", func(t *testing.T) {
+		assert.NoError(t, s.SubmitImportJob(jobId, func(ctx context.Context) error {
+			return errors.New("invalid excel file")
+		}))
+
+		assert.Eventually(t, func() bool {
+			job, _ := s.GetImportJob(jobId)
+			return job.Status == constant.IMPORT_JOB_STATUS_ABORTED
+		}, 5*time.Second, 50*time.Millisecond)
+
+		job, _ := s.GetImportJob(jobId)
+		assert.Equal(t, "invalid excel file", job.ErrorMsg)
+	})
+
+	t.Run("should report a missing job", func(t *testing.T) {
+		_, err := s.GetImportJob(jobId + 1)
+		assert.Equal(t, xerrors.ErrImportJobNotFound, err)
+	})
+}
+
+func TestImportJobService_RunDeptImport(t *testing.T) {
+	setup()
+	defer teardown()
+	seedImportJob()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_DEPT, "depts.xlsx", "admin")
+	assert.NoError(t, err)
+
+	rows := []dto.DeptImportRequest{
+		{DeptPath: "HQ/Sales", Status: "0"},
+		{DeptPath: "HQ / Sales / East", Leader: "east", Status: "0"},
+		{DeptPath: "HQ/Support/West", Status: "0"},
+		{DeptPath: "HQ/Sales", Status: "0"},
+		{DeptPath: "Branch", Status: "0"},
+		{DeptPath: "HQ", Leader: "boss", Status: "0"},
+	}
+
+	assert.NoError(t, s.RunDeptImport(jobId, rows, dto.ImportOptions{OperatorId: 1, OperatorName: "admin"}))
+
+	t.Run("should resolve parents by name path", func(t *testing.T) {
+		sales := (&DeptService{}).GetDeptByDeptName("Sales")
+		assert.Equal(t, 100, sales.ParentId)
+
+		east := (&DeptService{}).GetDeptByDeptName("East")
+		assert.Equal(t, sales.DeptId, east.ParentId)
+		assert.Equal(t, "0,100,"+strconv.Itoa(sales.DeptId), east.Ancestors)
+		assert.Equal(t, "east", east.Leader)
+	})
+
+	t.Run("should record each failed row with its error", func(t *testing.T) {
+		rowErrors, err := s.GetImportJobErrors(jobId)
+		assert.NoError(t, err)
+		assert.Len(t, rowErrors, 4)
+
+		assert.Equal(t, 4, rowErrors[0].RowNum)
+		assert.Equal(t, xerrors.ErrImportDeptParent.Error(), rowErrors[0].ErrorMsg)
+		assert.Equal(t, xerrors.ErrImportRowDuplicate.Error(), rowErrors[1].ErrorMsg)
+		assert.Equal(t, xerrors.ErrParentDeptEmpty.Error(), rowErrors[2].ErrorMsg)
+		assert.Equal(t, xerrors.ErrImportRowExists.Error(), rowErrors[3].ErrorMsg)
+	})
+
+	t.Run("should update existing departments when update support is enabled", func(t *testing.T) {
+		jobId, _ := s.CreateImportJob(constant.IMPORT_JOB_TYPE_DEPT, "depts.xlsx", "admin")
+
+		assert.NoError(t, s.RunDeptImport(jobId, rows[5:], dto.ImportOptions{UpdateSupport: true, OperatorId: 1, OperatorName: "admin"}))
+
+		assert.Equal(t, "boss", (&DeptService{}).GetDeptByDeptId(100).Leader)
+	})
+}
+
+func TestImportJobService_RunPostImport(t *testing.T) {
+	setup()
+	defer teardown()
+	seedImportJob()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_POST, "posts.xlsx", "admin")
+	assert.NoError(t, err)
+
+	rows := []dto.PostImportRequest{
+		{PostCode: "cto", PostName: "CTO", PostSort: 2, Status: "0"},
+		{PostCode: "ceo", PostName: "Chief Executive", Status: "0"},
+		{PostCode: "coo", PostName: "CEO", Status: "0"},
+		{PostCode: "", PostName: "Nobody", Status: "0"},
+	}
+
+	assert.NoError(t, s.RunPostImport(jobId, rows, dto.ImportOptions{OperatorId: 1, OperatorName: "admin"}))
+
+	t.Run("should create new posts and report the failed rows", func(t *testing.T) {
+		assert.Equal(t, "CTO", (&PostService{}).GetPostByPostCode("cto").PostName)
+
+		rowErrors, _ := s.GetImportJobErrors(jobId)
+		assert.Len(t, rowErrors, 3)
+		assert.Equal(t, xerrors.ErrImportRowExists.Error(), rowErrors[0].ErrorMsg)
+		assert.Equal(t, xerrors.ErrImportPostNameTaken.Error(), rowErrors[1].ErrorMsg)
+		assert.Equal(t, xerrors.ErrPostCodeEmpty.Error(), rowErrors[2].ErrorMsg)
+	})
+
+	t.Run("should update existing posts when update support is enabled", func(t *testing.T) {
+		jobId, _ := s.CreateImportJob(constant.IMPORT_JOB_TYPE_POST, "posts.xlsx", "admin")
+
+		assert.NoError(t, s.RunPostImport(jobId, rows[1:2], dto.ImportOptions{UpdateSupport: true, OperatorId: 1, OperatorName: "admin"}))
+
+		assert.Equal(t, "Chief Executive", (&PostService{}).GetPostByPostCode("ceo").PostName)
+	})
+}
+
+func TestImportJobService_RunRoleImport(t *testing.T) {
+	setup()
+	defer teardown()
+	seedImportJob()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	dal.Gorm.Create(&model.SysMenu{MenuId: 1, MenuName: "System", MenuType: "M", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 100, MenuName: "User", ParentId: 1, MenuType: "C", Perms: "system:user:list", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 1000, MenuName: "User Query", ParentId: 100, MenuType: "F", Perms: "system:user:query", Status: "0"})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 100})
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_ROLE, "roles.xlsx", "admin")
+	assert.NoError(t, err)
+
+	rows := []dto.RoleImportRequest{
+		{RoleName: "Auditor", RoleKey: "auditor", Status: "0", Perms: "system:user:query"},
+		{RoleName: "Viewer", RoleKey: "viewer", Status: "0", Perms: "system:user:view"},
+		{RoleName: "Common", RoleKey: "reader", Status: "0"},
+		{RoleName: "Common Staff", RoleKey: "common", Status: "0"},
+	}
+
+	assert.NoError(t, s.RunRoleImport(jobId, rows, dto.ImportOptions{OperatorId: 1, OperatorName: "admin"}))
+
+	t.Run("should grant menus by permission string with their ancestors", func(t *testing.T) {
+		role, err := (&RoleService{}).GetRoleByRoleKey("auditor")
+		assert.NoError(t, err)
+
+		menuIds := make([]int, 0)
+		dal.Gorm.Model(model.SysRoleMenu{}).Where("role_id = ?", role.RoleId).Pluck("menu_id", &menuIds)
+		assert.ElementsMatch(t, []int{1, 100, 1000}, menuIds)
+	})
+
+	t.Run("should record each failed row with its error", func(t *testing.T) {
+		rowErrors, _ := s.GetImportJobErrors(jobId)
+		assert.Len(t, rowErrors, 3)
+		assert.Contains(t, rowErrors[0].ErrorMsg, xerrors.ErrImportMenuNotFound.Error())
+		assert.Contains(t, rowErrors[0].ErrorMsg, "system:user:view")
+		assert.Equal(t, xerrors.ErrImportRoleNameTaken.Error(), rowErrors[1].ErrorMsg)
+		assert.Equal(t, xerrors.ErrImportRowExists.Error(), rowErrors[2].ErrorMsg)
+	})
+
+	t.Run("should keep the menus of a role updated without permissions", func(t *testing.T) {
+		jobId, _ := s.CreateImportJob(constant.IMPORT_JOB_TYPE_ROLE, "roles.xlsx", "admin")
+
+		assert.NoError(t, s.RunRoleImport(jobId, rows[3:], dto.ImportOptions{UpdateSupport: true, OperatorId: 1, OperatorName: "admin"}))
+
+		role, _ := (&RoleService{}).GetRoleByRoleKey("common")
+		assert.Equal(t, "Common Staff", role.RoleName)
+
+		menuIds := make([]int, 0)
+		dal.Gorm.Model(model.SysRoleMenu{}).Where("role_id = ?", 2).Pluck("menu_id", &menuIds)
+		assert.Equal(t, []int{100}, menuIds)
+	})
+}
+
+func TestImportJobService_RunDictImport(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	typeJobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_DICT_TYPE, "dict_types.xlsx", "admin")
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.RunDictTypeImport(typeJobId, []dto.DictTypeImportRequest{
+		{DictName: "Gender", DictType: "sys_user_sex", Status: "0"},
+		{DictName: "Gender", DictType: "sys_user_sex", Status: "0"},
+	}, dto.ImportOptions{OperatorId: 1, OperatorName: "admin"}))
+
+	t.Run("should import dictionary types", func(t *testing.T) {
+		assert.Equal(t, "Gender", (&DictTypeService{}).GetDcitTypeByDictType("sys_user_sex").DictName)
+
+		job, _ := s.GetImportJob(typeJobId)
+		assert.Equal(t, 1, job.Succeeded)
+		assert.Equal(t, 1, job.Failed)
+	})
+
+	t.Run("should import dictionary data of existing types", func(t *testing.T) {
+		dataJobId, _ := s.CreateImportJob(constant.IMPORT_JOB_TYPE_DICT_DATA, "dict_data.xlsx", "admin")
+
+		assert.NoError(t, s.RunDictDataImport(dataJobId, []dto.DictDataImportRequest{
+			{DictType: "sys_user_sex", DictLabel: "Male", DictValue: "0", Status: "0"},
+			{DictType: "sys_user_sex", DictLabel: "Female", DictValue: "1", Status: "0"},
+			{DictType: "sys_unknown", DictLabel: "Yes", DictValue: "Y", Status: "0"},
+		}, dto.ImportOptions{OperatorId: 1, OperatorName: "admin"}))
+
+		assert.Len(t, (&DictDataService{}).GetDictDataByDictType("sys_user_sex"), 2)
+
+		rowErrors, _ := s.GetImportJobErrors(dataJobId)
+		assert.Len(t, rowErrors, 1)
+		assert.Equal(t, xerrors.ErrImportDictNotFound.Error(), rowErrors[0].ErrorMsg)
+	})
+
+	t.Run("should update dictionary data by key value when update support is enabled", func(t *testing.T) {
+		dataJobId, _ := s.CreateImportJob(constant.IMPORT_JOB_TYPE_DICT_DATA, "dict_data.xlsx", "admin")
+
+		assert.NoError(t, s.RunDictDataImport(dataJobId, []dto.DictDataImportRequest{
+			{DictType: "sys_user_sex", DictLabel: "Man", DictValue: "0", Status: "0"},
+		}, dto.ImportOptions{UpdateSupport: true, OperatorId: 1, OperatorName: "admin"}))
+
+		var dictLabel string
+		dal.Gorm.Model(model.SysDictData{}).Where("dict_type = ? AND dict_value = ?", "sys_user_sex", "0").Pluck("dict_label", &dictLabel)
+		assert.Equal(t, "Man", dictLabel)
+	})
+}
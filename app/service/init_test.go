@@ -3,6 +3,7 @@ package service
 import (
 	"log"
 
+	"mira/anima/cipher"
 	"mira/anima/dal"
 	"mira/app/model"
 	"mira/config"
@@ -20,11 +21,19 @@ func setup() {
 	redisMock = mock
 	dal.Redis = db
 
+	// Entries cached in process by earlier tests would outlive their data
+	for _, cache := range twoLevelCaches {
+		cache.Forget()
+	}
+
 	gormDB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	dal.Gorm = gormDB
+	if err = dal.Gorm.Use(cipher.Plugin{}); err != nil {
+		log.Fatalf("Failed to register the cipher plugin: %v", err)
+	}
 
 	// Auto-migrate the schema for the SysConfig model
 	dal.Gorm.AutoMigrate(&model.SysConfig{})
@@ -41,6 +50,28 @@ func setup() {
 	dal.Gorm.AutoMigrate(&model.SysPost{})
 	dal.Gorm.AutoMigrate(&model.SysUser{})
 	dal.Gorm.AutoMigrate(&model.SysUserPost{})
+	dal.Gorm.AutoMigrate(&model.SysPolicy{})
+	dal.Gorm.AutoMigrate(&model.SysApproval{})
+	dal.Gorm.AutoMigrate(&model.SysDeptDelegation{})
+	dal.Gorm.AutoMigrate(&model.SysDeptDelegationRole{})
+	dal.Gorm.AutoMigrate(&model.SysRecycle{})
+	dal.Gorm.AutoMigrate(&model.SysImportJob{})
+	dal.Gorm.AutoMigrate(&model.SysImportJobError{})
+	dal.Gorm.AutoMigrate(&model.SysExportJob{})
+	dal.Gorm.AutoMigrate(&model.SysUserGroup{})
+	dal.Gorm.AutoMigrate(&model.SysUserGroupUser{})
+	dal.Gorm.AutoMigrate(&model.SysUserGroupRole{})
+	dal.Gorm.AutoMigrate(&model.SysRoleGroup{})
+	dal.Gorm.AutoMigrate(&model.SysUserAttr{})
+	dal.Gorm.AutoMigrate(&model.SysUserAttrValue{})
+	dal.Gorm.AutoMigrate(&model.SysOnboardTemplate{})
+	dal.Gorm.AutoMigrate(&model.SysOnboardTemplateRole{})
+	dal.Gorm.AutoMigrate(&model.SysOnboardTemplatePost{})
+	dal.Gorm.AutoMigrate(&model.SysUserOffboard{})
+	dal.Gorm.AutoMigrate(&model.SysPostRole{})
+	dal.Gorm.AutoMigrate(&model.SysPrivacyRequest{})
+	dal.Gorm.AutoMigrate(&model.SysPrivacyRequestLog{})
+	dal.Gorm.AutoMigrate(&model.SysConfigHistory{})
 
 	// Initialize a minimal config for testing
 	config.Data = &config.Config{
@@ -76,6 +107,28 @@ func teardown() {
 		dal.Gorm.Exec("DELETE FROM sys_post")
 		dal.Gorm.Exec("DELETE FROM sys_user")
 		dal.Gorm.Exec("DELETE FROM sys_user_post")
+		dal.Gorm.Exec("DELETE FROM sys_policy")
+		dal.Gorm.Exec("DELETE FROM sys_approval")
+		dal.Gorm.Exec("DELETE FROM sys_dept_delegation")
+		dal.Gorm.Exec("DELETE FROM sys_dept_delegation_role")
+		dal.Gorm.Exec("DELETE FROM sys_recycle")
+		dal.Gorm.Exec("DELETE FROM sys_import_job")
+		dal.Gorm.Exec("DELETE FROM sys_import_job_error")
+		dal.Gorm.Exec("DELETE FROM sys_export_job")
+		dal.Gorm.Exec("DELETE FROM sys_user_group")
+		dal.Gorm.Exec("DELETE FROM sys_user_group_user")
+		dal.Gorm.Exec("DELETE FROM sys_user_group_role")
+		dal.Gorm.Exec("DELETE FROM sys_role_group")
+		dal.Gorm.Exec("DELETE FROM sys_user_attr")
+		dal.Gorm.Exec("DELETE FROM sys_user_attr_value")
+		dal.Gorm.Exec("DELETE FROM sys_onboard_template")
+		dal.Gorm.Exec("DELETE FROM sys_onboard_template_role")
+		dal.Gorm.Exec("DELETE FROM sys_onboard_template_post")
+		dal.Gorm.Exec("DELETE FROM sys_user_offboard")
+		dal.Gorm.Exec("DELETE FROM sys_post_role")
+		dal.Gorm.Exec("DELETE FROM sys_privacy_request")
+		dal.Gorm.Exec("DELETE FROM sys_privacy_request_log")
+		dal.Gorm.Exec("DELETE FROM sys_config_history")
 		db, _ := dal.Gorm.DB()
 		db.Close()
 	}
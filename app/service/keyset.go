@@ -0,0 +1,199 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// PAGE_DEFAULT_LIMIT is the size of a keyset page given neither a limit nor a page size
+const PAGE_DEFAULT_LIMIT = 10
+
+// keysetColumn is a column a list can be sorted by
+type keysetColumn[T any] struct {
+	// name is the column in the query, qualified if the query joins
+	name string
+	// value returns the value of the column in a row
+	value func(row T) interface{}
+}
+
+// keyset sorts a list by one of its columns, breaking ties by the unique key of the rows so that
+// the order is stable, and pages through it by keyset: each page starts after the sort value and
+// key of the last row of the previous one, read from the cursor, instead of skipping rows.
+type keyset[T any] struct {
+	// key is the unique column breaking ties, qualified if the query joins
+	key   string
+	keyOf func(row T) int
+	// columns are the sortable columns keyed by the snake_case name utils.ParseSort returns
+	columns map[string]keysetColumn[T]
+}
+
+// keysetCursor is the position of a keyset page, after the row of the sort value and key.
+// The sort it was read in is kept so that a cursor is not used with another sort.
+type keysetCursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	Key   int             `json:"k"`
+}
+
+// order returns the ORDER BY clause sorting by a column, then by the key in the same direction
+func (k keyset[T]) order(name, rule string) (string, error) {
+	column, ok := k.columns[name]
+	if !ok {
+		return "", xerrors.ErrPageSortInvalid
+	}
+
+	rule = keysetRule(rule)
+	if column.name == k.key {
+		return column.name + " " + rule, nil
+	}
+	return column.name + " " + rule + ", " + k.key + " " + rule, nil
+}
+
+// page reads the page of a query after the cursor of param, sorted by a column. Pages hold the
+// limit of param, or its page size, in rows; the cursor of the next page is empty on the last page.
+func (k keyset[T]) page(query *gorm.DB, param dto.PageRequest, name, rule string) ([]T, string, error) {
+	order, err := k.order(name, rule)
+	if err != nil {
+		return nil, "", err
+	}
+	column := k.columns[name]
+	rule = keysetRule(rule)
+	sort := name + " " + rule
+
+	limit := param.Limit
+	if limit <= 0 {
+		limit = param.PageSize
+	}
+	if limit <= 0 {
+		limit = PAGE_DEFAULT_LIMIT
+	}
+
+	if param.Cursor != "" {
+		value, key, err := k.decode(param.Cursor, column, sort)
+		if err != nil {
+			return nil, "", err
+		}
+
+		op := "<"
+		if rule == "ASC" {
+			op = ">"
+		}
+		if column.name == k.key {
+			query = query.Where(k.key+" "+op+" ?", key)
+		} else {
+			query = query.Where("("+column.name+" "+op+" ? OR ("+column.name+" = ? AND "+k.key+" "+op+" ?))", value, value, key)
+		}
+	}
+
+	rows := make([]T, 0, limit+1)
+	if err = query.Order(order).Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, "", errors.Wrap(err, "failed to read the page")
+	}
+	if len(rows) <= limit {
+		return rows, "", nil
+	}
+
+	rows = rows[:limit]
+	next, err := k.encode(rows[limit-1], column, sort)
+	if err != nil {
+		return nil, "", err
+	}
+	return rows, next, nil
+}
+
+// encode returns the cursor of the page after a row
+func (k keyset[T]) encode(row T, column keysetColumn[T], sort string) (string, error) {
+	value, err := json.Marshal(column.value(row))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode the page cursor")
+	}
+
+	cursor, err := json.Marshal(keysetCursor{Sort: sort, Value: value, Key: k.keyOf(row)})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode the page cursor")
+	}
+	return base64.RawURLEncoding.EncodeToString(cursor), nil
+}
+
+// decode returns the sort value and key of a cursor, decoding the value into the type of the column
+func (k keyset[T]) decode(encoded string, column keysetColumn[T], sort string) (interface{}, int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, 0, xerrors.ErrPageCursorInvalid
+	}
+
+	var cursor keysetCursor
+	if err = json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort || cursor.Value == nil {
+		return nil, 0, xerrors.ErrPageCursorInvalid
+	}
+
+	var zero T
+	value := reflect.New(reflect.TypeOf(column.value(zero)))
+	if err = json.Unmarshal(cursor.Value, value.Interface()); err != nil {
+		return nil, 0, xerrors.ErrPageCursorInvalid
+	}
+	return value.Elem().Interface(), cursor.Key, nil
+}
+
+// keysetRule normalizes a sort rule, descending unless it is ascending
+func keysetRule(rule string) string {
+	if strings.EqualFold(rule, "asc") {
+		return "ASC"
+	}
+	return "DESC"
+}
+
+// queryPlanRow is a row of the MySQL query plan
+type queryPlanRow struct {
+	Rows     *float64
+	Filtered *float64
+}
+
+// countPage counts the rows of a query as the count mode asks, returning -1 when they are not counted.
+// Estimates come from the MySQL query plan, other databases count exactly.
+func countPage(query *gorm.DB, mode string) (int, error) {
+	switch mode {
+	case constant.PAGE_COUNT_NONE:
+		return -1, nil
+	case constant.PAGE_COUNT_ESTIMATE:
+		if query.Dialector.Name() == "mysql" {
+			return estimateCount(query)
+		}
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// estimateCount estimates the rows of a query as the rows the first table of its plan reads,
+// scaled by the share of them its conditions keep
+func estimateCount(query *gorm.DB) (int, error) {
+	stmt := query.Session(&gorm.Session{DryRun: true}).Find(&[]map[string]interface{}{}).Statement
+
+	plan := make([]queryPlanRow, 0)
+	if err := dal.Gorm.Raw("EXPLAIN "+stmt.SQL.String(), stmt.Vars...).Scan(&plan).Error; err != nil {
+		return 0, err
+	}
+	if len(plan) == 0 || plan[0].Rows == nil {
+		return 0, nil
+	}
+
+	estimate := *plan[0].Rows
+	if plan[0].Filtered != nil {
+		estimate = estimate * *plan[0].Filtered / 100
+	}
+	return int(estimate + 0.5), nil
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"mira/anima/cipher"
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/types/constant"
 	rediskey "mira/common/types/redis-key"
 )
 
@@ -14,8 +17,10 @@ import (
 type LogininforServiceInterface interface {
 	DeleteLogininfor(infoIds []int) error
 	GetLogininforList(param dto.LogininforListRequest, isPaging bool) ([]dto.LogininforListResponse, int)
+	GetLogininforPage(param dto.LogininforListRequest) ([]dto.LogininforListResponse, int, string, error)
 	Unlock(userName string) error
 	CreateSysLogininfor(param dto.SaveLogininforRequest) error
+	ExportDataset(param dto.LogininforListRequest) ExportDataset
 }
 
 // LogininforService implements the login information management interface
@@ -58,13 +63,75 @@ func (s *LogininforService) GetLogininforList(param dto.LogininforListRequest, i
 
 // GetLogininforListWithErr retrieves a list of login information records with error handling
 func (s *LogininforService) GetLogininforListWithErr(param dto.LogininforListRequest, isPaging bool) ([]dto.LogininforListResponse, int, error) {
-	var count int64
+	var count int
 	logininfos := make([]dto.LogininforListResponse, 0)
 
-	query := dal.Gorm.Model(model.SysLogininfor{}).Order(param.OrderByColumn + " " + param.OrderRule)
+	order, err := logininforKeyset.order(param.OrderByColumn, param.OrderRule)
+	if err != nil {
+		order = param.OrderByColumn + " " + param.OrderRule
+	}
+	query := logininforListQuery(param).Order(order)
+
+	if isPaging {
+		count, err = countPage(query, param.Count)
+		if err != nil {
+			return logininfos, 0, errors.Wrap(err, "failed to count login records")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	err = query.Find(&logininfos).Error
+	if err != nil {
+		return logininfos, 0, errors.Wrap(err, "failed to retrieve login records")
+	}
+
+	return logininfos, count, nil
+}
+
+// logininforKeyset sorts and pages the login records by the columns the list can be sorted by
+var logininforKeyset = keyset[dto.LogininforListResponse]{
+	key:   "info_id",
+	keyOf: func(row dto.LogininforListResponse) int { return row.InfoId },
+	columns: map[string]keysetColumn[dto.LogininforListResponse]{
+		"info_id":    {"info_id", func(row dto.LogininforListResponse) interface{} { return row.InfoId }},
+		"login_time": {"login_time", func(row dto.LogininforListResponse) interface{} { return row.LoginTime.Time }},
+		"user_name":  {"user_name", func(row dto.LogininforListResponse) interface{} { return row.UserName }},
+	},
+}
+
+// GetLogininforPage retrieves a keyset page of login records, see dto.PageRequest
+//
+// Returns:
+//   - []dto.LogininforListResponse: The login records of the page
+//   - int: Total record count as the count mode of the page asks, -1 if not counted
+//   - string: The cursor of the next page, empty on the last page
+//   - error: ErrPageSortInvalid or ErrPageCursorInvalid for a bad sort or cursor, or any query error
+func (s *LogininforService) GetLogininforPage(param dto.LogininforListRequest) ([]dto.LogininforListResponse, int, string, error) {
+	if param.OrderByColumn == "" {
+		param.OrderByColumn = "info_id"
+	}
+
+	query := logininforListQuery(param)
+
+	count, err := countPage(query, param.Count)
+	if err != nil {
+		return nil, 0, "", errors.Wrap(err, "failed to count login records")
+	}
+
+	logininfos, next, err := logininforKeyset.page(query, param.PageRequest, param.OrderByColumn, param.OrderRule)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	return logininfos, count, next, nil
+}
+
+// logininforListQuery builds the login record query with the search conditions applied
+func logininforListQuery(param dto.LogininforListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysLogininfor{})
 
 	if param.Ipaddr != "" {
-		query = query.Where("ipaddr LIKE ?", "%"+param.Ipaddr+"%")
+		query = query.Where(piiContains("ipaddr", param.Ipaddr))
 	}
 
 	if param.UserName != "" {
@@ -79,20 +146,33 @@ func (s *LogininforService) GetLogininforListWithErr(param dto.LogininforListReq
 		query = query.Where("login_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
 	}
 
-	if isPaging {
-		err := query.Count(&count).Error
-		if err != nil {
-			return logininfos, 0, errors.Wrap(err, "failed to count login records")
-		}
-		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
-	}
+	return query
+}
 
-	err := query.Find(&logininfos).Error
-	if err != nil {
-		return logininfos, 0, errors.Wrap(err, "failed to retrieve login records")
+// ExportDataset describes the export of the login records matching the search conditions, newest first
+func (s *LogininforService) ExportDataset(param dto.LogininforListRequest) ExportDataset {
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_LOGININFOR,
+		Query: func() *gorm.DB {
+			return logininforListQuery(param)
+		},
+		KeyField:  "info_id",
+		KeyColumn: "info_id",
+		Desc:      true,
+		Columns: []ExportColumn{
+			{Key: "infoId", Field: "info_id", Header: "ID", HeaderZh: "序号"},
+			{Key: "userName", Field: "user_name", Header: "User Account", HeaderZh: "用户账号"},
+			{Key: "status", Field: "status", Header: "Login Status", HeaderZh: "登录状态", DictType: "sys_common_status", Labels: map[string]string{
+				"0": "Success", "1": "Failure",
+			}},
+			{Key: "ipaddr", Field: "ipaddr", Header: "Login Address", HeaderZh: "登录地址", Encrypted: model.SysLogininforIpaddr{}},
+			{Key: "loginLocation", Field: "login_location", Header: "Login Location", HeaderZh: "登录地点"},
+			{Key: "browser", Field: "browser", Header: "Browser", HeaderZh: "浏览器"},
+			{Key: "os", Field: "os", Header: "Operating System", HeaderZh: "操作系统"},
+			{Key: "msg", Field: "msg", Header: "Message", HeaderZh: "提示消息"},
+			{Key: "loginTime", Field: "login_time", Header: "Access Time", HeaderZh: "访问时间"},
+		},
 	}
-
-	return logininfos, int(count), nil
 }
 
 // Unlock removes the login error count cache for a user, effectively unlocking their account
@@ -134,7 +214,7 @@ func (s *LogininforService) CreateSysLogininforWithErr(param dto.SaveLogininforR
 	// Create the login record
 	err := dal.Gorm.Model(model.SysLogininfor{}).Create(&model.SysLogininfor{
 		UserName:      param.UserName,
-		Ipaddr:        param.Ipaddr,
+		Ipaddr:        cipher.String[model.SysLogininforIpaddr](param.Ipaddr),
 		LoginLocation: param.LoginLocation,
 		Browser:       param.Browser,
 		Os:            param.Os,
@@ -86,6 +86,36 @@ func TestLogininforService_GetLogininforList(t *testing.T) {
 	})
 }
 
+func TestLogininforService_GetLogininforPage(t *testing.T) {
+	setup()
+	defer teardown()
+	s := &LogininforService{}
+
+	loginTime := datetime.Datetime{Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)}
+	for id := 1; id <= 3; id++ {
+		dal.Gorm.Create(&model.SysLogininfor{InfoId: id, UserName: "admin", LoginTime: loginTime})
+	}
+
+	t.Run("should page by login time, breaking ties by ID", func(t *testing.T) {
+		param := dto.LogininforListRequest{
+			PageRequest:   dto.PageRequest{Limit: 2},
+			OrderByColumn: "login_time",
+			OrderRule:     "DESC",
+		}
+		logininfors, count, next, err := s.GetLogininforPage(param)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, []int{3, 2}, []int{logininfors[0].InfoId, logininfors[1].InfoId})
+
+		param.Cursor = next
+		logininfors, _, next, err = s.GetLogininforPage(param)
+		assert.NoError(t, err)
+		assert.Len(t, logininfors, 1)
+		assert.Equal(t, 1, logininfors[0].InfoId)
+		assert.Empty(t, next)
+	})
+}
+
 func TestLogininforService_Unlock(t *testing.T) {
 	setup()
 	defer teardown()
@@ -15,7 +15,7 @@ type MenuServiceInterface interface {
 	// Menu CRUD operations
 	CreateMenu(param dto.SaveMenu) error
 	UpdateMenu(param dto.SaveMenu) error
-	DeleteMenu(menuId int) error
+	DeleteMenu(menuId int, deleteBy string) error
 	GetMenuList(param dto.MenuListRequest) []dto.MenuListResponse
 	GetMenuByMenuId(menuId int) dto.MenuDetailResponse
 	GetMenuByMenuName(menuName string) dto.MenuDetailResponse
@@ -67,7 +67,7 @@ func (s *MenuService) CreateMenuWithErr(param dto.SaveMenu) error {
 		return errors.New("menu name cannot be empty")
 	}
 
-	err := dal.Gorm.Model(model.SysMenu{}).Create(&model.SysMenu{
+	menu := model.SysMenu{
 		MenuName:  param.MenuName,
 		ParentId:  param.ParentId,
 		OrderNum:  param.OrderNum,
@@ -84,11 +84,15 @@ func (s *MenuService) CreateMenuWithErr(param dto.SaveMenu) error {
 		Status:    param.Status,
 		Remark:    param.Remark,
 		CreateBy:  param.CreateBy,
-	}).Error
-	if err != nil {
+	}
+	if err := dal.Gorm.Model(model.SysMenu{}).Create(&menu).Error; err != nil {
 		return errors.Wrap(err, "failed to create menu")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_MENU, menu.MenuId)
+
+	publishCacheIds(constant.CACHE_EVENT_MENU, menu.MenuId)
+
 	return nil
 }
 
@@ -130,16 +134,20 @@ func (s *MenuService) UpdateMenuWithErr(param dto.SaveMenu) error {
 		return errors.Wrap(err, "failed to update menu")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_MENU, param.MenuId)
+
+	publishCacheIds(constant.CACHE_EVENT_MENU, param.MenuId)
+
 	return nil
 }
 
-// DeleteMenu removes a menu by its ID
-func (s *MenuService) DeleteMenu(menuId int) error {
-	return s.DeleteMenuWithErr(menuId)
+// DeleteMenu removes a menu by its ID, keeping it in the recycle bin
+func (s *MenuService) DeleteMenu(menuId int, deleteBy string) error {
+	return s.DeleteMenuWithErr(menuId, deleteBy)
 }
 
 // DeleteMenuWithErr removes a menu with proper error handling
-func (s *MenuService) DeleteMenuWithErr(menuId int) error {
+func (s *MenuService) DeleteMenuWithErr(menuId int, deleteBy string) error {
 	// Input validation
 	if menuId <= 0 {
 		return errors.New("invalid menu ID")
@@ -155,11 +163,26 @@ func (s *MenuService) DeleteMenuWithErr(menuId int) error {
 		return errors.New("menu is assigned to roles, cannot delete")
 	}
 
-	err := dal.Gorm.Model(model.SysMenu{}).Where("menu_id = ?", menuId).Delete(&model.SysMenu{}).Error
-	if err != nil {
+	tx := dal.Gorm.Begin()
+
+	if err := recycleMenus(tx, []int{menuId}, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(model.SysMenu{}).Where("menu_id = ?", menuId).Delete(&model.SysMenu{}).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "failed to delete menu")
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	notifySearch(constant.SEARCH_TYPE_MENU, menuId)
+
+	publishCacheIds(constant.CACHE_EVENT_MENU, menuId)
+
 	return nil
 }
 
@@ -302,8 +325,8 @@ func (s *MenuService) GetPermsByUserIdWithErr(userId int) ([]string, error) {
 	err := dal.Gorm.Model(model.SysMenu{}).
 		Joins("JOIN sys_role_menu ON sys_menu.menu_id = sys_role_menu.menu_id").
 		Joins("JOIN sys_role ON sys_role_menu.role_id = sys_role.role_id").
-		Joins("JOIN sys_user_role ON sys_role.role_id = sys_user_role.role_id").
-		Where("sys_user_role.user_id = ? AND sys_menu.status = ?", userId, constant.NORMAL_STATUS).
+		Joins("JOIN (?) AS user_roles ON sys_role.role_id = user_roles.role_id", userRoleQuery(userId)).
+		Where("sys_menu.status = ?", constant.NORMAL_STATUS).
 		Pluck("sys_menu.perms", &perms).Error
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to retrieve user permissions")
@@ -408,13 +431,14 @@ func (s *MenuService) GetMenuMCListByUserIdWithErr(userId int) ([]dto.MenuListRe
 	query := dal.Gorm.Model(model.SysMenu{}).
 		Distinct("sys_menu.*").
 		Order("sys_menu.parent_id, sys_menu.order_num").
-		Joins("LEFT JOIN sys_role_menu ON sys_menu.menu_id = sys_role_menu.menu_id").
-		Joins("LEFT JOIN sys_role ON sys_role_menu.role_id = sys_role.role_id").
-		Joins("LEFT JOIN sys_user_role ON sys_role.role_id = sys_user_role.role_id").
 		Where("sys_menu.status = ? AND sys_menu.menu_type IN ?", constant.NORMAL_STATUS, []string{"M", "C"})
 
 	if userId > 1 {
-		query = query.Where("sys_user_role.user_id = ? AND sys_role.status = ?", userId, constant.NORMAL_STATUS)
+		query = query.
+			Joins("JOIN sys_role_menu ON sys_menu.menu_id = sys_role_menu.menu_id").
+			Joins("JOIN sys_role ON sys_role_menu.role_id = sys_role.role_id").
+			Joins("JOIN (?) AS user_roles ON sys_role.role_id = user_roles.role_id", userRoleQuery(userId)).
+			Where("sys_role.status = ?", constant.NORMAL_STATUS)
 	}
 
 	err := query.Find(&menus).Error
@@ -90,7 +90,7 @@ func TestMenuService_DeleteMenu(t *testing.T) {
 		dal.Gorm.Create(menu)
 
 		// Execute
-		err := s.DeleteMenu(5)
+		err := s.DeleteMenu(5, "admin")
 		assert.NoError(t, err)
 
 		// Verify
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// OnboardTemplateServiceInterface defines operations for onboarding templates
+type OnboardTemplateServiceInterface interface {
+	CreateOnboardTemplate(param dto.SaveOnboardTemplate, roleIds, postIds []int) error
+	UpdateOnboardTemplate(param dto.SaveOnboardTemplate, roleIds, postIds []int) error
+	DeleteOnboardTemplate(templateIds []int) error
+	GetOnboardTemplateList(param dto.OnboardTemplateListRequest, isPaging bool) ([]dto.OnboardTemplateListResponse, int)
+	GetOnboardTemplateByTemplateId(templateId int) dto.OnboardTemplateDetailResponse
+	GetOnboardTemplateByTemplateName(templateName string) dto.OnboardTemplateDetailResponse
+	GetMatchingTemplateIds(userId int) ([]int, error)
+}
+
+// OnboardTemplateService implements onboarding templates
+//
+// A template names the roles and posts a new user receives when onboarded. It applies to
+// the users of a department and its sub-departments, to the users holding a post, or to
+// both when it names a department and a post; 0 stands for any department or any post.
+type OnboardTemplateService struct{}
+
+// Ensure OnboardTemplateService implements OnboardTemplateServiceInterface
+var _ OnboardTemplateServiceInterface = (*OnboardTemplateService)(nil)
+
+// NewOnboardTemplateService creates a new OnboardTemplateService
+func NewOnboardTemplateService() *OnboardTemplateService {
+	return &OnboardTemplateService{}
+}
+
+// CreateOnboardTemplate creates a new onboarding template with its roles and posts
+func (s *OnboardTemplateService) CreateOnboardTemplate(param dto.SaveOnboardTemplate, roleIds, postIds []int) error {
+	return s.CreateOnboardTemplateWithErr(param, roleIds, postIds)
+}
+
+// CreateOnboardTemplateWithErr creates a new onboarding template with proper error handling
+func (s *OnboardTemplateService) CreateOnboardTemplateWithErr(param dto.SaveOnboardTemplate, roleIds, postIds []int) error {
+	if param.TemplateName == "" {
+		return xerrors.ErrOnboardTemplateNameEmpty
+	}
+
+	tx := dal.Gorm.Begin()
+
+	template := model.SysOnboardTemplate{
+		TemplateName: param.TemplateName,
+		DeptId:       param.DeptId,
+		PostId:       param.PostId,
+		Status:       param.Status,
+		CreateBy:     param.CreateBy,
+		Remark:       param.Remark,
+	}
+
+	if err := tx.Model(model.SysOnboardTemplate{}).Create(&template).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to create onboarding template")
+	}
+
+	if err := saveOnboardTemplateGrants(tx, template.TemplateId, roleIds, postIds); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// UpdateOnboardTemplate updates an onboarding template and replaces its roles and posts
+func (s *OnboardTemplateService) UpdateOnboardTemplate(param dto.SaveOnboardTemplate, roleIds, postIds []int) error {
+	return s.UpdateOnboardTemplateWithErr(param, roleIds, postIds)
+}
+
+// UpdateOnboardTemplateWithErr updates an onboarding template with proper error handling
+func (s *OnboardTemplateService) UpdateOnboardTemplateWithErr(param dto.SaveOnboardTemplate, roleIds, postIds []int) error {
+	if param.TemplateId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if param.TemplateName == "" {
+		return xerrors.ErrOnboardTemplateNameEmpty
+	}
+
+	tx := dal.Gorm.Begin()
+
+	// The department and post are saved even when zero, which makes the template apply to any
+	if err := tx.Model(model.SysOnboardTemplate{}).Where("template_id = ?", param.TemplateId).
+		Select("template_name", "dept_id", "post_id", "status", "update_by", "remark").
+		Updates(&model.SysOnboardTemplate{
+			TemplateName: param.TemplateName,
+			DeptId:       param.DeptId,
+			PostId:       param.PostId,
+			Status:       param.Status,
+			UpdateBy:     param.UpdateBy,
+			Remark:       param.Remark,
+		}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to update onboarding template")
+	}
+
+	if err := deleteOnboardTemplateGrants(tx, []int{param.TemplateId}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := saveOnboardTemplateGrants(tx, param.TemplateId, roleIds, postIds); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// DeleteOnboardTemplate deletes onboarding templates with their roles and posts
+func (s *OnboardTemplateService) DeleteOnboardTemplate(templateIds []int) error {
+	return s.DeleteOnboardTemplateWithErr(templateIds)
+}
+
+// DeleteOnboardTemplateWithErr deletes onboarding templates with proper error handling
+func (s *OnboardTemplateService) DeleteOnboardTemplateWithErr(templateIds []int) error {
+	if len(templateIds) == 0 {
+		return xerrors.ErrParam
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Model(model.SysOnboardTemplate{}).Where("template_id IN ?", templateIds).Delete(&model.SysOnboardTemplate{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to delete onboarding templates")
+	}
+
+	if err := deleteOnboardTemplateGrants(tx, templateIds); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// GetOnboardTemplateList retrieves a list of onboarding templates based on search parameters
+func (s *OnboardTemplateService) GetOnboardTemplateList(param dto.OnboardTemplateListRequest, isPaging bool) ([]dto.OnboardTemplateListResponse, int) {
+	templates, count, _ := s.GetOnboardTemplateListWithErr(param, isPaging)
+	return templates, count
+}
+
+// GetOnboardTemplateListWithErr retrieves a list of onboarding templates with proper error handling
+func (s *OnboardTemplateService) GetOnboardTemplateListWithErr(param dto.OnboardTemplateListRequest, isPaging bool) ([]dto.OnboardTemplateListResponse, int, error) {
+	var count int64
+	templates := make([]dto.OnboardTemplateListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysOnboardTemplate{}).
+		Select("sys_onboard_template.*", "sys_dept.dept_name", "sys_post.post_name").
+		Joins("LEFT JOIN sys_dept ON sys_dept.dept_id = sys_onboard_template.dept_id").
+		Joins("LEFT JOIN sys_post ON sys_post.post_id = sys_onboard_template.post_id").
+		Order("sys_onboard_template.template_id")
+
+	if param.TemplateName != "" {
+		query = query.Where("sys_onboard_template.template_name LIKE ?", "%"+param.TemplateName+"%")
+	}
+
+	if param.DeptId > 0 {
+		query = query.Where("sys_onboard_template.dept_id = ?", param.DeptId)
+	}
+
+	if param.PostId > 0 {
+		query = query.Where("sys_onboard_template.post_id = ?", param.PostId)
+	}
+
+	if param.Status != "" {
+		query = query.Where("sys_onboard_template.status = ?", param.Status)
+	}
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count onboarding templates")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&templates).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve onboarding templates")
+	}
+
+	return templates, int(count), nil
+}
+
+// GetOnboardTemplateByTemplateId retrieves onboarding template details with its roles and posts by ID
+func (s *OnboardTemplateService) GetOnboardTemplateByTemplateId(templateId int) dto.OnboardTemplateDetailResponse {
+	template, _ := s.GetOnboardTemplateByTemplateIdWithErr(templateId)
+	return template
+}
+
+// GetOnboardTemplateByTemplateIdWithErr retrieves onboarding template details with proper error handling
+func (s *OnboardTemplateService) GetOnboardTemplateByTemplateIdWithErr(templateId int) (dto.OnboardTemplateDetailResponse, error) {
+	var template dto.OnboardTemplateDetailResponse
+
+	if templateId <= 0 {
+		return template, xerrors.ErrParam
+	}
+
+	if err := dal.Gorm.Model(model.SysOnboardTemplate{}).Where("template_id = ?", templateId).Last(&template).Error; err != nil {
+		return template, errors.Wrap(err, "failed to retrieve onboarding template by ID")
+	}
+
+	template.RoleIds = make([]int, 0)
+	if err := dal.Gorm.Model(model.SysOnboardTemplateRole{}).Where("template_id = ?", templateId).Pluck("role_id", &template.RoleIds).Error; err != nil {
+		return template, errors.Wrap(err, "failed to retrieve onboarding template roles")
+	}
+
+	template.PostIds = make([]int, 0)
+	if err := dal.Gorm.Model(model.SysOnboardTemplatePost{}).Where("template_id = ?", templateId).Pluck("post_id", &template.PostIds).Error; err != nil {
+		return template, errors.Wrap(err, "failed to retrieve onboarding template posts")
+	}
+
+	return template, nil
+}
+
+// GetOnboardTemplateByTemplateName retrieves onboarding template details by name
+func (s *OnboardTemplateService) GetOnboardTemplateByTemplateName(templateName string) dto.OnboardTemplateDetailResponse {
+	var template dto.OnboardTemplateDetailResponse
+
+	dal.Gorm.Model(model.SysOnboardTemplate{}).Where("template_name = ?", templateName).Last(&template)
+
+	return template
+}
+
+// GetMatchingTemplateIds returns the normal onboarding templates that apply to a user,
+// matched by the department of the user and its parents and by the posts the user holds
+func (s *OnboardTemplateService) GetMatchingTemplateIds(userId int) ([]int, error) {
+	templateIds := make([]int, 0)
+
+	var user model.SysUser
+	if err := dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", userId).Take(&user).Error; err != nil {
+		return templateIds, errors.Wrapf(err, "failed to get user ID %d", userId)
+	}
+
+	deptIds := []int{0}
+	if user.DeptId > 0 {
+		var dept model.SysDept
+		if err := dal.Gorm.Model(model.SysDept{}).Where("dept_id = ?", user.DeptId).Limit(1).Find(&dept).Error; err != nil {
+			return templateIds, errors.Wrapf(err, "failed to get department ID %d", user.DeptId)
+		}
+		deptIds = append(deptIds, user.DeptId)
+		for _, ancestor := range strings.Split(dept.Ancestors, ",") {
+			if ancestorId, err := strconv.Atoi(ancestor); err == nil && ancestorId > 0 {
+				deptIds = append(deptIds, ancestorId)
+			}
+		}
+	}
+
+	postIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysUserPost{}).Where("user_id = ?", userId).Pluck("post_id", &postIds).Error; err != nil {
+		return templateIds, errors.Wrapf(err, "failed to get posts of user ID %d", userId)
+	}
+	postIds = append(postIds, 0)
+
+	if err := dal.Gorm.Model(model.SysOnboardTemplate{}).
+		Where("status = ? AND dept_id IN ? AND post_id IN ?", constant.NORMAL_STATUS, deptIds, postIds).
+		Order("template_id").
+		Pluck("template_id", &templateIds).Error; err != nil {
+		return templateIds, errors.Wrap(err, "failed to match onboarding templates")
+	}
+
+	return templateIds, nil
+}
+
+// saveOnboardTemplateGrants records the roles and posts an onboarding template grants
+func saveOnboardTemplateGrants(tx *gorm.DB, templateId int, roleIds, postIds []int) error {
+	for _, roleId := range roleIds {
+		if err := tx.Create(&model.SysOnboardTemplateRole{TemplateId: templateId, RoleId: roleId}).Error; err != nil {
+			return errors.Wrapf(err, "failed to assign role ID %d to onboarding template ID %d", roleId, templateId)
+		}
+	}
+
+	for _, postId := range postIds {
+		if err := tx.Create(&model.SysOnboardTemplatePost{TemplateId: templateId, PostId: postId}).Error; err != nil {
+			return errors.Wrapf(err, "failed to assign post ID %d to onboarding template ID %d", postId, templateId)
+		}
+	}
+
+	return nil
+}
+
+// deleteOnboardTemplateGrants removes the roles and posts of onboarding templates
+func deleteOnboardTemplateGrants(tx *gorm.DB, templateIds []int) error {
+	if err := tx.Where("template_id IN ?", templateIds).Delete(&model.SysOnboardTemplateRole{}).Error; err != nil {
+		return errors.Wrap(err, "failed to delete onboarding template roles")
+	}
+
+	if err := tx.Where("template_id IN ?", templateIds).Delete(&model.SysOnboardTemplatePost{}).Error; err != nil {
+		return errors.Wrap(err, "failed to delete onboarding template posts")
+	}
+
+	return nil
+}
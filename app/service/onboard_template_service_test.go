@@ -0,0 +1,122 @@
+package service
+
+import (
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seedOnboardTemplate creates a department tree, posts, roles and templates matching by
+// department, by department and post, by post alone, plus a disabled catch-all template
+func seedOnboardTemplate() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 101, ParentId: 100, Ancestors: "0,100", DeptName: "Engineering"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 102, ParentId: 100, Ancestors: "0,100", DeptName: "Sales"})
+
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "dev", PostName: "Developer", Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 2, PostCode: "ops", PostName: "Operator", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Employee", RoleKey: "employee", Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 3, RoleName: "Engineer", RoleKey: "engineer", Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 4, RoleName: "On Call", RoleKey: "oncall", Status: "0"})
+
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 101, UserName: "alice", Status: "1"})
+	dal.Gorm.Create(&model.SysUserPost{UserId: 2, PostId: 1})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 2})
+
+	s := NewOnboardTemplateService()
+	s.CreateOnboardTemplate(dto.SaveOnboardTemplate{TemplateName: "Everyone at HQ", DeptId: 100, Status: "0"}, []int{2}, nil)
+	s.CreateOnboardTemplate(dto.SaveOnboardTemplate{TemplateName: "Engineering developers", DeptId: 101, PostId: 1, Status: "0"}, []int{3}, []int{2})
+	s.CreateOnboardTemplate(dto.SaveOnboardTemplate{TemplateName: "Operators", PostId: 2, Status: "0"}, []int{4}, nil)
+	s.CreateOnboardTemplate(dto.SaveOnboardTemplate{TemplateName: "Retired", Status: "1"}, []int{4}, nil)
+}
+
+func TestOnboardTemplateService_CRUD(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOnboardTemplate()
+	s := NewOnboardTemplateService()
+
+	t.Run("should list templates with their department and post names", func(t *testing.T) {
+		templates, total := s.GetOnboardTemplateList(dto.OnboardTemplateListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}, Status: "0"}, true)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, "HQ", templates[0].DeptName)
+		assert.Equal(t, "Developer", templates[1].PostName)
+		assert.Empty(t, templates[2].DeptName)
+	})
+
+	t.Run("should replace the grants and clear the department", func(t *testing.T) {
+		template := s.GetOnboardTemplateByTemplateId(s.GetOnboardTemplateByTemplateName("Engineering developers").TemplateId)
+		assert.Equal(t, []int{3}, template.RoleIds)
+		assert.Equal(t, []int{2}, template.PostIds)
+
+		assert.NoError(t, s.UpdateOnboardTemplate(dto.SaveOnboardTemplate{TemplateId: template.TemplateId, TemplateName: "Developers", PostId: 1, Status: "0"}, []int{3, 4}, nil))
+
+		template = s.GetOnboardTemplateByTemplateId(template.TemplateId)
+		assert.Equal(t, "Developers", template.TemplateName)
+		assert.Zero(t, template.DeptId)
+		assert.Equal(t, []int{3, 4}, template.RoleIds)
+		assert.Empty(t, template.PostIds)
+	})
+
+	t.Run("should delete templates with their grants", func(t *testing.T) {
+		template := s.GetOnboardTemplateByTemplateName("Retired")
+		assert.NoError(t, s.DeleteOnboardTemplate([]int{template.TemplateId}))
+
+		var count int64
+		dal.Gorm.Model(model.SysOnboardTemplateRole{}).Where("template_id = ?", template.TemplateId).Count(&count)
+		assert.Zero(t, count)
+		assert.Zero(t, s.GetOnboardTemplateByTemplateName("Retired").TemplateId)
+	})
+
+	t.Run("should return error for invalid parameters", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrOnboardTemplateNameEmpty, s.CreateOnboardTemplate(dto.SaveOnboardTemplate{}, []int{2}, nil))
+		assert.Equal(t, xerrors.ErrParam, s.UpdateOnboardTemplate(dto.SaveOnboardTemplate{TemplateName: "Developers"}, []int{2}, nil))
+		assert.Equal(t, xerrors.ErrParam, s.DeleteOnboardTemplate(nil))
+	})
+}
+
+func TestOnboardTemplateService_GetMatchingTemplateIds(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOnboardTemplate()
+	s := NewOnboardTemplateService()
+
+	templateId := func(templateName string) int {
+		return s.GetOnboardTemplateByTemplateName(templateName).TemplateId
+	}
+
+	t.Run("should match by parent department and by post", func(t *testing.T) {
+		templateIds, err := s.GetMatchingTemplateIds(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{templateId("Everyone at HQ"), templateId("Engineering developers")}, templateIds)
+	})
+
+	t.Run("should skip templates of other posts and disabled templates", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 102, UserName: "bob", Status: "1"})
+		dal.Gorm.Create(&model.SysUserPost{UserId: 3, PostId: 2})
+
+		templateIds, err := s.GetMatchingTemplateIds(3)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{templateId("Everyone at HQ"), templateId("Operators")}, templateIds)
+	})
+
+	t.Run("should return error for an unknown user", func(t *testing.T) {
+		_, err := s.GetMatchingTemplateIds(99)
+		assert.Error(t, err)
+	})
+
+	t.Run("should ignore disabled templates", func(t *testing.T) {
+		dal.Gorm.Model(model.SysOnboardTemplate{}).Where("template_name = ?", "Operators").Update("status", constant.EXCEPTION_STATUS)
+
+		templateIds, _ := s.GetMatchingTemplateIds(3)
+		assert.Equal(t, []int{templateId("Everyone at HQ")}, templateIds)
+	})
+}
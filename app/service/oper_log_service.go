@@ -4,15 +4,19 @@ import (
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/types/constant"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 // OperLogServiceInterface defines operations for operation log management
 type OperLogServiceInterface interface {
 	DeleteOperLog(operIds []int) error
 	GetOperLogList(param dto.OperLogListRequest, isPaging bool) ([]dto.OperLogListResponse, int)
+	GetOperLogPage(param dto.OperLogListRequest) ([]dto.OperLogListResponse, int, string, error)
 	CreateSysOperLog(param dto.SaveOperLogRequest) error
+	ExportDataset(param dto.OperLogListRequest) ExportDataset
 }
 
 // OperLogService implements the operation log management interface
@@ -60,7 +64,7 @@ func (s *OperLogService) GetOperLogList(param dto.OperLogListRequest, isPaging b
 
 // GetOperLogListWithErr retrieves a list of operation logs with proper error handling
 func (s *OperLogService) GetOperLogListWithErr(param dto.OperLogListRequest, isPaging bool) ([]dto.OperLogListResponse, int, error) {
-	var count int64
+	var count int
 	operLogs := make([]dto.OperLogListResponse, 0)
 
 	if param.OrderByColumn == "" {
@@ -69,7 +73,70 @@ func (s *OperLogService) GetOperLogListWithErr(param dto.OperLogListRequest, isP
 	if param.OrderRule == "" {
 		param.OrderRule = "desc"
 	}
-	query := dal.Gorm.Model(model.SysOperLog{}).Order(param.OrderByColumn + " " + param.OrderRule)
+	order, err := operLogKeyset.order(param.OrderByColumn, param.OrderRule)
+	if err != nil {
+		order = param.OrderByColumn + " " + param.OrderRule
+	}
+	query := operLogListQuery(param).Order(order)
+
+	if isPaging {
+		count, err = countPage(query, param.Count)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count operation logs")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	err = query.Find(&operLogs).Error
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve operation logs")
+	}
+
+	return operLogs, count, nil
+}
+
+// operLogKeyset sorts and pages the operation logs by the columns the list can be sorted by
+var operLogKeyset = keyset[dto.OperLogListResponse]{
+	key:   "oper_id",
+	keyOf: func(row dto.OperLogListResponse) int { return row.OperId },
+	columns: map[string]keysetColumn[dto.OperLogListResponse]{
+		"oper_id":   {"oper_id", func(row dto.OperLogListResponse) interface{} { return row.OperId }},
+		"oper_time": {"oper_time", func(row dto.OperLogListResponse) interface{} { return row.OperTime.Time }},
+		"oper_name": {"oper_name", func(row dto.OperLogListResponse) interface{} { return row.OperName }},
+		"cost_time": {"cost_time", func(row dto.OperLogListResponse) interface{} { return row.CostTime }},
+	},
+}
+
+// GetOperLogPage retrieves a keyset page of operation logs, see dto.PageRequest
+//
+// Returns:
+//   - []dto.OperLogListResponse: The operation logs of the page
+//   - int: Total record count as the count mode of the page asks, -1 if not counted
+//   - string: The cursor of the next page, empty on the last page
+//   - error: ErrPageSortInvalid or ErrPageCursorInvalid for a bad sort or cursor, or any query error
+func (s *OperLogService) GetOperLogPage(param dto.OperLogListRequest) ([]dto.OperLogListResponse, int, string, error) {
+	if param.OrderByColumn == "" {
+		param.OrderByColumn = "oper_id"
+	}
+
+	query := operLogListQuery(param)
+
+	count, err := countPage(query, param.Count)
+	if err != nil {
+		return nil, 0, "", errors.Wrap(err, "failed to count operation logs")
+	}
+
+	operLogs, next, err := operLogKeyset.page(query, param.PageRequest, param.OrderByColumn, param.OrderRule)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	return operLogs, count, next, nil
+}
+
+// operLogListQuery builds the operation log query with the search conditions applied
+func operLogListQuery(param dto.OperLogListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysOperLog{})
 
 	if param.OperIp != "" {
 		query = query.Where("oper_ip LIKE ?", "%"+param.OperIp+"%")
@@ -95,20 +162,42 @@ func (s *OperLogService) GetOperLogListWithErr(param dto.OperLogListRequest, isP
 		query = query.Where("oper_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
 	}
 
-	if isPaging {
-		err := query.Count(&count).Error
-		if err != nil {
-			return nil, 0, errors.Wrap(err, "failed to count operation logs")
-		}
-		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
-	}
+	return query
+}
 
-	err := query.Find(&operLogs).Error
-	if err != nil {
-		return nil, 0, errors.Wrap(err, "failed to retrieve operation logs")
+// ExportDataset describes the export of the operation logs matching the search conditions, newest first
+func (s *OperLogService) ExportDataset(param dto.OperLogListRequest) ExportDataset {
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_OPER_LOG,
+		Query: func() *gorm.DB {
+			return operLogListQuery(param)
+		},
+		KeyField:  "oper_id",
+		KeyColumn: "oper_id",
+		Desc:      true,
+		Columns: []ExportColumn{
+			{Key: "operId", Field: "oper_id", Header: "Operation ID", HeaderZh: "操作序号"},
+			{Key: "title", Field: "title", Header: "Operation Module", HeaderZh: "操作模块"},
+			{Key: "businessType", Field: "business_type", Header: "Business Type", HeaderZh: "业务类型", DictType: "sys_oper_type", Labels: map[string]string{
+				"0": "Other", "1": "Add", "2": "Update", "3": "Delete", "4": "Auth", "5": "Export", "6": "Import", "7": "Force", "8": "Gen Code", "9": "Clean",
+			}},
+			{Key: "method", Field: "method", Header: "Request Method", HeaderZh: "请求方法"},
+			{Key: "requestMethod", Field: "request_method", Header: "Request Mode", HeaderZh: "请求方式"},
+			{Key: "operName", Field: "oper_name", Header: "Operator", HeaderZh: "操作人员"},
+			{Key: "deptName", Field: "dept_name", Header: "Dept Name", HeaderZh: "部门名称"},
+			{Key: "operUrl", Field: "oper_url", Header: "Request URL", HeaderZh: "请求地址"},
+			{Key: "operIp", Field: "oper_ip", Header: "Operator IP", HeaderZh: "操作地址"},
+			{Key: "operLocation", Field: "oper_location", Header: "Operator Location", HeaderZh: "操作地点"},
+			{Key: "operParam", Field: "oper_param", Header: "Request Params", HeaderZh: "请求参数"},
+			{Key: "jsonResult", Field: "json_result", Header: "Return Params", HeaderZh: "返回参数"},
+			{Key: "status", Field: "status", Header: "Operation Status", HeaderZh: "操作状态", DictType: "sys_common_status", Labels: map[string]string{
+				"0": "Normal", "1": "Abnormal",
+			}},
+			{Key: "errorMsg", Field: "error_msg", Header: "Error Message", HeaderZh: "错误消息"},
+			{Key: "operTime", Field: "oper_time", Header: "Operation Time", HeaderZh: "操作时间"},
+			{Key: "costTime", Field: "cost_time", Header: "Cost Time", HeaderZh: "消耗时间", Suffix: "ms"},
+		},
 	}
-
-	return operLogs, int(count), nil
 }
 
 // CreateSysOperLog records operation log information asynchronously
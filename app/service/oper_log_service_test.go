@@ -8,6 +8,8 @@ import (
 	"mira/anima/datetime"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -138,3 +140,125 @@ func TestOperLogService_GetOperLogList(t *testing.T) {
 		assert.Equal(t, "Special Log", logs[0].Title)
 	})
 }
+
+func TestOperLogService_GetOperLogPage(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewOperLogService()
+
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	for i, minutes := range []int{0, 5, 5, 5, 10} {
+		dal.Gorm.Create(&model.SysOperLog{
+			OperId:   i + 1,
+			Title:    "Log",
+			OperName: "admin",
+			OperTime: datetime.Datetime{Time: base.Add(time.Duration(minutes) * time.Minute)},
+			CostTime: 10 - i,
+		})
+	}
+
+	// operLogIds reads every page of a sort, returning the IDs of the logs in order
+	operLogIds := func(t *testing.T, param dto.OperLogListRequest) []int {
+		ids := make([]int, 0)
+		for pages := 0; pages < 10; pages++ {
+			logs, count, next, err := s.GetOperLogPage(param)
+			assert.NoError(t, err)
+			assert.Equal(t, 5, count)
+			for _, log := range logs {
+				ids = append(ids, log.OperId)
+			}
+			if next == "" {
+				return ids
+			}
+			param.Cursor = next
+		}
+		t.Fatal("too many pages")
+		return nil
+	}
+
+	t.Run("should page by keyset in a stable order", func(t *testing.T) {
+		ids := operLogIds(t, dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Limit: 2},
+			OrderByColumn: "oper_time",
+			OrderRule:     "DESC",
+		})
+		assert.Equal(t, []int{5, 4, 3, 2, 1}, ids)
+
+		ids = operLogIds(t, dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Limit: 2},
+			OrderByColumn: "oper_time",
+			OrderRule:     "ASC",
+		})
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+
+		ids = operLogIds(t, dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Limit: 3},
+			OrderByColumn: "cost_time",
+			OrderRule:     "ASC",
+		})
+		assert.Equal(t, []int{5, 4, 3, 2, 1}, ids)
+	})
+
+	t.Run("should break ties by ID in offset pages", func(t *testing.T) {
+		logs, _, err := s.GetOperLogListWithErr(dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{PageNum: 2, PageSize: 2},
+			OrderByColumn: "oper_time",
+			OrderRule:     "DESC",
+		}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, logs[0].OperId)
+		assert.Equal(t, 2, logs[1].OperId)
+	})
+
+	t.Run("should skip or estimate the count", func(t *testing.T) {
+		logs, count, next, err := s.GetOperLogPage(dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Limit: 10, Count: constant.PAGE_COUNT_NONE},
+			OrderByColumn: "oper_id",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, logs, 5)
+		assert.Equal(t, -1, count)
+		assert.Empty(t, next)
+
+		// Only MySQL estimates, other databases count exactly
+		_, count, _, err = s.GetOperLogPage(dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Limit: 10, Count: constant.PAGE_COUNT_ESTIMATE},
+			OrderByColumn: "oper_id",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, count)
+
+		_, count, err = s.GetOperLogListWithErr(dto.OperLogListRequest{
+			PageRequest: dto.PageRequest{PageNum: 1, PageSize: 2, Count: constant.PAGE_COUNT_NONE},
+		}, true)
+		assert.NoError(t, err)
+		assert.Equal(t, -1, count)
+	})
+
+	t.Run("should reject bad sorts and cursors", func(t *testing.T) {
+		_, _, _, err := s.GetOperLogPage(dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Limit: 2},
+			OrderByColumn: "oper_param",
+		})
+		assert.Equal(t, xerrors.ErrPageSortInvalid, err)
+
+		_, _, _, err = s.GetOperLogPage(dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Cursor: "not a cursor"},
+			OrderByColumn: "oper_time",
+		})
+		assert.Equal(t, xerrors.ErrPageCursorInvalid, err)
+
+		// A cursor only continues the sort it was read in
+		_, _, next, err := s.GetOperLogPage(dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Limit: 2},
+			OrderByColumn: "oper_time",
+		})
+		assert.NoError(t, err)
+		_, _, _, err = s.GetOperLogPage(dto.OperLogListRequest{
+			PageRequest:   dto.PageRequest{Cursor: next},
+			OrderByColumn: "oper_time",
+			OrderRule:     "ASC",
+		})
+		assert.Equal(t, xerrors.ErrPageCursorInvalid, err)
+	})
+}
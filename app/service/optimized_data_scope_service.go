@@ -27,14 +27,15 @@ func NewOptimizedDataScopeService(userService DataScopeUserServiceInterface, rol
 
 // DataScopeInfo represents cached data scope information
 type DataScopeInfo struct {
-	UserID      int                    `json:"user_id"`
-	RoleIDs     []int                  `json:"role_ids"`
-	DeptID      int                    `json:"dept_id"`
-	DataScope   string                 `json:"data_scope"`
-	DeptIDs     []int                  `json:"dept_ids"`
-	UserIDs     []int                  `json:"user_ids"`
-	CacheTime   time.Time              `json:"cache_time"`
-	TTL         time.Duration          `json:"ttl"`
+	UserID       int           `json:"user_id"`
+	RoleIDs      []int         `json:"role_ids"`
+	DeptID       int           `json:"dept_id"`
+	DataScope    string        `json:"data_scope"`
+	DeptIDs      []int         `json:"dept_ids"`
+	UserIDs      []int         `json:"user_ids"`
+	GroupUserIDs []int         `json:"group_user_ids"`
+	CacheTime    time.Time     `json:"cache_time"`
+	TTL          time.Duration `json:"ttl"`
 }
 
 // GetDataScopeOptimized returns optimized data scope filtering with caching
@@ -56,13 +57,30 @@ func (ods *OptimizedDataScopeService) GetDataScopeOptimized(ctx context.Context,
 	scopeInfo.CacheTime = time.Now()
 	scopeInfo.TTL = 30 * time.Minute
 
-	if setErr := ods.cacheService.Set(ctx, cacheKey, scopeInfo, scopeInfo.TTL); setErr != nil {
+	if setErr := ods.cacheService.SetWithTags(ctx, cacheKey, scopeInfo, scopeInfo.TTL, dataScopeCacheTags(&scopeInfo)...); setErr != nil {
 		fmt.Printf("Warning: failed to cache data scope for user %d: %v\n", userId, setErr)
 	}
 
 	return ods.buildDataScopeQuery(deptAlias, userId, userAlias, &scopeInfo)
 }
 
+// dataScopeCacheTags returns the tags of a cached data scope: its user, and the departments it is
+// granted on, whose changes may change the scope (see deptCacheTags)
+func dataScopeCacheTags(scopeInfo *DataScopeInfo) []string {
+	tags := []string{cacheTagUser(scopeInfo.UserID)}
+
+	switch scopeInfo.DataScope {
+	case DATA_SCOPE_DEPT, DATA_SCOPE_DEPT_SUB:
+		tags = append(tags, cacheTagDept(scopeInfo.DeptID))
+	case DATA_SCOPE_CUSTOM:
+		for _, deptId := range scopeInfo.DeptIDs {
+			tags = append(tags, cacheTagDept(deptId))
+		}
+	}
+
+	return tags
+}
+
 // calculateDataScope calculates data scope information for a user
 func (ods *OptimizedDataScopeService) calculateDataScope(ctx context.Context, userId int) DataScopeInfo {
 	// Get user information
@@ -94,6 +112,7 @@ func (ods *OptimizedDataScopeService) calculateDataScope(ctx context.Context, us
 	case DATA_SCOPE_CUSTOM:
 		scopeInfo.DeptIDs = ods.getCustomDeptIds(ctx, scopeInfo.RoleIDs)
 		scopeInfo.UserIDs = ods.getCustomUserIds(ctx, scopeInfo.DeptIDs)
+		scopeInfo.GroupUserIDs = ods.getCustomGroupUserIds(scopeInfo.RoleIDs)
 
 	case DATA_SCOPE_DEPT:
 		scopeInfo.DeptIDs = []int{userInfo.DeptId}
@@ -132,6 +151,7 @@ func (ods *OptimizedDataScopeService) getCustomDeptIds(ctx context.Context, role
 		INNER JOIN sys_role_dept rd ON d.dept_id = rd.dept_id
 		WHERE rd.role_id IN ?
 		AND d.status = '0'
+		AND d.delete_time IS NULL
 	`
 
 	if err := dal.Gorm.Raw(query, roleIDs).Scan(&deptList).Error; err != nil {
@@ -175,6 +195,7 @@ func (ods *OptimizedDataScopeService) getCustomUserIds(ctx context.Context, dept
 		FROM sys_user
 		WHERE dept_id IN ?
 		AND status = '0'
+		AND delete_time IS NULL
 	`
 
 	if err := dal.Gorm.Raw(query, deptIDs).Scan(&userList).Error; err != nil {
@@ -193,6 +214,36 @@ func (ods *OptimizedDataScopeService) getCustomUserIds(ctx context.Context, dept
 	return userIDs
 }
 
+// getCustomGroupUserIds gets the members of the user groups selected by custom data scope.
+// They are only cached as part of the user's data scope, which group changes invalidate.
+func (ods *OptimizedDataScopeService) getCustomGroupUserIds(roleIDs []int) []int {
+	var userList []struct {
+		UserId int
+	}
+
+	query := `
+		SELECT DISTINCT gu.user_id
+		FROM sys_user_group_user gu
+		INNER JOIN sys_role_group rg ON gu.group_id = rg.group_id
+		INNER JOIN sys_user_group g ON gu.group_id = g.group_id
+		WHERE rg.role_id IN ?
+		AND g.status = '0'
+		AND g.delete_time IS NULL
+	`
+
+	if err := dal.Gorm.Raw(query, roleIDs).Scan(&userList).Error; err != nil {
+		fmt.Printf("Error getting custom group user IDs: %v\n", err)
+		return []int{}
+	}
+
+	userIDs := make([]int, len(userList))
+	for i, user := range userList {
+		userIDs[i] = user.UserId
+	}
+
+	return userIDs
+}
+
 // getSubDeptIds gets all sub-department IDs for a given department
 func (ods *OptimizedDataScopeService) getSubDeptIds(deptId int) []int {
 	cacheKey := fmt.Sprintf("sub_depts:%d", deptId)
@@ -215,7 +266,7 @@ func (ods *OptimizedDataScopeService) getSubDeptIds(deptId int) []int {
 			UNION ALL
 			SELECT d.dept_id FROM sys_dept d
 			INNER JOIN dept_tree dt ON d.parent_id = dt.dept_id
-			WHERE d.status = '0'
+			WHERE d.status = '0' AND d.delete_time IS NULL
 		)
 		SELECT dept_id FROM dept_tree
 	`
@@ -261,6 +312,7 @@ func (ods *OptimizedDataScopeService) getDeptUserIds(deptIDs ...int) []int {
 		FROM sys_user
 		WHERE dept_id IN ?
 		AND status = '0'
+		AND delete_time IS NULL
 	`
 
 	if err := dal.Gorm.Raw(query, deptIDs).Scan(&userList).Error; err != nil {
@@ -293,6 +345,11 @@ func (ods *OptimizedDataScopeService) buildDataScopeQuery(deptAlias string, user
 			return db
 
 		case DATA_SCOPE_CUSTOM:
+			// Members of the selected user groups are visible wherever their department is
+			if len(scopeInfo.GroupUserIDs) > 0 && userAlias != "" {
+				db = db.Where(fmt.Sprintf("%s.dept_id IN ? OR %s.user_id IN ?", deptAlias, userAlias), scopeInfo.DeptIDs, scopeInfo.GroupUserIDs)
+				break
+			}
 			if len(scopeInfo.DeptIDs) > 0 {
 				db = db.Where(fmt.Sprintf("%s.dept_id IN ?", deptAlias), scopeInfo.DeptIDs)
 			}
@@ -402,21 +459,17 @@ func (ods *OptimizedDataScopeService) PreloadDataScopeCache(ctx context.Context,
 		return nil
 	}
 
-	// Create batch of cache items
-	items := make([]CacheItem, 0, len(userIDs))
-
 	for _, userID := range userIDs {
 		scopeInfo := ods.calculateDataScope(ctx, userID)
 		scopeInfo.CacheTime = time.Now()
 		scopeInfo.TTL = 30 * time.Minute
 
+		// Tagged one by one, so that department changes find the scopes
 		cacheKey := rediskey.UserDataScopeKey(userID)
-		items = append(items, CacheItem{
-			Key:        cacheKey,
-			Value:      scopeInfo,
-			Expiration: scopeInfo.TTL,
-		})
+		if err := ods.cacheService.SetWithTags(ctx, cacheKey, scopeInfo, scopeInfo.TTL, dataScopeCacheTags(&scopeInfo)...); err != nil {
+			return err
+		}
 	}
 
-	return ods.cacheService.SetMultiple(ctx, items)
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"mira/app/dto"
+	"mira/common/pdf"
+	"mira/common/types/constant"
+)
+
+// Org chart layout, in points
+const (
+	orgChartMargin     = 20.0
+	orgChartBoxWidth   = 180.0
+	orgChartPadding    = 8.0
+	orgChartTitleSize  = 11.0
+	orgChartTextSize   = 9.0
+	orgChartLineHeight = 13.0
+	orgChartGapX       = 24.0
+	orgChartGapY       = 36.0
+	orgChartMaxLines   = 10
+)
+
+// orgChartLabels are the labels of the org chart lines in English and Chinese
+var orgChartLabels = map[string]map[string]string{
+	constant.EXPORT_LANG_EN: {
+		"leader": "Leader: %s", "headCount": "Head count: %d (%d in total)", "members": "Members: %s",
+		"post": "%s (%d)", "postHolders": "%s: %s", "more": "+%d more", "page": "Page %d of %d (row %d, column %d)",
+	},
+	constant.EXPORT_LANG_ZH: {
+		"leader": "负责人：%s", "headCount": "人数：%d（合计 %d）", "members": "成员：%s",
+		"post": "%s（%d）", "postHolders": "%s：%s", "more": "另有 %d 项", "page": "第 %d/%d 页（第 %d 行，第 %d 列）",
+	},
+}
+
+// orgChartFields are the visible fields of an org chart
+type orgChartFields map[string]bool
+
+// parseOrgChartFields parses a comma-separated list of visible fields, the leader and the
+// head count are shown when none is given
+func parseOrgChartFields(fields string) orgChartFields {
+	if strings.TrimSpace(fields) == "" {
+		return orgChartFields{constant.ORG_CHART_FIELD_LEADER: true, constant.ORG_CHART_FIELD_HEAD_COUNT: true}
+	}
+
+	visible := make(orgChartFields)
+	for _, field := range strings.Split(fields, ",") {
+		visible[strings.TrimSpace(field)] = true
+	}
+	return visible
+}
+
+// orgChartBox is a department placed on the chart
+type orgChartBox struct {
+	x, y, width, height float64
+	depth               int
+	parent              int
+	title               string
+	lines               []string
+}
+
+// orgChartLayout is the tree of department boxes with the size of the whole chart
+type orgChartLayout struct {
+	boxes  []orgChartBox
+	width  float64
+	height float64
+}
+
+// layoutOrgChart places the departments of the tree top-down, each parent centered above its children
+func layoutOrgChart(tree []dto.OrgChartDeptResponse, fields orgChartFields, lang string) orgChartLayout {
+	layout := orgChartLayout{boxes: make([]orgChartBox, 0)}
+	labels := orgChartLabelsOf(lang)
+
+	levelHeights := make([]float64, 0)
+	cursor := orgChartMargin
+
+	var place func(dept dto.OrgChartDeptResponse, depth, parent int) int
+	place = func(dept dto.OrgChartDeptResponse, depth, parent int) int {
+		lines := orgChartLines(dept, fields, labels)
+		index := len(layout.boxes)
+		layout.boxes = append(layout.boxes, orgChartBox{
+			width:  orgChartBoxWidth,
+			height: 2*orgChartPadding + orgChartTitleSize + 4 + float64(len(lines))*orgChartLineHeight,
+			depth:  depth,
+			parent: parent,
+			title:  dept.DeptName,
+			lines:  lines,
+		})
+
+		if len(levelHeights) <= depth {
+			levelHeights = append(levelHeights, 0)
+		}
+		levelHeights[depth] = math.Max(levelHeights[depth], layout.boxes[index].height)
+
+		if len(dept.Children) == 0 {
+			layout.boxes[index].x = cursor
+			cursor += orgChartBoxWidth + orgChartGapX
+			return index
+		}
+
+		first, last := -1, -1
+		for _, child := range dept.Children {
+			childIndex := place(child, depth+1, index)
+			if first < 0 {
+				first = childIndex
+			}
+			last = childIndex
+		}
+		layout.boxes[index].x = (layout.boxes[first].x + layout.boxes[last].x) / 2
+		return index
+	}
+
+	for _, dept := range tree {
+		place(dept, 0, -1)
+	}
+
+	levelTops := make([]float64, len(levelHeights))
+	top := orgChartMargin
+	for depth, height := range levelHeights {
+		levelTops[depth] = top
+		top += height + orgChartGapY
+	}
+	for i := range layout.boxes {
+		layout.boxes[i].y = levelTops[layout.boxes[i].depth]
+	}
+
+	layout.width = math.Max(cursor-orgChartGapX+orgChartMargin, orgChartBoxWidth+2*orgChartMargin)
+	layout.height = math.Max(top-orgChartGapY+orgChartMargin, 2*orgChartMargin)
+
+	return layout
+}
+
+// orgChartLabelsOf returns the labels of a language, English by default
+func orgChartLabelsOf(lang string) map[string]string {
+	if labels, ok := orgChartLabels[lang]; ok {
+		return labels
+	}
+	return orgChartLabels[constant.EXPORT_LANG_EN]
+}
+
+// orgChartLines builds the text lines of a department box from the visible fields
+func orgChartLines(dept dto.OrgChartDeptResponse, fields orgChartFields, labels map[string]string) []string {
+	lines := make([]string, 0)
+
+	if fields[constant.ORG_CHART_FIELD_LEADER] && dept.Leader != "" {
+		lines = append(lines, fmt.Sprintf(labels["leader"], dept.Leader))
+	}
+
+	if fields[constant.ORG_CHART_FIELD_HEAD_COUNT] {
+		lines = append(lines, fmt.Sprintf(labels["headCount"], dept.HeadCount, dept.TotalHeadCount))
+	}
+
+	members := dept.Users
+	if fields[constant.ORG_CHART_FIELD_POSTS] {
+		for _, post := range dept.Posts {
+			if fields[constant.ORG_CHART_FIELD_USERS] {
+				lines = append(lines, fmt.Sprintf(labels["postHolders"], post.PostName, orgChartUserNames(post.Users)))
+			} else {
+				lines = append(lines, fmt.Sprintf(labels["post"], post.PostName, len(post.Users)))
+			}
+		}
+	} else {
+		members = orgChartMembers(dept)
+	}
+
+	if fields[constant.ORG_CHART_FIELD_USERS] && len(members) > 0 {
+		lines = append(lines, fmt.Sprintf(labels["members"], orgChartUserNames(members)))
+	}
+
+	if len(lines) > orgChartMaxLines {
+		more := len(lines) - orgChartMaxLines + 1
+		lines = append(lines[:orgChartMaxLines-1], fmt.Sprintf(labels["more"], more))
+	}
+
+	return lines
+}
+
+// orgChartMembers returns the users of a department, holding a post or not, once each
+func orgChartMembers(dept dto.OrgChartDeptResponse) []dto.OrgChartUserResponse {
+	members := make([]dto.OrgChartUserResponse, 0, dept.HeadCount)
+	seen := make(map[int]bool)
+	for _, post := range dept.Posts {
+		for _, user := range post.Users {
+			if !seen[user.UserId] {
+				seen[user.UserId] = true
+				members = append(members, user)
+			}
+		}
+	}
+	return append(members, dept.Users...)
+}
+
+// orgChartUserNames joins the names of users, preferring the nickname
+func orgChartUserNames(users []dto.OrgChartUserResponse) string {
+	names := make([]string, 0, len(users))
+	for _, user := range users {
+		names = append(names, orgChartUserName(user))
+	}
+	return strings.Join(names, ", ")
+}
+
+// orgChartUserName returns the nickname of a user, or the username when it has none
+func orgChartUserName(user dto.OrgChartUserResponse) string {
+	if user.NickName != "" {
+		return user.NickName
+	}
+	return user.UserName
+}
+
+// fitOrgChartText shortens text to fit a width, estimating half an em for ASCII characters
+// and a full em for the others
+func fitOrgChartText(text string, size, width float64) string {
+	runes := []rune(text)
+	used := 0.0
+	for i, r := range runes {
+		advance := size
+		if r < 0x80 {
+			advance = size * 0.55
+		}
+		if used+advance > width {
+			// Leave room for the ellipsis
+			for i > 0 && used+3*size*0.55 > width {
+				i--
+				used -= orgChartRuneWidth(runes[i], size)
+			}
+			return string(runes[:i]) + "..."
+		}
+		used += advance
+	}
+	return text
+}
+
+// orgChartRuneWidth estimates the width of a character
+func orgChartRuneWidth(r rune, size float64) float64 {
+	if r < 0x80 {
+		return size * 0.55
+	}
+	return size
+}
+
+// orgChartConnector returns the elbow connecting a parent box to a child box as a polyline
+func orgChartConnector(parent, child orgChartBox) [4][2]float64 {
+	midY := child.y - orgChartGapY/2
+	return [4][2]float64{
+		{parent.x + parent.width/2, parent.y + parent.height},
+		{parent.x + parent.width/2, midY},
+		{child.x + child.width/2, midY},
+		{child.x + child.width/2, child.y},
+	}
+}
+
+// writeOrgChartSVG renders the org chart as SVG
+func writeOrgChartSVG(w io.Writer, layout orgChartLayout) error {
+	bw := bufio.NewWriter(w)
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace
+
+	fmt.Fprintf(bw, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="sans-serif">`+"\n",
+		svgNum(layout.width), svgNum(layout.height), svgNum(layout.width), svgNum(layout.height))
+	fmt.Fprintf(bw, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+
+	bw.WriteString(`<g fill="none" stroke="#8c8c8c" stroke-width="1">` + "\n")
+	for _, box := range layout.boxes {
+		if box.parent < 0 {
+			continue
+		}
+		p := orgChartConnector(layout.boxes[box.parent], box)
+		fmt.Fprintf(bw, `<path d="M%s %s V%s H%s V%s"/>`+"\n", svgNum(p[0][0]), svgNum(p[0][1]), svgNum(p[1][1]), svgNum(p[2][0]), svgNum(p[3][1]))
+	}
+	bw.WriteString("</g>\n")
+
+	for _, box := range layout.boxes {
+		fmt.Fprintf(bw, `<g><rect x="%s" y="%s" width="%s" height="%s" rx="4" fill="#f5f7fa" stroke="#595959"/>`+"\n",
+			svgNum(box.x), svgNum(box.y), svgNum(box.width), svgNum(box.height))
+
+		textWidth := box.width - 2*orgChartPadding
+		baseline := box.y + orgChartPadding + orgChartTitleSize
+		fmt.Fprintf(bw, `<text x="%s" y="%s" font-size="%s" font-weight="bold">%s</text>`+"\n",
+			svgNum(box.x+orgChartPadding), svgNum(baseline), svgNum(orgChartTitleSize), escape(fitOrgChartText(box.title, orgChartTitleSize, textWidth)))
+
+		baseline += 4
+		for _, line := range box.lines {
+			baseline += orgChartLineHeight
+			fmt.Fprintf(bw, `<text x="%s" y="%s" font-size="%s" fill="#262626">%s</text>`+"\n",
+				svgNum(box.x+orgChartPadding), svgNum(baseline), svgNum(orgChartTextSize), escape(fitOrgChartText(line, orgChartTextSize, textWidth)))
+		}
+		bw.WriteString("</g>\n")
+	}
+
+	bw.WriteString("</svg>\n")
+
+	return bw.Flush()
+}
+
+// svgNum formats a coordinate with at most two decimals
+func svgNum(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// writeOrgChartPDF renders the org chart as a PDF on A4 landscape pages. A chart larger than
+// a page is tiled across pages row by row, each page footer telling its place in the grid.
+func writeOrgChartPDF(w io.Writer, layout orgChartLayout, lang string) error {
+	const margin, footer = 28.0, 16.0
+	contentWidth := pdf.A4LandscapeWidth - 2*margin
+	contentHeight := pdf.A4LandscapeHeight - 2*margin - footer
+
+	cols := int(math.Ceil(layout.width / contentWidth))
+	rows := int(math.Ceil(layout.height / contentHeight))
+	labels := orgChartLabelsOf(lang)
+
+	doc := pdf.New(pdf.A4LandscapeWidth, pdf.A4LandscapeHeight)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			page := doc.AddPage()
+
+			page.Clip(margin, margin, contentWidth, contentHeight)
+			page.Translate(margin-float64(col)*contentWidth, margin-float64(row)*contentHeight)
+			drawOrgChartPage(page, layout)
+			page.Restore()
+			page.Restore()
+
+			page.SetFillGray(0.4)
+			page.Text(margin, pdf.A4LandscapeHeight-margin, 8,
+				fmt.Sprintf(labels["page"], row*cols+col+1, rows*cols, row+1, col+1))
+		}
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+// drawOrgChartPage draws the connectors and the department boxes of the chart on a page
+func drawOrgChartPage(page *pdf.Page, layout orgChartLayout) {
+	page.SetLineWidth(1)
+	page.SetStrokeGray(0.55)
+	for _, box := range layout.boxes {
+		if box.parent < 0 {
+			continue
+		}
+		p := orgChartConnector(layout.boxes[box.parent], box)
+		for i := 0; i < len(p)-1; i++ {
+			page.Line(p[i][0], p[i][1], p[i+1][0], p[i+1][1])
+		}
+	}
+
+	textWidth := orgChartBoxWidth - 2*orgChartPadding
+	for _, box := range layout.boxes {
+		page.SetStrokeGray(0.35)
+		page.SetFillGray(0.96)
+		page.Rect(box.x, box.y, box.width, box.height, true)
+
+		page.SetFillGray(0)
+		baseline := box.y + orgChartPadding + orgChartTitleSize
+		page.Text(box.x+orgChartPadding, baseline, orgChartTitleSize, fitOrgChartText(box.title, orgChartTitleSize, textWidth))
+
+		page.SetFillGray(0.15)
+		baseline += 4
+		for _, line := range box.lines {
+			baseline += orgChartLineHeight
+			page.Text(box.x+orgChartPadding, baseline, orgChartTextSize, fitOrgChartText(line, orgChartTextSize, textWidth))
+		}
+	}
+}
+
+// buildOrgChartGraph converts the org chart into nodes and edges for graph libraries. Departments
+// link to their sub-departments, posts to the department they are held in and to the post they
+// report to in the same or a superior department, and users to their posts or departments.
+func buildOrgChartGraph(tree []dto.OrgChartDeptResponse, fields orgChartFields) dto.OrgChartGraphResponse {
+	graph := dto.OrgChartGraphResponse{Nodes: make([]dto.OrgChartGraphNode, 0), Edges: make([]dto.OrgChartGraphEdge, 0)}
+	users := make(map[int]bool)
+
+	var visit func(dept dto.OrgChartDeptResponse, parentId string, ancestorPosts []map[int]string)
+	visit = func(dept dto.OrgChartDeptResponse, parentId string, ancestorPosts []map[int]string) {
+		deptId := "dept:" + strconv.Itoa(dept.DeptId)
+		data := map[string]interface{}{"deptId": dept.DeptId, "parentId": dept.ParentId}
+		if fields[constant.ORG_CHART_FIELD_LEADER] {
+			data["leader"] = dept.Leader
+		}
+		if fields[constant.ORG_CHART_FIELD_HEAD_COUNT] {
+			data["headCount"] = dept.HeadCount
+			data["totalHeadCount"] = dept.TotalHeadCount
+		}
+		graph.Nodes = append(graph.Nodes, dto.OrgChartGraphNode{Id: deptId, Type: "dept", Label: dept.DeptName, Data: data})
+		if parentId != "" {
+			graph.Edges = append(graph.Edges, dto.OrgChartGraphEdge{Source: parentId, Target: deptId, Type: "subDept"})
+		}
+
+		addUser := func(user dto.OrgChartUserResponse, source, edgeType string) {
+			userId := "user:" + strconv.Itoa(user.UserId)
+			if !users[user.UserId] {
+				users[user.UserId] = true
+				graph.Nodes = append(graph.Nodes, dto.OrgChartGraphNode{Id: userId, Type: "user", Label: orgChartUserName(user), Data: map[string]interface{}{
+					"userId": user.UserId, "userName": user.UserName, "nickName": user.NickName,
+				}})
+			}
+			graph.Edges = append(graph.Edges, dto.OrgChartGraphEdge{Source: source, Target: userId, Type: edgeType})
+		}
+
+		posts := make(map[int]string)
+		if fields[constant.ORG_CHART_FIELD_POSTS] {
+			for _, post := range dept.Posts {
+				posts[post.PostId] = "post:" + strconv.Itoa(dept.DeptId) + ":" + strconv.Itoa(post.PostId)
+			}
+			scopes := append([]map[int]string{posts}, ancestorPosts...)
+
+			for _, post := range dept.Posts {
+				postId := posts[post.PostId]
+				graph.Nodes = append(graph.Nodes, dto.OrgChartGraphNode{Id: postId, Type: "post", Label: post.PostName, Data: map[string]interface{}{
+					"postId": post.PostId, "postCode": post.PostCode, "parentId": post.ParentId, "deptId": dept.DeptId,
+				}})
+				graph.Edges = append(graph.Edges, dto.OrgChartGraphEdge{Source: deptId, Target: postId, Type: "post"})
+
+				// The superior post is looked up in the department first, then up the tree
+				for _, scope := range scopes {
+					if superiorId, ok := scope[post.ParentId]; ok && post.ParentId != 0 {
+						graph.Edges = append(graph.Edges, dto.OrgChartGraphEdge{Source: postId, Target: superiorId, Type: "reportsTo"})
+						break
+					}
+				}
+
+				if fields[constant.ORG_CHART_FIELD_USERS] {
+					for _, user := range post.Users {
+						addUser(user, postId, "holder")
+					}
+				}
+			}
+
+			ancestorPosts = scopes
+		}
+
+		if fields[constant.ORG_CHART_FIELD_USERS] {
+			members := dept.Users
+			if !fields[constant.ORG_CHART_FIELD_POSTS] {
+				members = orgChartMembers(dept)
+			}
+			for _, user := range members {
+				addUser(user, deptId, "member")
+			}
+		}
+
+		for _, child := range dept.Children {
+			visit(child, deptId, ancestorPosts)
+		}
+	}
+
+	for _, dept := range tree {
+		visit(dept, "", nil)
+	}
+
+	return graph
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"io"
+	"sort"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+
+	"github.com/pkg/errors"
+)
+
+// OrgChartServiceInterface defines operations for the organization chart
+type OrgChartServiceInterface interface {
+	GetOrgChart(param dto.OrgChartRequest, userId int) []dto.OrgChartDeptResponse
+	GetOrgChartGraph(param dto.OrgChartExportRequest, userId int) dto.OrgChartGraphResponse
+	WriteOrgChart(w io.Writer, param dto.OrgChartExportRequest, userId int) error
+}
+
+// OrgChartService combines the department tree, the posts and the users holding them
+type OrgChartService struct{}
+
+// Ensure OrgChartService implements OrgChartServiceInterface
+var _ OrgChartServiceInterface = (*OrgChartService)(nil)
+
+// NewOrgChartService creates a new OrgChartService
+func NewOrgChartService() *OrgChartService {
+	return &OrgChartService{}
+}
+
+// orgChartUser is a normal user with the department it belongs to
+type orgChartUser struct {
+	dto.OrgChartUserResponse
+	DeptId int
+}
+
+// orgChartUserPost is a normal post held by a user
+type orgChartUserPost struct {
+	UserId   int
+	PostId   int
+	ParentId int
+	PostCode string
+	PostName string
+}
+
+// GetOrgChart retrieves the organization chart within the data scope of a user
+func (s *OrgChartService) GetOrgChart(param dto.OrgChartRequest, userId int) []dto.OrgChartDeptResponse {
+	tree, _ := s.GetOrgChartWithErr(param, userId)
+	return tree
+}
+
+// GetOrgChartWithErr retrieves the organization chart with proper error handling
+//
+// Each department lists the posts held by its users, with the superior post they report to,
+// the users holding no post and the head counts of the department and its subtree. Only normal departments, posts and users within the data scope
+// are included; a department whose parent is outside the scope becomes a root. A deptId selects
+// the subtree of that department.
+func (s *OrgChartService) GetOrgChartWithErr(param dto.OrgChartRequest, userId int) ([]dto.OrgChartDeptResponse, error) {
+	depts := make([]dto.OrgChartDeptResponse, 0)
+	if err := dal.Gorm.Model(model.SysDept{}).
+		Select("dept_id", "parent_id", "dept_name", "leader").
+		Where("status = ?", constant.NORMAL_STATUS).
+		Scopes(GetDataScope("sys_dept", userId, "")).
+		Order("order_num, dept_id").
+		Find(&depts).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve departments")
+	}
+
+	deptIds := make([]int, 0, len(depts))
+	for _, dept := range depts {
+		deptIds = append(deptIds, dept.DeptId)
+	}
+
+	users := make([]orgChartUser, 0)
+	if len(deptIds) > 0 {
+		if err := dal.Gorm.Model(model.SysUser{}).
+			Select("sys_user.user_id", "sys_user.user_name", "sys_user.nick_name", "sys_user.dept_id").
+			Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
+			Where("sys_user.status = ? AND sys_user.dept_id IN ?", constant.NORMAL_STATUS, deptIds).
+			Scopes(GetDataScope("sys_dept", userId, "sys_user")).
+			Order("sys_user.user_id").
+			Find(&users).Error; err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve users")
+		}
+	}
+
+	userIds := make([]int, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.UserId)
+	}
+
+	userPosts := make([]orgChartUserPost, 0)
+	if len(userIds) > 0 {
+		if err := dal.Gorm.Model(model.SysUserPost{}).
+			Select("sys_user_post.user_id", "sys_post.post_id", "sys_post.parent_id", "sys_post.post_code", "sys_post.post_name").
+			Joins("JOIN sys_post ON sys_post.post_id = sys_user_post.post_id AND sys_post.status = ? AND sys_post.delete_time IS NULL", constant.NORMAL_STATUS).
+			Where("sys_user_post.user_id IN ?", userIds).
+			Order("sys_post.post_sort, sys_post.post_id").
+			Find(&userPosts).Error; err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve user posts")
+		}
+	}
+
+	postsByUser := make(map[int][]orgChartUserPost)
+	for _, userPost := range userPosts {
+		postsByUser[userPost.UserId] = append(postsByUser[userPost.UserId], userPost)
+	}
+
+	deptIndex := make(map[int]int, len(depts))
+	for i := range depts {
+		depts[i].Posts = make([]dto.OrgChartPostResponse, 0)
+		depts[i].Users = make([]dto.OrgChartUserResponse, 0)
+		deptIndex[depts[i].DeptId] = i
+	}
+
+	// Posts are shared across departments, each department lists the ones its users hold
+	postIndex := make(map[[2]int]int)
+	for _, user := range users {
+		dept := &depts[deptIndex[user.DeptId]]
+		dept.HeadCount++
+		if len(postsByUser[user.UserId]) == 0 {
+			dept.Users = append(dept.Users, user.OrgChartUserResponse)
+			continue
+		}
+		for _, userPost := range postsByUser[user.UserId] {
+			key := [2]int{dept.DeptId, userPost.PostId}
+			if _, ok := postIndex[key]; !ok {
+				postIndex[key] = len(dept.Posts)
+				dept.Posts = append(dept.Posts, dto.OrgChartPostResponse{
+					PostId:   userPost.PostId,
+					ParentId: userPost.ParentId,
+					PostCode: userPost.PostCode,
+					PostName: userPost.PostName,
+					Users:    make([]dto.OrgChartUserResponse, 0),
+				})
+			}
+			post := &dept.Posts[postIndex[key]]
+			post.Users = append(post.Users, user.OrgChartUserResponse)
+		}
+	}
+
+	// Posts are listed in post order, users joined them in user order
+	postOrder := make(map[int]int, len(userPosts))
+	for i, userPost := range userPosts {
+		if _, ok := postOrder[userPost.PostId]; !ok {
+			postOrder[userPost.PostId] = i
+		}
+	}
+	for i := range depts {
+		posts := depts[i].Posts
+		sort.SliceStable(posts, func(a, b int) bool {
+			return postOrder[posts[a].PostId] < postOrder[posts[b].PostId]
+		})
+	}
+
+	tree := make([]dto.OrgChartDeptResponse, 0)
+	for _, dept := range depts {
+		_, hasParent := deptIndex[dept.ParentId]
+		if (param.DeptId > 0 && dept.DeptId == param.DeptId) || (param.DeptId <= 0 && !hasParent) {
+			dept.Children = orgChartToTree(depts, dept.DeptId)
+			tree = append(tree, dept)
+		}
+	}
+	countOrgChart(tree)
+
+	return tree, nil
+}
+
+// GetOrgChartGraph retrieves the organization chart as a graph of nodes and edges
+func (s *OrgChartService) GetOrgChartGraph(param dto.OrgChartExportRequest, userId int) dto.OrgChartGraphResponse {
+	graph, _ := s.GetOrgChartGraphWithErr(param, userId)
+	return graph
+}
+
+// GetOrgChartGraphWithErr retrieves the organization chart as a graph with proper error handling
+func (s *OrgChartService) GetOrgChartGraphWithErr(param dto.OrgChartExportRequest, userId int) (dto.OrgChartGraphResponse, error) {
+	tree, err := s.GetOrgChartWithErr(dto.OrgChartRequest{DeptId: param.DeptId}, userId)
+	if err != nil {
+		return dto.OrgChartGraphResponse{}, err
+	}
+
+	return buildOrgChartGraph(tree, parseOrgChartFields(param.Fields)), nil
+}
+
+// WriteOrgChart renders the organization chart as SVG, or as PDF, with the visible fields of the request
+func (s *OrgChartService) WriteOrgChart(w io.Writer, param dto.OrgChartExportRequest, userId int) error {
+	tree, err := s.GetOrgChartWithErr(dto.OrgChartRequest{DeptId: param.DeptId}, userId)
+	if err != nil {
+		return err
+	}
+
+	layout := layoutOrgChart(tree, parseOrgChartFields(param.Fields), param.Lang)
+	if param.Format == constant.ORG_CHART_FORMAT_PDF {
+		return errors.Wrap(writeOrgChartPDF(w, layout, param.Lang), "failed to write org chart")
+	}
+	return errors.Wrap(writeOrgChartSVG(w, layout), "failed to write org chart")
+}
+
+// countOrgChart sums up the head counts of the departments with their subtrees, returning the total
+func countOrgChart(depts []dto.OrgChartDeptResponse) int {
+	total := 0
+	for i := range depts {
+		depts[i].TotalHeadCount = depts[i].HeadCount + countOrgChart(depts[i].Children)
+		total += depts[i].TotalHeadCount
+	}
+	return total
+}
+
+// orgChartToTree builds the subtree of the departments below a parent department
+func orgChartToTree(depts []dto.OrgChartDeptResponse, parentId int) []dto.OrgChartDeptResponse {
+	tree := make([]dto.OrgChartDeptResponse, 0)
+
+	for _, dept := range depts {
+		if dept.ParentId == parentId {
+			dept.Children = orgChartToTree(depts, dept.DeptId)
+			tree = append(tree, dept)
+		}
+	}
+
+	return tree
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seedOrgChart() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ", Leader: "admin", OrderNum: 1, Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 101, ParentId: 100, Ancestors: "0,100", DeptName: "Sales", OrderNum: 1, Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 102, ParentId: 100, Ancestors: "0,100", DeptName: "R&D", OrderNum: 2, Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 103, ParentId: 100, Ancestors: "0,100", DeptName: "Closed", OrderNum: 3, Status: "1"})
+
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", NickName: "Admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 101, UserName: "alice", NickName: "Alice", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 102, UserName: "bob", NickName: "Bob", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 102, UserName: "carol", NickName: "Carol", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 5, DeptId: 102, UserName: "dave", NickName: "Dave", Status: "1"})
+
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "ceo", PostName: "CEO", PostSort: 1, Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 2, ParentId: 1, PostCode: "se", PostName: "Manager", PostSort: 2, Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 3, ParentId: 2, PostCode: "user", PostName: "Staff", PostSort: 3, Status: "0"})
+
+	dal.Gorm.Create(&model.SysUserPost{UserId: 1, PostId: 1})
+	dal.Gorm.Create(&model.SysUserPost{UserId: 3, PostId: 3})
+	dal.Gorm.Create(&model.SysUserPost{UserId: 3, PostId: 2})
+	dal.Gorm.Create(&model.SysUserPost{UserId: 4, PostId: 3})
+	dal.Gorm.Create(&model.SysUserPost{UserId: 5, PostId: 3})
+}
+
+func TestOrgChartService_GetOrgChart(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOrgChart()
+	s := NewOrgChartService()
+
+	t.Run("should combine the dept tree with the posts and their holders", func(t *testing.T) {
+		tree, err := s.GetOrgChartWithErr(dto.OrgChartRequest{}, 1)
+		assert.NoError(t, err)
+		assert.Len(t, tree, 1)
+
+		hq := tree[0]
+		assert.Equal(t, "HQ", hq.DeptName)
+		assert.Equal(t, "admin", hq.Leader)
+		assert.Len(t, hq.Posts, 1)
+		assert.Equal(t, "CEO", hq.Posts[0].PostName)
+		assert.Equal(t, "admin", hq.Posts[0].Users[0].UserName)
+
+		// Disabled departments are left out
+		assert.Len(t, hq.Children, 2)
+		sales, rd := hq.Children[0], hq.Children[1]
+		assert.Equal(t, 1, hq.HeadCount)
+		assert.Equal(t, 4, hq.TotalHeadCount)
+		assert.Equal(t, 2, rd.HeadCount)
+
+		assert.Empty(t, sales.Posts)
+		assert.Equal(t, []dto.OrgChartUserResponse{{UserId: 2, UserName: "alice", NickName: "Alice"}}, sales.Users)
+
+		// Posts follow the post order with the superior post they report to, disabled users are left out
+		assert.Len(t, rd.Posts, 2)
+		assert.Equal(t, "Manager", rd.Posts[0].PostName)
+		assert.Equal(t, 1, rd.Posts[0].ParentId)
+		assert.Equal(t, "Staff", rd.Posts[1].PostName)
+		assert.Equal(t, 2, rd.Posts[1].ParentId)
+		assert.Len(t, rd.Posts[1].Users, 2)
+		assert.Empty(t, rd.Users)
+	})
+
+	t.Run("should leave out disabled posts", func(t *testing.T) {
+		dal.Gorm.Model(model.SysPost{}).Where("post_id = ?", 2).Update("status", "1")
+		defer dal.Gorm.Model(model.SysPost{}).Where("post_id = ?", 2).Update("status", "0")
+
+		tree := s.GetOrgChart(dto.OrgChartRequest{DeptId: 102}, 1)
+		assert.Len(t, tree, 1)
+		assert.Len(t, tree[0].Posts, 1)
+		assert.Equal(t, "Staff", tree[0].Posts[0].PostName)
+	})
+
+	t.Run("should select the subtree of a department", func(t *testing.T) {
+		tree := s.GetOrgChart(dto.OrgChartRequest{DeptId: 101}, 1)
+		assert.Len(t, tree, 1)
+		assert.Equal(t, "Sales", tree[0].DeptName)
+		assert.Empty(t, tree[0].Children)
+
+		assert.Empty(t, s.GetOrgChart(dto.OrgChartRequest{DeptId: 103}, 1))
+	})
+}
+
+func TestOrgChartService_Export(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOrgChart()
+	s := NewOrgChartService()
+
+	t.Run("should render the chart as SVG with the leader and head counts by default", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := s.WriteOrgChart(&buf, dto.OrgChartExportRequest{}, 1)
+		assert.NoError(t, err)
+
+		svg := buf.String()
+		assert.True(t, strings.HasPrefix(svg, "<?xml"))
+		assert.Contains(t, svg, ">HQ</text>")
+		assert.Contains(t, svg, ">R&amp;D</text>")
+		assert.Contains(t, svg, "Leader: admin")
+		assert.Contains(t, svg, "Head count: 1 (4 in total)")
+		assert.NotContains(t, svg, "Staff")
+		// One connector per child department
+		assert.Equal(t, 2, strings.Count(svg, "<path "))
+	})
+
+	t.Run("should render the visible fields in the requested language", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := s.WriteOrgChart(&buf, dto.OrgChartExportRequest{DeptId: 102, Fields: "posts,users", Lang: "zh"}, 1)
+		assert.NoError(t, err)
+
+		svg := buf.String()
+		assert.Contains(t, svg, "Manager：Bob")
+		assert.Contains(t, svg, "Staff：Bob, Carol")
+		assert.NotContains(t, svg, "负责人")
+		assert.NotContains(t, svg, "HQ")
+	})
+
+	t.Run("should render the chart as PDF", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := s.WriteOrgChart(&buf, dto.OrgChartExportRequest{Format: "pdf"}, 1)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(buf.String(), "%PDF-1.4"))
+		assert.Contains(t, buf.String(), "/Count 1")
+	})
+
+	t.Run("should tile a wide chart across PDF pages", func(t *testing.T) {
+		tree := make([]dto.OrgChartDeptResponse, 0)
+		for i := 0; i < 10; i++ {
+			tree = append(tree, dto.OrgChartDeptResponse{DeptId: i + 1, DeptName: "Dept"})
+		}
+		layout := layoutOrgChart(tree, parseOrgChartFields(""), "")
+
+		var buf bytes.Buffer
+		assert.NoError(t, writeOrgChartPDF(&buf, layout, ""))
+		// 10 boxes of 180pt with gaps need 3 pages of 785pt
+		assert.Contains(t, buf.String(), "/Count 3")
+	})
+
+	t.Run("should convert the chart into a graph", func(t *testing.T) {
+		graph := s.GetOrgChartGraph(dto.OrgChartExportRequest{Fields: "headCount,posts,users"}, 1)
+
+		nodes := make(map[string]dto.OrgChartGraphNode)
+		for _, node := range graph.Nodes {
+			nodes[node.Id] = node
+		}
+		assert.Len(t, nodes, len(graph.Nodes))
+		assert.Equal(t, "dept", nodes["dept:100"].Type)
+		assert.Equal(t, 4, nodes["dept:100"].Data["totalHeadCount"])
+		assert.NotContains(t, nodes["dept:100"].Data, "leader")
+		assert.Equal(t, "Manager", nodes["post:102:2"].Label)
+		assert.Equal(t, "Bob", nodes["user:3"].Label)
+
+		edges := make(map[dto.OrgChartGraphEdge]bool)
+		for _, edge := range graph.Edges {
+			edges[edge] = true
+		}
+		assert.True(t, edges[dto.OrgChartGraphEdge{Source: "dept:100", Target: "dept:102", Type: "subDept"}])
+		assert.True(t, edges[dto.OrgChartGraphEdge{Source: "dept:102", Target: "post:102:3", Type: "post"}])
+		// Superior posts are found in the same department, then up the tree
+		assert.True(t, edges[dto.OrgChartGraphEdge{Source: "post:102:3", Target: "post:102:2", Type: "reportsTo"}])
+		assert.True(t, edges[dto.OrgChartGraphEdge{Source: "post:102:2", Target: "post:100:1", Type: "reportsTo"}])
+		// A user holding two posts is a single node linked to both
+		assert.True(t, edges[dto.OrgChartGraphEdge{Source: "post:102:2", Target: "user:3", Type: "holder"}])
+		assert.True(t, edges[dto.OrgChartGraphEdge{Source: "post:102:3", Target: "user:3", Type: "holder"}])
+		assert.True(t, edges[dto.OrgChartGraphEdge{Source: "dept:101", Target: "user:2", Type: "member"}])
+	})
+}
@@ -0,0 +1,568 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/policy"
+	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+)
+
+// Policy decisions
+const (
+	PolicyDecisionAllow         = "allow"
+	PolicyDecisionDeny          = "deny"
+	PolicyDecisionNotApplicable = "not_applicable"
+)
+
+// PolicyServiceInterface defines operations for attribute-based access policies
+type PolicyServiceInterface interface {
+	CreatePolicy(param dto.SavePolicy) error
+	UpdatePolicy(param dto.SavePolicy) error
+	DeletePolicy(policyIds []int) error
+	GetPolicyList(param dto.PolicyListRequest, isPaging bool) ([]dto.PolicyListResponse, int)
+	GetPolicyByPolicyId(policyId int) dto.PolicyDetailResponse
+	GetPolicyByPolicyName(policyName string) dto.PolicyDetailResponse
+	Evaluate(action string, input dto.PolicyContext) dto.PolicyDecisionResponse
+	Enforce(action string, input dto.PolicyContext) error
+	LoadResources(action string, request map[string]interface{}) ([]map[string]interface{}, error)
+}
+
+// PolicyService implements the policy management interface
+//
+// Policies are evaluated per action (a permission string such as "system:user:edit",
+// a prefix pattern such as "system:user:*", or "*"). A matching deny policy always
+// wins; when allow policies exist for an action at least one of them must match.
+// Actions without any enabled policy are not restricted here and fall back to RBAC.
+type PolicyService struct {
+	programs sync.Map
+}
+
+// Ensure PolicyService implements PolicyServiceInterface
+var _ PolicyServiceInterface = (*PolicyService)(nil)
+
+// NewPolicyService creates a new PolicyService
+func NewPolicyService() *PolicyService {
+	return &PolicyService{}
+}
+
+// CreatePolicy creates a new policy
+func (s *PolicyService) CreatePolicy(param dto.SavePolicy) error {
+	return s.CreatePolicyWithErr(param)
+}
+
+// CreatePolicyWithErr creates a new policy with proper error handling
+func (s *PolicyService) CreatePolicyWithErr(param dto.SavePolicy) error {
+	if err := checkPolicy(param); err != nil {
+		return err
+	}
+
+	err := dal.Gorm.Model(model.SysPolicy{}).Create(&model.SysPolicy{
+		PolicyName: param.PolicyName,
+		Action:     param.Action,
+		Effect:     param.Effect,
+		Condition:  param.Condition,
+		Status:     param.Status,
+		CreateBy:   param.CreateBy,
+		Remark:     param.Remark,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to create policy")
+	}
+
+	return nil
+}
+
+// UpdatePolicy updates an existing policy
+func (s *PolicyService) UpdatePolicy(param dto.SavePolicy) error {
+	return s.UpdatePolicyWithErr(param)
+}
+
+// UpdatePolicyWithErr updates an existing policy with proper error handling
+func (s *PolicyService) UpdatePolicyWithErr(param dto.SavePolicy) error {
+	if param.PolicyId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if err := checkPolicy(param); err != nil {
+		return err
+	}
+
+	err := dal.Gorm.Model(model.SysPolicy{}).Where("policy_id = ?", param.PolicyId).Updates(&model.SysPolicy{
+		PolicyName: param.PolicyName,
+		Action:     param.Action,
+		Effect:     param.Effect,
+		Condition:  param.Condition,
+		Status:     param.Status,
+		UpdateBy:   param.UpdateBy,
+		Remark:     param.Remark,
+	}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to update policy")
+	}
+
+	return nil
+}
+
+// DeletePolicy deletes policies by IDs
+func (s *PolicyService) DeletePolicy(policyIds []int) error {
+	return s.DeletePolicyWithErr(policyIds)
+}
+
+// DeletePolicyWithErr deletes policies by IDs with proper error handling
+func (s *PolicyService) DeletePolicyWithErr(policyIds []int) error {
+	if len(policyIds) == 0 {
+		return xerrors.ErrParam
+	}
+
+	err := dal.Gorm.Model(model.SysPolicy{}).Where("policy_id IN ?", policyIds).Delete(&model.SysPolicy{}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to delete policies")
+	}
+
+	return nil
+}
+
+// GetPolicyList retrieves a list of policies based on search parameters
+func (s *PolicyService) GetPolicyList(param dto.PolicyListRequest, isPaging bool) ([]dto.PolicyListResponse, int) {
+	policies, count, _ := s.GetPolicyListWithErr(param, isPaging)
+	return policies, count
+}
+
+// GetPolicyListWithErr retrieves a list of policies with proper error handling
+func (s *PolicyService) GetPolicyListWithErr(param dto.PolicyListRequest, isPaging bool) ([]dto.PolicyListResponse, int, error) {
+	var count int64
+	policies := make([]dto.PolicyListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysPolicy{}).Order("policy_id")
+
+	if param.PolicyName != "" {
+		query = query.Where("policy_name LIKE ?", "%"+param.PolicyName+"%")
+	}
+
+	if param.Action != "" {
+		query = query.Where("action LIKE ?", "%"+param.Action+"%")
+	}
+
+	if param.Effect != "" {
+		query = query.Where("effect = ?", param.Effect)
+	}
+
+	if param.Status != "" {
+		query = query.Where("status = ?", param.Status)
+	}
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count policies")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&policies).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve policies")
+	}
+
+	return policies, int(count), nil
+}
+
+// GetPolicyByPolicyId retrieves policy details by ID
+func (s *PolicyService) GetPolicyByPolicyId(policyId int) dto.PolicyDetailResponse {
+	policy, _ := s.GetPolicyByPolicyIdWithErr(policyId)
+	return policy
+}
+
+// GetPolicyByPolicyIdWithErr retrieves policy details by ID with proper error handling
+func (s *PolicyService) GetPolicyByPolicyIdWithErr(policyId int) (dto.PolicyDetailResponse, error) {
+	var policy dto.PolicyDetailResponse
+
+	if policyId <= 0 {
+		return policy, xerrors.ErrParam
+	}
+
+	err := dal.Gorm.Model(model.SysPolicy{}).Where("policy_id = ?", policyId).Last(&policy).Error
+	if err != nil {
+		return policy, errors.Wrap(err, "failed to retrieve policy by ID")
+	}
+
+	return policy, nil
+}
+
+// GetPolicyByPolicyName retrieves policy details by name
+func (s *PolicyService) GetPolicyByPolicyName(policyName string) dto.PolicyDetailResponse {
+	policy, _ := s.GetPolicyByPolicyNameWithErr(policyName)
+	return policy
+}
+
+// GetPolicyByPolicyNameWithErr retrieves policy details by name with proper error handling
+func (s *PolicyService) GetPolicyByPolicyNameWithErr(policyName string) (dto.PolicyDetailResponse, error) {
+	var policy dto.PolicyDetailResponse
+
+	if policyName == "" {
+		return policy, xerrors.ErrPolicyNameEmpty
+	}
+
+	err := dal.Gorm.Model(model.SysPolicy{}).Where("policy_name = ?", policyName).Last(&policy).Error
+	if err != nil {
+		return policy, errors.Wrap(err, "failed to retrieve policy by name")
+	}
+
+	return policy, nil
+}
+
+// GetPoliciesByAction retrieves enabled policies that apply to an action
+func (s *PolicyService) GetPoliciesByAction(action string) ([]dto.PolicyDetailResponse, error) {
+	policies := make([]dto.PolicyDetailResponse, 0)
+
+	if err := dal.Gorm.Model(model.SysPolicy{}).
+		Where("status = ?", constant.NORMAL_STATUS).
+		Order("policy_id").
+		Find(&policies).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve policies")
+	}
+
+	return utils.Filter(policies, func(policy dto.PolicyDetailResponse) bool {
+		return matchPolicyAction(policy.Action, action)
+	}), nil
+}
+
+// Evaluate evaluates all enabled policies for an action and explains the decision
+func (s *PolicyService) Evaluate(action string, input dto.PolicyContext) dto.PolicyDecisionResponse {
+	policies, err := s.GetPoliciesByAction(action)
+	if err != nil {
+		return dto.PolicyDecisionResponse{
+			Action:   action,
+			Allowed:  false,
+			Decision: PolicyDecisionDeny,
+			Reason:   err.Error(),
+			Policies: make([]dto.PolicyEvaluationResponse, 0),
+		}
+	}
+
+	return s.EvaluatePolicies(action, policies, input)
+}
+
+// EvaluatePolicies evaluates the given policies against the input
+//
+// Conditions that fail to evaluate are treated as matching for deny policies
+// and as not matching for allow policies, so errors never widen access. A
+// subject that fails to load denies the action.
+func (s *PolicyService) EvaluatePolicies(action string, policies []dto.PolicyDetailResponse, input dto.PolicyContext) dto.PolicyDecisionResponse {
+	vars, err := s.BuildPolicyInput(input)
+	if err != nil {
+		return dto.PolicyDecisionResponse{
+			Action:   action,
+			Allowed:  false,
+			Decision: PolicyDecisionDeny,
+			Reason:   err.Error(),
+			Policies: make([]dto.PolicyEvaluationResponse, 0),
+		}
+	}
+
+	decision := dto.PolicyDecisionResponse{
+		Action:   action,
+		Input:    vars,
+		Policies: make([]dto.PolicyEvaluationResponse, 0, len(policies)),
+	}
+
+	var allowPolicies, allowMatched, denyMatched []string
+	for _, item := range policies {
+		evaluation := dto.PolicyEvaluationResponse{
+			PolicyId:   item.PolicyId,
+			PolicyName: item.PolicyName,
+			Effect:     item.Effect,
+			Condition:  item.Condition,
+			Steps:      make([]policy.Step, 0),
+		}
+
+		program, err := s.compile(item.Condition)
+		if err == nil {
+			var steps []policy.Step
+			evaluation.Matched, steps, err = program.Explain(vars)
+			if steps != nil {
+				evaluation.Steps = steps
+			}
+		}
+		if err != nil {
+			evaluation.Error = err.Error()
+			evaluation.Matched = item.Effect == constant.POLICY_EFFECT_DENY
+		}
+
+		if item.Effect == constant.POLICY_EFFECT_DENY {
+			if evaluation.Matched {
+				denyMatched = append(denyMatched, item.PolicyName)
+			}
+		} else {
+			allowPolicies = append(allowPolicies, item.PolicyName)
+			if evaluation.Matched {
+				allowMatched = append(allowMatched, item.PolicyName)
+			}
+		}
+
+		decision.Policies = append(decision.Policies, evaluation)
+	}
+
+	switch {
+	case len(denyMatched) > 0:
+		decision.Decision = PolicyDecisionDeny
+		decision.Reason = "denied by policy " + strings.Join(denyMatched, ", ")
+	case len(allowMatched) > 0:
+		decision.Allowed = true
+		decision.Decision = PolicyDecisionAllow
+		decision.Reason = "allowed by policy " + strings.Join(allowMatched, ", ")
+	case len(allowPolicies) > 0:
+		decision.Decision = PolicyDecisionDeny
+		decision.Reason = "no allow policy matched: " + strings.Join(allowPolicies, ", ")
+	default:
+		decision.Allowed = true
+		decision.Decision = PolicyDecisionNotApplicable
+		decision.Reason = "no policy applies to " + action
+	}
+
+	return decision
+}
+
+// Enforce evaluates policies for an action and returns an error when access is denied
+func (s *PolicyService) Enforce(action string, input dto.PolicyContext) error {
+	decision := s.Evaluate(action, input)
+	if !decision.Allowed {
+		return errors.Wrap(xerrors.ErrPolicyDenied, decision.Reason)
+	}
+
+	return nil
+}
+
+// policyResourceLoader loads the entities of the actions starting with prefix,
+// identified by the request keys in idKeys
+type policyResourceLoader struct {
+	prefix string
+	idKeys []string
+	load   func(s *PolicyService, id int) (map[string]interface{}, error)
+}
+
+// policyResourceLoaders are the resource types whose entities policies can see.
+// The user as stored exposes its ID, name, department with its ancestors and role keys.
+var policyResourceLoaders = []policyResourceLoader{
+	{
+		prefix: "system:user:",
+		idKeys: []string{"userId", "userIds"},
+		load:   (*PolicyService).buildSubject,
+	},
+}
+
+// LoadResources loads the entities an action applies to, identified by the IDs of
+// the request, and returns one resource per entity
+//
+// Each resource holds the request attributes overridden by the attributes of the
+// entity as stored, so that a client cannot forge them. Actions without a loader
+// and requests naming no entity, such as creations, get a single empty resource:
+// conditions on the resource then never match allow policies and deny policies
+// failing on it still apply. An entity that fails to load is returned as an error.
+func (s *PolicyService) LoadResources(action string, request map[string]interface{}) ([]map[string]interface{}, error) {
+	empty := []map[string]interface{}{make(map[string]interface{})}
+
+	var loader *policyResourceLoader
+	for i := range policyResourceLoaders {
+		if strings.HasPrefix(action, policyResourceLoaders[i].prefix) {
+			loader = &policyResourceLoaders[i]
+			break
+		}
+	}
+	if loader == nil {
+		return empty, nil
+	}
+
+	ids := make([]int, 0)
+	for _, key := range loader.idKeys {
+		for _, id := range policyRequestIds(request[key]) {
+			if !utils.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return empty, nil
+	}
+
+	resources := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		entity, err := loader.load(s, id)
+		if err != nil {
+			return nil, err
+		}
+
+		resource := make(map[string]interface{}, len(request)+len(entity))
+		for key, value := range request {
+			resource[key] = value
+		}
+		for key, value := range entity {
+			resource[key] = value
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// policyRequestIds reads the IDs of a request value: a number, a comma-separated
+// string or a list of either
+func policyRequestIds(value interface{}) []int {
+	ids := make([]int, 0)
+
+	switch value := value.(type) {
+	case float64:
+		if value > 0 {
+			ids = append(ids, int(value))
+		}
+	case string:
+		if parsed, err := utils.StringToIntSlice(value, ","); err == nil {
+			ids = append(ids, utils.Filter(parsed, func(id int) bool { return id > 0 })...)
+		}
+	case []string:
+		for _, item := range value {
+			ids = append(ids, policyRequestIds(item)...)
+		}
+	case []interface{}:
+		for _, item := range value {
+			ids = append(ids, policyRequestIds(item)...)
+		}
+	}
+
+	return ids
+}
+
+// BuildPolicyInput builds the attributes visible to policy expressions
+//
+// The input contains three attributes:
+//   - subject: userId, userName, deptId, deptAncestors, roles (role keys)
+//   - resource: the entity or request payload supplied by the caller
+//   - env: time (15:04), date (2006-01-02), datetime, hour, weekday (0 = Sunday), ip
+func (s *PolicyService) BuildPolicyInput(input dto.PolicyContext) (map[string]interface{}, error) {
+	now := input.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	resource := input.Resource
+	if resource == nil {
+		resource = make(map[string]interface{})
+	}
+
+	subject, err := s.buildSubject(input.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"subject":  subject,
+		"resource": resource,
+		"env": map[string]interface{}{
+			"time":     now.Format("15:04"),
+			"date":     now.Format("2006-01-02"),
+			"datetime": now.Format(time.DateTime),
+			"hour":     now.Hour(),
+			"weekday":  int(now.Weekday()),
+			"ip":       input.Ip,
+		},
+	}, nil
+}
+
+// buildSubject loads the user attributes visible as "subject"
+//
+// A user that cannot be loaded is returned as an error rather than a partial
+// subject, so that policies are never evaluated against missing attributes.
+func (s *PolicyService) buildSubject(userId int) (map[string]interface{}, error) {
+	subject := map[string]interface{}{
+		"userId":        userId,
+		"userName":      "",
+		"deptId":        0,
+		"deptAncestors": []int{},
+		"roles":         []string{},
+	}
+
+	if userId <= 0 {
+		return subject, nil
+	}
+
+	var user struct {
+		UserName  string
+		DeptId    int
+		Ancestors string
+	}
+	if err := dal.Gorm.Model(model.SysUser{}).
+		Select("sys_user.user_name", "sys_user.dept_id", "sys_dept.ancestors").
+		Joins("LEFT JOIN sys_dept ON sys_dept.dept_id = sys_user.dept_id").
+		Where("sys_user.user_id = ?", userId).
+		Take(&user).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to load user %d for policy evaluation", userId)
+	}
+	subject["userName"] = user.UserName
+	subject["deptId"] = user.DeptId
+	if ancestors, err := utils.StringToIntSlice(user.Ancestors, ","); err == nil {
+		subject["deptAncestors"] = ancestors
+	}
+
+	roleKeys, err := (&RoleService{}).GetRoleKeysByUserId(userId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load roles of user %d for policy evaluation", userId)
+	}
+	subject["roles"] = roleKeys
+
+	return subject, nil
+}
+
+// compile compiles a condition, reusing previously compiled programs
+func (s *PolicyService) compile(condition string) (*policy.Program, error) {
+	if program, ok := s.programs.Load(condition); ok {
+		return program.(*policy.Program), nil
+	}
+
+	program, err := policy.Compile(condition)
+	if err != nil {
+		return nil, err
+	}
+
+	s.programs.Store(condition, program)
+	return program, nil
+}
+
+// checkPolicy validates the fields required to save a policy
+func checkPolicy(param dto.SavePolicy) error {
+	switch {
+	case param.PolicyName == "":
+		return xerrors.ErrPolicyNameEmpty
+	case param.Action == "":
+		return xerrors.ErrPolicyActionEmpty
+	case param.Effect != constant.POLICY_EFFECT_ALLOW && param.Effect != constant.POLICY_EFFECT_DENY:
+		return xerrors.ErrPolicyEffectInvalid
+	case param.Condition == "":
+		return xerrors.ErrPolicyConditionEmpty
+	}
+
+	if _, err := policy.Compile(param.Condition); err != nil {
+		return errors.Wrap(xerrors.ErrPolicyConditionInvalid, err.Error())
+	}
+
+	return nil
+}
+
+// matchPolicyAction reports whether a policy action applies to the requested action
+func matchPolicyAction(pattern, action string) bool {
+	if pattern == "*" || pattern == action {
+		return true
+	}
+
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(action, strings.TrimSuffix(pattern, "*"))
+	}
+
+	return false
+}
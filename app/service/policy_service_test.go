@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyService_CreatePolicy(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewPolicyService()
+
+	t.Run("should create policy successfully", func(t *testing.T) {
+		err := s.CreatePolicyWithErr(dto.SavePolicy{
+			PolicyName: "no self role change",
+			Action:     "system:user:edit",
+			Effect:     "deny",
+			Condition:  "resource.userId == subject.userId",
+			Status:     "0",
+			CreateBy:   "admin",
+		})
+		assert.NoError(t, err)
+
+		var result model.SysPolicy
+		dal.Gorm.First(&result, "policy_name = ?", "no self role change")
+		assert.Equal(t, "system:user:edit", result.Action)
+	})
+
+	t.Run("should reject invalid condition", func(t *testing.T) {
+		err := s.CreatePolicyWithErr(dto.SavePolicy{
+			PolicyName: "broken",
+			Action:     "system:user:edit",
+			Effect:     "allow",
+			Condition:  "subject.userId ==",
+		})
+		assert.True(t, errors.Is(err, xerrors.ErrPolicyConditionInvalid))
+	})
+
+	t.Run("should reject invalid effect", func(t *testing.T) {
+		err := s.CreatePolicyWithErr(dto.SavePolicy{
+			PolicyName: "broken",
+			Action:     "system:user:edit",
+			Effect:     "maybe",
+			Condition:  "true",
+		})
+		assert.Equal(t, xerrors.ErrPolicyEffectInvalid, err)
+	})
+}
+
+func TestPolicyService_UpdateAndDeletePolicy(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewPolicyService()
+
+	dal.Gorm.Create(&model.SysPolicy{PolicyId: 1, PolicyName: "old", Action: "system:user:edit", Effect: "allow", Condition: "true", Status: "0"})
+
+	t.Run("should update policy successfully", func(t *testing.T) {
+		err := s.UpdatePolicyWithErr(dto.SavePolicy{
+			PolicyId:   1,
+			PolicyName: "new",
+			Action:     "system:user:*",
+			Effect:     "deny",
+			Condition:  "false",
+		})
+		assert.NoError(t, err)
+
+		policy := s.GetPolicyByPolicyId(1)
+		assert.Equal(t, "new", policy.PolicyName)
+		assert.Equal(t, "system:user:*", policy.Action)
+	})
+
+	t.Run("should delete policy successfully", func(t *testing.T) {
+		assert.NoError(t, s.DeletePolicyWithErr([]int{1}))
+		assert.Equal(t, 0, s.GetPolicyByPolicyId(1).PolicyId)
+	})
+
+	t.Run("should return error when policy ids is empty", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrParam, s.DeletePolicyWithErr([]int{}))
+	})
+}
+
+func TestPolicyService_Evaluate(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewPolicyService()
+
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 105, ParentId: 100, Ancestors: "0,100", DeptName: "Sales"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 105, UserName: "editor"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Editor", RoleKey: "editor", Status: "0"})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 2})
+
+	dal.Gorm.Create(&model.SysPolicy{PolicyName: "own dept in business hours", Action: "system:user:edit", Effect: "allow", Status: "0",
+		Condition: `"editor" in subject.roles && resource.deptId == subject.deptId && env.time >= "09:00" && env.time < "18:00"`})
+	dal.Gorm.Create(&model.SysPolicy{PolicyName: "no self edit", Action: "system:user:*", Effect: "deny", Status: "0",
+		Condition: "resource.userId == subject.userId"})
+	dal.Gorm.Create(&model.SysPolicy{PolicyName: "disabled", Action: "*", Effect: "deny", Status: "1", Condition: "true"})
+
+	businessHours := time.Date(2024, 5, 6, 10, 0, 0, 0, time.Local)
+
+	t.Run("should allow when allow policy matches", func(t *testing.T) {
+		decision := s.Evaluate("system:user:edit", dto.PolicyContext{
+			UserId:   2,
+			Resource: map[string]interface{}{"userId": 3, "deptId": 105},
+			Time:     businessHours,
+		})
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, PolicyDecisionAllow, decision.Decision)
+		assert.Len(t, decision.Policies, 2)
+		assert.NotEmpty(t, decision.Policies[0].Steps)
+	})
+
+	t.Run("should deny outside business hours", func(t *testing.T) {
+		decision := s.Evaluate("system:user:edit", dto.PolicyContext{
+			UserId:   2,
+			Resource: map[string]interface{}{"userId": 3, "deptId": 105},
+			Time:     businessHours.Add(10 * time.Hour),
+		})
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, PolicyDecisionDeny, decision.Decision)
+	})
+
+	t.Run("should deny when deny policy matches", func(t *testing.T) {
+		err := s.Enforce("system:user:edit", dto.PolicyContext{
+			UserId:   2,
+			Resource: map[string]interface{}{"userId": 2, "deptId": 105},
+			Time:     businessHours,
+		})
+		assert.True(t, errors.Is(err, xerrors.ErrPolicyDenied))
+	})
+
+	t.Run("should not restrict actions without policies", func(t *testing.T) {
+		decision := s.Evaluate("system:post:edit", dto.PolicyContext{UserId: 2})
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, PolicyDecisionNotApplicable, decision.Decision)
+	})
+
+	t.Run("should treat failing deny condition as a match", func(t *testing.T) {
+		decision := s.EvaluatePolicies("system:post:edit", []dto.PolicyDetailResponse{
+			{PolicyId: 9, PolicyName: "bad", Effect: "deny", Condition: "subject.userId < \"a\""},
+		}, dto.PolicyContext{UserId: 2})
+		assert.False(t, decision.Allowed)
+		assert.NotEmpty(t, decision.Policies[0].Error)
+	})
+}
+
+func TestPolicyService_LoadResources(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewPolicyService()
+
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 105, ParentId: 100, Ancestors: "0,100", DeptName: "Sales"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 100, UserName: "central"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 105, UserName: "seller"})
+
+	t.Run("should override forged attributes with the stored entity", func(t *testing.T) {
+		resources, err := s.LoadResources("system:user:edit", map[string]interface{}{"userId": float64(3), "deptId": float64(105), "roleIds": []interface{}{float64(1)}})
+		assert.NoError(t, err)
+		if assert.Len(t, resources, 1) {
+			assert.Equal(t, 3, resources[0]["userId"])
+			assert.Equal(t, 100, resources[0]["deptId"])
+			assert.Equal(t, "central", resources[0]["userName"])
+			assert.Equal(t, []interface{}{float64(1)}, resources[0]["roleIds"])
+		}
+	})
+
+	t.Run("should load every user named by the request", func(t *testing.T) {
+		resources, err := s.LoadResources("system:user:remove", map[string]interface{}{"userIds": "3,4,3"})
+		assert.NoError(t, err)
+		if assert.Len(t, resources, 2) {
+			assert.Equal(t, 100, resources[0]["deptId"])
+			assert.Equal(t, 105, resources[1]["deptId"])
+			assert.Equal(t, []int{0, 100}, resources[1]["deptAncestors"])
+		}
+	})
+
+	t.Run("should not expose requests naming no entity or without a loader", func(t *testing.T) {
+		request := map[string]interface{}{"deptId": float64(105)}
+		for _, action := range []string{"system:user:add", "system:post:edit", "system:role:edit"} {
+			resources, err := s.LoadResources(action, request)
+			assert.NoError(t, err)
+			assert.Equal(t, []map[string]interface{}{{}}, resources, action)
+		}
+	})
+
+	t.Run("should fail when a named user cannot be loaded", func(t *testing.T) {
+		_, err := s.LoadResources("system:user:edit", map[string]interface{}{"userId": "99"})
+		assert.Error(t, err)
+	})
+
+	t.Run("should deny when the subject cannot be loaded", func(t *testing.T) {
+		decision := s.EvaluatePolicies("system:user:edit", []dto.PolicyDetailResponse{
+			{PolicyId: 9, PolicyName: "own dept", Effect: "allow", Condition: "subject.deptId == 100"},
+		}, dto.PolicyContext{UserId: 99})
+		assert.False(t, decision.Allowed)
+		assert.Equal(t, PolicyDecisionDeny, decision.Decision)
+	})
+}
+
+func TestMatchPolicyAction(t *testing.T) {
+	assert.True(t, matchPolicyAction("*", "system:user:edit"))
+	assert.True(t, matchPolicyAction("system:user:edit", "system:user:edit"))
+	assert.True(t, matchPolicyAction("system:user:*", "system:user:edit"))
+	assert.False(t, matchPolicyAction("system:role:*", "system:user:edit"))
+	assert.False(t, matchPolicyAction("system:user:add", "system:user:edit"))
+}
@@ -5,21 +5,25 @@ import (
 	"mira/app/dto"
 	"mira/app/model"
 	"mira/common/types/constant"
+	"mira/common/xerrors"
 
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 // PostServiceInterface defines operations for post management
 type PostServiceInterface interface {
-	CreatePost(param dto.SavePost) error
-	DeletePost(postIds []int) error
-	UpdatePost(param dto.SavePost) error
+	CreatePost(param dto.SavePost, roleIds []int) error
+	DeletePost(postIds []int, deleteBy string) error
+	UpdatePost(param dto.SavePost, roleIds []int) error
 	GetPostList(param dto.PostListRequest, isPaging bool) ([]dto.PostListResponse, int)
+	ExportDataset(param dto.PostListRequest) ExportDataset
 	GetPostByPostId(postId int) dto.PostDetailResponse
 	GetPostByPostName(postName string) dto.PostDetailResponse
 	GetPostByPostCode(postCode string) dto.PostDetailResponse
 	GetPostIdsByUserId(userId int) []int
 	GetPostNamesByUserId(userId int) []string
+	GetRoleIdsByPostIds(postIds []int) []int
 }
 
 // PostService implements the post management interface
@@ -33,13 +37,13 @@ func NewPostService() *PostService {
 	return &PostService{}
 }
 
-// CreatePost creates a new post
-func (s *PostService) CreatePost(param dto.SavePost) error {
-	return s.CreatePostWithErr(param)
+// CreatePost creates a new post with its default roles
+func (s *PostService) CreatePost(param dto.SavePost, roleIds []int) error {
+	return s.CreatePostWithErr(param, roleIds)
 }
 
 // CreatePostWithErr creates a new post with proper error handling
-func (s *PostService) CreatePostWithErr(param dto.SavePost) error {
+func (s *PostService) CreatePostWithErr(param dto.SavePost, roleIds []int) error {
 	// Input validation
 	if param.PostCode == "" {
 		return errors.New("post code cannot be empty")
@@ -49,48 +53,90 @@ func (s *PostService) CreatePostWithErr(param dto.SavePost) error {
 		return errors.New("post name cannot be empty")
 	}
 
-	err := dal.Gorm.Model(model.SysPost{}).Create(&model.SysPost{
+	tx := dal.Gorm.Begin()
+
+	if err := checkPostParent(tx, 0, param.ParentId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	post := model.SysPost{
+		ParentId: param.ParentId,
 		PostCode: param.PostCode,
 		PostName: param.PostName,
 		PostSort: param.PostSort,
 		Status:   param.Status,
 		Remark:   param.Remark,
 		CreateBy: param.CreateBy,
-	}).Error
-	if err != nil {
+	}
+
+	if err := tx.Model(model.SysPost{}).Create(&post).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "failed to create post")
 	}
 
+	if err := savePostRoles(tx, post.PostId, roleIds); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	notifySearch(constant.SEARCH_TYPE_POST, post.PostId)
+
+	publishCacheIds(constant.CACHE_EVENT_POST, post.PostId)
+
 	return nil
 }
 
-// DeletePost deletes posts by IDs
-func (s *PostService) DeletePost(postIds []int) error {
-	return s.DeletePostWithErr(postIds)
+// DeletePost deletes posts by IDs, keeping them in the recycle bin
+func (s *PostService) DeletePost(postIds []int, deleteBy string) error {
+	return s.DeletePostWithErr(postIds, deleteBy)
 }
 
 // DeletePostWithErr deletes posts by IDs with proper error handling
-func (s *PostService) DeletePostWithErr(postIds []int) error {
+func (s *PostService) DeletePostWithErr(postIds []int, deleteBy string) error {
 	// Input validation
 	if len(postIds) == 0 {
 		return errors.New("post IDs cannot be empty")
 	}
 
-	err := dal.Gorm.Model(model.SysPost{}).Where("post_id IN ?", postIds).Delete(&model.SysPost{}).Error
-	if err != nil {
+	userIds := postHolderIds(postIds...)
+
+	tx := dal.Gorm.Begin()
+
+	if err := recyclePosts(tx, postIds, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(model.SysPost{}).Where("post_id IN ?", postIds).Delete(&model.SysPost{}).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "failed to delete posts")
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	invalidateUserPermCaches(userIds)
+
+	notifySearch(constant.SEARCH_TYPE_POST, postIds...)
+
+	publishCacheIds(constant.CACHE_EVENT_POST, postIds...)
+
 	return nil
 }
 
-// UpdatePost updates an existing post
-func (s *PostService) UpdatePost(param dto.SavePost) error {
-	return s.UpdatePostWithErr(param)
+// UpdatePost updates an existing post; nil roleIds keeps the current default roles
+func (s *PostService) UpdatePost(param dto.SavePost, roleIds []int) error {
+	return s.UpdatePostWithErr(param, roleIds)
 }
 
 // UpdatePostWithErr updates an existing post with proper error handling
-func (s *PostService) UpdatePostWithErr(param dto.SavePost) error {
+func (s *PostService) UpdatePostWithErr(param dto.SavePost, roleIds []int) error {
 	// Input validation
 	if param.PostId <= 0 {
 		return errors.New("invalid post ID")
@@ -104,18 +150,58 @@ func (s *PostService) UpdatePostWithErr(param dto.SavePost) error {
 		return errors.New("post name cannot be empty")
 	}
 
-	err := dal.Gorm.Model(model.SysPost{}).Where("post_id = ?", param.PostId).Updates(&model.SysPost{
+	tx := dal.Gorm.Begin()
+
+	// Only a new superior post is checked, a post whose superior was deleted stays editable
+	var current model.SysPost
+	tx.Model(model.SysPost{}).Select("parent_id").Where("post_id = ?", param.PostId).Take(&current)
+	if param.ParentId != current.ParentId {
+		if err := checkPostParent(tx, param.PostId, param.ParentId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Model(model.SysPost{}).Where("post_id = ?", param.PostId).Updates(&model.SysPost{
 		PostCode: param.PostCode,
 		PostName: param.PostName,
 		PostSort: param.PostSort,
 		Status:   param.Status,
 		Remark:   param.Remark,
 		UpdateBy: param.UpdateBy,
-	}).Error
-	if err != nil {
+	}).Error; err != nil {
+		tx.Rollback()
 		return errors.Wrap(err, "failed to update post")
 	}
 
+	// Updates skips zero values, a post moved to the top of the hierarchy needs its own update
+	if err := tx.Model(model.SysPost{}).Where("post_id = ?", param.PostId).Update("parent_id", param.ParentId).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to update superior post")
+	}
+
+	if roleIds != nil {
+		if err := tx.Model(model.SysPostRole{}).Where("post_id = ?", param.PostId).Delete(&model.SysPostRole{}).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete post roles")
+		}
+		if err := savePostRoles(tx, param.PostId, roleIds); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	// The status and the default roles of the post both change what its holders hold
+	invalidateUserPermCaches(postHolderIds(param.PostId))
+
+	notifySearch(constant.SEARCH_TYPE_POST, param.PostId)
+
+	publishCacheIds(constant.CACHE_EVENT_POST, param.PostId)
+
 	return nil
 }
 
@@ -130,19 +216,7 @@ func (s *PostService) GetPostListWithErr(param dto.PostListRequest, isPaging boo
 	var count int64
 	posts := make([]dto.PostListResponse, 0)
 
-	query := dal.Gorm.Model(model.SysPost{}).Order("post_sort, post_id")
-
-	if param.PostCode != "" {
-		query = query.Where("post_code LIKE ?", "%"+param.PostCode+"%")
-	}
-
-	if param.PostName != "" {
-		query = query.Where("post_name LIKE ?", "%"+param.PostName+"%")
-	}
-
-	if param.Status != "" {
-		query = query.Where("status = ?", param.Status)
-	}
+	query := postListQuery(param).Order("post_sort, post_id")
 
 	if isPaging {
 		err := query.Count(&count).Error
@@ -160,6 +234,46 @@ func (s *PostService) GetPostListWithErr(param dto.PostListRequest, isPaging boo
 	return posts, int(count), nil
 }
 
+// postListQuery builds the post query with the search conditions applied
+func postListQuery(param dto.PostListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysPost{})
+
+	if param.PostCode != "" {
+		query = query.Where("post_code LIKE ?", "%"+param.PostCode+"%")
+	}
+
+	if param.PostName != "" {
+		query = query.Where("post_name LIKE ?", "%"+param.PostName+"%")
+	}
+
+	if param.Status != "" {
+		query = query.Where("status = ?", param.Status)
+	}
+
+	return query
+}
+
+// ExportDataset describes the export of the posts matching the search conditions
+func (s *PostService) ExportDataset(param dto.PostListRequest) ExportDataset {
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_POST,
+		Query: func() *gorm.DB {
+			return postListQuery(param)
+		},
+		KeyField:  "post_id",
+		KeyColumn: "post_id",
+		Columns: []ExportColumn{
+			{Key: "postId", Field: "post_id", Header: "Post ID", HeaderZh: "岗位序号"},
+			{Key: "postCode", Field: "post_code", Header: "Post Code", HeaderZh: "岗位编码"},
+			{Key: "postName", Field: "post_name", Header: "Post Name", HeaderZh: "岗位名称"},
+			{Key: "postSort", Field: "post_sort", Header: "Post Sort", HeaderZh: "岗位排序"},
+			{Key: "status", Field: "status", Header: "Status", HeaderZh: "状态", DictType: "sys_normal_disable", Labels: map[string]string{
+				"0": "Normal", "1": "Disabled",
+			}},
+		},
+	}
+}
+
 // GetPostByPostId retrieves post details by ID
 func (s *PostService) GetPostByPostId(postId int) dto.PostDetailResponse {
 	post, _ := s.GetPostByPostIdWithErr(postId)
@@ -179,6 +293,11 @@ func (s *PostService) GetPostByPostIdWithErr(postId int) (dto.PostDetailResponse
 		return post, errors.Wrap(err, "failed to retrieve post by ID")
 	}
 
+	post.RoleIds = make([]int, 0)
+	if err := dal.Gorm.Model(model.SysPostRole{}).Where("post_id = ?", postId).Pluck("role_id", &post.RoleIds).Error; err != nil {
+		return post, errors.Wrap(err, "failed to retrieve post roles")
+	}
+
 	return post, nil
 }
 
@@ -275,3 +394,80 @@ func (s *PostService) GetPostNamesByUserIdWithErr(userId int) ([]string, error)
 
 	return postNames, nil
 }
+
+// GetRoleIdsByPostIds retrieves the default roles of posts
+func (s *PostService) GetRoleIdsByPostIds(postIds []int) []int {
+	roleIds, _ := s.GetRoleIdsByPostIdsWithErr(postIds)
+	return roleIds
+}
+
+// GetRoleIdsByPostIdsWithErr retrieves the default roles of posts with proper error handling
+func (s *PostService) GetRoleIdsByPostIdsWithErr(postIds []int) ([]int, error) {
+	roleIds := make([]int, 0)
+
+	if len(postIds) == 0 {
+		return roleIds, nil
+	}
+
+	err := dal.Gorm.Model(model.SysPostRole{}).Where("post_id IN ?", postIds).Distinct().Pluck("role_id", &roleIds).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve post roles")
+	}
+
+	return roleIds, nil
+}
+
+// savePostRoles assigns default roles to a post
+func savePostRoles(tx *gorm.DB, postId int, roleIds []int) error {
+	for _, roleId := range roleIds {
+		if err := tx.Create(&model.SysPostRole{PostId: postId, RoleId: roleId}).Error; err != nil {
+			return errors.Wrapf(err, "failed to assign role ID %d to post ID %d", roleId, postId)
+		}
+	}
+
+	return nil
+}
+
+// postHolderIds returns the users holding any of the posts
+func postHolderIds(postIds ...int) []int {
+	userIds := make([]int, 0)
+	dal.Gorm.Model(model.SysUserPost{}).Where("post_id IN ?", postIds).Distinct().Pluck("user_id", &userIds)
+	return userIds
+}
+
+// checkPostParent checks that a post may report to the superior post: the superior post must
+// exist and must not be the post itself or one of its subordinates. A postId of 0 checks a new post.
+func checkPostParent(tx *gorm.DB, postId, parentId int) error {
+	if parentId == 0 {
+		return nil
+	}
+
+	if parentId == postId {
+		return xerrors.ErrPostParentSelf
+	}
+
+	posts := make([]model.SysPost, 0)
+	if err := tx.Model(model.SysPost{}).Select("post_id", "parent_id").Find(&posts).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve posts")
+	}
+
+	parents := make(map[int]int, len(posts))
+	for _, post := range posts {
+		parents[post.PostId] = post.ParentId
+	}
+
+	if _, ok := parents[parentId]; !ok {
+		return xerrors.ErrPostParentNotFound
+	}
+
+	// Walk up from the superior post, a chain leading back to the post would close a cycle
+	visited := make(map[int]bool)
+	for id := parentId; id != 0 && !visited[id]; id = parents[id] {
+		if id == postId {
+			return xerrors.ErrPostParentCycle
+		}
+		visited[id] = true
+	}
+
+	return nil
+}
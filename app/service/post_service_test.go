@@ -6,6 +6,7 @@ import (
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/xerrors"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,7 +28,7 @@ func TestPostService_CreatePost(t *testing.T) {
 		}
 
 		// Execute
-		err := s.CreatePostWithErr(post)
+		err := s.CreatePostWithErr(post, nil)
 		assert.NoError(t, err)
 
 		// Verify
@@ -43,7 +44,7 @@ func TestPostService_CreatePost(t *testing.T) {
 		}
 
 		// Execute
-		err := s.CreatePostWithErr(post)
+		err := s.CreatePostWithErr(post, nil)
 		assert.Error(t, err)
 	})
 
@@ -54,7 +55,7 @@ func TestPostService_CreatePost(t *testing.T) {
 		}
 
 		// Execute
-		err := s.CreatePostWithErr(post)
+		err := s.CreatePostWithErr(post, nil)
 		assert.Error(t, err)
 	})
 }
@@ -75,7 +76,7 @@ func TestPostService_UpdatePost(t *testing.T) {
 		}
 
 		// Execute
-		err := s.UpdatePostWithErr(update)
+		err := s.UpdatePostWithErr(update, nil)
 		assert.NoError(t, err)
 
 		// Verify
@@ -97,7 +98,7 @@ func TestPostService_DeletePost(t *testing.T) {
 		dal.Gorm.Create(&post)
 
 		// Execute
-		err := s.DeletePostWithErr([]int{1})
+		err := s.DeletePostWithErr([]int{1}, "admin")
 		assert.NoError(t, err)
 
 		// Verify
@@ -222,3 +223,109 @@ func TestPostService_GetPostNamesByUserId(t *testing.T) {
 		assert.ElementsMatch(t, []string{"Post 1", "Post 2"}, postNames)
 	})
 }
+
+func seedPostHierarchy() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 100, UserName: "alice", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Viewer", RoleKey: "viewer", Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 3, RoleName: "Editor", RoleKey: "editor", Status: "0"})
+
+	dal.Gorm.Create(&model.SysMenu{MenuId: 1, MenuName: "User", MenuType: "C", Perms: "system:user:list", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 2, MenuName: "Edit User", MenuType: "F", Perms: "system:user:edit", Status: "0"})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 3, MenuId: 2})
+
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "ceo", PostName: "CEO", Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 2, ParentId: 1, PostCode: "se", PostName: "Manager", Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 3, ParentId: 2, PostCode: "user", PostName: "Staff", Status: "0"})
+}
+
+func TestPostService_Hierarchy(t *testing.T) {
+	setup()
+	defer teardown()
+	seedPostHierarchy()
+	s := NewPostService()
+
+	t.Run("should create a post reporting to a superior post", func(t *testing.T) {
+		assert.NoError(t, s.CreatePost(dto.SavePost{ParentId: 2, PostCode: "dev", PostName: "Developer", Status: "0"}, []int{3}))
+
+		post := s.GetPostByPostCode("dev")
+		assert.Equal(t, 2, post.ParentId)
+		assert.Equal(t, []int{3}, s.GetPostByPostId(post.PostId).RoleIds)
+	})
+
+	t.Run("should reject a missing superior post", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrPostParentNotFound, s.CreatePost(dto.SavePost{ParentId: 99, PostCode: "qa", PostName: "QA"}, nil))
+	})
+
+	t.Run("should reject a post reporting to itself or its subordinates", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrPostParentSelf, s.UpdatePost(dto.SavePost{PostId: 1, ParentId: 1, PostCode: "ceo", PostName: "CEO"}, nil))
+		assert.Equal(t, xerrors.ErrPostParentCycle, s.UpdatePost(dto.SavePost{PostId: 1, ParentId: 3, PostCode: "ceo", PostName: "CEO"}, nil))
+	})
+
+	t.Run("should move a post to the top of the hierarchy", func(t *testing.T) {
+		assert.NoError(t, s.UpdatePost(dto.SavePost{PostId: 3, ParentId: 0, PostCode: "user", PostName: "Staff"}, nil))
+		assert.Equal(t, 0, s.GetPostByPostId(3).ParentId)
+	})
+
+	t.Run("should keep a post whose superior post was deleted editable", func(t *testing.T) {
+		postId := s.GetPostByPostCode("dev").PostId
+		assert.NoError(t, s.DeletePost([]int{2}, "admin"))
+
+		assert.NoError(t, s.UpdatePost(dto.SavePost{PostId: postId, ParentId: 2, PostCode: "dev", PostName: "Developers"}, nil))
+		assert.Equal(t, "Developers", s.GetPostByPostId(postId).PostName)
+	})
+}
+
+func TestPostService_PostRoles(t *testing.T) {
+	setup()
+	defer teardown()
+	seedPostHierarchy()
+	s := NewPostService()
+	userService := &UserService{}
+	menuService := &MenuService{}
+
+	assert.NoError(t, s.UpdatePost(dto.SavePost{PostId: 2, ParentId: 1, PostCode: "se", PostName: "Manager", Status: "0"}, []int{3}))
+
+	t.Run("should grant the default roles of an assigned post", func(t *testing.T) {
+		assert.NoError(t, userService.UpdateUser(dto.SaveUser{UserId: 2}, []int{2}, []int{2}))
+
+		assert.True(t, userService.UserHasRoles(2, []string{"editor"}))
+		assert.ElementsMatch(t, []string{"system:user:list", "system:user:edit"}, menuService.GetPermsByUserId(2))
+		assert.Len(t, (&RoleService{}).GetRoleListByUserIdCompat(2), 2)
+
+		// The roles assigned for editing stay the explicit ones
+		roles, err := (&RoleService{}).GetRoleListByUserId(2)
+		assert.NoError(t, err)
+		assert.Len(t, roles, 1)
+	})
+
+	t.Run("should not grant the default roles of a disabled post", func(t *testing.T) {
+		assert.NoError(t, s.UpdatePost(dto.SavePost{PostId: 2, ParentId: 1, PostCode: "se", PostName: "Manager", Status: "1"}, nil))
+		assert.False(t, userService.UserHasRoles(2, []string{"editor"}))
+
+		assert.NoError(t, s.UpdatePost(dto.SavePost{PostId: 2, ParentId: 1, PostCode: "se", PostName: "Manager", Status: "0"}, nil))
+		assert.True(t, userService.UserHasRoles(2, []string{"editor"}))
+	})
+
+	t.Run("should revoke the default roles when the post is removed", func(t *testing.T) {
+		assert.NoError(t, userService.UpdateUser(dto.SaveUser{UserId: 2}, nil, []int{}))
+
+		assert.False(t, userService.UserHasRoles(2, []string{"editor"}))
+		assert.True(t, userService.UserHasRoles(2, []string{"viewer"}))
+	})
+
+	t.Run("should keep a default role granted explicitly", func(t *testing.T) {
+		assert.NoError(t, userService.UpdateUser(dto.SaveUser{UserId: 2}, []int{2, 3}, []int{2}))
+		assert.NoError(t, userService.UpdateUser(dto.SaveUser{UserId: 2}, nil, []int{}))
+
+		assert.True(t, userService.UserHasRoles(2, []string{"editor"}))
+	})
+
+	t.Run("should list the default roles of posts", func(t *testing.T) {
+		assert.Equal(t, []int{3}, s.GetRoleIdsByPostIds([]int{1, 2}))
+		assert.Empty(t, s.GetRoleIdsByPostIds(nil))
+	})
+}
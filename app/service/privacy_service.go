@@ -0,0 +1,584 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"mira/anima/cipher"
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+	"mira/config"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// privacyOperLogColumns are the operation log columns of a personal data export,
+// the return values are left out as they hold the data of other users
+const privacyOperLogColumns = "operId,title,businessType,method,requestMethod,operName,deptName,operUrl,operIp,operLocation,operParam,status,errorMsg,operTime,costTime"
+
+// privacyNameColumn is a column naming users by account, renamed to the pseudonym of an erased user
+type privacyNameColumn struct {
+	table  string
+	column string
+}
+
+// privacyNameColumns are the columns referring to users by account outside of the logs
+var privacyNameColumns = []privacyNameColumn{
+	{"sys_user", "create_by"}, {"sys_user", "update_by"},
+	{"sys_dept", "create_by"}, {"sys_dept", "update_by"},
+	{"sys_role", "create_by"}, {"sys_role", "update_by"},
+	{"sys_post", "create_by"}, {"sys_post", "update_by"},
+	{"sys_menu", "create_by"}, {"sys_menu", "update_by"},
+	{"sys_config", "create_by"}, {"sys_config", "update_by"},
+	{"sys_dict_type", "create_by"}, {"sys_dict_type", "update_by"},
+	{"sys_dict_data", "create_by"}, {"sys_dict_data", "update_by"},
+	{"sys_policy", "create_by"}, {"sys_policy", "update_by"},
+	{"sys_dept_delegation", "create_by"}, {"sys_dept_delegation", "update_by"},
+	{"sys_user_group", "create_by"}, {"sys_user_group", "update_by"},
+	{"sys_user_attr", "create_by"}, {"sys_user_attr", "update_by"},
+	{"sys_onboard_template", "create_by"}, {"sys_onboard_template", "update_by"},
+	{"sys_approval", "requester_name"}, {"sys_approval", "reviewer_name"},
+	{"sys_export_job", "create_by"},
+	{"sys_import_job", "create_by"},
+	{"sys_recycle", "delete_by"},
+	{"sys_user_offboard", "create_by"},
+	{"sys_privacy_request", "create_by"},
+	{"sys_config_history", "create_by"},
+}
+
+// PrivacyServiceInterface defines operations for personal data requests
+type PrivacyServiceInterface interface {
+	ExportUserData(w io.Writer, param dto.PrivacyExportRequest, operName string) (dto.PrivacyExportReport, error)
+	EraseUserData(param dto.PrivacyEraseRequest, operName string) (dto.PrivacyEraseReport, error)
+	GetPrivacyRequestList(param dto.PrivacyRequestListRequest) ([]dto.PrivacyRequestListResponse, int, error)
+	GetPrivacyRequest(requestId int) (dto.PrivacyRequestDetailResponse, error)
+}
+
+// PrivacyService implements personal data export and erasure
+//
+// An export bundles the profile, roles, posts, login history, operation logs and avatar
+// of a user into a ZIP archive. An erasure scrubs the personal data of a disabled user:
+// the account is renamed to a pseudonym everywhere it is referred to, and the logs keep
+// their rows under the pseudonym with their IP addresses pseudonymised, so that audit
+// counts and the links between records survive. Every request keeps its own audit trail.
+type PrivacyService struct{}
+
+// Ensure PrivacyService implements PrivacyServiceInterface
+var _ PrivacyServiceInterface = (*PrivacyService)(nil)
+
+// NewPrivacyService creates a new PrivacyService
+func NewPrivacyService() *PrivacyService {
+	return &PrivacyService{}
+}
+
+// ExportUserData writes the personal data of a user to w as a ZIP archive
+//
+// The archive holds manifest.json, profile.json, roles.json, posts.json, login_history.csv,
+// oper_logs.csv and the uploaded avatar, if any.
+func (s *PrivacyService) ExportUserData(w io.Writer, param dto.PrivacyExportRequest, operName string) (dto.PrivacyExportReport, error) {
+	report := dto.PrivacyExportReport{UserId: param.UserId}
+
+	var user model.SysUser
+	dal.Gorm.Unscoped().Model(model.SysUser{}).Where("user_id = ?", param.UserId).Limit(1).Find(&user)
+	if user.UserId <= 0 {
+		return report, xerrors.ErrPrivacyUserNotFound
+	}
+
+	trail, err := startPrivacyRequest(user.UserId, constant.PRIVACY_REQUEST_EXPORT, param.Reason, operName)
+	if err != nil {
+		return report, err
+	}
+	report.RequestId = trail.requestId
+
+	if err = writePrivacyExport(w, user, param.Lang, trail, &report); err != nil {
+		trail.fail(err)
+		return report, err
+	}
+
+	return report, trail.finish(report)
+}
+
+// writePrivacyExport writes the export archive of a user, recording every file in the trail
+func writePrivacyExport(w io.Writer, user model.SysUser, lang string, trail *privacyTrail, report *dto.PrivacyExportReport) error {
+	archive := zip.NewWriter(w)
+
+	var profile dto.PrivacyProfileResponse
+	if err := dal.Gorm.Unscoped().Model(model.SysUser{}).
+		Select("sys_user.*", "sys_dept.dept_name").
+		Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
+		Where("sys_user.user_id = ?", user.UserId).
+		Take(&profile).Error; err != nil {
+		return errors.Wrap(err, "failed to read user profile")
+	}
+	attrValues, err := userAttrValueMap([]int{user.UserId})
+	if err != nil {
+		return err
+	}
+	profile.Attributes = attrValues[user.UserId]
+	if profile.Attributes == nil {
+		profile.Attributes = make(map[string]string)
+	}
+
+	roles := make([]dto.RoleListResponse, 0)
+	if err = dal.Gorm.Model(model.SysRole{}).Select("sys_role.*").
+		Joins("JOIN sys_user_role ON sys_role.role_id = sys_user_role.role_id").
+		Where("sys_user_role.user_id = ?", user.UserId).
+		Order("sys_role.role_id").
+		Find(&roles).Error; err != nil {
+		return errors.Wrap(err, "failed to read user roles")
+	}
+	report.Roles = len(roles)
+
+	posts := make([]dto.PostListResponse, 0)
+	if err = dal.Gorm.Model(model.SysPost{}).Select("sys_post.*").
+		Joins("JOIN sys_user_post ON sys_post.post_id = sys_user_post.post_id").
+		Where("sys_user_post.user_id = ?", user.UserId).
+		Order("sys_post.post_id").
+		Find(&posts).Error; err != nil {
+		return errors.Wrap(err, "failed to read user posts")
+	}
+	report.Posts = len(posts)
+
+	for _, file := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"profile.json", profile},
+		{"roles.json", roles},
+		{"posts.json", posts},
+	} {
+		if err = writePrivacyJson(archive, file.name, file.value); err != nil {
+			return err
+		}
+	}
+	trail.log("profile", fmt.Sprintf("exported the profile, %d roles and %d posts", report.Roles, report.Posts))
+
+	loginDataset := (&LogininforService{}).ExportDataset(dto.LogininforListRequest{})
+	loginDataset.Query = func() *gorm.DB {
+		return dal.Gorm.Model(model.SysLogininfor{}).Where("user_name = ?", user.UserName)
+	}
+	if report.LoginLogs, err = writePrivacyCsv(archive, "login_history.csv", loginDataset, "", lang); err != nil {
+		return err
+	}
+	trail.log("login history", fmt.Sprintf("exported %d login records", report.LoginLogs))
+
+	operDataset := (&OperLogService{}).ExportDataset(dto.OperLogListRequest{})
+	operDataset.Query = func() *gorm.DB {
+		return dal.Gorm.Model(model.SysOperLog{}).Where("oper_name = ?", user.UserName)
+	}
+	if report.OperLogs, err = writePrivacyCsv(archive, "oper_logs.csv", operDataset, privacyOperLogColumns, lang); err != nil {
+		return err
+	}
+	trail.log("operation logs", fmt.Sprintf("exported %d operation logs", report.OperLogs))
+
+	if path, ok := privacyAvatarPath(user.Avatar); ok {
+		content, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "failed to read avatar")
+		}
+		if err == nil {
+			file, err := archive.Create("avatar" + filepath.Ext(path))
+			if err != nil {
+				return errors.Wrap(err, "failed to write export archive")
+			}
+			if _, err = file.Write(content); err != nil {
+				return errors.Wrap(err, "failed to write export archive")
+			}
+			report.Avatar = true
+			trail.log("avatar", "exported the avatar")
+		}
+	}
+
+	if err = writePrivacyJson(archive, "manifest.json", report); err != nil {
+		return err
+	}
+
+	if err = archive.Close(); err != nil {
+		return errors.Wrap(err, "failed to write export archive")
+	}
+	return nil
+}
+
+// writePrivacyJson writes a value to the archive as indented JSON
+func writePrivacyJson(archive *zip.Writer, name string, value interface{}) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode %s", name)
+	}
+
+	file, err := archive.Create(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to write export archive")
+	}
+	if _, err = file.Write(content); err != nil {
+		return errors.Wrap(err, "failed to write export archive")
+	}
+	return nil
+}
+
+// writePrivacyCsv writes the rows of an export dataset to the archive as CSV, returning how many were written
+func writePrivacyCsv(archive *zip.Writer, name string, dataset ExportDataset, columns, lang string) (int, error) {
+	file, err := archive.Create(name)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to write export archive")
+	}
+
+	return writeExport(file, dataset, dto.ExportRequest{Format: constant.EXPORT_FORMAT_CSV, Columns: columns, Lang: lang}, nil)
+}
+
+// EraseUserData scrubs the personal data of a disabled user
+//
+// The account is renamed to a pseudonym and its name, contact details, password, avatar,
+// custom attributes and recycle bin snapshots are removed. The operation and login logs of
+// the user keep their rows under the pseudonym, with the IP addresses replaced by salted
+// hashes that stay equal for equal addresses and the locations cleared. Every column naming
+// the account, such as create_by, is renamed to the pseudonym as well.
+func (s *PrivacyService) EraseUserData(param dto.PrivacyEraseRequest, operName string) (dto.PrivacyEraseReport, error) {
+	report := dto.PrivacyEraseReport{UserId: param.UserId}
+
+	if param.UserId == 1 {
+		return report, xerrors.ErrPrivacySuperAdminErase
+	}
+
+	var user model.SysUser
+	dal.Gorm.Unscoped().Model(model.SysUser{}).Where("user_id = ?", param.UserId).Limit(1).Find(&user)
+	if user.UserId <= 0 {
+		return report, xerrors.ErrPrivacyUserNotFound
+	}
+
+	pseudonym := constant.PRIVACY_ERASED_USER_PREFIX + strconv.Itoa(user.UserId)
+	if user.UserName == pseudonym {
+		return report, xerrors.ErrPrivacyUserErased
+	}
+	if user.Status == constant.NORMAL_STATUS {
+		return report, xerrors.ErrPrivacyEraseActiveUser
+	}
+	report.Pseudonym = pseudonym
+
+	trail, err := startPrivacyRequest(user.UserId, constant.PRIVACY_REQUEST_ERASE, param.Reason, operName)
+	if err != nil {
+		return report, err
+	}
+	report.RequestId = trail.requestId
+
+	salt := make([]byte, 16)
+	if _, err = rand.Read(salt); err != nil {
+		trail.fail(err)
+		return report, errors.Wrap(err, "failed to generate pseudonym salt")
+	}
+
+	if err = erasePrivacyData(user, pseudonym, salt, operName, &report); err != nil {
+		trail.fail(err)
+		return report, err
+	}
+
+	trail.log("profile", fmt.Sprintf("renamed the account to %s, cleared its contact details and password and removed %d custom attribute values", pseudonym, report.RemovedAttributes))
+	trail.log("operation logs", fmt.Sprintf("pseudonymised %d operation logs", report.PseudonymisedOperLogs))
+	trail.log("login history", fmt.Sprintf("pseudonymised %d login records", report.PseudonymisedLogins))
+	trail.log("references", fmt.Sprintf("renamed %d references to the account and removed %d recycle bin snapshots", report.RenamedReferences, report.RemovedRecycleEntries))
+
+	// The avatar goes once the account no longer refers to it; a file left behind is reported
+	if path, ok := privacyAvatarPath(user.Avatar); ok {
+		if err = os.Remove(path); err == nil {
+			report.RemovedAvatar = true
+			trail.log("avatar", "removed the avatar")
+		} else if !os.IsNotExist(err) {
+			trail.log("avatar", "failed to remove the avatar: "+err.Error())
+		}
+	}
+
+	invalidateUserPermCaches([]int{user.UserId})
+	notifySearch(constant.SEARCH_TYPE_USER, user.UserId)
+	publishCacheIds(constant.CACHE_EVENT_USER, user.UserId)
+
+	return report, trail.finish(report)
+}
+
+// erasePrivacyData scrubs the personal data of a user in one transaction, filling in the report
+func erasePrivacyData(user model.SysUser, pseudonym string, salt []byte, operName string, report *dto.PrivacyEraseReport) error {
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Unscoped().Model(model.SysUser{}).Where("user_id = ?", user.UserId).
+		Select("user_name", "nick_name", "email", "phonenumber", "sex", "avatar", "password", "login_ip", "remark", "update_by").
+		Updates(&model.SysUser{UserName: pseudonym, NickName: pseudonym, Sex: "2", UpdateBy: operName}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to scrub user profile")
+	}
+
+	result := tx.Where("user_id = ?", user.UserId).Delete(&model.SysUserAttrValue{})
+	if result.Error != nil {
+		tx.Rollback()
+		return errors.Wrap(result.Error, "failed to delete custom attribute values")
+	}
+	report.RemovedAttributes = int(result.RowsAffected)
+
+	operLogs, err := pseudonymiseLogs(tx, model.SysOperLog{}, "oper_name", "oper_ip", "oper_location", user.UserName, pseudonym, salt)
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to pseudonymise operation logs")
+	}
+	report.PseudonymisedOperLogs = operLogs
+
+	logins, err := pseudonymiseLogs(tx, model.SysLogininfor{}, "user_name", "ipaddr", "login_location", user.UserName, pseudonym, salt)
+	if err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to pseudonymise login records")
+	}
+	report.PseudonymisedLogins = logins
+
+	for _, name := range privacyNameColumns {
+		result = tx.Table(name.table).Where(name.column+" = ?", user.UserName).Update(name.column, pseudonym)
+		if result.Error != nil {
+			tx.Rollback()
+			return errors.Wrapf(result.Error, "failed to rename %s.%s", name.table, name.column)
+		}
+		report.RenamedReferences += int(result.RowsAffected)
+	}
+
+	renamed, err := renameOffboardReports(tx, user, pseudonym)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	report.RenamedReferences += renamed
+
+	result = tx.Where("entity_type = ? AND entity_id = ?", constant.RECYCLE_ENTITY_USER, user.UserId).Delete(&model.SysRecycle{})
+	if result.Error != nil {
+		tx.Rollback()
+		return errors.Wrap(result.Error, "failed to delete recycle bin snapshots")
+	}
+	report.RemovedRecycleEntries = int(result.RowsAffected)
+
+	if err = tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	return nil
+}
+
+// pseudonymiseLogs renames the user of its log rows to the pseudonym, replacing their IP
+// addresses by salted hashes and clearing their locations, returning how many rows changed
+func pseudonymiseLogs(tx *gorm.DB, logModel interface{}, nameColumn, ipColumn, locationColumn, userName, pseudonym string, salt []byte) (int, error) {
+	ips := make([]string, 0)
+	if err := tx.Model(logModel).Where(nameColumn+" = ?", userName).Distinct().Pluck(ipColumn, &ips).Error; err != nil {
+		return 0, err
+	}
+
+	// Encrypted addresses differ on every row, so they are hashed by their plaintext and the
+	// hashes encrypted in turn
+	var label cipher.Label
+	if named, ok := logModel.(interface{ TableName() string }); ok {
+		label = encryptedColumnLabel(named.TableName(), ipColumn)
+	}
+	for _, ip := range ips {
+		plaintext := ip
+		if label != nil {
+			var err error
+			if plaintext, err = cipher.Decrypt(label.Label(), ip); err != nil {
+				return 0, err
+			}
+		}
+		if plaintext == "" {
+			continue
+		}
+		var hashed interface{} = pseudonymiseIp(plaintext, salt)
+		if label != nil {
+			hashed = cipher.NewLabelled(label.Label(), pseudonymiseIp(plaintext, salt))
+		}
+		if err := tx.Model(logModel).Where(nameColumn+" = ? AND "+ipColumn+" = ?", userName, ip).
+			Update(ipColumn, hashed).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	result := tx.Model(logModel).Where(nameColumn+" = ?", userName).
+		Updates(map[string]interface{}{nameColumn: pseudonym, locationColumn: ""})
+	return int(result.RowsAffected), result.Error
+}
+
+// pseudonymiseIp replaces an IP address by a salted hash, equal for equal addresses under the same salt
+func pseudonymiseIp(ip string, salt []byte) string {
+	sum := sha256.Sum256(append(append([]byte{}, salt...), ip...))
+	return "ip-" + hex.EncodeToString(sum[:6])
+}
+
+// renameOffboardReports renames the user in its offboarding reports and in the reports that
+// transferred data to it, returning how many reports changed
+func renameOffboardReports(tx *gorm.DB, user model.SysUser, pseudonym string) (int, error) {
+	rows := make([]model.SysUserOffboard, 0)
+	if err := tx.Model(model.SysUserOffboard{}).Where("user_id = ? OR transfer_to = ?", user.UserId, user.UserName).Find(&rows).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to read offboarding reports")
+	}
+
+	for _, row := range rows {
+		var report dto.UserOffboardReport
+		if err := json.Unmarshal([]byte(row.Report), &report); err != nil {
+			return 0, errors.Wrapf(err, "failed to decode offboarding report ID %d", row.OffboardId)
+		}
+		if row.UserId == user.UserId {
+			row.UserName, report.UserName = pseudonym, pseudonym
+		}
+		if row.TransferTo == user.UserName {
+			row.TransferTo, report.TransferTo = pseudonym, pseudonym
+		}
+
+		content, err := json.Marshal(report)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to encode offboarding report")
+		}
+		if err = tx.Model(model.SysUserOffboard{}).Where("offboard_id = ?", row.OffboardId).
+			Updates(map[string]interface{}{"user_name": row.UserName, "transfer_to": row.TransferTo, "report": string(content)}).Error; err != nil {
+			return 0, errors.Wrap(err, "failed to rename offboarding report")
+		}
+	}
+
+	return len(rows), nil
+}
+
+// privacyAvatarPath returns the file of an uploaded avatar, which is served under the upload path
+func privacyAvatarPath(avatar string) (string, bool) {
+	if config.Data == nil || config.Data.Ruoyi.UploadPath == "" || avatar == "" || strings.Contains(avatar, "://") {
+		return "", false
+	}
+
+	// Avatar URLs are the upload path, which may be absolute, behind a slash
+	root := filepath.Clean(config.Data.Ruoyi.UploadPath)
+	path := filepath.Clean(strings.TrimPrefix(avatar, "/"))
+	if !strings.HasPrefix(path, root+string(filepath.Separator)) {
+		return "", false
+	}
+	return path, true
+}
+
+// GetPrivacyRequestList retrieves the privacy requests, newest first
+func (s *PrivacyService) GetPrivacyRequestList(param dto.PrivacyRequestListRequest) ([]dto.PrivacyRequestListResponse, int, error) {
+	requests := make([]dto.PrivacyRequestListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysPrivacyRequest{}).Order("request_id DESC")
+	if param.UserId != 0 {
+		query = query.Where("user_id = ?", param.UserId)
+	}
+	if param.RequestType != "" {
+		query = query.Where("request_type = ?", param.RequestType)
+	}
+	if param.Status != "" {
+		query = query.Where("status = ?", param.Status)
+	}
+
+	count, err := countPage(query, param.Count)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to count privacy requests")
+	}
+
+	if err = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize).Find(&requests).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve privacy requests")
+	}
+
+	return requests, count, nil
+}
+
+// GetPrivacyRequest retrieves a privacy request with its result and audit trail
+func (s *PrivacyService) GetPrivacyRequest(requestId int) (dto.PrivacyRequestDetailResponse, error) {
+	var detail dto.PrivacyRequestDetailResponse
+
+	var request model.SysPrivacyRequest
+	dal.Gorm.Model(model.SysPrivacyRequest{}).Where("request_id = ?", requestId).Limit(1).Find(&request)
+	if request.RequestId <= 0 {
+		return detail, xerrors.ErrPrivacyRequestNotFound
+	}
+
+	detail.PrivacyRequestListResponse = dto.PrivacyRequestListResponse{
+		RequestId:   request.RequestId,
+		UserId:      request.UserId,
+		RequestType: request.RequestType,
+		Status:      request.Status,
+		Reason:      request.Reason,
+		ErrorMsg:    request.ErrorMsg,
+		CreateBy:    request.CreateBy,
+		CreateTime:  request.CreateTime,
+		FinishTime:  request.FinishTime,
+	}
+	if request.Result != "" {
+		detail.Result = json.RawMessage(request.Result)
+	}
+
+	detail.Logs = make([]dto.PrivacyRequestLogResponse, 0)
+	if err := dal.Gorm.Model(model.SysPrivacyRequestLog{}).Where("request_id = ?", requestId).Order("log_id").Find(&detail.Logs).Error; err != nil {
+		return detail, errors.Wrap(err, "failed to retrieve privacy request trail")
+	}
+
+	return detail, nil
+}
+
+// privacyTrail records the audit trail of a privacy request being processed
+type privacyTrail struct {
+	requestId int
+}
+
+// startPrivacyRequest records a privacy request as running
+func startPrivacyRequest(userId int, requestType, reason, operName string) (*privacyTrail, error) {
+	request := model.SysPrivacyRequest{
+		UserId:      userId,
+		RequestType: requestType,
+		Status:      constant.PRIVACY_REQUEST_STATUS_RUNNING,
+		Reason:      reason,
+		CreateBy:    operName,
+	}
+	if err := dal.Gorm.Create(&request).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to create privacy request")
+	}
+
+	trail := &privacyTrail{requestId: request.RequestId}
+	trail.log("created", fmt.Sprintf("%s of user ID %d requested by %s", requestType, userId, operName))
+	return trail, nil
+}
+
+// log appends a step to the audit trail; the trail is best effort so a step it fails to
+// record does not undo the work already done
+func (t *privacyTrail) log(step, detail string) {
+	dal.Gorm.Create(&model.SysPrivacyRequestLog{RequestId: t.requestId, Step: step, Detail: detail})
+}
+
+// finish records the request as finished with its result
+func (t *privacyTrail) finish(result interface{}) error {
+	content, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode privacy request result")
+	}
+
+	if err = dal.Gorm.Model(model.SysPrivacyRequest{}).Where("request_id = ?", t.requestId).Updates(&model.SysPrivacyRequest{
+		Status:     constant.PRIVACY_REQUEST_STATUS_FINISHED,
+		Result:     string(content),
+		FinishTime: datetime.Datetime{Time: time.Now()},
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed to finish privacy request")
+	}
+
+	t.log("finished", "")
+	return nil
+}
+
+// fail records the request as failed with its cause
+func (t *privacyTrail) fail(cause error) {
+	dal.Gorm.Model(model.SysPrivacyRequest{}).Where("request_id = ?", t.requestId).Updates(&model.SysPrivacyRequest{
+		Status:     constant.PRIVACY_REQUEST_STATUS_FAILED,
+		ErrorMsg:   cause.Error(),
+		FinishTime: datetime.Datetime{Time: time.Now()},
+	})
+
+	t.log("failed", cause.Error())
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+	"mira/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seedPrivacy gives bob, a disabled user, roles, posts, logs, references and a recycle bin
+// snapshot, with alice as an active colleague whose data must be left alone
+func seedPrivacy() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 102, ParentId: 100, Ancestors: "0,100", DeptName: "R&D", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 102, UserName: "alice", NickName: "Alice", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 102, UserName: "bob", NickName: "Bob", Email: "bob@example.com",
+		Phonenumber: "13800000003", Sex: "0", Avatar: "/profile/avatar/bob.png", LoginIP: "10.0.0.3", Remark: "on leave", Status: "1"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Developer", RoleKey: "dev", Status: "0"})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 2})
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "dev", PostName: "Developer", Status: "0"})
+	dal.Gorm.Create(&model.SysUserPost{UserId: 3, PostId: 1})
+
+	dal.Gorm.Create(&model.SysLogininfor{InfoId: 1, UserName: "bob", Ipaddr: "10.0.0.3", LoginLocation: "Intranet", Msg: "Login successful"})
+	dal.Gorm.Create(&model.SysLogininfor{InfoId: 2, UserName: "bob", Ipaddr: "10.0.0.3", LoginLocation: "Intranet", Msg: "Logout successful"})
+	dal.Gorm.Create(&model.SysLogininfor{InfoId: 3, UserName: "bob", Ipaddr: "10.0.0.4", LoginLocation: "Intranet", Msg: "Login successful"})
+	dal.Gorm.Create(&model.SysLogininfor{InfoId: 4, UserName: "alice", Ipaddr: "10.0.0.3", LoginLocation: "Intranet", Msg: "Login successful"})
+	dal.Gorm.Create(&model.SysOperLog{OperId: 1, Title: "User Management", OperName: "bob", OperIp: "10.0.0.3", OperLocation: "Intranet", JsonResult: `{"code":200}`})
+	dal.Gorm.Create(&model.SysOperLog{OperId: 2, Title: "User Management", OperName: "alice", OperIp: "10.0.0.2"})
+
+	dal.Gorm.Create(&model.SysPost{PostId: 2, PostCode: "ops", PostName: "Operations", Status: "0", CreateBy: "bob"})
+	dal.Gorm.Create(&model.SysRecycle{RecycleId: 1, EntityType: constant.RECYCLE_ENTITY_USER, EntityId: 3, EntityName: "bob", Snapshot: `{"userName":"bob"}`})
+	dal.Gorm.Create(&model.SysRecycle{RecycleId: 2, EntityType: constant.RECYCLE_ENTITY_ROLE, EntityId: 3, EntityName: "Tester"})
+}
+
+// readPrivacyArchive returns the files of an export archive by name
+func readPrivacyArchive(t *testing.T, content []byte) map[string][]byte {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	assert.NoError(t, err)
+
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		files[file.Name], _ = io.ReadAll(reader)
+		reader.Close()
+	}
+	return files
+}
+
+func TestPrivacyService_ExportUserData(t *testing.T) {
+	setup()
+	defer teardown()
+	seedPrivacy()
+	s := NewPrivacyService()
+
+	uploadPath := config.Data.Ruoyi.UploadPath
+	defer func() { config.Data.Ruoyi.UploadPath = uploadPath }()
+	config.Data.Ruoyi.UploadPath = filepath.Join(t.TempDir(), "profile") + "/"
+	dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", 3).Update("avatar", "/"+config.Data.Ruoyi.UploadPath+"avatar/bob.png")
+	assert.NoError(t, os.MkdirAll(filepath.Join(config.Data.Ruoyi.UploadPath, "avatar"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(config.Data.Ruoyi.UploadPath, "avatar", "bob.png"), []byte("png"), 0644))
+
+	t.Run("should return error for an unknown user", func(t *testing.T) {
+		_, err := s.ExportUserData(io.Discard, dto.PrivacyExportRequest{UserId: 99}, "admin")
+		assert.Equal(t, xerrors.ErrPrivacyUserNotFound, err)
+	})
+
+	var buf bytes.Buffer
+	report, err := s.ExportUserData(&buf, dto.PrivacyExportRequest{UserId: 3, Reason: "subject access request"}, "admin")
+	assert.NoError(t, err)
+	files := readPrivacyArchive(t, buf.Bytes())
+
+	t.Run("should report every file", func(t *testing.T) {
+		assert.Positive(t, report.RequestId)
+		assert.Equal(t, dto.PrivacyExportReport{RequestId: report.RequestId, UserId: 3, Roles: 1, Posts: 1, LoginLogs: 3, OperLogs: 1, Avatar: true}, report)
+
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		assert.ElementsMatch(t, []string{"manifest.json", "profile.json", "roles.json", "posts.json", "login_history.csv", "oper_logs.csv", "avatar.png"}, names)
+		assert.Equal(t, []byte("png"), files["avatar.png"])
+	})
+
+	t.Run("should export the profile, roles and posts as JSON", func(t *testing.T) {
+		var profile dto.PrivacyProfileResponse
+		assert.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+		assert.Equal(t, "bob", profile.UserName)
+		assert.Equal(t, "bob@example.com", string(profile.Email))
+		assert.Equal(t, "R&D", profile.DeptName)
+		assert.Equal(t, "on leave", profile.Remark)
+
+		roles := make([]dto.RoleListResponse, 0)
+		assert.NoError(t, json.Unmarshal(files["roles.json"], &roles))
+		assert.Len(t, roles, 1)
+		assert.Equal(t, "dev", roles[0].RoleKey)
+
+		posts := make([]dto.PostListResponse, 0)
+		assert.NoError(t, json.Unmarshal(files["posts.json"], &posts))
+		assert.Len(t, posts, 1)
+		assert.Equal(t, "dev", posts[0].PostCode)
+	})
+
+	t.Run("should export only the logs of the user as CSV", func(t *testing.T) {
+		logins, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(files["login_history.csv"], []byte("\xef\xbb\xbf")))).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, logins, 4)
+		assert.Contains(t, logins[0], "User Account")
+
+		operLogs, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(files["oper_logs.csv"], []byte("\xef\xbb\xbf")))).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, operLogs, 2)
+		assert.NotContains(t, string(files["oper_logs.csv"]), `{"code":200}`)
+	})
+
+	t.Run("should keep an audit trail", func(t *testing.T) {
+		request, err := s.GetPrivacyRequest(report.RequestId)
+		assert.NoError(t, err)
+		assert.Equal(t, constant.PRIVACY_REQUEST_EXPORT, request.RequestType)
+		assert.Equal(t, constant.PRIVACY_REQUEST_STATUS_FINISHED, request.Status)
+		assert.Equal(t, "subject access request", request.Reason)
+		assert.Equal(t, "admin", request.CreateBy)
+		assert.Len(t, request.Logs, 6)
+		assert.Equal(t, "created", request.Logs[0].Step)
+		assert.Equal(t, "avatar", request.Logs[4].Step)
+	})
+}
+
+func TestPrivacyService_EraseUserData(t *testing.T) {
+	setup()
+	defer teardown()
+	seedPrivacy()
+	s := NewPrivacyService()
+
+	t.Run("should return error for invalid targets", func(t *testing.T) {
+		_, err := s.EraseUserData(dto.PrivacyEraseRequest{UserId: 1}, "admin")
+		assert.Equal(t, xerrors.ErrPrivacySuperAdminErase, err)
+
+		_, err = s.EraseUserData(dto.PrivacyEraseRequest{UserId: 99}, "admin")
+		assert.Equal(t, xerrors.ErrPrivacyUserNotFound, err)
+
+		_, err = s.EraseUserData(dto.PrivacyEraseRequest{UserId: 2}, "admin")
+		assert.Equal(t, xerrors.ErrPrivacyEraseActiveUser, err)
+
+		// No request is recorded for a refused erasure
+		var count int64
+		dal.Gorm.Model(model.SysPrivacyRequest{}).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	report, err := s.EraseUserData(dto.PrivacyEraseRequest{UserId: 3, Reason: "right to erasure"}, "admin")
+	assert.NoError(t, err)
+
+	t.Run("should report every step", func(t *testing.T) {
+		assert.Equal(t, dto.PrivacyEraseReport{
+			RequestId:             report.RequestId,
+			UserId:                3,
+			Pseudonym:             "erased_3",
+			PseudonymisedOperLogs: 1,
+			PseudonymisedLogins:   3,
+			RenamedReferences:     1,
+			RemovedRecycleEntries: 1,
+		}, report)
+	})
+
+	t.Run("should scrub the profile", func(t *testing.T) {
+		var user model.SysUser
+		dal.Gorm.Where("user_id = ?", 3).Take(&user)
+		assert.Equal(t, "erased_3", user.UserName)
+		assert.Equal(t, "erased_3", user.NickName)
+		assert.Equal(t, "2", user.Sex)
+		assert.Empty(t, user.Email)
+		assert.Empty(t, user.Phonenumber)
+		assert.Empty(t, user.Avatar)
+		assert.Empty(t, user.Password)
+		assert.Empty(t, user.LoginIP)
+		assert.Empty(t, user.Remark)
+		assert.Equal(t, 102, user.DeptId)
+	})
+
+	t.Run("should keep the logs under the pseudonym", func(t *testing.T) {
+		logins := make([]model.SysLogininfor, 0)
+		dal.Gorm.Order("info_id").Find(&logins)
+		assert.Len(t, logins, 4)
+		for _, login := range logins[:3] {
+			assert.Equal(t, "erased_3", login.UserName)
+			assert.Empty(t, login.LoginLocation)
+			assert.True(t, strings.HasPrefix(string(login.Ipaddr), "ip-"))
+		}
+		// Equal addresses map to equal pseudonyms, so the logs can still be correlated
+		assert.Equal(t, logins[0].Ipaddr, logins[1].Ipaddr)
+		assert.NotEqual(t, logins[0].Ipaddr, logins[2].Ipaddr)
+		assert.Equal(t, "alice", logins[3].UserName)
+		assert.Equal(t, "10.0.0.3", string(logins[3].Ipaddr))
+
+		operLogs := make([]model.SysOperLog, 0)
+		dal.Gorm.Order("oper_id").Find(&operLogs)
+		assert.Equal(t, "erased_3", operLogs[0].OperName)
+		assert.Equal(t, string(logins[0].Ipaddr), operLogs[0].OperIp)
+		assert.Equal(t, "10.0.0.2", operLogs[1].OperIp)
+	})
+
+	t.Run("should rename references and remove snapshots", func(t *testing.T) {
+		var post model.SysPost
+		dal.Gorm.Where("post_id = ?", 2).Take(&post)
+		assert.Equal(t, "erased_3", post.CreateBy)
+
+		ids := make([]int, 0)
+		dal.Gorm.Model(model.SysRecycle{}).Pluck("recycle_id", &ids)
+		assert.Equal(t, []int{2}, ids)
+	})
+
+	t.Run("should refuse to erase twice", func(t *testing.T) {
+		_, err := s.EraseUserData(dto.PrivacyEraseRequest{UserId: 3}, "admin")
+		assert.Equal(t, xerrors.ErrPrivacyUserErased, err)
+	})
+
+	t.Run("should keep an audit trail", func(t *testing.T) {
+		request, err := s.GetPrivacyRequest(report.RequestId)
+		assert.NoError(t, err)
+		assert.Equal(t, constant.PRIVACY_REQUEST_ERASE, request.RequestType)
+		assert.Equal(t, constant.PRIVACY_REQUEST_STATUS_FINISHED, request.Status)
+		assert.Len(t, request.Logs, 6)
+
+		var result dto.PrivacyEraseReport
+		assert.NoError(t, json.Unmarshal(request.Result, &result))
+		assert.Equal(t, report, result)
+
+		rows, total, err := s.GetPrivacyRequestList(dto.PrivacyRequestListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}, UserId: 3, RequestType: constant.PRIVACY_REQUEST_ERASE})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, report.RequestId, rows[0].RequestId)
+
+		_, total, err = s.GetPrivacyRequestList(dto.PrivacyRequestListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}, UserId: 3, RequestType: constant.PRIVACY_REQUEST_EXPORT})
+		assert.NoError(t, err)
+		assert.Zero(t, total)
+
+		_, err = s.GetPrivacyRequest(99)
+		assert.Equal(t, xerrors.ErrPrivacyRequestNotFound, err)
+	})
+}
@@ -0,0 +1,877 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"mira/anima/cipher"
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Recycle bin configuration
+const (
+	// RECYCLE_RETENTION_DAYS_CONFIG_KEY is the number of days deleted items stay in the recycle bin
+	RECYCLE_RETENTION_DAYS_CONFIG_KEY = "sys.recycle.retentionDays"
+	// RECYCLE_DEFAULT_RETENTION_DAYS applies when the retention days are not configured
+	RECYCLE_DEFAULT_RETENTION_DAYS = 30
+)
+
+// RecycleServiceInterface defines operations for the recycle bin
+type RecycleServiceInterface interface {
+	GetRecycleList(param dto.RecycleListRequest, isPaging bool) ([]dto.RecycleListResponse, int)
+	RestoreRecycle(recycleIds []int) error
+	PurgeRecycle(recycleIds []int) (int, error)
+	PurgeExpiredRecycle() (int, error)
+	GetRecycleRetentionDays() int
+}
+
+// RecycleService implements the recycle bin
+//
+// Deleting a user, role, department, post, menu, user group or dictionary records an entry with a
+// snapshot of the deleted row and of the links removed with it. Soft-deleted rows are
+// restored in place and their links recreated; dictionaries are hard-deleted and are
+// restored from the snapshot. Entries older than the retention period are purged.
+type RecycleService struct{}
+
+// Ensure RecycleService implements RecycleServiceInterface
+var _ RecycleServiceInterface = (*RecycleService)(nil)
+
+// NewRecycleService creates a new RecycleService
+func NewRecycleService() *RecycleService {
+	return &RecycleService{}
+}
+
+// recycleSnapshot is the content of a recycle bin entry
+type recycleSnapshot struct {
+	Row      json.RawMessage `json:"row"`
+	RoleIds  []int           `json:"roleIds,omitempty"`
+	PostIds  []int           `json:"postIds,omitempty"`
+	MenuIds  []int           `json:"menuIds,omitempty"`
+	DeptIds  []int           `json:"deptIds,omitempty"`
+	GroupIds []int           `json:"groupIds,omitempty"`
+}
+
+// recycleUniqueCheck is a unique column a restored row must not share with a live row
+type recycleUniqueCheck struct {
+	label  string
+	column string
+	value  string
+}
+
+// GetRecycleList returns the recycle bin entries based on filtering criteria
+func (s *RecycleService) GetRecycleList(param dto.RecycleListRequest, isPaging bool) ([]dto.RecycleListResponse, int) {
+	entries, count, _ := s.GetRecycleListWithErr(param, isPaging)
+	return entries, count
+}
+
+// GetRecycleListWithErr returns the recycle bin entries with proper error handling
+func (s *RecycleService) GetRecycleListWithErr(param dto.RecycleListRequest, isPaging bool) ([]dto.RecycleListResponse, int, error) {
+	var count int64
+	entries := make([]dto.RecycleListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysRecycle{}).Order("delete_time DESC, recycle_id DESC")
+
+	if param.EntityType != "" {
+		query = query.Where("entity_type = ?", param.EntityType)
+	}
+
+	if param.EntityName != "" {
+		query = query.Where("entity_name LIKE ?", "%"+param.EntityName+"%")
+	}
+
+	if param.DeleteBy != "" {
+		query = query.Where("delete_by LIKE ?", "%"+param.DeleteBy+"%")
+	}
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count recycle bin entries")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve recycle bin entries")
+	}
+
+	retention := time.Duration(s.GetRecycleRetentionDays()) * 24 * time.Hour
+	for i := range entries {
+		entries[i].PurgeTime = datetime.Datetime{Time: entries[i].DeleteTime.Add(retention)}
+	}
+
+	return entries, int(count), nil
+}
+
+// GetRecycleRetentionDays returns the number of days deleted items stay in the recycle bin
+func (s *RecycleService) GetRecycleRetentionDays() int {
+	return (&ConfigService{}).GetInt(RECYCLE_RETENTION_DAYS_CONFIG_KEY)
+}
+
+// RestoreRecycle restores the deleted items of recycle bin entries, all or nothing
+func (s *RecycleService) RestoreRecycle(recycleIds []int) error {
+	return s.RestoreRecycleWithErr(recycleIds)
+}
+
+// RestoreRecycleWithErr restores the deleted items of recycle bin entries with proper error handling
+func (s *RecycleService) RestoreRecycleWithErr(recycleIds []int) error {
+	if len(recycleIds) == 0 {
+		return xerrors.ErrParam
+	}
+
+	// Restore in reverse deletion order, so a parent deleted after its children comes back first
+	entries := make([]model.SysRecycle, 0)
+	if err := dal.Gorm.Model(model.SysRecycle{}).Where("recycle_id IN ?", recycleIds).Order("recycle_id DESC").Find(&entries).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve recycle bin entries")
+	}
+
+	if len(entries) != len(recycleIds) {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	tx := dal.Gorm.Begin()
+
+	restoredDeptIds, restoredDictTypes, restoredGroupIds, restoredPostIds := make([]int, 0), make([]string, 0), make([]int, 0), make([]int, 0)
+	for _, entry := range entries {
+		var snapshot recycleSnapshot
+		if err := json.Unmarshal([]byte(entry.Snapshot), &snapshot); err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "failed to decode recycle bin entry %d", entry.RecycleId)
+		}
+
+		var err error
+		switch entry.EntityType {
+		case constant.RECYCLE_ENTITY_USER:
+			err = restoreRecycledUser(tx, entry, snapshot)
+		case constant.RECYCLE_ENTITY_ROLE:
+			err = restoreRecycledRole(tx, entry, snapshot)
+		case constant.RECYCLE_ENTITY_DEPT:
+			err = restoreRecycledDept(tx, entry)
+			restoredDeptIds = append(restoredDeptIds, entry.EntityId)
+		case constant.RECYCLE_ENTITY_POST:
+			err = restoreRecycledPost(tx, entry)
+			restoredPostIds = append(restoredPostIds, entry.EntityId)
+		case constant.RECYCLE_ENTITY_MENU:
+			err = restoreRecycledMenu(tx, entry)
+		case constant.RECYCLE_ENTITY_USER_GROUP:
+			err = restoreRecycledUserGroup(tx, entry)
+			restoredGroupIds = append(restoredGroupIds, entry.EntityId)
+		case constant.RECYCLE_ENTITY_DICT_TYPE:
+			var dictType model.SysDictType
+			if err = json.Unmarshal(snapshot.Row, &dictType); err == nil {
+				err = restoreRecycledDictType(tx, dictType)
+				restoredDictTypes = append(restoredDictTypes, dictType.DictType)
+			}
+		case constant.RECYCLE_ENTITY_DICT_DATA:
+			var dictData model.SysDictData
+			if err = json.Unmarshal(snapshot.Row, &dictData); err == nil {
+				err = restoreRecycledDictData(tx, dictData)
+				restoredDictTypes = append(restoredDictTypes, dictData.DictType)
+			}
+		default:
+			err = xerrors.ErrRecycleTypeInvalid
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Where("recycle_id = ?", entry.RecycleId).Delete(&model.SysRecycle{}).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete recycle bin entry")
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	if len(restoredDeptIds) > 0 {
+		invalidateDeptCaches(deptCacheTags(restoredDeptIds...))
+	}
+
+	if len(restoredGroupIds) > 0 {
+		invalidateUserPermCaches(groupMemberIds(restoredGroupIds...))
+	}
+
+	if len(restoredPostIds) > 0 {
+		invalidateUserPermCaches(postHolderIds(restoredPostIds...))
+	}
+
+	if len(restoredDictTypes) > 0 {
+		refreshDictCache(restoredDictTypes...)
+	}
+
+	// Recycled entity types share their names with the search and cache event types, the others
+	// are ignored
+	for _, entry := range entries {
+		notifySearch(entry.EntityType, entry.EntityId)
+		publishCacheIds(entry.EntityType, entry.EntityId)
+	}
+
+	return nil
+}
+
+// PurgeRecycle permanently deletes the items of recycle bin entries, returning the number purged
+func (s *RecycleService) PurgeRecycle(recycleIds []int) (int, error) {
+	return s.PurgeRecycleWithErr(recycleIds)
+}
+
+// PurgeRecycleWithErr permanently deletes the items of recycle bin entries with proper error handling
+func (s *RecycleService) PurgeRecycleWithErr(recycleIds []int) (int, error) {
+	if len(recycleIds) == 0 {
+		return 0, xerrors.ErrParam
+	}
+
+	entries := make([]model.SysRecycle, 0)
+	if err := dal.Gorm.Model(model.SysRecycle{}).Where("recycle_id IN ?", recycleIds).Find(&entries).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve recycle bin entries")
+	}
+
+	tx := dal.Gorm.Begin()
+
+	for _, entry := range entries {
+		if err := purgeRecycled(tx, entry); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return len(entries), nil
+}
+
+// PurgeExpiredRecycle permanently deletes the items that outlived the retention period,
+// recording the purge in the operation log
+func (s *RecycleService) PurgeExpiredRecycle() (int, error) {
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -s.GetRecycleRetentionDays())
+
+	recycleIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysRecycle{}).Where("delete_time < ?", cutoff).Pluck("recycle_id", &recycleIds).Error; err != nil {
+		return 0, errors.Wrap(err, "failed to retrieve expired recycle bin entries")
+	}
+
+	if len(recycleIds) == 0 {
+		return 0, nil
+	}
+
+	purged, err := s.PurgeRecycleWithErr(recycleIds)
+	if err != nil {
+		return 0, err
+	}
+
+	param, _ := json.Marshal(recycleIds)
+
+	return purged, (&OperLogService{}).CreateSysOperLogWithErr(dto.SaveOperLogRequest{
+		Title:        "Recycle Bin Purge",
+		BusinessType: constant.REQUEST_BUSINESS_TYPE_CLEAN,
+		Method:       "RecycleService.PurgeExpiredRecycle",
+		OperParam:    string(param),
+		JsonResult:   fmt.Sprintf("purged %d recycle bin entries deleted before %s", purged, cutoff.Format(time.DateTime)),
+		Status:       constant.NORMAL_STATUS,
+		OperTime:     datetime.Datetime{Time: now},
+	})
+}
+
+// StartPurgeScheduler purges expired recycle bin entries at every interval until the context is done
+func (s *RecycleService) StartPurgeScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.PurgeExpiredRecycle(); err != nil {
+					log.Printf("Warning: Failed to purge expired recycle bin entries: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// addRecycleEntry records a deleted row in the recycle bin. Encrypted fields are left out of the
+// snapshot, which is stored in clear; rows holding them are soft-deleted and restored in place.
+func addRecycleEntry(tx *gorm.DB, entityType string, entityId int, entityName string, row interface{}, snapshot recycleSnapshot, deleteBy string) error {
+	var err error
+	if snapshot.Row, err = json.Marshal(omitEncryptedFields(row)); err != nil {
+		return errors.Wrap(err, "failed to encode recycle bin snapshot")
+	}
+
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode recycle bin snapshot")
+	}
+
+	if err := tx.Create(&model.SysRecycle{
+		EntityType: entityType,
+		EntityId:   entityId,
+		EntityName: entityName,
+		Snapshot:   string(content),
+		DeleteBy:   deleteBy,
+		DeleteTime: datetime.Datetime{Time: time.Now()},
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed to create recycle bin entry")
+	}
+
+	return nil
+}
+
+// omitEncryptedFields returns a copy of a struct with its cipher.String fields cleared
+func omitEncryptedFields(row interface{}) interface{} {
+	value := reflect.ValueOf(row)
+	if value.Kind() != reflect.Struct {
+		return row
+	}
+
+	encrypted := reflect.TypeOf((*cipher.Text)(nil)).Elem()
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+	for i := 0; i < copied.NumField(); i++ {
+		if field := copied.Field(i); field.Type().Implements(encrypted) && field.CanSet() {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+
+	return copied.Interface()
+}
+
+// recycleUsers records users with their role and post links in the recycle bin before deletion.
+// The password hash and personal data are left out of the snapshot; restoring reads them back
+// from the soft-deleted row.
+func recycleUsers(tx *gorm.DB, userIds []int, deleteBy string) error {
+	users := make([]model.SysUser, 0)
+	if err := tx.Model(model.SysUser{}).Where("user_id IN ?", userIds).Find(&users).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve users")
+	}
+
+	for _, user := range users {
+		snapshot := recycleSnapshot{}
+		if err := tx.Model(model.SysUserRole{}).Where("user_id = ?", user.UserId).Pluck("role_id", &snapshot.RoleIds).Error; err != nil {
+			return errors.Wrap(err, "failed to retrieve user roles")
+		}
+		if err := tx.Model(model.SysUserPost{}).Where("user_id = ?", user.UserId).Pluck("post_id", &snapshot.PostIds).Error; err != nil {
+			return errors.Wrap(err, "failed to retrieve user posts")
+		}
+
+		user.Password = ""
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_USER, user.UserId, user.UserName, user, snapshot, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleRoles records roles with their menu, department and user group links in the recycle bin before deletion
+func recycleRoles(tx *gorm.DB, roleIds []int, deleteBy string) error {
+	roles := make([]model.SysRole, 0)
+	if err := tx.Model(model.SysRole{}).Where("role_id IN ?", roleIds).Find(&roles).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve roles")
+	}
+
+	for _, role := range roles {
+		snapshot := recycleSnapshot{}
+		if err := tx.Model(model.SysRoleMenu{}).Where("role_id = ?", role.RoleId).Pluck("menu_id", &snapshot.MenuIds).Error; err != nil {
+			return errors.Wrap(err, "failed to retrieve role menus")
+		}
+		if err := tx.Model(model.SysRoleDept{}).Where("role_id = ?", role.RoleId).Pluck("dept_id", &snapshot.DeptIds).Error; err != nil {
+			return errors.Wrap(err, "failed to retrieve role departments")
+		}
+		if err := tx.Model(model.SysRoleGroup{}).Where("role_id = ?", role.RoleId).Pluck("group_id", &snapshot.GroupIds).Error; err != nil {
+			return errors.Wrap(err, "failed to retrieve role user groups")
+		}
+
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_ROLE, role.RoleId, role.RoleName, role, snapshot, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleDepts records departments in the recycle bin before deletion
+func recycleDepts(tx *gorm.DB, deptIds []int, deleteBy string) error {
+	depts := make([]model.SysDept, 0)
+	if err := tx.Model(model.SysDept{}).Where("dept_id IN ?", deptIds).Find(&depts).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve departments")
+	}
+
+	for _, dept := range depts {
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_DEPT, dept.DeptId, dept.DeptName, dept, recycleSnapshot{}, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recyclePosts records posts in the recycle bin before deletion
+func recyclePosts(tx *gorm.DB, postIds []int, deleteBy string) error {
+	posts := make([]model.SysPost, 0)
+	if err := tx.Model(model.SysPost{}).Where("post_id IN ?", postIds).Find(&posts).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve posts")
+	}
+
+	for _, post := range posts {
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_POST, post.PostId, post.PostName, post, recycleSnapshot{}, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleUserGroups records user groups in the recycle bin before deletion
+func recycleUserGroups(tx *gorm.DB, groupIds []int, deleteBy string) error {
+	groups := make([]model.SysUserGroup, 0)
+	if err := tx.Model(model.SysUserGroup{}).Where("group_id IN ?", groupIds).Find(&groups).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve user groups")
+	}
+
+	for _, group := range groups {
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_USER_GROUP, group.GroupId, group.GroupName, group, recycleSnapshot{}, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleMenus records menus in the recycle bin before deletion
+func recycleMenus(tx *gorm.DB, menuIds []int, deleteBy string) error {
+	menus := make([]model.SysMenu, 0)
+	if err := tx.Model(model.SysMenu{}).Where("menu_id IN ?", menuIds).Find(&menus).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve menus")
+	}
+
+	for _, menu := range menus {
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_MENU, menu.MenuId, menu.MenuName, menu, recycleSnapshot{}, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleDictTypes records dictionary types in the recycle bin before deletion
+func recycleDictTypes(tx *gorm.DB, dictIds []int, deleteBy string) error {
+	dictTypes := make([]model.SysDictType, 0)
+	if err := tx.Model(model.SysDictType{}).Where("dict_id IN ?", dictIds).Find(&dictTypes).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve dictionary types")
+	}
+
+	for _, dictType := range dictTypes {
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_DICT_TYPE, dictType.DictId, dictType.DictType, dictType, recycleSnapshot{}, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recycleDictData records dictionary data in the recycle bin before deletion
+func recycleDictData(tx *gorm.DB, dictCodes []int, deleteBy string) error {
+	dictDatas := make([]model.SysDictData, 0)
+	if err := tx.Model(model.SysDictData{}).Where("dict_code IN ?", dictCodes).Find(&dictDatas).Error; err != nil {
+		return errors.Wrap(err, "failed to retrieve dictionary data")
+	}
+
+	for _, dictData := range dictDatas {
+		name := dictData.DictType + ":" + dictData.DictLabel
+		if err := addRecycleEntry(tx, constant.RECYCLE_ENTITY_DICT_DATA, dictData.DictCode, name, dictData, recycleSnapshot{}, deleteBy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkRecycleConflicts rejects a restore when a live row has reused one of the unique values
+func checkRecycleConflicts(tx *gorm.DB, table interface{}, checks ...recycleUniqueCheck) error {
+	conflicts := make([]string, 0)
+
+	for _, check := range checks {
+		if check.value == "" {
+			continue
+		}
+
+		// Encrypted columns are compared through their blind index
+		condition, value := check.column+" = ?", check.value
+		if named, ok := table.(interface{ TableName() string }); ok && isIndexedColumn(named.TableName(), check.column) {
+			condition, value = piiEquals(check.column, check.value)
+		}
+
+		var count int64
+		if err := tx.Model(table).Where(condition, value).Count(&count).Error; err != nil {
+			return errors.Wrap(err, "failed to check restore conflicts")
+		}
+		if count > 0 {
+			conflicts = append(conflicts, check.label+" "+check.value)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return errors.Wrap(xerrors.ErrRecycleConflict, strings.Join(conflicts, ", ")+" already in use")
+	}
+
+	return nil
+}
+
+// liveIds keeps the IDs that still exist in a table
+func liveIds(tx *gorm.DB, table interface{}, column string, ids []int) ([]int, error) {
+	live := make([]int, 0)
+	if len(ids) == 0 {
+		return live, nil
+	}
+
+	if err := tx.Model(table).Where(column+" IN ?", ids).Pluck(column, &live).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to check linked rows")
+	}
+
+	return live, nil
+}
+
+// undelete clears the soft-delete mark of a row
+func undelete(tx *gorm.DB, table interface{}, column string, id int, updates map[string]interface{}) error {
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["delete_time"] = nil
+
+	result := tx.Unscoped().Model(table).Where(column+" = ? AND delete_time IS NOT NULL", id).Updates(updates)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "failed to restore deleted row")
+	}
+	if result.RowsAffected == 0 {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	return nil
+}
+
+// restoreRecycledUser restores a user with the roles and posts that still exist
+func restoreRecycledUser(tx *gorm.DB, entry model.SysRecycle, snapshot recycleSnapshot) error {
+	var user model.SysUser
+	if err := tx.Unscoped().Where("user_id = ? AND delete_time IS NOT NULL", entry.EntityId).Take(&user).Error; err != nil {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	if err := checkRecycleConflicts(tx, model.SysUser{},
+		recycleUniqueCheck{"user name", "user_name", user.UserName},
+		recycleUniqueCheck{"email", "email", string(user.Email)},
+		recycleUniqueCheck{"phone number", "phonenumber", string(user.Phonenumber)},
+	); err != nil {
+		return err
+	}
+
+	if user.DeptId != 0 {
+		if deptIds, err := liveIds(tx, model.SysDept{}, "dept_id", []int{user.DeptId}); err != nil {
+			return err
+		} else if len(deptIds) == 0 {
+			return xerrors.ErrRecycleParentMissing
+		}
+	}
+
+	if err := undelete(tx, model.SysUser{}, "user_id", user.UserId, nil); err != nil {
+		return err
+	}
+
+	roleIds, err := liveIds(tx, model.SysRole{}, "role_id", snapshot.RoleIds)
+	if err != nil {
+		return err
+	}
+	if len(roleIds) > 0 {
+		userRoles := make([]model.SysUserRole, 0, len(roleIds))
+		for _, roleId := range roleIds {
+			userRoles = append(userRoles, model.SysUserRole{UserId: user.UserId, RoleId: roleId})
+		}
+		if err := tx.Create(&userRoles).Error; err != nil {
+			return errors.Wrap(err, "failed to restore user roles")
+		}
+	}
+
+	postIds, err := liveIds(tx, model.SysPost{}, "post_id", snapshot.PostIds)
+	if err != nil {
+		return err
+	}
+	if len(postIds) > 0 {
+		userPosts := make([]model.SysUserPost, 0, len(postIds))
+		for _, postId := range postIds {
+			userPosts = append(userPosts, model.SysUserPost{UserId: user.UserId, PostId: postId})
+		}
+		if err := tx.Create(&userPosts).Error; err != nil {
+			return errors.Wrap(err, "failed to restore user posts")
+		}
+	}
+
+	return nil
+}
+
+// restoreRecycledRole restores a role with the menus, departments and user groups that still exist
+func restoreRecycledRole(tx *gorm.DB, entry model.SysRecycle, snapshot recycleSnapshot) error {
+	var role model.SysRole
+	if err := tx.Unscoped().Where("role_id = ? AND delete_time IS NOT NULL", entry.EntityId).Take(&role).Error; err != nil {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	if err := checkRecycleConflicts(tx, model.SysRole{},
+		recycleUniqueCheck{"role name", "role_name", role.RoleName},
+		recycleUniqueCheck{"role key", "role_key", role.RoleKey},
+	); err != nil {
+		return err
+	}
+
+	if err := undelete(tx, model.SysRole{}, "role_id", role.RoleId, nil); err != nil {
+		return err
+	}
+
+	menuIds, err := liveIds(tx, model.SysMenu{}, "menu_id", snapshot.MenuIds)
+	if err != nil {
+		return err
+	}
+	if len(menuIds) > 0 {
+		roleMenus := make([]model.SysRoleMenu, 0, len(menuIds))
+		for _, menuId := range menuIds {
+			roleMenus = append(roleMenus, model.SysRoleMenu{RoleId: role.RoleId, MenuId: menuId})
+		}
+		if err := tx.Create(&roleMenus).Error; err != nil {
+			return errors.Wrap(err, "failed to restore role menus")
+		}
+	}
+
+	deptIds, err := liveIds(tx, model.SysDept{}, "dept_id", snapshot.DeptIds)
+	if err != nil {
+		return err
+	}
+	if len(deptIds) > 0 {
+		roleDepts := make([]model.SysRoleDept, 0, len(deptIds))
+		for _, deptId := range deptIds {
+			roleDepts = append(roleDepts, model.SysRoleDept{RoleId: role.RoleId, DeptId: deptId})
+		}
+		if err := tx.Create(&roleDepts).Error; err != nil {
+			return errors.Wrap(err, "failed to restore role departments")
+		}
+	}
+
+	groupIds, err := liveIds(tx, model.SysUserGroup{}, "group_id", snapshot.GroupIds)
+	if err != nil {
+		return err
+	}
+	if len(groupIds) > 0 {
+		roleGroups := make([]model.SysRoleGroup, 0, len(groupIds))
+		for _, groupId := range groupIds {
+			roleGroups = append(roleGroups, model.SysRoleGroup{RoleId: role.RoleId, GroupId: groupId})
+		}
+		if err := tx.Create(&roleGroups).Error; err != nil {
+			return errors.Wrap(err, "failed to restore role user groups")
+		}
+	}
+
+	return nil
+}
+
+// restoreRecycledDept restores a department under its parent, recomputing its ancestors
+// since the parent may have moved in the meantime
+func restoreRecycledDept(tx *gorm.DB, entry model.SysRecycle) error {
+	var dept model.SysDept
+	if err := tx.Unscoped().Where("dept_id = ? AND delete_time IS NOT NULL", entry.EntityId).Take(&dept).Error; err != nil {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	if err := checkRecycleConflicts(tx, model.SysDept{},
+		recycleUniqueCheck{"department name", "dept_name", dept.DeptName},
+	); err != nil {
+		return err
+	}
+
+	ancestors := "0"
+	if dept.ParentId != 0 {
+		var parent model.SysDept
+		if err := tx.Model(model.SysDept{}).Where("dept_id = ?", dept.ParentId).Take(&parent).Error; err != nil {
+			return xerrors.ErrRecycleParentMissing
+		}
+		ancestors = parent.Ancestors + "," + strconv.Itoa(parent.DeptId)
+	}
+
+	return undelete(tx, model.SysDept{}, "dept_id", dept.DeptId, map[string]interface{}{"ancestors": ancestors})
+}
+
+// restoreRecycledPost restores a post, moving it to the top of the hierarchy when its superior
+// post has come to report to one of its subordinates meanwhile
+func restoreRecycledPost(tx *gorm.DB, entry model.SysRecycle) error {
+	var post model.SysPost
+	if err := tx.Unscoped().Where("post_id = ? AND delete_time IS NOT NULL", entry.EntityId).Take(&post).Error; err != nil {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	if err := checkRecycleConflicts(tx, model.SysPost{},
+		recycleUniqueCheck{"post name", "post_name", post.PostName},
+		recycleUniqueCheck{"post code", "post_code", post.PostCode},
+	); err != nil {
+		return err
+	}
+
+	var columns map[string]interface{}
+	if err := checkPostParent(tx, post.PostId, post.ParentId); errors.Is(err, xerrors.ErrPostParentCycle) {
+		columns = map[string]interface{}{"parent_id": 0}
+	}
+
+	return undelete(tx, model.SysPost{}, "post_id", post.PostId, columns)
+}
+
+// restoreRecycledUserGroup restores a user group with the members, roles and data scope links it kept
+func restoreRecycledUserGroup(tx *gorm.DB, entry model.SysRecycle) error {
+	var group model.SysUserGroup
+	if err := tx.Unscoped().Where("group_id = ? AND delete_time IS NOT NULL", entry.EntityId).Take(&group).Error; err != nil {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	if err := checkRecycleConflicts(tx, model.SysUserGroup{},
+		recycleUniqueCheck{"group name", "group_name", group.GroupName},
+	); err != nil {
+		return err
+	}
+
+	return undelete(tx, model.SysUserGroup{}, "group_id", group.GroupId, nil)
+}
+
+// restoreRecycledMenu restores a menu under its parent
+func restoreRecycledMenu(tx *gorm.DB, entry model.SysRecycle) error {
+	var menu model.SysMenu
+	if err := tx.Unscoped().Where("menu_id = ? AND delete_time IS NOT NULL", entry.EntityId).Take(&menu).Error; err != nil {
+		return xerrors.ErrRecycleNotFound
+	}
+
+	if err := checkRecycleConflicts(tx, model.SysMenu{},
+		recycleUniqueCheck{"menu name", "menu_name", menu.MenuName},
+	); err != nil {
+		return err
+	}
+
+	if menu.ParentId != 0 {
+		if menuIds, err := liveIds(tx, model.SysMenu{}, "menu_id", []int{menu.ParentId}); err != nil {
+			return err
+		} else if len(menuIds) == 0 {
+			return xerrors.ErrRecycleParentMissing
+		}
+	}
+
+	return undelete(tx, model.SysMenu{}, "menu_id", menu.MenuId, nil)
+}
+
+// restoreRecycledDictType recreates a dictionary type from its snapshot
+func restoreRecycledDictType(tx *gorm.DB, dictType model.SysDictType) error {
+	if err := checkRecycleConflicts(tx, model.SysDictType{},
+		recycleUniqueCheck{"dictionary type", "dict_type", dictType.DictType},
+	); err != nil {
+		return err
+	}
+
+	if err := tx.Create(&dictType).Error; err != nil {
+		return errors.Wrap(err, "failed to restore dictionary type")
+	}
+
+	return nil
+}
+
+// restoreRecycledDictData recreates dictionary data from its snapshot under its dictionary type
+func restoreRecycledDictData(tx *gorm.DB, dictData model.SysDictData) error {
+	var count int64
+	if err := tx.Model(model.SysDictType{}).Where("dict_type = ?", dictData.DictType).Count(&count).Error; err != nil {
+		return errors.Wrap(err, "failed to check dictionary type")
+	}
+	if count == 0 {
+		return xerrors.ErrRecycleParentMissing
+	}
+
+	if err := tx.Model(model.SysDictData{}).Where("dict_type = ? AND dict_value = ?", dictData.DictType, dictData.DictValue).Count(&count).Error; err != nil {
+		return errors.Wrap(err, "failed to check restore conflicts")
+	}
+	if count > 0 {
+		return errors.Wrap(xerrors.ErrRecycleConflict, "dictionary value "+dictData.DictValue+" already in use")
+	}
+
+	if err := tx.Create(&dictData).Error; err != nil {
+		return errors.Wrap(err, "failed to restore dictionary data")
+	}
+
+	return nil
+}
+
+// purgeRecycled permanently deletes the row of a recycle bin entry and the links still pointing to it
+func purgeRecycled(tx *gorm.DB, entry model.SysRecycle) error {
+	var err error
+
+	switch entry.EntityType {
+	case constant.RECYCLE_ENTITY_USER:
+		if err = tx.Unscoped().Where("user_id = ? AND delete_time IS NOT NULL", entry.EntityId).Delete(&model.SysUser{}).Error; err == nil {
+			err = tx.Where("user_id = ?", entry.EntityId).Delete(&model.SysUserGroupUser{}).Error
+		}
+		if err == nil {
+			err = tx.Where("user_id = ?", entry.EntityId).Delete(&model.SysUserAttrValue{}).Error
+		}
+	case constant.RECYCLE_ENTITY_ROLE:
+		if err = tx.Unscoped().Where("role_id = ? AND delete_time IS NOT NULL", entry.EntityId).Delete(&model.SysRole{}).Error; err == nil {
+			err = tx.Where("role_id = ?", entry.EntityId).Delete(&model.SysUserRole{}).Error
+		}
+		if err == nil {
+			err = tx.Where("role_id = ?", entry.EntityId).Delete(&model.SysUserGroupRole{}).Error
+		}
+		if err == nil {
+			err = tx.Where("role_id = ?", entry.EntityId).Delete(&model.SysPostRole{}).Error
+		}
+	case constant.RECYCLE_ENTITY_DEPT:
+		if err = tx.Unscoped().Where("dept_id = ? AND delete_time IS NOT NULL", entry.EntityId).Delete(&model.SysDept{}).Error; err == nil {
+			err = tx.Where("dept_id = ?", entry.EntityId).Delete(&model.SysRoleDept{}).Error
+		}
+	case constant.RECYCLE_ENTITY_POST:
+		if err = tx.Unscoped().Where("post_id = ? AND delete_time IS NOT NULL", entry.EntityId).Delete(&model.SysPost{}).Error; err == nil {
+			err = tx.Where("post_id = ?", entry.EntityId).Delete(&model.SysPostRole{}).Error
+		}
+	case constant.RECYCLE_ENTITY_MENU:
+		if err = tx.Unscoped().Where("menu_id = ? AND delete_time IS NOT NULL", entry.EntityId).Delete(&model.SysMenu{}).Error; err == nil {
+			err = tx.Where("menu_id = ?", entry.EntityId).Delete(&model.SysRoleMenu{}).Error
+		}
+	case constant.RECYCLE_ENTITY_USER_GROUP:
+		if err = tx.Unscoped().Where("group_id = ? AND delete_time IS NOT NULL", entry.EntityId).Delete(&model.SysUserGroup{}).Error; err == nil {
+			for _, link := range []interface{}{&model.SysUserGroupUser{}, &model.SysUserGroupRole{}, &model.SysRoleGroup{}} {
+				if err = tx.Where("group_id = ?", entry.EntityId).Delete(link).Error; err != nil {
+					break
+				}
+			}
+		}
+	case constant.RECYCLE_ENTITY_DICT_TYPE, constant.RECYCLE_ENTITY_DICT_DATA:
+		// Dictionaries are hard-deleted, only the snapshot remains
+	default:
+		return xerrors.ErrRecycleTypeInvalid
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to purge %s %d", entry.EntityType, entry.EntityId)
+	}
+
+	if err := tx.Where("recycle_id = ?", entry.RecycleId).Delete(&model.SysRecycle{}).Error; err != nil {
+		return errors.Wrap(err, "failed to delete recycle bin entry")
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seedRecycle() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 101, ParentId: 100, Ancestors: "0,100", DeptName: "Shenzhen"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 102, ParentId: 101, Ancestors: "0,100,101", DeptName: "R&D"})
+
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 101, UserName: "manager", Email: "manager@example.com", Password: "$2a$10$secret", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Common", RoleKey: "common", Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 3, RoleName: "Team Lead", RoleKey: "lead", Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "ceo", PostName: "CEO", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 1, MenuName: "System", MenuType: "M", Status: "0"})
+
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 2})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 3})
+	dal.Gorm.Create(&model.SysUserPost{UserId: 2, PostId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 3, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleDept{RoleId: 3, DeptId: 102})
+}
+
+func recycleIdsOf(entityType string) []int {
+	recycleIds := make([]int, 0)
+	dal.Gorm.Model(model.SysRecycle{}).Where("entity_type = ?", entityType).Order("recycle_id").Pluck("recycle_id", &recycleIds)
+	return recycleIds
+}
+
+func TestRecycleService_RestoreUser(t *testing.T) {
+	setup()
+	defer teardown()
+	seedRecycle()
+	s := NewRecycleService()
+
+	assert.NoError(t, (&UserService{}).DeleteUser([]int{2}, "admin"))
+
+	t.Run("should list the deleted user", func(t *testing.T) {
+		entries, total := s.GetRecycleList(dto.RecycleListRequest{
+			PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10},
+			EntityType:  constant.RECYCLE_ENTITY_USER,
+		}, true)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "manager", entries[0].EntityName)
+		assert.Equal(t, "admin", entries[0].DeleteBy)
+		assert.True(t, entries[0].PurgeTime.After(entries[0].DeleteTime.Time))
+	})
+
+	t.Run("should keep the password and personal data out of the snapshot", func(t *testing.T) {
+		var entry model.SysRecycle
+		dal.Gorm.Where("entity_type = ?", constant.RECYCLE_ENTITY_USER).Take(&entry)
+		assert.Contains(t, entry.Snapshot, "manager")
+		assert.NotContains(t, entry.Snapshot, "$2a$10$secret")
+		assert.NotContains(t, entry.Snapshot, "manager@example.com")
+	})
+
+	t.Run("should restore the user with its roles and posts", func(t *testing.T) {
+		assert.NoError(t, s.RestoreRecycle(recycleIdsOf(constant.RECYCLE_ENTITY_USER)))
+
+		assert.Equal(t, "manager", (&UserService{}).GetUserByUsername("manager").UserName)
+
+		var user model.SysUser
+		dal.Gorm.Where("user_id = ?", 2).Take(&user)
+		assert.Equal(t, "$2a$10$secret", user.Password)
+		assert.Equal(t, "manager@example.com", string(user.Email))
+
+		roleIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUserRole{}).Where("user_id = ?", 2).Pluck("role_id", &roleIds)
+		assert.ElementsMatch(t, []int{2, 3}, roleIds)
+
+		postIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUserPost{}).Where("user_id = ?", 2).Pluck("post_id", &postIds)
+		assert.Equal(t, []int{1}, postIds)
+
+		assert.Empty(t, recycleIdsOf(constant.RECYCLE_ENTITY_USER))
+	})
+}
+
+func TestRecycleService_RestoreConflict(t *testing.T) {
+	setup()
+	defer teardown()
+	seedRecycle()
+	s := NewRecycleService()
+
+	assert.NoError(t, (&UserService{}).DeleteUser([]int{2}, "admin"))
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 101, UserName: "manager", Status: "0"})
+
+	t.Run("should refuse to restore a user whose username was reused", func(t *testing.T) {
+		err := s.RestoreRecycle(recycleIdsOf(constant.RECYCLE_ENTITY_USER))
+		assert.ErrorIs(t, err, xerrors.ErrRecycleConflict)
+		assert.Contains(t, err.Error(), "user name manager")
+
+		var count int64
+		dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", 2).Count(&count)
+		assert.Equal(t, int64(0), count)
+		assert.Len(t, recycleIdsOf(constant.RECYCLE_ENTITY_USER), 1)
+	})
+
+	t.Run("should refuse to restore a dictionary type whose type was reused", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysDictType{DictId: 10, DictName: "Gender", DictType: "sys_user_sex"})
+		assert.NoError(t, (&DictTypeService{}).DeleteDictType([]int{10}, "admin"))
+		dal.Gorm.Create(&model.SysDictType{DictId: 11, DictName: "Sex", DictType: "sys_user_sex"})
+
+		err := s.RestoreRecycle(recycleIdsOf(constant.RECYCLE_ENTITY_DICT_TYPE))
+		assert.ErrorIs(t, err, xerrors.ErrRecycleConflict)
+	})
+}
+
+func TestRecycleService_RestoreRole(t *testing.T) {
+	setup()
+	defer teardown()
+	seedRecycle()
+	s := NewRecycleService()
+
+	assert.NoError(t, (&RoleService{}).DeleteRole([]int{3}, "admin"))
+
+	t.Run("should restore the role with its menus and departments", func(t *testing.T) {
+		assert.NoError(t, s.RestoreRecycle(recycleIdsOf(constant.RECYCLE_ENTITY_ROLE)))
+
+		role, err := (&RoleService{}).GetRoleByRoleKey("lead")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, role.RoleId)
+
+		var menus, depts int64
+		dal.Gorm.Model(model.SysRoleMenu{}).Where("role_id = ?", 3).Count(&menus)
+		dal.Gorm.Model(model.SysRoleDept{}).Where("role_id = ?", 3).Count(&depts)
+		assert.Equal(t, int64(1), menus)
+		assert.Equal(t, int64(1), depts)
+	})
+}
+
+func TestRecycleService_RestoreDept(t *testing.T) {
+	setup()
+	defer teardown()
+	seedRecycle()
+	s := NewRecycleService()
+
+	assert.NoError(t, (&DeptService{}).DeleteDept(102, "admin"))
+	assert.NoError(t, (&DeptService{}).DeleteDept(101, "admin"))
+	recycleIds := recycleIdsOf(constant.RECYCLE_ENTITY_DEPT)
+
+	t.Run("should refuse to restore a department whose parent is deleted", func(t *testing.T) {
+		err := s.RestoreRecycle(recycleIds[:1])
+		assert.Equal(t, xerrors.ErrRecycleParentMissing, err)
+	})
+
+	t.Run("should restore a parent before its children", func(t *testing.T) {
+		assert.NoError(t, s.RestoreRecycle(recycleIds))
+
+		dept := (&DeptService{}).GetDeptByDeptId(102)
+		assert.Equal(t, "R&D", dept.DeptName)
+		assert.Equal(t, "0,100,101", dept.Ancestors)
+	})
+}
+
+func TestRecycleService_RestoreDictData(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewRecycleService()
+
+	dal.Gorm.Create(&model.SysDictType{DictId: 10, DictName: "Gender", DictType: "sys_user_sex"})
+	dal.Gorm.Create(&model.SysDictData{DictCode: 20, DictLabel: "Male", DictValue: "0", DictType: "sys_user_sex"})
+	assert.NoError(t, (&DictDataService{}).DeleteDictData([]int{20}, "admin"))
+
+	t.Run("should recreate dictionary data from its snapshot", func(t *testing.T) {
+		assert.NoError(t, s.RestoreRecycle(recycleIdsOf(constant.RECYCLE_ENTITY_DICT_DATA)))
+
+		dictData := (&DictDataService{}).GetDictDataByDictCode(20)
+		assert.Equal(t, "Male", dictData.DictLabel)
+	})
+}
+
+func TestRecycleService_PurgeRecycle(t *testing.T) {
+	setup()
+	defer teardown()
+	seedRecycle()
+	s := NewRecycleService()
+
+	assert.NoError(t, (&PostService{}).DeletePost([]int{1}, "admin"))
+
+	t.Run("should permanently delete the post", func(t *testing.T) {
+		purged, err := s.PurgeRecycle(recycleIdsOf(constant.RECYCLE_ENTITY_POST))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		var count int64
+		dal.Gorm.Unscoped().Model(model.SysPost{}).Where("post_id = ?", 1).Count(&count)
+		assert.Equal(t, int64(0), count)
+		assert.Empty(t, recycleIdsOf(constant.RECYCLE_ENTITY_POST))
+	})
+}
+
+func TestRecycleService_PurgeExpiredRecycle(t *testing.T) {
+	setup()
+	defer teardown()
+	seedRecycle()
+	s := NewRecycleService()
+
+	assert.NoError(t, (&UserService{}).DeleteUser([]int{2}, "admin"))
+	assert.NoError(t, (&PostService{}).DeletePost([]int{1}, "admin"))
+
+	expired := time.Now().AddDate(0, 0, -(RECYCLE_DEFAULT_RETENTION_DAYS + 1))
+	dal.Gorm.Model(model.SysRecycle{}).Where("entity_type = ?", constant.RECYCLE_ENTITY_USER).Update("delete_time", expired)
+
+	t.Run("should purge only the entries past the retention period", func(t *testing.T) {
+		purged, err := s.PurgeExpiredRecycle()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		var count int64
+		dal.Gorm.Unscoped().Model(model.SysUser{}).Where("user_id = ?", 2).Count(&count)
+		assert.Equal(t, int64(0), count)
+		assert.Len(t, recycleIdsOf(constant.RECYCLE_ENTITY_POST), 1)
+
+		dal.Gorm.Model(model.SysOperLog{}).Where("method = ?", "RecycleService.PurgeExpiredRecycle").Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+}
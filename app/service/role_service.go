@@ -8,6 +8,10 @@ import (
 	"mira/app/dto"
 	"mira/app/model"
 	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+
+	"gorm.io/gorm"
 )
 
 // RoleServiceInterface defines the contract for role management operations
@@ -27,8 +31,9 @@ type RoleServiceInterface interface {
 
 	// DeleteRole removes roles by IDs with their associated permissions
 	// roleIds: IDs of roles to delete
+	// deleteBy: username of the operator, recorded in the recycle bin
 	// Returns error if operation fails
-	DeleteRole(roleIds []int) error
+	DeleteRole(roleIds []int, deleteBy string) error
 
 	// GetRoleList returns a list of roles based on filtering criteria
 	// param: filter criteria for roles
@@ -36,6 +41,9 @@ type RoleServiceInterface interface {
 	// Returns role list and total count
 	GetRoleList(param dto.RoleListRequest, isPaging bool) ([]dto.RoleListResponse, int)
 
+	// ExportDataset describes the export of the roles matching the filtering criteria
+	ExportDataset(param dto.RoleListRequest) ExportDataset
+
 	// GetRoleByRoleId retrieves detailed role information by role ID
 	// roleId: ID of the role to retrieve
 	// Returns role details
@@ -60,17 +68,17 @@ type RoleServiceInterface interface {
 
 	// GetRoleListByUserIdCompat is a backward compatibility method for DataScopeRoleServiceInterface
 	// userId: ID of the user
-	// Returns list of roles assigned to the user
+	// Returns list of roles the user holds directly or through user groups
 	GetRoleListByUserIdCompat(userId int) []dto.RoleListResponse
 
 	// GetRoleKeysByUserId returns role keys for a specific user
 	// userId: ID of the user
-	// Returns list of role keys assigned to the user
+	// Returns list of role keys the user holds directly or through user groups
 	GetRoleKeysByUserId(userId int) ([]string, error)
 
 	// GetRoleNamesByUserId returns role names for a specific user
 	// userId: ID of the user
-	// Returns list of role names assigned to the user
+	// Returns list of role names the user holds directly or through user groups
 	GetRoleNamesByUserId(userId int) ([]string, error)
 
 	// GetRoleByRoleName retrieves role details by role name
@@ -82,6 +90,13 @@ type RoleServiceInterface interface {
 	// roleKey: key of the role to retrieve
 	// Returns role details and error if not found
 	GetRoleByRoleKey(roleKey string) (dto.RoleDetailResponse, error)
+
+	// CheckRolesGrantable checks that an operator may grant roles without escalating privileges
+	// operatorId: ID of the user granting the roles
+	// roleIds: IDs of the roles to grant
+	// Returns xerrors.ErrDelegationRoleEscalation if a role carries a menu the operator does not hold,
+	// a data scope wider than the operator's, or custom departments outside the operator's reach
+	CheckRolesGrantable(operatorId int, roleIds []int) error
 }
 
 // RoleService implements RoleServiceInterface for role management
@@ -93,8 +108,22 @@ func NewRoleService() RoleServiceInterface {
 }
 
 // GetRoleListByUserIdCompat is a backward compatibility method for DataScopeRoleServiceInterface
+//
+// Unlike GetRoleListByUserId, which lists the roles assigned to the user for editing, it
+// includes the roles of the user groups and the default roles of the posts, since they
+// count towards the data scope.
 func (s *RoleService) GetRoleListByUserIdCompat(userId int) []dto.RoleListResponse {
-	roles, _ := s.GetRoleListByUserId(userId)
+	roles := make([]dto.RoleListResponse, 0)
+
+	if userId <= 0 {
+		return roles
+	}
+
+	dal.Gorm.Model(model.SysRole{}).Distinct("sys_role.*").
+		Joins("JOIN (?) AS user_roles ON sys_role.role_id = user_roles.role_id", userRoleQuery(userId)).
+		Where("sys_role.status = ?", constant.NORMAL_STATUS).
+		Find(&roles)
+
 	return roles
 }
 
@@ -142,6 +171,10 @@ func (s *RoleService) CreateRole(param dto.SaveRole, menuIds []int) error {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_ROLE, role.RoleId)
+
+	publishCacheIds(constant.CACHE_EVENT_ROLE, role.RoleId)
+
 	return nil
 }
 
@@ -217,11 +250,15 @@ func (s *RoleService) UpdateRole(param dto.SaveRole, menuIds, deptIds []int) err
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_ROLE, param.RoleId)
+
+	publishCacheIds(constant.CACHE_EVENT_ROLE, param.RoleId)
+
 	return nil
 }
 
-// DeleteRole removes roles by IDs with their associated permissions
-func (s *RoleService) DeleteRole(roleIds []int) error {
+// DeleteRole removes roles by IDs with their associated permissions, keeping them in the recycle bin
+func (s *RoleService) DeleteRole(roleIds []int, deleteBy string) error {
 	// Validate input parameters
 	if len(roleIds) == 0 {
 		return errors.New("role IDs cannot be empty")
@@ -229,6 +266,11 @@ func (s *RoleService) DeleteRole(roleIds []int) error {
 
 	tx := dal.Gorm.Begin()
 
+	if err := recycleRoles(tx, roleIds, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Model(model.SysRole{}).Where("role_id IN ?", roleIds).Delete(&model.SysRole{}).Error; err != nil {
 		tx.Rollback()
 		return errors.Wrap(err, "failed to delete roles")
@@ -244,10 +286,19 @@ func (s *RoleService) DeleteRole(roleIds []int) error {
 		return errors.Wrap(err, "failed to delete role departments")
 	}
 
+	if err := tx.Model(model.SysRoleGroup{}).Where("role_id IN ?", roleIds).Delete(&model.SysRoleGroup{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to delete role user groups")
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_ROLE, roleIds...)
+
+	publishCacheIds(constant.CACHE_EVENT_ROLE, roleIds...)
+
 	return nil
 }
 
@@ -256,33 +307,64 @@ func (s *RoleService) GetRoleList(param dto.RoleListRequest, isPaging bool) ([]d
 	var count int64
 	roles := make([]dto.RoleListResponse, 0)
 
-	query := dal.Gorm.Model(model.SysRole{}).Order("role_sort, role_id")
+	query := roleListQuery(param).Order("role_sort, role_id")
+
+	if isPaging {
+		query.Count(&count).Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&roles).Error; err != nil {
+		// Just continue execution, but error is not returned to caller
+	}
+
+	return roles, int(count)
+}
+
+// roleListQuery builds the role query with the search conditions applied
+func roleListQuery(param dto.RoleListRequest) *gorm.DB {
+	query := dal.Gorm.Model(model.SysRole{})
 
 	if param.RoleName != "" {
-		query.Where("role_name LIKE ?", "%"+param.RoleName+"%")
+		query = query.Where("role_name LIKE ?", "%"+param.RoleName+"%")
 	}
 
 	if param.RoleKey != "" {
-		query.Where("role_key LIKE ?", "%"+param.RoleKey+"%")
+		query = query.Where("role_key LIKE ?", "%"+param.RoleKey+"%")
 	}
 
 	if param.Status != "" {
-		query.Where("status = ?", param.Status)
+		query = query.Where("status = ?", param.Status)
 	}
 
 	if param.BeginTime != "" && param.EndTime != "" {
-		query = query.Where("sys_user.create_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
+		query = query.Where("create_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
 	}
 
-	if isPaging {
-		query.Count(&count).Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
-	}
+	return query
+}
 
-	if err := query.Find(&roles).Error; err != nil {
-		// Just continue execution, but error is not returned to caller
+// ExportDataset describes the export of the roles matching the search conditions
+func (s *RoleService) ExportDataset(param dto.RoleListRequest) ExportDataset {
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_ROLE,
+		Query: func() *gorm.DB {
+			return roleListQuery(param)
+		},
+		KeyField:  "role_id",
+		KeyColumn: "role_id",
+		Columns: []ExportColumn{
+			{Key: "roleId", Field: "role_id", Header: "Role ID", HeaderZh: "角色序号"},
+			{Key: "roleName", Field: "role_name", Header: "Role Name", HeaderZh: "角色名称"},
+			{Key: "roleKey", Field: "role_key", Header: "Role Permission", HeaderZh: "角色权限"},
+			{Key: "roleSort", Field: "role_sort", Header: "Role Sort", HeaderZh: "角色排序"},
+			{Key: "dataScope", Field: "data_scope", Header: "Data Scope", HeaderZh: "数据范围", Labels: map[string]string{
+				"1": "All Data Permissions", "2": "Custom Data Permissions", "3": "Department Data Permissions", "4": "Department and Below Data Permissions", "5": "Only Personal Data Permissions",
+			}},
+			{Key: "status", Field: "status", Header: "Role Status", HeaderZh: "角色状态", DictType: "sys_normal_disable", Labels: map[string]string{
+				"0": "Normal", "1": "Disabled",
+			}},
+		},
 	}
-
-	return roles, int(count)
 }
 
 // GetRoleByRoleId retrieves detailed role information by role ID
@@ -332,6 +414,8 @@ func (s *RoleService) AuthUserSelectAll(roleId int, userIds []int) error {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	publishCacheIds(constant.CACHE_EVENT_USER, userIds...)
+
 	return nil
 }
 
@@ -349,6 +433,8 @@ func (s *RoleService) AuthUserDelete(roleId int, userIds []int) error {
 		return errors.Wrapf(err, "failed to remove role ID %d from users", roleId)
 	}
 
+	publishCacheIds(constant.CACHE_EVENT_USER, userIds...)
+
 	return nil
 }
 
@@ -378,9 +464,9 @@ func (s *RoleService) GetRoleKeysByUserId(userId int) ([]string, error) {
 		return roleKeys, errors.New("invalid user ID")
 	}
 
-	if err := dal.Gorm.Model(model.SysRole{}).
-		Joins("JOIN sys_user_role ON sys_user_role.role_id = sys_role.role_id").
-		Where("sys_user_role.user_id = ? AND sys_role.status = ?", userId, constant.NORMAL_STATUS).
+	if err := dal.Gorm.Model(model.SysRole{}).Distinct().
+		Joins("JOIN (?) AS user_roles ON user_roles.role_id = sys_role.role_id", userRoleQuery(userId)).
+		Where("sys_role.status = ?", constant.NORMAL_STATUS).
 		Pluck("sys_role.role_key", &roleKeys).Error; err != nil {
 		return roleKeys, errors.Wrapf(err, "failed to fetch role keys for user ID %d", userId)
 	}
@@ -396,9 +482,9 @@ func (s *RoleService) GetRoleNamesByUserId(userId int) ([]string, error) {
 		return roleNames, errors.New("invalid user ID")
 	}
 
-	if err := dal.Gorm.Model(model.SysRole{}).
-		Joins("JOIN sys_user_role ON sys_user_role.role_id = sys_role.role_id").
-		Where("sys_user_role.user_id = ? AND sys_role.status = ?", userId, constant.NORMAL_STATUS).
+	if err := dal.Gorm.Model(model.SysRole{}).Distinct().
+		Joins("JOIN (?) AS user_roles ON user_roles.role_id = sys_role.role_id", userRoleQuery(userId)).
+		Where("sys_role.status = ?", constant.NORMAL_STATUS).
 		Pluck("sys_role.role_name", &roleNames).Error; err != nil {
 		return roleNames, errors.Wrapf(err, "failed to fetch role names for user ID %d", userId)
 	}
@@ -447,3 +533,147 @@ func (s *RoleService) GetRoleByRoleKey(roleKey string) (dto.RoleDetailResponse,
 
 	return role, nil
 }
+
+// CheckRolesGrantable checks that an operator may grant roles without escalating privileges:
+// the roles may only carry menus the operator holds and data scopes no wider than its own
+func (s *RoleService) CheckRolesGrantable(operatorId int, roleIds []int) error {
+	if operatorId == 1 || len(roleIds) == 0 {
+		return nil
+	}
+
+	if utils.Contains(roleIds, 1) {
+		return xerrors.ErrDelegationRoleEscalation
+	}
+
+	heldMenuIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysRoleMenu{}).
+		Joins("JOIN (?) AS user_roles ON user_roles.role_id = sys_role_menu.role_id", userRoleQuery(operatorId)).
+		Joins("JOIN sys_role ON sys_role.role_id = sys_role_menu.role_id AND sys_role.status = ? AND sys_role.delete_time IS NULL", constant.NORMAL_STATUS).
+		Distinct().
+		Pluck("sys_role_menu.menu_id", &heldMenuIds).Error; err != nil {
+		return errors.Wrapf(err, "failed to fetch menus for user ID %d", operatorId)
+	}
+
+	grantedMenuIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysRoleMenu{}).
+		Where("role_id IN ?", roleIds).
+		Distinct().
+		Pluck("menu_id", &grantedMenuIds).Error; err != nil {
+		return errors.Wrap(err, "failed to fetch menus of granted roles")
+	}
+
+	for _, menuId := range grantedMenuIds {
+		if !utils.Contains(heldMenuIds, menuId) {
+			return xerrors.ErrDelegationRoleEscalation
+		}
+	}
+
+	return s.checkDataScopesGrantable(operatorId, roleIds)
+}
+
+// dataScopeWidths orders the data scopes relative to the department of their holder,
+// from personal data only to all data. Custom data scopes are compared by department.
+var dataScopeWidths = map[string]int{
+	DATA_SCOPE_PERSONAL: 1,
+	DATA_SCOPE_DEPT:     2,
+	DATA_SCOPE_DEPT_SUB: 3,
+	DATA_SCOPE_ALL:      4,
+}
+
+// checkDataScopesGrantable checks that the data scopes of granted roles are no wider
+// than the widest data scope the operator holds, and that the departments of custom
+// data scopes lie within the departments delegated to the operator or visible to it
+func (s *RoleService) checkDataScopesGrantable(operatorId int, roleIds []int) error {
+	heldRoles := make([]model.SysRole, 0)
+	if err := dal.Gorm.Table("(?) AS user_roles", userRoleQuery(operatorId)).
+		Joins("JOIN sys_role ON sys_role.role_id = user_roles.role_id AND sys_role.status = ? AND sys_role.delete_time IS NULL", constant.NORMAL_STATUS).
+		Select("sys_role.role_id", "sys_role.data_scope").
+		Scan(&heldRoles).Error; err != nil {
+		return errors.Wrapf(err, "failed to fetch roles for user ID %d", operatorId)
+	}
+
+	heldWidth := dataScopeWidths[DATA_SCOPE_PERSONAL]
+	heldCustomRoleIds := make([]int, 0)
+	for _, role := range heldRoles {
+		if role.DataScope == DATA_SCOPE_CUSTOM {
+			heldCustomRoleIds = append(heldCustomRoleIds, role.RoleId)
+		} else if dataScopeWidths[role.DataScope] > heldWidth {
+			heldWidth = dataScopeWidths[role.DataScope]
+		}
+	}
+	if heldWidth == dataScopeWidths[DATA_SCOPE_ALL] {
+		return nil
+	}
+
+	grantedRoles := make([]model.SysRole, 0)
+	if err := dal.Gorm.Model(model.SysRole{}).
+		Select("role_id", "data_scope").
+		Where("role_id IN ?", roleIds).
+		Find(&grantedRoles).Error; err != nil {
+		return errors.Wrap(err, "failed to fetch data scopes of granted roles")
+	}
+
+	customRoleIds := make([]int, 0)
+	for _, role := range grantedRoles {
+		if role.DataScope == DATA_SCOPE_CUSTOM {
+			customRoleIds = append(customRoleIds, role.RoleId)
+		} else if dataScopeWidths[role.DataScope] > heldWidth {
+			return xerrors.ErrDelegationRoleEscalation
+		}
+	}
+	if len(customRoleIds) == 0 {
+		return nil
+	}
+
+	grantedDeptIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysRoleDept{}).
+		Where("role_id IN ?", customRoleIds).
+		Distinct().
+		Pluck("dept_id", &grantedDeptIds).Error; err != nil {
+		return errors.Wrap(err, "failed to fetch departments of granted roles")
+	}
+
+	scope, err := (&DelegationService{}).GetDelegationScope(operatorId)
+	if err != nil {
+		return err
+	}
+	allowedDeptIds := scope.DeptIds
+
+	if len(heldCustomRoleIds) > 0 {
+		heldDeptIds := make([]int, 0)
+		if err := dal.Gorm.Model(model.SysRoleDept{}).
+			Where("role_id IN ?", heldCustomRoleIds).
+			Pluck("dept_id", &heldDeptIds).Error; err != nil {
+			return errors.Wrapf(err, "failed to fetch departments for user ID %d", operatorId)
+		}
+		allowedDeptIds = append(allowedDeptIds, heldDeptIds...)
+	}
+
+	if heldWidth >= dataScopeWidths[DATA_SCOPE_DEPT] {
+		var operator model.SysUser
+		if err := dal.Gorm.Model(model.SysUser{}).Select("dept_id").Where("user_id = ?", operatorId).Take(&operator).Error; err != nil {
+			return errors.Wrapf(err, "failed to fetch department for user ID %d", operatorId)
+		}
+		allowedDeptIds = append(allowedDeptIds, operator.DeptId)
+
+		if heldWidth == dataScopeWidths[DATA_SCOPE_DEPT_SUB] {
+			depts := make([]model.SysDept, 0)
+			if err := dal.Gorm.Model(model.SysDept{}).Select("dept_id", "ancestors").Find(&depts).Error; err != nil {
+				return errors.Wrap(err, "failed to get departments")
+			}
+			for _, dept := range depts {
+				if inDeptSubtrees(dept.DeptId, dept.Ancestors, []int{operator.DeptId}) {
+					allowedDeptIds = append(allowedDeptIds, dept.DeptId)
+				}
+			}
+		}
+	}
+
+	for _, deptId := range grantedDeptIds {
+		if !utils.Contains(allowedDeptIds, deptId) {
+			return xerrors.ErrDelegationRoleEscalation
+		}
+	}
+
+	return nil
+}
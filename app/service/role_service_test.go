@@ -90,7 +90,7 @@ func TestRoleService_DeleteRole(t *testing.T) {
 		dal.Gorm.Create(&role)
 
 		// Execute
-		err := s.DeleteRole([]int{1})
+		err := s.DeleteRole([]int{1}, "admin")
 		assert.NoError(t, err)
 
 		// Verify
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"mira/anima/cipher"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Kinds of SCIM attributes, deciding how filter values are compared with the column
+const (
+	scimAttrString = iota
+	scimAttrId
+	scimAttrActive
+	scimAttrTime
+	// scimAttrEncrypted is a string encrypted at rest, only compared for equality once encrypted
+	scimAttrEncrypted
+)
+
+// scimAttribute maps a SCIM attribute onto the column it is filtered by
+type scimAttribute struct {
+	column string
+	kind   int
+}
+
+// scimUserAttributes are the filterable user attributes, keyed by lower-case path
+var scimUserAttributes = map[string]scimAttribute{
+	"id":                 {"user_id", scimAttrId},
+	"username":           {"user_name", scimAttrString},
+	"displayname":        {"nick_name", scimAttrString},
+	"name.formatted":     {"nick_name", scimAttrString},
+	"emails":             {"email", scimAttrEncrypted},
+	"emails.value":       {"email", scimAttrEncrypted},
+	"phonenumbers":       {"phonenumber", scimAttrEncrypted},
+	"phonenumbers.value": {"phonenumber", scimAttrEncrypted},
+	"active":             {"status", scimAttrActive},
+	"meta.created":       {"create_time", scimAttrTime},
+	"meta.lastmodified":  {"update_time", scimAttrTime},
+}
+
+// scimGroupAttributes are the filterable group attributes, keyed by lower-case path
+var scimGroupAttributes = map[string]scimAttribute{
+	"id":                {"role_id", scimAttrId},
+	"displayname":       {"role_name", scimAttrString},
+	"meta.created":      {"create_time", scimAttrTime},
+	"meta.lastmodified": {"update_time", scimAttrTime},
+}
+
+// scimComparisons are the SQL operators of the ordering comparisons
+var scimComparisons = map[string]string{
+	"eq": "=",
+	"ne": "<>",
+	"gt": ">",
+	"ge": ">=",
+	"lt": "<",
+	"le": "<=",
+}
+
+// Kinds of filter tokens
+const (
+	scimTokenWord = iota
+	scimTokenString
+	scimTokenLParen
+	scimTokenRParen
+	scimTokenLBracket
+	scimTokenRBracket
+	scimTokenEnd
+)
+
+type scimToken struct {
+	kind int
+	text string
+}
+
+// scimFilterParser compiles a SCIM filter (RFC 7644 section 3.4.2.2) into a SQL condition
+type scimFilterParser struct {
+	tokens     []scimToken
+	pos        int
+	schema     string
+	attributes map[string]scimAttribute
+}
+
+// scimFilterQuery applies the SCIM filter to the query. Attributes are looked up in attributes,
+// optionally prefixed with the schema URN; any other attribute makes the filter invalid.
+func scimFilterQuery(query *gorm.DB, filter, schema string, attributes map[string]scimAttribute) (*gorm.DB, error) {
+	if strings.TrimSpace(filter) == "" {
+		return query, nil
+	}
+
+	tokens, err := scimFilterTokens(filter)
+	if err != nil {
+		return query, err
+	}
+
+	parser := &scimFilterParser{tokens: tokens, schema: schema, attributes: attributes}
+
+	condition, args, err := parser.parseOr("")
+	if err != nil {
+		return query, err
+	}
+	if token := parser.peek(); token.kind != scimTokenEnd {
+		return query, errors.Wrapf(xerrors.ErrScimInvalidFilter, "unexpected %q", token.text)
+	}
+
+	return query.Where(condition, args...), nil
+}
+
+// scimFilterTokens splits the filter into words, quoted strings, parentheses and brackets
+func scimFilterTokens(filter string) ([]scimToken, error) {
+	tokens := make([]scimToken, 0)
+
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; c {
+		case ' ', '\t', '\n', '\r':
+			i++
+		case '(':
+			tokens = append(tokens, scimToken{scimTokenLParen, "("})
+			i++
+		case ')':
+			tokens = append(tokens, scimToken{scimTokenRParen, ")"})
+			i++
+		case '[':
+			tokens = append(tokens, scimToken{scimTokenLBracket, "["})
+			i++
+		case ']':
+			tokens = append(tokens, scimToken{scimTokenRBracket, "]"})
+			i++
+		case '"':
+			end := i + 1
+			for ; end < len(filter) && filter[end] != '"'; end++ {
+				if filter[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(filter) {
+				return nil, errors.Wrap(xerrors.ErrScimInvalidFilter, "unterminated string")
+			}
+
+			var text string
+			if err := json.Unmarshal([]byte(filter[i:end+1]), &text); err != nil {
+				return nil, errors.Wrapf(xerrors.ErrScimInvalidFilter, "malformed string %s", filter[i:end+1])
+			}
+
+			tokens = append(tokens, scimToken{scimTokenString, text})
+			i = end + 1
+		default:
+			length := strings.IndexAny(filter[i:], " \t\n\r()[]\"")
+			if length < 0 {
+				length = len(filter) - i
+			}
+
+			tokens = append(tokens, scimToken{scimTokenWord, filter[i : i+length]})
+			i += length
+		}
+	}
+
+	return append(tokens, scimToken{scimTokenEnd, "end of filter"}), nil
+}
+
+func (p *scimFilterParser) peek() scimToken {
+	return p.tokens[p.pos]
+}
+
+func (p *scimFilterParser) next() scimToken {
+	token := p.tokens[p.pos]
+	if token.kind != scimTokenEnd {
+		p.pos++
+	}
+	return token
+}
+
+// isKeyword reports whether the next token is the given logical operator
+func (p *scimFilterParser) isKeyword(keyword string) bool {
+	token := p.peek()
+	return token.kind == scimTokenWord && strings.EqualFold(token.text, keyword)
+}
+
+func (p *scimFilterParser) expect(kind int, text string) error {
+	if token := p.next(); token.kind != kind {
+		return errors.Wrapf(xerrors.ErrScimInvalidFilter, "expected %q but found %q", text, token.text)
+	}
+	return nil
+}
+
+// parseOr parses expressions joined by "or", which binds weaker than "and".
+// prefix is the parent attribute inside a value filter such as emails[type eq "work"].
+func (p *scimFilterParser) parseOr(prefix string) (string, []interface{}, error) {
+	condition, args, err := p.parseAnd(prefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for p.isKeyword("or") {
+		p.next()
+
+		right, rightArgs, err := p.parseAnd(prefix)
+		if err != nil {
+			return "", nil, err
+		}
+
+		condition = "(" + condition + " OR " + right + ")"
+		args = append(args, rightArgs...)
+	}
+
+	return condition, args, nil
+}
+
+// parseAnd parses expressions joined by "and"
+func (p *scimFilterParser) parseAnd(prefix string) (string, []interface{}, error) {
+	condition, args, err := p.parseUnary(prefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for p.isKeyword("and") {
+		p.next()
+
+		right, rightArgs, err := p.parseUnary(prefix)
+		if err != nil {
+			return "", nil, err
+		}
+
+		condition = "(" + condition + " AND " + right + ")"
+		args = append(args, rightArgs...)
+	}
+
+	return condition, args, nil
+}
+
+// parseUnary parses a negation, a parenthesized expression or an attribute expression
+func (p *scimFilterParser) parseUnary(prefix string) (string, []interface{}, error) {
+	negate := false
+	if p.isKeyword("not") {
+		p.next()
+		negate = true
+		if p.peek().kind != scimTokenLParen {
+			return "", nil, errors.Wrap(xerrors.ErrScimInvalidFilter, "expected \"(\" after not")
+		}
+	}
+
+	if p.peek().kind == scimTokenLParen {
+		p.next()
+
+		condition, args, err := p.parseOr(prefix)
+		if err != nil {
+			return "", nil, err
+		}
+		if err = p.expect(scimTokenRParen, ")"); err != nil {
+			return "", nil, err
+		}
+
+		if negate {
+			return "NOT (" + condition + ")", args, nil
+		}
+		return "(" + condition + ")", args, nil
+	}
+
+	return p.parseAttribute(prefix)
+}
+
+// parseAttribute parses attrPath op value, attrPath pr or attrPath[valFilter]
+func (p *scimFilterParser) parseAttribute(prefix string) (string, []interface{}, error) {
+	token := p.next()
+	if token.kind != scimTokenWord {
+		return "", nil, errors.Wrapf(xerrors.ErrScimInvalidFilter, "expected an attribute but found %q", token.text)
+	}
+	path := prefix + scimAttributePath(token.text, p.schema)
+
+	if p.peek().kind == scimTokenLBracket {
+		if prefix != "" {
+			return "", nil, errors.Wrap(xerrors.ErrScimInvalidFilter, "value filters cannot be nested")
+		}
+		p.next()
+
+		condition, args, err := p.parseOr(path + ".")
+		if err != nil {
+			return "", nil, err
+		}
+		if err = p.expect(scimTokenRBracket, "]"); err != nil {
+			return "", nil, err
+		}
+
+		return "(" + condition + ")", args, nil
+	}
+
+	attribute, ok := p.attributes[path]
+	if !ok {
+		return "", nil, errors.Wrapf(xerrors.ErrScimInvalidFilter, "unsupported attribute %s", token.text)
+	}
+
+	operator := p.next()
+	if operator.kind != scimTokenWord {
+		return "", nil, errors.Wrapf(xerrors.ErrScimInvalidFilter, "expected an operator but found %q", operator.text)
+	}
+	op := strings.ToLower(operator.text)
+
+	if op == "pr" {
+		return scimPresent(attribute), nil, nil
+	}
+
+	value := p.next()
+	switch value.kind {
+	case scimTokenString:
+		return scimCompare(attribute, op, value.text)
+	case scimTokenWord:
+		switch text := strings.ToLower(value.text); text {
+		case "null":
+			switch op {
+			case "eq":
+				return "NOT " + scimPresent(attribute), nil, nil
+			case "ne":
+				return scimPresent(attribute), nil, nil
+			}
+		case "true", "false":
+			return scimCompare(attribute, op, text == "true")
+		default:
+			if number, err := strconv.Atoi(value.text); err == nil {
+				return scimCompare(attribute, op, number)
+			}
+		}
+	}
+
+	return "", nil, errors.Wrapf(xerrors.ErrScimInvalidFilter, "invalid value %q for %s %s", value.text, token.text, operator.text)
+}
+
+// scimPresent returns the condition of the attribute having a value
+func scimPresent(attribute scimAttribute) string {
+	switch attribute.kind {
+	case scimAttrActive:
+		return "1 = 1"
+	case scimAttrString, scimAttrEncrypted:
+		return "(" + attribute.column + " IS NOT NULL AND " + attribute.column + " <> '')"
+	default:
+		return attribute.column + " IS NOT NULL"
+	}
+}
+
+// scimCompare returns the condition comparing the attribute with the value, which is a
+// string, bool or int as written in the filter
+func scimCompare(attribute scimAttribute, op string, value interface{}) (string, []interface{}, error) {
+	invalid := errors.Wrapf(xerrors.ErrScimInvalidFilter, "operator %s cannot compare %v", op, value)
+
+	switch attribute.kind {
+	case scimAttrActive:
+		active, ok := value.(bool)
+		if !ok || (op != "eq" && op != "ne") {
+			return "", nil, invalid
+		}
+		// active is stored as the normal status
+		if active == (op == "eq") {
+			return attribute.column + " = ?", []interface{}{constant.NORMAL_STATUS}, nil
+		}
+		return attribute.column + " <> ?", []interface{}{constant.NORMAL_STATUS}, nil
+
+	case scimAttrId:
+		sqlOp, ok := scimComparisons[op]
+		if !ok {
+			return "", nil, invalid
+		}
+
+		id, ok := value.(int)
+		if text, isText := value.(string); isText {
+			var err error
+			id, err = strconv.Atoi(text)
+			ok = err == nil
+		}
+		if !ok {
+			// A non-numeric ID never matches
+			if op == "ne" {
+				return "1 = 1", nil, nil
+			}
+			return "1 = 0", nil, nil
+		}
+		return attribute.column + " " + sqlOp + " ?", []interface{}{id}, nil
+
+	case scimAttrTime:
+		sqlOp, ok := scimComparisons[op]
+		text, isText := value.(string)
+		if !ok || !isText {
+			return "", nil, invalid
+		}
+
+		at, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return "", nil, errors.Wrapf(xerrors.ErrScimInvalidFilter, "invalid date time %q", text)
+		}
+		return attribute.column + " " + sqlOp + " ?", []interface{}{at.In(time.Local)}, nil
+
+	case scimAttrEncrypted:
+		if !cipher.Enabled() {
+			return scimCompare(scimAttribute{attribute.column, scimAttrString}, op, value)
+		}
+
+		text, isText := value.(string)
+		if !isText || (op != "eq" && op != "ne") {
+			return "", nil, invalid
+		}
+		condition, index := piiEquals(attribute.column, text)
+		if op == "ne" {
+			return "NOT (" + condition + ")", []interface{}{index}, nil
+		}
+		return condition, []interface{}{index}, nil
+
+	default:
+		text, isText := value.(string)
+		if !isText {
+			return "", nil, invalid
+		}
+
+		// ! escapes the LIKE wildcards, as the backslash differs between databases
+		escaped := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(text)
+
+		switch op {
+		case "co":
+			return attribute.column + " LIKE ? ESCAPE '!'", []interface{}{"%" + escaped + "%"}, nil
+		case "sw":
+			return attribute.column + " LIKE ? ESCAPE '!'", []interface{}{escaped + "%"}, nil
+		case "ew":
+			return attribute.column + " LIKE ? ESCAPE '!'", []interface{}{"%" + escaped}, nil
+		}
+
+		sqlOp, ok := scimComparisons[op]
+		if !ok {
+			return "", nil, invalid
+		}
+		return attribute.column + " " + sqlOp + " ?", []interface{}{text}, nil
+	}
+}
+
+// scimAttributePath returns the lower-case attribute path without the schema URN prefix
+func scimAttributePath(path, schema string) string {
+	path = strings.ToLower(strings.TrimSpace(path))
+	return strings.TrimPrefix(path, strings.ToLower(schema)+":")
+}
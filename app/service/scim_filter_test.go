@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScimFilterQuery(t *testing.T) {
+	setup()
+	defer teardown()
+
+	dal.Gorm.Create(&model.SysUser{UserId: 1, UserName: "admin", NickName: "Admin", Email: "admin@example.com", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, UserName: "alice_w", NickName: "Alice", Email: "alice@example.org", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, UserName: "bob", NickName: "Bob", Status: "1"})
+
+	tests := []struct {
+		name    string
+		filter  string
+		want    []int
+		wantErr bool
+		err     error
+	}{
+		{name: "empty filter", filter: "", want: []int{1, 2, 3}},
+		{name: "equal", filter: `userName eq "bob"`, want: []int{3}},
+		{name: "schema prefixed attribute", filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "bob"`, want: []int{3}},
+		{name: "case-insensitive operators", filter: `USERNAME EQ "bob"`, want: []int{3}},
+		{name: "not equal", filter: `userName ne "bob"`, want: []int{1, 2}},
+		{name: "contains escapes wildcards", filter: `userName co "_"`, want: []int{2}},
+		{name: "starts with", filter: `displayName sw "A"`, want: []int{1, 2}},
+		{name: "ends with", filter: `emails.value ew ".org"`, want: []int{2}},
+		{name: "value filter", filter: `emails[value ew ".com"]`, want: []int{1}},
+		{name: "present", filter: "emails pr", want: []int{1, 2}},
+		{name: "equal null", filter: "emails eq null", want: []int{3}},
+		{name: "active", filter: "active eq false", want: []int{3}},
+		{name: "numeric id", filter: `id gt "1"`, want: []int{2, 3}},
+		{name: "non-numeric id", filter: `id eq "abc"`, want: []int{}},
+		{name: "and binds before or", filter: `userName eq "bob" or userName eq "admin" and active eq false`, want: []int{3}},
+		{name: "parentheses", filter: `(userName eq "bob" or userName eq "admin") and active eq true`, want: []int{1}},
+		{name: "not", filter: `not (userName eq "bob")`, want: []int{1, 2}},
+		{name: "meta created", filter: `meta.created gt "2000-01-01T00:00:00Z"`, want: []int{1, 2, 3}},
+		{name: "unknown attribute", filter: `title eq "x"`, wantErr: true, err: xerrors.ErrScimInvalidFilter},
+		{name: "unknown sub-attribute", filter: `emails[type eq "work"]`, wantErr: true, err: xerrors.ErrScimInvalidFilter},
+		{name: "missing value", filter: "userName eq", wantErr: true, err: xerrors.ErrScimInvalidFilter},
+		{name: "unterminated string", filter: `userName eq "bob`, wantErr: true, err: xerrors.ErrScimInvalidFilter},
+		{name: "unbalanced parentheses", filter: `(userName eq "bob"`, wantErr: true, err: xerrors.ErrScimInvalidFilter},
+		{name: "trailing token", filter: `userName eq "bob" "x"`, wantErr: true, err: xerrors.ErrScimInvalidFilter},
+		{name: "active compared with a string", filter: `active eq "yes"`, wantErr: true, err: xerrors.ErrScimInvalidFilter},
+		{name: "invalid date time", filter: `meta.created gt "yesterday"`, wantErr: true, err: xerrors.ErrScimInvalidFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := scimFilterQuery(dal.Gorm.Model(model.SysUser{}), tt.filter, constant.SCIM_SCHEMA_USER, scimUserAttributes)
+			if tt.wantErr {
+				assert.Equal(t, tt.err, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+
+			userIds := make([]int, 0)
+			assert.NoError(t, query.Order("user_id").Pluck("user_id", &userIds).Error)
+			assert.Equal(t, tt.want, userIds)
+		})
+	}
+}
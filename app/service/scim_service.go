@@ -0,0 +1,1077 @@
+package service
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/app/validator"
+	"mira/common/password"
+	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// SCIM_DEFAULT_COUNT is the page size of a SCIM list that does not ask for a count
+const SCIM_DEFAULT_COUNT = 100
+
+// SCIM_MAX_COUNT caps the page size of a SCIM list
+const SCIM_MAX_COUNT = 1000
+
+// scimMemberFilter matches the member path of a group patch, e.g. members[value eq "2"]
+var scimMemberFilter = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+// ScimServiceInterface defines the SCIM 2.0 provisioning of users and groups
+type ScimServiceInterface interface {
+	GetUsers(param dto.ScimListRequest) (dto.ScimListResponse, error)
+	GetUser(id string) (dto.ScimUser, error)
+	CreateUser(user dto.ScimUser) (dto.ScimUser, error)
+	ReplaceUser(id string, user dto.ScimUser) (dto.ScimUser, error)
+	PatchUser(id string, patch dto.ScimPatchRequest) (dto.ScimUser, error)
+	DeprovisionUser(id string) error
+	GetGroups(param dto.ScimListRequest) (dto.ScimListResponse, error)
+	GetGroup(id string, excludeMembers bool) (dto.ScimGroup, error)
+	CreateGroup(group dto.ScimGroup) (dto.ScimGroup, error)
+	ReplaceGroup(id string, group dto.ScimGroup) (dto.ScimGroup, error)
+	PatchGroup(id string, patch dto.ScimPatchRequest) (dto.ScimGroup, error)
+	DeleteGroup(id string) error
+}
+
+// ScimService maps SCIM users onto sys_user and SCIM groups onto sys_role. Changes go through
+// UserService and RoleService with the same validation as the admin pages.
+//
+// sys_user keeps a single nick name, which both displayName and name map to; userName cannot
+// be changed and externalId is not stored.
+type ScimService struct {
+	userService *UserService
+	roleService *RoleService
+}
+
+// Ensure ScimService implements ScimServiceInterface
+var _ ScimServiceInterface = (*ScimService)(nil)
+
+// NewScimService creates a new SCIM service on top of the user and role services
+func NewScimService(userService *UserService, roleService *RoleService) *ScimService {
+	return &ScimService{
+		userService: userService,
+		roleService: roleService,
+	}
+}
+
+// GetUsers returns the page of users matching the SCIM filter
+func (s *ScimService) GetUsers(param dto.ScimListRequest) (dto.ScimListResponse, error) {
+	query, err := scimFilterQuery(dal.Gorm.Model(model.SysUser{}), param.Filter, constant.SCIM_SCHEMA_USER, scimUserAttributes)
+	if err != nil {
+		return dto.ScimListResponse{}, err
+	}
+
+	var count int64
+	if err = query.Count(&count).Error; err != nil {
+		return dto.ScimListResponse{}, errors.Wrap(err, "failed to count users")
+	}
+
+	startIndex, limit := scimPage(param)
+
+	users := make([]model.SysUser, 0)
+	if limit > 0 {
+		if err = query.Order("user_id").Offset(startIndex - 1).Limit(limit).Find(&users).Error; err != nil {
+			return dto.ScimListResponse{}, errors.Wrap(err, "failed to retrieve users")
+		}
+	}
+
+	resources, err := s.toScimUsers(users)
+	if err != nil {
+		return dto.ScimListResponse{}, err
+	}
+
+	return scimList(int(count), startIndex, resources, len(resources)), nil
+}
+
+// GetUser returns the user with the SCIM ID
+func (s *ScimService) GetUser(id string) (dto.ScimUser, error) {
+	user, err := scimLoadUser(id)
+	if err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	users, err := s.toScimUsers([]model.SysUser{user})
+	if err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	return users[0], nil
+}
+
+// CreateUser provisions a user. Without a password the user gets the initial password
+// of the sys.user.initPassword parameter.
+func (s *ScimService) CreateUser(user dto.ScimUser) (dto.ScimUser, error) {
+	plainPassword := user.Password
+	if plainPassword == "" {
+		plainPassword = (&ConfigService{}).GetString(USER_INIT_PASSWORD_CONFIG_KEY)
+	}
+
+	param := dto.CreateUserRequest{
+		UserName:    strings.TrimSpace(user.UserName),
+		NickName:    scimNickName(user),
+		Email:       scimPrimaryValue(user.Emails),
+		Phonenumber: scimPrimaryValue(user.PhoneNumbers),
+		Password:    plainPassword,
+		Status:      scimStatus(user.Active, constant.NORMAL_STATUS),
+	}
+
+	if err := validator.CreateUserValidator(param); err != nil {
+		return dto.ScimUser{}, errors.Wrap(xerrors.ErrScimInvalidValue, err.Error())
+	}
+
+	if err := s.checkUserUnique(0, param.UserName, param.Email, param.Phonenumber); err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	hashedPassword, err := password.Generate(param.Password)
+	if err != nil {
+		return dto.ScimUser{}, errors.Wrap(err, "failed to process password")
+	}
+
+	if err = s.userService.CreateUser(dto.SaveUser{
+		UserName:    param.UserName,
+		NickName:    param.NickName,
+		Email:       param.Email,
+		Phonenumber: param.Phonenumber,
+		Password:    hashedPassword,
+		Status:      param.Status,
+		CreateBy:    constant.SCIM_OPERATOR,
+	}, nil, nil); err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	return s.GetUser(strconv.Itoa(s.userService.GetUserByUsername(param.UserName).UserId))
+}
+
+// ReplaceUser replaces the attributes of the user with the SCIM ID. The user keeps its
+// status when active is left out.
+func (s *ScimService) ReplaceUser(id string, user dto.ScimUser) (dto.ScimUser, error) {
+	current, err := scimLoadUser(id)
+	if err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	return s.saveUser(current, user)
+}
+
+// PatchUser applies the patch operations to the user with the SCIM ID
+func (s *ScimService) PatchUser(id string, patch dto.ScimPatchRequest) (dto.ScimUser, error) {
+	current, err := scimLoadUser(id)
+	if err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	if len(patch.Operations) == 0 {
+		return dto.ScimUser{}, errors.Wrap(xerrors.ErrScimInvalidSyntax, "no patch operations")
+	}
+
+	user := scimUser(current, nil)
+	for _, operation := range patch.Operations {
+		if err = scimPatchUser(&user, operation); err != nil {
+			return dto.ScimUser{}, err
+		}
+	}
+
+	return s.saveUser(current, user)
+}
+
+// DeprovisionUser disables the user with the SCIM ID instead of deleting it
+func (s *ScimService) DeprovisionUser(id string) error {
+	current, err := scimLoadUser(id)
+	if err != nil {
+		return err
+	}
+	if current.UserId == 1 {
+		return errors.Wrap(xerrors.ErrScimMutability, "the super administrator cannot be provisioned")
+	}
+
+	return s.userService.UpdateUser(dto.SaveUser{
+		UserId:   current.UserId,
+		Status:   constant.EXCEPTION_STATUS,
+		UpdateBy: constant.SCIM_OPERATOR,
+	}, nil, nil)
+}
+
+// saveUser updates the stored user to the SCIM representation
+func (s *ScimService) saveUser(current model.SysUser, user dto.ScimUser) (dto.ScimUser, error) {
+	if current.UserId == 1 {
+		return dto.ScimUser{}, errors.Wrap(xerrors.ErrScimMutability, "the super administrator cannot be provisioned")
+	}
+
+	if userName := strings.TrimSpace(user.UserName); userName != "" && userName != current.UserName {
+		return dto.ScimUser{}, errors.Wrap(xerrors.ErrScimMutability, "userName")
+	}
+
+	param := dto.UpdateUserRequest{
+		UserId:      current.UserId,
+		NickName:    scimNickName(dto.ScimUser{UserName: current.UserName, DisplayName: user.DisplayName, Name: user.Name}),
+		Email:       scimPrimaryValue(user.Emails),
+		Phonenumber: scimPrimaryValue(user.PhoneNumbers),
+		Status:      scimStatus(user.Active, current.Status),
+	}
+
+	if err := validator.UpdateUserValidator(param); err != nil {
+		return dto.ScimUser{}, errors.Wrap(xerrors.ErrScimInvalidValue, err.Error())
+	}
+
+	if err := s.checkUserUnique(current.UserId, current.UserName, param.Email, param.Phonenumber); err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	var hashedPassword string
+	if user.Password != "" {
+		var err error
+		if hashedPassword, err = password.Generate(user.Password); err != nil {
+			return dto.ScimUser{}, errors.Wrap(err, "failed to process password")
+		}
+	}
+
+	if err := s.userService.UpdateUser(dto.SaveUser{
+		UserId:      current.UserId,
+		NickName:    param.NickName,
+		Email:       param.Email,
+		Phonenumber: param.Phonenumber,
+		Password:    hashedPassword,
+		Status:      param.Status,
+		UpdateBy:    constant.SCIM_OPERATOR,
+	}, nil, nil); err != nil {
+		return dto.ScimUser{}, err
+	}
+
+	// UpdateUser leaves empty fields alone, so removed emails and phone numbers are cleared here
+	cleared := make(map[string]interface{})
+	if param.Email == "" && current.Email != "" {
+		cleared["email"] = ""
+	}
+	if param.Phonenumber == "" && current.Phonenumber != "" {
+		cleared["phonenumber"] = ""
+	}
+	if len(cleared) > 0 {
+		if err := dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", current.UserId).Updates(cleared).Error; err != nil {
+			return dto.ScimUser{}, errors.Wrap(err, "failed to clear user attributes")
+		}
+	}
+
+	return s.GetUser(strconv.Itoa(current.UserId))
+}
+
+// checkUserUnique checks that no other user than userId has the user name, email or phone number
+func (s *ScimService) checkUserUnique(userId int, userName, email, phonenumber string) error {
+	if user := s.userService.GetUserByUsername(userName); user.UserId > 0 && user.UserId != userId {
+		return errors.Wrapf(xerrors.ErrScimUniqueness, "userName %s", userName)
+	}
+
+	if email != "" {
+		if user := s.userService.GetUserByEmail(email); user.UserId > 0 && user.UserId != userId {
+			return errors.Wrapf(xerrors.ErrScimUniqueness, "email %s", email)
+		}
+	}
+
+	if phonenumber != "" {
+		if user := s.userService.GetUserByPhonenumber(phonenumber); user.UserId > 0 && user.UserId != userId {
+			return errors.Wrapf(xerrors.ErrScimUniqueness, "phone number %s", phonenumber)
+		}
+	}
+
+	return nil
+}
+
+// toScimUsers converts the users with the roles they belong to as groups
+func (s *ScimService) toScimUsers(users []model.SysUser) ([]dto.ScimUser, error) {
+	userIds := make([]int, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.UserId)
+	}
+
+	groups := make(map[int][]dto.ScimMember, len(users))
+	if len(userIds) > 0 {
+		var links []struct {
+			UserId   int
+			RoleId   int
+			RoleName string
+		}
+		if err := dal.Gorm.Model(model.SysUserRole{}).
+			Select("sys_user_role.user_id, sys_role.role_id, sys_role.role_name").
+			Joins("JOIN sys_role ON sys_role.role_id = sys_user_role.role_id AND sys_role.delete_time IS NULL").
+			Where("sys_user_role.user_id IN ?", userIds).
+			Order("sys_role.role_id").
+			Scan(&links).Error; err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve user roles")
+		}
+
+		for _, link := range links {
+			groups[link.UserId] = append(groups[link.UserId], dto.ScimMember{
+				Value:   strconv.Itoa(link.RoleId),
+				Display: link.RoleName,
+				Ref:     constant.SCIM_BASE_PATH + "/Groups/" + strconv.Itoa(link.RoleId),
+			})
+		}
+	}
+
+	result := make([]dto.ScimUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, scimUser(user, groups[user.UserId]))
+	}
+
+	return result, nil
+}
+
+// GetGroups returns the page of groups matching the SCIM filter
+func (s *ScimService) GetGroups(param dto.ScimListRequest) (dto.ScimListResponse, error) {
+	query, err := scimFilterQuery(dal.Gorm.Model(model.SysRole{}), param.Filter, constant.SCIM_SCHEMA_GROUP, scimGroupAttributes)
+	if err != nil {
+		return dto.ScimListResponse{}, err
+	}
+
+	var count int64
+	if err = query.Count(&count).Error; err != nil {
+		return dto.ScimListResponse{}, errors.Wrap(err, "failed to count roles")
+	}
+
+	startIndex, limit := scimPage(param)
+
+	roles := make([]model.SysRole, 0)
+	if limit > 0 {
+		if err = query.Order("role_id").Offset(startIndex - 1).Limit(limit).Find(&roles).Error; err != nil {
+			return dto.ScimListResponse{}, errors.Wrap(err, "failed to retrieve roles")
+		}
+	}
+
+	resources, err := s.toScimGroups(roles, scimExcludesMembers(param.ExcludedAttributes))
+	if err != nil {
+		return dto.ScimListResponse{}, err
+	}
+
+	return scimList(int(count), startIndex, resources, len(resources)), nil
+}
+
+// GetGroup returns the group with the SCIM ID, without its members if excludeMembers is set
+func (s *ScimService) GetGroup(id string, excludeMembers bool) (dto.ScimGroup, error) {
+	role, err := scimLoadRole(id)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	groups, err := s.toScimGroups([]model.SysRole{role}, excludeMembers)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	return groups[0], nil
+}
+
+// CreateGroup provisions a role named after the group, with the group members assigned.
+// The role key is derived from the name and grants no menus until they are configured.
+func (s *ScimService) CreateGroup(group dto.ScimGroup) (dto.ScimGroup, error) {
+	param := dto.CreateRoleRequest{
+		RoleName: strings.TrimSpace(group.DisplayName),
+		RoleKey:  scimRoleKey(group.DisplayName),
+		Status:   constant.NORMAL_STATUS,
+	}
+
+	if err := validator.CreateRoleValidator(param); err != nil {
+		return dto.ScimGroup{}, errors.Wrap(xerrors.ErrScimInvalidValue, err.Error())
+	}
+
+	if err := s.checkRoleUnique(0, param.RoleName, param.RoleKey); err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	memberIds, err := scimMemberIds(group.Members)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	if err = s.roleService.CreateRole(dto.SaveRole{
+		RoleName: param.RoleName,
+		RoleKey:  param.RoleKey,
+		Status:   param.Status,
+		CreateBy: constant.SCIM_OPERATOR,
+	}, nil); err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	role, err := s.roleService.GetRoleByRoleName(param.RoleName)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	if err = s.setGroupMembers(role.RoleId, memberIds); err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	return s.GetGroup(strconv.Itoa(role.RoleId), false)
+}
+
+// ReplaceGroup renames the group with the SCIM ID and replaces its members
+func (s *ScimService) ReplaceGroup(id string, group dto.ScimGroup) (dto.ScimGroup, error) {
+	role, err := scimLoadRole(id)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	memberIds, err := scimMemberIds(group.Members)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	return s.saveGroup(role, group.DisplayName, memberIds)
+}
+
+// PatchGroup applies the patch operations to the group with the SCIM ID
+func (s *ScimService) PatchGroup(id string, patch dto.ScimPatchRequest) (dto.ScimGroup, error) {
+	role, err := scimLoadRole(id)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	if len(patch.Operations) == 0 {
+		return dto.ScimGroup{}, errors.Wrap(xerrors.ErrScimInvalidSyntax, "no patch operations")
+	}
+
+	memberIds, err := scimRoleMemberIds(role.RoleId)
+	if err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	roleName := role.RoleName
+	for _, operation := range patch.Operations {
+		if roleName, memberIds, err = scimPatchGroup(roleName, memberIds, operation); err != nil {
+			return dto.ScimGroup{}, err
+		}
+	}
+
+	return s.saveGroup(role, roleName, memberIds)
+}
+
+// DeleteGroup deletes the role of the group with the SCIM ID, keeping it in the recycle bin
+func (s *ScimService) DeleteGroup(id string) error {
+	role, err := scimLoadRole(id)
+	if err != nil {
+		return err
+	}
+
+	if err = validator.RemoveRoleValidator([]int{role.RoleId}, 0, ""); err != nil {
+		return errors.Wrap(xerrors.ErrScimMutability, err.Error())
+	}
+
+	return s.roleService.DeleteRole([]int{role.RoleId}, constant.SCIM_OPERATOR)
+}
+
+// saveGroup renames the role if needed and sets its members
+func (s *ScimService) saveGroup(role model.SysRole, roleName string, memberIds []int) (dto.ScimGroup, error) {
+	if role.RoleId == 1 {
+		return dto.ScimGroup{}, errors.Wrap(xerrors.ErrScimMutability, "the super administrator role cannot be provisioned")
+	}
+
+	if roleName = strings.TrimSpace(roleName); roleName != role.RoleName {
+		// The role key stays, as permissions may already refer to it
+		param := dto.UpdateRoleRequest{
+			RoleId:   role.RoleId,
+			RoleName: roleName,
+			RoleKey:  role.RoleKey,
+		}
+
+		if err := validator.UpdateRoleValidator(param); err != nil {
+			return dto.ScimGroup{}, errors.Wrap(xerrors.ErrScimInvalidValue, err.Error())
+		}
+
+		if err := s.checkRoleUnique(role.RoleId, param.RoleName, ""); err != nil {
+			return dto.ScimGroup{}, err
+		}
+
+		if err := s.roleService.UpdateRole(dto.SaveRole{
+			RoleId:   param.RoleId,
+			RoleName: param.RoleName,
+			RoleKey:  param.RoleKey,
+			UpdateBy: constant.SCIM_OPERATOR,
+		}, nil, nil); err != nil {
+			return dto.ScimGroup{}, err
+		}
+	}
+
+	if err := s.setGroupMembers(role.RoleId, memberIds); err != nil {
+		return dto.ScimGroup{}, err
+	}
+
+	return s.GetGroup(strconv.Itoa(role.RoleId), false)
+}
+
+// checkRoleUnique checks that no other role than roleId has the role name or key
+func (s *ScimService) checkRoleUnique(roleId int, roleName, roleKey string) error {
+	if role, err := s.roleService.GetRoleByRoleName(roleName); err == nil && role.RoleId > 0 && role.RoleId != roleId {
+		return errors.Wrapf(xerrors.ErrScimUniqueness, "displayName %s", roleName)
+	}
+
+	if roleKey != "" {
+		if role, err := s.roleService.GetRoleByRoleKey(roleKey); err == nil && role.RoleId > 0 && role.RoleId != roleId {
+			return errors.Wrapf(xerrors.ErrScimUniqueness, "role key %s", roleKey)
+		}
+	}
+
+	return nil
+}
+
+// setGroupMembers assigns the role to exactly the given users
+func (s *ScimService) setGroupMembers(roleId int, memberIds []int) error {
+	var count int64
+	if len(memberIds) > 0 {
+		if err := dal.Gorm.Model(model.SysUser{}).Where("user_id IN ?", memberIds).Count(&count).Error; err != nil {
+			return errors.Wrap(err, "failed to check group members")
+		}
+	}
+	if int(count) != len(memberIds) {
+		return errors.Wrap(xerrors.ErrScimInvalidValue, "members refer to a user that does not exist")
+	}
+
+	currentIds, err := scimRoleMemberIds(roleId)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[int]bool, len(memberIds))
+	for _, userId := range memberIds {
+		wanted[userId] = true
+	}
+
+	removed := make([]int, 0)
+	for _, userId := range currentIds {
+		if wanted[userId] {
+			delete(wanted, userId)
+			continue
+		}
+		removed = append(removed, userId)
+	}
+
+	added := make([]int, 0, len(wanted))
+	for userId := range wanted {
+		added = append(added, userId)
+	}
+	sort.Ints(added)
+
+	if len(added) > 0 {
+		if err = s.roleService.AuthUserSelectAll(roleId, added); err != nil {
+			return err
+		}
+	}
+
+	if len(removed) > 0 {
+		if err = s.roleService.AuthUserDelete(roleId, removed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toScimGroups converts the roles with their users as members
+func (s *ScimService) toScimGroups(roles []model.SysRole, excludeMembers bool) ([]dto.ScimGroup, error) {
+	members := make(map[int][]dto.ScimMember, len(roles))
+
+	if !excludeMembers && len(roles) > 0 {
+		roleIds := make([]int, 0, len(roles))
+		for _, role := range roles {
+			roleIds = append(roleIds, role.RoleId)
+		}
+
+		var links []struct {
+			RoleId   int
+			UserId   int
+			UserName string
+		}
+		if err := dal.Gorm.Model(model.SysUserRole{}).
+			Select("sys_user_role.role_id, sys_user.user_id, sys_user.user_name").
+			Joins("JOIN sys_user ON sys_user.user_id = sys_user_role.user_id AND sys_user.delete_time IS NULL").
+			Where("sys_user_role.role_id IN ?", roleIds).
+			Order("sys_user.user_id").
+			Scan(&links).Error; err != nil {
+			return nil, errors.Wrap(err, "failed to retrieve role members")
+		}
+
+		for _, link := range links {
+			members[link.RoleId] = append(members[link.RoleId], dto.ScimMember{
+				Value:   strconv.Itoa(link.UserId),
+				Display: link.UserName,
+				Ref:     constant.SCIM_BASE_PATH + "/Users/" + strconv.Itoa(link.UserId),
+			})
+		}
+	}
+
+	result := make([]dto.ScimGroup, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, dto.ScimGroup{
+			Schemas:     []string{constant.SCIM_SCHEMA_GROUP},
+			Id:          strconv.Itoa(role.RoleId),
+			DisplayName: role.RoleName,
+			Members:     members[role.RoleId],
+			Meta:        scimMeta("Group", "/Groups/"+strconv.Itoa(role.RoleId), role.CreateTime, role.UpdateTime),
+		})
+	}
+
+	return result, nil
+}
+
+// scimPatchUser applies a patch operation to the SCIM representation of a user
+func scimPatchUser(user *dto.ScimUser, operation dto.ScimPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return errors.Wrapf(xerrors.ErrScimInvalidSyntax, "unsupported patch operation %q", operation.Op)
+	}
+
+	path := scimValuePath(scimAttributePath(operation.Path, constant.SCIM_SCHEMA_USER))
+
+	if op == "remove" {
+		switch path {
+		case "":
+			return errors.Wrap(xerrors.ErrScimInvalidPath, "remove requires a path")
+		case "displayname", "name", "name.formatted", "name.givenname", "name.familyname":
+			user.DisplayName, user.Name = "", nil
+		case "emails", "emails.value":
+			user.Emails = nil
+		case "phonenumbers", "phonenumbers.value":
+			user.PhoneNumbers = nil
+		case "externalid":
+		case "username", "password", "active":
+			return errors.Wrap(xerrors.ErrScimMutability, operation.Path)
+		default:
+			return errors.Wrap(xerrors.ErrScimInvalidPath, operation.Path)
+		}
+		return nil
+	}
+
+	if path == "" {
+		// Without a path the value holds the attributes to set
+		values := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(operation.Value, &values); err != nil {
+			return errors.Wrap(xerrors.ErrScimInvalidValue, "patch value must be an object")
+		}
+
+		for key, value := range values {
+			if err := scimSetUserAttribute(user, scimValuePath(scimAttributePath(key, constant.SCIM_SCHEMA_USER)), key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return scimSetUserAttribute(user, path, operation.Path, operation.Value)
+}
+
+// scimSetUserAttribute sets the attribute at the lower-case path to the JSON value
+func scimSetUserAttribute(user *dto.ScimUser, path, rawPath string, value json.RawMessage) error {
+	var err error
+
+	switch path {
+	case "username":
+		err = scimDecode(value, &user.UserName)
+	case "displayname":
+		err = scimDecode(value, &user.DisplayName)
+	case "name":
+		var name dto.ScimName
+		if err = scimDecode(value, &name); err == nil {
+			user.Name = &name
+			user.DisplayName = scimFormattedName(name)
+		}
+	case "name.formatted", "name.givenname", "name.familyname":
+		var text string
+		if err = scimDecode(value, &text); err == nil {
+			if user.Name == nil {
+				user.Name = &dto.ScimName{}
+			}
+
+			switch path {
+			case "name.formatted":
+				user.Name.Formatted = text
+			case "name.givenname":
+				user.Name.GivenName, user.Name.Formatted = text, ""
+			default:
+				user.Name.FamilyName, user.Name.Formatted = text, ""
+			}
+			user.DisplayName = scimFormattedName(*user.Name)
+		}
+	case "emails", "phonenumbers":
+		var values []dto.ScimMultiValue
+		if err = scimDecode(value, &values); err == nil {
+			if path == "emails" {
+				user.Emails = values
+			} else {
+				user.PhoneNumbers = values
+			}
+		}
+	case "emails.value", "phonenumbers.value":
+		var text string
+		if err = scimDecode(value, &text); err == nil {
+			values := []dto.ScimMultiValue{{Value: text, Type: "work", Primary: true}}
+			if path == "emails.value" {
+				user.Emails = values
+			} else {
+				user.PhoneNumbers = values
+			}
+		}
+	case "active":
+		var active bool
+		if active, err = scimBool(value); err == nil {
+			user.Active = &active
+		}
+	case "password":
+		err = scimDecode(value, &user.Password)
+	case "externalid", "schemas", "id", "meta":
+		// Not stored, or read-only
+	default:
+		return errors.Wrap(xerrors.ErrScimInvalidPath, rawPath)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, rawPath)
+	}
+	return nil
+}
+
+// scimPatchGroup applies a patch operation to the role name and member IDs of a group
+func scimPatchGroup(roleName string, memberIds []int, operation dto.ScimPatchOperation) (string, []int, error) {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return roleName, memberIds, errors.Wrapf(xerrors.ErrScimInvalidSyntax, "unsupported patch operation %q", operation.Op)
+	}
+
+	path := scimAttributePath(operation.Path, constant.SCIM_SCHEMA_GROUP)
+
+	if match := scimMemberFilter.FindStringSubmatch(path); match != nil {
+		if op != "remove" {
+			return roleName, memberIds, errors.Wrap(xerrors.ErrScimInvalidPath, operation.Path)
+		}
+		userId, err := strconv.Atoi(match[1])
+		if err != nil {
+			return roleName, memberIds, nil
+		}
+		return roleName, scimRemoveIds(memberIds, []int{userId}), nil
+	}
+
+	if path == "" {
+		if op == "remove" {
+			return roleName, memberIds, errors.Wrap(xerrors.ErrScimInvalidPath, "remove requires a path")
+		}
+
+		// Without a path the value holds the attributes to set
+		values := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(operation.Value, &values); err != nil {
+			return roleName, memberIds, errors.Wrap(xerrors.ErrScimInvalidValue, "patch value must be an object")
+		}
+
+		for key, value := range values {
+			var err error
+			if roleName, memberIds, err = scimPatchGroup(roleName, memberIds, dto.ScimPatchOperation{Op: op, Path: key, Value: value}); err != nil {
+				return roleName, memberIds, err
+			}
+		}
+		return roleName, memberIds, nil
+	}
+
+	switch path {
+	case "displayname":
+		if op == "remove" {
+			return roleName, memberIds, errors.Wrap(xerrors.ErrScimMutability, operation.Path)
+		}
+		if err := scimDecode(operation.Value, &roleName); err != nil {
+			return roleName, memberIds, errors.Wrap(err, operation.Path)
+		}
+		return roleName, memberIds, nil
+
+	case "members":
+		var members []dto.ScimMember
+		if len(operation.Value) > 0 && string(operation.Value) != "null" {
+			if err := scimDecode(operation.Value, &members); err != nil {
+				return roleName, memberIds, errors.Wrap(err, operation.Path)
+			}
+		}
+
+		ids, err := scimMemberIds(members)
+		if err != nil {
+			return roleName, memberIds, err
+		}
+
+		switch op {
+		case "add":
+			return roleName, append(scimRemoveIds(memberIds, ids), ids...), nil
+		case "replace":
+			return roleName, ids, nil
+		default:
+			// Removing members without a value removes all of them
+			if len(members) == 0 {
+				return roleName, []int{}, nil
+			}
+			return roleName, scimRemoveIds(memberIds, ids), nil
+		}
+
+	case "externalid", "schemas", "id", "meta":
+		// Not stored, or read-only
+		return roleName, memberIds, nil
+	}
+
+	return roleName, memberIds, errors.Wrap(xerrors.ErrScimInvalidPath, operation.Path)
+}
+
+// scimLoadUser returns the user with the SCIM ID
+func scimLoadUser(id string) (model.SysUser, error) {
+	var user model.SysUser
+
+	userId, err := strconv.Atoi(id)
+	if err != nil || userId <= 0 {
+		return user, errors.Wrapf(xerrors.ErrScimNotFound, "user %s", id)
+	}
+
+	if err = dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", userId).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, errors.Wrapf(xerrors.ErrScimNotFound, "user %s", id)
+		}
+		return user, errors.Wrapf(err, "failed to get user by ID %d", userId)
+	}
+
+	return user, nil
+}
+
+// scimLoadRole returns the role with the SCIM ID
+func scimLoadRole(id string) (model.SysRole, error) {
+	var role model.SysRole
+
+	roleId, err := strconv.Atoi(id)
+	if err != nil || roleId <= 0 {
+		return role, errors.Wrapf(xerrors.ErrScimNotFound, "group %s", id)
+	}
+
+	if err = dal.Gorm.Model(model.SysRole{}).Where("role_id = ?", roleId).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return role, errors.Wrapf(xerrors.ErrScimNotFound, "group %s", id)
+		}
+		return role, errors.Wrapf(err, "failed to get role by ID %d", roleId)
+	}
+
+	return role, nil
+}
+
+// scimRoleMemberIds returns the IDs of the users assigned the role
+func scimRoleMemberIds(roleId int) ([]int, error) {
+	userIds := make([]int, 0)
+
+	if err := dal.Gorm.Model(model.SysUserRole{}).Where("role_id = ?", roleId).Order("user_id").Pluck("user_id", &userIds).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to retrieve members of role ID %d", roleId)
+	}
+
+	return userIds, nil
+}
+
+// scimUser converts a user to its SCIM representation
+func scimUser(user model.SysUser, groups []dto.ScimMember) dto.ScimUser {
+	active := user.Status == constant.NORMAL_STATUS
+
+	result := dto.ScimUser{
+		Schemas:     []string{constant.SCIM_SCHEMA_USER},
+		Id:          strconv.Itoa(user.UserId),
+		UserName:    user.UserName,
+		Name:        &dto.ScimName{Formatted: user.NickName},
+		DisplayName: user.NickName,
+		Active:      &active,
+		Groups:      groups,
+		Meta:        scimMeta("User", "/Users/"+strconv.Itoa(user.UserId), user.CreateTime, user.UpdateTime),
+	}
+
+	if user.Email != "" {
+		result.Emails = []dto.ScimMultiValue{{Value: string(user.Email), Type: "work", Primary: true}}
+	}
+	if user.Phonenumber != "" {
+		result.PhoneNumbers = []dto.ScimMultiValue{{Value: string(user.Phonenumber), Type: "work", Primary: true}}
+	}
+
+	return result
+}
+
+// scimMeta returns the metadata of a resource at the path under the SCIM base path
+func scimMeta(resourceType, path string, created, lastModified datetime.Datetime) *dto.ScimMeta {
+	meta := &dto.ScimMeta{
+		ResourceType: resourceType,
+		Location:     constant.SCIM_BASE_PATH + path,
+	}
+
+	if !created.IsZero() {
+		meta.Created = created.Format(time.RFC3339)
+	}
+	if !lastModified.IsZero() {
+		meta.LastModified = lastModified.Format(time.RFC3339)
+	}
+
+	return meta
+}
+
+// scimList returns a list response of a page of resources
+func scimList(total, startIndex int, resources interface{}, itemsPerPage int) dto.ScimListResponse {
+	return dto.ScimListResponse{
+		Schemas:      []string{constant.SCIM_MESSAGE_LIST_RESPONSE},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	}
+}
+
+// scimPage returns the 1-based start index and the page size of a list request
+func scimPage(param dto.ScimListRequest) (int, int) {
+	startIndex := param.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+
+	count := SCIM_DEFAULT_COUNT
+	if param.Count != nil {
+		count = *param.Count
+	}
+	if count < 0 {
+		count = 0
+	}
+	if count > SCIM_MAX_COUNT {
+		count = SCIM_MAX_COUNT
+	}
+
+	return startIndex, count
+}
+
+// scimExcludesMembers reports whether the excluded attributes of a request name the members
+func scimExcludesMembers(excludedAttributes string) bool {
+	for _, attribute := range strings.Split(excludedAttributes, ",") {
+		if scimAttributePath(attribute, constant.SCIM_SCHEMA_GROUP) == "members" {
+			return true
+		}
+	}
+	return false
+}
+
+// scimNickName returns the nick name of a user: the display name, else the name, else the user name
+func scimNickName(user dto.ScimUser) string {
+	if nickName := strings.TrimSpace(user.DisplayName); nickName != "" {
+		return nickName
+	}
+	if user.Name != nil {
+		if nickName := scimFormattedName(*user.Name); nickName != "" {
+			return nickName
+		}
+	}
+	return strings.TrimSpace(user.UserName)
+}
+
+// scimFormattedName returns the formatted name, or the given and family name joined
+func scimFormattedName(name dto.ScimName) string {
+	if formatted := strings.TrimSpace(name.Formatted); formatted != "" {
+		return formatted
+	}
+	return strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+}
+
+// scimPrimaryValue returns the primary value of a multi-valued attribute, else the first one
+func scimPrimaryValue(values []dto.ScimMultiValue) string {
+	for _, value := range values {
+		if value.Primary {
+			return strings.TrimSpace(value.Value)
+		}
+	}
+	if len(values) > 0 {
+		return strings.TrimSpace(values[0].Value)
+	}
+	return ""
+}
+
+// scimStatus returns the user status for active, or the fallback when it is not given
+func scimStatus(active *bool, fallback string) string {
+	switch {
+	case active == nil:
+		return fallback
+	case *active:
+		return constant.NORMAL_STATUS
+	default:
+		return constant.EXCEPTION_STATUS
+	}
+}
+
+// scimRoleKey derives the role key of a group from its name
+func scimRoleKey(displayName string) string {
+	words := strings.Fields(strings.ToLower(displayName))
+	if len(words) == 0 {
+		return ""
+	}
+	return constant.SCIM_OPERATOR + "_" + strings.Join(words, "_")
+}
+
+// scimMemberIds returns the user IDs of the members
+func scimMemberIds(members []dto.ScimMember) ([]int, error) {
+	userIds := make([]int, 0, len(members))
+
+	for _, member := range members {
+		userId, err := strconv.Atoi(member.Value)
+		if err != nil || userId <= 0 {
+			return nil, errors.Wrapf(xerrors.ErrScimInvalidValue, "member %q", member.Value)
+		}
+		if !utils.Contains(userIds, userId) {
+			userIds = append(userIds, userId)
+		}
+	}
+
+	return userIds, nil
+}
+
+// scimRemoveIds returns the IDs without the removed ones
+func scimRemoveIds(ids, removed []int) []int {
+	result := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !utils.Contains(removed, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// scimValuePath drops the value filter of a path, so emails[type eq "work"].value becomes emails.value
+func scimValuePath(path string) string {
+	start := strings.Index(path, "[")
+	end := strings.LastIndex(path, "]")
+	if start < 0 || end < start {
+		return path
+	}
+	return path[:start] + path[end+1:]
+}
+
+// scimDecode decodes a JSON patch value
+func scimDecode(value json.RawMessage, target interface{}) error {
+	if err := json.Unmarshal(value, target); err != nil {
+		return xerrors.ErrScimInvalidValue
+	}
+	return nil
+}
+
+// scimBool decodes a boolean patch value, which some identity providers send as a string
+func scimBool(value json.RawMessage) (bool, error) {
+	var result bool
+	if err := json.Unmarshal(value, &result); err == nil {
+		return result, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err == nil {
+		if result, err = strconv.ParseBool(strings.ToLower(text)); err == nil {
+			return result, nil
+		}
+	}
+
+	return false, xerrors.ErrScimInvalidValue
+}
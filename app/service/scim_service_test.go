@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func seedScim() {
+	dal.Gorm.Create(&model.SysConfig{ConfigId: 1, ConfigKey: "sys.user.initPassword", ConfigValue: "123456"})
+	dal.Gorm.Create(&model.SysUser{UserId: 1, UserName: "admin", NickName: "Admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, UserName: "alice", NickName: "Alice", Email: "alice@example.com", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, UserName: "bob", NickName: "Bob", Phonenumber: "13800000000", Status: "1"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 1, RoleName: "Super Admin", RoleKey: "admin"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Engineering", RoleKey: "engineering"})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 2})
+}
+
+func TestScimService_GetUsers(t *testing.T) {
+	setup()
+	defer teardown()
+	seedScim()
+	s := NewScimService(&UserService{}, &RoleService{})
+
+	t.Run("should filter users and include their groups", func(t *testing.T) {
+		list, err := s.GetUsers(dto.ScimListRequest{Filter: `userName eq "alice"`})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, list.TotalResults)
+
+		users := list.Resources.([]dto.ScimUser)
+		assert.Len(t, users, 1)
+		assert.Equal(t, "2", users[0].Id)
+		assert.Equal(t, "alice@example.com", users[0].Emails[0].Value)
+		assert.True(t, *users[0].Active)
+		assert.Equal(t, []dto.ScimMember{{Value: "2", Display: "Engineering", Ref: "/scim/v2/Groups/2"}}, users[0].Groups)
+		assert.Equal(t, "/scim/v2/Users/2", users[0].Meta.Location)
+	})
+
+	t.Run("should page with a 1-based start index", func(t *testing.T) {
+		count := 1
+		list, err := s.GetUsers(dto.ScimListRequest{Filter: "active eq true or active eq false", StartIndex: 2, Count: &count})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, list.TotalResults)
+		assert.Equal(t, 2, list.StartIndex)
+		assert.Equal(t, 1, list.ItemsPerPage)
+		assert.Equal(t, "alice", list.Resources.([]dto.ScimUser)[0].UserName)
+	})
+
+	t.Run("should reject an unsupported filter", func(t *testing.T) {
+		_, err := s.GetUsers(dto.ScimListRequest{Filter: `title eq "x"`})
+		assert.Equal(t, xerrors.ErrScimInvalidFilter, errors.Cause(err))
+	})
+}
+
+func TestScimService_CreateUser(t *testing.T) {
+	setup()
+	defer teardown()
+	seedScim()
+	s := NewScimService(&UserService{}, &RoleService{})
+
+	t.Run("should create the user with the initial password", func(t *testing.T) {
+		user, err := s.CreateUser(dto.ScimUser{
+			UserName: "carol",
+			Name:     &dto.ScimName{GivenName: "Carol", FamilyName: "Chen"},
+			Emails:   []dto.ScimMultiValue{{Value: "other@example.com"}, {Value: "carol@example.com", Primary: true}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Carol Chen", user.DisplayName)
+
+		var stored model.SysUser
+		dal.Gorm.First(&stored, "user_name = ?", "carol")
+		assert.Equal(t, "carol@example.com", string(stored.Email))
+		assert.Equal(t, constant.NORMAL_STATUS, stored.Status)
+		assert.Equal(t, constant.SCIM_OPERATOR, stored.CreateBy)
+		assert.NotEmpty(t, stored.Password)
+	})
+
+	t.Run("should reject a taken user name or email", func(t *testing.T) {
+		_, err := s.CreateUser(dto.ScimUser{UserName: "alice"})
+		assert.Equal(t, xerrors.ErrScimUniqueness, errors.Cause(err))
+
+		_, err = s.CreateUser(dto.ScimUser{UserName: "dave", Emails: []dto.ScimMultiValue{{Value: "alice@example.com"}}})
+		assert.Equal(t, xerrors.ErrScimUniqueness, errors.Cause(err))
+	})
+
+	t.Run("should validate like the user page", func(t *testing.T) {
+		_, err := s.CreateUser(dto.ScimUser{UserName: "dave", Emails: []dto.ScimMultiValue{{Value: "not-an-email"}}})
+		assert.Equal(t, xerrors.ErrScimInvalidValue, errors.Cause(err))
+		assert.Contains(t, err.Error(), xerrors.ErrUserEmailFormat.Error())
+	})
+}
+
+func TestScimService_PatchUser(t *testing.T) {
+	setup()
+	defer teardown()
+	seedScim()
+	s := NewScimService(&UserService{}, &RoleService{})
+
+	patch := func(operations ...dto.ScimPatchOperation) dto.ScimPatchRequest {
+		return dto.ScimPatchRequest{Schemas: []string{constant.SCIM_MESSAGE_PATCH_OP}, Operations: operations}
+	}
+
+	t.Run("should apply operations with and without a path", func(t *testing.T) {
+		user, err := s.PatchUser("3", patch(
+			dto.ScimPatchOperation{Op: "Replace", Path: "active", Value: json.RawMessage(`"True"`)},
+			dto.ScimPatchOperation{Op: "replace", Value: json.RawMessage(`{"displayName":"Robert","emails[type eq \"work\"].value":"bob@example.com"}`)},
+		))
+		assert.NoError(t, err)
+		assert.True(t, *user.Active)
+		assert.Equal(t, "Robert", user.DisplayName)
+		assert.Equal(t, "bob@example.com", user.Emails[0].Value)
+		assert.Equal(t, "13800000000", user.PhoneNumbers[0].Value)
+	})
+
+	t.Run("should clear a removed phone number", func(t *testing.T) {
+		user, err := s.PatchUser("3", patch(dto.ScimPatchOperation{Op: "remove", Path: "phoneNumbers[type eq \"work\"].value"}))
+		assert.NoError(t, err)
+		assert.Empty(t, user.PhoneNumbers)
+
+		var stored model.SysUser
+		dal.Gorm.First(&stored, 3)
+		assert.Equal(t, "", string(stored.Phonenumber))
+		assert.Equal(t, "bob@example.com", string(stored.Email))
+	})
+
+	t.Run("should reject renaming the user or an unknown path", func(t *testing.T) {
+		_, err := s.PatchUser("3", patch(dto.ScimPatchOperation{Op: "replace", Path: "userName", Value: json.RawMessage(`"robert"`)}))
+		assert.Equal(t, xerrors.ErrScimMutability, errors.Cause(err))
+
+		_, err = s.PatchUser("3", patch(dto.ScimPatchOperation{Op: "replace", Path: "title", Value: json.RawMessage(`"CTO"`)}))
+		assert.Equal(t, xerrors.ErrScimInvalidPath, errors.Cause(err))
+	})
+
+	t.Run("should not change the super administrator", func(t *testing.T) {
+		_, err := s.PatchUser("1", patch(dto.ScimPatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}))
+		assert.Equal(t, xerrors.ErrScimMutability, errors.Cause(err))
+	})
+
+	t.Run("should report an unknown user", func(t *testing.T) {
+		_, err := s.PatchUser("99", patch(dto.ScimPatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}))
+		assert.Equal(t, xerrors.ErrScimNotFound, errors.Cause(err))
+	})
+}
+
+func TestScimService_DeprovisionUser(t *testing.T) {
+	setup()
+	defer teardown()
+	seedScim()
+	s := NewScimService(&UserService{}, &RoleService{})
+
+	t.Run("should disable the user instead of deleting it", func(t *testing.T) {
+		assert.NoError(t, s.DeprovisionUser("2"))
+
+		user, err := s.GetUser("2")
+		assert.NoError(t, err)
+		assert.False(t, *user.Active)
+		assert.Len(t, user.Groups, 1)
+	})
+
+	t.Run("should not disable the super administrator", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrScimMutability, errors.Cause(s.DeprovisionUser("1")))
+	})
+}
+
+func TestScimService_Groups(t *testing.T) {
+	setup()
+	defer teardown()
+	seedScim()
+	s := NewScimService(&UserService{}, &RoleService{})
+
+	var groupId string
+
+	t.Run("should create a role with the members", func(t *testing.T) {
+		group, err := s.CreateGroup(dto.ScimGroup{DisplayName: "Sales Team", Members: []dto.ScimMember{{Value: "2"}, {Value: "3"}}})
+		assert.NoError(t, err)
+		assert.Len(t, group.Members, 2)
+		groupId = group.Id
+
+		var stored model.SysRole
+		dal.Gorm.First(&stored, "role_name = ?", "Sales Team")
+		assert.Equal(t, "scim_sales_team", stored.RoleKey)
+		assert.Equal(t, constant.NORMAL_STATUS, stored.Status)
+	})
+
+	t.Run("should reject a taken name or an unknown member", func(t *testing.T) {
+		_, err := s.CreateGroup(dto.ScimGroup{DisplayName: "Engineering"})
+		assert.Equal(t, xerrors.ErrScimUniqueness, errors.Cause(err))
+
+		_, err = s.CreateGroup(dto.ScimGroup{DisplayName: "Support", Members: []dto.ScimMember{{Value: "99"}}})
+		assert.Equal(t, xerrors.ErrScimInvalidValue, errors.Cause(err))
+	})
+
+	t.Run("should rename and remove members with a patch", func(t *testing.T) {
+		group, err := s.PatchGroup(groupId, dto.ScimPatchRequest{Operations: []dto.ScimPatchOperation{
+			{Op: "replace", Path: "displayName", Value: json.RawMessage(`"Sales"`)},
+			{Op: "remove", Path: `members[value eq "2"]`},
+			{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"1"}]`)},
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, "Sales", group.DisplayName)
+		assert.Equal(t, []string{"1", "3"}, []string{group.Members[0].Value, group.Members[1].Value})
+
+		var stored model.SysRole
+		dal.Gorm.First(&stored, "role_name = ?", "Sales")
+		assert.Equal(t, "scim_sales_team", stored.RoleKey)
+	})
+
+	t.Run("should replace the members", func(t *testing.T) {
+		group, err := s.ReplaceGroup(groupId, dto.ScimGroup{DisplayName: "Sales", Members: []dto.ScimMember{{Value: "2"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, []dto.ScimMember{{Value: "2", Display: "alice", Ref: "/scim/v2/Users/2"}}, group.Members)
+	})
+
+	t.Run("should list groups without members on request", func(t *testing.T) {
+		list, err := s.GetGroups(dto.ScimListRequest{Filter: `displayName sw "Sal"`, ExcludedAttributes: "members"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, list.TotalResults)
+		assert.Empty(t, list.Resources.([]dto.ScimGroup)[0].Members)
+	})
+
+	t.Run("should delete the role but not the super administrator role", func(t *testing.T) {
+		assert.NoError(t, s.DeleteGroup(groupId))
+
+		_, err := s.GetGroup(groupId, false)
+		assert.Equal(t, xerrors.ErrScimNotFound, errors.Cause(err))
+
+		assert.Equal(t, xerrors.ErrScimMutability, errors.Cause(s.DeleteGroup("1")))
+	})
+}
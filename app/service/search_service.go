@@ -0,0 +1,604 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"mira/anima/cipher"
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/search"
+	"mira/common/types/constant"
+	"mira/config"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Default number of items returned by a search
+const SEARCH_DEFAULT_LIMIT = 20
+
+// Maximum number of rows of each type the SQL backends rank
+const SEARCH_CANDIDATE_LIMIT = 200
+
+// SearchServiceInterface defines operations for the unified search
+type SearchServiceInterface interface {
+	Search(param dto.SearchRequest, userId int) dto.SearchResponse
+}
+
+// SearchBackend finds the entities matching a keyword, ranked by score. The service then drops
+// the entities outside the data scope and permissions of the user, so backends only need to
+// apply them to keep their candidates relevant.
+type SearchBackend interface {
+	Name() string
+	Search(keyword string, types []string, userId int) ([]search.Hit, error)
+}
+
+// SearchService searches users, departments, roles, posts, menus and configs in one call
+type SearchService struct {
+	userService UserServiceInterface
+	backend     SearchBackend
+}
+
+// Ensure SearchService implements SearchServiceInterface
+var _ SearchServiceInterface = (*SearchService)(nil)
+
+// NewSearchService creates a new SearchService with the backend of the configuration
+func NewSearchService(userService UserServiceInterface) *SearchService {
+	backend := constant.SEARCH_BACKEND_LIKE
+	if config.Data != nil && config.Data.Search.Backend != "" {
+		backend = config.Data.Search.Backend
+	}
+	return NewSearchServiceWithBackend(userService, NewSearchBackend(backend))
+}
+
+// NewSearchServiceWithBackend creates a new SearchService with a given backend
+func NewSearchServiceWithBackend(userService UserServiceInterface, backend SearchBackend) *SearchService {
+	return &SearchService{userService: userService, backend: backend}
+}
+
+// NewSearchBackend creates the backend of a name, the LIKE backend when the name is unknown. The
+// index backend registers itself as the search hook to follow the changes of the services.
+func NewSearchBackend(name string) SearchBackend {
+	switch name {
+	case constant.SEARCH_BACKEND_FULLTEXT:
+		return &SqlSearchBackend{fulltext: true}
+	case constant.SEARCH_BACKEND_INDEX:
+		backend := NewIndexSearchBackend()
+		SetSearchHook(backend.Changed)
+		return backend
+	default:
+		return &SqlSearchBackend{}
+	}
+}
+
+// Search searches the entities matching a keyword
+func (s *SearchService) Search(param dto.SearchRequest, userId int) dto.SearchResponse {
+	result, _ := s.SearchWithErr(param, userId)
+	return result
+}
+
+// SearchWithErr searches the entities matching a keyword with proper error handling
+//
+// Users and departments are limited to the data scope of the user, menus to the ones granted to
+// the user, and every other type to users allowed to list it. Items are ranked by score, exact
+// matches first, then prefixes, substrings and fuzzy matches.
+func (s *SearchService) SearchWithErr(param dto.SearchRequest, userId int) (dto.SearchResponse, error) {
+	result := dto.SearchResponse{Backend: s.backend.Name(), Items: make([]dto.SearchItemResponse, 0)}
+
+	types := make([]string, 0, len(searchSources))
+	for _, source := range searchSources {
+		if searchTypeRequested(param.Types, source.typ) && s.canSearch(source, userId) {
+			types = append(types, source.typ)
+		}
+	}
+	if len(types) == 0 {
+		return result, nil
+	}
+
+	hits, err := s.backend.Search(param.Keyword, types, userId)
+	if err != nil {
+		return result, err
+	}
+
+	limit := param.Limit
+	if limit <= 0 {
+		limit = SEARCH_DEFAULT_LIMIT
+	}
+
+	// Candidates are loaded again within the scope of the user, in batches of each type
+	idsByType := make(map[string][]int)
+	for _, hit := range hits {
+		idsByType[hit.Type] = append(idsByType[hit.Type], hit.Id)
+	}
+	rowsByType := make(map[string]map[int]searchRow, len(idsByType))
+	for _, source := range searchSources {
+		ids := idsByType[source.typ]
+		if len(ids) == 0 {
+			continue
+		}
+		rows, err := loadSearchRows(source, userId, func(query *gorm.DB) *gorm.DB {
+			return query.Where(source.column(source.key)+" IN ?", ids)
+		})
+		if err != nil {
+			return result, err
+		}
+		rowsByType[source.typ] = make(map[int]searchRow, len(rows))
+		for _, row := range rows {
+			rowsByType[source.typ][row.Id] = row
+		}
+	}
+
+	for _, hit := range hits {
+		row, ok := rowsByType[hit.Type][hit.Id]
+		if !ok {
+			continue
+		}
+		result.Items = append(result.Items, dto.SearchItemResponse{
+			Type:        hit.Type,
+			Id:          hit.Id,
+			Title:       string(row.T0),
+			Description: string(row.T1),
+			Status:      row.Status,
+			Matched:     hit.Field,
+			Score:       hit.Score,
+		})
+		if len(result.Items) >= limit {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// canSearch reports whether a user may search a type, menus are searchable by everyone within
+// the menus granted to them
+func (s *SearchService) canSearch(source searchSource, userId int) bool {
+	return userId == 1 || source.perm == "" || s.userService.UserHasPerms(userId, []string{source.perm})
+}
+
+// searchTypeRequested reports whether a type is in a comma-separated list, every type is
+// requested when the list is empty
+func searchTypeRequested(types, typ string) bool {
+	if strings.TrimSpace(types) == "" {
+		return true
+	}
+	for _, requested := range strings.Split(types, ",") {
+		if strings.TrimSpace(requested) == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// searchField is a searchable column of an entity
+type searchField struct {
+	name   string
+	column string
+	boost  float64
+	// encrypted is the label of an encrypted column, matched by the SQL backends through its
+	// blind index on whole values
+	encrypted cipher.Label
+}
+
+// searchSource describes how an entity type is searched. The first field is the title of its
+// items and the second their description.
+type searchSource struct {
+	typ    string
+	model  interface{}
+	table  string
+	key    string
+	status string
+	fields []searchField
+	// perm is the permission needed to search the type, none for menus
+	perm string
+	// scope restricts the query to the rows a user may see
+	scope func(query *gorm.DB, userId int) *gorm.DB
+}
+
+// column qualifies a column with the table of the source
+func (s searchSource) column(column string) string {
+	return s.table + "." + column
+}
+
+// searchRow is an entity loaded for searching, with the texts of its fields in order, decrypted
+// when encrypted
+type searchRow struct {
+	Id     int
+	Status string
+	T0     string
+	T1     string
+	T2     string
+	T3     string
+}
+
+// texts returns the texts of the fields of a row
+func (r searchRow) texts() []string {
+	return []string{r.T0, r.T1, r.T2, r.T3}
+}
+
+// document converts a row into the indexed document of a source
+func (s searchSource) document(row searchRow) search.Document {
+	doc := search.Document{Type: s.typ, Id: row.Id, Fields: make([]search.Field, 0, len(s.fields))}
+	for i, field := range s.fields {
+		doc.Fields = append(doc.Fields, search.Field{Name: field.name, Text: row.texts()[i], Boost: field.boost})
+	}
+	return doc
+}
+
+// searchSources are the searchable entity types, in the order of the tie-breaking of their items
+var searchSources = []searchSource{
+	{
+		typ:    constant.SEARCH_TYPE_USER,
+		model:  model.SysUser{},
+		table:  "sys_user",
+		key:    "user_id",
+		status: "status",
+		fields: []searchField{
+			{name: "nickName", column: "nick_name", boost: 1},
+			{name: "userName", column: "user_name", boost: 1},
+			{name: "phonenumber", column: "phonenumber", boost: 0.8, encrypted: model.SysUserPhonenumber{}},
+			{name: "email", column: "email", boost: 0.8, encrypted: model.SysUserEmail{}},
+		},
+		perm: "system:user:list",
+		scope: func(query *gorm.DB, userId int) *gorm.DB {
+			return query.Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
+				Scopes(GetDataScope("sys_dept", userId, "sys_user"))
+		},
+	},
+	{
+		typ:    constant.SEARCH_TYPE_DEPT,
+		model:  model.SysDept{},
+		table:  "sys_dept",
+		key:    "dept_id",
+		status: "status",
+		fields: []searchField{
+			{name: "deptName", column: "dept_name", boost: 1},
+			{name: "leader", column: "leader", boost: 0.6},
+		},
+		perm: "system:dept:list",
+		scope: func(query *gorm.DB, userId int) *gorm.DB {
+			return query.Scopes(GetDataScope("sys_dept", userId, ""))
+		},
+	},
+	{
+		typ:    constant.SEARCH_TYPE_ROLE,
+		model:  model.SysRole{},
+		table:  "sys_role",
+		key:    "role_id",
+		status: "status",
+		fields: []searchField{
+			{name: "roleName", column: "role_name", boost: 1},
+			{name: "roleKey", column: "role_key", boost: 0.8},
+		},
+		perm: "system:role:list",
+	},
+	{
+		typ:    constant.SEARCH_TYPE_POST,
+		model:  model.SysPost{},
+		table:  "sys_post",
+		key:    "post_id",
+		status: "status",
+		fields: []searchField{
+			{name: "postName", column: "post_name", boost: 1},
+			{name: "postCode", column: "post_code", boost: 0.8},
+		},
+		perm: "system:post:list",
+	},
+	{
+		typ:    constant.SEARCH_TYPE_MENU,
+		model:  model.SysMenu{},
+		table:  "sys_menu",
+		key:    "menu_id",
+		status: "status",
+		fields: []searchField{
+			{name: "menuName", column: "menu_name", boost: 1},
+			{name: "perms", column: "perms", boost: 0.8},
+		},
+		scope: func(query *gorm.DB, userId int) *gorm.DB {
+			if userId == 1 {
+				return query
+			}
+			return query.Where("sys_menu.menu_id IN (?)", dal.Gorm.Table("sys_role_menu").
+				Select("sys_role_menu.menu_id").
+				Joins("JOIN sys_role ON sys_role.role_id = sys_role_menu.role_id AND sys_role.status = ? AND sys_role.delete_time IS NULL", constant.NORMAL_STATUS).
+				Joins("JOIN (?) AS user_roles ON user_roles.role_id = sys_role.role_id", userRoleQuery(userId)))
+		},
+	},
+	{
+		typ:   constant.SEARCH_TYPE_CONFIG,
+		model: model.SysConfig{},
+		table: "sys_config",
+		key:   "config_id",
+		fields: []searchField{
+			{name: "configName", column: "config_name", boost: 0.8},
+			{name: "configKey", column: "config_key", boost: 1},
+		},
+		perm: "system:config:list",
+	},
+}
+
+// searchSourceOf returns the source of a type
+func searchSourceOf(typ string) (searchSource, bool) {
+	for _, source := range searchSources {
+		if source.typ == typ {
+			return source, true
+		}
+	}
+	return searchSource{}, false
+}
+
+// loadSearchRows loads the rows of a source with a condition, within the scope of a user unless
+// the user ID is 0
+func loadSearchRows(source searchSource, userId int, condition func(query *gorm.DB) *gorm.DB) ([]searchRow, error) {
+	columns := []string{source.column(source.key) + " AS id"}
+	if source.status != "" {
+		columns = append(columns, source.column(source.status)+" AS status")
+	}
+	for i, field := range source.fields {
+		columns = append(columns, "COALESCE("+source.column(field.column)+", '') AS t"+string(rune('0'+i)))
+	}
+
+	query := dal.Gorm.Model(source.model).Select(columns)
+	if userId > 0 && source.scope != nil {
+		query = source.scope(query, userId)
+	}
+
+	rows := make([]searchRow, 0)
+	if err := condition(query).Find(&rows).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed to search %s", source.typ)
+	}
+
+	for i := range rows {
+		texts := []*string{&rows[i].T0, &rows[i].T1, &rows[i].T2, &rows[i].T3}
+		for j, field := range source.fields {
+			if field.encrypted == nil {
+				continue
+			}
+			text, err := cipher.Decrypt(field.encrypted.Label(), *texts[j])
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decrypt %s", field.column)
+			}
+			*texts[j] = text
+		}
+	}
+
+	return rows, nil
+}
+
+// SqlSearchBackend searches the database with LIKE conditions, or with the FULLTEXT indexes of
+// section 34 of ruoyi.sql, and ranks the matching rows of each type
+type SqlSearchBackend struct {
+	fulltext bool
+}
+
+// Name returns the name of the backend
+func (b *SqlSearchBackend) Name() string {
+	if b.fulltext {
+		return constant.SEARCH_BACKEND_FULLTEXT
+	}
+	return constant.SEARCH_BACKEND_LIKE
+}
+
+// Search finds the rows of each type containing every term of the keyword in one of their fields
+func (b *SqlSearchBackend) Search(keyword string, types []string, userId int) ([]search.Hit, error) {
+	terms := search.Terms(keyword)
+	hits := make([]search.Hit, 0)
+	if len(terms) == 0 {
+		return hits, nil
+	}
+
+	for _, typ := range types {
+		source, ok := searchSourceOf(typ)
+		if !ok {
+			continue
+		}
+
+		rows, err := loadSearchRows(source, userId, func(query *gorm.DB) *gorm.DB {
+			for _, term := range terms {
+				query = b.match(query, source, term)
+			}
+			return query.Limit(SEARCH_CANDIDATE_LIMIT)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			if score, field := search.Match(terms, source.document(row).Fields); score > 0 {
+				hits = append(hits, search.Hit{Type: typ, Id: row.Id, Score: score, Field: field})
+			}
+		}
+	}
+
+	search.SortHits(hits)
+	return hits, nil
+}
+
+// match adds the condition of a term to a query. The ngram parser of the FULLTEXT indexes does
+// not index single characters, which are matched with LIKE instead. Once encrypted, encrypted
+// columns only match whole values through their blind index.
+func (b *SqlSearchBackend) match(query *gorm.DB, source searchSource, term string) *gorm.DB {
+	columns := make([]string, 0, len(source.fields))
+	for _, field := range source.fields {
+		columns = append(columns, source.column(field.column))
+	}
+
+	conditions := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns))
+	fulltext := b.fulltext && utf8.RuneCountInString(term) > 1
+	if fulltext {
+		conditions = append(conditions, "MATCH("+strings.Join(columns, ", ")+") AGAINST (? IN BOOLEAN MODE)")
+		args = append(args, `"`+term+`"`)
+	}
+	for i, field := range source.fields {
+		switch {
+		case field.encrypted != nil && cipher.Enabled():
+			condition, arg := piiEquals(columns[i], term)
+			conditions = append(conditions, condition)
+			args = append(args, arg)
+		case !fulltext:
+			conditions = append(conditions, columns[i]+" LIKE ?")
+			args = append(args, "%"+term+"%")
+		}
+	}
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// IndexSearchBackend searches an in-memory index of every searchable entity, which supports
+// fuzzy matching. The index is built on the first search and follows the changes the services
+// report through the search hook: changed entities are reloaded before the next search, so the
+// reports may come before their transactions commit.
+type IndexSearchBackend struct {
+	index   *search.Index
+	mu      sync.Mutex
+	built   bool
+	changed map[string]map[int]bool
+}
+
+// NewIndexSearchBackend creates a new IndexSearchBackend
+func NewIndexSearchBackend() *IndexSearchBackend {
+	return &IndexSearchBackend{index: search.NewIndex(), changed: make(map[string]map[int]bool)}
+}
+
+// Name returns the name of the backend
+func (b *IndexSearchBackend) Name() string {
+	return constant.SEARCH_BACKEND_INDEX
+}
+
+// Changed records the entities of a type to reload, all of them when no ID is given
+func (b *IndexSearchBackend) Changed(typ string, ids ...int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(ids) == 0 {
+		b.changed[typ] = nil
+		return
+	}
+	if pending, ok := b.changed[typ]; ok && pending == nil {
+		return
+	}
+	if b.changed[typ] == nil {
+		b.changed[typ] = make(map[int]bool)
+	}
+	for _, id := range ids {
+		b.changed[typ][id] = true
+	}
+}
+
+// Search finds the indexed entities matching a keyword
+func (b *IndexSearchBackend) Search(keyword string, types []string, userId int) ([]search.Hit, error) {
+	if err := b.refresh(); err != nil {
+		return nil, err
+	}
+	return b.index.Search(keyword, types...), nil
+}
+
+// refresh builds the index, or reloads the changed entities into it
+func (b *IndexSearchBackend) refresh() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.built {
+		for _, source := range searchSources {
+			b.changed[source.typ] = nil
+		}
+	}
+
+	for typ, ids := range b.changed {
+		source, ok := searchSourceOf(typ)
+		if !ok {
+			delete(b.changed, typ)
+			continue
+		}
+
+		if ids == nil {
+			rows, err := loadSearchRows(source, 0, func(query *gorm.DB) *gorm.DB { return query })
+			if err != nil {
+				return err
+			}
+			b.index.Reset(typ)
+			for _, row := range rows {
+				b.index.Put(source.document(row))
+			}
+		} else {
+			keys := make([]int, 0, len(ids))
+			for id := range ids {
+				keys = append(keys, id)
+			}
+			rows, err := loadSearchRows(source, 0, func(query *gorm.DB) *gorm.DB {
+				return query.Where(source.column(source.key)+" IN ?", keys)
+			})
+			if err != nil {
+				return err
+			}
+			for _, id := range keys {
+				b.index.Delete(typ, id)
+			}
+			for _, row := range rows {
+				b.index.Put(source.document(row))
+			}
+		}
+
+		delete(b.changed, typ)
+	}
+
+	b.built = true
+	return nil
+}
+
+// SearchHook is told by the services which entities of a type they changed, all of them when no
+// ID is given
+type SearchHook func(typ string, ids ...int)
+
+var (
+	searchHookMu sync.RWMutex
+	searchHook   SearchHook
+)
+
+// SetSearchHook sets the hook told about changes to searchable entities, nil to remove it
+func SetSearchHook(hook SearchHook) {
+	searchHookMu.Lock()
+	defer searchHookMu.Unlock()
+
+	searchHook = hook
+}
+
+// notifySearch tells the search hook about changed entities
+func notifySearch(typ string, ids ...int) {
+	searchHookMu.RLock()
+	hook := searchHook
+	searchHookMu.RUnlock()
+
+	if hook != nil {
+		hook(typ, ids...)
+	}
+}
+
+// searchCacheEventTypes are the search types of the cache events changing searchable entities
+var searchCacheEventTypes = map[string]string{
+	constant.CACHE_EVENT_USER:   constant.SEARCH_TYPE_USER,
+	constant.CACHE_EVENT_DEPT:   constant.SEARCH_TYPE_DEPT,
+	constant.CACHE_EVENT_ROLE:   constant.SEARCH_TYPE_ROLE,
+	constant.CACHE_EVENT_POST:   constant.SEARCH_TYPE_POST,
+	constant.CACHE_EVENT_MENU:   constant.SEARCH_TYPE_MENU,
+	constant.CACHE_EVENT_CONFIG: constant.SEARCH_TYPE_CONFIG,
+}
+
+// The search hook of every instance follows the changes made on the others. Configuration
+// parameters are announced by key, so all of them are reloaded.
+func init() {
+	OnCacheEvent("", func(event CacheEvent) {
+		typ, ok := searchCacheEventTypes[event.Type]
+		if !ok || !event.Remote() {
+			return
+		}
+		if event.Type == constant.CACHE_EVENT_CONFIG {
+			notifySearch(typ)
+			return
+		}
+		notifySearch(typ, event.Ids...)
+	})
+}
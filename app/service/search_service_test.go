@@ -0,0 +1,175 @@
+package service
+
+import (
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func seedSearch() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ", Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 101, ParentId: 100, Ancestors: "0,100", DeptName: "Sales", Leader: "alice", Status: "0"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 102, ParentId: 100, Ancestors: "0,100", DeptName: "R&D", Status: "0"})
+
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", NickName: "Admin", Email: "admin@example.com", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 101, UserName: "alice", NickName: "Alice", Email: "alice@example.com", Phonenumber: "13800000002", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 102, UserName: "bob", NickName: "Bob", Email: "bob@example.com", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 101, UserName: "alina", NickName: "Alina", Email: "alina@example.com", Status: "1"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Sales Viewer", RoleKey: "viewer", DataScope: DATA_SCOPE_CUSTOM, Status: "0"})
+	dal.Gorm.Create(&model.SysPost{PostId: 1, PostCode: "sales_mgr", PostName: "Sales Manager", Status: "0"})
+	dal.Gorm.Create(&model.SysConfig{ConfigId: 1, ConfigName: "Self registration", ConfigKey: "sys.account.registerUser", ConfigValue: "false"})
+
+	dal.Gorm.Create(&model.SysMenu{MenuId: 1, MenuName: "User", MenuType: "C", Perms: "system:user:list", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 2, MenuName: "Config", MenuType: "C", Perms: "system:config:list", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 3, MenuName: "Edit User", MenuType: "F", Perms: "system:user:edit", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 3})
+	dal.Gorm.Create(&model.SysRoleDept{RoleId: 2, DeptId: 101})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 2})
+}
+
+// searchItemKeys returns the type and ID of search items
+func searchItemKeys(items []dto.SearchItemResponse) [][2]interface{} {
+	keys := make([][2]interface{}, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, [2]interface{}{item.Type, item.Id})
+	}
+	return keys
+}
+
+func TestSearchService_Search(t *testing.T) {
+	setup()
+	defer teardown()
+	seedSearch()
+	s := NewSearchServiceWithBackend(&UserService{}, NewSearchBackend(constant.SEARCH_BACKEND_LIKE))
+
+	t.Run("should rank the matches of every type", func(t *testing.T) {
+		result, err := s.SearchWithErr(dto.SearchRequest{Keyword: "sales"}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, constant.SEARCH_BACKEND_LIKE, result.Backend)
+		assert.Equal(t, [][2]interface{}{{"dept", 101}, {"post", 1}, {"role", 2}}, searchItemKeys(result.Items))
+
+		dept := result.Items[0]
+		assert.Equal(t, "Sales", dept.Title)
+		assert.Equal(t, "alice", dept.Description)
+		assert.Equal(t, "deptName", dept.Matched)
+		assert.Equal(t, 1.0, dept.Score)
+	})
+
+	t.Run("should search users by any of their fields", func(t *testing.T) {
+		result := s.Search(dto.SearchRequest{Keyword: "ali", Types: "user"}, 1)
+		assert.Equal(t, [][2]interface{}{{"user", 2}, {"user", 4}}, searchItemKeys(result.Items))
+		assert.Equal(t, "Alice", result.Items[0].Title)
+		assert.Equal(t, "alice", result.Items[0].Description)
+		assert.Equal(t, "1", result.Items[1].Status)
+
+		result = s.Search(dto.SearchRequest{Keyword: "13800000002"}, 1)
+		assert.Equal(t, [][2]interface{}{{"user", 2}}, searchItemKeys(result.Items))
+		assert.Equal(t, "phonenumber", result.Items[0].Matched)
+
+		result = s.Search(dto.SearchRequest{Keyword: "registerUser"}, 1)
+		assert.Equal(t, [][2]interface{}{{"config", 1}}, searchItemKeys(result.Items))
+	})
+
+	t.Run("should require every term and apply the limit", func(t *testing.T) {
+		result := s.Search(dto.SearchRequest{Keyword: "bob example"}, 1)
+		assert.Equal(t, [][2]interface{}{{"user", 3}}, searchItemKeys(result.Items))
+
+		result = s.Search(dto.SearchRequest{Keyword: "example", Limit: 2}, 1)
+		assert.Len(t, result.Items, 2)
+	})
+
+	t.Run("should respect the data scope and permissions of the user", func(t *testing.T) {
+		// Alice may only list users, within the Sales department
+		result := s.Search(dto.SearchRequest{Keyword: "example"}, 2)
+		assert.Equal(t, [][2]interface{}{{"user", 2}, {"user", 4}}, searchItemKeys(result.Items))
+
+		result = s.Search(dto.SearchRequest{Keyword: "sales"}, 2)
+		assert.Empty(t, result.Items)
+
+		// Only the menus granted to the user are found
+		result = s.Search(dto.SearchRequest{Keyword: "user", Types: "menu,config"}, 2)
+		assert.Equal(t, [][2]interface{}{{"menu", 1}, {"menu", 3}}, searchItemKeys(result.Items))
+
+		result = s.Search(dto.SearchRequest{Keyword: "config"}, 2)
+		assert.Empty(t, result.Items)
+	})
+}
+
+func TestSearchService_IndexBackend(t *testing.T) {
+	setup()
+	defer teardown()
+	seedSearch()
+	defer SetSearchHook(nil)
+	s := NewSearchServiceWithBackend(&UserService{}, NewSearchBackend(constant.SEARCH_BACKEND_INDEX))
+
+	t.Run("should match misspelled keywords", func(t *testing.T) {
+		result, err := s.SearchWithErr(dto.SearchRequest{Keyword: "alicr", Types: "user"}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, constant.SEARCH_BACKEND_INDEX, result.Backend)
+		// Alina is a fuzzy match as well, with a lower score
+		assert.Equal(t, [][2]interface{}{{"user", 2}, {"user", 4}}, searchItemKeys(result.Items))
+		assert.Greater(t, result.Items[0].Score, result.Items[1].Score)
+	})
+
+	t.Run("should respect the data scope of the user", func(t *testing.T) {
+		result := s.Search(dto.SearchRequest{Keyword: "example"}, 2)
+		assert.Equal(t, [][2]interface{}{{"user", 2}, {"user", 4}}, searchItemKeys(result.Items))
+	})
+
+	t.Run("should follow the changes of the services", func(t *testing.T) {
+		assert.NoError(t, (&UserService{}).UpdateUser(dto.SaveUser{UserId: 3, NickName: "Robert"}, nil, nil))
+		assert.NoError(t, (&UserService{}).DeleteUser([]int{4}, "admin"))
+		assert.NoError(t, (&DeptService{}).CreateDept(dto.SaveDept{ParentId: 100, Ancestors: "0,100", DeptName: "Marketing", Status: "0"}))
+		assert.NoError(t, (&ConfigService{}).UpdateConfig(dto.SaveConfig{ConfigId: 1, ConfigName: "Open registration"}))
+
+		result := s.Search(dto.SearchRequest{Keyword: "robert"}, 1)
+		assert.Equal(t, [][2]interface{}{{"user", 3}}, searchItemKeys(result.Items))
+
+		result = s.Search(dto.SearchRequest{Keyword: "alina", Types: "user"}, 1)
+		assert.NotContains(t, searchItemKeys(result.Items), [2]interface{}{"user", 4})
+
+		result = s.Search(dto.SearchRequest{Keyword: "marketing"}, 1)
+		assert.Len(t, result.Items, 1)
+		assert.Equal(t, "dept", result.Items[0].Type)
+
+		result = s.Search(dto.SearchRequest{Keyword: "open registration"}, 1)
+		assert.Equal(t, [][2]interface{}{{"config", 1}}, searchItemKeys(result.Items))
+	})
+
+	t.Run("should reload changes made before their transaction commits", func(t *testing.T) {
+		tx := dal.Gorm.Begin()
+		tx.Create(&model.SysPost{PostId: 2, PostCode: "ceo", PostName: "Chief Executive", Status: "0"})
+		notifySearch(constant.SEARCH_TYPE_POST, 2)
+		tx.Commit()
+
+		result := s.Search(dto.SearchRequest{Keyword: "chief"}, 1)
+		assert.Equal(t, [][2]interface{}{{"post", 2}}, searchItemKeys(result.Items))
+	})
+}
+
+func TestSqlSearchBackend_Fulltext(t *testing.T) {
+	setup()
+	defer teardown()
+
+	b := NewSearchBackend(constant.SEARCH_BACKEND_FULLTEXT).(*SqlSearchBackend)
+	assert.Equal(t, constant.SEARCH_BACKEND_FULLTEXT, b.Name())
+
+	source, _ := searchSourceOf(constant.SEARCH_TYPE_ROLE)
+	rows := make([]searchRow, 0)
+	stmt := b.match(dal.Gorm.Session(&gorm.Session{DryRun: true}).Model(model.SysRole{}), source, "管理员").Find(&rows).Statement
+	assert.Contains(t, stmt.SQL.String(), "MATCH(sys_role.role_name, sys_role.role_key) AGAINST (? IN BOOLEAN MODE)")
+	assert.Equal(t, `"管理员"`, stmt.Vars[0])
+
+	// Single characters are not in the ngram index
+	stmt = b.match(dal.Gorm.Session(&gorm.Session{DryRun: true}).Model(model.SysRole{}), source, "a").Find(&rows).Statement
+	assert.Contains(t, stmt.SQL.String(), "sys_role.role_name LIKE ? OR sys_role.role_key LIKE ?")
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/common/lru"
+	"mira/common/singleflight"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Two-level cache
+//
+// A two-level cache keeps the entries read recently in a bounded in-process LRU in front of a
+// Redis hash shared by the instances, and loads missing entries once for all concurrent readers.
+// Data known to be missing is remembered as well, for a shorter time in process, and every TTL
+// is lengthened by a random share so that entries set together do not expire together. Each
+// entry in Redis carries its own expiry, checked on read, as fields of a hash cannot expire. The
+// services drop the entries in Redis when they change the data, and the handlers of the cache
+// events drop them from the process of every instance, see cache_bus.go.
+
+// twoLevelCacheMissing is stored in Redis for data known to be missing. It is not valid JSON, so
+// that it cannot be taken for a value.
+const twoLevelCacheMissing = "-"
+
+// TwoLevelCacheOptions configures a two-level cache
+type TwoLevelCacheOptions struct {
+	// Name identifies the cache in the metrics
+	Name string
+	// Hash returns the Redis hash holding the entries by key
+	Hash func() string
+	// LocalSize bounds the entries kept in process
+	LocalSize int
+	// LocalTTL and RedisTTL are how long entries are kept in process and in Redis
+	LocalTTL time.Duration
+	RedisTTL time.Duration
+	// NegativeTTL is how long missing data is remembered in process, not at all when not
+	// positive. Redis keeps it as long as the other entries.
+	NegativeTTL time.Duration
+	// Jitter is the largest share of a TTL added at random
+	Jitter float64
+}
+
+// TwoLevelCacheLoader loads an entry, reporting whether the data exists
+type TwoLevelCacheLoader[T any] func(key string) (T, bool, error)
+
+// TwoLevelCache is a typed cache in process and in Redis
+type TwoLevelCache[T any] struct {
+	options TwoLevelCacheOptions
+	load    TwoLevelCacheLoader[T]
+	local   *lru.Cache[string, twoLevelCacheEntry[T]]
+	loads   singleflight.Group[string, twoLevelCacheEntry[T]]
+	// generation changes whenever entries are dropped, so that loads overtaken by a change are
+	// not stored
+	generation atomic.Uint64
+
+	localHits    atomic.Uint64
+	redisHits    atomic.Uint64
+	misses       atomic.Uint64
+	negativeHits atomic.Uint64
+	coalesced    atomic.Uint64
+	loadErrors   atomic.Uint64
+}
+
+type twoLevelCacheEntry[T any] struct {
+	value T
+	found bool
+}
+
+// twoLevelCacheMetrics is what the registry needs of the caches of every type
+type twoLevelCacheMetrics interface {
+	Metrics() dto.CacheMetricsResponse
+	Forget(keys ...string)
+}
+
+var (
+	twoLevelCachesMu sync.RWMutex
+	twoLevelCaches   []twoLevelCacheMetrics
+)
+
+// NewTwoLevelCache returns a cache loading missing entries with a loader, and registers it for
+// the metrics
+func NewTwoLevelCache[T any](options TwoLevelCacheOptions, load TwoLevelCacheLoader[T]) *TwoLevelCache[T] {
+	c := &TwoLevelCache[T]{
+		options: options,
+		load:    load,
+		local:   lru.New[string, twoLevelCacheEntry[T]](options.LocalSize),
+	}
+
+	twoLevelCachesMu.Lock()
+	defer twoLevelCachesMu.Unlock()
+	twoLevelCaches = append(twoLevelCaches, c)
+
+	return c
+}
+
+// Get returns the entry of a key and whether the data exists, loading it on a miss. Redis
+// failures are read as misses; only loader errors are returned.
+func (c *TwoLevelCache[T]) Get(ctx context.Context, key string) (T, bool, error) {
+	if entry, ok := c.local.Get(key); ok {
+		c.localHits.Add(1)
+		if !entry.found {
+			c.negativeHits.Add(1)
+		}
+		return entry.value, entry.found, nil
+	}
+
+	generation := c.generation.Load()
+	leader := false
+	entry, err, _ := c.loads.Do(key, func() (twoLevelCacheEntry[T], error) {
+		leader = true
+		return c.fetch(ctx, key, generation)
+	})
+	if !leader {
+		c.coalesced.Add(1)
+	}
+	if err != nil {
+		var zero T
+		return zero, false, err
+	}
+
+	return entry.value, entry.found, nil
+}
+
+// fetch reads an entry missing in process from Redis, loading it when Redis misses too
+func (c *TwoLevelCache[T]) fetch(ctx context.Context, key string, generation uint64) (twoLevelCacheEntry[T], error) {
+	hash := c.options.Hash()
+
+	payload, err := dal.Redis.HGet(ctx, hash, key).Result()
+	if err == nil {
+		if entry, ok := decodeTwoLevelCacheEntry[T](payload, time.Now()); ok {
+			c.redisHits.Add(1)
+			if !entry.found {
+				c.negativeHits.Add(1)
+			}
+			c.storeLocal(key, entry, generation)
+			return entry, nil
+		}
+	} else if err != redis.Nil {
+		log.Printf("Warning: Failed to read %s cache entry %s: %v", c.options.Name, key, err)
+	}
+
+	c.misses.Add(1)
+	value, found, err := c.load(key)
+	if err != nil {
+		c.loadErrors.Add(1)
+		return twoLevelCacheEntry[T]{}, err
+	}
+
+	entry := twoLevelCacheEntry[T]{value: value, found: found}
+	if !found && c.options.NegativeTTL <= 0 {
+		return entry, nil
+	}
+	if c.storeLocal(key, entry, generation) {
+		c.storeRedis(ctx, hash, key, entry)
+	}
+
+	return entry, nil
+}
+
+// storeLocal keeps an entry in process unless entries were dropped since it was read
+func (c *TwoLevelCache[T]) storeLocal(key string, entry twoLevelCacheEntry[T], generation uint64) bool {
+	if c.generation.Load() != generation {
+		return false
+	}
+
+	ttl := c.options.LocalTTL
+	if !entry.found {
+		if c.options.NegativeTTL <= 0 {
+			return true
+		}
+		ttl = c.options.NegativeTTL
+	}
+	c.local.Set(key, entry, c.jitter(ttl))
+
+	return true
+}
+
+// storeRedis shares a loaded entry with the other instances, stamped with its own jittered
+// expiry. The hash expires once none of its entries can be read any longer.
+func (c *TwoLevelCache[T]) storeRedis(ctx context.Context, hash, key string, entry twoLevelCacheEntry[T]) {
+	payload := twoLevelCacheMissing
+	if entry.found {
+		bytes, err := json.Marshal(entry.value)
+		if err != nil {
+			log.Printf("Warning: Failed to encode %s cache entry %s: %v", c.options.Name, key, err)
+			return
+		}
+		payload = string(bytes)
+	}
+
+	var expiresAt int64
+	if c.options.RedisTTL > 0 {
+		expiresAt = time.Now().Add(c.jitter(c.options.RedisTTL)).UnixMilli()
+	}
+
+	if err := dal.Redis.HSet(ctx, hash, key, strconv.FormatInt(expiresAt, 10)+":"+payload).Err(); err != nil {
+		log.Printf("Warning: Failed to write %s cache entry %s: %v", c.options.Name, key, err)
+		return
+	}
+	if c.options.RedisTTL > 0 {
+		dal.Redis.Expire(ctx, hash, c.options.RedisTTL+time.Duration(float64(c.options.RedisTTL)*c.options.Jitter))
+	}
+}
+
+// decodeTwoLevelCacheEntry reads an entry stored in Redis as its expiry in Unix milliseconds, 0
+// for none, and its payload separated by a colon. Expired entries are read as misses.
+func decodeTwoLevelCacheEntry[T any](payload string, now time.Time) (twoLevelCacheEntry[T], bool) {
+	stamp, payload, ok := strings.Cut(payload, ":")
+	if !ok {
+		return twoLevelCacheEntry[T]{}, false
+	}
+	expiresAt, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil || (expiresAt > 0 && now.UnixMilli() >= expiresAt) {
+		return twoLevelCacheEntry[T]{}, false
+	}
+
+	if payload == twoLevelCacheMissing {
+		return twoLevelCacheEntry[T]{}, true
+	}
+
+	var value T
+	if err := json.Unmarshal([]byte(payload), &value); err != nil {
+		return twoLevelCacheEntry[T]{}, false
+	}
+	return twoLevelCacheEntry[T]{value: value, found: true}, true
+}
+
+// jitter lengthens a TTL by a random share of it
+func (c *TwoLevelCache[T]) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || c.options.Jitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(float64(ttl)*c.options.Jitter)+1))
+}
+
+// Forget drops entries from the process, every entry when no key is given
+func (c *TwoLevelCache[T]) Forget(keys ...string) {
+	c.generation.Add(1)
+
+	if len(keys) == 0 {
+		c.local.Purge()
+		c.loads.ForgetAll()
+		return
+	}
+	c.local.Delete(keys...)
+	c.loads.Forget(keys...)
+}
+
+// Invalidate drops entries from Redis and from the process, every entry when no key is given
+func (c *TwoLevelCache[T]) Invalidate(ctx context.Context, keys ...string) error {
+	var err error
+	if len(keys) == 0 {
+		err = dal.Redis.Del(ctx, c.options.Hash()).Err()
+	} else {
+		err = dal.Redis.HDel(ctx, c.options.Hash(), keys...).Err()
+	}
+
+	c.Forget(keys...)
+
+	return err
+}
+
+// drop drops entries on a cache event, every entry when no key is given. The entries are dropped
+// in Redis as well on the events of this instance, for caches the services do not drop there.
+func (c *TwoLevelCache[T]) drop(event CacheEvent, keys ...string) {
+	if event.Remote() {
+		c.Forget(keys...)
+		return
+	}
+
+	if err := c.Invalidate(context.Background(), keys...); err != nil {
+		log.Printf("Warning: Failed to drop %s cache entries: %v", c.options.Name, err)
+	}
+}
+
+// cacheEventIdKeys returns the cache keys of the IDs of an event
+func cacheEventIdKeys(ids []int) []string {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, strconv.Itoa(id))
+	}
+	return keys
+}
+
+// Metrics returns the hit and miss counts of the cache
+func (c *TwoLevelCache[T]) Metrics() dto.CacheMetricsResponse {
+	metrics := dto.CacheMetricsResponse{
+		Name:         c.options.Name,
+		LocalSize:    c.local.Len(),
+		LocalHits:    c.localHits.Load(),
+		RedisHits:    c.redisHits.Load(),
+		Misses:       c.misses.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		Coalesced:    c.coalesced.Load(),
+		LoadErrors:   c.loadErrors.Load(),
+		Evictions:    c.local.Evictions(),
+	}
+	if reads := metrics.LocalHits + metrics.RedisHits + metrics.Misses; reads > 0 {
+		metrics.HitRate = float64(metrics.LocalHits+metrics.RedisHits) / float64(reads)
+	}
+	return metrics
+}
+
+// twoLevelCacheMetricsList returns the metrics of every two-level cache ordered by name
+func twoLevelCacheMetricsList() []dto.CacheMetricsResponse {
+	twoLevelCachesMu.RLock()
+	defer twoLevelCachesMu.RUnlock()
+
+	list := make([]dto.CacheMetricsResponse, 0, len(twoLevelCaches))
+	for _, c := range twoLevelCaches {
+		list = append(list, c.Metrics())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/app/model"
+	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
+
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// matchTwoLevelCacheEntry matches the HSet of an entry whose payload, the last argument, is
+// stamped with an expiry within a TTL lengthened by up to a share of it
+func matchTwoLevelCacheEntry(ttl time.Duration, jitter float64) redismock.CustomMatch {
+	return func(expected, actual []interface{}) error {
+		if len(expected) != len(actual) {
+			return errors.New("arguments differ")
+		}
+		last := len(expected) - 1
+		for i := 0; i < last; i++ {
+			if fmt.Sprint(expected[i]) != fmt.Sprint(actual[i]) {
+				return fmt.Errorf("argument %d differs: %v != %v", i, expected[i], actual[i])
+			}
+		}
+		stamp, payload, _ := strings.Cut(fmt.Sprint(actual[last]), ":")
+		if payload != fmt.Sprint(expected[last]) {
+			return fmt.Errorf("payload differs: %v != %v", expected[last], payload)
+		}
+		expiresAt, _ := strconv.ParseInt(stamp, 10, 64)
+		remaining := time.Until(time.UnixMilli(expiresAt))
+		if remaining < ttl-time.Second || float64(remaining) > float64(ttl)*(1+jitter) {
+			return fmt.Errorf("expiry in %v is not within %v and %.0fs", remaining, ttl, ttl.Seconds()*(1+jitter))
+		}
+		return nil
+	}
+}
+
+// countingLoader loads the length of keys, counting the loads; keys starting with x are missing
+func countingLoader(loads *int) TwoLevelCacheLoader[int] {
+	return func(key string) (int, bool, error) {
+		*loads++
+		if strings.HasPrefix(key, "x") {
+			return 0, false, nil
+		}
+		return len(key), true, nil
+	}
+}
+
+func TestTwoLevelCache_Get(t *testing.T) {
+	setup()
+	defer teardown()
+	hash := func() string { return "test:lengths" }
+	loads := 0
+	c := NewTwoLevelCache(TwoLevelCacheOptions{
+		Name: "test.lengths", Hash: hash, LocalSize: 2, LocalTTL: time.Minute, RedisTTL: time.Hour, NegativeTTL: time.Minute, Jitter: 0.1,
+	}, countingLoader(&loads))
+
+	t.Run("should load a miss into Redis and the process", func(t *testing.T) {
+		redisMock.ExpectHGet(hash(), "abc").RedisNil()
+		redisMock.CustomMatch(matchTwoLevelCacheEntry(time.Hour, 0.1)).ExpectHSet(hash(), "abc", "3").SetVal(1)
+		redisMock.ExpectExpire(hash(), 66*time.Minute).SetVal(true)
+
+		value, found, err := c.Get(context.Background(), "abc")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, 3, value)
+
+		value, _, _ = c.Get(context.Background(), "abc")
+		assert.Equal(t, 3, value)
+		assert.Equal(t, 1, loads)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should read entries of other instances from Redis", func(t *testing.T) {
+		redisMock.ExpectHGet(hash(), "de").SetVal(strconv.FormatInt(time.Now().Add(time.Minute).UnixMilli(), 10) + ":7")
+
+		value, found, err := c.Get(context.Background(), "de")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, 7, value)
+		assert.Equal(t, 1, loads)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+
+	t.Run("should remember missing data", func(t *testing.T) {
+		redisMock.ExpectHGet(hash(), "xy").RedisNil()
+		redisMock.CustomMatch(matchTwoLevelCacheEntry(time.Hour, 0.1)).ExpectHSet(hash(), "xy", twoLevelCacheMissing).SetVal(1)
+		redisMock.ExpectExpire(hash(), 66*time.Minute).SetVal(true)
+
+		_, found, err := c.Get(context.Background(), "xy")
+		assert.NoError(t, err)
+		assert.False(t, found)
+		_, found, _ = c.Get(context.Background(), "xy")
+		assert.False(t, found)
+		assert.Equal(t, 2, loads)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+
+		redisMock.ExpectHGet(hash(), "xz").SetVal("0:" + twoLevelCacheMissing)
+		_, found, _ = c.Get(context.Background(), "xz")
+		assert.False(t, found)
+		assert.Equal(t, 2, loads)
+	})
+
+	t.Run("should drop entries", func(t *testing.T) {
+		redisMock.ExpectHDel(hash(), "xy").SetVal(1)
+		assert.NoError(t, c.Invalidate(context.Background(), "xy"))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+
+		// Redis is down, the entry is loaded again
+		_, found, _ := c.Get(context.Background(), "xy")
+		assert.False(t, found)
+		assert.Equal(t, 3, loads)
+	})
+
+	t.Run("should count hits and misses", func(t *testing.T) {
+		metrics := c.Metrics()
+		assert.Equal(t, "test.lengths", metrics.Name)
+		assert.Equal(t, uint64(2), metrics.LocalHits)
+		assert.Equal(t, uint64(2), metrics.RedisHits)
+		assert.Equal(t, uint64(3), metrics.Misses)
+		assert.Equal(t, uint64(2), metrics.NegativeHits)
+		assert.Equal(t, uint64(2), metrics.Evictions)
+		assert.Equal(t, 2, metrics.LocalSize)
+		assert.InDelta(t, 4.0/7, metrics.HitRate, 0.001)
+
+		names := make([]string, 0)
+		for _, metrics := range twoLevelCacheMetricsList() {
+			names = append(names, metrics.Name)
+		}
+		assert.Subset(t, names, []string{"config", "dataScope", "dict", "perms", "test.lengths"})
+	})
+
+	t.Run("should load entries expired in Redis again", func(t *testing.T) {
+		redisMock.ExpectHGet(hash(), "fg").SetVal(strconv.FormatInt(time.Now().Add(-time.Second).UnixMilli(), 10) + ":7")
+		redisMock.CustomMatch(matchTwoLevelCacheEntry(time.Hour, 0.1)).ExpectHSet(hash(), "fg", "2").SetVal(1)
+		redisMock.ExpectExpire(hash(), 66*time.Minute).SetVal(true)
+
+		value, found, err := c.Get(context.Background(), "fg")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, 2, value)
+		assert.Equal(t, 4, loads)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
+
+func TestTwoLevelCache_Load(t *testing.T) {
+	setup()
+	defer teardown()
+
+	t.Run("should load concurrent misses once", func(t *testing.T) {
+		var loads int32
+		release := make(chan struct{})
+		c := NewTwoLevelCache(TwoLevelCacheOptions{Name: "test.slow", Hash: func() string { return "test:slow" }, LocalSize: 10, LocalTTL: time.Minute},
+			func(key string) (int, bool, error) {
+				atomic.AddInt32(&loads, 1)
+				<-release
+				return 1, true, nil
+			})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, _, _ := c.Get(context.Background(), "a")
+				assert.Equal(t, 1, value)
+			}()
+		}
+		for atomic.LoadInt32(&loads) == 0 {
+			runtime.Gosched()
+		}
+		// Give the other readers time to join the load
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&loads))
+		assert.Equal(t, uint64(4), c.Metrics().Coalesced)
+	})
+
+	t.Run("should not keep loads overtaken by a change", func(t *testing.T) {
+		loads := 0
+		var c *TwoLevelCache[int]
+		c = NewTwoLevelCache(TwoLevelCacheOptions{Name: "test.changing", Hash: func() string { return "test:changing" }, LocalSize: 10, LocalTTL: time.Minute},
+			func(key string) (int, bool, error) {
+				loads++
+				if loads == 1 {
+					c.Forget(key)
+				}
+				return loads, true, nil
+			})
+
+		value, _, _ := c.Get(context.Background(), "a")
+		assert.Equal(t, 1, value)
+		value, _, _ = c.Get(context.Background(), "a")
+		assert.Equal(t, 2, value)
+		value, _, _ = c.Get(context.Background(), "a")
+		assert.Equal(t, 2, value)
+	})
+
+	t.Run("should not keep failed loads", func(t *testing.T) {
+		failure := errors.New("failure")
+		loads := 0
+		c := NewTwoLevelCache(TwoLevelCacheOptions{Name: "test.failing", Hash: func() string { return "test:failing" }, LocalSize: 10, LocalTTL: time.Minute},
+			func(key string) (int, bool, error) {
+				loads++
+				return 0, false, failure
+			})
+
+		_, _, err := c.Get(context.Background(), "a")
+		assert.ErrorIs(t, err, failure)
+		_, _, err = c.Get(context.Background(), "a")
+		assert.ErrorIs(t, err, failure)
+		assert.Equal(t, 2, loads)
+		assert.Equal(t, uint64(2), c.Metrics().LoadErrors)
+	})
+}
+
+func TestTwoLevelCache_Jitter(t *testing.T) {
+	c := &TwoLevelCache[int]{options: TwoLevelCacheOptions{Jitter: 0.5}}
+	for i := 0; i < 100; i++ {
+		ttl := c.jitter(time.Minute)
+		assert.GreaterOrEqual(t, ttl, time.Minute)
+		assert.LessOrEqual(t, ttl, 90*time.Second)
+	}
+	assert.Equal(t, time.Duration(0), c.jitter(0))
+}
+
+func TestTwoLevelCache_Events(t *testing.T) {
+	setup()
+	defer teardown()
+
+	t.Run("should follow the permissions of users", func(t *testing.T) {
+		dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Editor", RoleKey: "editor", Status: constant.NORMAL_STATUS})
+		dal.Gorm.Create(&model.SysMenu{MenuId: 1, MenuName: "Edit", Perms: "system:user:edit", Status: constant.NORMAL_STATUS})
+		dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 1})
+		s := &UserService{}
+
+		assert.False(t, s.UserHasPerms(5, []string{"system:user:edit"}))
+
+		assert.NoError(t, (&RoleService{}).AuthUserSelectAll(2, []int{5}))
+		assert.True(t, s.UserHasPerms(5, []string{"system:user:edit"}))
+
+		// Another instance took the role back
+		dal.Gorm.Where("user_id = ?", 5).Delete(&model.SysUserRole{})
+		assert.True(t, s.UserHasPerms(5, []string{"system:user:edit"}))
+		receiveCacheEvent(`{"type":"role","ids":[2],"origin":"other"}`)
+		assert.False(t, s.UserHasPerms(5, []string{"system:user:edit"}))
+	})
+
+	t.Run("should drop entries in Redis on the events of this instance only", func(t *testing.T) {
+		redisMock.ExpectHDel(rediskey.SysUserDataScopeKey(), "5").SetVal(1)
+		redisMock.ExpectHDel(rediskey.SysUserPermsKey(), "5").SetVal(1)
+		publishCacheIds(constant.CACHE_EVENT_USER, 5)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+
+		receiveCacheEvent(`{"type":"user","ids":[5],"origin":"other"}`)
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// USER_ATTR_VALUE_MAX_LENGTH is the longest custom attribute value accepted, in characters
+const USER_ATTR_VALUE_MAX_LENGTH = 500
+
+// UserAttrServiceInterface defines operations for custom user attributes
+type UserAttrServiceInterface interface {
+	CreateUserAttr(param dto.SaveUserAttr) error
+	UpdateUserAttr(param dto.SaveUserAttr) error
+	DeleteUserAttr(attrIds []int) error
+	GetUserAttrList(param dto.UserAttrListRequest, isPaging bool) ([]dto.UserAttrListResponse, int)
+	GetActiveUserAttrs() []dto.UserAttrListResponse
+	GetUserAttrByAttrId(attrId int) dto.UserAttrDetailResponse
+	GetUserAttrByAttrKey(attrKey string) dto.UserAttrDetailResponse
+	GetUserAttrValues(userId int) map[string]string
+	CheckUserAttrValues(values map[string]string) error
+}
+
+// UserAttrService implements custom user attributes
+//
+// Administrators define the attributes users carry next to the built-in fields, with a
+// type, an optional validation pattern, a dictionary for select attributes and whether
+// the attribute is required or searchable. Values are stored per user and attribute;
+// the values of a disabled attribute are kept but neither shown nor validated.
+type UserAttrService struct{}
+
+// Ensure UserAttrService implements UserAttrServiceInterface
+var _ UserAttrServiceInterface = (*UserAttrService)(nil)
+
+// NewUserAttrService creates a new UserAttrService
+func NewUserAttrService() *UserAttrService {
+	return &UserAttrService{}
+}
+
+// CreateUserAttr creates a new custom user attribute
+func (s *UserAttrService) CreateUserAttr(param dto.SaveUserAttr) error {
+	return s.CreateUserAttrWithErr(param)
+}
+
+// CreateUserAttrWithErr creates a new custom user attribute with proper error handling
+func (s *UserAttrService) CreateUserAttrWithErr(param dto.SaveUserAttr) error {
+	if param.AttrKey == "" {
+		return xerrors.ErrUserAttrKeyEmpty
+	}
+
+	if param.AttrLabel == "" {
+		return xerrors.ErrUserAttrLabelEmpty
+	}
+
+	if err := dal.Gorm.Model(model.SysUserAttr{}).Create(&model.SysUserAttr{
+		AttrKey:      param.AttrKey,
+		AttrLabel:    param.AttrLabel,
+		AttrType:     param.AttrType,
+		Pattern:      param.Pattern,
+		DictType:     param.DictType,
+		IsRequired:   param.IsRequired,
+		IsSearchable: param.IsSearchable,
+		AttrSort:     param.AttrSort,
+		Status:       param.Status,
+		CreateBy:     param.CreateBy,
+		Remark:       param.Remark,
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed to create user attribute")
+	}
+
+	return nil
+}
+
+// UpdateUserAttr updates a custom user attribute
+func (s *UserAttrService) UpdateUserAttr(param dto.SaveUserAttr) error {
+	return s.UpdateUserAttrWithErr(param)
+}
+
+// UpdateUserAttrWithErr updates a custom user attribute with proper error handling
+func (s *UserAttrService) UpdateUserAttrWithErr(param dto.SaveUserAttr) error {
+	if param.AttrId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if param.AttrLabel == "" {
+		return xerrors.ErrUserAttrLabelEmpty
+	}
+
+	// Select keeps the empty pattern and dictionary type instead of skipping them as zero values
+	if err := dal.Gorm.Model(model.SysUserAttr{}).Where("attr_id = ?", param.AttrId).
+		Select("attr_key", "attr_label", "attr_type", "pattern", "dict_type", "is_required", "is_searchable", "attr_sort", "status", "update_by", "remark").
+		Updates(&model.SysUserAttr{
+			AttrKey:      param.AttrKey,
+			AttrLabel:    param.AttrLabel,
+			AttrType:     param.AttrType,
+			Pattern:      param.Pattern,
+			DictType:     param.DictType,
+			IsRequired:   param.IsRequired,
+			IsSearchable: param.IsSearchable,
+			AttrSort:     param.AttrSort,
+			Status:       param.Status,
+			UpdateBy:     param.UpdateBy,
+			Remark:       param.Remark,
+		}).Error; err != nil {
+		return errors.Wrap(err, "failed to update user attribute")
+	}
+
+	return nil
+}
+
+// DeleteUserAttr deletes custom user attributes with the values users hold for them
+func (s *UserAttrService) DeleteUserAttr(attrIds []int) error {
+	return s.DeleteUserAttrWithErr(attrIds)
+}
+
+// DeleteUserAttrWithErr deletes custom user attributes with proper error handling
+func (s *UserAttrService) DeleteUserAttrWithErr(attrIds []int) error {
+	if len(attrIds) == 0 {
+		return xerrors.ErrParam
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Where("attr_id IN ?", attrIds).Delete(&model.SysUserAttrValue{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to delete user attribute values")
+	}
+
+	if err := tx.Where("attr_id IN ?", attrIds).Delete(&model.SysUserAttr{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to delete user attributes")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// GetUserAttrList retrieves a list of custom user attributes based on search parameters
+func (s *UserAttrService) GetUserAttrList(param dto.UserAttrListRequest, isPaging bool) ([]dto.UserAttrListResponse, int) {
+	attrs, count, _ := s.GetUserAttrListWithErr(param, isPaging)
+	return attrs, count
+}
+
+// GetUserAttrListWithErr retrieves a list of custom user attributes with proper error handling
+func (s *UserAttrService) GetUserAttrListWithErr(param dto.UserAttrListRequest, isPaging bool) ([]dto.UserAttrListResponse, int, error) {
+	var count int64
+	attrs := make([]dto.UserAttrListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysUserAttr{}).Order("attr_sort, attr_id")
+
+	if param.AttrKey != "" {
+		query = query.Where("attr_key LIKE ?", "%"+param.AttrKey+"%")
+	}
+
+	if param.AttrLabel != "" {
+		query = query.Where("attr_label LIKE ?", "%"+param.AttrLabel+"%")
+	}
+
+	if param.Status != "" {
+		query = query.Where("status = ?", param.Status)
+	}
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count user attributes")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&attrs).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve user attributes")
+	}
+
+	return attrs, int(count), nil
+}
+
+// GetActiveUserAttrs retrieves the enabled custom user attributes in display order
+func (s *UserAttrService) GetActiveUserAttrs() []dto.UserAttrListResponse {
+	attrs, _ := activeUserAttrs(dal.Gorm)
+	return attrs
+}
+
+// GetUserAttrByAttrId retrieves custom user attribute details by ID
+func (s *UserAttrService) GetUserAttrByAttrId(attrId int) dto.UserAttrDetailResponse {
+	attr, _ := s.GetUserAttrByAttrIdWithErr(attrId)
+	return attr
+}
+
+// GetUserAttrByAttrIdWithErr retrieves custom user attribute details by ID with proper error handling
+func (s *UserAttrService) GetUserAttrByAttrIdWithErr(attrId int) (dto.UserAttrDetailResponse, error) {
+	var attr dto.UserAttrDetailResponse
+
+	if attrId <= 0 {
+		return attr, xerrors.ErrParam
+	}
+
+	if err := dal.Gorm.Model(model.SysUserAttr{}).Where("attr_id = ?", attrId).Last(&attr).Error; err != nil {
+		return attr, errors.Wrap(err, "failed to retrieve user attribute by ID")
+	}
+
+	return attr, nil
+}
+
+// GetUserAttrByAttrKey retrieves custom user attribute details by key
+func (s *UserAttrService) GetUserAttrByAttrKey(attrKey string) dto.UserAttrDetailResponse {
+	attr, _ := s.GetUserAttrByAttrKeyWithErr(attrKey)
+	return attr
+}
+
+// GetUserAttrByAttrKeyWithErr retrieves custom user attribute details by key with proper error handling
+func (s *UserAttrService) GetUserAttrByAttrKeyWithErr(attrKey string) (dto.UserAttrDetailResponse, error) {
+	var attr dto.UserAttrDetailResponse
+
+	if attrKey == "" {
+		return attr, xerrors.ErrUserAttrKeyEmpty
+	}
+
+	if err := dal.Gorm.Model(model.SysUserAttr{}).Where("attr_key = ?", attrKey).Last(&attr).Error; err != nil {
+		return attr, errors.Wrap(err, "failed to retrieve user attribute by key")
+	}
+
+	return attr, nil
+}
+
+// GetUserAttrValues retrieves the values of the enabled custom attributes of a user, keyed by attribute key
+func (s *UserAttrService) GetUserAttrValues(userId int) map[string]string {
+	values, _ := userAttrValueMap([]int{userId})
+	if values[userId] == nil {
+		return make(map[string]string)
+	}
+	return values[userId]
+}
+
+// CheckUserAttrValues validates custom attribute values against the enabled attributes
+//
+// Every key must name an enabled attribute, every required attribute needs a value and
+// non-empty values must suit the type, the dictionary and the pattern of their attribute.
+// The values replace all current ones when saved, so a missing key counts as empty.
+func (s *UserAttrService) CheckUserAttrValues(values map[string]string) error {
+	attrs, err := activeUserAttrs(dal.Gorm)
+	if err != nil {
+		return err
+	}
+
+	return checkUserAttrValues(attrs, values)
+}
+
+// checkUserAttrValues validates custom attribute values against the given enabled attributes
+func checkUserAttrValues(attrs []dto.UserAttrListResponse, values map[string]string) error {
+	byKey := make(map[string]dto.UserAttrListResponse, len(attrs))
+	for _, attr := range attrs {
+		byKey[attr.AttrKey] = attr
+	}
+
+	for key := range values {
+		if _, ok := byKey[key]; !ok {
+			return errors.Wrap(xerrors.ErrUserAttrUnknown, key)
+		}
+	}
+
+	for _, attr := range attrs {
+		value := values[attr.AttrKey]
+		if value == "" {
+			if attr.IsRequired == constant.IS_DEFAULT_YES {
+				return errors.Wrap(xerrors.ErrUserAttrRequired, attr.AttrLabel)
+			}
+			continue
+		}
+		if !validUserAttrValue(attr, value) {
+			return errors.Wrap(xerrors.ErrUserAttrValueInvalid, attr.AttrLabel)
+		}
+	}
+
+	return nil
+}
+
+// validUserAttrValue reports whether a non-empty value suits its attribute
+func validUserAttrValue(attr dto.UserAttrListResponse, value string) bool {
+	if utf8.RuneCountInString(value) > USER_ATTR_VALUE_MAX_LENGTH {
+		return false
+	}
+
+	switch attr.AttrType {
+	case constant.USER_ATTR_TYPE_NUMBER:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return false
+		}
+	case constant.USER_ATTR_TYPE_DATE:
+		if _, err := time.Parse(constant.USER_ATTR_DATE_LAYOUT, value); err != nil {
+			return false
+		}
+	case constant.USER_ATTR_TYPE_SELECT:
+		dictDatas, err := (&DictDataService{}).GetDictDataCacheByDictTypeWithErr(attr.DictType)
+		if err != nil {
+			return false
+		}
+		found := false
+		for _, dictData := range dictDatas {
+			if dictData.DictValue == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if attr.Pattern != "" {
+		r, err := regexp.Compile(attr.Pattern)
+		if err != nil || !r.MatchString(value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// activeUserAttrs retrieves the enabled custom user attributes in display order
+func activeUserAttrs(db *gorm.DB) ([]dto.UserAttrListResponse, error) {
+	attrs := make([]dto.UserAttrListResponse, 0)
+
+	if err := db.Model(model.SysUserAttr{}).Where("status = ?", constant.NORMAL_STATUS).Order("attr_sort, attr_id").Find(&attrs).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve active user attributes")
+	}
+
+	return attrs, nil
+}
+
+// saveUserAttrValues replaces the values of the enabled custom attributes of a user,
+// keeping the values of disabled attributes; empty values are not stored
+func saveUserAttrValues(tx *gorm.DB, userId int, values map[string]string) error {
+	attrs, err := activeUserAttrs(tx)
+	if err != nil {
+		return err
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	attrIds := make([]int, 0, len(attrs))
+	for _, attr := range attrs {
+		attrIds = append(attrIds, attr.AttrId)
+	}
+
+	if err = tx.Where("user_id = ? AND attr_id IN ?", userId, attrIds).Delete(&model.SysUserAttrValue{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to delete attribute values of user ID %d", userId)
+	}
+
+	rows := make([]model.SysUserAttrValue, 0, len(values))
+	for _, attr := range attrs {
+		if value := values[attr.AttrKey]; value != "" {
+			rows = append(rows, model.SysUserAttrValue{UserId: userId, AttrId: attr.AttrId, AttrValue: value})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	if err = tx.Create(&rows).Error; err != nil {
+		return errors.Wrapf(err, "failed to save attribute values of user ID %d", userId)
+	}
+
+	return nil
+}
+
+// userAttrValueMap retrieves the values of the enabled custom attributes of users, keyed by user ID and attribute key
+func userAttrValueMap(userIds []int) (map[int]map[string]string, error) {
+	values := make(map[int]map[string]string)
+	if len(userIds) == 0 {
+		return values, nil
+	}
+
+	var rows []struct {
+		UserId    int
+		AttrKey   string
+		AttrValue string
+	}
+
+	if err := dal.Gorm.Model(model.SysUserAttrValue{}).
+		Select("sys_user_attr_value.user_id", "sys_user_attr.attr_key", "sys_user_attr_value.attr_value").
+		Joins("JOIN sys_user_attr ON sys_user_attr.attr_id = sys_user_attr_value.attr_id AND sys_user_attr.status = ?", constant.NORMAL_STATUS).
+		Where("sys_user_attr_value.user_id IN ?", userIds).
+		Find(&rows).Error; err != nil {
+		return values, errors.Wrap(err, "failed to retrieve user attribute values")
+	}
+
+	for _, row := range rows {
+		if values[row.UserId] == nil {
+			values[row.UserId] = make(map[string]string)
+		}
+		values[row.UserId][row.AttrKey] = row.AttrValue
+	}
+
+	return values, nil
+}
+
+// userAttrFilterScope restricts a user query to the users whose searchable attributes match
+// the filters: select, number and date attributes match exactly, text attributes by substring.
+// Filters on unknown or unsearchable attributes and empty filters are ignored.
+func userAttrFilterScope(filters map[string]string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(filters) == 0 {
+			return db
+		}
+
+		attrs, err := activeUserAttrs(dal.Gorm)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+
+		for _, attr := range attrs {
+			value := filters[attr.AttrKey]
+			if value == "" || attr.IsSearchable != constant.IS_DEFAULT_YES {
+				continue
+			}
+
+			condition, arg := "sys_user_attr_value.attr_value = ?", value
+			if attr.AttrType == constant.USER_ATTR_TYPE_TEXT {
+				condition, arg = "sys_user_attr_value.attr_value LIKE ?", "%"+value+"%"
+			}
+
+			db = db.Where("EXISTS (SELECT 1 FROM sys_user_attr_value WHERE sys_user_attr_value.user_id = sys_user.user_id"+
+				" AND sys_user_attr_value.attr_id = ? AND "+condition+")", attr.AttrId, arg)
+		}
+
+		return db
+	}
+}
+
+// userAttrExportColumns returns a column per enabled custom attribute with the selects reading its value
+func userAttrExportColumns() ([]ExportColumn, []string) {
+	attrs, _ := activeUserAttrs(dal.Gorm)
+
+	columns := make([]ExportColumn, 0, len(attrs))
+	selects := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		field := "attr_" + attr.AttrKey
+		column := ExportColumn{Key: "attrs." + attr.AttrKey, Field: field, Header: attr.AttrLabel, HeaderZh: attr.AttrLabel}
+		if attr.AttrType == constant.USER_ATTR_TYPE_SELECT {
+			column.DictType = attr.DictType
+		}
+		columns = append(columns, column)
+
+		// Attribute keys are restricted to letters, digits and underscores, so they are safe as aliases
+		selects = append(selects, fmt.Sprintf("(SELECT attr_value FROM sys_user_attr_value WHERE sys_user_attr_value.user_id = sys_user.user_id"+
+			" AND sys_user_attr_value.attr_id = %d) AS %s", attr.AttrId, field))
+	}
+
+	return columns, selects
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func seedUserAttr() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", NickName: "Admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 100, UserName: "alice", NickName: "Alice", Status: "0"})
+	seedUserAttrDefinitions()
+}
+
+// seedUserAttrDefinitions creates a required searchable text attribute, a searchable select,
+// an optional date and a disabled number attribute
+func seedUserAttrDefinitions() {
+	dal.Gorm.Create(&model.SysDictData{DictCode: 1, DictLabel: "Small", DictValue: "S", DictType: "user_shirt_size", Status: "0"})
+	dal.Gorm.Create(&model.SysDictData{DictCode: 2, DictLabel: "Large", DictValue: "L", DictType: "user_shirt_size", Status: "0"})
+
+	dal.Gorm.Create(&model.SysUserAttr{AttrId: 1, AttrKey: "employee_no", AttrLabel: "Employee Number", AttrType: constant.USER_ATTR_TYPE_TEXT, Pattern: "^E[0-9]+$", IsRequired: "Y", IsSearchable: "Y", AttrSort: 1, Status: "0"})
+	dal.Gorm.Create(&model.SysUserAttr{AttrId: 2, AttrKey: "shirt_size", AttrLabel: "Shirt Size", AttrType: constant.USER_ATTR_TYPE_SELECT, DictType: "user_shirt_size", IsSearchable: "Y", AttrSort: 2, Status: "0"})
+	dal.Gorm.Create(&model.SysUserAttr{AttrId: 3, AttrKey: "hired_on", AttrLabel: "Hired On", AttrType: constant.USER_ATTR_TYPE_DATE, AttrSort: 3, Status: "0"})
+	dal.Gorm.Create(&model.SysUserAttr{AttrId: 4, AttrKey: "badge", AttrLabel: "Badge", AttrType: constant.USER_ATTR_TYPE_NUMBER, AttrSort: 4, Status: "1"})
+}
+
+func TestUserAttrService_CRUD(t *testing.T) {
+	setup()
+	defer teardown()
+	s := NewUserAttrService()
+
+	t.Run("should create and update an attribute", func(t *testing.T) {
+		assert.NoError(t, s.CreateUserAttr(dto.SaveUserAttr{AttrKey: "desk", AttrLabel: "Desk", AttrType: constant.USER_ATTR_TYPE_TEXT, Pattern: "^D", Status: "0"}))
+
+		attr := s.GetUserAttrByAttrKey("desk")
+		assert.NotZero(t, attr.AttrId)
+		assert.Equal(t, constant.IS_DEFAULT_NO, attr.IsRequired)
+
+		assert.NoError(t, s.UpdateUserAttr(dto.SaveUserAttr{AttrId: attr.AttrId, AttrKey: "desk", AttrLabel: "Desk Number", AttrType: constant.USER_ATTR_TYPE_TEXT, Status: "0"}))
+		attr = s.GetUserAttrByAttrId(attr.AttrId)
+		assert.Equal(t, "Desk Number", attr.AttrLabel)
+		assert.Empty(t, attr.Pattern)
+
+		attrs, total := s.GetUserAttrList(dto.UserAttrListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}, AttrLabel: "Desk"}, true)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "desk", attrs[0].AttrKey)
+	})
+
+	t.Run("should delete an attribute with its values", func(t *testing.T) {
+		attr := s.GetUserAttrByAttrKey("desk")
+		dal.Gorm.Create(&model.SysUserAttrValue{UserId: 2, AttrId: attr.AttrId, AttrValue: "D12"})
+
+		assert.NoError(t, s.DeleteUserAttr([]int{attr.AttrId}))
+
+		var count int64
+		dal.Gorm.Model(model.SysUserAttrValue{}).Where("attr_id = ?", attr.AttrId).Count(&count)
+		assert.Zero(t, count)
+		assert.Zero(t, s.GetUserAttrByAttrKey("desk").AttrId)
+	})
+
+	t.Run("should return error for invalid parameters", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrUserAttrKeyEmpty, s.CreateUserAttr(dto.SaveUserAttr{AttrLabel: "Desk"}))
+		assert.Equal(t, xerrors.ErrParam, s.UpdateUserAttr(dto.SaveUserAttr{AttrLabel: "Desk"}))
+		assert.Equal(t, xerrors.ErrParam, s.DeleteUserAttr(nil))
+	})
+}
+
+func TestUserAttrService_CheckUserAttrValues(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserAttr()
+	s := NewUserAttrService()
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		err    error
+	}{
+		{name: "valid", values: map[string]string{"employee_no": "E100", "shirt_size": "L", "hired_on": "2024-03-01"}},
+		{name: "optional attributes left empty", values: map[string]string{"employee_no": "E100", "shirt_size": ""}},
+		{name: "required attribute missing", values: map[string]string{"shirt_size": "S"}, err: xerrors.ErrUserAttrRequired},
+		{name: "unknown attribute", values: map[string]string{"employee_no": "E100", "floor": "3"}, err: xerrors.ErrUserAttrUnknown},
+		{name: "disabled attribute", values: map[string]string{"employee_no": "E100", "badge": "7"}, err: xerrors.ErrUserAttrUnknown},
+		{name: "pattern mismatch", values: map[string]string{"employee_no": "100"}, err: xerrors.ErrUserAttrValueInvalid},
+		{name: "value outside the dictionary", values: map[string]string{"employee_no": "E100", "shirt_size": "XL"}, err: xerrors.ErrUserAttrValueInvalid},
+		{name: "invalid date", values: map[string]string{"employee_no": "E100", "hired_on": "01/03/2024"}, err: xerrors.ErrUserAttrValueInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, errors.Cause(s.CheckUserAttrValues(tt.values)))
+		})
+	}
+}
+
+func TestUserAttrService_UserValues(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserAttr()
+	s := NewUserAttrService()
+	userService := &UserService{}
+	dal.Gorm.Create(&model.SysUserAttrValue{UserId: 2, AttrId: 4, AttrValue: "7"})
+
+	t.Run("should save the attributes with the user", func(t *testing.T) {
+		assert.NoError(t, userService.CreateUser(dto.SaveUser{UserName: "bob", NickName: "Bob", Password: "secret", Status: "0",
+			Attributes: map[string]string{"employee_no": "E200", "shirt_size": "S"}}, nil, nil))
+
+		userId := userService.GetUserByUsername("bob").UserId
+		assert.Equal(t, map[string]string{"employee_no": "E200", "shirt_size": "S"}, s.GetUserAttrValues(userId))
+	})
+
+	t.Run("should replace the enabled attributes and keep the disabled ones", func(t *testing.T) {
+		assert.NoError(t, userService.UpdateUser(dto.SaveUser{UserId: 2, Attributes: map[string]string{"employee_no": "E300", "hired_on": "2024-03-01"}}, nil, nil))
+		assert.Equal(t, map[string]string{"employee_no": "E300", "hired_on": "2024-03-01"}, s.GetUserAttrValues(2))
+
+		assert.NoError(t, userService.UpdateUser(dto.SaveUser{UserId: 2, NickName: "Alicia"}, nil, nil))
+		assert.Equal(t, map[string]string{"employee_no": "E300", "hired_on": "2024-03-01"}, s.GetUserAttrValues(2))
+
+		var count int64
+		dal.Gorm.Model(model.SysUserAttrValue{}).Where("user_id = ? AND attr_id = ?", 2, 4).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should list and filter users by searchable attributes", func(t *testing.T) {
+		param := dto.UserListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}}
+
+		users, total := userService.GetUserList(param, 1, true)
+		assert.Equal(t, 3, total)
+		assert.Empty(t, users[0].Attributes)
+		assert.Equal(t, "E300", users[1].Attributes["employee_no"])
+
+		param.Attrs = map[string]string{"employee_no": "E3"}
+		users, total = userService.GetUserList(param, 1, true)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "alice", users[0].UserName)
+
+		param.Attrs = map[string]string{"shirt_size": "S"}
+		users, _ = userService.GetUserList(param, 1, true)
+		assert.Len(t, users, 1)
+		assert.Equal(t, "bob", users[0].UserName)
+
+		// hired_on is not searchable
+		param.Attrs = map[string]string{"hired_on": "2099-01-01"}
+		_, total = userService.GetUserList(param, 1, true)
+		assert.Equal(t, 3, total)
+	})
+
+	t.Run("should export a column per enabled attribute", func(t *testing.T) {
+		dataset := userService.ExportDataset(dto.UserListRequest{}, 1)
+
+		var buf bytes.Buffer
+		_, err := NewExportService(NewBackgroundTaskService(1)).WriteExport(&buf, dataset, dto.ExportRequest{
+			Format:  constant.EXPORT_FORMAT_CSV,
+			Columns: "userName,attrs.employee_no,attrs.shirt_size",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "\xEF\xBB\xBFLogin Name,Employee Number,Shirt Size\nadmin,,\nalice,E300,\nbob,E200,Small\n", buf.String())
+	})
+}
+
+func TestUserAttrService_ImportUsers(t *testing.T) {
+	setup()
+	defer teardown()
+	seedImportJob()
+	seedUserAttrDefinitions()
+	s := NewImportJobService(NewBackgroundTaskService(1))
+
+	jobId, err := s.CreateImportJob(constant.IMPORT_JOB_TYPE_USER, "users.xlsx", "admin")
+	assert.NoError(t, err)
+
+	rows := []dto.UserImportRequest{
+		{DeptId: 100, UserName: "dave", NickName: "Dave", Status: "0", Attributes: "employee_no=E400; shirt_size=L"},
+		{DeptId: 100, UserName: "erin", NickName: "Erin", Status: "0"},
+		{DeptId: 100, UserName: "frank", NickName: "Frank", Status: "0", Attributes: "employee_no"},
+		{DeptId: 100, UserName: "manager", NickName: "Manager", Status: "0"},
+	}
+
+	assert.NoError(t, s.RunUserImport(jobId, rows, dto.ImportOptions{UpdateSupport: true, OperatorId: 1, OperatorName: "admin"}))
+
+	t.Run("should save the attributes of imported users", func(t *testing.T) {
+		userId := (&UserService{}).GetUserByUsername("dave").UserId
+		assert.Equal(t, map[string]string{"employee_no": "E400", "shirt_size": "L"}, NewUserAttrService().GetUserAttrValues(userId))
+	})
+
+	t.Run("should require the attributes of new users only", func(t *testing.T) {
+		job, _ := s.GetImportJob(jobId)
+		assert.Equal(t, 2, job.Succeeded)
+
+		rowErrors, err := s.GetImportJobErrors(jobId)
+		assert.NoError(t, err)
+		assert.Len(t, rowErrors, 2)
+		assert.Contains(t, rowErrors[0].ErrorMsg, xerrors.ErrUserAttrRequired.Error())
+		assert.Contains(t, rowErrors[1].ErrorMsg, xerrors.ErrImportAttrFormat.Error())
+	})
+}
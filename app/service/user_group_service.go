@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	rediskey "mira/common/types/redis-key"
+)
+
+// UserGroupServiceInterface defines operations for user group management
+type UserGroupServiceInterface interface {
+	CreateUserGroup(param dto.SaveUserGroup, roleIds []int) error
+	UpdateUserGroup(param dto.SaveUserGroup, roleIds []int) error
+	DeleteUserGroup(groupIds []int, deleteBy string) error
+	GetUserGroupList(param dto.UserGroupListRequest, isPaging bool) ([]dto.UserGroupListResponse, int)
+	GetUserGroupByGroupId(groupId int) dto.UserGroupDetailResponse
+	GetUserGroupByGroupName(groupName string) dto.UserGroupDetailResponse
+	GetMemberList(param dto.UserGroupMemberListRequest, userId int, isAllocation bool) ([]dto.UserListResponse, int)
+	AddMembers(groupId int, userIds []int) error
+	RemoveMembers(groupId int, userIds []int) error
+	GetGroupIdsByRoleId(roleId int) []int
+	UpdateRoleGroups(roleId int, groupIds []int) error
+}
+
+// UserGroupService implements user group management
+//
+// A user group gathers users across departments. The members of a normal group hold
+// the roles assigned to the group on top of their own roles, and a role with a custom
+// data scope may select groups next to departments, covering the data of their members.
+// Changes to a group drop the cached permissions of the users they affect.
+type UserGroupService struct{}
+
+// Ensure UserGroupService implements UserGroupServiceInterface
+var _ UserGroupServiceInterface = (*UserGroupService)(nil)
+
+// NewUserGroupService creates a new UserGroupService
+func NewUserGroupService() *UserGroupService {
+	return &UserGroupService{}
+}
+
+// CreateUserGroup creates a new user group with its roles
+func (s *UserGroupService) CreateUserGroup(param dto.SaveUserGroup, roleIds []int) error {
+	return s.CreateUserGroupWithErr(param, roleIds)
+}
+
+// CreateUserGroupWithErr creates a new user group with proper error handling
+func (s *UserGroupService) CreateUserGroupWithErr(param dto.SaveUserGroup, roleIds []int) error {
+	if param.GroupName == "" {
+		return xerrors.ErrUserGroupNameEmpty
+	}
+
+	tx := dal.Gorm.Begin()
+
+	group := model.SysUserGroup{
+		GroupName: param.GroupName,
+		GroupSort: param.GroupSort,
+		Status:    param.Status,
+		CreateBy:  param.CreateBy,
+		Remark:    param.Remark,
+	}
+
+	if err := tx.Model(model.SysUserGroup{}).Create(&group).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to create user group")
+	}
+
+	if err := saveUserGroupRoles(tx, group.GroupId, roleIds); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// UpdateUserGroup updates a user group; nil roleIds keeps the current roles
+func (s *UserGroupService) UpdateUserGroup(param dto.SaveUserGroup, roleIds []int) error {
+	return s.UpdateUserGroupWithErr(param, roleIds)
+}
+
+// UpdateUserGroupWithErr updates a user group with proper error handling
+func (s *UserGroupService) UpdateUserGroupWithErr(param dto.SaveUserGroup, roleIds []int) error {
+	if param.GroupId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if param.GroupName == "" {
+		return xerrors.ErrUserGroupNameEmpty
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Model(model.SysUserGroup{}).Where("group_id = ?", param.GroupId).Updates(&model.SysUserGroup{
+		GroupName: param.GroupName,
+		GroupSort: param.GroupSort,
+		Status:    param.Status,
+		UpdateBy:  param.UpdateBy,
+		Remark:    param.Remark,
+	}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to update user group")
+	}
+
+	if roleIds != nil {
+		if err := tx.Model(model.SysUserGroupRole{}).Where("group_id = ?", param.GroupId).Delete(&model.SysUserGroupRole{}).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "failed to delete user group roles")
+		}
+		if err := saveUserGroupRoles(tx, param.GroupId, roleIds); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	// The status and the roles of the group both change what its members hold
+	invalidateUserPermCaches(groupMemberIds(param.GroupId))
+
+	return nil
+}
+
+// DeleteUserGroup deletes user groups, keeping them in the recycle bin
+func (s *UserGroupService) DeleteUserGroup(groupIds []int, deleteBy string) error {
+	return s.DeleteUserGroupWithErr(groupIds, deleteBy)
+}
+
+// DeleteUserGroupWithErr deletes user groups with proper error handling
+//
+// The members, roles and data scope links of a deleted group are kept, so restoring it
+// from the recycle bin brings them back; they are ignored while the group is deleted.
+func (s *UserGroupService) DeleteUserGroupWithErr(groupIds []int, deleteBy string) error {
+	if len(groupIds) == 0 {
+		return xerrors.ErrParam
+	}
+
+	userIds := groupMemberIds(groupIds...)
+
+	tx := dal.Gorm.Begin()
+
+	if err := recycleUserGroups(tx, groupIds, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(model.SysUserGroup{}).Where("group_id IN ?", groupIds).Delete(&model.SysUserGroup{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrap(err, "failed to delete user groups")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	invalidateUserPermCaches(userIds)
+
+	return nil
+}
+
+// GetUserGroupList retrieves a list of user groups based on search parameters
+func (s *UserGroupService) GetUserGroupList(param dto.UserGroupListRequest, isPaging bool) ([]dto.UserGroupListResponse, int) {
+	groups, count, _ := s.GetUserGroupListWithErr(param, isPaging)
+	return groups, count
+}
+
+// GetUserGroupListWithErr retrieves a list of user groups with proper error handling
+func (s *UserGroupService) GetUserGroupListWithErr(param dto.UserGroupListRequest, isPaging bool) ([]dto.UserGroupListResponse, int, error) {
+	var count int64
+	groups := make([]dto.UserGroupListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysUserGroup{}).
+		Select(
+			"sys_user_group.*",
+			"(SELECT COUNT(*) FROM sys_user_group_user JOIN sys_user ON sys_user.user_id = sys_user_group_user.user_id AND sys_user.delete_time IS NULL"+
+				" WHERE sys_user_group_user.group_id = sys_user_group.group_id) AS member_count",
+		).
+		Order("sys_user_group.group_sort, sys_user_group.group_id")
+
+	if param.GroupName != "" {
+		query = query.Where("sys_user_group.group_name LIKE ?", "%"+param.GroupName+"%")
+	}
+
+	if param.Status != "" {
+		query = query.Where("sys_user_group.status = ?", param.Status)
+	}
+
+	if isPaging {
+		if err := query.Count(&count).Error; err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count user groups")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err := query.Find(&groups).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to retrieve user groups")
+	}
+
+	return groups, int(count), nil
+}
+
+// GetUserGroupByGroupId retrieves user group details with its roles by ID
+func (s *UserGroupService) GetUserGroupByGroupId(groupId int) dto.UserGroupDetailResponse {
+	group, _ := s.GetUserGroupByGroupIdWithErr(groupId)
+	return group
+}
+
+// GetUserGroupByGroupIdWithErr retrieves user group details with proper error handling
+func (s *UserGroupService) GetUserGroupByGroupIdWithErr(groupId int) (dto.UserGroupDetailResponse, error) {
+	var group dto.UserGroupDetailResponse
+
+	if groupId <= 0 {
+		return group, xerrors.ErrParam
+	}
+
+	if err := dal.Gorm.Model(model.SysUserGroup{}).Where("group_id = ?", groupId).Last(&group).Error; err != nil {
+		return group, errors.Wrap(err, "failed to retrieve user group by ID")
+	}
+
+	group.RoleIds = make([]int, 0)
+	if err := dal.Gorm.Model(model.SysUserGroupRole{}).Where("group_id = ?", groupId).Pluck("role_id", &group.RoleIds).Error; err != nil {
+		return group, errors.Wrap(err, "failed to retrieve user group roles")
+	}
+
+	return group, nil
+}
+
+// GetUserGroupByGroupName retrieves user group details by name
+func (s *UserGroupService) GetUserGroupByGroupName(groupName string) dto.UserGroupDetailResponse {
+	group, _ := s.GetUserGroupByGroupNameWithErr(groupName)
+	return group
+}
+
+// GetUserGroupByGroupNameWithErr retrieves user group details by name with proper error handling
+func (s *UserGroupService) GetUserGroupByGroupNameWithErr(groupName string) (dto.UserGroupDetailResponse, error) {
+	var group dto.UserGroupDetailResponse
+
+	if groupName == "" {
+		return group, xerrors.ErrUserGroupNameEmpty
+	}
+
+	if err := dal.Gorm.Model(model.SysUserGroup{}).Where("group_name = ?", groupName).Last(&group).Error; err != nil {
+		return group, errors.Wrap(err, "failed to retrieve user group by name")
+	}
+
+	return group, nil
+}
+
+// GetMemberList retrieves the users in or outside a user group
+func (s *UserGroupService) GetMemberList(param dto.UserGroupMemberListRequest, userId int, isAllocation bool) ([]dto.UserListResponse, int) {
+	users, count, _ := s.GetMemberListWithErr(param, userId, isAllocation)
+	return users, count
+}
+
+// GetMemberListWithErr retrieves the users in or outside a user group with proper error handling
+//
+// Parameters:
+//   - param: Request object containing query conditions
+//   - userId: The ID of the currently authorized user (for data scope)
+//   - isAllocation: true for the members of the group, false for the other users
+func (s *UserGroupService) GetMemberListWithErr(param dto.UserGroupMemberListRequest, userId int, isAllocation bool) ([]dto.UserListResponse, int, error) {
+	var count int64
+	users := make([]dto.UserListResponse, 0)
+
+	query := dal.Gorm.Model(model.SysUser{}).
+		Select("sys_user.*", "sys_dept.dept_name", "sys_dept.leader").
+		Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
+		Scopes(GetDataScope("sys_dept", userId, "sys_user")).
+		Order("sys_user.user_id")
+
+	if isAllocation {
+		query = query.Joins("JOIN sys_user_group_user ON sys_user_group_user.user_id = sys_user.user_id AND sys_user_group_user.group_id = ?", param.GroupId)
+	} else {
+		query = query.Joins("LEFT JOIN sys_user_group_user ON sys_user_group_user.user_id = sys_user.user_id AND sys_user_group_user.group_id = ?", param.GroupId).
+			Where("sys_user_group_user.user_id IS NULL")
+	}
+
+	if param.UserName != "" {
+		query = query.Where("sys_user.user_name LIKE ?", "%"+param.UserName+"%")
+	}
+
+	if param.Phonenumber != "" {
+		query = query.Where(piiContains("sys_user.phonenumber", param.Phonenumber))
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to count members of user group ID %d", param.GroupId)
+	}
+
+	query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+
+	if err := query.Find(&users).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed to query members of user group ID %d", param.GroupId)
+	}
+
+	return users, int(count), nil
+}
+
+// AddMembers adds users to a user group, skipping the current members
+func (s *UserGroupService) AddMembers(groupId int, userIds []int) error {
+	return s.AddMembersWithErr(groupId, userIds)
+}
+
+// AddMembersWithErr adds users to a user group with proper error handling
+func (s *UserGroupService) AddMembersWithErr(groupId int, userIds []int) error {
+	if groupId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if len(userIds) == 0 {
+		return xerrors.ErrUserGroupMembersEmpty
+	}
+
+	memberIds := groupMemberIds(groupId)
+
+	members := make([]model.SysUserGroupUser, 0, len(userIds))
+	for _, userId := range userIds {
+		if !utils.Contains(memberIds, userId) {
+			members = append(members, model.SysUserGroupUser{GroupId: groupId, UserId: userId})
+			memberIds = append(memberIds, userId)
+		}
+	}
+
+	if len(members) == 0 {
+		return nil
+	}
+
+	if err := dal.Gorm.Create(&members).Error; err != nil {
+		return errors.Wrapf(err, "failed to add members to user group ID %d", groupId)
+	}
+
+	invalidateUserPermCaches(userIds)
+
+	return nil
+}
+
+// RemoveMembers removes users from a user group
+func (s *UserGroupService) RemoveMembers(groupId int, userIds []int) error {
+	return s.RemoveMembersWithErr(groupId, userIds)
+}
+
+// RemoveMembersWithErr removes users from a user group with proper error handling
+func (s *UserGroupService) RemoveMembersWithErr(groupId int, userIds []int) error {
+	if groupId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if len(userIds) == 0 {
+		return xerrors.ErrUserGroupMembersEmpty
+	}
+
+	if err := dal.Gorm.Where("group_id = ? AND user_id IN ?", groupId, userIds).Delete(&model.SysUserGroupUser{}).Error; err != nil {
+		return errors.Wrapf(err, "failed to remove members from user group ID %d", groupId)
+	}
+
+	invalidateUserPermCaches(userIds)
+
+	return nil
+}
+
+// GetGroupIdsByRoleId retrieves the user groups selected by the custom data scope of a role
+func (s *UserGroupService) GetGroupIdsByRoleId(roleId int) []int {
+	groupIds, _ := s.GetGroupIdsByRoleIdWithErr(roleId)
+	return groupIds
+}
+
+// GetGroupIdsByRoleIdWithErr retrieves the user groups selected by a role with proper error handling
+func (s *UserGroupService) GetGroupIdsByRoleIdWithErr(roleId int) ([]int, error) {
+	groupIds := make([]int, 0)
+
+	if roleId <= 0 {
+		return groupIds, xerrors.ErrParam
+	}
+
+	if err := dal.Gorm.Model(model.SysRoleGroup{}).
+		Joins("JOIN sys_user_group ON sys_user_group.group_id = sys_role_group.group_id AND sys_user_group.delete_time IS NULL").
+		Where("sys_role_group.role_id = ?", roleId).
+		Pluck("sys_role_group.group_id", &groupIds).Error; err != nil {
+		return groupIds, errors.Wrapf(err, "failed to retrieve user groups of role ID %d", roleId)
+	}
+
+	return groupIds, nil
+}
+
+// UpdateRoleGroups replaces the user groups selected by the custom data scope of a role
+func (s *UserGroupService) UpdateRoleGroups(roleId int, groupIds []int) error {
+	return s.UpdateRoleGroupsWithErr(roleId, groupIds)
+}
+
+// UpdateRoleGroupsWithErr replaces the user groups selected by a role with proper error handling
+func (s *UserGroupService) UpdateRoleGroupsWithErr(roleId int, groupIds []int) error {
+	if roleId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err := tx.Where("role_id = ?", roleId).Delete(&model.SysRoleGroup{}).Error; err != nil {
+		tx.Rollback()
+		return errors.Wrapf(err, "failed to delete user groups of role ID %d", roleId)
+	}
+
+	for _, groupId := range groupIds {
+		if err := tx.Create(&model.SysRoleGroup{RoleId: roleId, GroupId: groupId}).Error; err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "failed to associate role ID %d with user group ID %d", roleId, groupId)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	userIds := make([]int, 0)
+	dal.Gorm.Table("(?) AS user_roles", userRoleQuery(0)).Where("role_id = ?", roleId).Distinct().Pluck("user_id", &userIds)
+	invalidateUserPermCaches(userIds)
+
+	return nil
+}
+
+// saveUserGroupRoles assigns roles to a user group
+func saveUserGroupRoles(tx *gorm.DB, groupId int, roleIds []int) error {
+	for _, roleId := range roleIds {
+		if err := tx.Create(&model.SysUserGroupRole{GroupId: groupId, RoleId: roleId}).Error; err != nil {
+			return errors.Wrapf(err, "failed to assign role ID %d to user group ID %d", roleId, groupId)
+		}
+	}
+
+	return nil
+}
+
+// groupMemberIds returns the users belonging to any of the user groups
+func groupMemberIds(groupIds ...int) []int {
+	userIds := make([]int, 0)
+	dal.Gorm.Model(model.SysUserGroupUser{}).Where("group_id IN ?", groupIds).Distinct().Pluck("user_id", &userIds)
+	return userIds
+}
+
+// userRoleQuery selects the user_id and role_id pairs of the roles users hold directly, through
+// the normal user groups they belong to or as the default roles of the normal posts they hold,
+// for one user or for every user when userId is 0. It stands in for sys_user_role wherever
+// permissions are resolved.
+func userRoleQuery(userId int) *gorm.DB {
+	direct := "SELECT user_id, role_id FROM sys_user_role"
+	grouped := "SELECT sys_user_group_user.user_id, sys_user_group_role.role_id FROM sys_user_group_user" +
+		" JOIN sys_user_group_role ON sys_user_group_role.group_id = sys_user_group_user.group_id" +
+		" JOIN sys_user_group ON sys_user_group.group_id = sys_user_group_user.group_id" +
+		" AND sys_user_group.status = ? AND sys_user_group.delete_time IS NULL"
+	posted := "SELECT sys_user_post.user_id, sys_post_role.role_id FROM sys_user_post" +
+		" JOIN sys_post_role ON sys_post_role.post_id = sys_user_post.post_id" +
+		" JOIN sys_post ON sys_post.post_id = sys_user_post.post_id" +
+		" AND sys_post.status = ? AND sys_post.delete_time IS NULL"
+
+	if userId <= 0 {
+		return dal.Gorm.Raw(direct+" UNION "+grouped+" UNION "+posted, constant.NORMAL_STATUS, constant.NORMAL_STATUS)
+	}
+
+	return dal.Gorm.Raw(direct+" WHERE user_id = ? UNION "+grouped+" WHERE sys_user_group_user.user_id = ? UNION "+
+		posted+" WHERE sys_user_post.user_id = ?",
+		userId, constant.NORMAL_STATUS, userId, constant.NORMAL_STATUS, userId)
+}
+
+// invalidateUserPermCaches drops the cached permissions, roles and data scopes of users after
+// their group or post roles change. The cache is best effort; failures only delay the refresh until expiry.
+func invalidateUserPermCaches(userIds []int) {
+	if len(userIds) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(userIds)*8)
+	for _, userId := range userIds {
+		keys = append(keys,
+			rediskey.UserPermsKey(userId), rediskey.UserAllPermsKey(userId),
+			rediskey.UserMenuPermsKey(userId), rediskey.UserBtnPermsKey(userId), rediskey.UserRolesKey(userId),
+			rediskey.UserDataScopeKey(userId), rediskey.UserDataScopeDeptsKey(userId), rediskey.UserDataScopeUsersKey(userId),
+		)
+	}
+
+	NewCacheService().DeleteMultiple(context.Background(), keys)
+	publishCacheIds(constant.CACHE_EVENT_USER, userIds...)
+}
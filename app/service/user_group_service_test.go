@@ -0,0 +1,258 @@
+package service
+
+import (
+	"testing"
+
+	"mira/anima/dal"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+
+	rediskey "mira/common/types/redis-key"
+)
+
+func seedUserGroup() {
+	dal.Gorm.Create(&model.SysDept{DeptId: 100, ParentId: 0, Ancestors: "0", DeptName: "HQ"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 101, ParentId: 100, Ancestors: "0,100", DeptName: "Sales"})
+	dal.Gorm.Create(&model.SysDept{DeptId: 102, ParentId: 100, Ancestors: "0,100", DeptName: "R&D"})
+
+	dal.Gorm.Create(&model.SysUser{UserId: 1, DeptId: 100, UserName: "admin", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 2, DeptId: 101, UserName: "alice", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 102, UserName: "bob", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 102, UserName: "carol", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRole{RoleId: 2, RoleName: "Viewer", RoleKey: "viewer", DataScope: DATA_SCOPE_CUSTOM, Status: "0"})
+	dal.Gorm.Create(&model.SysRole{RoleId: 3, RoleName: "Editor", RoleKey: "editor", DataScope: DATA_SCOPE_PERSONAL, Status: "0"})
+
+	dal.Gorm.Create(&model.SysMenu{MenuId: 1, MenuName: "User", MenuType: "C", Perms: "system:user:list", Status: "0"})
+	dal.Gorm.Create(&model.SysMenu{MenuId: 2, MenuName: "Edit User", MenuType: "F", Perms: "system:user:edit", Status: "0"})
+
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 2, MenuId: 1})
+	dal.Gorm.Create(&model.SysRoleMenu{RoleId: 3, MenuId: 2})
+	dal.Gorm.Create(&model.SysRoleDept{RoleId: 2, DeptId: 101})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 2, RoleId: 2})
+}
+
+// createUserGroup creates a user group and returns its ID
+func createUserGroup(t *testing.T, s *UserGroupService, groupName string, roleIds []int, userIds ...int) int {
+	assert.NoError(t, s.CreateUserGroup(dto.SaveUserGroup{GroupName: groupName, Status: constant.NORMAL_STATUS, CreateBy: "admin"}, roleIds))
+
+	group := s.GetUserGroupByGroupName(groupName)
+	if len(userIds) > 0 {
+		assert.NoError(t, s.AddMembers(group.GroupId, userIds))
+	}
+
+	return group.GroupId
+}
+
+func TestUserGroupService_CreateUserGroup(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserGroup()
+	s := NewUserGroupService()
+
+	t.Run("should create the group with its roles", func(t *testing.T) {
+		groupId := createUserGroup(t, s, "Project X", []int{2, 3})
+
+		group := s.GetUserGroupByGroupId(groupId)
+		assert.Equal(t, "Project X", group.GroupName)
+		assert.ElementsMatch(t, []int{2, 3}, group.RoleIds)
+	})
+
+	t.Run("should replace the roles only when given", func(t *testing.T) {
+		groupId := s.GetUserGroupByGroupName("Project X").GroupId
+
+		assert.NoError(t, s.UpdateUserGroup(dto.SaveUserGroup{GroupId: groupId, GroupName: "Project Y", Status: constant.NORMAL_STATUS}, nil))
+		assert.ElementsMatch(t, []int{2, 3}, s.GetUserGroupByGroupId(groupId).RoleIds)
+
+		assert.NoError(t, s.UpdateUserGroup(dto.SaveUserGroup{GroupId: groupId, GroupName: "Project Y", Status: constant.NORMAL_STATUS}, []int{3}))
+		group := s.GetUserGroupByGroupId(groupId)
+		assert.Equal(t, "Project Y", group.GroupName)
+		assert.Equal(t, []int{3}, group.RoleIds)
+	})
+
+	t.Run("should return error when the group name is empty", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrUserGroupNameEmpty, s.CreateUserGroup(dto.SaveUserGroup{}, nil))
+	})
+}
+
+func TestUserGroupService_Members(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserGroup()
+	s := NewUserGroupService()
+	groupId := createUserGroup(t, s, "Project X", nil, 3, 4)
+
+	t.Run("should skip the current members", func(t *testing.T) {
+		assert.NoError(t, s.AddMembers(groupId, []int{2, 3}))
+
+		groups, total := s.GetUserGroupList(dto.UserGroupListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}}, true)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, 3, groups[0].MemberCount)
+	})
+
+	t.Run("should list the members and the other users", func(t *testing.T) {
+		param := dto.UserGroupMemberListRequest{PageRequest: dto.PageRequest{PageNum: 1, PageSize: 10}, GroupId: groupId}
+
+		members, total := s.GetMemberList(param, 1, true)
+		assert.Equal(t, 3, total)
+		assert.Equal(t, "alice", members[0].UserName)
+
+		others, total := s.GetMemberList(param, 1, false)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "admin", others[0].UserName)
+	})
+
+	t.Run("should remove members", func(t *testing.T) {
+		assert.NoError(t, s.RemoveMembers(groupId, []int{2, 4}))
+		assert.Equal(t, []int{3}, groupMemberIds(groupId))
+	})
+
+	t.Run("should return error when no members are given", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrUserGroupMembersEmpty, s.AddMembers(groupId, nil))
+		assert.Equal(t, xerrors.ErrParam, s.RemoveMembers(0, []int{3}))
+	})
+}
+
+func TestUserGroupService_GroupRoles(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserGroup()
+	s := NewUserGroupService()
+	userService := &UserService{}
+	menuService := &MenuService{}
+	groupId := createUserGroup(t, s, "Project X", []int{3}, 2, 3)
+
+	t.Run("should grant the group roles to the members", func(t *testing.T) {
+		assert.True(t, userService.UserHasPerms(3, []string{"system:user:edit"}))
+		assert.True(t, userService.UserHasRoles(3, []string{"editor"}))
+		assert.Equal(t, []string{"system:user:edit"}, menuService.GetPermsByUserId(3))
+		assert.False(t, userService.UserHasPerms(4, []string{"system:user:edit"}))
+	})
+
+	t.Run("should combine the group roles with the direct roles", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"system:user:list", "system:user:edit"}, menuService.GetPermsByUserId(2))
+
+		roles := (&RoleService{}).GetRoleListByUserIdCompat(2)
+		assert.Len(t, roles, 2)
+	})
+
+	t.Run("should not grant the roles of a disabled group", func(t *testing.T) {
+		assert.NoError(t, s.UpdateUserGroup(dto.SaveUserGroup{GroupId: groupId, GroupName: "Project X", Status: "1"}, nil))
+
+		assert.False(t, userService.UserHasPerms(3, []string{"system:user:edit"}))
+		assert.False(t, userService.UserHasRoles(3, []string{"editor"}))
+		assert.Empty(t, menuService.GetPermsByUserId(3))
+	})
+
+	t.Run("should not grant the roles of a deleted group", func(t *testing.T) {
+		assert.NoError(t, s.UpdateUserGroup(dto.SaveUserGroup{GroupId: groupId, GroupName: "Project X", Status: constant.NORMAL_STATUS}, nil))
+		assert.True(t, userService.UserHasRoles(3, []string{"editor"}))
+
+		assert.NoError(t, s.DeleteUserGroup([]int{groupId}, "admin"))
+		assert.False(t, userService.UserHasRoles(3, []string{"editor"}))
+	})
+}
+
+func TestUserGroupService_DataScope(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserGroup()
+	s := NewUserGroupService()
+	groupId := createUserGroup(t, s, "Project X", nil, 3)
+
+	visibleUserIds := func() []int {
+		userIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUser{}).
+			Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
+			Scopes(GetDataScope("sys_dept", 2, "sys_user")).
+			Order("sys_user.user_id").
+			Pluck("sys_user.user_id", &userIds)
+		return userIds
+	}
+
+	t.Run("should cover the selected departments only", func(t *testing.T) {
+		assert.Equal(t, []int{2}, visibleUserIds())
+	})
+
+	t.Run("should cover the members of the selected groups", func(t *testing.T) {
+		assert.NoError(t, s.UpdateRoleGroups(2, []int{groupId}))
+		assert.Equal(t, []int{groupId}, s.GetGroupIdsByRoleId(2))
+
+		assert.Equal(t, []int{2, 3}, visibleUserIds())
+	})
+
+	t.Run("should ignore a disabled group", func(t *testing.T) {
+		assert.NoError(t, s.UpdateUserGroup(dto.SaveUserGroup{GroupId: groupId, GroupName: "Project X", Status: "1"}, nil))
+
+		assert.Equal(t, []int{2}, visibleUserIds())
+	})
+}
+
+func TestUserGroupService_Recycle(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserGroup()
+	s := NewUserGroupService()
+	recycleService := NewRecycleService()
+	userService := &UserService{}
+	groupId := createUserGroup(t, s, "Project X", []int{3}, 3)
+	assert.NoError(t, s.UpdateRoleGroups(2, []int{groupId}))
+
+	assert.NoError(t, s.DeleteUserGroup([]int{groupId}, "admin"))
+
+	entries, _ := recycleService.GetRecycleList(dto.RecycleListRequest{EntityType: constant.RECYCLE_ENTITY_USER_GROUP}, false)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "Project X", entries[0].EntityName)
+
+	t.Run("should restore the group with its members and roles", func(t *testing.T) {
+		assert.NoError(t, recycleService.RestoreRecycle([]int{entries[0].RecycleId}))
+
+		assert.Equal(t, groupId, s.GetUserGroupByGroupName("Project X").GroupId)
+		assert.True(t, userService.UserHasRoles(3, []string{"editor"}))
+		assert.Equal(t, []int{groupId}, s.GetGroupIdsByRoleId(2))
+	})
+
+	t.Run("should purge the group with its links", func(t *testing.T) {
+		assert.NoError(t, s.DeleteUserGroup([]int{groupId}, "admin"))
+		entries, _ := recycleService.GetRecycleList(dto.RecycleListRequest{EntityType: constant.RECYCLE_ENTITY_USER_GROUP}, false)
+
+		purged, err := recycleService.PurgeRecycle([]int{entries[0].RecycleId})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		var count int64
+		dal.Gorm.Model(model.SysUserGroupUser{}).Where("group_id = ?", groupId).Count(&count)
+		assert.Zero(t, count)
+		dal.Gorm.Model(model.SysUserGroupRole{}).Where("group_id = ?", groupId).Count(&count)
+		assert.Zero(t, count)
+		dal.Gorm.Model(model.SysRoleGroup{}).Where("group_id = ?", groupId).Count(&count)
+		assert.Zero(t, count)
+	})
+}
+
+func TestUserGroupService_InvalidateCaches(t *testing.T) {
+	setup()
+	defer teardown()
+	seedUserGroup()
+	s := NewUserGroupService()
+	groupId := createUserGroup(t, s, "Project X", []int{3}, 3)
+
+	t.Run("should drop the cached permissions of removed members", func(t *testing.T) {
+		redisMock.ClearExpect()
+		redisMock.ExpectDel(rediskey.UserPermsKey(3)).SetVal(1)
+		redisMock.ExpectDel(rediskey.UserAllPermsKey(3)).SetVal(1)
+		redisMock.ExpectDel(rediskey.UserMenuPermsKey(3)).SetVal(1)
+		redisMock.ExpectDel(rediskey.UserBtnPermsKey(3)).SetVal(1)
+		redisMock.ExpectDel(rediskey.UserRolesKey(3)).SetVal(1)
+		redisMock.ExpectDel(rediskey.UserDataScopeKey(3)).SetVal(1)
+		redisMock.ExpectDel(rediskey.UserDataScopeDeptsKey(3)).SetVal(1)
+		redisMock.ExpectDel(rediskey.UserDataScopeUsersKey(3)).SetVal(1)
+
+		assert.NoError(t, s.RemoveMembers(groupId, []int{3}))
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/app/token"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/pkg/errors"
+)
+
+// UserLifecycleServiceInterface defines operations for the user lifecycle
+type UserLifecycleServiceInterface interface {
+	ScheduleUserLifecycle(param dto.UserLifecycleScheduleRequest, updateBy string) error
+	OnboardUser(userId int, operName string) (dto.UserOnboardResponse, error)
+	OffboardUser(param dto.UserOffboardRequest, operName string) (dto.UserOffboardReport, error)
+	GetOffboardReports(userId int) ([]dto.UserOffboardResponse, error)
+	RunLifecycleSweep(now time.Time) (int, int, error)
+}
+
+// UserLifecycleService implements user onboarding, offboarding and scheduled activation
+//
+// Onboarding grants the roles and posts of the matching onboarding templates and enables
+// the user. Offboarding logs the user out everywhere, disables the account, removes its
+// role grants, expires the approvals it requested and hands its export jobs to another
+// user, keeping a report of what was done. Users may carry an activation and a deactivation
+// time, which the lifecycle scheduler applies once they pass. Every step is written to the
+// operation log.
+type UserLifecycleService struct{}
+
+// Ensure UserLifecycleService implements UserLifecycleServiceInterface
+var _ UserLifecycleServiceInterface = (*UserLifecycleService)(nil)
+
+// NewUserLifecycleService creates a new UserLifecycleService
+func NewUserLifecycleService() *UserLifecycleService {
+	return &UserLifecycleService{}
+}
+
+// ScheduleUserLifecycle sets when a user is activated and deactivated; a zero time clears the schedule
+func (s *UserLifecycleService) ScheduleUserLifecycle(param dto.UserLifecycleScheduleRequest, updateBy string) error {
+	if param.UserId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	if param.UserId == 1 && !param.DeactivateTime.IsZero() {
+		return xerrors.ErrUserSuperAdminOffboard
+	}
+
+	if !param.ActivateTime.IsZero() && !param.DeactivateTime.IsZero() && !param.DeactivateTime.After(param.ActivateTime.Time) {
+		return xerrors.ErrUserLifecycleScheduleOrder
+	}
+
+	if err := dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", param.UserId).
+		Select("activate_time", "deactivate_time", "update_by").
+		Updates(&model.SysUser{
+			ActivateTime:   param.ActivateTime,
+			DeactivateTime: param.DeactivateTime,
+			UpdateBy:       updateBy,
+		}).Error; err != nil {
+		return errors.Wrap(err, "failed to schedule user lifecycle")
+	}
+
+	return nil
+}
+
+// OnboardUser grants a user the roles and posts of its onboarding templates and enables it.
+// Roles and posts the user already holds are kept.
+func (s *UserLifecycleService) OnboardUser(userId int, operName string) (dto.UserOnboardResponse, error) {
+	result := dto.UserOnboardResponse{
+		UserId:      userId,
+		TemplateIds: make([]int, 0),
+		RoleIds:     make([]int, 0),
+		PostIds:     make([]int, 0),
+	}
+
+	if userId <= 0 {
+		return result, xerrors.ErrParam
+	}
+
+	var err error
+	if result.TemplateIds, err = NewOnboardTemplateService().GetMatchingTemplateIds(userId); err != nil {
+		return result, err
+	}
+
+	if len(result.TemplateIds) > 0 {
+		if err = dal.Gorm.Model(model.SysOnboardTemplateRole{}).
+			Where("template_id IN ?", result.TemplateIds).
+			Where("role_id NOT IN (?)", dal.Gorm.Model(model.SysUserRole{}).Select("role_id").Where("user_id = ?", userId)).
+			Distinct().Order("role_id").Pluck("role_id", &result.RoleIds).Error; err != nil {
+			return result, errors.Wrap(err, "failed to get onboarding template roles")
+		}
+
+		if err = dal.Gorm.Model(model.SysOnboardTemplatePost{}).
+			Where("template_id IN ?", result.TemplateIds).
+			Where("post_id NOT IN (?)", dal.Gorm.Model(model.SysUserPost{}).Select("post_id").Where("user_id = ?", userId)).
+			Distinct().Order("post_id").Pluck("post_id", &result.PostIds).Error; err != nil {
+			return result, errors.Wrap(err, "failed to get onboarding template posts")
+		}
+	}
+
+	tx := dal.Gorm.Begin()
+
+	for _, roleId := range result.RoleIds {
+		if err = tx.Create(&model.SysUserRole{UserId: userId, RoleId: roleId}).Error; err != nil {
+			tx.Rollback()
+			return result, errors.Wrapf(err, "failed to assign role ID %d", roleId)
+		}
+	}
+
+	for _, postId := range result.PostIds {
+		if err = tx.Create(&model.SysUserPost{UserId: userId, PostId: postId}).Error; err != nil {
+			tx.Rollback()
+			return result, errors.Wrapf(err, "failed to assign post ID %d", postId)
+		}
+	}
+
+	if err = tx.Model(model.SysUser{}).Where("user_id = ?", userId).
+		Select("status", "activate_time", "update_by").
+		Updates(&model.SysUser{Status: constant.NORMAL_STATUS, UpdateBy: operName}).Error; err != nil {
+		tx.Rollback()
+		return result, errors.Wrap(err, "failed to enable user")
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return result, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	invalidateUserPermCaches([]int{userId})
+
+	steps := []lifecycleStep{
+		{constant.REQUEST_BUSINESS_TYPE_GRANT, fmt.Sprintf("granted roles %v and posts %v from onboarding templates %v", result.RoleIds, result.PostIds, result.TemplateIds)},
+		{constant.REQUEST_BUSINESS_TYPE_UPDATE, "enabled the account"},
+	}
+
+	return result, logLifecycleSteps("User Onboarding", "UserLifecycleService.OnboardUser", operName, map[string]int{"userId": userId}, steps)
+}
+
+// OffboardUser takes a user out of the organization and returns the report of what was done
+//
+// The sessions of the user are revoked before the account is disabled and once more after,
+// so no login slips in between. Its direct roles, group memberships and delegations are
+// removed; with archiveGrants the report keeps their IDs to restore them later. The pending
+// approvals it requested expire, and when transferTo names another user its export jobs
+// change hands.
+func (s *UserLifecycleService) OffboardUser(param dto.UserOffboardRequest, operName string) (dto.UserOffboardReport, error) {
+	report := dto.UserOffboardReport{UserId: param.UserId}
+
+	if param.UserId <= 0 {
+		return report, xerrors.ErrParam
+	}
+
+	if param.UserId == 1 {
+		return report, xerrors.ErrUserSuperAdminOffboard
+	}
+
+	var user model.SysUser
+	if err := dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", param.UserId).Take(&user).Error; err != nil {
+		return report, errors.Wrapf(err, "failed to get user ID %d", param.UserId)
+	}
+	report.UserName = user.UserName
+
+	if param.TransferTo != 0 {
+		var target model.SysUser
+		dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", param.TransferTo).Limit(1).Find(&target)
+		if target.UserId <= 0 || target.UserId == user.UserId || target.Status != constant.NORMAL_STATUS {
+			return report, xerrors.ErrUserOffboardTransferInvalid
+		}
+		report.TransferTo = target.UserName
+	}
+
+	roleIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysUserRole{}).Where("user_id = ?", user.UserId).Order("role_id").Pluck("role_id", &roleIds).Error; err != nil {
+		return report, errors.Wrap(err, "failed to get user roles")
+	}
+
+	groupIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysUserGroupUser{}).Where("user_id = ?", user.UserId).Order("group_id").Pluck("group_id", &groupIds).Error; err != nil {
+		return report, errors.Wrap(err, "failed to get user groups")
+	}
+
+	delegationIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysDeptDelegation{}).
+		Where("delegate_type = ? AND delegate_id = ?", constant.DELEGATE_TYPE_USER, user.UserId).
+		Order("delegation_id").Pluck("delegation_id", &delegationIds).Error; err != nil {
+		return report, errors.Wrap(err, "failed to get user delegations")
+	}
+
+	revoked, err := token.RevokeUserTokens(context.Background(), user.UserId)
+	if err != nil {
+		return report, errors.Wrap(err, "failed to revoke user sessions")
+	}
+
+	tx := dal.Gorm.Begin()
+
+	if err = tx.Model(model.SysUser{}).Where("user_id = ?", user.UserId).
+		Select("status", "deactivate_time", "update_by").
+		Updates(&model.SysUser{Status: constant.EXCEPTION_STATUS, UpdateBy: operName}).Error; err != nil {
+		tx.Rollback()
+		return report, errors.Wrap(err, "failed to disable user")
+	}
+
+	if err = tx.Where("user_id = ?", user.UserId).Delete(&model.SysUserRole{}).Error; err != nil {
+		tx.Rollback()
+		return report, errors.Wrap(err, "failed to delete user roles")
+	}
+
+	if err = tx.Where("user_id = ?", user.UserId).Delete(&model.SysUserGroupUser{}).Error; err != nil {
+		tx.Rollback()
+		return report, errors.Wrap(err, "failed to delete user group memberships")
+	}
+
+	if len(delegationIds) > 0 {
+		if err = tx.Where("delegation_id IN ?", delegationIds).Delete(&model.SysDeptDelegation{}).Error; err != nil {
+			tx.Rollback()
+			return report, errors.Wrap(err, "failed to delete user delegations")
+		}
+		if err = tx.Where("delegation_id IN ?", delegationIds).Delete(&model.SysDeptDelegationRole{}).Error; err != nil {
+			tx.Rollback()
+			return report, errors.Wrap(err, "failed to delete delegated roles")
+		}
+	}
+
+	result := tx.Model(model.SysApproval{}).
+		Where("requester_id = ? AND status = ?", user.UserId, constant.APPROVAL_STATUS_PENDING).
+		Update("status", constant.APPROVAL_STATUS_EXPIRED)
+	if result.Error != nil {
+		tx.Rollback()
+		return report, errors.Wrap(result.Error, "failed to expire pending approvals")
+	}
+	report.ExpiredApprovals = int(result.RowsAffected)
+
+	// Export files can only be downloaded by their creator
+	if report.TransferTo != "" {
+		result = tx.Model(model.SysExportJob{}).Where("create_by = ?", user.UserName).Update("create_by", report.TransferTo)
+		if result.Error != nil {
+			tx.Rollback()
+			return report, errors.Wrap(result.Error, "failed to transfer export jobs")
+		}
+		report.TransferredExportJobs = int(result.RowsAffected)
+	}
+
+	if err = tx.Commit().Error; err != nil {
+		return report, errors.Wrap(err, "failed to commit transaction")
+	}
+
+	// Sessions opened while the account was being disabled; the cache is best effort
+	if late, err := token.RevokeUserTokens(context.Background(), user.UserId); err == nil {
+		revoked += late
+	}
+	invalidateUserPermCaches([]int{user.UserId})
+
+	report.RevokedSessions = revoked
+	report.RemovedRoles = len(roleIds)
+	report.RemovedGroups = len(groupIds)
+	report.RemovedDelegations = len(delegationIds)
+	if param.ArchiveGrants {
+		report.ArchivedRoleIds = roleIds
+		report.ArchivedGroupIds = groupIds
+		report.ArchivedDelegationIds = delegationIds
+	}
+
+	content, err := json.Marshal(report)
+	if err != nil {
+		return report, errors.Wrap(err, "failed to encode offboarding report")
+	}
+
+	if err = dal.Gorm.Create(&model.SysUserOffboard{
+		UserId:     user.UserId,
+		UserName:   user.UserName,
+		TransferTo: report.TransferTo,
+		Report:     string(content),
+		CreateBy:   operName,
+	}).Error; err != nil {
+		return report, errors.Wrap(err, "failed to save offboarding report")
+	}
+
+	grants := fmt.Sprintf("removed %d roles, %d group memberships and %d delegations", report.RemovedRoles, report.RemovedGroups, report.RemovedDelegations)
+	if param.ArchiveGrants {
+		grants += fmt.Sprintf(", archived roles %v, groups %v and delegations %v", roleIds, groupIds, delegationIds)
+	}
+
+	steps := []lifecycleStep{
+		{constant.REQUEST_BUSINESS_TYPE_FORCE, fmt.Sprintf("revoked %d sessions", report.RevokedSessions)},
+		{constant.REQUEST_BUSINESS_TYPE_UPDATE, "disabled the account"},
+		{constant.REQUEST_BUSINESS_TYPE_GRANT, grants},
+		{constant.REQUEST_BUSINESS_TYPE_UPDATE, fmt.Sprintf("expired %d pending approvals", report.ExpiredApprovals)},
+	}
+	if report.TransferTo != "" {
+		steps = append(steps, lifecycleStep{constant.REQUEST_BUSINESS_TYPE_UPDATE, fmt.Sprintf("transferred %d export jobs to %s", report.TransferredExportJobs, report.TransferTo)})
+	}
+
+	return report, logLifecycleSteps("User Offboarding", "UserLifecycleService.OffboardUser", operName, param, steps)
+}
+
+// GetOffboardReports retrieves the offboarding reports of a user, newest first
+func (s *UserLifecycleService) GetOffboardReports(userId int) ([]dto.UserOffboardResponse, error) {
+	rows := make([]model.SysUserOffboard, 0)
+	if err := dal.Gorm.Model(model.SysUserOffboard{}).Where("user_id = ?", userId).Order("offboard_id DESC").Find(&rows).Error; err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve offboarding reports")
+	}
+
+	reports := make([]dto.UserOffboardResponse, 0, len(rows))
+	for _, row := range rows {
+		report := dto.UserOffboardResponse{
+			OffboardId: row.OffboardId,
+			UserId:     row.UserId,
+			UserName:   row.UserName,
+			TransferTo: row.TransferTo,
+			CreateBy:   row.CreateBy,
+			CreateTime: row.CreateTime,
+		}
+		if err := json.Unmarshal([]byte(row.Report), &report.Report); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode offboarding report ID %d", row.OffboardId)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// RunLifecycleSweep onboards the users whose activation time has passed and disables the
+// users whose deactivation time has passed, returning how many of each were processed.
+// Every instance runs the sweep, so each user is claimed by clearing its due time first
+// and skipped when another instance cleared it. A failing user is released for the next
+// sweep and does not stop this one; the first error is returned.
+func (s *UserLifecycleService) RunLifecycleSweep(now time.Time) (int, int, error) {
+	var activated, deactivated int
+	var firstErr error
+
+	userIds := make([]int, 0)
+	if err := dal.Gorm.Model(model.SysUser{}).Where("activate_time IS NOT NULL AND activate_time <= ?", now).Order("user_id").Pluck("user_id", &userIds).Error; err != nil {
+		return 0, 0, errors.Wrap(err, "failed to retrieve users due for activation")
+	}
+
+	for _, userId := range userIds {
+		claimed, err := claimLifecycleUser("activate_time", userId, now)
+		if err == nil && claimed {
+			if _, err = s.OnboardUser(userId, constant.USER_LIFECYCLE_OPERATOR); err != nil {
+				releaseLifecycleUser("activate_time", userId, now)
+			}
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if claimed {
+			activated++
+		}
+	}
+
+	userIds = make([]int, 0)
+	if err := dal.Gorm.Model(model.SysUser{}).Where("deactivate_time IS NOT NULL AND deactivate_time <= ?", now).Order("user_id").Pluck("user_id", &userIds).Error; err != nil {
+		return activated, 0, errors.Wrap(err, "failed to retrieve users due for deactivation")
+	}
+
+	for _, userId := range userIds {
+		claimed, err := claimLifecycleUser("deactivate_time", userId, now)
+		if err == nil && claimed {
+			if err = s.deactivateUser(userId); err != nil {
+				releaseLifecycleUser("deactivate_time", userId, now)
+			}
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if claimed {
+			deactivated++
+		}
+	}
+
+	return activated, deactivated, firstErr
+}
+
+// StartLifecycleScheduler runs the lifecycle sweep at every interval until the context is done
+func (s *UserLifecycleService) StartLifecycleScheduler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, _, err := s.RunLifecycleSweep(now); err != nil {
+					log.Printf("Warning: Failed to run the user lifecycle sweep: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// claimLifecycleUser clears the activation or deactivation time of a user when it is due,
+// reporting whether this call cleared it
+func claimLifecycleUser(column string, userId int, now time.Time) (bool, error) {
+	result := dal.Gorm.Model(model.SysUser{}).
+		Where("user_id = ? AND "+column+" IS NOT NULL AND "+column+" <= ?", userId, now).
+		Update(column, nil)
+	if result.Error != nil {
+		return false, errors.Wrapf(result.Error, "failed to claim user ID %d", userId)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// releaseLifecycleUser sets the activation or deactivation time of a claimed user back to
+// now, so that the next sweep retries it, unless it was scheduled again meanwhile
+func releaseLifecycleUser(column string, userId int, now time.Time) {
+	if err := dal.Gorm.Model(model.SysUser{}).
+		Where("user_id = ? AND "+column+" IS NULL", userId).
+		Update(column, now).Error; err != nil {
+		log.Printf("Warning: Failed to release user ID %d for the next lifecycle sweep: %v", userId, err)
+	}
+}
+
+// deactivateUser disables a user whose deactivation time has passed and revokes its sessions
+func (s *UserLifecycleService) deactivateUser(userId int) error {
+	revoked, err := token.RevokeUserTokens(context.Background(), userId)
+	if err != nil {
+		return errors.Wrapf(err, "failed to revoke sessions of user ID %d", userId)
+	}
+
+	if err = dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", userId).
+		Select("status", "deactivate_time", "update_by").
+		Updates(&model.SysUser{Status: constant.EXCEPTION_STATUS, UpdateBy: constant.USER_LIFECYCLE_OPERATOR}).Error; err != nil {
+		return errors.Wrapf(err, "failed to disable user ID %d", userId)
+	}
+
+	if late, err := token.RevokeUserTokens(context.Background(), userId); err == nil {
+		revoked += late
+	}
+
+	steps := []lifecycleStep{
+		{constant.REQUEST_BUSINESS_TYPE_FORCE, fmt.Sprintf("revoked %d sessions", revoked)},
+		{constant.REQUEST_BUSINESS_TYPE_UPDATE, "disabled the account"},
+	}
+
+	return logLifecycleSteps("User Deactivation", "UserLifecycleService.RunLifecycleSweep", constant.USER_LIFECYCLE_OPERATOR, map[string]int{"userId": userId}, steps)
+}
+
+// lifecycleStep is one step of a lifecycle operation as recorded in the operation log
+type lifecycleStep struct {
+	businessType int
+	result       string
+}
+
+// logLifecycleSteps writes every step of a lifecycle operation to the operation log
+func logLifecycleSteps(title, method, operName string, param interface{}, steps []lifecycleStep) error {
+	operParam, _ := json.Marshal(param)
+	now := time.Now()
+
+	for _, step := range steps {
+		if err := (&OperLogService{}).CreateSysOperLogWithErr(dto.SaveOperLogRequest{
+			Title:        title,
+			BusinessType: step.businessType,
+			Method:       method,
+			OperName:     operName,
+			OperParam:    string(operParam),
+			JsonResult:   step.result,
+			Status:       constant.NORMAL_STATUS,
+			OperTime:     datetime.Datetime{Time: now},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
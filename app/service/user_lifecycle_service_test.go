@@ -0,0 +1,291 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mira/anima/dal"
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+
+	"github.com/stretchr/testify/assert"
+
+	rediskey "mira/common/types/redis-key"
+)
+
+// seedUserOffboard gives bob direct roles, a group membership, a delegation, approvals
+// and export jobs to offboard, with carol as an enabled colleague to take them over
+func seedUserOffboard() {
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 102, UserName: "bob", Status: "0"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 102, UserName: "carol", Status: "0"})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 2})
+	dal.Gorm.Create(&model.SysUserRole{UserId: 3, RoleId: 3})
+
+	dal.Gorm.Create(&model.SysUserGroup{GroupId: 1, GroupName: "Project X", Status: "0"})
+	dal.Gorm.Create(&model.SysUserGroupUser{GroupId: 1, UserId: 3})
+	dal.Gorm.Create(&model.SysUserGroupUser{GroupId: 1, UserId: 4})
+
+	dal.Gorm.Create(&model.SysDeptDelegation{DelegationId: 1, DeptId: 102, DelegateType: constant.DELEGATE_TYPE_USER, DelegateId: 3, Status: "0"})
+	dal.Gorm.Create(&model.SysDeptDelegationRole{DelegationId: 1, RoleId: 2})
+	dal.Gorm.Create(&model.SysDeptDelegation{DelegationId: 2, DeptId: 102, DelegateType: constant.DELEGATE_TYPE_ROLE, DelegateId: 3, Status: "0"})
+
+	dal.Gorm.Create(&model.SysApproval{ApprovalId: 1, Action: "system:user:remove", RequesterId: 3, RequesterName: "bob", Status: constant.APPROVAL_STATUS_PENDING})
+	dal.Gorm.Create(&model.SysApproval{ApprovalId: 2, Action: "system:user:remove", RequesterId: 3, RequesterName: "bob", Status: constant.APPROVAL_STATUS_APPROVED})
+
+	dal.Gorm.Create(&model.SysExportJob{JobId: 1, Dataset: "user", CreateBy: "bob"})
+	dal.Gorm.Create(&model.SysExportJob{JobId: 2, Dataset: "role", CreateBy: "bob"})
+	dal.Gorm.Create(&model.SysExportJob{JobId: 3, Dataset: "user", CreateBy: "carol"})
+}
+
+func TestUserLifecycleService_ScheduleUserLifecycle(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOnboardTemplate()
+	s := NewUserLifecycleService()
+	activateTime := time.Date(2026, 11, 2, 9, 0, 0, 0, time.Local)
+
+	t.Run("should set and clear the schedule", func(t *testing.T) {
+		assert.NoError(t, s.ScheduleUserLifecycle(dto.UserLifecycleScheduleRequest{
+			UserId:         2,
+			ActivateTime:   datetime.Datetime{Time: activateTime},
+			DeactivateTime: datetime.Datetime{Time: activateTime.AddDate(0, 6, 0)},
+		}, "admin"))
+
+		user := (&UserService{}).GetUserByUserId(2)
+		assert.True(t, user.ActivateTime.Equal(activateTime))
+		assert.True(t, user.DeactivateTime.Equal(activateTime.AddDate(0, 6, 0)))
+
+		assert.NoError(t, s.ScheduleUserLifecycle(dto.UserLifecycleScheduleRequest{UserId: 2, ActivateTime: datetime.Datetime{Time: activateTime}}, "admin"))
+
+		user = (&UserService{}).GetUserByUserId(2)
+		assert.True(t, user.ActivateTime.Equal(activateTime))
+		assert.True(t, user.DeactivateTime.IsZero())
+	})
+
+	t.Run("should return error for an invalid schedule", func(t *testing.T) {
+		assert.Equal(t, xerrors.ErrParam, s.ScheduleUserLifecycle(dto.UserLifecycleScheduleRequest{}, "admin"))
+		assert.Equal(t, xerrors.ErrUserSuperAdminOffboard, s.ScheduleUserLifecycle(dto.UserLifecycleScheduleRequest{
+			UserId: 1, DeactivateTime: datetime.Datetime{Time: activateTime},
+		}, "admin"))
+		assert.Equal(t, xerrors.ErrUserLifecycleScheduleOrder, s.ScheduleUserLifecycle(dto.UserLifecycleScheduleRequest{
+			UserId: 2, ActivateTime: datetime.Datetime{Time: activateTime}, DeactivateTime: datetime.Datetime{Time: activateTime},
+		}, "admin"))
+	})
+}
+
+func TestUserLifecycleService_OnboardUser(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOnboardTemplate()
+	s := NewUserLifecycleService()
+	templates := NewOnboardTemplateService()
+
+	result, err := s.OnboardUser(2, "admin")
+	assert.NoError(t, err)
+
+	t.Run("should grant the missing roles and posts of the matching templates", func(t *testing.T) {
+		assert.Equal(t, []int{
+			templates.GetOnboardTemplateByTemplateName("Everyone at HQ").TemplateId,
+			templates.GetOnboardTemplateByTemplateName("Engineering developers").TemplateId,
+		}, result.TemplateIds)
+		assert.Equal(t, []int{3}, result.RoleIds)
+		assert.Equal(t, []int{2}, result.PostIds)
+
+		roleIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUserRole{}).Where("user_id = ?", 2).Order("role_id").Pluck("role_id", &roleIds)
+		assert.Equal(t, []int{2, 3}, roleIds)
+
+		postIds := make([]int, 0)
+		dal.Gorm.Model(model.SysUserPost{}).Where("user_id = ?", 2).Order("post_id").Pluck("post_id", &postIds)
+		assert.Equal(t, []int{1, 2}, postIds)
+	})
+
+	t.Run("should enable the user and log every step", func(t *testing.T) {
+		assert.Equal(t, constant.NORMAL_STATUS, (&UserService{}).GetUserByUserId(2).Status)
+
+		var count int64
+		dal.Gorm.Model(model.SysOperLog{}).Where("title = ? AND oper_name = ?", "User Onboarding", "admin").Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("should grant only what the user lacks when run again", func(t *testing.T) {
+		// The operator post granted above now matches its template as well
+		result, err := s.OnboardUser(2, "admin")
+		assert.NoError(t, err)
+		assert.Equal(t, []int{4}, result.RoleIds)
+		assert.Empty(t, result.PostIds)
+
+		result, _ = s.OnboardUser(2, "admin")
+		assert.Empty(t, result.RoleIds)
+	})
+}
+
+func TestUserLifecycleService_OffboardUser(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOnboardTemplate()
+	seedUserOffboard()
+	s := NewUserLifecycleService()
+
+	t.Run("should return error for invalid targets", func(t *testing.T) {
+		_, err := s.OffboardUser(dto.UserOffboardRequest{UserId: 1}, "admin")
+		assert.Equal(t, xerrors.ErrUserSuperAdminOffboard, err)
+
+		// alice is disabled until onboarded
+		_, err = s.OffboardUser(dto.UserOffboardRequest{UserId: 3, TransferTo: 2}, "admin")
+		assert.Equal(t, xerrors.ErrUserOffboardTransferInvalid, err)
+
+		_, err = s.OffboardUser(dto.UserOffboardRequest{UserId: 3, TransferTo: 99}, "admin")
+		assert.Equal(t, xerrors.ErrUserOffboardTransferInvalid, err)
+	})
+
+	redisMock.ExpectSMembers(rediskey.UserAuthTokensKey(3)).SetVal([]string{"token-a", "token-b"})
+	redisMock.ExpectUnlink("token-a").SetVal(1)
+	redisMock.ExpectUnlink("token-b").SetVal(1)
+	redisMock.ExpectDel(rediskey.UserAuthTokensKey(3)).SetVal(1)
+
+	report, err := s.OffboardUser(dto.UserOffboardRequest{UserId: 3, TransferTo: 4, ArchiveGrants: true}, "admin")
+	assert.NoError(t, err)
+
+	t.Run("should report every step", func(t *testing.T) {
+		assert.Equal(t, dto.UserOffboardReport{
+			UserId:                3,
+			UserName:              "bob",
+			RevokedSessions:       2,
+			RemovedRoles:          2,
+			RemovedGroups:         1,
+			RemovedDelegations:    1,
+			ArchivedRoleIds:       []int{2, 3},
+			ArchivedGroupIds:      []int{1},
+			ArchivedDelegationIds: []int{1},
+			ExpiredApprovals:      1,
+			TransferTo:            "carol",
+			TransferredExportJobs: 2,
+		}, report)
+
+		var count int64
+		dal.Gorm.Model(model.SysOperLog{}).Where("title = ?", "User Offboarding").Count(&count)
+		assert.Equal(t, int64(5), count)
+	})
+
+	t.Run("should disable the user and remove its grants", func(t *testing.T) {
+		assert.Equal(t, constant.EXCEPTION_STATUS, (&UserService{}).GetUserByUserId(3).Status)
+
+		var count int64
+		dal.Gorm.Model(model.SysUserRole{}).Where("user_id = ?", 3).Count(&count)
+		assert.Zero(t, count)
+		dal.Gorm.Model(model.SysUserGroupUser{}).Where("user_id = ?", 3).Count(&count)
+		assert.Zero(t, count)
+		dal.Gorm.Model(model.SysUserGroupUser{}).Where("user_id = ?", 4).Count(&count)
+		assert.Equal(t, int64(1), count)
+		dal.Gorm.Model(model.SysDeptDelegationRole{}).Where("delegation_id = ?", 1).Count(&count)
+		assert.Zero(t, count)
+
+		// Delegations to the role with the same ID are not the user's
+		dal.Gorm.Model(model.SysDeptDelegation{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should expire pending approvals and transfer export jobs", func(t *testing.T) {
+		statuses := make([]string, 0)
+		dal.Gorm.Model(model.SysApproval{}).Order("approval_id").Pluck("status", &statuses)
+		assert.Equal(t, []string{constant.APPROVAL_STATUS_EXPIRED, constant.APPROVAL_STATUS_APPROVED}, statuses)
+
+		var count int64
+		dal.Gorm.Model(model.SysExportJob{}).Where("create_by = ?", "carol").Count(&count)
+		assert.Equal(t, int64(3), count)
+	})
+
+	t.Run("should keep the report", func(t *testing.T) {
+		reports, err := s.GetOffboardReports(3)
+		assert.NoError(t, err)
+		assert.Len(t, reports, 1)
+		assert.Equal(t, "carol", reports[0].TransferTo)
+		assert.Equal(t, "admin", reports[0].CreateBy)
+		assert.Equal(t, report, reports[0].Report)
+	})
+}
+
+func TestUserLifecycleService_RunLifecycleSweep(t *testing.T) {
+	setup()
+	defer teardown()
+	seedOnboardTemplate()
+	s := NewUserLifecycleService()
+	now := time.Now()
+
+	dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", 2).Update("activate_time", now.Add(-time.Hour))
+	dal.Gorm.Create(&model.SysUser{UserId: 3, DeptId: 102, UserName: "bob", Status: "0", DeactivateTime: datetime.Datetime{Time: now.Add(-time.Minute)}})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, DeptId: 102, UserName: "carol", Status: "1", ActivateTime: datetime.Datetime{Time: now.Add(time.Hour)}})
+
+	redisMock.ExpectSMembers(rediskey.UserAuthTokensKey(3)).SetVal([]string{"token-a"})
+	redisMock.ExpectUnlink("token-a").SetVal(1)
+	redisMock.ExpectDel(rediskey.UserAuthTokensKey(3)).SetVal(1)
+
+	activated, deactivated, err := s.RunLifecycleSweep(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, activated)
+	assert.Equal(t, 1, deactivated)
+
+	t.Run("should onboard the users due for activation", func(t *testing.T) {
+		user := (&UserService{}).GetUserByUserId(2)
+		assert.Equal(t, constant.NORMAL_STATUS, user.Status)
+		assert.True(t, user.ActivateTime.IsZero())
+
+		var count int64
+		dal.Gorm.Model(model.SysUserRole{}).Where("user_id = ? AND role_id = ?", 2, 3).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should disable the users due for deactivation", func(t *testing.T) {
+		user := (&UserService{}).GetUserByUserId(3)
+		assert.Equal(t, constant.EXCEPTION_STATUS, user.Status)
+		assert.True(t, user.DeactivateTime.IsZero())
+
+		var count int64
+		dal.Gorm.Model(model.SysOperLog{}).Where("title = ? AND oper_name = ?", "User Deactivation", constant.USER_LIFECYCLE_OPERATOR).Count(&count)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("should leave the users not yet due", func(t *testing.T) {
+		user := (&UserService{}).GetUserByUserId(4)
+		assert.Equal(t, constant.EXCEPTION_STATUS, user.Status)
+		assert.False(t, user.ActivateTime.IsZero())
+	})
+
+	t.Run("should find nothing on the next run", func(t *testing.T) {
+		activated, deactivated, err := s.RunLifecycleSweep(now)
+		assert.NoError(t, err)
+		assert.Zero(t, activated)
+		assert.Zero(t, deactivated)
+	})
+
+	t.Run("should skip users claimed by another instance", func(t *testing.T) {
+		dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", 4).Update("activate_time", now.Add(-time.Minute))
+
+		claimed, err := claimLifecycleUser("activate_time", 4, now)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+		claimed, err = claimLifecycleUser("activate_time", 4, now)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+
+		activated, _, err := s.RunLifecycleSweep(now)
+		assert.NoError(t, err)
+		assert.Zero(t, activated)
+		assert.Equal(t, constant.EXCEPTION_STATUS, (&UserService{}).GetUserByUserId(4).Status)
+	})
+
+	t.Run("should release users failing to be processed", func(t *testing.T) {
+		dal.Gorm.Model(model.SysUser{}).Where("user_id = ?", 4).Update("deactivate_time", now.Add(-time.Minute))
+		redisMock.ExpectSMembers(rediskey.UserAuthTokensKey(4)).SetErr(errors.New("redis is down"))
+
+		_, deactivated, err := s.RunLifecycleSweep(now)
+		assert.Error(t, err)
+		assert.Zero(t, deactivated)
+		assert.False(t, (&UserService{}).GetUserByUserId(4).DeactivateTime.IsZero())
+	})
+}
@@ -1,21 +1,34 @@
 package service
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/pkg/errors"
+	"mira/anima/cipher"
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/app/token"
+	"mira/app/validator"
 	"mira/common/types/constant"
+	rediskey "mira/common/types/redis-key"
+	"mira/common/utils"
 	"mira/common/xerrors"
+
+	"gorm.io/gorm"
 )
 
 // UserServiceInterface defines operations for user management
 type UserServiceInterface interface {
 	CreateUser(param dto.SaveUser, roleIds, postIds []int) error
 	UpdateUser(param dto.SaveUser, roleIds, postIds []int) error
-	DeleteUser(userIds []int) error
+	DeleteUser(userIds []int, deleteBy string) error
 	AddAuthRole(userId int, roleIds []int) error
 	GetUserList(param dto.UserListRequest, userId int, isPaging bool) ([]dto.UserListResponse, int)
+	GetUserPage(param dto.UserListRequest, userId int) ([]dto.UserListResponse, int, string, error)
+	ExportDataset(param dto.UserListRequest, userId int) ExportDataset
 	GetUserByUserId(userId int) dto.UserDetailResponse
 	GetUserByUsername(userName string) dto.UserTokenResponse
 	GetUserByEmail(email string) dto.UserTokenResponse
@@ -25,6 +38,8 @@ type UserServiceInterface interface {
 	UserHasDeptByDeptId(deptId int) bool
 	UserHasPerms(userId int, perms []string) bool
 	UserHasRoles(userId int, roles []string) bool
+	CheckUserDelegation(operatorId int, scope dto.DelegationScope, userIds ...int) error
+	CheckDelegatedUser(operatorId int, scope *dto.DelegationScope, userId, deptId int, roleIds, postIds []int) error
 }
 
 // UserService implements the user management interface
@@ -33,12 +48,13 @@ type UserService struct{}
 // Ensure UserService implements UserServiceInterface
 var _ UserServiceInterface = (*UserService)(nil)
 
-// CreateUser creates a new system user with assigned roles and posts
+// CreateUser creates a new system user with assigned roles and posts, confined by
+// CheckDelegatedUser when param.Delegation is set
 //
 // Parameters:
 //   - param: User data transfer object containing all required fields
 //   - roleIds: List of role IDs to assign to the user
-//   - postIds: List of post IDs to assign to the user
+//   - postIds: List of post IDs to assign to the user, which also grant the default roles of the posts
 //
 // Returns:
 //   - error: Any error that occurred during creation, or nil on success
@@ -53,6 +69,9 @@ func (s *UserService) CreateUser(param dto.SaveUser, roleIds, postIds []int) err
 	if param.NickName == "" {
 		return xerrors.ErrUserNicknameEmpty
 	}
+	if err := s.CheckDelegatedUser(param.OperatorId, param.Delegation, 0, param.DeptId, roleIds, postIds); err != nil {
+		return err
+	}
 
 	tx := dal.Gorm.Begin()
 
@@ -61,12 +80,12 @@ func (s *UserService) CreateUser(param dto.SaveUser, roleIds, postIds []int) err
 		UserName:    param.UserName,
 		NickName:    param.NickName,
 		UserType:    param.UserType,
-		Email:       param.Email,
-		Phonenumber: param.Phonenumber,
+		Email:       cipher.String[model.SysUserEmail](param.Email),
+		Phonenumber: cipher.String[model.SysUserPhonenumber](param.Phonenumber),
 		Sex:         param.Sex,
 		Avatar:      param.Avatar,
 		Password:    param.Password,
-		LoginIP:     param.LoginIP,
+		LoginIP:     cipher.String[model.SysUserLoginIP](param.LoginIP),
 		LoginDate:   param.LoginDate,
 		Status:      param.Status,
 		CreateBy:    param.CreateBy,
@@ -102,19 +121,31 @@ func (s *UserService) CreateUser(param dto.SaveUser, roleIds, postIds []int) err
 		}
 	}
 
+	if param.Attributes != nil {
+		if err := saveUserAttrValues(tx, user.UserId, param.Attributes); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_USER, user.UserId)
+
+	publishCacheIds(constant.CACHE_EVENT_USER, user.UserId)
+
 	return nil
 }
 
-// UpdateUser updates an existing system user with assigned roles and posts
+// UpdateUser updates an existing system user with assigned roles and posts, confined by
+// CheckDelegatedUser when param.Delegation is set
 //
 // Parameters:
 //   - param: User data transfer object containing fields to update
 //   - roleIds: List of role IDs to assign to the user
-//   - postIds: List of post IDs to assign to the user
+//   - postIds: List of post IDs to assign to the user, which also grant the default roles of the posts
 //
 // Returns:
 //   - error: Any error that occurred during update, or nil on success
@@ -123,18 +154,22 @@ func (s *UserService) UpdateUser(param dto.SaveUser, roleIds, postIds []int) err
 		return xerrors.ErrParam
 	}
 
+	if err := s.CheckDelegatedUser(param.OperatorId, param.Delegation, param.UserId, param.DeptId, roleIds, postIds); err != nil {
+		return err
+	}
+
 	tx := dal.Gorm.Begin()
 
 	if err := tx.Model(model.SysUser{}).Where("user_id = ?", param.UserId).Updates(&model.SysUser{
 		DeptId:      param.DeptId,
 		NickName:    param.NickName,
 		UserType:    param.UserType,
-		Email:       param.Email,
-		Phonenumber: param.Phonenumber,
+		Email:       cipher.String[model.SysUserEmail](param.Email),
+		Phonenumber: cipher.String[model.SysUserPhonenumber](param.Phonenumber),
 		Sex:         param.Sex,
 		Avatar:      param.Avatar,
 		Password:    param.Password,
-		LoginIP:     param.LoginIP,
+		LoginIP:     cipher.String[model.SysUserLoginIP](param.LoginIP),
 		LoginDate:   param.LoginDate,
 		Status:      param.Status,
 		UpdateBy:    param.UpdateBy,
@@ -180,21 +215,43 @@ func (s *UserService) UpdateUser(param dto.SaveUser, roleIds, postIds []int) err
 		}
 	}
 
+	if param.Attributes != nil {
+		if err := saveUserAttrValues(tx, param.UserId, param.Attributes); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_USER, param.UserId)
+
+	publishCacheIds(constant.CACHE_EVENT_USER, param.UserId)
+
+	// A disabled user is logged out everywhere instead of keeping the sessions opened before
+	if param.Status == constant.EXCEPTION_STATUS {
+		token.RevokeUserTokens(context.Background(), param.UserId)
+	}
+
+	// The default roles of added or removed posts change what the user holds
+	if postIds != nil {
+		invalidateUserPermCaches([]int{param.UserId})
+	}
+
 	return nil
 }
 
-// DeleteUser deletes users by their IDs
+// DeleteUser deletes users by their IDs, keeping them with their role and post links in the recycle bin
 //
 // Parameters:
 //   - userIds: Array of user IDs to delete
+//   - deleteBy: Username of the operator deleting the users
 //
 // Returns:
 //   - error: Any error that occurred during deletion, or nil on success
-func (s *UserService) DeleteUser(userIds []int) error {
+func (s *UserService) DeleteUser(userIds []int, deleteBy string) error {
 	if len(userIds) == 0 {
 		return xerrors.ErrParam
 	}
@@ -208,6 +265,11 @@ func (s *UserService) DeleteUser(userIds []int) error {
 
 	tx := dal.Gorm.Begin()
 
+	if err := recycleUsers(tx, userIds, deleteBy); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	if err := tx.Model(model.SysUser{}).Where("user_id IN ?", userIds).Delete(&model.SysUser{}).Error; err != nil {
 		tx.Rollback()
 		return errors.Wrap(err, "failed to delete users")
@@ -227,6 +289,10 @@ func (s *UserService) DeleteUser(userIds []int) error {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	notifySearch(constant.SEARCH_TYPE_USER, userIds...)
+
+	publishCacheIds(constant.CACHE_EVENT_USER, userIds...)
+
 	return nil
 }
 
@@ -268,6 +334,8 @@ func (s *UserService) AddAuthRole(userId int, roleIds []int) error {
 		return errors.Wrap(err, "failed to commit transaction")
 	}
 
+	publishCacheIds(constant.CACHE_EVENT_USER, userId)
+
 	return nil
 }
 
@@ -301,20 +369,122 @@ func (s *UserService) GetUserList(param dto.UserListRequest, userId int, isPagin
 //   - int: Total record count if isPaging is true; otherwise 0
 //   - error: Any error that occurred during retrieval, or nil on success
 func (s *UserService) GetUserListWithErr(param dto.UserListRequest, userId int, isPaging bool) ([]dto.UserListResponse, int, error) {
-	var count int64
+	var count int
 	users := make([]dto.UserListResponse, 0)
 
+	if param.OrderByColumn == "" {
+		param.OrderByColumn, param.OrderRule = "user_id", "asc"
+	}
+	order, err := userKeyset.order(param.OrderByColumn, param.OrderRule)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := userListQuery(param, userId).Order(order)
+
+	if isPaging {
+		if count, err = countPage(query, param.Count); err != nil {
+			return nil, 0, errors.Wrap(err, "failed to count users")
+		}
+		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	}
+
+	if err = query.Find(&users).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "failed to query users")
+	}
+
+	if err = setUserListAttributes(users); err != nil {
+		return nil, 0, err
+	}
+
+	return users, count, nil
+}
+
+// userKeyset sorts and pages the users by the columns the list can be sorted by
+var userKeyset = keyset[dto.UserListResponse]{
+	key:   "sys_user.user_id",
+	keyOf: func(row dto.UserListResponse) int { return row.UserId },
+	columns: map[string]keysetColumn[dto.UserListResponse]{
+		"user_id":     {"sys_user.user_id", func(row dto.UserListResponse) interface{} { return row.UserId }},
+		"user_name":   {"sys_user.user_name", func(row dto.UserListResponse) interface{} { return row.UserName }},
+		"nick_name":   {"sys_user.nick_name", func(row dto.UserListResponse) interface{} { return row.NickName }},
+		"create_time": {"sys_user.create_time", func(row dto.UserListResponse) interface{} { return row.CreateTime.Time }},
+	},
+}
+
+// GetUserPage gets a keyset page of users within the data scope of the authorized user, see dto.PageRequest
+//
+// Parameters:
+//   - param: Request object containing query conditions and the cursor of the page
+//   - userId: The ID of the currently authorized user (for data scope)
+//
+// Returns:
+//   - []dto.UserListResponse: The users of the page
+//   - int: Total record count as the count mode of the page asks, -1 if not counted
+//   - string: The cursor of the next page, empty on the last page
+//   - error: ErrPageSortInvalid or ErrPageCursorInvalid for a bad sort or cursor, or any query error
+func (s *UserService) GetUserPage(param dto.UserListRequest, userId int) ([]dto.UserListResponse, int, string, error) {
+	if param.OrderByColumn == "" {
+		param.OrderByColumn, param.OrderRule = "user_id", "asc"
+	}
+
+	query := userListQuery(param, userId)
+
+	count, err := countPage(query, param.Count)
+	if err != nil {
+		return nil, 0, "", errors.Wrap(err, "failed to count users")
+	}
+
+	users, next, err := userKeyset.page(query, param.PageRequest, param.OrderByColumn, param.OrderRule)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	if err = setUserListAttributes(users); err != nil {
+		return nil, 0, "", err
+	}
+
+	return users, count, next, nil
+}
+
+// setUserListAttributes sets the custom attribute values of the listed users
+func setUserListAttributes(users []dto.UserListResponse) error {
+	userIds := make([]int, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.UserId)
+	}
+	attrValues, err := userAttrValueMap(userIds)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		users[i].Attributes = attrValues[users[i].UserId]
+		if users[i].Attributes == nil {
+			users[i].Attributes = make(map[string]string)
+		}
+	}
+
+	return nil
+}
+
+// userListQuery builds the user query, joined with the department, with the search conditions
+// and the data scope of the authorized user applied
+func userListQuery(param dto.UserListRequest, userId int) *gorm.DB {
 	query := dal.Gorm.Model(model.SysUser{}).
 		Select("sys_user.*", "sys_dept.dept_name", "sys_dept.leader").
-		Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
-		Scopes(GetDataScope("sys_dept", userId, "sys_user"))
+		Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id")
+
+	if param.DeptIds != nil {
+		query = query.Where("sys_user.dept_id IN ?", param.DeptIds)
+	} else {
+		query = query.Scopes(GetDataScope("sys_dept", userId, "sys_user"))
+	}
 
 	if param.UserName != "" {
 		query = query.Where("sys_user.user_name LIKE ?", "%"+param.UserName+"%")
 	}
 
 	if param.Phonenumber != "" {
-		query = query.Where("sys_user.phonenumber LIKE ?", "%"+param.Phonenumber+"%")
+		query = query.Where(piiContains("sys_user.phonenumber", param.Phonenumber))
 	}
 
 	if param.Status != "" {
@@ -329,18 +499,43 @@ func (s *UserService) GetUserListWithErr(param dto.UserListRequest, userId int,
 		query = query.Where("sys_user.create_time BETWEEN ? AND ?", param.BeginTime, param.EndTime)
 	}
 
-	if isPaging {
-		if err := query.Count(&count).Error; err != nil {
-			return nil, 0, errors.Wrap(err, "failed to count users")
-		}
-		query = query.Offset((param.PageNum - 1) * param.PageSize).Limit(param.PageSize)
+	if len(param.Attrs) > 0 {
+		query = query.Scopes(userAttrFilterScope(param.Attrs))
 	}
 
-	if err := query.Find(&users).Error; err != nil {
-		return nil, 0, errors.Wrap(err, "failed to query users")
-	}
+	return query
+}
 
-	return users, int(count), nil
+// ExportDataset describes the export of the users matching the search conditions within the data scope of userId,
+// with a column per enabled custom attribute after the built-in ones
+func (s *UserService) ExportDataset(param dto.UserListRequest, userId int) ExportDataset {
+	attrColumns, attrSelects := userAttrExportColumns()
+
+	return ExportDataset{
+		Name: constant.EXPORT_DATASET_USER,
+		Query: func() *gorm.DB {
+			return userListQuery(param, userId).Select(append([]string{"sys_user.*", "sys_dept.dept_name", "sys_dept.leader"}, attrSelects...))
+		},
+		KeyField:  "sys_user.user_id",
+		KeyColumn: "user_id",
+		Columns: append([]ExportColumn{
+			{Key: "userId", Field: "user_id", Header: "User ID", HeaderZh: "用户序号"},
+			{Key: "userName", Field: "user_name", Header: "Login Name", HeaderZh: "登录名称"},
+			{Key: "nickName", Field: "nick_name", Header: "User Name", HeaderZh: "用户名称"},
+			{Key: "email", Field: "email", Header: "User Email", HeaderZh: "用户邮箱", Encrypted: model.SysUserEmail{}},
+			{Key: "phonenumber", Field: "phonenumber", Header: "Phone Number", HeaderZh: "手机号码", Encrypted: model.SysUserPhonenumber{}},
+			{Key: "sex", Field: "sex", Header: "User Gender", HeaderZh: "用户性别", DictType: "sys_user_sex", Labels: map[string]string{
+				"0": "Male", "1": "Female", "2": "Unknown",
+			}},
+			{Key: "status", Field: "status", Header: "Account Status", HeaderZh: "帐号状态", DictType: "sys_normal_disable", Labels: map[string]string{
+				"0": "Normal", "1": "Disabled",
+			}},
+			{Key: "loginIp", Field: "login_ip", Header: "Last Login IP", HeaderZh: "最后登录IP", Encrypted: model.SysUserLoginIP{}},
+			{Key: "loginDate", Field: "login_date", Header: "Last Login Time", HeaderZh: "最后登录时间"},
+			{Key: "deptName", Field: "dept_name", Header: "Dept Name", HeaderZh: "部门名称"},
+			{Key: "deptLeader", Field: "leader", Header: "Dept Leader", HeaderZh: "部门负责人"},
+		}, attrColumns...),
+	}
 }
 
 // GetUserByUserId gets user details by user ID
@@ -472,7 +667,7 @@ func (s *UserService) GetUserByEmailWithErr(email string) (dto.UserTokenResponse
 			"sys_dept.dept_name",
 		).
 		Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
-		Where("sys_user.email = ?", email).
+		Where(piiEquals("sys_user.email", email)).
 		Last(&user).Error; err != nil {
 		return user, errors.Wrapf(err, "failed to get user by email %s", email)
 	}
@@ -522,7 +717,7 @@ func (s *UserService) GetUserByPhonenumberWithErr(phonenumber string) (dto.UserT
 			"sys_dept.dept_name",
 		).
 		Joins("LEFT JOIN sys_dept ON sys_user.dept_id = sys_dept.dept_id").
-		Where("sys_user.phonenumber = ?", phonenumber).
+		Where(piiEquals("sys_user.phonenumber", phonenumber)).
 		Last(&user).Error; err != nil {
 		return user, errors.Wrapf(err, "failed to get user by phone number %s", phonenumber)
 	}
@@ -603,7 +798,7 @@ func (s *UserService) GetUserListByRoleIdWithErr(param dto.RoleAuthUserAllocated
 	}
 
 	if param.Phonenumber != "" {
-		query = query.Where("sys_user.phonenumber LIKE ?", "%"+param.Phonenumber+"%")
+		query = query.Where(piiContains("sys_user.phonenumber", param.Phonenumber))
 	}
 
 	if err := query.Count(&count).Error; err != nil {
@@ -682,23 +877,66 @@ func (s *UserService) UserHasPerms(userId int, perms []string) bool {
 //   - bool: true if the user has at least one of the specified permissions, false otherwise
 //   - error: Any error that occurred during the check, or nil on success
 func (s *UserService) UserHasPermsWithErr(userId int, perms []string) (bool, error) {
-	var count int64
-
 	if userId <= 0 || len(perms) == 0 {
 		return false, nil
 	}
 
-	if err := dal.Gorm.Model(model.SysUserRole{}).
-		Joins("JOIN sys_role ON sys_user_role.role_id = sys_role.role_id AND sys_role.status = ?", constant.NORMAL_STATUS).
+	userPerms, _, err := userPermsCache.Get(context.Background(), strconv.Itoa(userId))
+	if err != nil {
+		return false, err
+	}
+
+	for _, perm := range perms {
+		if utils.Contains(userPerms, perm) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// userPermsCache holds the permissions of the users by user ID
+var userPermsCache = NewTwoLevelCache(TwoLevelCacheOptions{
+	Name:      "perms",
+	Hash:      rediskey.SysUserPermsKey,
+	LocalSize: 4096,
+	LocalTTL:  5 * time.Minute,
+	RedisTTL:  30 * time.Minute,
+	Jitter:    0.1,
+}, loadUserPermsCache)
+
+// Changes to the roles of users drop their permissions, changes to roles or menus drop those of
+// every user
+func init() {
+	OnCacheEvent("", func(event CacheEvent) {
+		switch event.Type {
+		case constant.CACHE_EVENT_USER:
+			userPermsCache.drop(event, cacheEventIdKeys(event.Ids)...)
+		case constant.CACHE_EVENT_ROLE, constant.CACHE_EVENT_MENU:
+			userPermsCache.drop(event)
+		}
+	})
+}
+
+// loadUserPermsCache loads the permissions a user holds through the normal roles and menus
+func loadUserPermsCache(key string) ([]string, bool, error) {
+	perms := make([]string, 0)
+
+	userId, err := strconv.Atoi(key)
+	if err != nil {
+		return perms, false, errors.Wrapf(err, "invalid user ID %s", key)
+	}
+
+	if err := dal.Gorm.Table("(?) AS user_roles", userRoleQuery(userId)).
+		Joins("JOIN sys_role ON user_roles.role_id = sys_role.role_id AND sys_role.status = ?", constant.NORMAL_STATUS).
 		Joins("JOIN sys_role_menu ON sys_role_menu.role_id = sys_role.role_id").
 		Joins("JOIN sys_menu ON sys_menu.menu_id = sys_role_menu.menu_id AND sys_menu.status = ?", constant.NORMAL_STATUS).
-		Where("sys_role.delete_time IS NULL AND sys_menu.delete_time IS NULL").
-		Where("sys_user_role.user_id = ? AND sys_menu.perms IN ?", userId, perms).
-		Count(&count).Error; err != nil {
-		return false, errors.Wrapf(err, "failed to check if user ID %d has permissions %v", userId, perms)
+		Where("sys_role.delete_time IS NULL AND sys_menu.delete_time IS NULL AND sys_menu.perms <> ''").
+		Distinct().Pluck("sys_menu.perms", &perms).Error; err != nil {
+		return nil, false, errors.Wrapf(err, "failed to get permissions of user ID %d", userId)
 	}
 
-	return count > 0, nil
+	return perms, true, nil
 }
 
 // UserHasRoles checks if a user has specific roles
@@ -733,13 +971,142 @@ func (s *UserService) UserHasRolesWithErr(userId int, roles []string) (bool, err
 		return false, nil
 	}
 
-	if err := dal.Gorm.Model(model.SysUserRole{}).
-		Joins("JOIN sys_role ON sys_user_role.role_id = sys_role.role_id AND sys_role.status = ?", constant.NORMAL_STATUS).
+	if err := dal.Gorm.Table("(?) AS user_roles", userRoleQuery(userId)).
+		Joins("JOIN sys_role ON user_roles.role_id = sys_role.role_id AND sys_role.status = ?", constant.NORMAL_STATUS).
 		Where("sys_role.delete_time IS NULL").
-		Where("sys_user_role.user_id = ? AND sys_role.role_key IN ?", userId, roles).
+		Where("sys_role.role_key IN ?", roles).
 		Count(&count).Error; err != nil {
 		return false, errors.Wrapf(err, "failed to check if user ID %d has roles %v", userId, roles)
 	}
 
 	return count > 0, nil
 }
+
+// CheckUserDelegation checks that an operator may manage users under a delegation scope:
+// the users belong to the delegated departments and hold no role, directly or through
+// groups and posts, that the operator could not assign
+//
+// Parameters:
+//   - operatorId: ID of the user managing the users
+//   - scope: Delegation scope of the operator
+//   - userIds: IDs of the users being managed
+//
+// Returns:
+//   - error: xerrors.ErrDelegationUserOutOfScope if any user is outside the scope,
+//     xerrors.ErrDelegationUserPrivileged if any user holds a role outside the whitelisted
+//     roles or with permissions the operator does not hold, or nil
+func (s *UserService) CheckUserDelegation(operatorId int, scope dto.DelegationScope, userIds ...int) error {
+	var count int64
+
+	uniqueIds := make([]int, 0, len(userIds))
+	for _, userId := range userIds {
+		if userId == 1 {
+			return xerrors.ErrDelegationUserOutOfScope
+		}
+		if !utils.Contains(uniqueIds, userId) {
+			uniqueIds = append(uniqueIds, userId)
+		}
+	}
+
+	if len(uniqueIds) == 0 {
+		return nil
+	}
+
+	if len(scope.DeptIds) == 0 {
+		return xerrors.ErrDelegationUserOutOfScope
+	}
+
+	if err := dal.Gorm.Model(model.SysUser{}).
+		Where("user_id IN ? AND dept_id IN ?", uniqueIds, scope.DeptIds).
+		Count(&count).Error; err != nil {
+		return errors.Wrap(err, "failed to check delegated users")
+	}
+
+	if int(count) != len(uniqueIds) {
+		return xerrors.ErrDelegationUserOutOfScope
+	}
+
+	heldRoleIds := make([]int, 0)
+	if err := dal.Gorm.Table("(?) AS user_roles", userRoleQuery(0)).
+		Where("user_roles.user_id IN ?", uniqueIds).
+		Distinct().
+		Pluck("user_roles.role_id", &heldRoleIds).Error; err != nil {
+		return errors.Wrap(err, "failed to get roles of delegated users")
+	}
+
+	for _, roleId := range heldRoleIds {
+		if !utils.Contains(scope.RoleIds, roleId) {
+			return xerrors.ErrDelegationUserPrivileged
+		}
+	}
+
+	if err := (&RoleService{}).CheckRolesGrantable(operatorId, heldRoleIds); err != nil {
+		if errors.Is(err, xerrors.ErrDelegationRoleEscalation) {
+			return xerrors.ErrDelegationUserPrivileged
+		}
+		return err
+	}
+
+	return nil
+}
+
+// CheckDelegatedUser confines a change authorized by a department delegation to the
+// delegated departments and whitelisted roles, and to roles the operator could grant itself
+//
+// Parameters:
+//   - operatorId: ID of the user making the change
+//   - scope: Delegation scope authorizing the change, nil when authorized by the global permission
+//   - userId: ID of the user being changed, 0 for a new user
+//   - deptId: Department the user is placed in, 0 to keep the department of the user
+//   - roleIds: IDs of the roles assigned directly
+//   - postIds: IDs of the posts assigned, whose default roles are checked when newly received
+//
+// Returns:
+//   - error: The error of CheckUserDelegation, DelegatedUserValidator or CheckRolesGrantable, or nil
+func (s *UserService) CheckDelegatedUser(operatorId int, scope *dto.DelegationScope, userId, deptId int, roleIds, postIds []int) error {
+	if scope == nil {
+		return nil
+	}
+
+	if userId > 0 {
+		if err := s.CheckUserDelegation(operatorId, *scope, userId); err != nil {
+			return err
+		}
+		if deptId == 0 {
+			deptId = s.GetUserByUserId(userId).DeptId
+		}
+	}
+
+	roleIds = withPostRoleIds(userId, roleIds, postIds)
+	if err := validator.DelegatedUserValidator(*scope, deptId, roleIds); err != nil {
+		return err
+	}
+
+	return (&RoleService{}).CheckRolesGrantable(operatorId, roleIds)
+}
+
+// withPostRoleIds adds the default roles of the posts a user newly receives to the roles
+// assigned directly, so that a post grants no more than the roles could be assigned directly.
+// A userId of 0 treats all posts as new.
+func withPostRoleIds(userId int, roleIds, postIds []int) []int {
+	heldPostIds := make([]int, 0)
+	if userId > 0 {
+		heldPostIds = (&PostService{}).GetPostIdsByUserId(userId)
+	}
+
+	addedPostIds := utils.Filter(postIds, func(postId int) bool {
+		return !utils.Contains(heldPostIds, postId)
+	})
+	if len(addedPostIds) == 0 {
+		return roleIds
+	}
+
+	grantRoleIds := append(make([]int, 0, len(roleIds)), roleIds...)
+	for _, roleId := range (&PostService{}).GetRoleIdsByPostIds(addedPostIds) {
+		if !utils.Contains(grantRoleIds, roleId) {
+			grantRoleIds = append(grantRoleIds, roleId)
+		}
+	}
+
+	return grantRoleIds
+}
@@ -6,8 +6,12 @@ import (
 	"mira/anima/dal"
 	"mira/app/dto"
 	"mira/app/model"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
 
 	"github.com/stretchr/testify/assert"
+
+	rediskey "mira/common/types/redis-key"
 )
 
 func TestUserService_CreateUser(t *testing.T) {
@@ -79,6 +83,21 @@ func TestUserService_UpdateUser(t *testing.T) {
 		assert.Len(t, userPosts, 1)
 		assert.Equal(t, 2, userPosts[0].PostId)
 	})
+
+	t.Run("should revoke the sessions of a disabled user", func(t *testing.T) {
+		// Prepare
+		dal.Gorm.Create(&model.SysUser{UserId: 2, UserName: "leaver", NickName: "Leaver"})
+		redisMock.ExpectSMembers(rediskey.UserAuthTokensKey(2)).SetVal([]string{"token-a"})
+		redisMock.ExpectUnlink("token-a").SetVal(1)
+		redisMock.ExpectDel(rediskey.UserAuthTokensKey(2)).SetVal(1)
+
+		// Execute
+		err := s.UpdateUser(dto.SaveUser{UserId: 2, Status: constant.EXCEPTION_STATUS}, nil, nil)
+		assert.NoError(t, err)
+
+		// Verify
+		assert.NoError(t, redisMock.ExpectationsWereMet())
+	})
 }
 
 func TestUserService_DeleteUser(t *testing.T) {
@@ -92,7 +111,7 @@ func TestUserService_DeleteUser(t *testing.T) {
 		dal.Gorm.Create(&user)
 
 		// Execute
-		err := s.DeleteUser([]int{2})
+		err := s.DeleteUser([]int{2}, "admin")
 		assert.NoError(t, err)
 
 		// Verify
@@ -103,7 +122,7 @@ func TestUserService_DeleteUser(t *testing.T) {
 
 	t.Run("should not delete super admin", func(t *testing.T) {
 		// Execute
-		err := s.DeleteUser([]int{1})
+		err := s.DeleteUser([]int{1}, "admin")
 		assert.Error(t, err)
 	})
 }
@@ -133,6 +152,60 @@ func TestUserService_GetUserList(t *testing.T) {
 	})
 }
 
+func TestUserService_GetUserPage(t *testing.T) {
+	setup()
+	defer teardown()
+	s := &UserService{}
+
+	dal.Gorm.Create(&model.SysUser{UserId: 2, UserName: "carol", NickName: "Sam"})
+	dal.Gorm.Create(&model.SysUser{UserId: 3, UserName: "alice", NickName: "Sam"})
+	dal.Gorm.Create(&model.SysUser{UserId: 4, UserName: "bob", NickName: "Kim"})
+
+	t.Run("should page through the users", func(t *testing.T) {
+		users, count, next, err := s.GetUserPage(dto.UserListRequest{PageRequest: dto.PageRequest{Limit: 2}}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, []int{2, 3}, []int{users[0].UserId, users[1].UserId})
+		assert.NotNil(t, users[0].Attributes)
+
+		users, _, next, err = s.GetUserPage(dto.UserListRequest{PageRequest: dto.PageRequest{Limit: 2, Cursor: next}}, 1)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, 4, users[0].UserId)
+		assert.Empty(t, next)
+	})
+
+	t.Run("should sort by name, breaking ties by ID", func(t *testing.T) {
+		param := dto.UserListRequest{
+			PageRequest:   dto.PageRequest{Limit: 1},
+			OrderByColumn: "nick_name",
+			OrderRule:     "DESC",
+		}
+		ids := make([]int, 0)
+		for {
+			users, _, next, err := s.GetUserPage(param, 1)
+			assert.NoError(t, err)
+			ids = append(ids, users[0].UserId)
+			if next == "" {
+				break
+			}
+			param.Cursor = next
+		}
+		assert.Equal(t, []int{3, 2, 4}, ids)
+
+		users, _, err := s.GetUserListWithErr(dto.UserListRequest{
+			PageRequest:   dto.PageRequest{PageNum: 1, PageSize: 10},
+			OrderByColumn: "user_name",
+			OrderRule:     "ASC",
+		}, 1, true)
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", users[0].UserName)
+
+		_, _, err = s.GetUserListWithErr(dto.UserListRequest{OrderByColumn: "password"}, 1, false)
+		assert.Equal(t, xerrors.ErrPageSortInvalid, err)
+	})
+}
+
 func TestUserService_GetUserByUserId(t *testing.T) {
 	setup()
 	defer teardown()
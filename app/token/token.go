@@ -16,6 +16,7 @@ import (
 	rediskey "mira/common/types/redis-key"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -70,12 +71,56 @@ func GenerateToken(claims *SysUserClaim, user dto.UserTokenResponse) (string, er
 		return "", err
 	}
 
+	// Index the token under its user so every session of the user can be revoked at once
+	if err = dal.Redis.SAdd(context.Background(), rediskey.UserAuthTokensKey(user.UserId), rediskey.UserTokenKey()+claims.Uuid).Err(); err != nil {
+		return "", err
+	}
+	if err = dal.Redis.Expire(context.Background(), rediskey.UserAuthTokensKey(user.UserId), time.Minute*time.Duration(config.Data.Token.ExpireTime)).Err(); err != nil {
+		return "", err
+	}
+
 	return token, nil
 }
 
 // RefreshToken refreshes the token.
 func RefreshToken(ctx context.Context, tokenKey string, user *UserTokenResponse) error {
-	return dal.Redis.Set(ctx, tokenKey, user, time.Minute*time.Duration(config.Data.Token.ExpireTime)).Err()
+	if err := dal.Redis.Set(ctx, tokenKey, user, time.Minute*time.Duration(config.Data.Token.ExpireTime)).Err(); err != nil {
+		return err
+	}
+
+	// The token index must live as long as the newest token it holds
+	return dal.Redis.Expire(ctx, rediskey.UserAuthTokensKey(user.UserId), time.Minute*time.Duration(config.Data.Token.ExpireTime)).Err()
+}
+
+// RevokeUserTokens deletes every token issued to the user, logging out all of their sessions.
+// It returns the number of sessions that were still alive. Tokens are unlinked one key per
+// command, since they may belong to different slots on Redis Cluster.
+func RevokeUserTokens(ctx context.Context, userId int) (int, error) {
+	tokenKeys, err := dal.Redis.SMembers(ctx, rediskey.UserAuthTokensKey(userId)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	var revoked int64
+	if len(tokenKeys) > 0 {
+		pipe := dal.Redis.Pipeline()
+		unlinks := make([]*redis.IntCmd, 0, len(tokenKeys))
+		for _, tokenKey := range tokenKeys {
+			unlinks = append(unlinks, pipe.Unlink(ctx, tokenKey))
+		}
+		if _, err = pipe.Exec(ctx); err != nil {
+			return 0, err
+		}
+		for _, unlink := range unlinks {
+			revoked += unlink.Val()
+		}
+	}
+
+	if err = dal.Redis.Del(ctx, rediskey.UserAuthTokensKey(userId)).Err(); err != nil {
+		return 0, err
+	}
+
+	return int(revoked), nil
 }
 
 // GetAuthUser parses the token.
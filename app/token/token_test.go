@@ -54,9 +54,12 @@ func TestGenerateToken(t *testing.T) {
 		ExpireTime:        datetime.Datetime{Time: claims.ExpiresAt.Time},
 	}
 	mock.ExpectSet(rediskey.UserTokenKey()+claims.Uuid, expectedRedisValue, time.Minute*time.Duration(config.Data.Token.ExpireTime)).SetVal("OK")
+	mock.ExpectSAdd(rediskey.UserAuthTokensKey(user.UserId), rediskey.UserTokenKey()+claims.Uuid).SetVal(1)
+	mock.ExpectExpire(rediskey.UserAuthTokensKey(user.UserId), time.Minute*time.Duration(config.Data.Token.ExpireTime)).SetVal(true)
 
 	_, err := GenerateToken(claims, user)
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 
 	// Mock Redis SET failure
 	mock.ExpectSet(rediskey.UserTokenKey()+claims.Uuid, expectedRedisValue, time.Minute*time.Duration(config.Data.Token.ExpireTime)).SetErr(fmt.Errorf("redis error"))
@@ -120,11 +123,45 @@ func TestRefreshToken(t *testing.T) {
 	}
 
 	mock.ExpectSet("test-key", user, time.Minute*time.Duration(config.Data.Token.ExpireTime)).SetVal("OK")
+	mock.ExpectExpire(rediskey.UserAuthTokensKey(1), time.Minute*time.Duration(config.Data.Token.ExpireTime)).SetVal(true)
 
 	err := RefreshToken(context.Background(), "test-key", user)
 	assert.NoError(t, err)
 }
 
+func TestRevokeUserTokens(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	dal.Redis = db
+
+	t.Run("should delete every indexed token", func(t *testing.T) {
+		mock.ExpectSMembers(rediskey.UserAuthTokensKey(1)).SetVal([]string{"token-a", "token-b"})
+		mock.ExpectUnlink("token-a").SetVal(1)
+		mock.ExpectUnlink("token-b").SetVal(0)
+		mock.ExpectDel(rediskey.UserAuthTokensKey(1)).SetVal(1)
+
+		revoked, err := RevokeUserTokens(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, revoked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should succeed for a user without sessions", func(t *testing.T) {
+		mock.ExpectSMembers(rediskey.UserAuthTokensKey(2)).SetVal([]string{})
+		mock.ExpectDel(rediskey.UserAuthTokensKey(2)).SetVal(0)
+
+		revoked, err := RevokeUserTokens(context.Background(), 2)
+		assert.NoError(t, err)
+		assert.Zero(t, revoked)
+	})
+
+	t.Run("should return redis errors", func(t *testing.T) {
+		mock.ExpectSMembers(rediskey.UserAuthTokensKey(3)).SetErr(fmt.Errorf("redis error"))
+
+		_, err := RevokeUserTokens(context.Background(), 3)
+		assert.Error(t, err)
+	})
+}
+
 func TestGetAuthUser(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	dal.Redis = db
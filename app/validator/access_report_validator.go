@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// PermUsersValidator validates the request to list users holding a permission.
+func PermUsersValidator(param dto.PermUsersRequest) error {
+	switch {
+	case param.Perm == "":
+		return xerrors.ErrAccessPermEmpty
+	default:
+		return nil
+	}
+}
+
+// UserEffectivePermsValidator validates the request to list a user's effective permissions.
+func UserEffectivePermsValidator(param dto.UserEffectivePermsRequest) error {
+	switch {
+	case param.UserId <= 0:
+		return xerrors.ErrParam
+	default:
+		return nil
+	}
+}
+
+// AccessCompareValidator validates the request to compare two users or two roles.
+func AccessCompareValidator(param dto.AccessCompareRequest) error {
+	switch {
+	case param.Type != constant.ACCESS_COMPARE_USER && param.Type != constant.ACCESS_COMPARE_ROLE:
+		return xerrors.ErrAccessCompareType
+	case param.LeftId <= 0 || param.RightId <= 0:
+		return xerrors.ErrAccessCompareIdsEmpty
+	default:
+		return nil
+	}
+}
+
+// AccessExplainValidator validates the request to explain a permission check.
+func AccessExplainValidator(param dto.AccessExplainRequest) error {
+	switch {
+	case param.UserId <= 0:
+		return xerrors.ErrParam
+	case param.Perm == "":
+		return xerrors.ErrAccessPermEmpty
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,121 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestAccessCompareValidator(t *testing.T) {
+	type args struct {
+		param dto.AccessCompareRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_type",
+			args: args{
+				param: dto.AccessCompareRequest{
+					Type:    "dept",
+					LeftId:  1,
+					RightId: 2,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrAccessCompareType,
+		},
+		{
+			name: "empty_right_id",
+			args: args{
+				param: dto.AccessCompareRequest{
+					Type:    "role",
+					LeftId:  1,
+					RightId: 0,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrAccessCompareIdsEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.AccessCompareRequest{
+					Type:    "user",
+					LeftId:  1,
+					RightId: 2,
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := AccessCompareValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("AccessCompareValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("AccessCompareValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAccessExplainValidator(t *testing.T) {
+	type args struct {
+		param dto.AccessExplainRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_user_id",
+			args: args{
+				param: dto.AccessExplainRequest{
+					UserId: 0,
+					Perm:   "system:user:edit",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_perm",
+			args: args{
+				param: dto.AccessExplainRequest{
+					UserId: 2,
+					Perm:   "",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrAccessPermEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.AccessExplainRequest{
+					UserId: 2,
+					Perm:   "system:user:edit",
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := AccessExplainValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("AccessExplainValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("AccessExplainValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
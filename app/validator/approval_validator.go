@@ -0,0 +1,30 @@
+package validator
+
+import (
+	"strings"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+// ApproveApprovalValidator validates the request to approve a change request.
+func ApproveApprovalValidator(param dto.ReviewApprovalRequest) error {
+	switch {
+	case param.ApprovalId <= 0:
+		return xerrors.ErrParam
+	default:
+		return nil
+	}
+}
+
+// RejectApprovalValidator validates the request to reject a change request.
+func RejectApprovalValidator(param dto.ReviewApprovalRequest) error {
+	switch {
+	case param.ApprovalId <= 0:
+		return xerrors.ErrParam
+	case strings.TrimSpace(param.Reason) == "":
+		return xerrors.ErrApprovalReasonEmpty
+	default:
+		return nil
+	}
+}
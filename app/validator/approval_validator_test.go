@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestRejectApprovalValidator(t *testing.T) {
+	type args struct {
+		param dto.ReviewApprovalRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_approval_id",
+			args: args{
+				param: dto.ReviewApprovalRequest{
+					ApprovalId: 0,
+					Reason:     "not justified",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_reason",
+			args: args{
+				param: dto.ReviewApprovalRequest{
+					ApprovalId: 1,
+					Reason:     "  ",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrApprovalReasonEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.ReviewApprovalRequest{
+					ApprovalId: 1,
+					Reason:     "not justified",
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RejectApprovalValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("RejectApprovalValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("RejectApprovalValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
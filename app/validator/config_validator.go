@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"unicode/utf8"
+
 	"mira/app/dto"
 	"mira/common/xerrors"
 )
@@ -30,6 +32,23 @@ func UpdateConfigValidator(param dto.UpdateConfigRequest) error {
 		return xerrors.ErrConfigKeyEmpty
 	case param.ConfigValue == "":
 		return xerrors.ErrConfigValueEmpty
+	case utf8.RuneCountInString(param.Reason) > 500:
+		return xerrors.ErrConfigHistoryReasonLong
+	default:
+		return nil
+	}
+}
+
+// ConfigHistoryRollbackValidator validates the request to roll a configuration or dictionary
+// entry back to a version of its change history.
+func ConfigHistoryRollbackValidator(param dto.ConfigHistoryRollbackRequest) error {
+	switch {
+	case param.HistoryId <= 0:
+		return xerrors.ErrParam
+	case param.Reason == "":
+		return xerrors.ErrConfigHistoryReasonEmpty
+	case utf8.RuneCountInString(param.Reason) > 500:
+		return xerrors.ErrConfigHistoryReasonLong
 	default:
 		return nil
 	}
@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"strings"
 	"testing"
 
 	"mira/app/dto"
@@ -139,6 +140,20 @@ func TestUpdateConfigValidator(t *testing.T) {
 			wantErr: true,
 			err:     xerrors.ErrConfigValueEmpty,
 		},
+		{
+			name: "reason_too_long",
+			args: args{
+				param: dto.UpdateConfigRequest{
+					ConfigId:    1,
+					ConfigName:  "name",
+					ConfigKey:   "key",
+					ConfigValue: "value",
+					Reason:      strings.Repeat("理", 501),
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrConfigHistoryReasonLong,
+		},
 		{
 			name: "success",
 			args: args{
@@ -163,3 +178,69 @@ func TestUpdateConfigValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigHistoryRollbackValidator(t *testing.T) {
+	type args struct {
+		param dto.ConfigHistoryRollbackRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_history_id",
+			args: args{
+				param: dto.ConfigHistoryRollbackRequest{
+					HistoryId: 0,
+					Reason:    "revert",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_reason",
+			args: args{
+				param: dto.ConfigHistoryRollbackRequest{
+					HistoryId: 1,
+					Reason:    "",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrConfigHistoryReasonEmpty,
+		},
+		{
+			name: "reason_too_long",
+			args: args{
+				param: dto.ConfigHistoryRollbackRequest{
+					HistoryId: 1,
+					Reason:    strings.Repeat("理", 501),
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrConfigHistoryReasonLong,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.ConfigHistoryRollbackRequest{
+					HistoryId: 1,
+					Reason:    strings.Repeat("理", 500),
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ConfigHistoryRollbackValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("ConfigHistoryRollbackValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("ConfigHistoryRollbackValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+)
+
+// CreateDelegationValidator validates the request to create a delegation.
+func CreateDelegationValidator(param dto.CreateDelegationRequest) error {
+	switch {
+	case param.DeptId <= 0:
+		return xerrors.ErrDelegationDeptEmpty
+	case param.DelegateType != constant.DELEGATE_TYPE_USER && param.DelegateType != constant.DELEGATE_TYPE_ROLE:
+		return xerrors.ErrDelegationTypeInvalid
+	case param.DelegateId <= 0:
+		return xerrors.ErrDelegationDelegateEmpty
+	default:
+		return nil
+	}
+}
+
+// UpdateDelegationValidator validates the request to update a delegation.
+func UpdateDelegationValidator(param dto.UpdateDelegationRequest) error {
+	switch {
+	case param.DelegationId <= 0:
+		return xerrors.ErrParam
+	case param.DeptId <= 0:
+		return xerrors.ErrDelegationDeptEmpty
+	case param.DelegateType != constant.DELEGATE_TYPE_USER && param.DelegateType != constant.DELEGATE_TYPE_ROLE:
+		return xerrors.ErrDelegationTypeInvalid
+	case param.DelegateId <= 0:
+		return xerrors.ErrDelegationDelegateEmpty
+	default:
+		return nil
+	}
+}
+
+// DelegatedUserValidator validates that a delegate places a user in a delegated
+// department and assigns whitelisted roles only.
+func DelegatedUserValidator(scope dto.DelegationScope, deptId int, roleIds []int) error {
+	switch {
+	case !utils.Contains(scope.DeptIds, deptId):
+		return xerrors.ErrDelegationDeptOutOfScope
+	case len(utils.Filter(roleIds, func(roleId int) bool { return !utils.Contains(scope.RoleIds, roleId) })) > 0:
+		return xerrors.ErrDelegationRoleNotAllowed
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,186 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+func TestCreateDelegationValidator(t *testing.T) {
+	type args struct {
+		param dto.CreateDelegationRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_dept",
+			args: args{
+				param: dto.CreateDelegationRequest{
+					DelegateType: constant.DELEGATE_TYPE_USER,
+					DelegateId:   2,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrDelegationDeptEmpty,
+		},
+		{
+			name: "invalid_type",
+			args: args{
+				param: dto.CreateDelegationRequest{
+					DeptId:       103,
+					DelegateType: "dept",
+					DelegateId:   2,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrDelegationTypeInvalid,
+		},
+		{
+			name: "empty_delegate",
+			args: args{
+				param: dto.CreateDelegationRequest{
+					DeptId:       103,
+					DelegateType: constant.DELEGATE_TYPE_ROLE,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrDelegationDelegateEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.CreateDelegationRequest{
+					DeptId:       103,
+					DelegateType: constant.DELEGATE_TYPE_USER,
+					DelegateId:   2,
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CreateDelegationValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("CreateDelegationValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("CreateDelegationValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUpdateDelegationValidator(t *testing.T) {
+	type args struct {
+		param dto.UpdateDelegationRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_delegation_id",
+			args: args{
+				param: dto.UpdateDelegationRequest{
+					DeptId:       103,
+					DelegateType: constant.DELEGATE_TYPE_USER,
+					DelegateId:   2,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "invalid_type",
+			args: args{
+				param: dto.UpdateDelegationRequest{
+					DelegationId: 1,
+					DeptId:       103,
+					DelegateType: "",
+					DelegateId:   2,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrDelegationTypeInvalid,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.UpdateDelegationRequest{
+					DelegationId: 1,
+					DeptId:       103,
+					DelegateType: constant.DELEGATE_TYPE_ROLE,
+					DelegateId:   2,
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UpdateDelegationValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateDelegationValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("UpdateDelegationValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestDelegatedUserValidator(t *testing.T) {
+	scope := dto.DelegationScope{DeptIds: []int{101, 103, 104}, RoleIds: []int{2}}
+
+	type args struct {
+		deptId  int
+		roleIds []int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name:    "dept_out_of_scope",
+			args:    args{deptId: 105, roleIds: []int{2}},
+			wantErr: true,
+			err:     xerrors.ErrDelegationDeptOutOfScope,
+		},
+		{
+			name:    "role_not_allowed",
+			args:    args{deptId: 103, roleIds: []int{2, 3}},
+			wantErr: true,
+			err:     xerrors.ErrDelegationRoleNotAllowed,
+		},
+		{
+			name:    "no_roles",
+			args:    args{deptId: 104, roleIds: nil},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name:    "success",
+			args:    args{deptId: 103, roleIds: []int{2}},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := DelegatedUserValidator(scope, tt.args.deptId, tt.args.roleIds); (err != nil) != tt.wantErr {
+				t.Errorf("DelegatedUserValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("DelegatedUserValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -32,3 +32,29 @@ func UpdateDeptValidator(param dto.UpdateDeptRequest) error {
 		return nil
 	}
 }
+
+// MoveDeptValidator validates the request to move a department.
+func MoveDeptValidator(param dto.MoveDeptRequest) error {
+	switch {
+	case param.DeptId <= 0:
+		return xerrors.ErrParam
+	case param.ParentId <= 0:
+		return xerrors.ErrParentDeptEmpty
+	case param.DeptId == param.ParentId:
+		return xerrors.ErrDeptParentSelf
+	default:
+		return nil
+	}
+}
+
+// MergeDeptValidator validates the request to merge a department into another.
+func MergeDeptValidator(param dto.MergeDeptRequest) error {
+	switch {
+	case param.SourceDeptId <= 0 || param.TargetDeptId <= 0:
+		return xerrors.ErrParam
+	case param.SourceDeptId == param.TargetDeptId:
+		return xerrors.ErrDeptMergeSelf
+	default:
+		return nil
+	}
+}
@@ -155,3 +155,103 @@ func TestUpdateDeptValidator(t *testing.T) {
 		})
 	}
 }
+
+func TestMoveDeptValidator(t *testing.T) {
+	type args struct {
+		param dto.MoveDeptRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_dept_id",
+			args: args{
+				param: dto.MoveDeptRequest{ParentId: 100},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_parent",
+			args: args{
+				param: dto.MoveDeptRequest{DeptId: 101},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParentDeptEmpty,
+		},
+		{
+			name: "parent_self",
+			args: args{
+				param: dto.MoveDeptRequest{DeptId: 101, ParentId: 101},
+			},
+			wantErr: true,
+			err:     xerrors.ErrDeptParentSelf,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.MoveDeptRequest{DeptId: 103, ParentId: 102},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := MoveDeptValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("MoveDeptValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("MoveDeptValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestMergeDeptValidator(t *testing.T) {
+	type args struct {
+		param dto.MergeDeptRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_source",
+			args: args{
+				param: dto.MergeDeptRequest{TargetDeptId: 102},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "merge_self",
+			args: args{
+				param: dto.MergeDeptRequest{SourceDeptId: 102, TargetDeptId: 102},
+			},
+			wantErr: true,
+			err:     xerrors.ErrDeptMergeSelf,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.MergeDeptRequest{SourceDeptId: 103, TargetDeptId: 102, DryRun: true},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := MergeDeptValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("MergeDeptValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("MergeDeptValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package validator
 
 import (
+	"unicode/utf8"
+
 	"mira/app/dto"
 	"mira/common/xerrors"
 )
@@ -52,6 +54,8 @@ func UpdateDictDataValidator(param dto.UpdateDictDataRequest) error {
 		return xerrors.ErrDictLabelEmpty
 	case param.DictValue == "":
 		return xerrors.ErrDictValueEmpty
+	case utf8.RuneCountInString(param.Reason) > 500:
+		return xerrors.ErrConfigHistoryReasonLong
 	default:
 		return nil
 	}
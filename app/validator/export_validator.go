@@ -0,0 +1,28 @@
+package validator
+
+import (
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// ExportValidator validates the format and header language of an export.
+func ExportValidator(param dto.ExportRequest) error {
+	switch param.Format {
+	case "",
+		constant.EXPORT_FORMAT_XLSX,
+		constant.EXPORT_FORMAT_CSV,
+		constant.EXPORT_FORMAT_NDJSON:
+	default:
+		return xerrors.ErrExportFormatInvalid
+	}
+
+	switch param.Lang {
+	case "",
+		constant.EXPORT_LANG_EN,
+		constant.EXPORT_LANG_ZH:
+		return nil
+	default:
+		return xerrors.ErrExportLangInvalid
+	}
+}
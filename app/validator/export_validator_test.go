@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+func TestExportValidator(t *testing.T) {
+	type args struct {
+		param dto.ExportRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_format",
+			args: args{
+				param: dto.ExportRequest{Format: "pdf"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrExportFormatInvalid,
+		},
+		{
+			name: "invalid_lang",
+			args: args{
+				param: dto.ExportRequest{Format: constant.EXPORT_FORMAT_CSV, Lang: "fr"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrExportLangInvalid,
+		},
+		{
+			name: "defaults",
+			args: args{
+				param: dto.ExportRequest{},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.ExportRequest{Format: constant.EXPORT_FORMAT_NDJSON, Lang: constant.EXPORT_LANG_ZH},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ExportValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("ExportValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("ExportValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package validator
+
+import (
+	"strings"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// OrgChartExportValidator validates the format, visible fields and language of an org chart export.
+func OrgChartExportValidator(param dto.OrgChartExportRequest) error {
+	switch param.Format {
+	case "",
+		constant.ORG_CHART_FORMAT_SVG,
+		constant.ORG_CHART_FORMAT_PDF,
+		constant.ORG_CHART_FORMAT_JSON:
+	default:
+		return xerrors.ErrOrgChartFormatInvalid
+	}
+
+	if param.Fields != "" {
+		for _, field := range strings.Split(param.Fields, ",") {
+			switch strings.TrimSpace(field) {
+			case constant.ORG_CHART_FIELD_LEADER,
+				constant.ORG_CHART_FIELD_HEAD_COUNT,
+				constant.ORG_CHART_FIELD_POSTS,
+				constant.ORG_CHART_FIELD_USERS:
+			default:
+				return xerrors.ErrOrgChartFieldInvalid
+			}
+		}
+	}
+
+	switch param.Lang {
+	case "",
+		constant.EXPORT_LANG_EN,
+		constant.EXPORT_LANG_ZH:
+		return nil
+	default:
+		return xerrors.ErrExportLangInvalid
+	}
+}
@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+func TestOrgChartExportValidator(t *testing.T) {
+	type args struct {
+		param dto.OrgChartExportRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_format",
+			args: args{
+				param: dto.OrgChartExportRequest{Format: "png"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrOrgChartFormatInvalid,
+		},
+		{
+			name: "invalid_field",
+			args: args{
+				param: dto.OrgChartExportRequest{Format: constant.ORG_CHART_FORMAT_SVG, Fields: "leader,phone"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrOrgChartFieldInvalid,
+		},
+		{
+			name: "invalid_lang",
+			args: args{
+				param: dto.OrgChartExportRequest{Format: constant.ORG_CHART_FORMAT_PDF, Lang: "fr"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrExportLangInvalid,
+		},
+		{
+			name: "defaults",
+			args: args{
+				param: dto.OrgChartExportRequest{},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.OrgChartExportRequest{Format: constant.ORG_CHART_FORMAT_JSON, Fields: "leader, headCount,posts,users", Lang: constant.EXPORT_LANG_ZH},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := OrgChartExportValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("OrgChartExportValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("OrgChartExportValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package validator
+
+import (
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// PageValidator validates the keyset limit and count mode of a page.
+func PageValidator(param dto.PageRequest) error {
+	if param.Limit < 0 || param.Limit > 500 {
+		return xerrors.ErrPageLimitInvalid
+	}
+
+	switch param.Count {
+	case "",
+		constant.PAGE_COUNT_EXACT,
+		constant.PAGE_COUNT_ESTIMATE,
+		constant.PAGE_COUNT_NONE:
+		return nil
+	default:
+		return xerrors.ErrPageCountInvalid
+	}
+}
@@ -0,0 +1,70 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestPageValidator(t *testing.T) {
+	type args struct {
+		param dto.PageRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "negative_limit",
+			args: args{
+				param: dto.PageRequest{Limit: -1},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPageLimitInvalid,
+		},
+		{
+			name: "limit_too_large",
+			args: args{
+				param: dto.PageRequest{Limit: 501},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPageLimitInvalid,
+		},
+		{
+			name: "invalid_count",
+			args: args{
+				param: dto.PageRequest{Count: "approx"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPageCountInvalid,
+		},
+		{
+			name: "offset_page",
+			args: args{
+				param: dto.PageRequest{PageNum: 2, PageSize: 10},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "keyset_page",
+			args: args{
+				param: dto.PageRequest{Cursor: "abc", Limit: 500, Count: "none"},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := PageValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("PageValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("PageValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
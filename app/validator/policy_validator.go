@@ -0,0 +1,67 @@
+package validator
+
+import (
+	"fmt"
+
+	"mira/app/dto"
+	"mira/common/policy"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// CreatePolicyValidator validates the request to create a policy.
+func CreatePolicyValidator(param dto.CreatePolicyRequest) error {
+	switch {
+	case param.PolicyName == "":
+		return xerrors.ErrPolicyNameEmpty
+	case param.Action == "":
+		return xerrors.ErrPolicyActionEmpty
+	case param.Effect != constant.POLICY_EFFECT_ALLOW && param.Effect != constant.POLICY_EFFECT_DENY:
+		return xerrors.ErrPolicyEffectInvalid
+	default:
+		return policyConditionValidator(param.Condition)
+	}
+}
+
+// UpdatePolicyValidator validates the request to update a policy.
+func UpdatePolicyValidator(param dto.UpdatePolicyRequest) error {
+	switch {
+	case param.PolicyId <= 0:
+		return xerrors.ErrParam
+	case param.PolicyName == "":
+		return xerrors.ErrPolicyNameEmpty
+	case param.Action == "":
+		return xerrors.ErrPolicyActionEmpty
+	case param.Effect != constant.POLICY_EFFECT_ALLOW && param.Effect != constant.POLICY_EFFECT_DENY:
+		return xerrors.ErrPolicyEffectInvalid
+	default:
+		return policyConditionValidator(param.Condition)
+	}
+}
+
+// PolicyTestValidator validates the request to test policies.
+func PolicyTestValidator(param dto.PolicyTestRequest) error {
+	switch {
+	case param.Action == "":
+		return xerrors.ErrPolicyActionEmpty
+	case param.UserId <= 0:
+		return xerrors.ErrParam
+	case param.Condition != "":
+		return policyConditionValidator(param.Condition)
+	default:
+		return nil
+	}
+}
+
+// policyConditionValidator checks that the condition is a valid policy expression.
+func policyConditionValidator(condition string) error {
+	if condition == "" {
+		return xerrors.ErrPolicyConditionEmpty
+	}
+
+	if _, err := policy.Compile(condition); err != nil {
+		return fmt.Errorf("%w: %v", xerrors.ErrPolicyConditionInvalid, err)
+	}
+
+	return nil
+}
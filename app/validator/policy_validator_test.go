@@ -0,0 +1,233 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestCreatePolicyValidator(t *testing.T) {
+	type args struct {
+		param dto.CreatePolicyRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_policy_name",
+			args: args{
+				param: dto.CreatePolicyRequest{
+					PolicyName: "",
+					Action:     "system:user:edit",
+					Effect:     "allow",
+					Condition:  "true",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPolicyNameEmpty,
+		},
+		{
+			name: "empty_action",
+			args: args{
+				param: dto.CreatePolicyRequest{
+					PolicyName: "name",
+					Action:     "",
+					Effect:     "allow",
+					Condition:  "true",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPolicyActionEmpty,
+		},
+		{
+			name: "invalid_effect",
+			args: args{
+				param: dto.CreatePolicyRequest{
+					PolicyName: "name",
+					Action:     "system:user:edit",
+					Effect:     "maybe",
+					Condition:  "true",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPolicyEffectInvalid,
+		},
+		{
+			name: "empty_condition",
+			args: args{
+				param: dto.CreatePolicyRequest{
+					PolicyName: "name",
+					Action:     "system:user:edit",
+					Effect:     "deny",
+					Condition:  "",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPolicyConditionEmpty,
+		},
+		{
+			name: "invalid_condition",
+			args: args{
+				param: dto.CreatePolicyRequest{
+					PolicyName: "name",
+					Action:     "system:user:edit",
+					Effect:     "deny",
+					Condition:  "subject.userId ==",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPolicyConditionInvalid,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.CreatePolicyRequest{
+					PolicyName: "name",
+					Action:     "system:user:edit",
+					Effect:     "deny",
+					Condition:  "resource.userId == subject.userId",
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CreatePolicyValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("CreatePolicyValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if !errors.Is(err, tt.err) {
+				t.Errorf("CreatePolicyValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUpdatePolicyValidator(t *testing.T) {
+	type args struct {
+		param dto.UpdatePolicyRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_policy_id",
+			args: args{
+				param: dto.UpdatePolicyRequest{
+					PolicyId:   0,
+					PolicyName: "name",
+					Action:     "system:user:edit",
+					Effect:     "allow",
+					Condition:  "true",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_policy_name",
+			args: args{
+				param: dto.UpdatePolicyRequest{
+					PolicyId:   1,
+					PolicyName: "",
+					Action:     "system:user:edit",
+					Effect:     "allow",
+					Condition:  "true",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPolicyNameEmpty,
+		},
+		{
+			name: "invalid_condition",
+			args: args{
+				param: dto.UpdatePolicyRequest{
+					PolicyId:   1,
+					PolicyName: "name",
+					Action:     "system:user:edit",
+					Effect:     "allow",
+					Condition:  "(true",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPolicyConditionInvalid,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.UpdatePolicyRequest{
+					PolicyId:   1,
+					PolicyName: "name",
+					Action:     "system:user:edit",
+					Effect:     "allow",
+					Condition:  "subject.deptId == resource.deptId",
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UpdatePolicyValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("UpdatePolicyValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if !errors.Is(err, tt.err) {
+				t.Errorf("UpdatePolicyValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPolicyTestValidator(t *testing.T) {
+	type args struct {
+		param dto.PolicyTestRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name:    "empty_action",
+			args:    args{param: dto.PolicyTestRequest{UserId: 2}},
+			wantErr: true,
+			err:     xerrors.ErrPolicyActionEmpty,
+		},
+		{
+			name:    "invalid_user_id",
+			args:    args{param: dto.PolicyTestRequest{Action: "system:user:edit"}},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name:    "invalid_condition",
+			args:    args{param: dto.PolicyTestRequest{Action: "system:user:edit", UserId: 2, Condition: "1 <"}},
+			wantErr: true,
+			err:     xerrors.ErrPolicyConditionInvalid,
+		},
+		{
+			name:    "success",
+			args:    args{param: dto.PolicyTestRequest{Action: "system:user:edit", UserId: 2}},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := PolicyTestValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("PolicyTestValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if !errors.Is(err, tt.err) {
+				t.Errorf("PolicyTestValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
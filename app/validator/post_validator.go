@@ -26,6 +26,8 @@ func UpdatePostValidator(param dto.UpdatePostRequest) error {
 		return xerrors.ErrPostCodeEmpty
 	case param.PostName == "":
 		return xerrors.ErrPostNameEmpty
+	case param.ParentId == param.PostId:
+		return xerrors.ErrPostParentSelf
 	default:
 		return nil
 	}
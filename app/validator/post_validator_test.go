@@ -108,6 +108,19 @@ func TestUpdatePostValidator(t *testing.T) {
 			wantErr: true,
 			err:     xerrors.ErrPostNameEmpty,
 		},
+		{
+			name: "parent_self",
+			args: args{
+				param: dto.UpdatePostRequest{
+					PostId:   1,
+					ParentId: 1,
+					PostCode: "code",
+					PostName: "name",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPostParentSelf,
+		},
 		{
 			name: "success",
 			args: args{
@@ -0,0 +1,49 @@
+package validator
+
+import (
+	"unicode/utf8"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// PrivacyExportValidator validates the request to export the personal data of a user.
+func PrivacyExportValidator(param dto.PrivacyExportRequest) error {
+	switch {
+	case param.UserId <= 0:
+		return xerrors.ErrParam
+	case param.Lang != "" && param.Lang != constant.EXPORT_LANG_EN && param.Lang != constant.EXPORT_LANG_ZH:
+		return xerrors.ErrExportLangInvalid
+	case utf8.RuneCountInString(param.Reason) > 500:
+		return xerrors.ErrPrivacyReasonTooLong
+	default:
+		return nil
+	}
+}
+
+// PrivacyEraseValidator validates the request to erase the personal data of a user.
+func PrivacyEraseValidator(param dto.PrivacyEraseRequest, authUserId int) error {
+	switch {
+	case param.UserId <= 0:
+		return xerrors.ErrParam
+	case param.UserId == 1:
+		return xerrors.ErrPrivacySuperAdminErase
+	case param.UserId == authUserId:
+		return xerrors.ErrPrivacyEraseActiveUser
+	case utf8.RuneCountInString(param.Reason) > 500:
+		return xerrors.ErrPrivacyReasonTooLong
+	default:
+		return nil
+	}
+}
+
+// PrivacyRequestListValidator validates the filters of the privacy request list.
+func PrivacyRequestListValidator(param dto.PrivacyRequestListRequest) error {
+	switch param.RequestType {
+	case "", constant.PRIVACY_REQUEST_EXPORT, constant.PRIVACY_REQUEST_ERASE:
+		return nil
+	default:
+		return xerrors.ErrPrivacyRequestTypeInvalid
+	}
+}
@@ -0,0 +1,169 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestPrivacyExportValidator(t *testing.T) {
+	type args struct {
+		param dto.PrivacyExportRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_user_id",
+			args: args{
+				param: dto.PrivacyExportRequest{},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "invalid_lang",
+			args: args{
+				param: dto.PrivacyExportRequest{UserId: 2, Lang: "fr"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrExportLangInvalid,
+		},
+		{
+			name: "reason_too_long",
+			args: args{
+				param: dto.PrivacyExportRequest{UserId: 2, Reason: strings.Repeat("因", 501)},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPrivacyReasonTooLong,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.PrivacyExportRequest{UserId: 2, Lang: "zh", Reason: "Subject access request"},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := PrivacyExportValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("PrivacyExportValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("PrivacyExportValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPrivacyEraseValidator(t *testing.T) {
+	type args struct {
+		param      dto.PrivacyEraseRequest
+		authUserId int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_user_id",
+			args: args{
+				param:      dto.PrivacyEraseRequest{},
+				authUserId: 1,
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "super_admin",
+			args: args{
+				param:      dto.PrivacyEraseRequest{UserId: 1},
+				authUserId: 1,
+			},
+			wantErr: true,
+			err:     xerrors.ErrPrivacySuperAdminErase,
+		},
+		{
+			name: "current_user",
+			args: args{
+				param:      dto.PrivacyEraseRequest{UserId: 2},
+				authUserId: 2,
+			},
+			wantErr: true,
+			err:     xerrors.ErrPrivacyEraseActiveUser,
+		},
+		{
+			name: "reason_too_long",
+			args: args{
+				param:      dto.PrivacyEraseRequest{UserId: 2, Reason: strings.Repeat("因", 501)},
+				authUserId: 1,
+			},
+			wantErr: true,
+			err:     xerrors.ErrPrivacyReasonTooLong,
+		},
+		{
+			name: "success",
+			args: args{
+				param:      dto.PrivacyEraseRequest{UserId: 2, Reason: "Right to erasure"},
+				authUserId: 1,
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := PrivacyEraseValidator(tt.args.param, tt.args.authUserId); (err != nil) != tt.wantErr {
+				t.Errorf("PrivacyEraseValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("PrivacyEraseValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestPrivacyRequestListValidator(t *testing.T) {
+	type args struct {
+		param dto.PrivacyRequestListRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_request_type",
+			args: args{
+				param: dto.PrivacyRequestListRequest{RequestType: "delete"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrPrivacyRequestTypeInvalid,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.PrivacyRequestListRequest{RequestType: "erase"},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := PrivacyRequestListValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("PrivacyRequestListValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("PrivacyRequestListValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package validator
+
+import (
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// RecycleListValidator validates the request to list recycle bin entries.
+func RecycleListValidator(param dto.RecycleListRequest) error {
+	switch param.EntityType {
+	case "",
+		constant.RECYCLE_ENTITY_USER,
+		constant.RECYCLE_ENTITY_ROLE,
+		constant.RECYCLE_ENTITY_DEPT,
+		constant.RECYCLE_ENTITY_POST,
+		constant.RECYCLE_ENTITY_MENU,
+		constant.RECYCLE_ENTITY_DICT_TYPE,
+		constant.RECYCLE_ENTITY_DICT_DATA,
+		constant.RECYCLE_ENTITY_USER_GROUP:
+		return nil
+	default:
+		return xerrors.ErrRecycleTypeInvalid
+	}
+}
+
+// RecycleIdsValidator validates the recycle bin entries to restore or purge.
+func RecycleIdsValidator(recycleIds []int) error {
+	switch {
+	case len(recycleIds) == 0:
+		return xerrors.ErrParam
+	default:
+		return nil
+	}
+}
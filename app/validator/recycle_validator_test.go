@@ -0,0 +1,93 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+func TestRecycleListValidator(t *testing.T) {
+	type args struct {
+		param dto.RecycleListRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_type",
+			args: args{
+				param: dto.RecycleListRequest{EntityType: "config"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrRecycleTypeInvalid,
+		},
+		{
+			name: "all_types",
+			args: args{
+				param: dto.RecycleListRequest{},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.RecycleListRequest{EntityType: constant.RECYCLE_ENTITY_DICT_TYPE},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RecycleListValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("RecycleListValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("RecycleListValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRecycleIdsValidator(t *testing.T) {
+	type args struct {
+		recycleIds []int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_ids",
+			args: args{
+				recycleIds: []int{},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "success",
+			args: args{
+				recycleIds: []int{1, 2},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := RecycleIdsValidator(tt.args.recycleIds); (err != nil) != tt.wantErr {
+				t.Errorf("RecycleIdsValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("RecycleIdsValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
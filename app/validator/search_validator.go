@@ -0,0 +1,39 @@
+package validator
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/xerrors"
+)
+
+// SearchValidator validates the keyword, types and limit of a unified search.
+func SearchValidator(param dto.SearchRequest) error {
+	switch {
+	case strings.TrimSpace(param.Keyword) == "":
+		return xerrors.ErrSearchKeywordEmpty
+	case utf8.RuneCountInString(param.Keyword) > 100:
+		return xerrors.ErrSearchKeywordTooLong
+	case param.Limit < 0 || param.Limit > 100:
+		return xerrors.ErrSearchLimitInvalid
+	}
+
+	if param.Types != "" {
+		for _, typ := range strings.Split(param.Types, ",") {
+			switch strings.TrimSpace(typ) {
+			case constant.SEARCH_TYPE_USER,
+				constant.SEARCH_TYPE_DEPT,
+				constant.SEARCH_TYPE_ROLE,
+				constant.SEARCH_TYPE_POST,
+				constant.SEARCH_TYPE_MENU,
+				constant.SEARCH_TYPE_CONFIG:
+			default:
+				return xerrors.ErrSearchTypeInvalid
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestSearchValidator(t *testing.T) {
+	type args struct {
+		param dto.SearchRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_keyword",
+			args: args{
+				param: dto.SearchRequest{Keyword: "  "},
+			},
+			wantErr: true,
+			err:     xerrors.ErrSearchKeywordEmpty,
+		},
+		{
+			name: "keyword_too_long",
+			args: args{
+				param: dto.SearchRequest{Keyword: strings.Repeat("搜", 101)},
+			},
+			wantErr: true,
+			err:     xerrors.ErrSearchKeywordTooLong,
+		},
+		{
+			name: "invalid_limit",
+			args: args{
+				param: dto.SearchRequest{Keyword: "admin", Limit: 101},
+			},
+			wantErr: true,
+			err:     xerrors.ErrSearchLimitInvalid,
+		},
+		{
+			name: "invalid_type",
+			args: args{
+				param: dto.SearchRequest{Keyword: "admin", Types: "user,dict"},
+			},
+			wantErr: true,
+			err:     xerrors.ErrSearchTypeInvalid,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.SearchRequest{Keyword: "admin", Types: "user, dept,role,post,menu,config", Limit: 100},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SearchValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("SearchValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("SearchValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
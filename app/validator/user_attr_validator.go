@@ -0,0 +1,52 @@
+package validator
+
+import (
+	"regexp"
+
+	"mira/app/dto"
+	"mira/common/types/constant"
+	"mira/common/utils"
+	"mira/common/xerrors"
+
+	attrregexp "mira/common/types/regexp"
+)
+
+// CreateUserAttrValidator validates the request to create a custom user attribute.
+func CreateUserAttrValidator(param dto.CreateUserAttrRequest) error {
+	return checkUserAttr(param.AttrKey, param.AttrLabel, param.AttrType, param.Pattern, param.DictType)
+}
+
+// UpdateUserAttrValidator validates the request to update a custom user attribute.
+func UpdateUserAttrValidator(param dto.UpdateUserAttrRequest) error {
+	if param.AttrId <= 0 {
+		return xerrors.ErrParam
+	}
+
+	return checkUserAttr(param.AttrKey, param.AttrLabel, param.AttrType, param.Pattern, param.DictType)
+}
+
+// checkUserAttr validates the fields shared by the create and update requests.
+func checkUserAttr(attrKey, attrLabel, attrType, pattern, dictType string) error {
+	switch {
+	case attrKey == "":
+		return xerrors.ErrUserAttrKeyEmpty
+	case !utils.CheckRegex(attrregexp.ATTR_KEY, attrKey):
+		return xerrors.ErrUserAttrKeyFormat
+	case attrLabel == "":
+		return xerrors.ErrUserAttrLabelEmpty
+	case !utils.Contains([]string{constant.USER_ATTR_TYPE_TEXT, constant.USER_ATTR_TYPE_NUMBER, constant.USER_ATTR_TYPE_DATE, constant.USER_ATTR_TYPE_SELECT}, attrType):
+		return xerrors.ErrUserAttrTypeInvalid
+	case pattern != "" && !isValidPattern(pattern):
+		return xerrors.ErrUserAttrPatternInvalid
+	case attrType == constant.USER_ATTR_TYPE_SELECT && dictType == "":
+		return xerrors.ErrUserAttrDictTypeEmpty
+	default:
+		return nil
+	}
+}
+
+// isValidPattern reports whether the validation pattern of an attribute compiles.
+func isValidPattern(pattern string) bool {
+	_, err := regexp.Compile(pattern)
+	return err == nil
+}
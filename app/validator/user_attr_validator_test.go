@@ -0,0 +1,175 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestCreateUserAttrValidator(t *testing.T) {
+	type args struct {
+		param dto.CreateUserAttrRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_attr_key",
+			args: args{
+				param: dto.CreateUserAttrRequest{
+					AttrLabel: "Employee Number",
+					AttrType:  "text",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserAttrKeyEmpty,
+		},
+		{
+			name: "invalid_attr_key",
+			args: args{
+				param: dto.CreateUserAttrRequest{
+					AttrKey:   "1employee-no",
+					AttrLabel: "Employee Number",
+					AttrType:  "text",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserAttrKeyFormat,
+		},
+		{
+			name: "empty_attr_label",
+			args: args{
+				param: dto.CreateUserAttrRequest{
+					AttrKey:  "employee_no",
+					AttrType: "text",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserAttrLabelEmpty,
+		},
+		{
+			name: "invalid_attr_type",
+			args: args{
+				param: dto.CreateUserAttrRequest{
+					AttrKey:   "employee_no",
+					AttrLabel: "Employee Number",
+					AttrType:  "boolean",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserAttrTypeInvalid,
+		},
+		{
+			name: "invalid_pattern",
+			args: args{
+				param: dto.CreateUserAttrRequest{
+					AttrKey:   "employee_no",
+					AttrLabel: "Employee Number",
+					AttrType:  "text",
+					Pattern:   "^E[0-9+$",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserAttrPatternInvalid,
+		},
+		{
+			name: "select_without_dict_type",
+			args: args{
+				param: dto.CreateUserAttrRequest{
+					AttrKey:   "shirt_size",
+					AttrLabel: "Shirt Size",
+					AttrType:  "select",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserAttrDictTypeEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.CreateUserAttrRequest{
+					AttrKey:    "employee_no",
+					AttrLabel:  "Employee Number",
+					AttrType:   "text",
+					Pattern:    "^E[0-9]+$",
+					IsRequired: "Y",
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CreateUserAttrValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("CreateUserAttrValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("CreateUserAttrValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUpdateUserAttrValidator(t *testing.T) {
+	type args struct {
+		param dto.UpdateUserAttrRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_attr_id",
+			args: args{
+				param: dto.UpdateUserAttrRequest{
+					AttrKey:   "employee_no",
+					AttrLabel: "Employee Number",
+					AttrType:  "text",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_attr_label",
+			args: args{
+				param: dto.UpdateUserAttrRequest{
+					AttrId:   1,
+					AttrKey:  "employee_no",
+					AttrType: "text",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserAttrLabelEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.UpdateUserAttrRequest{
+					AttrId:    1,
+					AttrKey:   "shirt_size",
+					AttrLabel: "Shirt Size",
+					AttrType:  "select",
+					DictType:  "user_shirt_size",
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UpdateUserAttrValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateUserAttrValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("UpdateUserAttrValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+// CreateUserGroupValidator validates the request to create a user group.
+func CreateUserGroupValidator(param dto.CreateUserGroupRequest) error {
+	switch {
+	case param.GroupName == "":
+		return xerrors.ErrUserGroupNameEmpty
+	default:
+		return nil
+	}
+}
+
+// UpdateUserGroupValidator validates the request to update a user group.
+func UpdateUserGroupValidator(param dto.UpdateUserGroupRequest) error {
+	switch {
+	case param.GroupId <= 0:
+		return xerrors.ErrParam
+	case param.GroupName == "":
+		return xerrors.ErrUserGroupNameEmpty
+	default:
+		return nil
+	}
+}
+
+// UserGroupMemberValidator validates the users added to or removed from a user group.
+func UserGroupMemberValidator(groupId int, userIds []int) error {
+	switch {
+	case groupId <= 0:
+		return xerrors.ErrParam
+	case len(userIds) == 0:
+		return xerrors.ErrUserGroupMembersEmpty
+	default:
+		return nil
+	}
+}
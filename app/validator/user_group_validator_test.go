@@ -0,0 +1,147 @@
+package validator
+
+import (
+	"testing"
+
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestCreateUserGroupValidator(t *testing.T) {
+	type args struct {
+		param dto.CreateUserGroupRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_group_name",
+			args: args{
+				param: dto.CreateUserGroupRequest{
+					GroupName: "",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserGroupNameEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.CreateUserGroupRequest{
+					GroupName: "Incident Response",
+					RoleIds:   []int{2},
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CreateUserGroupValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("CreateUserGroupValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("CreateUserGroupValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUpdateUserGroupValidator(t *testing.T) {
+	type args struct {
+		param dto.UpdateUserGroupRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_group_id",
+			args: args{
+				param: dto.UpdateUserGroupRequest{
+					GroupId:   0,
+					GroupName: "Incident Response",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_group_name",
+			args: args{
+				param: dto.UpdateUserGroupRequest{
+					GroupId:   1,
+					GroupName: "",
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserGroupNameEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.UpdateUserGroupRequest{
+					GroupId:   1,
+					GroupName: "Incident Response",
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UpdateUserGroupValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateUserGroupValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("UpdateUserGroupValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUserGroupMemberValidator(t *testing.T) {
+	type args struct {
+		groupId int
+		userIds []int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name:    "invalid_group_id",
+			args:    args{groupId: 0, userIds: []int{2}},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name:    "empty_members",
+			args:    args{groupId: 1, userIds: []int{}},
+			wantErr: true,
+			err:     xerrors.ErrUserGroupMembersEmpty,
+		},
+		{
+			name:    "success",
+			args:    args{groupId: 1, userIds: []int{2, 3}},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UserGroupMemberValidator(tt.args.groupId, tt.args.userIds); (err != nil) != tt.wantErr {
+				t.Errorf("UserGroupMemberValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("UserGroupMemberValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+// UserLifecycleScheduleValidator validates the scheduled activation and deactivation of a user.
+func UserLifecycleScheduleValidator(param dto.UserLifecycleScheduleRequest) error {
+	switch {
+	case param.UserId <= 0:
+		return xerrors.ErrParam
+	case param.UserId == 1 && !param.DeactivateTime.IsZero():
+		return xerrors.ErrUserSuperAdminOffboard
+	case !param.ActivateTime.IsZero() && !param.DeactivateTime.IsZero() && !param.DeactivateTime.After(param.ActivateTime.Time):
+		return xerrors.ErrUserLifecycleScheduleOrder
+	default:
+		return nil
+	}
+}
+
+// UserOffboardValidator validates the request to offboard a user.
+func UserOffboardValidator(param dto.UserOffboardRequest, authUserId int) error {
+	switch {
+	case param.UserId <= 0:
+		return xerrors.ErrParam
+	case param.UserId == 1:
+		return xerrors.ErrUserSuperAdminOffboard
+	case param.UserId == authUserId:
+		return xerrors.ErrUserCurrentUserOffboard
+	case param.TransferTo < 0 || param.TransferTo == param.UserId:
+		return xerrors.ErrUserOffboardTransferInvalid
+	default:
+		return nil
+	}
+}
+
+// CreateOnboardTemplateValidator validates the request to create an onboarding template.
+func CreateOnboardTemplateValidator(param dto.CreateOnboardTemplateRequest) error {
+	switch {
+	case param.TemplateName == "":
+		return xerrors.ErrOnboardTemplateNameEmpty
+	case len(param.RoleIds) == 0 && len(param.PostIds) == 0:
+		return xerrors.ErrOnboardTemplateGrantsEmpty
+	default:
+		return nil
+	}
+}
+
+// UpdateOnboardTemplateValidator validates the request to update an onboarding template.
+func UpdateOnboardTemplateValidator(param dto.UpdateOnboardTemplateRequest) error {
+	switch {
+	case param.TemplateId <= 0:
+		return xerrors.ErrParam
+	case param.TemplateName == "":
+		return xerrors.ErrOnboardTemplateNameEmpty
+	case len(param.RoleIds) == 0 && len(param.PostIds) == 0:
+		return xerrors.ErrOnboardTemplateGrantsEmpty
+	default:
+		return nil
+	}
+}
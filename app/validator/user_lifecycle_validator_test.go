@@ -0,0 +1,268 @@
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"mira/anima/datetime"
+	"mira/app/dto"
+	"mira/common/xerrors"
+)
+
+func TestUserLifecycleScheduleValidator(t *testing.T) {
+	now := time.Now()
+
+	type args struct {
+		param dto.UserLifecycleScheduleRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_user_id",
+			args: args{
+				param: dto.UserLifecycleScheduleRequest{
+					ActivateTime: datetime.Datetime{Time: now},
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "deactivate_super_admin",
+			args: args{
+				param: dto.UserLifecycleScheduleRequest{
+					UserId:         1,
+					DeactivateTime: datetime.Datetime{Time: now},
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserSuperAdminOffboard,
+		},
+		{
+			name: "deactivation_before_activation",
+			args: args{
+				param: dto.UserLifecycleScheduleRequest{
+					UserId:         2,
+					ActivateTime:   datetime.Datetime{Time: now},
+					DeactivateTime: datetime.Datetime{Time: now.Add(-time.Hour)},
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserLifecycleScheduleOrder,
+		},
+		{
+			name: "clear_schedule",
+			args: args{
+				param: dto.UserLifecycleScheduleRequest{
+					UserId: 2,
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.UserLifecycleScheduleRequest{
+					UserId:         2,
+					ActivateTime:   datetime.Datetime{Time: now},
+					DeactivateTime: datetime.Datetime{Time: now.AddDate(0, 6, 0)},
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UserLifecycleScheduleValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("UserLifecycleScheduleValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("UserLifecycleScheduleValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUserOffboardValidator(t *testing.T) {
+	type args struct {
+		param      dto.UserOffboardRequest
+		authUserId int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_user_id",
+			args: args{
+				param:      dto.UserOffboardRequest{},
+				authUserId: 1,
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "offboard_super_admin",
+			args: args{
+				param:      dto.UserOffboardRequest{UserId: 1},
+				authUserId: 2,
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserSuperAdminOffboard,
+		},
+		{
+			name: "offboard_current_user",
+			args: args{
+				param:      dto.UserOffboardRequest{UserId: 2},
+				authUserId: 2,
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserCurrentUserOffboard,
+		},
+		{
+			name: "transfer_to_the_same_user",
+			args: args{
+				param:      dto.UserOffboardRequest{UserId: 3, TransferTo: 3},
+				authUserId: 1,
+			},
+			wantErr: true,
+			err:     xerrors.ErrUserOffboardTransferInvalid,
+		},
+		{
+			name: "success",
+			args: args{
+				param:      dto.UserOffboardRequest{UserId: 3, TransferTo: 2, ArchiveGrants: true},
+				authUserId: 1,
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UserOffboardValidator(tt.args.param, tt.args.authUserId); (err != nil) != tt.wantErr {
+				t.Errorf("UserOffboardValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("UserOffboardValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCreateOnboardTemplateValidator(t *testing.T) {
+	type args struct {
+		param dto.CreateOnboardTemplateRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "empty_template_name",
+			args: args{
+				param: dto.CreateOnboardTemplateRequest{
+					RoleIds: []int{2},
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrOnboardTemplateNameEmpty,
+		},
+		{
+			name: "no_grants",
+			args: args{
+				param: dto.CreateOnboardTemplateRequest{
+					TemplateName: "Engineering",
+					DeptId:       103,
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrOnboardTemplateGrantsEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.CreateOnboardTemplateRequest{
+					TemplateName: "Engineering",
+					DeptId:       103,
+					PostIds:      []int{4},
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CreateOnboardTemplateValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("CreateOnboardTemplateValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("CreateOnboardTemplateValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestUpdateOnboardTemplateValidator(t *testing.T) {
+	type args struct {
+		param dto.UpdateOnboardTemplateRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid_template_id",
+			args: args{
+				param: dto.UpdateOnboardTemplateRequest{
+					TemplateName: "Engineering",
+					RoleIds:      []int{2},
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrParam,
+		},
+		{
+			name: "empty_template_name",
+			args: args{
+				param: dto.UpdateOnboardTemplateRequest{
+					TemplateId: 1,
+					RoleIds:    []int{2},
+				},
+			},
+			wantErr: true,
+			err:     xerrors.ErrOnboardTemplateNameEmpty,
+		},
+		{
+			name: "success",
+			args: args{
+				param: dto.UpdateOnboardTemplateRequest{
+					TemplateId:   1,
+					TemplateName: "Engineering",
+					RoleIds:      []int{2},
+				},
+			},
+			wantErr: false,
+			err:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UpdateOnboardTemplateValidator(tt.args.param); (err != nil) != tt.wantErr {
+				t.Errorf("UpdateOnboardTemplateValidator() error = %v, wantErr %v", err, tt.wantErr)
+			} else if err != tt.err {
+				t.Errorf("UpdateOnboardTemplateValidator() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// JSON Schema validation
+//
+// A subset of JSON Schema draft 7 sufficient to describe configuration values:
+//
+//	{"type": "object", "required": ["host"], "additionalProperties": false,
+//	 "properties": {"host": {"type": "string", "minLength": 1}, "port": {"type": "integer", "minimum": 1, "maximum": 65535}}}
+//
+// Supported keywords:
+//   - any value: type (a name or a list of names), enum, const
+//   - strings: minLength, maxLength, pattern
+//   - numbers: minimum, maximum, exclusiveMinimum, exclusiveMaximum
+//   - arrays: items, minItems, maxItems, uniqueItems
+//   - objects: properties, required, additionalProperties (a boolean or a schema)
+//
+// Other keywords, such as title and description, are ignored.
+
+var ErrInvalidSchema = errors.New("invalid JSON schema")
+
+// Schema is a compiled JSON schema
+type Schema struct {
+	types            []string
+	enum             []interface{}
+	constant         *interface{}
+	minLength        *int
+	maxLength        *int
+	pattern          *regexp.Regexp
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	items            *Schema
+	minItems         *int
+	maxItems         *int
+	uniqueItems      bool
+	properties       map[string]*Schema
+	required         []string
+	// additional validates the properties not listed, which are rejected when it is nil and
+	// closed is set
+	additional *Schema
+	closed     bool
+}
+
+// ValidationError reports where a value breaks its schema
+type ValidationError struct {
+	// Path is the JSON pointer of the value, empty for the root
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Compile parses a JSON schema
+func Compile(source string) (*Schema, error) {
+	var raw interface{}
+	if err := json.Unmarshal([]byte(source), &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return compile(raw, "")
+}
+
+// MustCompile parses a JSON schema, panicking when it is invalid
+func MustCompile(source string) *Schema {
+	schema, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// ValidateJSON validates a JSON document against the schema
+func (s *Schema) ValidateJSON(document string) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return &ValidationError{Message: "invalid JSON: " + err.Error()}
+	}
+	return s.Validate(value)
+}
+
+// Validate validates a decoded JSON value against the schema
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate(value, "")
+}
+
+func (s *Schema) validate(value interface{}, path string) error {
+	fail := func(format string, args ...interface{}) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if len(s.types) > 0 && !s.hasType(value) {
+		return fail("expected %s, got %s", joinTypes(s.types), typeOf(value))
+	}
+	if s.constant != nil && !equal(value, *s.constant) {
+		return fail("must be %s", encode(*s.constant))
+	}
+	if s.enum != nil {
+		found := false
+		for _, option := range s.enum {
+			if equal(value, option) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("must be one of %s", encode(s.enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.minLength != nil && length < *s.minLength {
+			return fail("must have at least %d characters", *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			return fail("must have at most %d characters", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fail("must match %s", s.pattern.String())
+		}
+
+	case float64:
+		if s.minimum != nil && v < *s.minimum {
+			return fail("must be at least %v", *s.minimum)
+		}
+		if s.maximum != nil && v > *s.maximum {
+			return fail("must be at most %v", *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && v <= *s.exclusiveMinimum {
+			return fail("must be greater than %v", *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && v >= *s.exclusiveMaximum {
+			return fail("must be less than %v", *s.exclusiveMaximum)
+		}
+
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			return fail("must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			return fail("must have at most %d items", *s.maxItems)
+		}
+		if s.uniqueItems {
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if equal(v[i], v[j]) {
+						return fail("items %d and %d are equal", j, i)
+					}
+				}
+			}
+		}
+		if s.items != nil {
+			for i, item := range v {
+				if err := s.items.validate(item, path+"/"+strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				return fail("missing property %s", name)
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			property, ok := s.properties[name]
+			if !ok {
+				if s.additional == nil {
+					if s.closed {
+						return fail("unknown property %s", name)
+					}
+					continue
+				}
+				property = s.additional
+			}
+			if err := property.validate(v[name], path+"/"+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasType reports whether a value has one of the types of the schema
+func (s *Schema) hasType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, expected := range s.types {
+		if expected == actual || (expected == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// compile builds the schema of a decoded JSON object
+func compile(raw interface{}, path string) (*Schema, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s%s", ErrInvalidSchema, pathPrefix(path), fmt.Sprintf(format, args...))
+	}
+
+	if allow, ok := raw.(bool); ok {
+		// true accepts every value, false none
+		if allow {
+			return &Schema{}, nil
+		}
+		return &Schema{enum: []interface{}{}}, nil
+	}
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, invalid("a schema must be an object or a boolean")
+	}
+
+	s := &Schema{}
+	var err error
+
+	switch t := object["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return nil, invalid("type must list type names")
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, invalid("type must be a type name or a list of them")
+	}
+	for _, name := range s.types {
+		switch name {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return nil, invalid("unknown type %s", name)
+		}
+	}
+
+	if enum, ok := object["enum"]; ok {
+		if s.enum, ok = enum.([]interface{}); !ok {
+			return nil, invalid("enum must be an array")
+		}
+	}
+	if constant, ok := object["const"]; ok {
+		s.constant = &constant
+	}
+
+	if s.minLength, err = count(object, "minLength"); err != nil {
+		return nil, invalid("%v", err)
+	}
+	if s.maxLength, err = count(object, "maxLength"); err != nil {
+		return nil, invalid("%v", err)
+	}
+	if pattern, ok := object["pattern"]; ok {
+		text, isText := pattern.(string)
+		if !isText {
+			return nil, invalid("pattern must be a string")
+		}
+		if s.pattern, err = regexp.Compile(text); err != nil {
+			return nil, invalid("invalid pattern: %v", err)
+		}
+	}
+
+	for keyword, target := range map[string]**float64{
+		"minimum": &s.minimum, "maximum": &s.maximum, "exclusiveMinimum": &s.exclusiveMinimum, "exclusiveMaximum": &s.exclusiveMaximum,
+	} {
+		if limit, ok := object[keyword]; ok {
+			number, isNumber := limit.(float64)
+			if !isNumber {
+				return nil, invalid("%s must be a number", keyword)
+			}
+			*target = &number
+		}
+	}
+
+	if items, ok := object["items"]; ok {
+		if s.items, err = compile(items, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+	if s.minItems, err = count(object, "minItems"); err != nil {
+		return nil, invalid("%v", err)
+	}
+	if s.maxItems, err = count(object, "maxItems"); err != nil {
+		return nil, invalid("%v", err)
+	}
+	if unique, ok := object["uniqueItems"]; ok {
+		if s.uniqueItems, ok = unique.(bool); !ok {
+			return nil, invalid("uniqueItems must be a boolean")
+		}
+	}
+
+	if properties, ok := object["properties"]; ok {
+		members, isObject := properties.(map[string]interface{})
+		if !isObject {
+			return nil, invalid("properties must be an object")
+		}
+		s.properties = make(map[string]*Schema, len(members))
+		for name, member := range members {
+			if s.properties[name], err = compile(member, path+"/properties/"+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if required, ok := object["required"]; ok {
+		names, isArray := required.([]interface{})
+		if !isArray {
+			return nil, invalid("required must be an array")
+		}
+		for _, item := range names {
+			name, isText := item.(string)
+			if !isText {
+				return nil, invalid("required must list property names")
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	switch additional := object["additionalProperties"].(type) {
+	case nil:
+	case bool:
+		s.closed = !additional
+	default:
+		if s.additional, err = compile(additional, path+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// count reads a non-negative integer keyword
+func count(object map[string]interface{}, keyword string) (*int, error) {
+	raw, ok := object[keyword]
+	if !ok {
+		return nil, nil
+	}
+	number, isNumber := raw.(float64)
+	if !isNumber || number < 0 || number != math.Trunc(number) {
+		return nil, fmt.Errorf("%s must be a non-negative integer", keyword)
+	}
+	n := int(number)
+	return &n, nil
+}
+
+// typeOf returns the JSON type name of a decoded value
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// equal compares decoded JSON values
+func equal(a, b interface{}) bool {
+	return encode(a) == encode(b)
+}
+
+// encode returns the JSON text of a decoded value, with object keys sorted
+func encode(value interface{}) string {
+	text, _ := json.Marshal(value)
+	return string(text)
+}
+
+func joinTypes(types []string) string {
+	if len(types) == 1 {
+		return types[0]
+	}
+	return encode(types)
+}
+
+func pathPrefix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + ": "
+}
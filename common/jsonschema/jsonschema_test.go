@@ -0,0 +1,88 @@
+package jsonschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	t.Run("should reject malformed schemas", func(t *testing.T) {
+		for _, source := range []string{
+			`{`,
+			`"string"`,
+			`{"type": "text"}`,
+			`{"type": 1}`,
+			`{"enum": "a"}`,
+			`{"minLength": -1}`,
+			`{"maxItems": 1.5}`,
+			`{"pattern": "("}`,
+			`{"minimum": "1"}`,
+			`{"properties": {"a": 1}}`,
+			`{"required": [1]}`,
+			`{"items": {"type": "text"}}`,
+		} {
+			_, err := Compile(source)
+			assert.True(t, errors.Is(err, ErrInvalidSchema), source)
+		}
+	})
+
+	t.Run("should ignore annotations", func(t *testing.T) {
+		schema, err := Compile(`{"title": "Port", "description": "Listening port", "type": "integer"}`)
+		assert.NoError(t, err)
+		assert.NoError(t, schema.ValidateJSON(`8080`))
+	})
+}
+
+func TestSchema_ValidateJSON(t *testing.T) {
+	schema := MustCompile(`{
+		"type": "object",
+		"required": ["host"],
+		"additionalProperties": false,
+		"properties": {
+			"host": {"type": "string", "minLength": 1, "pattern": "^[a-z.]+$"},
+			"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+			"ratio": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+			"mode": {"enum": ["fast", "safe"]},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2, "uniqueItems": true},
+			"extra": {"type": ["string", "null"]}
+		}
+	}`)
+
+	t.Run("should accept valid documents", func(t *testing.T) {
+		assert.NoError(t, schema.ValidateJSON(`{"host": "example.com"}`))
+		assert.NoError(t, schema.ValidateJSON(`{"host": "a", "port": 443, "ratio": 0.5, "mode": "safe", "tags": ["x", "y"], "extra": null}`))
+	})
+
+	t.Run("should report where documents break the schema", func(t *testing.T) {
+		for document, message := range map[string]string{
+			`not json`:                               "invalid JSON: invalid character 'o' in literal null (expecting 'u')",
+			`[]`:                                     "expected object, got array",
+			`{}`:                                     "missing property host",
+			`{"host": ""}`:                           "/host: must have at least 1 characters",
+			`{"host": "A"}`:                          "/host: must match ^[a-z.]+$",
+			`{"host": "a", "port": 1.5}`:             "/port: expected integer, got number",
+			`{"host": "a", "port": 0}`:               "/port: must be at least 1",
+			`{"host": "a", "ratio": 1}`:              "/ratio: must be less than 1",
+			`{"host": "a", "mode": "slow"}`:          `/mode: must be one of ["fast","safe"]`,
+			`{"host": "a", "tags": ["x", 1]}`:        "/tags/1: expected string, got integer",
+			`{"host": "a", "tags": ["x", "x"]}`:      "/tags: items 0 and 1 are equal",
+			`{"host": "a", "tags": ["x", "y", "z"]}`: "/tags: must have at most 2 items",
+			`{"host": "a", "extra": 1}`:              `/extra: expected ["string","null"], got integer`,
+			`{"host": "a", "other": true}`:           "unknown property other",
+		} {
+			err := schema.ValidateJSON(document)
+			var validationErr *ValidationError
+			if assert.True(t, errors.As(err, &validationErr), document) {
+				assert.Equal(t, message, err.Error(), document)
+			}
+		}
+	})
+
+	t.Run("should validate additional properties against a schema", func(t *testing.T) {
+		schema := MustCompile(`{"type": "object", "additionalProperties": {"type": "integer"}}`)
+		assert.NoError(t, schema.ValidateJSON(`{"a": 1, "b": 2}`))
+		assert.EqualError(t, schema.ValidateJSON(`{"a": 1, "b": "2"}`), "/b: expected integer, got string")
+	})
+}
@@ -0,0 +1,58 @@
+package lru
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("should evict the least recently used entry", func(t *testing.T) {
+		c := New[string, int](2)
+		c.Set("a", 1, 0)
+		c.Set("b", 2, 0)
+		c.Get("a")
+		c.Set("c", 3, 0)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok)
+		value, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.Equal(t, 2, c.Len())
+		assert.Equal(t, uint64(1), c.Evictions())
+	})
+
+	t.Run("should expire entries", func(t *testing.T) {
+		now := time.Now()
+		c := New[string, int](2)
+		c.now = func() time.Time { return now }
+		c.Set("a", 1, time.Minute)
+		c.Set("b", 2, 0)
+
+		now = now.Add(time.Minute)
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+		_, ok = c.Get("b")
+		assert.True(t, ok)
+		assert.Equal(t, 1, c.Len())
+		assert.Equal(t, uint64(0), c.Evictions())
+	})
+
+	t.Run("should replace, delete and purge entries", func(t *testing.T) {
+		c := New[int, string](0)
+		c.Set(1, "a", 0)
+		c.Set(1, "b", 0)
+		value, _ := c.Get(1)
+		assert.Equal(t, "b", value)
+
+		c.Delete(1, 2)
+		assert.Equal(t, 0, c.Len())
+
+		c.Set(2, "c", 0)
+		c.Purge()
+		_, ok := c.Get(2)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,196 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A4 page size in points, landscape
+const (
+	A4LandscapeWidth  = 841.89
+	A4LandscapeHeight = 595.28
+)
+
+// Document is a minimal PDF writer for vector drawings with text. Coordinates are in points
+// with the origin at the top left of the page. Text containing only ASCII is set in Helvetica;
+// other text is set in the STSong-Light CJK font, which PDF readers provide without embedding.
+type Document struct {
+	width  float64
+	height float64
+	pages  []*Page
+}
+
+// Page is a page of a document
+type Page struct {
+	height  float64
+	content bytes.Buffer
+}
+
+// New creates a document whose pages have the given size
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// AddPage appends a new page to the document
+func (d *Document) AddPage() *Page {
+	page := &Page{height: d.height}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// PageCount returns the number of pages of the document
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+// SetLineWidth sets the width of the lines stroked afterwards
+func (p *Page) SetLineWidth(width float64) {
+	fmt.Fprintf(&p.content, "%s w\n", num(width))
+}
+
+// SetStrokeGray sets the gray level, from 0 (black) to 1 (white), of the lines stroked afterwards
+func (p *Page) SetStrokeGray(gray float64) {
+	fmt.Fprintf(&p.content, "%s G\n", num(gray))
+}
+
+// SetFillGray sets the gray level, from 0 (black) to 1 (white), of the shapes and text filled afterwards
+func (p *Page) SetFillGray(gray float64) {
+	fmt.Fprintf(&p.content, "%s g\n", num(gray))
+}
+
+// Line strokes a line between two points
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "%s %s m %s %s l S\n", num(x1), num(p.height-y1), num(x2), num(p.height-y2))
+}
+
+// Rect draws a rectangle, filled and stroked when fill is true, stroked only otherwise
+func (p *Page) Rect(x, y, width, height float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "B"
+	}
+	fmt.Fprintf(&p.content, "%s %s %s %s re %s\n", num(x), num(p.height-y-height), num(width), num(height), op)
+}
+
+// Text draws text with its baseline starting at a point
+func (p *Page) Text(x, y, size float64, text string) {
+	font, encoded := "F1", literalString(text)
+	if !isASCII(text) {
+		font, encoded = "F2", ucs2String(text)
+	}
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td %s Tj ET\n", font, num(size), num(x), num(p.height-y), encoded)
+}
+
+// Clip restricts the drawing of the operations until Restore to a rectangle
+func (p *Page) Clip(x, y, width, height float64) {
+	fmt.Fprintf(&p.content, "q %s %s %s %s re W n\n", num(x), num(p.height-y-height), num(width), num(height))
+}
+
+// Restore ends the drawing restricted by Clip
+func (p *Page) Restore() {
+	p.content.WriteString("Q\n")
+}
+
+// Translate moves the origin of the drawing operations until Restore
+func (p *Page) Translate(dx, dy float64) {
+	fmt.Fprintf(&p.content, "q 1 0 0 1 %s %s cm\n", num(dx), num(-dy))
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	// Objects 1-6 are the catalog, the page tree and the fonts; each page takes two more
+	objects := make([]string, 6, 6+2*len(d.pages))
+	kids := make([]string, 0, len(d.pages))
+	for i, page := range d.pages {
+		pageId, contentId := 7+2*i, 8+2*i
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageId))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				num(d.width), num(d.height), contentId),
+			fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()),
+		)
+	}
+
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+	objects[3] = "<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light-UniGB-UCS2-H /Encoding /UniGB-UCS2-H /DescendantFonts [5 0 R] >>"
+	objects[4] = "<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light" +
+		" /CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >>" +
+		" /FontDescriptor 6 0 R /DW 1000 /W [1 95 500] >>"
+	objects[5] = "<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880]" +
+		" /ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>"
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// num formats a number with at most two decimals
+func num(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
+
+// isASCII reports whether text contains only ASCII characters
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// literalString encodes ASCII text as a PDF literal string
+func literalString(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", `\r`, "\n", `\n`)
+	return "(" + replacer.Replace(text) + ")"
+}
+
+// ucs2String encodes text as a hexadecimal string of UCS-2 code units, characters outside
+// the basic multilingual plane become question marks
+func ucs2String(text string) string {
+	var sb strings.Builder
+	sb.WriteString("<")
+	for _, r := range text {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pageContents inflates the content streams of a PDF file
+func pageContents(t *testing.T, data []byte) []string {
+	contents := make([]string, 0)
+	for _, match := range regexp.MustCompile(`(?s)<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(data[match[1] : match[1]+length]))
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		contents = append(contents, string(content))
+	}
+	return contents
+}
+
+func TestDocument_WriteTo(t *testing.T) {
+	t.Run("should write the pages with their drawing operations", func(t *testing.T) {
+		doc := New(A4LandscapeWidth, A4LandscapeHeight)
+		page := doc.AddPage()
+		page.Rect(10, 20, 100, 50, true)
+		page.Line(0, 0, 10.256, 10)
+		page.Text(15, 40, 10, "R&D (Beijing)")
+		page.Text(15, 60, 10, "研发部")
+		doc.AddPage().Text(0, 10, 8, "Page 2")
+
+		var buf bytes.Buffer
+		_, err := doc.WriteTo(&buf)
+		require.NoError(t, err)
+		data := buf.Bytes()
+
+		assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+		assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+		assert.Contains(t, buf.String(), "/Count 2")
+		assert.Equal(t, 2, doc.PageCount())
+
+		contents := pageContents(t, data)
+		require.Len(t, contents, 2)
+		assert.Contains(t, contents[0], "10 525.28 100 50 re B")
+		assert.Contains(t, contents[0], "0 595.28 m 10.26 585.28 l S")
+		assert.Contains(t, contents[0], `/F1 10 Tf 15 555.28 Td (R&D \(Beijing\)) Tj`)
+		assert.Contains(t, contents[0], "/F2 10 Tf 15 535.28 Td <781453D190E8> Tj")
+		assert.Contains(t, contents[1], "(Page 2) Tj")
+	})
+
+	t.Run("should point the cross-reference table at the objects", func(t *testing.T) {
+		doc := New(100, 100)
+		doc.AddPage().Text(0, 10, 8, "x")
+
+		var buf bytes.Buffer
+		_, err := doc.WriteTo(&buf)
+		require.NoError(t, err)
+		data := buf.String()
+
+		startxref := data[strings.LastIndex(data, "startxref\n")+len("startxref\n"):]
+		xref, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(startxref), "%%EOF")))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(data[xref:], "xref\n0 9\n"))
+
+		entries := strings.Split(data[xref:], "\n")[3:11]
+		for i, entry := range entries {
+			offset, err := strconv.Atoi(entry[:10])
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(data[offset:], strconv.Itoa(i+1)+" 0 obj\n"), "object %d", i+1)
+		}
+	})
+
+	t.Run("should write an empty page for an empty document", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := New(100, 100).WriteTo(&buf)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "/Count 1")
+	})
+}
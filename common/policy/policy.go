@@ -0,0 +1,790 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Policy expression language
+//
+// A condition is a boolean expression evaluated against a set of named
+// attributes, usually "subject", "resource" and "env":
+//
+//	subject.deptId == resource.deptId && env.time >= "09:00" && env.time < "18:00"
+//	resource.userId != subject.userId
+//	"admin" in subject.roles || cidr(env.ip, "10.0.0.0/8")
+//
+// Supported syntax:
+//   - literals: "string", 'string', 123, 1.5, true, false, null, [a, b, c]
+//   - attribute paths: subject.userId, resource.dept.deptId
+//   - comparison: == != < <= > >=
+//   - membership: in, not in
+//   - logic: && || ! (and, or, not)
+//   - functions: see Functions
+
+var (
+	ErrEmptyExpression = errors.New("policy expression cannot be empty")
+	ErrNotBoolean      = errors.New("policy expression must evaluate to a boolean")
+)
+
+// Func is a function callable from an expression
+type Func func(args []interface{}) (interface{}, error)
+
+// Functions available to every expression
+var Functions = map[string]Func{
+	"contains":   fnContains,
+	"startsWith": fnStartsWith,
+	"endsWith":   fnEndsWith,
+	"matches":    fnMatches,
+	"cidr":       fnCidr,
+	"len":        fnLen,
+}
+
+// Step records the value of one comparison or function call during evaluation
+type Step struct {
+	Expr  string      `json:"expr"`
+	Value interface{} `json:"value"`
+}
+
+// Program is a compiled expression
+type Program struct {
+	source string
+	root   node
+}
+
+// Compile parses an expression into a reusable program
+func Compile(source string) (*Program, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, ErrEmptyExpression
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+
+	return &Program{source: source, root: root}, nil
+}
+
+// String returns the source expression
+func (p *Program) String() string {
+	return p.source
+}
+
+// Eval evaluates the program as a boolean condition
+func (p *Program) Eval(vars map[string]interface{}) (bool, error) {
+	result, _, err := p.eval(vars, false)
+	return result, err
+}
+
+// Explain evaluates the program and records every comparison and function call
+func (p *Program) Explain(vars map[string]interface{}) (bool, []Step, error) {
+	return p.eval(vars, true)
+}
+
+func (p *Program) eval(vars map[string]interface{}, trace bool) (bool, []Step, error) {
+	e := &evaluator{vars: vars, trace: trace}
+	value, err := e.eval(p.root)
+	if err != nil {
+		return false, e.steps, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, e.steps, ErrNotBoolean
+	}
+
+	return result, e.steps, nil
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lex(source string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), pos: start})
+		default:
+			start := i
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					tokens = append(tokens, token{kind: tokOp, text: two, pos: start})
+					i += 2
+					continue
+				}
+			}
+			switch r {
+			case '<', '>', '!', '(', ')', '[', ']', ',', '.':
+				tokens = append(tokens, token{kind: tokOp, text: string(r), pos: start})
+				i++
+			default:
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, start)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(runes)}), nil
+}
+
+// Parser
+
+type node interface {
+	String() string
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+type pathNode struct {
+	parts []string
+}
+
+type listNode struct {
+	items []node
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n *literalNode) String() string {
+	if s, ok := n.value.(string); ok {
+		return strconv.Quote(s)
+	}
+	if n.value == nil {
+		return "null"
+	}
+	return fmt.Sprint(n.value)
+}
+
+func (n *pathNode) String() string {
+	return strings.Join(n.parts, ".")
+}
+
+func (n *listNode) String() string {
+	items := make([]string, 0, len(n.items))
+	for _, item := range n.items {
+		items = append(items, item.String())
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (n *unaryNode) String() string {
+	return "!" + n.operand.String()
+}
+
+func (n *binaryNode) String() string {
+	return n.left.String() + " " + n.op + " " + n.right.String()
+}
+
+func (n *callNode) String() string {
+	args := make([]string, 0, len(n.args))
+	for _, arg := range n.args {
+		args = append(args, arg.String())
+	}
+	return n.name + "(" + strings.Join(args, ", ") + ")"
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(text string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == text
+}
+
+func (p *parser) isKeyword(text string) bool {
+	t := p.peek()
+	return t.kind == tokIdent && t.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.isOp(text) {
+		t := p.peek()
+		return fmt.Errorf("expected %q at position %d", text, t.pos)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") || p.isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") || p.isKeyword("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("!") || p.isKeyword("not") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "!", operand: operand}, nil
+	}
+	return p.parseCompare()
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	switch {
+	case t.kind == tokOp && (t.text == "==" || t.text == "!=" || t.text == "<" || t.text == "<=" || t.text == ">" || t.text == ">="):
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: t.text, left: left, right: right}, nil
+	case p.isKeyword("in"):
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "in", left: left, right: right}, nil
+	case p.isKeyword("not") && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == tokIdent && p.tokens[p.pos+1].text == "in":
+		p.next()
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "not in", left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokString:
+		return &literalNode{value: t.text}, nil
+	case tokNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return &literalNode{value: value}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{value: nil}, nil
+		}
+		if p.isOp("(") {
+			return p.parseCall(t)
+		}
+		parts := []string{t.text}
+		for p.isOp(".") {
+			p.next()
+			part := p.next()
+			if part.kind != tokIdent {
+				return nil, fmt.Errorf("expected attribute name at position %d", part.pos)
+			}
+			parts = append(parts, part.text)
+		}
+		return &pathNode{parts: parts}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		case "[":
+			list := &listNode{}
+			for !p.isOp("]") {
+				item, err := p.parsePrimary()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+				if !p.isOp(",") {
+					break
+				}
+				p.next()
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return list, nil
+		}
+	case tokEOF:
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	if _, ok := Functions[name.text]; !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+
+	p.next()
+	call := &callNode{name: name.text}
+	for !p.isOp(")") {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if !p.isOp(",") {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	return call, nil
+}
+
+// Evaluator
+
+type evaluator struct {
+	vars  map[string]interface{}
+	trace bool
+	steps []Step
+}
+
+func (e *evaluator) record(n node, value interface{}) {
+	if e.trace {
+		e.steps = append(e.steps, Step{Expr: n.String(), Value: value})
+	}
+}
+
+func (e *evaluator) eval(n node) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+	case *pathNode:
+		return lookup(e.vars, n.parts), nil
+	case *listNode:
+		items := make([]interface{}, 0, len(n.items))
+		for _, item := range n.items {
+			value, err := e.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case *unaryNode:
+		value, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of ! must be a boolean: %s", n.operand)
+		}
+		return !b, nil
+	case *binaryNode:
+		return e.evalBinary(n)
+	case *callNode:
+		args := make([]interface{}, 0, len(n.args))
+		for _, arg := range n.args {
+			value, err := e.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, value)
+		}
+		value, err := Functions[n.name](args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n.name, err)
+		}
+		e.record(n, value)
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unsupported expression %s", n)
+}
+
+func (e *evaluator) evalBinary(n *binaryNode) (interface{}, error) {
+	left, err := e.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of %s must be a boolean: %s", n.op, n.left)
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operand of %s must be a boolean: %s", n.op, n.right)
+		}
+		return rb, nil
+	}
+
+	right, err := e.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	var result bool
+	switch n.op {
+	case "==":
+		result = equal(left, right)
+	case "!=":
+		result = !equal(left, right)
+	case "in":
+		result = contains(right, left)
+	case "not in":
+		result = !contains(right, left)
+	default:
+		cmp, err := compare(left, right)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", n, err)
+		}
+		switch n.op {
+		case "<":
+			result = cmp < 0
+		case "<=":
+			result = cmp <= 0
+		case ">":
+			result = cmp > 0
+		case ">=":
+			result = cmp >= 0
+		}
+	}
+
+	e.record(n, result)
+	return result, nil
+}
+
+// lookup resolves an attribute path, returning nil for missing attributes
+func lookup(vars map[string]interface{}, parts []string) interface{} {
+	var current interface{} = vars
+	for _, part := range parts {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[part]
+	}
+	return normalize(current)
+}
+
+// normalize converts numeric types to float64 and typed slices to []interface{}
+func normalize(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Slice, reflect.Array:
+		if _, ok := value.([]interface{}); ok {
+			return value
+		}
+		items := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, normalize(v.Index(i).Interface()))
+		}
+		return items
+	}
+
+	return value
+}
+
+func equal(a, b interface{}) bool {
+	a, b = normalize(a), normalize(b)
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(a, b interface{}) (int, error) {
+	a, b = normalize(a), normalize(b)
+
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			switch {
+			case av < bv:
+				return -1, nil
+			case av > bv:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %T with %T", a, b)
+}
+
+func contains(container, item interface{}) bool {
+	switch c := normalize(container).(type) {
+	case []interface{}:
+		for _, value := range c {
+			if equal(value, item) {
+				return true
+			}
+		}
+	case string:
+		if s, ok := item.(string); ok {
+			return strings.Contains(c, s)
+		}
+	case map[string]interface{}:
+		if s, ok := item.(string); ok {
+			_, exists := c[s]
+			return exists
+		}
+	}
+	return false
+}
+
+// Functions
+
+func checkArgs(args []interface{}, count int) error {
+	if len(args) != count {
+		return fmt.Errorf("expected %d arguments, got %d", count, len(args))
+	}
+	return nil
+}
+
+func stringArgs(args []interface{}, count int) ([]string, error) {
+	if err := checkArgs(args, count); err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, count)
+	for _, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			if arg == nil {
+				s = ""
+			} else {
+				return nil, fmt.Errorf("expected string argument, got %T", arg)
+			}
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
+
+func fnContains(args []interface{}) (interface{}, error) {
+	if err := checkArgs(args, 2); err != nil {
+		return nil, err
+	}
+	return contains(args[0], args[1]), nil
+}
+
+func fnStartsWith(args []interface{}) (interface{}, error) {
+	values, err := stringArgs(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(values[0], values[1]), nil
+}
+
+func fnEndsWith(args []interface{}) (interface{}, error) {
+	values, err := stringArgs(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(values[0], values[1]), nil
+}
+
+func fnMatches(args []interface{}) (interface{}, error) {
+	values, err := stringArgs(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(values[1])
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(values[0]), nil
+}
+
+func fnCidr(args []interface{}) (interface{}, error) {
+	values, err := stringArgs(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	_, network, err := net.ParseCIDR(values[1])
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(values[0])
+	if ip == nil {
+		return false, nil
+	}
+	return network.Contains(ip), nil
+}
+
+func fnLen(args []interface{}) (interface{}, error) {
+	if err := checkArgs(args, 1); err != nil {
+		return nil, err
+	}
+	switch v := normalize(args[0]).(type) {
+	case nil:
+		return float64(0), nil
+	case string:
+		return float64(len([]rune(v))), nil
+	case []interface{}:
+		return float64(len(v)), nil
+	case map[string]interface{}:
+		return float64(len(v)), nil
+	}
+	return nil, fmt.Errorf("len of %T is not supported", args[0])
+}
@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testVars() map[string]interface{} {
+	return map[string]interface{}{
+		"subject": map[string]interface{}{
+			"userId": 2,
+			"deptId": 105,
+			"roles":  []string{"common", "editor"},
+		},
+		"resource": map[string]interface{}{
+			"userId": float64(3),
+			"deptId": float64(105),
+		},
+		"env": map[string]interface{}{
+			"ip":   "10.1.2.3",
+			"time": "10:30",
+		},
+	}
+}
+
+func TestCompile(t *testing.T) {
+	t.Run("should reject empty expression", func(t *testing.T) {
+		_, err := Compile("  ")
+		assert.Equal(t, ErrEmptyExpression, err)
+	})
+
+	t.Run("should reject malformed expressions", func(t *testing.T) {
+		for _, source := range []string{
+			"subject.userId ==",
+			"(true",
+			"\"unterminated",
+			"unknown(1)",
+			"true false",
+			"1 # 2",
+		} {
+			_, err := Compile(source)
+			assert.Error(t, err, source)
+		}
+	})
+}
+
+func TestProgram_Eval(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   bool
+	}{
+		{"same dept", "subject.deptId == resource.deptId", true},
+		{"not self", "resource.userId != subject.userId", true},
+		{"role membership", "\"editor\" in subject.roles", true},
+		{"role non membership", "\"admin\" not in subject.roles", true},
+		{"business hours", "env.time >= \"09:00\" && env.time < \"18:00\"", true},
+		{"keyword operators", "not (subject.userId == 1) and (false or true)", true},
+		{"list literal", "subject.deptId in [100, 105]", true},
+		{"cidr", "cidr(env.ip, \"10.0.0.0/8\")", true},
+		{"starts with", "startsWith(env.ip, \"192.\")", false},
+		{"len", "len(subject.roles) >= 2", true},
+		{"missing attribute is null", "resource.missing == null", true},
+		{"type mismatch is not equal", "subject.deptId == \"105\"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.source)
+			assert.NoError(t, err)
+			got, err := program.Eval(testVars())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("should reject non-boolean result", func(t *testing.T) {
+		program, err := Compile("subject.userId")
+		assert.NoError(t, err)
+		_, err = program.Eval(testVars())
+		assert.Equal(t, ErrNotBoolean, err)
+	})
+
+	t.Run("should reject ordering of mismatched types", func(t *testing.T) {
+		program, err := Compile("subject.userId < \"a\"")
+		assert.NoError(t, err)
+		_, err = program.Eval(testVars())
+		assert.Error(t, err)
+	})
+}
+
+func TestProgram_Explain(t *testing.T) {
+	program, err := Compile("subject.deptId == resource.deptId && resource.userId == subject.userId")
+	assert.NoError(t, err)
+
+	result, steps, err := program.Explain(testVars())
+	assert.NoError(t, err)
+	assert.False(t, result)
+	assert.Equal(t, []Step{
+		{Expr: "subject.deptId == resource.deptId", Value: true},
+		{Expr: "resource.userId == subject.userId", Value: false},
+	}, steps)
+}
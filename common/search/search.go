@@ -0,0 +1,328 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Matching
+//
+// A query is split into terms, each of which must match a field of a document. A term
+// matches a field exactly, as a prefix of the field or of one of its words, as a substring,
+// or fuzzily when it shares enough trigrams with a word of the field, so that misspellings
+// such as "admni" still find "admin". Letters are compared case-insensitively.
+
+// Scores of the kinds of match, the fuzzy score is scaled by the trigram similarity
+const (
+	ScoreExact      = 1.0
+	ScorePrefix     = 0.9
+	ScoreWordPrefix = 0.8
+	ScoreSubstring  = 0.7
+	ScoreFuzzy      = 0.6
+)
+
+// MinSimilarity is the trigram similarity below which a word does not match fuzzily
+const MinSimilarity = 0.3
+
+// Field is a searchable text of a document, its boost weighs the score of its matches
+type Field struct {
+	Name  string
+	Text  string
+	Boost float64
+}
+
+// Document is an indexed entity
+type Document struct {
+	Type   string
+	Id     int
+	Fields []Field
+}
+
+// Hit is a document matching a query
+type Hit struct {
+	Type  string
+	Id    int
+	Score float64
+	// Field is the name of the field matching the first term best
+	Field string
+}
+
+// Index is an in-memory trigram index of documents, safe for concurrent use
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[docKey]Document
+	postings map[string]map[docKey]struct{}
+}
+
+type docKey struct {
+	typ string
+	id  int
+}
+
+// NewIndex creates an empty index
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[docKey]Document),
+		postings: make(map[string]map[docKey]struct{}),
+	}
+}
+
+// Put adds a document to the index, replacing the document of the same type and id
+func (x *Index) Put(doc Document) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	key := docKey{doc.Type, doc.Id}
+	x.remove(key)
+
+	x.docs[key] = doc
+	for _, field := range doc.Fields {
+		for _, word := range words(normalize(field.Text)) {
+			for gram := range trigrams(word) {
+				if x.postings[gram] == nil {
+					x.postings[gram] = make(map[docKey]struct{})
+				}
+				x.postings[gram][key] = struct{}{}
+			}
+		}
+	}
+}
+
+// Delete removes a document from the index
+func (x *Index) Delete(typ string, id int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.remove(docKey{typ, id})
+}
+
+// Reset removes all documents of a type from the index
+func (x *Index) Reset(typ string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for key := range x.docs {
+		if key.typ == typ {
+			x.remove(key)
+		}
+	}
+}
+
+// Len returns the number of documents in the index
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	return len(x.docs)
+}
+
+// remove unlinks a document from the postings, the lock must be held
+func (x *Index) remove(key docKey) {
+	doc, ok := x.docs[key]
+	if !ok {
+		return
+	}
+
+	for _, field := range doc.Fields {
+		for _, word := range words(normalize(field.Text)) {
+			for gram := range trigrams(word) {
+				delete(x.postings[gram], key)
+				if len(x.postings[gram]) == 0 {
+					delete(x.postings, gram)
+				}
+			}
+		}
+	}
+	delete(x.docs, key)
+}
+
+// Search finds the documents of the given types, or of any type when none is given, matching
+// a query. Hits are sorted by descending score, then by type and id.
+func (x *Index) Search(query string, types ...string) []Hit {
+	terms := words(normalize(query))
+	if len(terms) == 0 {
+		return []Hit{}
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(types))
+	for _, typ := range types {
+		wanted[typ] = true
+	}
+
+	hits := make([]Hit, 0)
+	for key := range x.candidates(terms) {
+		if len(wanted) > 0 && !wanted[key.typ] {
+			continue
+		}
+		if score, field := Match(terms, x.docs[key].Fields); score > 0 {
+			hits = append(hits, Hit{Type: key.typ, Id: key.id, Score: score, Field: field})
+		}
+	}
+
+	SortHits(hits)
+	return hits
+}
+
+// candidates returns the documents sharing a trigram with every term, terms too short to have
+// a trigram of their own match the documents as substrings so every document is a candidate
+func (x *Index) candidates(terms []string) map[docKey]struct{} {
+	var result map[docKey]struct{}
+
+	for _, term := range terms {
+		termDocs := make(map[docKey]struct{})
+		if len([]rune(term)) < 3 {
+			for key := range x.docs {
+				termDocs[key] = struct{}{}
+			}
+		} else {
+			for gram := range trigrams(term) {
+				for key := range x.postings[gram] {
+					termDocs[key] = struct{}{}
+				}
+			}
+		}
+
+		if result == nil {
+			result = termDocs
+			continue
+		}
+		for key := range result {
+			if _, ok := termDocs[key]; !ok {
+				delete(result, key)
+			}
+		}
+	}
+
+	return result
+}
+
+// Match scores the fields of a document against the terms of a query, see Terms. The score is the
+// mean of the best boosted score of each term, zero when a term matches no field.
+func Match(terms []string, fields []Field) (float64, string) {
+	total, matched := 0.0, ""
+
+	for i, term := range terms {
+		best, bestField := 0.0, ""
+		for _, field := range fields {
+			boost := field.Boost
+			if boost == 0 {
+				boost = 1
+			}
+			if score := Score(term, field.Text) * boost; score > best {
+				best, bestField = score, field.Name
+			}
+		}
+		if best == 0 {
+			return 0, ""
+		}
+		if i == 0 {
+			matched = bestField
+		}
+		total += best
+	}
+
+	return total / float64(len(terms)), matched
+}
+
+// Terms splits a query into the terms matched by Match
+func Terms(query string) []string {
+	return words(normalize(query))
+}
+
+// Score scores how well a normalized term matches a text, zero when it does not match
+func Score(term, text string) float64 {
+	text = normalize(text)
+	if term == "" || text == "" {
+		return 0
+	}
+
+	switch {
+	case text == term:
+		return ScoreExact
+	case strings.HasPrefix(text, term):
+		return ScorePrefix
+	}
+
+	textWords := words(text)
+	for _, word := range textWords {
+		if strings.HasPrefix(word, term) {
+			return ScoreWordPrefix
+		}
+	}
+
+	if strings.Contains(text, term) {
+		return ScoreSubstring
+	}
+
+	// Fuzzy matching needs trigrams, shorter terms only match as substrings
+	if len([]rune(term)) < 3 {
+		return 0
+	}
+	best := 0.0
+	termGrams := trigrams(term)
+	for _, word := range textWords {
+		if similarity := Similarity(termGrams, trigrams(word)); similarity > best {
+			best = similarity
+		}
+	}
+	if best < MinSimilarity {
+		return 0
+	}
+	return ScoreFuzzy * best
+}
+
+// Similarity returns the share of trigrams two sets have in common, from 0 to 1
+func Similarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common := 0
+	for gram := range a {
+		if _, ok := b[gram]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// SortHits sorts hits by descending score, then by type and id
+func SortHits(hits []Hit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Type != hits[j].Type {
+			return hits[i].Type < hits[j].Type
+		}
+		return hits[i].Id < hits[j].Id
+	})
+}
+
+// normalize lowercases text and trims its surrounding spaces
+func normalize(text string) string {
+	return strings.ToLower(strings.TrimSpace(text))
+}
+
+// words splits text into runs of letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigrams returns the trigrams of a word padded with two spaces in front and one behind, so
+// that short words and word beginnings have trigrams of their own
+func trigrams(word string) map[string]struct{} {
+	runes := []rune("  " + word + " ")
+	grams := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = struct{}{}
+	}
+	return grams
+}
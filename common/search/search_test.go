@@ -0,0 +1,93 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		text string
+		want float64
+	}{
+		{"exact", "admin", "Admin", ScoreExact},
+		{"prefix", "adm", "administrator", ScorePrefix},
+		{"word prefix", "ops", "Dev Ops", ScoreWordPrefix},
+		{"substring", "min", "admin", ScoreSubstring},
+		{"chinese substring", "研发", "深圳研发部门", ScoreSubstring},
+		{"short term does not match fuzzily", "xy", "admin", 0},
+		{"unrelated", "sales", "admin", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Score(tt.term, tt.text))
+		})
+	}
+
+	t.Run("should match misspelled words fuzzily", func(t *testing.T) {
+		score := Score("admni", "admin")
+		assert.Greater(t, score, 0.0)
+		assert.Less(t, score, ScoreSubstring)
+	})
+}
+
+func TestIndex_Search(t *testing.T) {
+	x := NewIndex()
+	x.Put(Document{Type: "user", Id: 1, Fields: []Field{{Name: "userName", Text: "admin"}, {Name: "email", Text: "admin@example.com", Boost: 0.8}}})
+	x.Put(Document{Type: "user", Id: 2, Fields: []Field{{Name: "userName", Text: "alice"}, {Name: "email", Text: "alice@example.com", Boost: 0.8}}})
+	x.Put(Document{Type: "dept", Id: 100, Fields: []Field{{Name: "deptName", Text: "Administration"}}})
+	x.Put(Document{Type: "menu", Id: 1, Fields: []Field{{Name: "menuName", Text: "用户管理"}, {Name: "perms", Text: "system:user:list"}}})
+
+	t.Run("should rank exact matches first", func(t *testing.T) {
+		hits := x.Search("admin")
+		assert.Len(t, hits, 2)
+		assert.Equal(t, Hit{Type: "user", Id: 1, Score: ScoreExact, Field: "userName"}, hits[0])
+		assert.Equal(t, "dept", hits[1].Type)
+	})
+
+	t.Run("should filter by type", func(t *testing.T) {
+		hits := x.Search("admin", "dept")
+		assert.Len(t, hits, 1)
+		assert.Equal(t, 100, hits[0].Id)
+	})
+
+	t.Run("should require every term to match", func(t *testing.T) {
+		hits := x.Search("alice example")
+		assert.Len(t, hits, 1)
+		assert.Equal(t, 2, hits[0].Id)
+
+		assert.Empty(t, x.Search("alice nobody"))
+	})
+
+	t.Run("should match misspellings, short terms and perms", func(t *testing.T) {
+		hits := x.Search("alise")
+		assert.Len(t, hits, 1)
+		assert.Equal(t, 2, hits[0].Id)
+
+		hits = x.Search("用户")
+		assert.Len(t, hits, 1)
+		assert.Equal(t, "menu", hits[0].Type)
+
+		hits = x.Search("system:user")
+		assert.Len(t, hits, 1)
+		assert.Equal(t, "perms", hits[0].Field)
+	})
+
+	t.Run("should replace and delete documents", func(t *testing.T) {
+		x.Put(Document{Type: "user", Id: 2, Fields: []Field{{Name: "userName", Text: "bob"}}})
+		assert.Empty(t, x.Search("alice"))
+		assert.Len(t, x.Search("bob"), 1)
+
+		x.Delete("user", 2)
+		assert.Empty(t, x.Search("bob"))
+		assert.Equal(t, 3, x.Len())
+
+		x.Reset("user")
+		assert.Equal(t, 2, x.Len())
+		assert.Empty(t, x.Search("  "))
+	})
+}
@@ -0,0 +1,68 @@
+package singleflight
+
+import "sync"
+
+// Group coalesces concurrent calls with the same key into one
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+type call[V any] struct {
+	done  sync.WaitGroup
+	value V
+	err   error
+	// dups counts the callers waiting for the call
+	dups int
+}
+
+// Do runs fn unless a call with the key is in flight, in which case it waits for that call and
+// returns its result. shared reports whether the result went to more than one caller.
+func (g *Group[K, V]) Do(key K, fn func() (V, error)) (value V, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.done.Wait()
+		return c.value, c.err, true
+	}
+
+	c := &call[V]{}
+	c.done.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		shared = c.dups > 0
+		g.mu.Unlock()
+		c.done.Done()
+	}()
+
+	c.value, c.err = fn()
+	return c.value, c.err, false
+}
+
+// Forget makes later calls with the keys run again instead of waiting for the calls in flight
+func (g *Group[K, V]) Forget(keys ...K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, key := range keys {
+		delete(g.calls, key)
+	}
+}
+
+// ForgetAll makes later calls run again instead of waiting for any call in flight
+func (g *Group[K, V]) ForgetAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.calls = nil
+}
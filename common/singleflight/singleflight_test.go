@@ -0,0 +1,97 @@
+package singleflight
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_Do(t *testing.T) {
+	t.Run("should coalesce concurrent calls", func(t *testing.T) {
+		var g Group[string, int]
+		var runs, shared int32
+		release := make(chan struct{})
+		started := make(chan struct{})
+
+		var wg sync.WaitGroup
+		call := func() {
+			defer wg.Done()
+			value, err, isShared := g.Do("a", func() (int, error) {
+				atomic.AddInt32(&runs, 1)
+				close(started)
+				<-release
+				return 42, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, 42, value)
+			if isShared {
+				atomic.AddInt32(&shared, 1)
+			}
+		}
+
+		wg.Add(1)
+		go call()
+		<-started
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go call()
+		}
+		// The waiting calls join the call in flight before it returns
+		for {
+			g.mu.Lock()
+			dups := g.calls["a"].dups
+			g.mu.Unlock()
+			if dups == 4 {
+				break
+			}
+			runtime.Gosched()
+		}
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+		assert.Equal(t, int32(5), atomic.LoadInt32(&shared))
+	})
+
+	t.Run("should run again once a call returned", func(t *testing.T) {
+		var g Group[string, int]
+		failure := errors.New("failure")
+
+		_, err, _ := g.Do("a", func() (int, error) { return 0, failure })
+		assert.ErrorIs(t, err, failure)
+
+		value, err, shared := g.Do("a", func() (int, error) { return 1, nil })
+		assert.NoError(t, err)
+		assert.Equal(t, 1, value)
+		assert.False(t, shared)
+	})
+
+	t.Run("should not join forgotten calls", func(t *testing.T) {
+		var g Group[string, int]
+		release := make(chan struct{})
+		started := make(chan struct{})
+		done := make(chan int)
+
+		go func() {
+			value, _, _ := g.Do("a", func() (int, error) {
+				close(started)
+				<-release
+				return 1, nil
+			})
+			done <- value
+		}()
+		<-started
+
+		g.Forget("a")
+		value, _, shared := g.Do("a", func() (int, error) { return 2, nil })
+		assert.Equal(t, 2, value)
+		assert.False(t, shared)
+
+		close(release)
+		assert.Equal(t, 1, <-done)
+	})
+}
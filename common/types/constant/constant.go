@@ -58,3 +58,339 @@ const (
 	REQUEST_BUSINESS_TYPE_GENCOD // Generate code
 	REQUEST_BUSINESS_TYPE_CLEAN  // Clear data
 )
+
+// Policy effect (allow)
+const POLICY_EFFECT_ALLOW = "allow"
+
+// Policy effect (deny)
+const POLICY_EFFECT_DENY = "deny"
+
+// Approval status (pending)
+const APPROVAL_STATUS_PENDING = "0"
+
+// Approval status (approved and executed)
+const APPROVAL_STATUS_APPROVED = "1"
+
+// Approval status (rejected)
+const APPROVAL_STATUS_REJECTED = "2"
+
+// Approval status (expired)
+const APPROVAL_STATUS_EXPIRED = "3"
+
+// Approval status (approved but execution failed)
+const APPROVAL_STATUS_FAILED = "4"
+
+// Delegate type (user)
+const DELEGATE_TYPE_USER = "user"
+
+// Delegate type (role)
+const DELEGATE_TYPE_ROLE = "role"
+
+// Access report compare type (user)
+const ACCESS_COMPARE_USER = "user"
+
+// Access report compare type (role)
+const ACCESS_COMPARE_ROLE = "role"
+
+// Department hierarchy issue (the parent department does not exist)
+const DEPT_ISSUE_ORPHAN = "orphan"
+
+// Department hierarchy issue (the parent chain loops back to the department)
+const DEPT_ISSUE_CYCLE = "cycle"
+
+// Department hierarchy issue (the ancestors do not match the parent chain)
+const DEPT_ISSUE_ANCESTORS = "ancestors"
+
+// Recycle bin entity type (user)
+const RECYCLE_ENTITY_USER = "user"
+
+// Recycle bin entity type (role)
+const RECYCLE_ENTITY_ROLE = "role"
+
+// Recycle bin entity type (department)
+const RECYCLE_ENTITY_DEPT = "dept"
+
+// Recycle bin entity type (post)
+const RECYCLE_ENTITY_POST = "post"
+
+// Recycle bin entity type (menu)
+const RECYCLE_ENTITY_MENU = "menu"
+
+// Recycle bin entity type (dictionary type)
+const RECYCLE_ENTITY_DICT_TYPE = "dict_type"
+
+// Recycle bin entity type (dictionary data)
+const RECYCLE_ENTITY_DICT_DATA = "dict_data"
+
+// Recycle bin entity type (user group)
+const RECYCLE_ENTITY_USER_GROUP = "user_group"
+
+// Custom user attribute type (free text)
+const USER_ATTR_TYPE_TEXT = "text"
+
+// Custom user attribute type (number)
+const USER_ATTR_TYPE_NUMBER = "number"
+
+// Custom user attribute type (date)
+const USER_ATTR_TYPE_DATE = "date"
+
+// Layout of custom user attribute dates
+const USER_ATTR_DATE_LAYOUT = "2006-01-02"
+
+// Custom user attribute type (select, values from a dictionary)
+const USER_ATTR_TYPE_SELECT = "select"
+
+// Configuration change history entity (configuration parameter, versioned by parameter ID)
+const CONFIG_HISTORY_ENTITY_CONFIG = "config"
+
+// Configuration change history entity (dictionary data entry, versioned by dictionary code)
+const CONFIG_HISTORY_ENTITY_DICT_DATA = "dict_data"
+
+// Configuration change history action (created)
+const CONFIG_HISTORY_ACTION_CREATE = "create"
+
+// Configuration change history action (updated)
+const CONFIG_HISTORY_ACTION_UPDATE = "update"
+
+// Configuration change history action (deleted)
+const CONFIG_HISTORY_ACTION_DELETE = "delete"
+
+// Configuration change history action (rolled back to an earlier version)
+const CONFIG_HISTORY_ACTION_ROLLBACK = "rollback"
+
+// Configuration parameter value type (free text)
+const CONFIG_VALUE_TYPE_STRING = "string"
+
+// Configuration parameter value type (true or false)
+const CONFIG_VALUE_TYPE_BOOL = "bool"
+
+// Configuration parameter value type (integer)
+const CONFIG_VALUE_TYPE_INT = "int"
+
+// Configuration parameter value type (duration such as 30m or 1h30m)
+const CONFIG_VALUE_TYPE_DURATION = "duration"
+
+// Configuration parameter value type (one of a list of options)
+const CONFIG_VALUE_TYPE_ENUM = "enum"
+
+// Configuration parameter value type (JSON document, optionally checked by a JSON schema)
+const CONFIG_VALUE_TYPE_JSON = "json"
+
+// Operator name recorded on built-in configuration parameters created at startup
+const CONFIG_INIT_OPERATOR = "system"
+
+// Operator name recorded on scheduled user activations and deactivations
+const USER_LIFECYCLE_OPERATOR = "lifecycle"
+
+// Import job type (user)
+const IMPORT_JOB_TYPE_USER = "user"
+
+// Import job type (department)
+const IMPORT_JOB_TYPE_DEPT = "dept"
+
+// Import job type (post)
+const IMPORT_JOB_TYPE_POST = "post"
+
+// Import job type (role)
+const IMPORT_JOB_TYPE_ROLE = "role"
+
+// Import job type (dictionary type)
+const IMPORT_JOB_TYPE_DICT_TYPE = "dict_type"
+
+// Import job type (dictionary data)
+const IMPORT_JOB_TYPE_DICT_DATA = "dict_data"
+
+// Import job status (waiting for a worker)
+const IMPORT_JOB_STATUS_PENDING = "0"
+
+// Import job status (running)
+const IMPORT_JOB_STATUS_RUNNING = "1"
+
+// Import job status (finished, failed rows are in the error report)
+const IMPORT_JOB_STATUS_FINISHED = "2"
+
+// Import job status (aborted before all rows were processed)
+const IMPORT_JOB_STATUS_ABORTED = "3"
+
+// Export format (Excel workbook)
+const EXPORT_FORMAT_XLSX = "xlsx"
+
+// Export format (comma-separated values)
+const EXPORT_FORMAT_CSV = "csv"
+
+// Export format (one JSON object per line)
+const EXPORT_FORMAT_NDJSON = "ndjson"
+
+// Export header language (English)
+const EXPORT_LANG_EN = "en"
+
+// Export header language (Chinese)
+const EXPORT_LANG_ZH = "zh"
+
+// Export job status (waiting for a worker)
+const EXPORT_JOB_STATUS_PENDING = "0"
+
+// Export job status (running)
+const EXPORT_JOB_STATUS_RUNNING = "1"
+
+// Export job status (finished, the file can be downloaded)
+const EXPORT_JOB_STATUS_FINISHED = "2"
+
+// Export job status (aborted, no file was produced)
+const EXPORT_JOB_STATUS_ABORTED = "3"
+
+// Export dataset (users)
+const EXPORT_DATASET_USER = "user"
+
+// Export dataset (roles)
+const EXPORT_DATASET_ROLE = "role"
+
+// Export dataset (posts)
+const EXPORT_DATASET_POST = "post"
+
+// Export dataset (parameter configuration)
+const EXPORT_DATASET_CONFIG = "config"
+
+// Export dataset (dictionary types)
+const EXPORT_DATASET_DICT_TYPE = "dict_type"
+
+// Export dataset (dictionary data)
+const EXPORT_DATASET_DICT_DATA = "dict_data"
+
+// Export dataset (operation logs)
+const EXPORT_DATASET_OPER_LOG = "oper_log"
+
+// Export dataset (login logs)
+const EXPORT_DATASET_LOGININFOR = "logininfor"
+
+// Export dataset (configuration parameter change history)
+const EXPORT_DATASET_CONFIG_HISTORY = "config_history"
+
+// Export dataset (dictionary data change history)
+const EXPORT_DATASET_DICT_DATA_HISTORY = "dict_data_history"
+
+// Org chart format (scalable vector graphics)
+const ORG_CHART_FORMAT_SVG = "svg"
+
+// Org chart format (paginated PDF)
+const ORG_CHART_FORMAT_PDF = "pdf"
+
+// Org chart format (JSON graph of nodes and edges)
+const ORG_CHART_FORMAT_JSON = "json"
+
+// Org chart field (department leader)
+const ORG_CHART_FIELD_LEADER = "leader"
+
+// Org chart field (head count of the department and its subtree)
+const ORG_CHART_FIELD_HEAD_COUNT = "headCount"
+
+// Org chart field (posts held in the department)
+const ORG_CHART_FIELD_POSTS = "posts"
+
+// Org chart field (users of the department)
+const ORG_CHART_FIELD_USERS = "users"
+
+// Privacy request type (personal data export)
+const PRIVACY_REQUEST_EXPORT = "export"
+
+// Privacy request type (personal data erasure)
+const PRIVACY_REQUEST_ERASE = "erase"
+
+// Privacy request status (running)
+const PRIVACY_REQUEST_STATUS_RUNNING = "0"
+
+// Privacy request status (finished)
+const PRIVACY_REQUEST_STATUS_FINISHED = "1"
+
+// Privacy request status (failed, erasures are rolled back)
+const PRIVACY_REQUEST_STATUS_FAILED = "2"
+
+// Privacy pseudonym prefix of erased user accounts
+const PRIVACY_ERASED_USER_PREFIX = "erased_"
+
+// Page count (exact count)
+const PAGE_COUNT_EXACT = "exact"
+
+// Page count (estimated by the query planner)
+const PAGE_COUNT_ESTIMATE = "estimate"
+
+// Page count (not counted)
+const PAGE_COUNT_NONE = "none"
+
+// Search backend (SQL LIKE queries)
+const SEARCH_BACKEND_LIKE = "like"
+
+// Search backend (MySQL FULLTEXT indexes with the ngram parser)
+const SEARCH_BACKEND_FULLTEXT = "fulltext"
+
+// Search backend (embedded in-memory index)
+const SEARCH_BACKEND_INDEX = "index"
+
+// Search type (user)
+const SEARCH_TYPE_USER = "user"
+
+// Search type (department)
+const SEARCH_TYPE_DEPT = "dept"
+
+// Search type (role)
+const SEARCH_TYPE_ROLE = "role"
+
+// Search type (post)
+const SEARCH_TYPE_POST = "post"
+
+// Search type (menu)
+const SEARCH_TYPE_MENU = "menu"
+
+// Search type (config)
+const SEARCH_TYPE_CONFIG = "config"
+
+// SCIM base path, resource locations are built under it
+const SCIM_BASE_PATH = "/scim/v2"
+
+// SCIM response content type
+const SCIM_CONTENT_TYPE = "application/scim+json"
+
+// SCIM operator name recorded on provisioned users and roles
+const SCIM_OPERATOR = "scim"
+
+// SCIM schema (user)
+const SCIM_SCHEMA_USER = "urn:ietf:params:scim:schemas:core:2.0:User"
+
+// SCIM schema (group)
+const SCIM_SCHEMA_GROUP = "urn:ietf:params:scim:schemas:core:2.0:Group"
+
+// SCIM schema (service provider configuration)
+const SCIM_SCHEMA_SERVICE_PROVIDER_CONFIG = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+// SCIM schema (schema definition)
+const SCIM_SCHEMA_SCHEMA = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+// SCIM message (list response)
+const SCIM_MESSAGE_LIST_RESPONSE = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+
+// SCIM message (patch operation)
+const SCIM_MESSAGE_PATCH_OP = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+
+// SCIM message (error)
+const SCIM_MESSAGE_ERROR = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+// Cache event type (configuration parameters, by key)
+const CACHE_EVENT_CONFIG = "config"
+
+// Cache event type (dictionary data, by dictionary type)
+const CACHE_EVENT_DICT = "dict"
+
+// Cache event type (users, by ID)
+const CACHE_EVENT_USER = "user"
+
+// Cache event type (roles, by ID)
+const CACHE_EVENT_ROLE = "role"
+
+// Cache event type (menus, by ID)
+const CACHE_EVENT_MENU = "menu"
+
+// Cache event type (departments, by ID)
+const CACHE_EVENT_DEPT = "dept"
+
+// Cache event type (posts, by ID)
+const CACHE_EVENT_POST = "post"
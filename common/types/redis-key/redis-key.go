@@ -35,6 +35,16 @@ func SysDictKey() string {
 	return config.Data.Ruoyi.Name + ":system:dict:data"
 }
 
+// SysUserPermsKey returns the redis key for the permissions of the users.
+func SysUserPermsKey() string {
+	return config.Data.Ruoyi.Name + ":system:user:perms"
+}
+
+// SysUserDataScopeKey returns the redis key for the data scope roles of the users.
+func SysUserDataScopeKey() string {
+	return config.Data.Ruoyi.Name + ":system:user:data_scope"
+}
+
 // User-specific cache keys for performance optimization
 func UserProfileKey(userID int) string {
 	return config.Data.Ruoyi.Name + ":user:profile:" + fmt.Sprintf("%d", userID)
@@ -103,6 +113,16 @@ func UserBtnPermsKey(userID int) string {
 	return config.Data.Ruoyi.Name + ":user:permissions:btn:" + fmt.Sprintf("%d", userID)
 }
 
+// CacheEventChannel returns the pub/sub channel the instances announce cache invalidations on.
+func CacheEventChannel() string {
+	return config.Data.Ruoyi.Name + ":cache:events"
+}
+
+// CacheTagKey returns the set of the keys cached under a tag.
+func CacheTagKey(tag string) string {
+	return config.Data.Ruoyi.Name + ":cache:tag:" + tag
+}
+
 // Cache key patterns for invalidation
 func UserPattern() string {
 	return config.Data.Ruoyi.Name + ":user:*"
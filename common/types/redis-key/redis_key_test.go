@@ -22,6 +22,10 @@ func TestRedisKeys(t *testing.T) {
 		{"RepeatSubmitKey", RepeatSubmitKey(), "test-project:repeat:submit:"},
 		{"SysConfigKey", SysConfigKey(), "test-project:system:config"},
 		{"SysDictKey", SysDictKey(), "test-project:system:dict:data"},
+		{"SysUserPermsKey", SysUserPermsKey(), "test-project:system:user:perms"},
+		{"SysUserDataScopeKey", SysUserDataScopeKey(), "test-project:system:user:data_scope"},
+		{"CacheEventChannel", CacheEventChannel(), "test-project:cache:events"},
+		{"CacheTagKey", CacheTagKey("user:42"), "test-project:cache:tag:user:42"},
 	}
 
 	for _, tt := range tests {
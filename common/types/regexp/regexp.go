@@ -4,4 +4,6 @@ const (
 	// Email regular expression
 	EMAIL = "^\\w+([-+.]\\w+)*@\\w+([-.]\\w+)*\\.\\w+([-.]\\w+)*$"
 	PHONE = "^(13[0-9]|14[01456879]|15[0-35-9]|16[2567]|17[0-8]|18[0-9]|19[0-35-9])\\d{8}$"
+	// Custom user attribute key
+	ATTR_KEY = "^[a-zA-Z][a-zA-Z0-9_]*$"
 )
@@ -19,6 +19,21 @@ var (
 	// Common
 	ErrParam = errors.New("parameter error")
 
+	// Approval
+	ErrApprovalNotFound      = errors.New("change request does not exist")
+	ErrApprovalNotPending    = errors.New("change request has already been processed")
+	ErrApprovalExpired       = errors.New("change request has expired")
+	ErrApprovalSelfReview    = errors.New("change request cannot be reviewed by its requester")
+	ErrApprovalReasonEmpty   = errors.New("please enter the reason for rejection")
+	ErrApprovalRequesterGone = errors.New("requester of the change request is no longer active")
+
+	// Access Report
+	ErrAccessPermEmpty       = errors.New("please enter the permission identifier")
+	ErrAccessCompareType     = errors.New("compare type must be user or role")
+	ErrAccessCompareIdsEmpty = errors.New("please select the two items to compare")
+	ErrAccessUserNotFound    = errors.New("user does not exist")
+	ErrAccessRoleNotFound    = errors.New("role does not exist")
+
 	// Auth
 	ErrUsernameEmpty     = errors.New("username cannot be empty")
 	ErrPasswordEmpty     = errors.New("password cannot be empty")
@@ -27,14 +42,42 @@ var (
 	ErrPasswordLength    = errors.New("password length must be between 5 and 20 characters")
 
 	// Config
-	ErrConfigNameEmpty  = errors.New("please enter the parameter name")
-	ErrConfigKeyEmpty   = errors.New("please enter the parameter key")
-	ErrConfigValueEmpty = errors.New("please enter the parameter value")
+	ErrConfigNameEmpty    = errors.New("please enter the parameter name")
+	ErrConfigKeyEmpty     = errors.New("please enter the parameter key")
+	ErrConfigValueEmpty   = errors.New("please enter the parameter value")
+	ErrConfigValueInvalid = errors.New("invalid parameter value")
+
+	// Config History
+	ErrConfigHistoryNotFound     = errors.New("the change history version does not exist")
+	ErrConfigHistoryEntity       = errors.New("the change history type must be config or dict_data")
+	ErrConfigHistoryDiffEntity   = errors.New("only versions of the same item can be compared")
+	ErrConfigHistoryDeleted      = errors.New("cannot roll back to a deletion")
+	ErrConfigHistoryItemNotFound = errors.New("the item of the change history no longer exists")
+	ErrConfigHistoryUnchanged    = errors.New("the item already matches this version")
+	ErrConfigHistoryConflict     = errors.New("another item already uses the key or value of this version")
+	ErrConfigHistoryReasonEmpty  = errors.New("please enter the reason for the rollback")
+	ErrConfigHistoryReasonLong   = errors.New("the change reason cannot exceed 500 characters")
 
 	// Dept
-	ErrParentDeptEmpty = errors.New("please select the parent department")
-	ErrDeptNameEmpty   = errors.New("please enter the department name")
-	ErrDeptParentSelf  = errors.New("the parent department cannot be itself")
+	ErrParentDeptEmpty    = errors.New("please select the parent department")
+	ErrDeptNameEmpty      = errors.New("please enter the department name")
+	ErrDeptParentSelf     = errors.New("the parent department cannot be itself")
+	ErrDeptNotFound       = errors.New("the department does not exist")
+	ErrDeptMoveCycle      = errors.New("a department cannot be moved under its own sub-department")
+	ErrDeptParentDisabled = errors.New("the parent department is disabled, the department cannot be enabled")
+	ErrDeptMergeSelf      = errors.New("a department cannot be merged into itself")
+	ErrDeptMergeRoot      = errors.New("the top-level department cannot be merged")
+	ErrDeptMergeSubtree   = errors.New("a department cannot be merged into its own sub-department")
+
+	// Delegation
+	ErrDelegationDeptEmpty      = errors.New("please select the delegated department")
+	ErrDelegationTypeInvalid    = errors.New("delegate type must be user or role")
+	ErrDelegationDelegateEmpty  = errors.New("please select the delegate")
+	ErrDelegationDeptOutOfScope = errors.New("the department is outside your delegated scope")
+	ErrDelegationUserOutOfScope = errors.New("the user is outside your delegated scope")
+	ErrDelegationRoleNotAllowed = errors.New("the role cannot be assigned under your delegation")
+	ErrDelegationRoleEscalation = errors.New("cannot assign a role with permissions you do not hold")
+	ErrDelegationUserPrivileged = errors.New("the user holds roles outside your delegated scope")
 
 	// Dict
 	ErrDictNameEmpty  = errors.New("please enter the dictionary name")
@@ -42,15 +85,81 @@ var (
 	ErrDictLabelEmpty = errors.New("please enter the data label")
 	ErrDictValueEmpty = errors.New("please enter the data key value")
 
+	// Import
+	ErrImportJobNotFound   = errors.New("import job does not exist")
+	ErrImportDataEmpty     = errors.New("import data cannot be empty")
+	ErrImportUserExists    = errors.New("account already exists")
+	ErrImportUserDuplicate = errors.New("account appears more than once in the file")
+	ErrImportRoleNotFound  = errors.New("role does not exist")
+	ErrImportPostNotFound  = errors.New("post does not exist")
+	ErrImportRowDuplicate  = errors.New("the row appears more than once in the file")
+	ErrImportRowExists     = errors.New("the data already exists")
+	ErrImportDeptPathEmpty = errors.New("please enter the department path")
+	ErrImportDeptParent    = errors.New("parent department does not exist")
+	ErrImportDeptNameTaken = errors.New("department name already exists")
+	ErrImportPostNameTaken = errors.New("post name already exists")
+	ErrImportRoleNameTaken = errors.New("role name already exists")
+	ErrImportMenuNotFound  = errors.New("no menu has the permission")
+	ErrImportDictNotFound  = errors.New("dictionary type does not exist")
+	ErrImportAttrFormat    = errors.New("custom attributes must be key=value pairs separated by semicolons")
+
+	// Export
+	ErrExportJobNotFound     = errors.New("export job does not exist")
+	ErrExportJobNotFinished  = errors.New("the export job has not finished yet")
+	ErrExportFormatInvalid   = errors.New("unsupported export format")
+	ErrExportLangInvalid     = errors.New("unsupported export header language")
+	ErrExportColumnInvalid   = errors.New("unknown export column")
+	ErrExportColumnDuplicate = errors.New("export column selected more than once")
+	ErrOrgChartFormatInvalid = errors.New("org chart format must be svg, pdf or json")
+	ErrOrgChartFieldInvalid  = errors.New("unknown org chart field")
+
+	// Page
+	ErrPageLimitInvalid  = errors.New("page limit must be between 1 and 500")
+	ErrPageCountInvalid  = errors.New("page count must be exact, estimate or none")
+	ErrPageCursorInvalid = errors.New("invalid page cursor")
+	ErrPageSortInvalid   = errors.New("the list cannot be paged by this sort column")
+
+	// Search
+	ErrSearchKeywordEmpty   = errors.New("please enter the search keyword")
+	ErrSearchKeywordTooLong = errors.New("search keyword cannot exceed 100 characters")
+	ErrSearchTypeInvalid    = errors.New("search type must be user, dept, role, post, menu or config")
+	ErrSearchLimitInvalid   = errors.New("search limit must be between 1 and 100")
+
+	// SCIM
+	ErrScimNotFound      = errors.New("resource does not exist")
+	ErrScimInvalidFilter = errors.New("invalid filter")
+	ErrScimInvalidPath   = errors.New("invalid attribute path")
+	ErrScimInvalidValue  = errors.New("invalid attribute value")
+	ErrScimInvalidSyntax = errors.New("invalid request body")
+	ErrScimUniqueness    = errors.New("value is already in use")
+	ErrScimMutability    = errors.New("attribute cannot be modified")
+
 	// Menu
 	ErrMenuNameEmpty      = errors.New("please enter the menu name")
 	ErrMenuPathEmpty      = errors.New("please enter the route address")
 	ErrMenuPathHttpPrefix = errors.New("the address must start with http(s)://")
 	ErrMenuParentSelf     = errors.New("the parent menu cannot be itself")
 
+	// Policy
+	ErrPolicyNameEmpty        = errors.New("please enter the policy name")
+	ErrPolicyActionEmpty      = errors.New("please enter the policy action")
+	ErrPolicyConditionEmpty   = errors.New("please enter the policy condition")
+	ErrPolicyEffectInvalid    = errors.New("policy effect must be allow or deny")
+	ErrPolicyConditionInvalid = errors.New("invalid policy condition")
+	ErrPolicyDenied           = errors.New("access denied by policy")
+
 	// Post
-	ErrPostCodeEmpty = errors.New("please enter the post code")
-	ErrPostNameEmpty = errors.New("please enter the post name")
+	ErrPostCodeEmpty      = errors.New("please enter the post code")
+	ErrPostNameEmpty      = errors.New("please enter the post name")
+	ErrPostParentSelf     = errors.New("a post cannot report to itself")
+	ErrPostParentNotFound = errors.New("the superior post does not exist")
+	ErrPostParentCycle    = errors.New("a post cannot report to its own subordinate post")
+
+	// Recycle
+	ErrRecycleNotFound      = errors.New("the recycle bin entry does not exist")
+	ErrRecycleTypeInvalid   = errors.New("unsupported recycle bin entry type")
+	ErrRecycleConflict      = errors.New("the deleted item conflicts with existing data and cannot be restored")
+	ErrRecycleParentMissing = errors.New("the parent of the deleted item no longer exists, restore it first")
 
 	// Role
 	ErrRoleNameEmpty        = errors.New("please enter the role name")
@@ -71,6 +180,38 @@ var (
 	ErrUserCurrentUserDelete = errors.New("the current user cannot be deleted")
 	ErrUserStatusEmpty       = errors.New("please select a status")
 
+	// User group
+	ErrUserGroupNameEmpty    = errors.New("please enter the group name")
+	ErrUserGroupMembersEmpty = errors.New("please select the group members")
+
+	// User attribute
+	ErrUserAttrKeyEmpty       = errors.New("please enter the attribute key")
+	ErrUserAttrKeyFormat      = errors.New("the attribute key must start with a letter and contain only letters, digits and underscores")
+	ErrUserAttrLabelEmpty     = errors.New("please enter the attribute label")
+	ErrUserAttrTypeInvalid    = errors.New("attribute type must be text, number, date or select")
+	ErrUserAttrPatternInvalid = errors.New("invalid validation regular expression")
+	ErrUserAttrDictTypeEmpty  = errors.New("please select the dictionary type of the select attribute")
+	ErrUserAttrUnknown        = errors.New("unknown custom attribute")
+	ErrUserAttrRequired       = errors.New("please enter the required custom attribute")
+	ErrUserAttrValueInvalid   = errors.New("invalid custom attribute value")
+
+	// User lifecycle
+	ErrUserLifecycleScheduleOrder  = errors.New("the deactivation time must be after the activation time")
+	ErrUserSuperAdminOffboard      = errors.New("the super administrator cannot be offboarded")
+	ErrUserCurrentUserOffboard     = errors.New("the current user cannot be offboarded")
+	ErrUserOffboardTransferInvalid = errors.New("ownership can only be transferred to another normal user")
+	ErrOnboardTemplateNameEmpty    = errors.New("please enter the template name")
+	ErrOnboardTemplateGrantsEmpty  = errors.New("please select the roles or posts the template grants")
+
+	// Privacy
+	ErrPrivacyUserNotFound       = errors.New("user does not exist")
+	ErrPrivacyRequestNotFound    = errors.New("privacy request does not exist")
+	ErrPrivacySuperAdminErase    = errors.New("the data of the super administrator cannot be erased")
+	ErrPrivacyEraseActiveUser    = errors.New("please offboard or disable the user before erasing their data")
+	ErrPrivacyUserErased         = errors.New("the data of the user has already been erased")
+	ErrPrivacyReasonTooLong      = errors.New("the reason cannot exceed 500 characters")
+	ErrPrivacyRequestTypeInvalid = errors.New("privacy request type must be export or erase")
+
 	// General
 	ErrNotImplemented = errors.New("not implemented")
 	ErrInternal       = errors.New("internal server error")
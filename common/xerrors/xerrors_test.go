@@ -89,17 +89,20 @@ func TestConfigErrors(t *testing.T) {
 		assert.Equal(t, "please enter the parameter name", ErrConfigNameEmpty.Error())
 		assert.Equal(t, "please enter the parameter key", ErrConfigKeyEmpty.Error())
 		assert.Equal(t, "please enter the parameter value", ErrConfigValueEmpty.Error())
+		assert.Equal(t, "invalid parameter value", ErrConfigValueInvalid.Error())
 	})
 
 	t.Run("should be identifiable as specific config errors", func(t *testing.T) {
 		assert.True(t, errors.Is(ErrConfigNameEmpty, ErrConfigNameEmpty))
 		assert.True(t, errors.Is(ErrConfigKeyEmpty, ErrConfigKeyEmpty))
 		assert.True(t, errors.Is(ErrConfigValueEmpty, ErrConfigValueEmpty))
+		assert.True(t, errors.Is(ErrConfigValueInvalid, ErrConfigValueInvalid))
 	})
 
 	t.Run("should not be equal to other config errors", func(t *testing.T) {
 		assert.NotEqual(t, ErrConfigNameEmpty, ErrConfigKeyEmpty)
 		assert.NotEqual(t, ErrConfigValueEmpty, ErrConfigNameEmpty)
+		assert.NotEqual(t, ErrConfigValueInvalid, ErrConfigValueEmpty)
 	})
 }
 
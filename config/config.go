@@ -59,6 +59,8 @@ type Config struct {
 		Database int `yaml:"database"`
 		// Password
 		Password string `yaml:"password"`
+		// Cluster node addresses, connecting to a Redis Cluster instead of host and port when given
+		Cluster []string `yaml:"cluster"`
 	} `yaml:"redis"`
 
 	// Token configuration
@@ -80,6 +82,33 @@ type Config struct {
 			LockTime int `yaml:"lockTime"`
 		} `yaml:"password"`
 	} `yaml:"user"`
+
+	// SCIM provisioning configuration
+	Scim struct {
+		// Bearer token of the identity provider, SCIM is disabled when empty
+		Token string `yaml:"token"`
+	} `yaml:"scim"`
+
+	// Unified search configuration
+	Search struct {
+		// Backend, optional values: like (default), fulltext, index
+		Backend string `yaml:"backend"`
+	} `yaml:"search"`
+
+	// Encryption of personal data at rest
+	Encryption struct {
+		// ID of the key new values are encrypted with, encryption is disabled when empty
+		Primary string `yaml:"primary"`
+		// Key encryption keys; a rotated key is kept until the values it protects are rewrapped
+		Keys []struct {
+			// ID stored with the values, at most 16 characters
+			Id string `yaml:"id"`
+			// Base64-encoded AES key of 16, 24 or 32 bytes
+			Secret string `yaml:"secret"`
+		} `yaml:"keys"`
+		// Base64-encoded HMAC key of the blind indexes
+		IndexKey string `yaml:"indexKey"`
+	} `yaml:"encryption"`
 }
 
 var Data *Config
@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"log"
+	"mira/anima/cipher"
 	"mira/anima/dal"
 	"mira/app/router"
+	"mira/app/service"
 	"mira/config"
 	"net/http"
 	"os"
@@ -26,6 +28,11 @@ func main() {
 		panic(err)
 	}
 
+	// Load the keys personal data is encrypted with
+	if err := service.InitEncryption(); err != nil {
+		panic(err)
+	}
+
 	// dsn := "user:pass@tcp(127.0.0.1:3306)/dbname?charset=utf8mb4&parseTime=True&loc=Local"
 	dsn := config.Data.Mysql.Username + ":" + config.Data.Mysql.Password + "@tcp(" + config.Data.Mysql.Host + ":" + strconv.Itoa(config.Data.Mysql.Port) + ")/" + config.Data.Mysql.Database + "?charset=" + config.Data.Mysql.Charset + "&parseTime=True&loc=Local"
 
@@ -55,6 +62,7 @@ func main() {
 			Port:               config.Data.Redis.Port,
 			Database:           config.Data.Redis.Database,
 			Password:           config.Data.Redis.Password,
+			Cluster:            config.Data.Redis.Cluster,
 			PoolSize:           50,  // Optimized pool size
 			MinIdleConns:       10,  // Minimum idle connections
 			MaxRetries:         3,   // Retry attempts
@@ -67,6 +75,30 @@ func main() {
 		},
 	})
 
+	// Keep the blind indexes of encrypted columns up to date
+	if err := dal.Gorm.Use(cipher.Plugin{}); err != nil {
+		panic(err)
+	}
+
+	// "mira encrypt-pii" encrypts the personal data written in plaintext and rewraps the values of
+	// rotated keys, then exits
+	if len(os.Args) > 1 && os.Args[1] == "encrypt-pii" {
+		reports, err := service.NewEncryptionService().EncryptTables()
+		for _, report := range reports {
+			log.Printf("%s: %d rows, %d updated, %d encrypted, %d rewrapped, %d indexed",
+				report.Table, report.Rows, report.Updated, report.Encrypted, report.Rewrapped, report.Indexed)
+		}
+		if err != nil {
+			log.Fatalf("Failed to encrypt personal data: %v", err)
+		}
+		return
+	}
+
+	// Create the built-in parameters missing from the database
+	if err := (&service.ConfigService{}).InitConfigs(); err != nil {
+		panic(err)
+	}
+
 	// Set mode
 	gin.SetMode(config.Data.Server.Mode)
 
@@ -89,6 +121,20 @@ func main() {
 	// Register router
 	router.Register(server)
 
+	// Purge expired recycle bin entries every hour
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	service.NewRecycleService().StartPurgeScheduler(schedulerCtx, time.Hour)
+
+	// Apply scheduled user activations and deactivations every minute
+	service.NewUserLifecycleService().StartLifecycleScheduler(schedulerCtx, time.Minute)
+
+	// Expire overdue change requests every minute
+	service.NewApprovalService().StartExpireScheduler(schedulerCtx, time.Minute)
+
+	// Follow the cache invalidations of the other instances
+	service.StartCacheBus(schedulerCtx)
+
 	// Create optimized HTTP server with performance settings
 	srv := &http.Server{
 		Addr:           ":" + strconv.Itoa(config.Data.Server.Port),
@@ -47,21 +47,29 @@ CREATE TABLE `sys_user` (
 	`user_name` VARCHAR(30) NOT NULL COMMENT '用户账号' COLLATE 'utf8mb4_general_ci',
 	`nick_name` VARCHAR(30) NOT NULL COMMENT '用户昵称' COLLATE 'utf8mb4_general_ci',
 	`user_type` VARCHAR(2) NOT NULL DEFAULT '00' COMMENT '用户类型：00-系统用户' COLLATE 'utf8mb4_general_ci',
-	`email` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '用户邮箱' COLLATE 'utf8mb4_general_ci',
-	`phonenumber` VARCHAR(11) NOT NULL DEFAULT '' COMMENT '手机号码' COLLATE 'utf8mb4_general_ci',
+	`email` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '用户邮箱，启用加密后为密文' COLLATE 'utf8mb4_general_ci',
+	`email_hash` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '用户邮箱盲索引' COLLATE 'utf8mb4_general_ci',
+	`phonenumber` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '手机号码，启用加密后为密文' COLLATE 'utf8mb4_general_ci',
+	`phonenumber_hash` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '手机号码盲索引' COLLATE 'utf8mb4_general_ci',
 	`sex` CHAR(1) NOT NULL DEFAULT '0' COMMENT '用户性别：0-男；1-女；2-未知' COLLATE 'utf8mb4_general_ci',
 	`avatar` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '头像地址' COLLATE 'utf8mb4_general_ci',
 	`password` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '密码' COLLATE 'utf8mb4_general_ci',
-	`login_ip` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '最后登录ip' COLLATE 'utf8mb4_general_ci',
+	`login_ip` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '最后登录ip，启用加密后为密文' COLLATE 'utf8mb4_general_ci',
 	`login_date` DATETIME NULL DEFAULT NULL COMMENT '最后登录时间',
 	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态：0-正常；1-停用' COLLATE 'utf8mb4_general_ci',
+	`activate_time` DATETIME NULL DEFAULT NULL COMMENT '计划启用时间',
+	`deactivate_time` DATETIME NULL DEFAULT NULL COMMENT '计划停用时间',
 	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
 	`create_time` DATETIME NOT NULL COMMENT '创建时间',
 	`update_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '更新者' COLLATE 'utf8mb4_general_ci',
 	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
 	`delete_time` DATETIME NULL DEFAULT NULL COMMENT '删除时间',
 	`remark` VARCHAR(500) NULL DEFAULT NULL COMMENT '备注' COLLATE 'utf8mb4_general_ci',
-	PRIMARY KEY (`user_id`) USING BTREE
+	PRIMARY KEY (`user_id`) USING BTREE,
+	INDEX `idx_sys_user_at` (`activate_time`) USING BTREE,
+	INDEX `idx_sys_user_dt` (`deactivate_time`) USING BTREE,
+	INDEX `idx_sys_user_eh` (`email_hash`) USING BTREE,
+	INDEX `idx_sys_user_ph` (`phonenumber_hash`) USING BTREE
 )
 COMMENT='用户信息表'
 COLLATE='utf8mb4_general_ci'
@@ -70,8 +78,8 @@ ENGINE=InnoDB;
 -- ----------------------------
 -- 初始化-用户信息表数据
 -- ----------------------------
-insert into sys_user values(1,  103, 'admin', '若依', '00', 'ry@163.com', '15888888888', '1', '', '$2a$10$7JB720yubVSZvUI0rEqK/.VqGOZTH.ulu33dHOiBE8ByOhJIrdAu2', '127.0.0.1', sysdate(), '0', 'admin', sysdate(), '', null, null, '管理员');
-insert into sys_user values(2,  105, 'ry',    '若依', '00', 'ry@qq.com',  '15666666666', '1', '', '$2a$10$7JB720yubVSZvUI0rEqK/.VqGOZTH.ulu33dHOiBE8ByOhJIrdAu2', '127.0.0.1', sysdate(), '0', 'admin', sysdate(), '', null, null, '测试员');
+insert into sys_user values(1,  103, 'admin', '若依', '00', 'ry@163.com', '', '15888888888', '', '1', '', '$2a$10$7JB720yubVSZvUI0rEqK/.VqGOZTH.ulu33dHOiBE8ByOhJIrdAu2', '127.0.0.1', sysdate(), '0', null, null, 'admin', sysdate(), '', null, null, '管理员');
+insert into sys_user values(2,  105, 'ry',    '若依', '00', 'ry@qq.com',  '', '15666666666', '', '1', '', '$2a$10$7JB720yubVSZvUI0rEqK/.VqGOZTH.ulu33dHOiBE8ByOhJIrdAu2', '127.0.0.1', sysdate(), '0', null, null, 'admin', sysdate(), '', null, null, '测试员');
 
 -- ----------------------------
 -- 3、岗位信息表
@@ -79,6 +87,7 @@ insert into sys_user values(2,  105, 'ry',    '若依', '00', 'ry@qq.com',  '156
 DROP TABLE IF EXISTS `sys_post`;
 CREATE TABLE `sys_post` (
 	`post_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '岗位id',
+	`parent_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '上级岗位id',
 	`post_code` VARCHAR(64) NOT NULL COMMENT '岗位编码' COLLATE 'utf8mb4_general_ci',
 	`post_name` VARCHAR(50) NOT NULL COMMENT '岗位名称' COLLATE 'utf8mb4_general_ci',
 	`post_sort` INT(10) NOT NULL DEFAULT '0' COMMENT '显示顺序',
@@ -89,7 +98,8 @@ CREATE TABLE `sys_post` (
 	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
 	`delete_time` DATETIME NULL DEFAULT NULL COMMENT '删除时间',
 	`remark` VARCHAR(500) NULL DEFAULT NULL COMMENT '备注' COLLATE 'utf8mb4_general_ci',
-	PRIMARY KEY (`post_id`) USING BTREE
+	PRIMARY KEY (`post_id`) USING BTREE,
+	INDEX `idx_sys_post_p` (`parent_id`) USING BTREE
 )
 COMMENT='岗位信息表'
 COLLATE='utf8mb4_general_ci'
@@ -98,10 +108,10 @@ ENGINE=InnoDB;
 -- ----------------------------
 -- 初始化-岗位信息表数据
 -- ----------------------------
-insert into sys_post values(1, 'ceo',  '董事长',    1, '0', 'admin', sysdate(), '', null, null, '');
-insert into sys_post values(2, 'se',   '项目经理',  2, '0', 'admin', sysdate(), '', null, null, '');
-insert into sys_post values(3, 'hr',   '人力资源',  3, '0', 'admin', sysdate(), '', null, null, '');
-insert into sys_post values(4, 'user', '普通员工',  4, '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_post values(1, 0, 'ceo',  '董事长',    1, '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_post values(2, 1, 'se',   '项目经理',  2, '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_post values(3, 1, 'hr',   '人力资源',  3, '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_post values(4, 2, 'user', '普通员工',  4, '0', 'admin', sysdate(), '', null, null, '');
 
 -- ----------------------------
 -- 4、角色信息表
@@ -180,10 +190,19 @@ insert into sys_menu values('103',  '部门管理', '1',   '4', 'dept',       's
 insert into sys_menu values('104',  '岗位管理', '1',   '5', 'post',       'system/post/index',        '', '', 1, 0, 'C', '0', 'system:post:list',        'post', '0', 'admin', sysdate(), '', null, null, '岗位管理菜单');
 insert into sys_menu values('105',  '字典管理', '1',   '6', 'dict',       'system/dict/index',        '', '', 1, 0, 'C', '0', 'system:dict:list',        'dict', '0', 'admin', sysdate(), '', null, null, '字典管理菜单');
 insert into sys_menu values('106',  '参数设置', '1',   '7', 'config',     'system/config/index',      '', '', 1, 0, 'C', '0', 'system:config:list',      'edit', '0', 'admin', sysdate(), '', null, null, '参数设置菜单');
+insert into sys_menu values('107',  '访问策略', '1',   '8', 'policy',     'system/policy/index',      '', '', 1, 0, 'C', '0', 'system:policy:list',      'lock', '0', 'admin', sysdate(), '', null, null, '访问策略菜单');
 insert into sys_menu values('108',  '日志管理', '1',   '9', 'log',        '',                         '', '', 1, 0, 'M', '0', '',                        'log', '0', 'admin', sysdate(), '', null, null, '日志管理菜单');
+insert into sys_menu values('109',  '变更审批', '1',   '10', 'approval',  'system/approval/index',    '', '', 1, 0, 'C', '0', 'system:approval:list',    'checkbox', '0', 'admin', sysdate(), '', null, null, '变更审批菜单');
+insert into sys_menu values('110',  '部门委派', '1',   '11', 'delegation', 'system/delegation/index', '', '', 1, 0, 'C', '0', 'system:delegation:list',  'tree', '0', 'admin', sysdate(), '', null, null, '部门委派菜单');
+insert into sys_menu values('111',  '回收站',   '1',   '12', 'recycle',   'system/recycle/index',     '', '', 1, 0, 'C', '0', 'system:recycle:list',     'tool', '0', 'admin', sysdate(), '', null, null, '回收站菜单');
+insert into sys_menu values('112',  '用户组',   '1',   '13', 'group',     'system/group/index',       '', '', 1, 0, 'C', '0', 'system:group:list',       'peoples', '0', 'admin', sysdate(), '', null, null, '用户组菜单');
+insert into sys_menu values('113',  '用户属性', '1',   '14', 'userAttr',  'system/userAttr/index',    '', '', 1, 0, 'C', '0', 'system:userAttr:list',    'form', '0', 'admin', sysdate(), '', null, null, '用户属性菜单');
+insert into sys_menu values('114',  '入职模板', '1',   '15', 'onboardTemplate', 'system/onboardTemplate/index', '', '', 1, 0, 'C', '0', 'system:onboardTemplate:list', 'guide', '0', 'admin', sysdate(), '', null, null, '入职模板菜单');
 -- 三级菜单
 insert into sys_menu values('500',  '操作日志', '108', '1', 'operlog',    'monitor/operlog/index',    '', '', 1, 0, 'C', '0', 'monitor:operlog:list',    'form', '0', 'admin', sysdate(), '', null, null, '操作日志菜单');
 insert into sys_menu values('501',  '登录日志', '108', '2', 'logininfor', 'monitor/logininfor/index', '', '', 1, 0, 'C', '0', 'monitor:logininfor:list', 'logininfor', '0', 'admin', sysdate(), '', null, null, '登录日志菜单');
+insert into sys_menu values('502',  '权限报告', '108', '3', 'access',     'monitor/access/index',     '', '', 1, 0, 'C', '0', 'monitor:access:report',   'people', '0', 'admin', sysdate(), '', null, null, '权限报告菜单');
+insert into sys_menu values('503',  '缓存监控', '108', '4', 'cache',      'monitor/cache/index',      '', '', 1, 0, 'C', '0', 'monitor:cache:list',      'redis', '0', 'admin', sysdate(), '', null, null, '缓存监控菜单');
 -- 用户管理按钮
 insert into sys_menu values('1000', '用户查询', '100', '1',  '', '', '', '', 1, 0, 'F', '0', 'system:user:query',          '#', '0', 'admin', sysdate(), '', null, null, '');
 insert into sys_menu values('1001', '用户新增', '100', '2',  '', '', '', '', 1, 0, 'F', '0', 'system:user:add',            '#', '0', 'admin', sysdate(), '', null, null, '');
@@ -235,6 +254,54 @@ insert into sys_menu values('1042', '登录查询', '501', '1', '#', '', '', '',
 insert into sys_menu values('1043', '登录删除', '501', '2', '#', '', '', '', 1, 0, 'F', '0', 'monitor:logininfor:remove',  '#', '0', 'admin', sysdate(), '', null, null, '');
 insert into sys_menu values('1044', '日志导出', '501', '3', '#', '', '', '', 1, 0, 'F', '0', 'monitor:logininfor:export',  '#', '0', 'admin', sysdate(), '', null, null, '');
 insert into sys_menu values('1045', '账户解锁', '501', '4', '#', '', '', '', 1, 0, 'F', '0', 'monitor:logininfor:unlock',  '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 访问策略按钮
+insert into sys_menu values('1046', '策略查询', '107', '1', '#', '', '', '', 1, 0, 'F', '0', 'system:policy:query',        '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1047', '策略新增', '107', '2', '#', '', '', '', 1, 0, 'F', '0', 'system:policy:add',          '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1048', '策略修改', '107', '3', '#', '', '', '', 1, 0, 'F', '0', 'system:policy:edit',         '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1049', '策略删除', '107', '4', '#', '', '', '', 1, 0, 'F', '0', 'system:policy:remove',       '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1050', '策略测试', '107', '5', '#', '', '', '', 1, 0, 'F', '0', 'system:policy:test',         '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 变更审批按钮
+insert into sys_menu values('1051', '审批查询', '109', '1', '#', '', '', '', 1, 0, 'F', '0', 'system:approval:query',      '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1052', '审批处理', '109', '2', '#', '', '', '', 1, 0, 'F', '0', 'system:approval:approve',    '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 部门委派按钮
+insert into sys_menu values('1053', '委派查询', '110', '1', '#', '', '', '', 1, 0, 'F', '0', 'system:delegation:query',    '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1054', '委派新增', '110', '2', '#', '', '', '', 1, 0, 'F', '0', 'system:delegation:add',      '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1055', '委派修改', '110', '3', '#', '', '', '', 1, 0, 'F', '0', 'system:delegation:edit',     '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1056', '委派删除', '110', '4', '#', '', '', '', 1, 0, 'F', '0', 'system:delegation:remove',   '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 部门管理按钮
+insert into sys_menu values('1057', '层级修复', '103', '5', '#', '', '', '', 1, 0, 'F', '0', 'system:dept:repair',         '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1058', '部门合并', '103', '6', '#', '', '', '', 1, 0, 'F', '0', 'system:dept:merge',          '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 回收站按钮
+insert into sys_menu values('1059', '回收站恢复', '111', '1', '#', '', '', '', 1, 0, 'F', '0', 'system:recycle:restore',   '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1060', '回收站清除', '111', '2', '#', '', '', '', 1, 0, 'F', '0', 'system:recycle:remove',    '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 导入按钮
+insert into sys_menu values('1061', '角色导入', '101', '6', '#', '', '', '', 1, 0, 'F', '0', 'system:role:import',         '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1062', '部门导入', '103', '7', '#', '', '', '', 1, 0, 'F', '0', 'system:dept:import',         '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1063', '岗位导入', '104', '6', '#', '', '', '', 1, 0, 'F', '0', 'system:post:import',         '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1064', '字典导入', '105', '6', '#', '', '', '', 1, 0, 'F', '0', 'system:dict:import',         '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 用户组按钮
+insert into sys_menu values('1065', '用户组查询', '112', '1', '#', '', '', '', 1, 0, 'F', '0', 'system:group:query',       '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1066', '用户组新增', '112', '2', '#', '', '', '', 1, 0, 'F', '0', 'system:group:add',         '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1067', '用户组修改', '112', '3', '#', '', '', '', 1, 0, 'F', '0', 'system:group:edit',        '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1068', '用户组删除', '112', '4', '#', '', '', '', 1, 0, 'F', '0', 'system:group:remove',      '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 用户属性按钮
+insert into sys_menu values('1069', '用户属性查询', '113', '1', '#', '', '', '', 1, 0, 'F', '0', 'system:userAttr:query',  '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1070', '用户属性新增', '113', '2', '#', '', '', '', 1, 0, 'F', '0', 'system:userAttr:add',    '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1071', '用户属性修改', '113', '3', '#', '', '', '', 1, 0, 'F', '0', 'system:userAttr:edit',   '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1072', '用户属性删除', '113', '4', '#', '', '', '', 1, 0, 'F', '0', 'system:userAttr:remove', '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 用户入离职按钮
+insert into sys_menu values('1073', '用户入职', '100', '8', '#', '', '', '', 1, 0, 'F', '0', 'system:user:onboard',   '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1074', '用户离职', '100', '9', '#', '', '', '', 1, 0, 'F', '0', 'system:user:offboard',  '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 入职模板按钮
+insert into sys_menu values('1075', '入职模板查询', '114', '1', '#', '', '', '', 1, 0, 'F', '0', 'system:onboardTemplate:query',  '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1076', '入职模板新增', '114', '2', '#', '', '', '', 1, 0, 'F', '0', 'system:onboardTemplate:add',    '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1077', '入职模板修改', '114', '3', '#', '', '', '', 1, 0, 'F', '0', 'system:onboardTemplate:edit',   '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1078', '入职模板删除', '114', '4', '#', '', '', '', 1, 0, 'F', '0', 'system:onboardTemplate:remove', '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 组织架构图按钮
+insert into sys_menu values('1079', '组织架构图导出', '103', '8', '#', '', '', '', 1, 0, 'F', '0', 'system:dept:export',         '#', '0', 'admin', sysdate(), '', null, null, '');
+-- 用户隐私按钮
+insert into sys_menu values('1080', '隐私数据导出', '100', '10', '#', '', '', '', 1, 0, 'F', '0', 'system:user:privacy',  '#', '0', 'admin', sysdate(), '', null, null, '');
+insert into sys_menu values('1081', '隐私数据擦除', '100', '11', '#', '', '', '', 1, 0, 'F', '0', 'system:user:erase',    '#', '0', 'admin', sysdate(), '', null, null, '');
 
 -- ----------------------------
 -- 6、用户和角色关联表  用户N-1角色
@@ -282,9 +349,18 @@ insert into sys_role_menu values ('2', '103');
 insert into sys_role_menu values ('2', '104');
 insert into sys_role_menu values ('2', '105');
 insert into sys_role_menu values ('2', '106');
+insert into sys_role_menu values ('2', '107');
 insert into sys_role_menu values ('2', '108');
+insert into sys_role_menu values ('2', '109');
+insert into sys_role_menu values ('2', '110');
+insert into sys_role_menu values ('2', '111');
+insert into sys_role_menu values ('2', '112');
+insert into sys_role_menu values ('2', '113');
+insert into sys_role_menu values ('2', '114');
 insert into sys_role_menu values ('2', '500');
 insert into sys_role_menu values ('2', '501');
+insert into sys_role_menu values ('2', '502');
+insert into sys_role_menu values ('2', '503');
 insert into sys_role_menu values ('2', '1000');
 insert into sys_role_menu values ('2', '1001');
 insert into sys_role_menu values ('2', '1002');
@@ -331,6 +407,42 @@ insert into sys_role_menu values ('2', '1042');
 insert into sys_role_menu values ('2', '1043');
 insert into sys_role_menu values ('2', '1044');
 insert into sys_role_menu values ('2', '1045');
+insert into sys_role_menu values ('2', '1046');
+insert into sys_role_menu values ('2', '1047');
+insert into sys_role_menu values ('2', '1048');
+insert into sys_role_menu values ('2', '1049');
+insert into sys_role_menu values ('2', '1050');
+insert into sys_role_menu values ('2', '1051');
+insert into sys_role_menu values ('2', '1052');
+insert into sys_role_menu values ('2', '1053');
+insert into sys_role_menu values ('2', '1054');
+insert into sys_role_menu values ('2', '1055');
+insert into sys_role_menu values ('2', '1056');
+insert into sys_role_menu values ('2', '1057');
+insert into sys_role_menu values ('2', '1058');
+insert into sys_role_menu values ('2', '1059');
+insert into sys_role_menu values ('2', '1060');
+insert into sys_role_menu values ('2', '1061');
+insert into sys_role_menu values ('2', '1062');
+insert into sys_role_menu values ('2', '1063');
+insert into sys_role_menu values ('2', '1064');
+insert into sys_role_menu values ('2', '1065');
+insert into sys_role_menu values ('2', '1066');
+insert into sys_role_menu values ('2', '1067');
+insert into sys_role_menu values ('2', '1068');
+insert into sys_role_menu values ('2', '1069');
+insert into sys_role_menu values ('2', '1070');
+insert into sys_role_menu values ('2', '1071');
+insert into sys_role_menu values ('2', '1072');
+insert into sys_role_menu values ('2', '1073');
+insert into sys_role_menu values ('2', '1074');
+insert into sys_role_menu values ('2', '1075');
+insert into sys_role_menu values ('2', '1076');
+insert into sys_role_menu values ('2', '1077');
+insert into sys_role_menu values ('2', '1078');
+insert into sys_role_menu values ('2', '1079');
+insert into sys_role_menu values ('2', '1080');
+insert into sys_role_menu values ('2', '1081');
 
 -- ----------------------------
 -- 8、角色和部门关联表  角色1-N部门
@@ -523,6 +635,9 @@ insert into sys_config values(2, '用户管理-账号初始密码',         'sys
 insert into sys_config values(3, '主框架页-侧边栏主题',           'sys.index.sideTheme',           'theme-dark',    'Y', 'admin', sysdate(), '', null, '深色主题theme-dark，浅色主题theme-light' );
 insert into sys_config values(4, '账号自助-验证码开关',           'sys.account.captchaEnabled',    'true',          'Y', 'admin', sysdate(), '', null, '是否开启验证码功能（true开启，false关闭）');
 insert into sys_config values(5, '账号自助-是否开启用户注册功能', 'sys.account.registerUser',      'false',         'Y', 'admin', sysdate(), '', null, '是否开启注册用户功能（true开启，false关闭）');
+insert into sys_config values(6, '变更审批-需审批的操作',         'sys.approval.actions',          'system:user:remove,system:user:resetPwd,system:user:authRole,system:user:import,system:role:dataScope,system:role:menu,system:role:authUser,system:role:import,system:menu:edit,system:dept:import,system:post:role,system:post:import,system:group:role,system:group:member,system:onboardTemplate:edit,system:policy:edit,system:delegation:edit,system:recycle:restore', 'Y', 'admin', sysdate(), '', null, '需双人审批的操作标识，多个用逗号分隔，支持 system:user:* 通配' );
+insert into sys_config values(7, '变更审批-待审批有效时长',       'sys.approval.expireHours',      '72',            'Y', 'admin', sysdate(), '', null, '待审批变更请求的有效时长（小时），过期自动失效' );
+insert into sys_config values(8, '回收站-保留天数',               'sys.recycle.retentionDays',     '30',            'Y', 'admin', sysdate(), '', null, '已删除数据在回收站的保留天数，到期后定时永久删除' );
 
 -- ----------------------------
 -- 14、系统访问记录
@@ -531,7 +646,8 @@ DROP TABLE IF EXISTS `sys_logininfor`;
 CREATE TABLE `sys_logininfor` (
 	`info_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '访问id',
 	`user_name` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '用户账号' COLLATE 'utf8mb4_general_ci',
-	`ipaddr` VARCHAR(128) NOT NULL DEFAULT '' COMMENT '登录ip地址' COLLATE 'utf8mb4_general_ci',
+	`ipaddr` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '登录ip地址，启用加密后为密文' COLLATE 'utf8mb4_general_ci',
+	`ipaddr_hash` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '登录ip地址盲索引' COLLATE 'utf8mb4_general_ci',
 	`login_location` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '登录地点' COLLATE 'utf8mb4_general_ci',
 	`browser` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '浏览器类型' COLLATE 'utf8mb4_general_ci',
 	`os` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '操作系统' COLLATE 'utf8mb4_general_ci',
@@ -540,8 +656,448 @@ CREATE TABLE `sys_logininfor` (
 	`login_time` DATETIME NOT NULL COMMENT '访问时间',
 	PRIMARY KEY (`info_id`) USING BTREE,
 	INDEX `idx_sys_logininfor_s` (`status`) USING BTREE,
-	INDEX `idx_sys_logininfor_lt` (`login_time`) USING BTREE
+	INDEX `idx_sys_logininfor_lt` (`login_time`) USING BTREE,
+	INDEX `idx_sys_logininfor_ih` (`ipaddr_hash`) USING BTREE
 )
 COMMENT='系统访问记录'
 COLLATE='utf8mb4_general_ci'
 ENGINE=InnoDB;
+
+-- ----------------------------
+-- 15、访问策略表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_policy`;
+CREATE TABLE `sys_policy` (
+	`policy_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '策略id',
+	`policy_name` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '策略名称' COLLATE 'utf8mb4_general_ci',
+	`action` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '作用的权限标识，支持前缀通配如 system:user:*' COLLATE 'utf8mb4_general_ci',
+	`effect` VARCHAR(10) NOT NULL DEFAULT 'allow' COMMENT '效果：allow-允许；deny-拒绝' COLLATE 'utf8mb4_general_ci',
+	`condition` VARCHAR(2000) NOT NULL DEFAULT '' COMMENT '条件表达式' COLLATE 'utf8mb4_general_ci',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态：0-正常；1-停用' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '更新者' COLLATE 'utf8mb4_general_ci',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	`remark` VARCHAR(500) NULL DEFAULT NULL COMMENT '备注' COLLATE 'utf8mb4_general_ci',
+	PRIMARY KEY (`policy_id`) USING BTREE,
+	INDEX `idx_sys_policy_a` (`action`) USING BTREE
+)
+COMMENT='访问策略表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 初始化-访问策略表数据
+-- ----------------------------
+insert into sys_policy values(1, '禁止修改自己的角色', 'system:user:edit', 'deny', 'resource.userId == subject.userId && resource.roleIds != null', '1', 'admin', sysdate(), '', null, '示例策略，默认停用');
+
+-- ----------------------------
+-- 16、变更审批表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_approval`;
+CREATE TABLE `sys_approval` (
+	`approval_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '变更请求id',
+	`action` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '操作标识' COLLATE 'utf8mb4_general_ci',
+	`title` VARCHAR(50) NOT NULL DEFAULT '' COMMENT '操作名称' COLLATE 'utf8mb4_general_ci',
+	`request_method` VARCHAR(10) NOT NULL DEFAULT '' COMMENT '请求方式' COLLATE 'utf8mb4_general_ci',
+	`request_url` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '请求URL' COLLATE 'utf8mb4_general_ci',
+	`request_body` MEDIUMTEXT NULL COMMENT '请求内容' COLLATE 'utf8mb4_general_ci',
+	`content_type` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '请求内容类型' COLLATE 'utf8mb4_general_ci',
+	`requester_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '申请人id',
+	`requester_name` VARCHAR(30) NOT NULL DEFAULT '' COMMENT '申请人账号' COLLATE 'utf8mb4_general_ci',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态：0-待审批；1-已通过；2-已驳回；3-已过期；4-执行失败' COLLATE 'utf8mb4_general_ci',
+	`reviewer_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '审批人id',
+	`reviewer_name` VARCHAR(30) NOT NULL DEFAULT '' COMMENT '审批人账号' COLLATE 'utf8mb4_general_ci',
+	`review_reason` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '审批意见' COLLATE 'utf8mb4_general_ci',
+	`review_time` DATETIME NULL DEFAULT NULL COMMENT '审批时间',
+	`json_result` TEXT NULL COMMENT '执行结果' COLLATE 'utf8mb4_general_ci',
+	`expire_time` DATETIME NOT NULL COMMENT '过期时间',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	PRIMARY KEY (`approval_id`) USING BTREE,
+	INDEX `idx_sys_approval_s` (`status`, `expire_time`) USING BTREE
+)
+COMMENT='变更审批表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 17、部门委派表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_dept_delegation`;
+CREATE TABLE `sys_dept_delegation` (
+	`delegation_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '委派id',
+	`dept_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '委派部门id（含下级部门）',
+	`delegate_type` VARCHAR(10) NOT NULL DEFAULT 'user' COMMENT '受托类型：user-用户；role-角色' COLLATE 'utf8mb4_general_ci',
+	`delegate_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '受托用户或角色id',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态：0-正常；1-停用' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '更新者' COLLATE 'utf8mb4_general_ci',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	`remark` VARCHAR(500) NULL DEFAULT NULL COMMENT '备注' COLLATE 'utf8mb4_general_ci',
+	PRIMARY KEY (`delegation_id`) USING BTREE,
+	INDEX `idx_sys_dept_delegation_d` (`delegate_type`, `delegate_id`) USING BTREE
+)
+COMMENT='部门委派表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 18、部门委派角色表  委派1-N角色（受托人可分配的角色白名单）
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_dept_delegation_role`;
+CREATE TABLE `sys_dept_delegation_role` (
+	`delegation_id` BIGINT(19) NOT NULL COMMENT '委派id',
+	`role_id` BIGINT(19) NOT NULL COMMENT '角色id',
+	PRIMARY KEY (`delegation_id`, `role_id`) USING BTREE
+)
+COMMENT='部门委派角色表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 19、回收站表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_recycle`;
+CREATE TABLE `sys_recycle` (
+	`recycle_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '回收站id',
+	`entity_type` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '数据类型：user-用户；role-角色；dept-部门；post-岗位；menu-菜单；dict_type-字典类型；dict_data-字典数据；user_group-用户组' COLLATE 'utf8mb4_general_ci',
+	`entity_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '数据id',
+	`entity_name` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '数据名称' COLLATE 'utf8mb4_general_ci',
+	`snapshot` LONGTEXT NULL DEFAULT NULL COMMENT '删除时的数据及关联快照' COLLATE 'utf8mb4_general_ci',
+	`delete_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '删除者' COLLATE 'utf8mb4_general_ci',
+	`delete_time` DATETIME NOT NULL COMMENT '删除时间',
+	PRIMARY KEY (`recycle_id`) USING BTREE,
+	INDEX `idx_sys_recycle_e` (`entity_type`, `entity_id`) USING BTREE,
+	INDEX `idx_sys_recycle_t` (`delete_time`) USING BTREE
+)
+COMMENT='回收站表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 20、导入任务表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_import_job`;
+CREATE TABLE `sys_import_job` (
+	`job_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '任务id',
+	`job_type` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '导入类型：user-用户；dept-部门；post-岗位；role-角色；dict_type-字典类型；dict_data-字典数据' COLLATE 'utf8mb4_general_ci',
+	`file_name` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '文件名' COLLATE 'utf8mb4_general_ci',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '任务状态：0-等待；1-执行中；2-完成；3-中止' COLLATE 'utf8mb4_general_ci',
+	`total` INT(10) NOT NULL DEFAULT '0' COMMENT '总行数',
+	`processed` INT(10) NOT NULL DEFAULT '0' COMMENT '已处理行数',
+	`succeeded` INT(10) NOT NULL DEFAULT '0' COMMENT '成功行数',
+	`failed` INT(10) NOT NULL DEFAULT '0' COMMENT '失败行数',
+	`error_msg` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '中止原因' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	`finish_time` DATETIME NULL DEFAULT NULL COMMENT '完成时间',
+	PRIMARY KEY (`job_id`) USING BTREE
+)
+COMMENT='导入任务表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 21、导入错误明细表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_import_job_error`;
+CREATE TABLE `sys_import_job_error` (
+	`error_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '错误id',
+	`job_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '任务id',
+	`row_num` INT(10) NOT NULL DEFAULT '0' COMMENT 'Excel行号',
+	`row_data` TEXT NULL DEFAULT NULL COMMENT '行数据' COLLATE 'utf8mb4_general_ci',
+	`error_msg` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '错误信息' COLLATE 'utf8mb4_general_ci',
+	PRIMARY KEY (`error_id`) USING BTREE,
+	INDEX `idx_sys_import_job_error_j` (`job_id`, `row_num`) USING BTREE
+)
+COMMENT='导入错误明细表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 22、导出任务表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_export_job`;
+CREATE TABLE `sys_export_job` (
+	`job_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '任务id',
+	`dataset` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '导出数据集：user-用户；role-角色；post-岗位；config-参数；dict_type-字典类型；dict_data-字典数据；oper_log-操作日志；logininfor-登录日志' COLLATE 'utf8mb4_general_ci',
+	`format` VARCHAR(10) NOT NULL DEFAULT '' COMMENT '文件格式：xlsx、csv、ndjson' COLLATE 'utf8mb4_general_ci',
+	`file_name` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '下载文件名' COLLATE 'utf8mb4_general_ci',
+	`file_path` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '文件存储路径' COLLATE 'utf8mb4_general_ci',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '任务状态：0-等待；1-执行中；2-完成；3-中止' COLLATE 'utf8mb4_general_ci',
+	`total` INT(10) NOT NULL DEFAULT '0' COMMENT '总行数',
+	`processed` INT(10) NOT NULL DEFAULT '0' COMMENT '已导出行数',
+	`error_msg` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '中止原因' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	`finish_time` DATETIME NULL DEFAULT NULL COMMENT '完成时间',
+	PRIMARY KEY (`job_id`) USING BTREE,
+	INDEX `idx_sys_export_job_c` (`create_by`) USING BTREE
+)
+COMMENT='导出任务表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 23、用户组表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_user_group`;
+CREATE TABLE `sys_user_group` (
+	`group_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '用户组id',
+	`group_name` VARCHAR(50) NOT NULL COMMENT '用户组名称' COLLATE 'utf8mb4_general_ci',
+	`group_sort` INT(10) NOT NULL DEFAULT '0' COMMENT '显示顺序',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态：0-正常；1-停用' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '更新者' COLLATE 'utf8mb4_general_ci',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	`delete_time` DATETIME NULL DEFAULT NULL COMMENT '删除时间',
+	`remark` VARCHAR(500) NULL DEFAULT NULL COMMENT '备注' COLLATE 'utf8mb4_general_ci',
+	PRIMARY KEY (`group_id`) USING BTREE
+)
+COMMENT='用户组表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 24、用户组成员表  用户组1-N用户
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_user_group_user`;
+CREATE TABLE `sys_user_group_user` (
+	`group_id` BIGINT(19) NOT NULL COMMENT '用户组id',
+	`user_id` BIGINT(19) NOT NULL COMMENT '用户id',
+	PRIMARY KEY (`group_id`, `user_id`) USING BTREE,
+	INDEX `idx_sys_user_group_user_u` (`user_id`) USING BTREE
+)
+COMMENT='用户组成员表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 25、用户组角色表  用户组1-N角色（成员继承的角色）
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_user_group_role`;
+CREATE TABLE `sys_user_group_role` (
+	`group_id` BIGINT(19) NOT NULL COMMENT '用户组id',
+	`role_id` BIGINT(19) NOT NULL COMMENT '角色id',
+	PRIMARY KEY (`group_id`, `role_id`) USING BTREE
+)
+COMMENT='用户组角色表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 26、角色和用户组关联表  角色1-N用户组（自定数据权限）
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_role_group`;
+CREATE TABLE `sys_role_group` (
+	`role_id` BIGINT(19) NOT NULL COMMENT '角色id',
+	`group_id` BIGINT(19) NOT NULL COMMENT '用户组id',
+	PRIMARY KEY (`role_id`, `group_id`) USING BTREE
+)
+COMMENT='角色和用户组关联表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 27、用户属性定义表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_user_attr`;
+CREATE TABLE `sys_user_attr` (
+	`attr_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '属性id',
+	`attr_key` VARCHAR(64) NOT NULL COMMENT '属性键' COLLATE 'utf8mb4_general_ci',
+	`attr_label` VARCHAR(100) NOT NULL COMMENT '属性名称' COLLATE 'utf8mb4_general_ci',
+	`attr_type` VARCHAR(10) NOT NULL DEFAULT 'text' COMMENT '属性类型：text-文本；number-数字；date-日期；select-下拉' COLLATE 'utf8mb4_general_ci',
+	`pattern` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '校验正则' COLLATE 'utf8mb4_general_ci',
+	`dict_type` VARCHAR(100) NOT NULL DEFAULT '' COMMENT '下拉属性的字典类型' COLLATE 'utf8mb4_general_ci',
+	`is_required` CHAR(1) NOT NULL DEFAULT 'N' COMMENT '是否必填：Y-是；N-否' COLLATE 'utf8mb4_general_ci',
+	`is_searchable` CHAR(1) NOT NULL DEFAULT 'N' COMMENT '是否可搜索：Y-是；N-否' COLLATE 'utf8mb4_general_ci',
+	`attr_sort` INT(10) NOT NULL DEFAULT '0' COMMENT '显示顺序',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态：0-正常；1-停用' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '更新者' COLLATE 'utf8mb4_general_ci',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	`remark` VARCHAR(500) NULL DEFAULT NULL COMMENT '备注' COLLATE 'utf8mb4_general_ci',
+	PRIMARY KEY (`attr_id`) USING BTREE,
+	UNIQUE INDEX `uk_sys_user_attr_k` (`attr_key`) USING BTREE
+)
+COMMENT='用户属性定义表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 28、用户属性值表  用户1-N属性值
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_user_attr_value`;
+CREATE TABLE `sys_user_attr_value` (
+	`user_id` BIGINT(19) NOT NULL COMMENT '用户id',
+	`attr_id` BIGINT(19) NOT NULL COMMENT '属性id',
+	`attr_value` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '属性值' COLLATE 'utf8mb4_general_ci',
+	PRIMARY KEY (`user_id`, `attr_id`) USING BTREE,
+	INDEX `idx_sys_user_attr_value_a` (`attr_id`, `attr_value`) USING BTREE
+)
+COMMENT='用户属性值表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 29、入职模板表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_onboard_template`;
+CREATE TABLE `sys_onboard_template` (
+	`template_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '模板id',
+	`template_name` VARCHAR(50) NOT NULL COMMENT '模板名称' COLLATE 'utf8mb4_general_ci',
+	`dept_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '适用部门id（含下级部门）：0-任意部门',
+	`post_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '适用岗位id：0-任意岗位',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态：0-正常；1-停用' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '创建者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '创建时间',
+	`update_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '更新者' COLLATE 'utf8mb4_general_ci',
+	`update_time` DATETIME NULL DEFAULT NULL COMMENT '更新时间',
+	`remark` VARCHAR(500) NULL DEFAULT NULL COMMENT '备注' COLLATE 'utf8mb4_general_ci',
+	PRIMARY KEY (`template_id`) USING BTREE,
+	UNIQUE INDEX `uk_sys_onboard_template_n` (`template_name`) USING BTREE,
+	INDEX `idx_sys_onboard_template_dp` (`dept_id`, `post_id`) USING BTREE
+)
+COMMENT='入职模板表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 30、入职模板和角色关联表  模板1-N角色
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_onboard_template_role`;
+CREATE TABLE `sys_onboard_template_role` (
+	`template_id` BIGINT(19) NOT NULL COMMENT '模板id',
+	`role_id` BIGINT(19) NOT NULL COMMENT '角色id',
+	PRIMARY KEY (`template_id`, `role_id`) USING BTREE
+)
+COMMENT='入职模板和角色关联表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 31、入职模板和岗位关联表  模板1-N岗位
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_onboard_template_post`;
+CREATE TABLE `sys_onboard_template_post` (
+	`template_id` BIGINT(19) NOT NULL COMMENT '模板id',
+	`post_id` BIGINT(19) NOT NULL COMMENT '岗位id',
+	PRIMARY KEY (`template_id`, `post_id`) USING BTREE
+)
+COMMENT='入职模板和岗位关联表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 32、用户离职记录表
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_user_offboard`;
+CREATE TABLE `sys_user_offboard` (
+	`offboard_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '离职记录id',
+	`user_id` BIGINT(19) NOT NULL COMMENT '用户id',
+	`user_name` VARCHAR(30) NOT NULL COMMENT '用户账号' COLLATE 'utf8mb4_general_ci',
+	`transfer_to` VARCHAR(30) NOT NULL DEFAULT '' COMMENT '数据移交用户账号' COLLATE 'utf8mb4_general_ci',
+	`report` TEXT NOT NULL COMMENT '离职报告（JSON）' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '离职时间',
+	PRIMARY KEY (`offboard_id`) USING BTREE,
+	INDEX `idx_sys_user_offboard_u` (`user_id`) USING BTREE
+)
+COMMENT='用户离职记录表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 33、岗位和角色关联表  岗位1-N默认角色
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_post_role`;
+CREATE TABLE `sys_post_role` (
+	`post_id` BIGINT(19) NOT NULL COMMENT '岗位id',
+	`role_id` BIGINT(19) NOT NULL COMMENT '角色id',
+	PRIMARY KEY (`post_id`, `role_id`) USING BTREE,
+	INDEX `idx_sys_post_role_r` (`role_id`) USING BTREE
+)
+COMMENT='岗位和角色关联表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 34、统一搜索全文索引  search.backend 配置为 fulltext 时执行（MySQL 5.7.6+ ngram 分词）
+-- ----------------------------
+ALTER TABLE `sys_user`   ADD FULLTEXT INDEX `ft_sys_user_search` (`nick_name`, `user_name`, `phonenumber`, `email`) WITH PARSER ngram;
+ALTER TABLE `sys_dept`   ADD FULLTEXT INDEX `ft_sys_dept_search` (`dept_name`, `leader`) WITH PARSER ngram;
+ALTER TABLE `sys_role`   ADD FULLTEXT INDEX `ft_sys_role_search` (`role_name`, `role_key`) WITH PARSER ngram;
+ALTER TABLE `sys_post`   ADD FULLTEXT INDEX `ft_sys_post_search` (`post_name`, `post_code`) WITH PARSER ngram;
+ALTER TABLE `sys_menu`   ADD FULLTEXT INDEX `ft_sys_menu_search` (`menu_name`, `perms`) WITH PARSER ngram;
+ALTER TABLE `sys_config` ADD FULLTEXT INDEX `ft_sys_config_search` (`config_name`, `config_key`) WITH PARSER ngram;
+
+
+-- ----------------------------
+-- 35、隐私请求表  个人数据导出与擦除
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_privacy_request`;
+CREATE TABLE `sys_privacy_request` (
+	`request_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '隐私请求id',
+	`user_id` BIGINT(19) NOT NULL COMMENT '数据主体用户id',
+	`request_type` VARCHAR(10) NOT NULL COMMENT '请求类型（export导出 erase擦除）' COLLATE 'utf8mb4_general_ci',
+	`status` CHAR(1) NOT NULL DEFAULT '0' COMMENT '状态（0处理中 1已完成 2失败）' COLLATE 'utf8mb4_general_ci',
+	`reason` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '请求原因' COLLATE 'utf8mb4_general_ci',
+	`result` TEXT NULL DEFAULT NULL COMMENT '处理结果（JSON）' COLLATE 'utf8mb4_general_ci',
+	`error_msg` VARCHAR(2000) NOT NULL DEFAULT '' COMMENT '错误消息' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '请求时间',
+	`finish_time` DATETIME NULL DEFAULT NULL COMMENT '完成时间',
+	PRIMARY KEY (`request_id`) USING BTREE,
+	INDEX `idx_sys_privacy_request_u` (`user_id`) USING BTREE
+)
+COMMENT='隐私请求表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 36、隐私请求审计记录表  请求1-N处理步骤
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_privacy_request_log`;
+CREATE TABLE `sys_privacy_request_log` (
+	`log_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '记录id',
+	`request_id` BIGINT(19) NOT NULL COMMENT '隐私请求id',
+	`step` VARCHAR(50) NOT NULL COMMENT '处理步骤' COLLATE 'utf8mb4_general_ci',
+	`detail` VARCHAR(2000) NOT NULL DEFAULT '' COMMENT '步骤详情' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '记录时间',
+	PRIMARY KEY (`log_id`) USING BTREE,
+	INDEX `idx_sys_privacy_request_log_r` (`request_id`) USING BTREE
+)
+COMMENT='隐私请求审计记录表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;
+
+-- ----------------------------
+-- 37、参数变更历史表  参数/字典数据1-N版本
+-- ----------------------------
+DROP TABLE IF EXISTS `sys_config_history`;
+CREATE TABLE `sys_config_history` (
+	`history_id` BIGINT(19) NOT NULL AUTO_INCREMENT COMMENT '历史id',
+	`entity_type` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '数据类型：config-参数；dict_data-字典数据' COLLATE 'utf8mb4_general_ci',
+	`entity_id` BIGINT(19) NOT NULL DEFAULT '0' COMMENT '参数id或字典编码',
+	`entity_name` VARCHAR(200) NOT NULL DEFAULT '' COMMENT '参数键名或字典类型:字典标签' COLLATE 'utf8mb4_general_ci',
+	`version` INT(10) NOT NULL DEFAULT '0' COMMENT '版本号',
+	`action` VARCHAR(20) NOT NULL DEFAULT '' COMMENT '操作：create-新增；update-修改；delete-删除；rollback-回滚' COLLATE 'utf8mb4_general_ci',
+	`old_value` TEXT NULL DEFAULT NULL COMMENT '变更前（JSON）' COLLATE 'utf8mb4_general_ci',
+	`new_value` TEXT NULL DEFAULT NULL COMMENT '变更后（JSON）' COLLATE 'utf8mb4_general_ci',
+	`source_version` INT(10) NOT NULL DEFAULT '0' COMMENT '回滚到的版本号',
+	`reason` VARCHAR(500) NOT NULL DEFAULT '' COMMENT '变更原因' COLLATE 'utf8mb4_general_ci',
+	`create_by` VARCHAR(64) NOT NULL DEFAULT '' COMMENT '操作者' COLLATE 'utf8mb4_general_ci',
+	`create_time` DATETIME NOT NULL COMMENT '变更时间',
+	PRIMARY KEY (`history_id`) USING BTREE,
+	UNIQUE INDEX `uk_sys_config_history_v` (`entity_type`, `entity_id`, `version`) USING BTREE,
+	INDEX `idx_sys_config_history_t` (`create_time`) USING BTREE
+)
+COMMENT='参数变更历史表'
+COLLATE='utf8mb4_general_ci'
+ENGINE=InnoDB;